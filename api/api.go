@@ -7,12 +7,14 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 
 	"github.com/bmizerany/pat"
 	"github.com/talmai/gobot"
 	"github.com/talmai/gobot/api/riot"
 	"github.com/talmai/gobot/api/robeaux"
+	"github.com/talmai/gobot/platforms/i2c"
 )
 
 // API represents an API server
@@ -123,6 +125,7 @@ func (a *API) Start() {
 	a.Post(robotDeviceCommandRoute, a.executeRobotDeviceCommand)
 	a.Get("/api/robots/:robot/connections", a.robotConnections)
 	a.Get("/api/robots/:robot/connections/:connection", a.robotConnection)
+	a.Get("/api/robots/:robot/connections/:connection/i2c/scan", a.robotConnectionI2cScan)
 	a.Get("/api/", a.mcp)
 
 	a.Get("/", func(res http.ResponseWriter, req *http.Request) {
@@ -323,6 +326,41 @@ func (a *API) robotConnection(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// robotConnectionI2cScan returns i2c scan route handler
+// writes JSON with the devices responding on an i2c bus of the connection.
+// The bus query parameter selects the bus, and identify=true identifies
+// known chips.
+func (a *API) robotConnectionI2cScan(res http.ResponseWriter, req *http.Request) {
+	robot := req.URL.Query().Get(":robot")
+	name := req.URL.Query().Get(":connection")
+	if _, err := a.jsonConnectionFor(robot, name); err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+
+	connector, ok := a.gobot.Robot(robot).Connection(name).(i2c.I2cConnector)
+	if !ok {
+		a.writeJSON(map[string]interface{}{"error": "Connection " + name + " has no i2c buses"}, res)
+		return
+	}
+
+	bus := -1
+	if b := req.URL.Query().Get("bus"); b != "" {
+		var err error
+		if bus, err = strconv.Atoi(b); err != nil {
+			a.writeJSON(map[string]interface{}{"error": "Invalid i2c bus " + b}, res)
+			return
+		}
+	}
+
+	devices, err := i2c.Scan(connector, bus, req.URL.Query().Get("identify") == "true")
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	a.writeJSON(map[string]interface{}{"devices": devices}, res)
+}
+
 // executeMcpCommand calls a global command asociated to requested route
 func (a *API) executeMcpCommand(res http.ResponseWriter, req *http.Request) {
 	a.executeCommand(a.gobot.Command(req.URL.Query().Get(":command")),
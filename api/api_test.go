@@ -13,6 +13,7 @@ import (
 
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/platforms/i2c"
 )
 
 func initTestAPI() *API {
@@ -366,6 +367,49 @@ func TestRobotConnection(t *testing.T) {
 	gobottest.Assert(t, body["error"], "No Connection found with the name UnknownConnection1")
 }
 
+func TestRobotConnectionI2cScan(t *testing.T) {
+	a := initTestAPI()
+	a.gobot.AddRobot(gobot.NewRobot("I2cRobot",
+		[]gobot.Connection{&testI2cAdaptor{testAdaptor{name: "I2cConnection"}}},
+	))
+
+	request, _ := http.NewRequest("GET",
+		"/api/robots/I2cRobot/connections/I2cConnection/i2c/scan?identify=true",
+		nil,
+	)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	var body map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&body)
+	devices := body["devices"].([]interface{})
+	gobottest.Assert(t, len(devices), 1)
+	gobottest.Assert(t, devices[0], map[string]interface{}{
+		"address": 104.0,
+		"chip":    "MPU6050",
+	})
+
+	request, _ = http.NewRequest("GET",
+		"/api/robots/I2cRobot/connections/I2cConnection/i2c/scan?bus=2",
+		nil,
+	)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	body = map[string]interface{}{}
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["error"], i2c.ErrInvalidBus.Error())
+
+	request, _ = http.NewRequest("GET",
+		"/api/robots/Robot1/connections/Connection1/i2c/scan",
+		nil,
+	)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	body = map[string]interface{}{}
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["error"], "Connection Connection1 has no i2c buses")
+}
+
 func TestRobotDeviceEvent(t *testing.T) {
 	a := initTestAPI()
 	server := httptest.NewServer(a)
@@ -1,9 +1,11 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/i2c"
 )
 
 type NullReadWriteCloser struct{}
@@ -78,6 +80,36 @@ func newTestAdaptor(name string, port string) *testAdaptor {
 	}
 }
 
+// testI2cAdaptor has an MPU6050 at 0x68 on its i2c bus 1
+type testI2cAdaptor struct {
+	testAdaptor
+}
+
+func (t *testI2cAdaptor) I2cGetConnection(address int, bus int) (i2c.I2cConnection, error) {
+	if bus != 1 {
+		return nil, i2c.ErrInvalidBus
+	}
+	return &testI2cConnection{address: address}, nil
+}
+func (t *testI2cAdaptor) I2cDefaultBus() int { return 1 }
+
+type testI2cConnection struct {
+	address int
+}
+
+func (t *testI2cConnection) Read(b []byte) (int, error) {
+	if t.address != 0x68 {
+		return 0, errors.New("no device")
+	}
+	b[0] = 0x68
+	return len(b), nil
+}
+func (t *testI2cConnection) ReadRegister(reg uint8, b []byte) (int, error) { return t.Read(b) }
+func (t *testI2cConnection) Write(b []byte) (int, error)                   { return len(b), nil }
+func (t *testI2cConnection) WriteWord(reg uint8, val uint16) (int, error)  { return 2, nil }
+func (t *testI2cConnection) Tx(w []byte, r []byte) error                   { return nil }
+func (t *testI2cConnection) Close() error                                  { return nil }
+
 func newTestRobot(name string) *gobot.Robot {
 	adaptor1 := newTestAdaptor("Connection1", "/dev/null")
 	adaptor2 := newTestAdaptor("Connection2", "/dev/null")
@@ -10,6 +10,7 @@ import (
 
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/platforms/bebop"
+	"github.com/hybridgroup/gobot/platforms/bebop/client"
 	"github.com/hybridgroup/gobot/platforms/joystick"
 )
 
@@ -68,11 +69,8 @@ func main() {
 		video, _, _ := ffmpeg()
 
 		go func() {
-			for {
-				if _, err := video.Write(<-drone.Video()); err != nil {
-					fmt.Println(err)
-          return
-				}
+			if err := drone.SubscribeVideo(30).Pipe(client.NewAnnexBWriter(video)); err != nil {
+				fmt.Println(err)
 			}
 		}()
 
@@ -0,0 +1,70 @@
+/*
+	This example records the video of the Bebop to bebop.mp4, and streams it
+	over RTP at the same time. To watch the stream, save the session
+	description printed by this program to bebop.sdp and run:
+		$ ffplay -protocol_whitelist file,udp,rtp bebop.sdp
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/bebop"
+	"github.com/hybridgroup/gobot/platforms/bebop/client"
+)
+
+func main() {
+	gbot := gobot.NewGobot()
+
+	bebopAdaptor := bebop.NewBebopAdaptor("Drone")
+	drone := bebop.NewBebopDriver(bebopAdaptor, "Drone")
+
+	work := func() {
+		file, err := os.Create("bebop.mp4")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		recording := drone.SubscribeVideo(30)
+		go func() {
+			if err := recording.Pipe(client.NewMP4Writer(file)); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		rtp, err := client.NewRTPWriter("127.0.0.1:5004")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		go drone.SubscribeVideo(30).Pipe(rtp)
+
+		gobot.After(2*time.Second, func() {
+			fmt.Println(rtp.SDP())
+		})
+
+		gobot.Every(5*time.Second, func() {
+			stats := drone.VideoStats()
+			fmt.Printf("%dx%d, %d frames, %d lost, latency %v (max %v)\n",
+				stats.Width, stats.Height, stats.Frames, stats.Lost,
+				stats.Latency, stats.MaxLatency,
+			)
+		})
+
+		gobot.After(60*time.Second, func() {
+			recording.Close()
+		})
+	}
+
+	robot := gobot.NewRobot("drone",
+		[]gobot.Connection{bebopAdaptor},
+		[]gobot.Device{drone},
+		work,
+	)
+	gbot.AddRobot(robot)
+
+	gbot.Start()
+}
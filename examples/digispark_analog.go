@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/digispark"
+	"github.com/hybridgroup/gobot/platforms/gpio"
+)
+
+func main() {
+	gbot := gobot.NewGobot()
+
+	digisparkAdaptor := digispark.NewDigisparkAdaptor("digispark")
+	sensor := gpio.NewAnalogSensorDriver(digisparkAdaptor, "sensor", "2")
+
+	work := func() {
+		gobot.On(sensor.Event("data"), func(data interface{}) {
+			fmt.Println("sensor", data)
+		})
+	}
+
+	robot := gobot.NewRobot("sensorBot",
+		[]gobot.Connection{digisparkAdaptor},
+		[]gobot.Device{sensor},
+		work,
+	)
+
+	gbot.AddRobot(robot)
+
+	gbot.Start()
+}
@@ -26,6 +26,10 @@ func main() {
 		gobot.On(wiichuck.Event("z"), func(data interface{}) {
 			fmt.Println("z")
 		})
+
+		gobot.On(wiichuck.Event("accelerometer"), func(data interface{}) {
+			fmt.Println("accelerometer", data)
+		})
 		gobot.On(wiichuck.Event("error"), func(data interface{}) {
 			fmt.Println("Wiichuck error:", data)
 		})
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/gpio"
+	"github.com/hybridgroup/gobot/platforms/linux"
+)
+
+func main() {
+	gbot := gobot.NewGobot()
+
+	board := linux.NewLinuxAdaptor("board", linux.Board{
+		Chips: []string{"gpiochip0"},
+	})
+	led := gpio.NewLedDriver(board, "led", "GPIO17")
+
+	work := func() {
+		gobot.Every(1*time.Second, func() {
+			led.Toggle()
+		})
+	}
+
+	robot := gobot.NewRobot("blinkBot",
+		[]gobot.Connection{board},
+		[]gobot.Device{led},
+		work,
+	)
+
+	gbot.AddRobot(robot)
+
+	gbot.Start()
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/mqtt"
+)
+
+func main() {
+	gbot := gobot.NewGobot()
+
+	mqttAdaptor := mqtt.NewMqttAdaptor("server", "tcp://test.mosquitto.org:1883", "bridge")
+	bridge := mqtt.NewBridge(gbot, mqttAdaptor)
+
+	work := func() {
+		if err := bridge.Start(); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	robot := gobot.NewRobot("mqttBot",
+		[]gobot.Connection{mqttAdaptor},
+		work,
+	)
+	robot.AddCommand("hello", func(params map[string]interface{}) interface{} {
+		return fmt.Sprintf("Hello, %v!", params["name"])
+	})
+
+	gbot.AddRobot(robot)
+
+	gbot.Start()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/gpio"
+	"github.com/hybridgroup/gobot/platforms/raspi"
+	"github.com/hybridgroup/gobot/platforms/spi"
+)
+
+func main() {
+	gbot := gobot.NewGobot()
+
+	r := raspi.NewRaspiAdaptor("raspi")
+	adc := spi.NewMCP3008Driver(r, "adc")
+	sensor := gpio.NewAnalogSensorDriver(adc, "sensor", "0", 100*time.Millisecond)
+
+	work := func() {
+		gobot.On(sensor.Event("data"), func(data interface{}) {
+			fmt.Println("sensor", data)
+		})
+	}
+
+	robot := gobot.NewRobot("adcBot",
+		[]gobot.Connection{r, adc},
+		[]gobot.Device{sensor},
+		work,
+	)
+
+	gbot.AddRobot(robot)
+
+	gbot.Start()
+}
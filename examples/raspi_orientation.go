@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/i2c"
+	"github.com/hybridgroup/gobot/platforms/orientation"
+	"github.com/hybridgroup/gobot/platforms/raspi"
+)
+
+func main() {
+	gbot := gobot.NewGobot()
+
+	r := raspi.NewRaspiAdaptor("raspi")
+	mpu6050 := i2c.NewMPU6050Driver(r, "mpu6050")
+	hmc6352 := i2c.NewHMC6352Driver(r, "hmc6352")
+	imu := orientation.NewOrientationDriver("imu",
+		orientation.MPU6050(mpu6050),
+		orientation.HMC6352(hmc6352),
+		orientation.NewMadgwickFilter(0.1),
+	)
+
+	work := func() {
+		gobot.On(imu.Event(orientation.Data), func(data interface{}) {
+			o := data.(orientation.Orientation)
+			fmt.Println("Euler", o.Euler, "Heading", o.Heading)
+		})
+	}
+
+	robot := gobot.NewRobot("imuBot",
+		[]gobot.Connection{r},
+		[]gobot.Device{mpu6050, hmc6352, imu},
+		work,
+	)
+
+	gbot.AddRobot(robot)
+
+	gbot.Start()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/i2c"
+	"github.com/hybridgroup/gobot/platforms/raspi"
+)
+
+func main() {
+	gbot := gobot.NewGobot()
+
+	r := raspi.NewRaspiAdaptor("raspi")
+	classic := i2c.NewWiiClassicDriver(r, "classic")
+
+	work := func() {
+		gobot.On(classic.Event(i2c.Push), func(data interface{}) {
+			fmt.Println("pushed", data)
+		})
+
+		gobot.On(classic.Event(i2c.Release), func(data interface{}) {
+			fmt.Println("released", data)
+		})
+
+		gobot.On(classic.Event(i2c.Data), func(data interface{}) {
+			reading := data.(i2c.WiiClassicReading)
+			if reading.Buttons["zl"] {
+				fmt.Println("left stick", reading.LeftStick, "right stick", reading.RightStick)
+			}
+		})
+
+		gobot.On(classic.Event(i2c.Error), func(data interface{}) {
+			fmt.Println("Wii Classic error:", data)
+		})
+	}
+
+	robot := gobot.NewRobot("classic",
+		[]gobot.Connection{r},
+		[]gobot.Device{classic},
+		work,
+	)
+
+	gbot.AddRobot(robot)
+
+	gbot.Start()
+}
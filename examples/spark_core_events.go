@@ -13,10 +13,10 @@ func main() {
 	sparkCore := spark.NewSparkCoreAdaptor("spark", "DEVICE_ID", "ACCESS_TOKEN")
 
 	work := func() {
-		if stream, err := sparkCore.EventStream("all", ""); err != nil {
+		if event, _, err := sparkCore.EventStream("all", ""); err != nil {
 			fmt.Println(err)
 		} else {
-			gobot.On(stream, func(data interface{}) {
+			gobot.On(event, func(data interface{}) {
 				fmt.Println(data.(spark.Event))
 			})
 		}
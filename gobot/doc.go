@@ -12,6 +12,7 @@ CLI tool for generating new Gobot projects.
 
 	COMMANDS:
 		 generate     Generate new Gobot skeleton project
+		 i2c          Inspect the i2c buses of the board
 		 help, h      Shows a list of commands or help for one command
 
 	GLOBAL OPTIONS:
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codegangsta/cli"
+	"github.com/hybridgroup/gobot/platforms/i2c"
+	"github.com/hybridgroup/gobot/sysfs"
+)
+
+// sysfsI2c connects to the devices on the i2c buses /dev/i2c-N of the
+// board the command runs on
+type sysfsI2c struct {
+	buses map[int]*sysfs.I2cBus
+}
+
+func (s *sysfsI2c) I2cGetConnection(address int, bus int) (i2c.I2cConnection, error) {
+	if s.buses[bus] == nil {
+		s.buses[bus] = sysfs.NewI2cBus(fmt.Sprintf("/dev/i2c-%v", bus))
+	}
+	device, err := s.buses[bus].Device(address)
+	if err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+func (s *sysfsI2c) I2cDefaultBus() int { return 1 }
+
+func (s *sysfsI2c) close() {
+	for _, bus := range s.buses {
+		bus.Close()
+	}
+}
+
+func I2c() cli.Command {
+	return cli.Command{
+		Name:  "i2c",
+		Usage: "Inspect the i2c buses of the board",
+		Subcommands: []cli.Command{
+			{
+				Name:  "scan",
+				Usage: "List the devices responding on an i2c bus",
+				Flags: []cli.Flag{
+					cli.IntFlag{Name: "bus, b", Value: 1, Usage: "the bus /dev/i2c-N to scan"},
+					cli.BoolFlag{Name: "identify, i", Usage: "identify known chips by their ID registers"},
+				},
+				Action: func(c *cli.Context) {
+					s := &sysfsI2c{buses: make(map[int]*sysfs.I2cBus)}
+					defer s.close()
+
+					devices, err := i2c.Scan(s, c.Int("bus"), c.Bool("identify"))
+					if err != nil {
+						fmt.Println(err)
+						return
+					}
+					printI2cScan(os.Stdout, devices)
+				},
+			},
+		},
+	}
+}
+
+// printI2cScan prints the responding addresses as a table like i2cdetect
+// does, followed by the identified chips
+func printI2cScan(w io.Writer, devices []i2c.ScanResult) {
+	found := make(map[int]bool)
+	for _, d := range devices {
+		found[d.Address] = true
+	}
+
+	fmt.Fprintln(w, "     0  1  2  3  4  5  6  7  8  9  a  b  c  d  e  f")
+	for row := 0x00; row <= 0x70; row += 0x10 {
+		fmt.Fprintf(w, "%02x:", row)
+		for address := row; address < row+0x10; address++ {
+			switch {
+			case address < 0x03 || address > 0x77:
+				fmt.Fprint(w, "   ")
+			case found[address]:
+				fmt.Fprintf(w, " %02x", address)
+			default:
+				fmt.Fprint(w, " --")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	for _, d := range devices {
+		if d.Chip != "" {
+			fmt.Fprintf(w, "0x%02x %v\n", d.Address, d.Chip)
+		}
+	}
+}
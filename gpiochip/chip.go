@@ -0,0 +1,146 @@
+package gpiochip
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unsafe"
+
+	"github.com/hybridgroup/gobot/sysfs"
+)
+
+// GPIODEVPATH default linux gpio character device path
+const GPIODEVPATH = "/dev"
+
+var (
+	// ErrLineNotFound is returned when no line on the chip matches a name
+	ErrLineNotFound = errors.New("gpio line not found")
+	// ErrInvalidOffset is returned when an offset is out of range for the chip
+	ErrInvalidOffset = errors.New("gpio line offset out of range")
+)
+
+// Chip represents an open gpio character device, eg. /dev/gpiochip0
+type Chip struct {
+	// Name is the kernel name of the chip, eg. "gpiochip0"
+	Name string
+	// Label is the functional name of the chip, eg. "pinctrl-bcm2835"
+	Label string
+	// Lines is the number of lines provided by the chip
+	Lines int
+
+	file sysfs.File
+}
+
+// LineInfo describes the current state of a single line
+type LineInfo struct {
+	Offset    int
+	Name      string
+	Consumer  string
+	Used      bool
+	Direction string
+	ActiveLow bool
+	Bias      Bias
+	Drive     Drive
+	Edge      Edge
+}
+
+// OpenChip opens the gpio character device given its name, eg. "gpiochip0",
+// or its full path
+func OpenChip(name string) (c *Chip, err error) {
+	path := name
+	if !strings.HasPrefix(path, "/") {
+		path = GPIODEVPATH + "/" + name
+	}
+
+	c = &Chip{}
+	if c.file, err = sysfs.OpenFile(path, os.O_RDWR, 0644); err != nil {
+		return nil, err
+	}
+
+	info := gpiochipInfo{}
+	if err = ioctl(c.file.Fd(), GPIO_GET_CHIPINFO_IOCTL, unsafe.Pointer(&info)); err != nil {
+		c.file.Close()
+		return nil, err
+	}
+	c.Name = cstring(info.name[:])
+	c.Label = cstring(info.label[:])
+	c.Lines = int(info.lines)
+	return c, nil
+}
+
+// Close closes the chip. Lines already requested from the chip stay valid
+// until they are closed themselves.
+func (c *Chip) Close() error {
+	return c.file.Close()
+}
+
+// LineInfo returns the state of the line at offset
+func (c *Chip) LineInfo(offset int) (info LineInfo, err error) {
+	if offset < 0 || offset >= c.Lines {
+		return info, ErrInvalidOffset
+	}
+
+	li := gpioV2LineInfo{offset: uint32(offset)}
+	if err = ioctl(c.file.Fd(), GPIO_V2_GET_LINEINFO_IOCTL, unsafe.Pointer(&li)); err != nil {
+		return
+	}
+
+	info = LineInfo{
+		Offset:    int(li.offset),
+		Name:      cstring(li.name[:]),
+		Consumer:  cstring(li.consumer[:]),
+		Used:      li.flags&GPIO_V2_LINE_FLAG_USED != 0,
+		ActiveLow: li.flags&GPIO_V2_LINE_FLAG_ACTIVE_LOW != 0,
+		Direction: sysfs.IN,
+	}
+	if li.flags&GPIO_V2_LINE_FLAG_OUTPUT != 0 {
+		info.Direction = sysfs.OUT
+	}
+	info.Bias, info.Drive, info.Edge = decodeFlags(li.flags)
+	return
+}
+
+// FindLine returns the offset of the line with the given name
+func (c *Chip) FindLine(name string) (offset int, err error) {
+	for i := 0; i < c.Lines; i++ {
+		info, err := c.LineInfo(i)
+		if err != nil {
+			return -1, err
+		}
+		if info.Name == name {
+			return i, nil
+		}
+	}
+	return -1, ErrLineNotFound
+}
+
+// RequestLine requests exclusive use of the line at offset, labelled with
+// consumer, and configures it with config
+func (c *Chip) RequestLine(offset int, consumer string, config LineConfig) (l Line, err error) {
+	if offset < 0 || offset >= c.Lines {
+		return nil, ErrInvalidOffset
+	}
+
+	req := gpioV2LineRequest{numLines: 1}
+	req.offsets[0] = uint32(offset)
+	copy(req.consumer[:GPIO_MAX_NAME_SIZE-1], consumer)
+	if req.config, err = config.encode(); err != nil {
+		return nil, err
+	}
+
+	if err = ioctl(c.file.Fd(), GPIO_V2_GET_LINE_IOCTL, unsafe.Pointer(&req)); err != nil {
+		return nil, err
+	}
+
+	return &line{
+		offset: offset,
+		config: config,
+		file:   newLineFile(uintptr(req.fd), fmt.Sprintf("%v:%v", c.Name, offset)),
+	}, nil
+}
+
+// newLineFile wraps the anonymous file descriptor returned for a line request
+var newLineFile = func(fd uintptr, name string) sysfs.File {
+	return os.NewFile(fd, name)
+}
@@ -0,0 +1,83 @@
+package gpiochip
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/sysfs"
+)
+
+func TestOpenChip(t *testing.T) {
+	c, _, _ := initTestChip()
+	gobottest.Assert(t, c.Name, "gpiochip0")
+	gobottest.Assert(t, c.Label, "pinctrl-fake")
+	gobottest.Assert(t, c.Lines, 4)
+	gobottest.Assert(t, c.Close(), nil)
+
+	c, err := OpenChip("/dev/gpiochip0")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, c.Name, "gpiochip0")
+
+	_, err = OpenChip("gpiochip1")
+	gobottest.Refute(t, err, nil)
+
+	sysfs.SetSyscall(&fakeChip{errno: syscall.ENOTTY})
+	_, err = OpenChip("gpiochip0")
+	gobottest.Refute(t, err, nil)
+}
+
+func TestChipLineInfo(t *testing.T) {
+	c, _, _ := initTestChip()
+
+	info, err := c.LineInfo(0)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, info.Name, "GPIO0")
+	gobottest.Assert(t, info.Used, false)
+	gobottest.Assert(t, info.Direction, sysfs.IN)
+
+	info, _ = c.LineInfo(2)
+	gobottest.Assert(t, info.Used, true)
+	gobottest.Assert(t, info.Direction, sysfs.OUT)
+	gobottest.Assert(t, info.Drive, DriveOpenDrain)
+
+	info, _ = c.LineInfo(3)
+	gobottest.Assert(t, info.Consumer, "button")
+	gobottest.Assert(t, info.Bias, BiasPullUp)
+	gobottest.Assert(t, info.Edge, EdgeBoth)
+
+	_, err = c.LineInfo(4)
+	gobottest.Assert(t, err, ErrInvalidOffset)
+}
+
+func TestChipFindLine(t *testing.T) {
+	c, _, _ := initTestChip()
+
+	offset, err := c.FindLine("GPIO2")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, offset, 2)
+
+	_, err = c.FindLine("GPIO17")
+	gobottest.Assert(t, err, ErrLineNotFound)
+}
+
+func TestChipRequestLine(t *testing.T) {
+	c, fake, _ := initTestChip()
+
+	l, err := c.RequestLine(1, "gobot", LineConfig{Direction: sysfs.OUT, Value: 1})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, l.Offset(), 1)
+	gobottest.Assert(t, fake.request.offsets[0], uint32(1))
+	gobottest.Assert(t, fake.request.numLines, uint32(1))
+	gobottest.Assert(t, cstring(fake.request.consumer[:]), "gobot")
+	gobottest.Assert(t, fake.config.flags, uint64(GPIO_V2_LINE_FLAG_OUTPUT))
+	gobottest.Assert(t, fake.config.numAttrs, uint32(1))
+	gobottest.Assert(t, fake.config.attrs[0].attr.id, uint32(GPIO_V2_LINE_ATTR_ID_OUTPUT_VALUES))
+	gobottest.Assert(t, fake.config.attrs[0].attr.value, uint64(1))
+
+	_, err = c.RequestLine(4, "gobot", LineConfig{})
+	gobottest.Assert(t, err, ErrInvalidOffset)
+
+	_, err = c.RequestLine(1, "gobot", LineConfig{Direction: sysfs.OUT, Edge: EdgeRising})
+	gobottest.Assert(t, err, ErrInvalidConfig)
+}
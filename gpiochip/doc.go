@@ -0,0 +1,11 @@
+/*
+Package gpiochip provides generic access to linux gpio through the gpio
+character device ABI (/dev/gpiochipN).
+
+It is the successor to the deprecated sysfs gpio interface, and adds support
+for line labels, bias, drive and timestamped edge events. Like the sysfs
+package, all file and ioctl access goes through sysfs.OpenFile and
+sysfs.Syscall, so it may be tested with sysfs.SetFilesystem and
+sysfs.SetSyscall.
+*/
+package gpiochip
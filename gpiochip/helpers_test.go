@@ -0,0 +1,93 @@
+package gpiochip
+
+import (
+	"syscall"
+	"unsafe"
+
+	"github.com/hybridgroup/gobot/sysfs"
+)
+
+// fakeChip emulates the ioctls of a gpio character device with a single
+// outstanding line request
+type fakeChip struct {
+	name   string
+	label  string
+	lines  []string
+	flags  map[uint32]uint64
+	values uint64
+
+	request gpioV2LineRequest
+	config  gpioV2LineConfig
+	errno   syscall.Errno
+}
+
+func newFakeChip() *fakeChip {
+	return &fakeChip{
+		name:  "gpiochip0",
+		label: "pinctrl-fake",
+		lines: []string{"GPIO0", "GPIO1", "GPIO2", "GPIO3"},
+		flags: map[uint32]uint64{
+			2: GPIO_V2_LINE_FLAG_USED | GPIO_V2_LINE_FLAG_OUTPUT | GPIO_V2_LINE_FLAG_OPEN_DRAIN,
+			3: GPIO_V2_LINE_FLAG_USED | GPIO_V2_LINE_FLAG_INPUT | GPIO_V2_LINE_FLAG_BIAS_PULL_UP |
+				GPIO_V2_LINE_FLAG_EDGE_RISING | GPIO_V2_LINE_FLAG_EDGE_FALLING,
+		},
+	}
+}
+
+func (f *fakeChip) Syscall(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+	if f.errno != 0 {
+		return 0, 0, f.errno
+	}
+	switch a2 {
+	case GPIO_GET_CHIPINFO_IOCTL:
+		info := (*gpiochipInfo)(ptr(a3))
+		copy(info.name[:], f.name)
+		copy(info.label[:], f.label)
+		info.lines = uint32(len(f.lines))
+	case GPIO_V2_GET_LINEINFO_IOCTL:
+		info := (*gpioV2LineInfo)(ptr(a3))
+		copy(info.name[:], f.lines[info.offset])
+		if info.offset == 3 {
+			copy(info.consumer[:], "button")
+		}
+		info.flags = f.flags[info.offset]
+	case GPIO_V2_GET_LINE_IOCTL:
+		req := (*gpioV2LineRequest)(ptr(a3))
+		req.fd = 42
+		f.request = *req
+		f.config = req.config
+	case GPIO_V2_LINE_SET_CONFIG_IOCTL:
+		f.config = *(*gpioV2LineConfig)(ptr(a3))
+	case GPIO_V2_LINE_GET_VALUES_IOCTL:
+		values := (*gpioV2LineValues)(ptr(a3))
+		values.bits = f.values & values.mask
+	case GPIO_V2_LINE_SET_VALUES_IOCTL:
+		values := (*gpioV2LineValues)(ptr(a3))
+		f.values = (f.values &^ values.mask) | (values.bits & values.mask)
+	default:
+		return 0, 0, syscall.ENOTTY
+	}
+	return 0, 0, 0
+}
+
+// initTestChip returns a Chip backed by a fakeChip, and the mock file used
+// for line requests
+func initTestChip() (*Chip, *fakeChip, *sysfs.MockFile) {
+	fs := sysfs.NewMockFilesystem([]string{
+		"/dev/gpiochip0",
+		"line",
+	})
+	sysfs.SetFilesystem(fs)
+	fake := newFakeChip()
+	sysfs.SetSyscall(fake)
+	newLineFile = func(fd uintptr, name string) sysfs.File {
+		return fs.Files["line"]
+	}
+	c, _ := OpenChip("gpiochip0")
+	return c, fake, fs.Files["line"]
+}
+
+// ptr converts an ioctl argument back into the pointer it was created from
+func ptr(a uintptr) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&a))
+}
@@ -0,0 +1,125 @@
+package gpiochip
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/hybridgroup/gobot/sysfs"
+)
+
+// Linux gpio character device ABI v2 specific docs.
+//  https://www.kernel.org/doc/html/latest/userspace-api/gpio/chardev.html
+//  include/uapi/linux/gpio.h
+const (
+	GPIO_MAX_NAME_SIZE         = 32
+	GPIO_V2_LINES_MAX          = 64
+	GPIO_V2_LINE_NUM_ATTRS_MAX = 10
+
+	GPIO_GET_CHIPINFO_IOCTL       = 0x8044B401
+	GPIO_V2_GET_LINEINFO_IOCTL    = 0xC100B405
+	GPIO_V2_GET_LINE_IOCTL        = 0xC250B407
+	GPIO_V2_LINE_SET_CONFIG_IOCTL = 0xC110B40D
+	GPIO_V2_LINE_GET_VALUES_IOCTL = 0xC010B40E
+	GPIO_V2_LINE_SET_VALUES_IOCTL = 0xC010B40F
+
+	GPIO_V2_LINE_FLAG_USED                 = 1 << 0
+	GPIO_V2_LINE_FLAG_ACTIVE_LOW           = 1 << 1
+	GPIO_V2_LINE_FLAG_INPUT                = 1 << 2
+	GPIO_V2_LINE_FLAG_OUTPUT               = 1 << 3
+	GPIO_V2_LINE_FLAG_EDGE_RISING          = 1 << 4
+	GPIO_V2_LINE_FLAG_EDGE_FALLING         = 1 << 5
+	GPIO_V2_LINE_FLAG_OPEN_DRAIN           = 1 << 6
+	GPIO_V2_LINE_FLAG_OPEN_SOURCE          = 1 << 7
+	GPIO_V2_LINE_FLAG_BIAS_PULL_UP         = 1 << 8
+	GPIO_V2_LINE_FLAG_BIAS_PULL_DOWN       = 1 << 9
+	GPIO_V2_LINE_FLAG_BIAS_DISABLED        = 1 << 10
+	GPIO_V2_LINE_FLAG_EVENT_CLOCK_REALTIME = 1 << 11
+
+	GPIO_V2_LINE_ATTR_ID_FLAGS         = 1
+	GPIO_V2_LINE_ATTR_ID_OUTPUT_VALUES = 2
+	GPIO_V2_LINE_ATTR_ID_DEBOUNCE      = 3
+
+	GPIO_V2_LINE_EVENT_RISING_EDGE  = 1
+	GPIO_V2_LINE_EVENT_FALLING_EDGE = 2
+
+	// size in bytes of a gpio_v2_line_event read from a line request
+	lineEventSize = 48
+)
+
+// The following structs mirror the kernel uapi structs. Every 64 bit member
+// is explicitly padded to an 8 byte offset by the kernel headers, so the
+// layout is the same on 32 and 64 bit platforms.
+
+type gpiochipInfo struct {
+	name  [GPIO_MAX_NAME_SIZE]byte
+	label [GPIO_MAX_NAME_SIZE]byte
+	lines uint32
+}
+
+type gpioV2LineAttribute struct {
+	id      uint32
+	padding uint32
+	// flags, values or debounce_period_us depending on id
+	value uint64
+}
+
+type gpioV2LineConfigAttribute struct {
+	attr gpioV2LineAttribute
+	mask uint64
+}
+
+type gpioV2LineConfig struct {
+	flags    uint64
+	numAttrs uint32
+	padding  [5]uint32
+	attrs    [GPIO_V2_LINE_NUM_ATTRS_MAX]gpioV2LineConfigAttribute
+}
+
+type gpioV2LineRequest struct {
+	offsets         [GPIO_V2_LINES_MAX]uint32
+	consumer        [GPIO_MAX_NAME_SIZE]byte
+	config          gpioV2LineConfig
+	numLines        uint32
+	eventBufferSize uint32
+	padding         [5]uint32
+	fd              int32
+}
+
+type gpioV2LineValues struct {
+	bits uint64
+	mask uint64
+}
+
+type gpioV2LineInfo struct {
+	name     [GPIO_MAX_NAME_SIZE]byte
+	consumer [GPIO_MAX_NAME_SIZE]byte
+	offset   uint32
+	numAttrs uint32
+	flags    uint64
+	attrs    [GPIO_V2_LINE_NUM_ATTRS_MAX]gpioV2LineAttribute
+	padding  [4]uint32
+}
+
+func ioctl(fd uintptr, request uintptr, data unsafe.Pointer) (err error) {
+	_, _, errno := sysfs.Syscall(
+		syscall.SYS_IOCTL,
+		fd,
+		request,
+		uintptr(data),
+	)
+	if errno != 0 {
+		err = fmt.Errorf("ioctl 0x%X failed with syscall.Errno %v", request, errno)
+	}
+	return
+}
+
+// cstring converts a NUL terminated byte array to a string
+func cstring(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
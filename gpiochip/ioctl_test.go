@@ -0,0 +1,22 @@
+package gpiochip
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestIoctlStructSizes(t *testing.T) {
+	// the ioctl request numbers encode the size of their argument
+	gobottest.Assert(t, unsafe.Sizeof(gpiochipInfo{}), uintptr((GPIO_GET_CHIPINFO_IOCTL>>16)&0x3fff))
+	gobottest.Assert(t, unsafe.Sizeof(gpioV2LineInfo{}), uintptr((GPIO_V2_GET_LINEINFO_IOCTL>>16)&0x3fff))
+	gobottest.Assert(t, unsafe.Sizeof(gpioV2LineRequest{}), uintptr((GPIO_V2_GET_LINE_IOCTL>>16)&0x3fff))
+	gobottest.Assert(t, unsafe.Sizeof(gpioV2LineConfig{}), uintptr((GPIO_V2_LINE_SET_CONFIG_IOCTL>>16)&0x3fff))
+	gobottest.Assert(t, unsafe.Sizeof(gpioV2LineValues{}), uintptr((GPIO_V2_LINE_GET_VALUES_IOCTL>>16)&0x3fff))
+}
+
+func TestCstring(t *testing.T) {
+	gobottest.Assert(t, cstring([]byte{'a', 'b', 0, 'c'}), "ab")
+	gobottest.Assert(t, cstring([]byte{'a', 'b'}), "ab")
+}
@@ -0,0 +1,250 @@
+package gpiochip
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+	"unsafe"
+
+	"github.com/hybridgroup/gobot/sysfs"
+)
+
+// Bias is the internal pull resistor configuration of an input line
+type Bias int
+
+const (
+	// BiasAsIs leaves the bias unchanged
+	BiasAsIs Bias = iota
+	// BiasDisabled disables the internal pull resistors
+	BiasDisabled
+	// BiasPullUp enables the internal pull up resistor
+	BiasPullUp
+	// BiasPullDown enables the internal pull down resistor
+	BiasPullDown
+)
+
+// Drive is the electrical configuration of an output line
+type Drive int
+
+const (
+	// DrivePushPull drives the line both high and low
+	DrivePushPull Drive = iota
+	// DriveOpenDrain only drives the line low
+	DriveOpenDrain
+	// DriveOpenSource only drives the line high
+	DriveOpenSource
+)
+
+// Edge selects which transitions of an input line generate events
+type Edge int
+
+const (
+	// EdgeNone disables edge detection
+	EdgeNone Edge = iota
+	// EdgeRising detects low to high transitions
+	EdgeRising
+	// EdgeFalling detects high to low transitions
+	EdgeFalling
+	// EdgeBoth detects all transitions
+	EdgeBoth
+)
+
+var (
+	// ErrInvalidConfig is returned when a LineConfig combines input and output
+	// only settings
+	ErrInvalidConfig = errors.New("invalid gpio line configuration")
+	// ErrShortEvent is returned when less than a whole event could be read
+	ErrShortEvent = errors.New("short read of gpio line event")
+)
+
+// LineConfig is the configuration of a requested line
+type LineConfig struct {
+	// Direction is either sysfs.IN or sysfs.OUT
+	Direction string
+	// ActiveLow inverts the logical value of the line
+	ActiveLow bool
+	// Bias is only used for inputs
+	Bias Bias
+	// Drive is only used for outputs
+	Drive Drive
+	// Edge is only used for inputs
+	Edge Edge
+	// Debounce is only used for inputs
+	Debounce time.Duration
+	// Value is the initial value of an output
+	Value int
+}
+
+// LineEvent is an edge event detected on a line
+type LineEvent struct {
+	Offset int
+	Edge   Edge
+	// Timestamp is the CLOCK_MONOTONIC time of the event
+	Timestamp time.Duration
+	// Seqno is the sequence number of the event on the line
+	Seqno uint32
+}
+
+// Line is the interface for a gpio line requested from a Chip
+type Line interface {
+	// Offset returns the offset of the line on its chip
+	Offset() int
+	// Read reads the current value of the line
+	Read() (int, error)
+	// Write writes the value of an output line
+	Write(int) error
+	// Reconfigure changes the configuration of the line without releasing it
+	Reconfigure(LineConfig) error
+	// ReadEvent blocks until an edge event is detected on the line
+	ReadEvent() (LineEvent, error)
+	// Close releases the line
+	Close() error
+}
+
+type line struct {
+	offset int
+	config LineConfig
+	file   sysfs.File
+}
+
+func (l *line) Offset() int { return l.offset }
+
+func (l *line) Read() (int, error) {
+	values := gpioV2LineValues{mask: 1}
+	if err := ioctl(l.file.Fd(), GPIO_V2_LINE_GET_VALUES_IOCTL, unsafe.Pointer(&values)); err != nil {
+		return 0, err
+	}
+	return int(values.bits & 1), nil
+}
+
+func (l *line) Write(val int) error {
+	values := gpioV2LineValues{mask: 1}
+	if val != 0 {
+		values.bits = 1
+	}
+	return ioctl(l.file.Fd(), GPIO_V2_LINE_SET_VALUES_IOCTL, unsafe.Pointer(&values))
+}
+
+func (l *line) Reconfigure(config LineConfig) error {
+	cfg, err := config.encode()
+	if err != nil {
+		return err
+	}
+	if err = ioctl(l.file.Fd(), GPIO_V2_LINE_SET_CONFIG_IOCTL, unsafe.Pointer(&cfg)); err != nil {
+		return err
+	}
+	l.config = config
+	return nil
+}
+
+func (l *line) ReadEvent() (e LineEvent, err error) {
+	buf := make([]byte, lineEventSize)
+	n, err := l.file.Read(buf)
+	if err != nil {
+		return
+	}
+	if n < lineEventSize {
+		return e, ErrShortEvent
+	}
+
+	e = LineEvent{
+		Timestamp: time.Duration(binary.LittleEndian.Uint64(buf[0:])),
+		Offset:    int(binary.LittleEndian.Uint32(buf[12:])),
+		Seqno:     binary.LittleEndian.Uint32(buf[20:]),
+		Edge:      EdgeFalling,
+	}
+	if binary.LittleEndian.Uint32(buf[8:]) == GPIO_V2_LINE_EVENT_RISING_EDGE {
+		e.Edge = EdgeRising
+	}
+	return
+}
+
+func (l *line) Close() error {
+	return l.file.Close()
+}
+
+// encode converts the LineConfig into a kernel gpio_v2_line_config
+func (c LineConfig) encode() (cfg gpioV2LineConfig, err error) {
+	switch c.Direction {
+	case sysfs.OUT:
+		if c.Edge != EdgeNone || c.Debounce != 0 {
+			return cfg, ErrInvalidConfig
+		}
+		cfg.flags |= GPIO_V2_LINE_FLAG_OUTPUT
+		switch c.Drive {
+		case DriveOpenDrain:
+			cfg.flags |= GPIO_V2_LINE_FLAG_OPEN_DRAIN
+		case DriveOpenSource:
+			cfg.flags |= GPIO_V2_LINE_FLAG_OPEN_SOURCE
+		}
+		cfg.addAttribute(GPIO_V2_LINE_ATTR_ID_OUTPUT_VALUES, uint64(c.Value&1))
+	case sysfs.IN, "":
+		if c.Drive != DrivePushPull {
+			return cfg, ErrInvalidConfig
+		}
+		cfg.flags |= GPIO_V2_LINE_FLAG_INPUT
+		switch c.Edge {
+		case EdgeRising:
+			cfg.flags |= GPIO_V2_LINE_FLAG_EDGE_RISING
+		case EdgeFalling:
+			cfg.flags |= GPIO_V2_LINE_FLAG_EDGE_FALLING
+		case EdgeBoth:
+			cfg.flags |= GPIO_V2_LINE_FLAG_EDGE_RISING | GPIO_V2_LINE_FLAG_EDGE_FALLING
+		}
+		if c.Debounce > 0 {
+			cfg.addAttribute(GPIO_V2_LINE_ATTR_ID_DEBOUNCE, uint64(c.Debounce/time.Microsecond))
+		}
+	default:
+		return cfg, ErrInvalidConfig
+	}
+
+	if c.ActiveLow {
+		cfg.flags |= GPIO_V2_LINE_FLAG_ACTIVE_LOW
+	}
+	switch c.Bias {
+	case BiasDisabled:
+		cfg.flags |= GPIO_V2_LINE_FLAG_BIAS_DISABLED
+	case BiasPullUp:
+		cfg.flags |= GPIO_V2_LINE_FLAG_BIAS_PULL_UP
+	case BiasPullDown:
+		cfg.flags |= GPIO_V2_LINE_FLAG_BIAS_PULL_DOWN
+	}
+	return
+}
+
+func (cfg *gpioV2LineConfig) addAttribute(id uint32, value uint64) {
+	cfg.attrs[cfg.numAttrs] = gpioV2LineConfigAttribute{
+		attr: gpioV2LineAttribute{id: id, value: value},
+		mask: 1,
+	}
+	cfg.numAttrs++
+}
+
+// decodeFlags extracts the bias, drive and edge settings from line flags
+func decodeFlags(flags uint64) (b Bias, d Drive, e Edge) {
+	switch {
+	case flags&GPIO_V2_LINE_FLAG_BIAS_DISABLED != 0:
+		b = BiasDisabled
+	case flags&GPIO_V2_LINE_FLAG_BIAS_PULL_UP != 0:
+		b = BiasPullUp
+	case flags&GPIO_V2_LINE_FLAG_BIAS_PULL_DOWN != 0:
+		b = BiasPullDown
+	}
+	switch {
+	case flags&GPIO_V2_LINE_FLAG_OPEN_DRAIN != 0:
+		d = DriveOpenDrain
+	case flags&GPIO_V2_LINE_FLAG_OPEN_SOURCE != 0:
+		d = DriveOpenSource
+	}
+	rising := flags&GPIO_V2_LINE_FLAG_EDGE_RISING != 0
+	falling := flags&GPIO_V2_LINE_FLAG_EDGE_FALLING != 0
+	switch {
+	case rising && falling:
+		e = EdgeBoth
+	case rising:
+		e = EdgeRising
+	case falling:
+		e = EdgeFalling
+	}
+	return
+}
@@ -0,0 +1,81 @@
+package gpiochip
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/sysfs"
+)
+
+func TestLineReadWrite(t *testing.T) {
+	c, fake, _ := initTestChip()
+	l, _ := c.RequestLine(1, "gobot", LineConfig{Direction: sysfs.OUT})
+
+	gobottest.Assert(t, l.Write(1), nil)
+	gobottest.Assert(t, fake.values, uint64(1))
+	val, err := l.Read()
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, 1)
+
+	gobottest.Assert(t, l.Write(0), nil)
+	val, _ = l.Read()
+	gobottest.Assert(t, val, 0)
+	gobottest.Assert(t, l.Close(), nil)
+}
+
+func TestLineReconfigure(t *testing.T) {
+	c, fake, _ := initTestChip()
+	l, _ := c.RequestLine(1, "gobot", LineConfig{Direction: sysfs.OUT})
+
+	err := l.Reconfigure(LineConfig{
+		Direction: sysfs.IN,
+		ActiveLow: true,
+		Bias:      BiasPullDown,
+		Edge:      EdgeBoth,
+		Debounce:  5 * time.Millisecond,
+	})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, fake.config.flags, uint64(GPIO_V2_LINE_FLAG_INPUT|
+		GPIO_V2_LINE_FLAG_ACTIVE_LOW|GPIO_V2_LINE_FLAG_BIAS_PULL_DOWN|
+		GPIO_V2_LINE_FLAG_EDGE_RISING|GPIO_V2_LINE_FLAG_EDGE_FALLING))
+	gobottest.Assert(t, fake.config.numAttrs, uint32(1))
+	gobottest.Assert(t, fake.config.attrs[0].attr.id, uint32(GPIO_V2_LINE_ATTR_ID_DEBOUNCE))
+	gobottest.Assert(t, fake.config.attrs[0].attr.value, uint64(5000))
+
+	err = l.Reconfigure(LineConfig{Direction: sysfs.IN, Drive: DriveOpenSource})
+	gobottest.Assert(t, err, ErrInvalidConfig)
+
+	err = l.Reconfigure(LineConfig{Direction: "sideways"})
+	gobottest.Assert(t, err, ErrInvalidConfig)
+}
+
+func TestLineReadEvent(t *testing.T) {
+	c, _, file := initTestChip()
+	l, _ := c.RequestLine(3, "gobot", LineConfig{Edge: EdgeBoth})
+
+	buf := make([]byte, lineEventSize)
+	binary.LittleEndian.PutUint64(buf[0:], uint64(1500*time.Millisecond))
+	binary.LittleEndian.PutUint32(buf[8:], GPIO_V2_LINE_EVENT_RISING_EDGE)
+	binary.LittleEndian.PutUint32(buf[12:], 3)
+	binary.LittleEndian.PutUint32(buf[16:], 7)
+	binary.LittleEndian.PutUint32(buf[20:], 2)
+	file.Contents = string(buf)
+
+	e, err := l.ReadEvent()
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, e.Offset, 3)
+	gobottest.Assert(t, e.Edge, EdgeRising)
+	gobottest.Assert(t, e.Timestamp, 1500*time.Millisecond)
+	gobottest.Assert(t, e.Seqno, uint32(2))
+
+	binary.LittleEndian.PutUint32(buf[8:], GPIO_V2_LINE_EVENT_FALLING_EDGE)
+	file.Contents = string(buf)
+	e, _ = l.ReadEvent()
+	gobottest.Assert(t, e.Edge, EdgeFalling)
+
+	file.Contents = "short"
+	_, err = l.ReadEvent()
+	gobottest.Assert(t, err, ErrShortEvent)
+}
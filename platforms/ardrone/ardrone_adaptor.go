@@ -22,12 +22,27 @@ type drone interface {
 	Hover()
 }
 
+// navdata is the part of the navigation data of the drone read by the
+// driver
+type navdata struct {
+	// controlState is the state of the SDK, ctrl_states
+	controlState int
+	battery      int
+}
+
 // ArdroneAdaptor is gobot.Adaptor representation for the Ardrone
 type ArdroneAdaptor struct {
 	name    string
 	drone   drone
 	config  client.Config
 	connect func(*ArdroneAdaptor) (drone, error)
+	// navdata holds the latest navigation data not read by the driver yet
+	navdata chan navdata
+
+	// ATPort is the UDP port of the AT commands of the drone, and
+	// NavdataPort the UDP port of its navigation data
+	ATPort      int
+	NavdataPort int
 }
 
 // NewArdroneAdaptor returns a new ArdroneAdaptor and optionally accepts:
@@ -36,13 +51,34 @@ type ArdroneAdaptor struct {
 //
 func NewArdroneAdaptor(name string, v ...string) *ArdroneAdaptor {
 	a := &ArdroneAdaptor{
-		name: name,
+		name:    name,
+		navdata: make(chan navdata, 1),
 		connect: func(a *ArdroneAdaptor) (drone, error) {
-			return client.Connect(a.config)
+			a.config.AtPort = a.ATPort
+			a.config.NavdataPort = a.NavdataPort
+			c, err := client.Connect(a.config)
+			if err != nil {
+				return nil, err
+			}
+			go func() {
+				for {
+					n, ok := <-c.Navdata
+					if !ok {
+						return
+					}
+					a.updateNavdata(navdata{
+						controlState: int(n.Demo.ControlState),
+						battery:      int(n.Demo.BatteryPercentage),
+					})
+				}
+			}()
+			return c, nil
 		},
 	}
 
 	a.config = client.DefaultConfig()
+	a.ATPort = a.config.AtPort
+	a.NavdataPort = a.config.NavdataPort
 	if len(v) > 0 {
 		a.config.Ip = v[0]
 	}
@@ -65,3 +101,16 @@ func (a *ArdroneAdaptor) Connect() (errs []error) {
 
 // Finalize terminates the connection to the ardrone
 func (a *ArdroneAdaptor) Finalize() (errs []error) { return }
+
+// updateNavdata replaces the navigation data not read by the driver yet
+// with n
+func (a *ArdroneAdaptor) updateNavdata(n navdata) {
+	select {
+	case <-a.navdata:
+	default:
+	}
+	select {
+	case a.navdata <- n:
+	default:
+	}
+}
@@ -19,6 +19,8 @@ func TestArdroneAdaptor(t *testing.T) {
 	a := NewArdroneAdaptor("drone")
 	gobottest.Assert(t, a.Name(), "drone")
 	gobottest.Assert(t, a.config.Ip, "192.168.1.1")
+	gobottest.Assert(t, a.ATPort, 5556)
+	gobottest.Assert(t, a.NavdataPort, 5554)
 
 	a = NewArdroneAdaptor("drone", "192.168.100.100")
 	gobottest.Assert(t, a.config.Ip, "192.168.100.100")
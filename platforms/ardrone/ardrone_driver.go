@@ -1,29 +1,56 @@
 package ardrone
 
 import (
+	"errors"
+	"sync"
+
 	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/flight"
 )
 
-var _ gobot.Driver = (*ArdroneDriver)(nil)
+var _ flight.Drone = (*ArdroneDriver)(nil)
+
+// ErrTakeOff is returned by TakeOff when the drone did not take off
+var ErrTakeOff = errors.New("Drone did not take off")
+
+// ctrl_states of the navigation data
+const (
+	controlStateLanded   = 2
+	controlStateFlying   = 3
+	controlStateHovering = 4
+	controlStateTakeOff  = 6
+	controlStateGotoFix  = 7
+	controlStateLanding  = 8
+	controlStateLooping  = 9
+)
 
 // ArdroneDriver is gobot.Driver representation for the Ardrone
 type ArdroneDriver struct {
 	name       string
 	connection gobot.Connection
 	gobot.Eventer
+	mutex   sync.Mutex
+	state   flight.State
+	battery int
+	halt    chan bool
 }
 
 // NewArdroneDriver creates an ArdroneDriver with specified name.
 //
 // It add the following events:
 //     'flying' - Sent when the device has taken off.
+//     'flightstate' - Sent with the flight.State when it changes.
+//     'battery' - Sent with the remaining battery in percent when it changes.
 func NewArdroneDriver(connection *ArdroneAdaptor, name string) *ArdroneDriver {
 	d := &ArdroneDriver{
 		name:       name,
 		connection: connection,
 		Eventer:    gobot.NewEventer(),
+		battery:    -1,
 	}
 	d.AddEvent("flying")
+	d.AddEvent(flight.FlightState)
+	d.AddEvent(flight.Battery)
 	return d
 }
 
@@ -38,24 +65,95 @@ func (a *ArdroneDriver) adaptor() *ArdroneAdaptor {
 	return a.Connection().(*ArdroneAdaptor)
 }
 
-// Start starts the ArdroneDriver
+// Start starts the ArdroneDriver, which reads the navigation data of the
+// drone from then on
 func (a *ArdroneDriver) Start() (errs []error) {
+	a.halt = make(chan bool)
+	go func(halt chan bool) {
+		for {
+			select {
+			case n := <-a.adaptor().navdata:
+				a.update(n)
+			case <-halt:
+				return
+			}
+		}
+	}(a.halt)
 	return
 }
 
 // Halt halts the ArdroneDriver
 func (a *ArdroneDriver) Halt() (errs []error) {
+	if a.halt != nil {
+		close(a.halt)
+		a.halt = nil
+	}
 	return
 }
 
+// update publishes the flight state and the battery of n when they changed
+func (a *ArdroneDriver) update(n navdata) {
+	a.mutex.Lock()
+	state, stateChanged := flightState(n.controlState), false
+	if state != a.state {
+		a.state, stateChanged = state, true
+	}
+	batteryChanged := n.battery != a.battery
+	a.battery = n.battery
+	a.mutex.Unlock()
+
+	if stateChanged {
+		gobot.Publish(a.Event(flight.FlightState), state)
+	}
+	if batteryChanged {
+		gobot.Publish(a.Event(flight.Battery), n.battery)
+	}
+}
+
+// flightState returns the flight.State of the control state s
+func flightState(s int) flight.State {
+	switch s {
+	case controlStateTakeOff:
+		return flight.TakingOff
+	case controlStateHovering:
+		return flight.Hovering
+	case controlStateFlying, controlStateGotoFix, controlStateLooping:
+		return flight.Flying
+	case controlStateLanding:
+		return flight.Landing
+	}
+	return flight.Landed
+}
+
+// FlightState returns the last known flight state of the drone
+func (a *ArdroneDriver) FlightState() flight.State {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.state
+}
+
+// Battery returns the last known remaining battery in percent, or -1 until
+// the navigation data is received
+func (a *ArdroneDriver) Battery() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.battery
+}
+
 // TakeOff makes the drone start flying, and publishes `flying` event
-func (a *ArdroneDriver) TakeOff() {
-	gobot.Publish(a.Event("flying"), a.adaptor().drone.Takeoff())
+func (a *ArdroneDriver) TakeOff() error {
+	flying := a.adaptor().drone.Takeoff()
+	gobot.Publish(a.Event("flying"), flying)
+	if !flying {
+		return ErrTakeOff
+	}
+	return nil
 }
 
 // Land causes the drone to land
-func (a *ArdroneDriver) Land() {
+func (a *ArdroneDriver) Land() error {
 	a.adaptor().drone.Land()
+	return nil
 }
 
 // Up makes the drone gain altitude.
@@ -108,7 +206,32 @@ func (a *ArdroneDriver) CounterClockwise(speed float64) {
 	a.adaptor().drone.Counterclockwise(speed)
 }
 
+// Move moves the drone along all axes at once, see flight.Drone. The values
+// are in the range -1 to 1.
+func (a *ArdroneDriver) Move(roll, pitch, yaw, throttle float64) error {
+	drone := a.adaptor().drone
+	axes := []struct {
+		value              float64
+		positive, negative func(float64)
+	}{
+		{roll, drone.Right, drone.Left},
+		{pitch, drone.Forward, drone.Backward},
+		{yaw, drone.Clockwise, drone.Counterclockwise},
+		{throttle, drone.Up, drone.Down},
+	}
+	for _, axis := range axes {
+		speed := flight.Clamp(axis.value)
+		if speed < 0 {
+			axis.negative(-speed)
+		} else {
+			axis.positive(speed)
+		}
+	}
+	return nil
+}
+
 // Hover makes the drone to hover in place.
-func (a *ArdroneDriver) Hover() {
+func (a *ArdroneDriver) Hover() error {
 	a.adaptor().drone.Hover()
+	return nil
 }
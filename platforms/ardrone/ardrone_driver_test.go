@@ -2,14 +2,17 @@ package ardrone
 
 import (
 	"testing"
+	"time"
 
+	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/platforms/flight"
 )
 
 func initTestArdroneDriver() *ArdroneDriver {
 	a := NewArdroneAdaptor("drone")
 	a.connect = func(a *ArdroneAdaptor) (drone, error) {
-		return &testDrone{}, nil
+		return newTestDrone(), nil
 	}
 	d := NewArdroneDriver(a, "drone")
 	a.Connect()
@@ -85,3 +88,49 @@ func TestArdroneDriverHover(t *testing.T) {
 	d := initTestArdroneDriver()
 	d.Hover()
 }
+
+func TestArdroneDriverMove(t *testing.T) {
+	d := initTestArdroneDriver()
+	drone := d.adaptor().drone.(*testDrone)
+	gobottest.Assert(t, d.Move(0.5, -0.25, -2, 0), nil)
+	gobottest.Assert(t, drone.moves, map[string]float64{
+		"right":            0.5,
+		"backward":         0.25,
+		"counterclockwise": 1,
+		"up":               0,
+	})
+}
+
+func TestArdroneDriverNavdata(t *testing.T) {
+	d := initTestArdroneDriver()
+	gobottest.Assert(t, d.FlightState(), flight.Landed)
+	gobottest.Assert(t, d.Battery(), -1)
+
+	states := make(chan interface{}, 1)
+	gobot.On(d.Event(flight.FlightState), func(data interface{}) {
+		states <- data
+	})
+	batteries := make(chan interface{}, 1)
+	gobot.On(d.Event(flight.Battery), func(data interface{}) {
+		batteries <- data
+	})
+
+	d.Start()
+	defer d.Halt()
+	d.adaptor().updateNavdata(navdata{controlState: controlStateHovering, battery: 73})
+
+	select {
+	case data := <-states:
+		gobottest.Assert(t, data, flight.Hovering)
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("FlightState event was not published")
+	}
+	select {
+	case data := <-batteries:
+		gobottest.Assert(t, data, 73)
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Battery event was not published")
+	}
+	gobottest.Assert(t, d.FlightState(), flight.Hovering)
+	gobottest.Assert(t, d.Battery(), 73)
+}
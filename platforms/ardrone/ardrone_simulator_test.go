@@ -0,0 +1,72 @@
+package ardrone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/platforms/ardrone/simulator"
+	"github.com/hybridgroup/gobot/platforms/flight"
+)
+
+// initSimulatedArdroneDriver returns a started simulator, and a started
+// driver of an adaptor connected to it
+func initSimulatedArdroneDriver(t *testing.T) (*simulator.Simulator, *ArdroneDriver) {
+	s := simulator.New()
+	s.Interval = 10 * time.Millisecond
+	s.ATPort = 0
+	s.NavdataPort = 0
+	gobottest.Assert(t, s.Start("127.0.0.1"), nil)
+
+	a := NewArdroneAdaptor("drone", "127.0.0.1")
+	a.ATPort = s.ATPort
+	a.NavdataPort = s.NavdataPort
+
+	d := NewArdroneDriver(a, "drone")
+	gobottest.Assert(t, len(a.Connect()), 0)
+	gobottest.Assert(t, len(d.Start()), 0)
+	return s, d
+}
+
+// waitFlightState waits for the flight state from states
+func waitFlightState(t *testing.T, states chan interface{}, state flight.State) {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case data := <-states:
+			if data == state {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("flight state %v was not published", state)
+		}
+	}
+}
+
+func TestArdroneDriverSimulated(t *testing.T) {
+	s, d := initSimulatedArdroneDriver(t)
+	defer s.Close()
+
+	states := make(chan interface{}, 100)
+	gobot.On(d.Event(flight.FlightState), func(data interface{}) {
+		states <- data
+	})
+
+	gobottest.Assert(t, d.TakeOff(), nil)
+	waitFlightState(t, states, flight.Hovering)
+	gobottest.Assert(t, s.Config("general:navdata_demo"), "TRUE")
+
+	gobottest.Assert(t, d.Move(0, 0.5, 0, 0), nil)
+	waitFlightState(t, states, flight.Flying)
+	gobottest.Assert(t, d.Hover(), nil)
+	waitFlightState(t, states, flight.Hovering)
+	gobottest.Assert(t, s.Received("PCMD") > 0, true)
+
+	gobottest.Assert(t, d.Land(), nil)
+	waitFlightState(t, states, flight.Landed)
+	gobottest.Assert(t, d.FlightState(), flight.Landed)
+
+	gobottest.Assert(t, len(d.Halt()), 0)
+	gobottest.Assert(t, len(d.adaptor().Finalize()), 0)
+}
@@ -0,0 +1,232 @@
+package simulator
+
+import (
+	"math"
+	"time"
+)
+
+// ctrl_states of the navigation data
+const (
+	ControlStateDefault  = 0
+	ControlStateInit     = 1
+	ControlStateLanded   = 2
+	ControlStateFlying   = 3
+	ControlStateHovering = 4
+	ControlStateTest     = 5
+	ControlStateTakeOff  = 6
+	ControlStateGotoFix  = 7
+	ControlStateLanding  = 8
+	ControlStateLooping  = 9
+)
+
+const (
+	// takeOffAltitude is the altitude the drone hovers at after taking off,
+	// in meters
+	takeOffAltitude = 1.0
+	// autoVerticalSpeed is the speed of the take off and of the landing, in
+	// m/s
+	autoVerticalSpeed = 0.7
+	// speedPerDegree is the horizontal speed of the drone for each degree
+	// of tilt, in m/s
+	speedPerDegree = 0.25
+	// batteryDrain is the battery used per second of flight, in percent
+	batteryDrain = 0.1
+	// lowBattery is the battery under which the drone reports it, and
+	// criticalBattery the battery under which it lands, in percent
+	lowBattery      = 20
+	criticalBattery = 5
+)
+
+// Settings are the control settings of the simulated drone, which the
+// client changes with AT*CONFIG
+type Settings struct {
+	// AltitudeMax is the maximum altitude, control:altitude_max, in
+	// millimeters
+	AltitudeMax float64
+	// EulerAngleMax is the maximum tilt, control:euler_angle_max, in
+	// radians
+	EulerAngleMax float64
+	// ControlVzMax is the maximum vertical speed, control:control_vz_max,
+	// in mm/s
+	ControlVzMax float64
+	// ControlYaw is the maximum rotation speed, control:control_yaw, in
+	// radians/s
+	ControlYaw float64
+	// Outdoor is control:outdoor
+	Outdoor bool
+}
+
+// DefaultSettings are the settings of a new simulator
+var DefaultSettings = Settings{
+	AltitudeMax:   3000,
+	EulerAngleMax: 0.21,
+	ControlVzMax:  700,
+	ControlYaw:    1.75,
+}
+
+// Pcmd is the last progressive command, AT*PCMD, of the client. The values
+// are in the range -1 to 1.
+type Pcmd struct {
+	// Progressive is the flag enabling the roll and the pitch, otherwise
+	// the drone keeps its position
+	Progressive bool
+	Roll        float64
+	Pitch       float64
+	Gaz         float64
+	Yaw         float64
+}
+
+// State is the state of the simulated drone
+type State struct {
+	// ControlState is the major ctrl_state of the navigation data
+	ControlState int
+	// Emergency is whether the motors were cut
+	Emergency bool
+	// North, East and Altitude are the position of the drone from its take
+	// off point, in meters
+	North    float64
+	East     float64
+	Altitude float64
+	// Psi is the heading of the drone, Theta its pitch and Phi its roll,
+	// in degrees
+	Psi   float64
+	Theta float64
+	Phi   float64
+	// Vx, Vy and Vz are the speed of the drone forward, to the right and
+	// up, in m/s
+	Vx float64
+	Vy float64
+	Vz float64
+	// Battery is the remaining battery in percent
+	Battery  float64
+	Pcmd     Pcmd
+	Settings Settings
+}
+
+// model is the kinematic model of the drone: it climbs, moves and turns at
+// the speeds commanded by the progressive commands, limited by the settings
+type model struct {
+	State
+}
+
+func newModel() model {
+	return model{State: State{
+		ControlState: ControlStateLanded,
+		Battery:      100,
+		Settings:     DefaultSettings,
+	}}
+}
+
+// flying returns whether the drone is in the air
+func (m *model) flying() bool {
+	switch m.ControlState {
+	case ControlStateFlying, ControlStateHovering, ControlStateTakeOff,
+		ControlStateGotoFix, ControlStateLanding, ControlStateLooping:
+		return true
+	}
+	return false
+}
+
+// takeOff makes a landed drone take off, unless its battery is critical
+func (m *model) takeOff() {
+	if m.ControlState == ControlStateLanded && !m.Emergency && m.Battery > criticalBattery {
+		m.ControlState = ControlStateTakeOff
+	}
+}
+
+// land makes a flying drone land
+func (m *model) land() {
+	if m.flying() && m.ControlState != ControlStateLanding {
+		m.ControlState = ControlStateLanding
+	}
+}
+
+// emergency cuts the motors of a flying drone, or resets the emergency of a
+// drone on the ground
+func (m *model) emergency() {
+	if m.Emergency {
+		m.Emergency = false
+		m.ControlState = ControlStateLanded
+		return
+	}
+	if m.flying() {
+		m.Emergency = true
+		m.ControlState = ControlStateDefault
+		m.ground()
+	}
+}
+
+// ground puts the drone on the ground
+func (m *model) ground() {
+	m.Altitude = 0
+	m.Theta, m.Phi = 0, 0
+	m.Vx, m.Vy, m.Vz = 0, 0, 0
+}
+
+// step moves the drone for dt
+func (m *model) step(dt time.Duration) {
+	seconds := dt.Seconds()
+	m.Theta, m.Phi = 0, 0
+	m.Vx, m.Vy, m.Vz = 0, 0, 0
+
+	switch m.ControlState {
+	case ControlStateTakeOff:
+		m.Vz = autoVerticalSpeed
+		if m.Altitude+m.Vz*seconds >= takeOffAltitude {
+			m.Vz = (takeOffAltitude - m.Altitude) / seconds
+			m.ControlState = ControlStateHovering
+		}
+	case ControlStateLanding:
+		m.Vz = -autoVerticalSpeed
+		if m.Altitude+m.Vz*seconds <= 0 {
+			m.ControlState = ControlStateLanded
+			m.ground()
+			return
+		}
+	case ControlStateHovering, ControlStateFlying:
+		if m.Battery <= criticalBattery {
+			m.ControlState = ControlStateLanding
+			return
+		}
+		tilt := m.Settings.EulerAngleMax * 180 / math.Pi
+		if m.Pcmd.Progressive {
+			m.Phi = m.Pcmd.Roll * tilt
+			m.Theta = m.Pcmd.Pitch * tilt
+		}
+		// the drone goes forward with the nose down, which is a negative
+		// pitch
+		m.Vx = -m.Theta * speedPerDegree
+		m.Vy = m.Phi * speedPerDegree
+		m.Vz = m.Pcmd.Gaz * m.Settings.ControlVzMax / 1000
+		m.Psi = normalizeAngle(m.Psi +
+			m.Pcmd.Yaw*m.Settings.ControlYaw*180/math.Pi*seconds)
+
+		if m.Vx != 0 || m.Vy != 0 || m.Vz != 0 || m.Pcmd.Yaw != 0 {
+			m.ControlState = ControlStateFlying
+		} else {
+			m.ControlState = ControlStateHovering
+		}
+	default:
+		return
+	}
+
+	sin, cos := math.Sincos(m.Psi * math.Pi / 180)
+	m.North += (m.Vx*cos - m.Vy*sin) * seconds
+	m.East += (m.Vx*sin + m.Vy*cos) * seconds
+	m.Altitude = math.Min(m.Altitude+m.Vz*seconds, m.Settings.AltitudeMax/1000)
+	if m.Altitude < 0 {
+		m.Altitude = 0
+	}
+	m.Battery = math.Max(m.Battery-batteryDrain*seconds, 0)
+}
+
+// normalizeAngle returns a in the range -180 to 180 degrees
+func normalizeAngle(a float64) float64 {
+	for a > 180 {
+		a -= 360
+	}
+	for a < -180 {
+		a += 360
+	}
+	return a
+}
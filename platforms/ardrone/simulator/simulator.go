@@ -0,0 +1,404 @@
+// Package simulator provides a simulated ARDrone, which speaks the AT
+// commands and the navigation data protocols of the drone over local
+// sockets, so that the clients and the gobot drivers can be tested without
+// a drone.
+package simulator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default ports of the drone
+const (
+	DefaultATPort      = 5556
+	DefaultNavdataPort = 5554
+)
+
+// navdataHeader starts every navigation data packet
+const navdataHeader = 0x55667788
+
+// Options of the navigation data
+const (
+	navdataDemoTag      = 0
+	navdataDemoSize     = 148
+	navdataChecksumTag  = 0xffff
+	navdataChecksumSize = 8
+)
+
+// Bits of the state of the navigation data
+const (
+	stateFlying      = 1 << 0
+	stateCommandAck  = 1 << 6
+	stateDemo        = 1 << 10
+	stateBootstrap   = 1 << 11
+	stateLowBattery  = 1 << 15
+	stateComWatchdog = 1 << 30
+	stateEmergency   = 1 << 31
+)
+
+// Bits of the argument of AT*REF
+const (
+	refEmergency = 1 << 8
+	refTakeOff   = 1 << 9
+)
+
+// ackControlMode is the mode of AT*CTRL acknowledging an AT*CONFIG
+const ackControlMode = 5
+
+// Simulator is a simulated ARDrone. It listens on the ports of the drone by
+// default, so that the clients connect to it with its IP:
+//
+//	s := simulator.New()
+//	s.Start("127.0.0.1")
+//	drone := ardrone.NewArdroneAdaptor("drone", "127.0.0.1")
+type Simulator struct {
+	// Interval is the period of the model and of the navigation data, 65ms
+	// by default
+	Interval time.Duration
+	// WatchdogTimeout is the time without AT commands after which the drone
+	// hovers, 2s by default
+	WatchdogTimeout time.Duration
+	// ATPort is the UDP port of the AT commands and NavdataPort the UDP port
+	// of the navigation data, the ports of the drone by default. Start sets
+	// them when they are 0.
+	ATPort      int
+	NavdataPort int
+
+	mutex       sync.Mutex
+	model       model
+	demo        bool
+	ack         bool
+	watchdog    bool
+	lastSeq     int
+	emergency   bool
+	lastCommand time.Time
+	received    map[string]int
+	config      map[string]string
+	sequence    uint32
+	navdataAddr *net.UDPAddr
+	at          *net.UDPConn
+	navdata     *net.UDPConn
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// New returns a landed Simulator with a full battery
+func New() *Simulator {
+	return &Simulator{
+		Interval:        65 * time.Millisecond,
+		WatchdogTimeout: 2 * time.Second,
+		ATPort:          DefaultATPort,
+		NavdataPort:     DefaultNavdataPort,
+		model:           newModel(),
+		received:        make(map[string]int),
+		config:          make(map[string]string),
+		done:            make(chan struct{}),
+	}
+}
+
+// Start listens for the client on the ports of ip, and starts the model
+func (s *Simulator) Start(ip string) (err error) {
+	s.at, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP(ip), Port: s.ATPort})
+	if err != nil {
+		return err
+	}
+	s.navdata, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP(ip), Port: s.NavdataPort})
+	if err != nil {
+		s.at.Close()
+		return err
+	}
+	s.ATPort = s.at.LocalAddr().(*net.UDPAddr).Port
+	s.NavdataPort = s.navdata.LocalAddr().(*net.UDPAddr).Port
+	s.lastCommand = time.Now()
+
+	go s.receiveCommands()
+	go s.receiveNavdataRequests()
+	go s.run()
+	return nil
+}
+
+// Close stops the simulator
+func (s *Simulator) Close() (err error) {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.navdata.Close()
+		err = s.at.Close()
+	})
+	return
+}
+
+// State returns the state of the simulated drone
+func (s *Simulator) State() State {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.model.State
+}
+
+// SetBattery sets the remaining battery, in percent
+func (s *Simulator) SetBattery(percent float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.model.Battery = percent
+}
+
+// Received returns the number of AT commands of name received, such as
+// "REF" or "PCMD"
+func (s *Simulator) Received(name string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.received[name]
+}
+
+// Config returns the value of key set by AT*CONFIG, or "" when it was not
+// set
+func (s *Simulator) Config(key string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.config[key]
+}
+
+// receiveCommands handles the AT commands sent by the client
+func (s *Simulator) receiveCommands() {
+	buf := make([]byte, 1024)
+	for {
+		n, _, err := s.at.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		for _, command := range strings.Split(string(buf[:n]), "\r") {
+			s.decodeCommand(command)
+		}
+		s.mutex.Unlock()
+	}
+}
+
+// receiveNavdataRequests sends the navigation data to the client from the
+// first packet it sends to the navigation data port
+func (s *Simulator) receiveNavdataRequests() {
+	buf := make([]byte, 64)
+	for {
+		_, addr, err := s.navdata.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		s.navdataAddr = addr
+		s.mutex.Unlock()
+	}
+}
+
+// decodeCommand applies the AT command, with the lock held. The commands
+// older than the last one are ignored, unless their sequence number is 1
+// which restarts the sequence.
+func (s *Simulator) decodeCommand(command string) {
+	command = strings.TrimSpace(command)
+	if !strings.HasPrefix(command, "AT*") {
+		return
+	}
+	i := strings.Index(command, "=")
+	if i < 0 {
+		return
+	}
+	name := command[3:i]
+	args := strings.Split(command[i+1:], ",")
+	seq, err := strconv.Atoi(args[0])
+	if err != nil || (seq <= s.lastSeq && seq != 1) {
+		return
+	}
+	s.lastSeq = seq
+	s.lastCommand = time.Now()
+	s.watchdog = false
+	s.received[name]++
+	args = args[1:]
+
+	switch name {
+	case "REF":
+		if len(args) < 1 {
+			return
+		}
+		ref, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return
+		}
+		// the emergency is toggled when the bit is set, and the drone
+		// flies as long as the take off bit is set
+		emergency := ref&refEmergency != 0
+		if emergency && !s.emergency {
+			s.model.emergency()
+		}
+		s.emergency = emergency
+		if ref&refTakeOff != 0 {
+			s.model.takeOff()
+		} else {
+			s.model.land()
+		}
+	case "PCMD", "PCMD_MAG":
+		if len(args) < 5 {
+			return
+		}
+		flag, _ := strconv.Atoi(args[0])
+		values := make([]float64, 4)
+		for i := range values {
+			values[i] = parseFloat(args[i+1])
+		}
+		s.model.Pcmd = Pcmd{
+			Progressive: flag&1 != 0,
+			Roll:        values[0],
+			Pitch:       values[1],
+			Gaz:         values[2],
+			Yaw:         values[3],
+		}
+	case "CONFIG":
+		if len(args) < 2 {
+			return
+		}
+		s.setConfig(unquote(args[0]), unquote(strings.Join(args[1:], ",")))
+	case "CTRL":
+		if len(args) > 0 && args[0] == strconv.Itoa(ackControlMode) {
+			s.ack = false
+		}
+	}
+}
+
+// setConfig sets the configuration key to value, with the lock held
+func (s *Simulator) setConfig(key, value string) {
+	s.config[key] = value
+	s.ack = true
+
+	number, err := strconv.ParseFloat(value, 64)
+	settings := &s.model.Settings
+	switch {
+	case key == "general:navdata_demo":
+		s.demo = value == "TRUE"
+	case key == "control:outdoor":
+		settings.Outdoor = value == "TRUE"
+	case err != nil:
+	case key == "control:altitude_max":
+		settings.AltitudeMax = number
+	case key == "control:euler_angle_max":
+		settings.EulerAngleMax = number
+	case key == "control:control_vz_max":
+		settings.ControlVzMax = number
+	case key == "control:control_yaw":
+		settings.ControlYaw = number
+	}
+}
+
+// run steps the model, and sends the navigation data
+func (s *Simulator) run() {
+	last := time.Now()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-time.After(s.Interval):
+		}
+		now := time.Now()
+
+		s.mutex.Lock()
+		if now.Sub(s.lastCommand) > s.WatchdogTimeout {
+			s.watchdog = true
+			s.model.Pcmd = Pcmd{}
+		}
+		s.model.step(now.Sub(last))
+		last = now
+		var packet []byte
+		addr := s.navdataAddr
+		if addr != nil {
+			packet = s.navdataPacket()
+		}
+		s.mutex.Unlock()
+
+		if addr != nil {
+			s.navdata.WriteToUDP(packet, addr)
+		}
+	}
+}
+
+// state returns the state bits of the navigation data, with the lock held
+func (s *Simulator) state() uint32 {
+	var state uint32
+	flags := []struct {
+		set bool
+		bit uint32
+	}{
+		{s.model.flying(), stateFlying},
+		{s.ack, stateCommandAck},
+		{s.demo, stateDemo},
+		{!s.demo, stateBootstrap},
+		{s.model.Battery < lowBattery, stateLowBattery},
+		{s.watchdog, stateComWatchdog},
+		{s.model.Emergency, stateEmergency},
+	}
+	for _, flag := range flags {
+		if flag.set {
+			state |= flag.bit
+		}
+	}
+	return state
+}
+
+// navdataPacket returns the next navigation data packet, with the lock
+// held. Until the client sets general:navdata_demo, the drone is in
+// bootstrap mode and the packets hold no option but the checksum.
+func (s *Simulator) navdataPacket() []byte {
+	s.sequence++
+	buf := &bytes.Buffer{}
+	write := func(v interface{}) { binary.Write(buf, binary.LittleEndian, v) }
+
+	write(uint32(navdataHeader))
+	write(s.state())
+	write(s.sequence)
+	write(uint32(0))
+
+	if s.demo {
+		m := s.model.State
+		write(uint16(navdataDemoTag))
+		write(uint16(navdataDemoSize))
+		write(uint32(m.ControlState << 16))
+		write(uint32(math.Ceil(m.Battery)))
+		// the angles are in millidegrees, the altitude in millimeters and
+		// the speeds in mm/s
+		write(float32(m.Theta * 1000))
+		write(float32(m.Phi * 1000))
+		write(float32(m.Psi * 1000))
+		write(int32(m.Altitude * 1000))
+		write(float32(m.Vx * 1000))
+		write(float32(m.Vy * 1000))
+		write(float32(m.Vz * 1000))
+		// the video and the detection are not simulated
+		buf.Write(make([]byte, navdataDemoSize-40))
+	}
+
+	var checksum uint32
+	for _, b := range buf.Bytes() {
+		checksum += uint32(b)
+	}
+	write(uint16(navdataChecksumTag))
+	write(uint16(navdataChecksumSize))
+	write(checksum)
+	return buf.Bytes()
+}
+
+// parseFloat returns the float sent as the int32 of its bits by AT
+// commands
+func parseFloat(arg string) float64 {
+	i, err := strconv.ParseInt(arg, 10, 32)
+	if err != nil {
+		return 0
+	}
+	return float64(math.Float32frombits(uint32(int32(i))))
+}
+
+// unquote returns arg without its quotes
+func unquote(arg string) string {
+	return strings.Trim(arg, "\"")
+}
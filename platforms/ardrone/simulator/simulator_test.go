@@ -0,0 +1,342 @@
+package simulator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// testClient speaks the AT commands and navigation data protocols
+type testClient struct {
+	t       *testing.T
+	at      *net.UDPConn
+	navdata *net.UDPConn
+	seq     int
+}
+
+// navdata is the decoded navigation data
+type navdata struct {
+	state        uint32
+	sequence     uint32
+	demo         bool
+	controlState int
+	battery      uint32
+	theta        float32
+	phi          float32
+	psi          float32
+	altitude     int32
+	vx           float32
+}
+
+// connect returns a started simulator on free ports, and a client receiving
+// its navigation data
+func connect(t *testing.T) (*Simulator, *testClient) {
+	s := New()
+	s.Interval = 10 * time.Millisecond
+	s.ATPort, s.NavdataPort = 0, 0
+	gobottest.Assert(t, s.Start("127.0.0.1"), nil)
+
+	c := &testClient{t: t}
+	var err error
+	c.at, err = net.DialUDP("udp", nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: s.ATPort})
+	gobottest.Assert(t, err, nil)
+	c.navdata, err = net.DialUDP("udp", nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: s.NavdataPort})
+	gobottest.Assert(t, err, nil)
+	_, err = c.navdata.Write([]byte{1, 0, 0, 0})
+	gobottest.Assert(t, err, nil)
+	return s, c
+}
+
+func (c *testClient) Close() {
+	c.at.Close()
+	c.navdata.Close()
+}
+
+// send sends the AT command name with the next sequence number
+func (c *testClient) send(name string, args ...interface{}) {
+	c.seq++
+	command := fmt.Sprintf("AT*%s=%d", name, c.seq)
+	for _, arg := range args {
+		command += fmt.Sprintf(",%v", arg)
+	}
+	_, err := c.at.Write([]byte(command + "\r"))
+	gobottest.Assert(c.t, err, nil)
+}
+
+// pcmd sends AT*PCMD with the values of the movements
+func (c *testClient) pcmd(roll, pitch, gaz, yaw float32) {
+	args := []interface{}{1}
+	for _, v := range []float32{roll, pitch, gaz, yaw} {
+		args = append(args, int32(math.Float32bits(v)))
+	}
+	c.send("PCMD", args...)
+}
+
+// read reads and decodes the next navigation data
+func (c *testClient) read() navdata {
+	buf := make([]byte, 1024)
+	c.navdata.SetReadDeadline(time.Now().Add(time.Second))
+	length, err := c.navdata.Read(buf)
+	if err != nil {
+		c.t.Fatalf("navigation data was not received: %v", err)
+	}
+	packet := buf[:length]
+
+	var checksum uint32
+	for _, b := range packet[:length-8] {
+		checksum += uint32(b)
+	}
+	r := bytes.NewReader(packet)
+	read := func(v interface{}) { binary.Read(r, binary.LittleEndian, v) }
+
+	var header, vision uint32
+	var n navdata
+	read(&header)
+	gobottest.Assert(c.t, header, uint32(navdataHeader))
+	read(&n.state)
+	read(&n.sequence)
+	read(&vision)
+	for {
+		var tag, size uint16
+		read(&tag)
+		read(&size)
+		switch tag {
+		case navdataDemoTag:
+			gobottest.Assert(c.t, size, uint16(navdataDemoSize))
+			var controlState uint32
+			var vy, vz float32
+			read(&controlState)
+			n.demo, n.controlState = true, int(controlState>>16)
+			read(&n.battery)
+			read(&n.theta)
+			read(&n.phi)
+			read(&n.psi)
+			read(&n.altitude)
+			read(&n.vx)
+			read(&vy)
+			read(&vz)
+			r.Seek(int64(size)-40, os.SEEK_CUR)
+		case navdataChecksumTag:
+			gobottest.Assert(c.t, size, uint16(navdataChecksumSize))
+			var sum uint32
+			read(&sum)
+			gobottest.Assert(c.t, sum, checksum)
+			return n
+		default:
+			c.t.Fatalf("unexpected option %v", tag)
+		}
+	}
+}
+
+// wait reads the navigation data until f returns true for 5 seconds
+func (c *testClient) wait(what string, f func(navdata) bool) navdata {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		n := c.read()
+		if f(n) {
+			return n
+		}
+		if time.Now().After(deadline) {
+			c.t.Fatalf("%s did not happen", what)
+		}
+	}
+}
+
+// eventually fails the test unless f returns true within 5 seconds
+func eventually(t *testing.T, what string, f func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s did not happen", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// received returns whether count AT commands of name were received
+func received(s *Simulator, name string, count int) func() bool {
+	return func() bool { return s.Received(name) == count }
+}
+
+func controlState(state int) func(navdata) bool {
+	return func(n navdata) bool { return n.controlState == state }
+}
+
+// ready switches the navigation data to the demo mode
+func (c *testClient) ready() {
+	c.send("CONFIG", `"general:navdata_demo"`, `"TRUE"`)
+	c.wait("demo mode", func(n navdata) bool { return n.demo })
+	c.send("CTRL", ackControlMode, 0)
+}
+
+func TestSimulatorBootstrap(t *testing.T) {
+	s, c := connect(t)
+	defer s.Close()
+	defer c.Close()
+
+	n := c.read()
+	gobottest.Assert(t, n.state&stateBootstrap != 0, true)
+	gobottest.Assert(t, n.demo, false)
+
+	c.send("CONFIG", `"general:navdata_demo"`, `"TRUE"`)
+	n = c.wait("demo mode", func(n navdata) bool { return n.demo })
+	gobottest.Assert(t, n.state&stateBootstrap, uint32(0))
+	gobottest.Assert(t, n.state&stateDemo != 0, true)
+	gobottest.Assert(t, n.state&stateCommandAck != 0, true)
+	gobottest.Assert(t, n.controlState, ControlStateLanded)
+	gobottest.Assert(t, n.battery, uint32(100))
+	gobottest.Assert(t, s.Config("general:navdata_demo"), "TRUE")
+
+	c.send("CTRL", ackControlMode, 0)
+	c.wait("ack reset", func(n navdata) bool { return n.state&stateCommandAck == 0 })
+	next := c.read()
+	gobottest.Assert(t, next.sequence > n.sequence, true)
+}
+
+func TestSimulatorFlight(t *testing.T) {
+	s, c := connect(t)
+	defer s.Close()
+	defer c.Close()
+	c.ready()
+
+	c.send("FTRIM")
+	c.send("REF", refTakeOff|0x11540000)
+	n := c.wait("take off", controlState(ControlStateHovering))
+	gobottest.Assert(t, n.state&stateFlying != 0, true)
+	gobottest.Assert(t, n.altitude, int32(1000))
+
+	// forward is a negative pitch
+	c.pcmd(0, -0.5, 0, 0)
+	n = c.wait("flight forward", controlState(ControlStateFlying))
+	gobottest.Assert(t, n.theta < 0, true)
+	gobottest.Assert(t, n.vx > 0, true)
+	c.pcmd(0, 0, 0, 1)
+	c.wait("turn", func(n navdata) bool { return n.psi > 10000 })
+	c.pcmd(0, 0, 0, 0)
+	c.wait("hover", controlState(ControlStateHovering))
+	gobottest.Assert(t, s.State().North > 0, true)
+
+	c.send("REF", 0x11540000)
+	n = c.wait("landing", controlState(ControlStateLanded))
+	gobottest.Assert(t, n.state&stateFlying, uint32(0))
+	gobottest.Assert(t, n.altitude, int32(0))
+	gobottest.Assert(t, s.Received("REF"), 2)
+	gobottest.Assert(t, s.Received("PCMD"), 3)
+	gobottest.Assert(t, s.Received("FTRIM"), 1)
+}
+
+func TestSimulatorSequence(t *testing.T) {
+	s, c := connect(t)
+	defer s.Close()
+	defer c.Close()
+	c.ready()
+
+	c.send("PCMD", 0, 0, 0, 0, 0)
+	// older commands are ignored
+	c.seq -= 2
+	c.send("REF", refTakeOff)
+	c.seq = 0
+	c.send("COMWDG")
+	eventually(t, "sequence reset", received(s, "COMWDG", 1))
+	gobottest.Assert(t, s.Received("REF"), 0)
+	gobottest.Assert(t, s.State().ControlState, ControlStateLanded)
+}
+
+func TestSimulatorEmergency(t *testing.T) {
+	s, c := connect(t)
+	defer s.Close()
+	defer c.Close()
+	c.ready()
+
+	c.send("REF", refTakeOff)
+	c.wait("take off", controlState(ControlStateHovering))
+
+	c.send("REF", refTakeOff|refEmergency)
+	n := c.wait("emergency", func(n navdata) bool { return n.state&stateEmergency != 0 })
+	gobottest.Assert(t, n.controlState, ControlStateDefault)
+	gobottest.Assert(t, n.altitude, int32(0))
+
+	// the drone does not take off until the emergency is reset
+	c.send("REF", 0)
+	c.send("REF", refTakeOff)
+	eventually(t, "take off command", received(s, "REF", 4))
+	gobottest.Assert(t, s.State().Emergency, true)
+	c.send("REF", refEmergency)
+	n = c.wait("emergency reset", controlState(ControlStateLanded))
+	gobottest.Assert(t, n.state&stateEmergency, uint32(0))
+}
+
+func TestSimulatorWatchdog(t *testing.T) {
+	s, c := connect(t)
+	defer s.Close()
+	defer c.Close()
+	s.mutex.Lock()
+	s.WatchdogTimeout = 100 * time.Millisecond
+	s.mutex.Unlock()
+	c.ready()
+
+	c.send("REF", refTakeOff)
+	c.wait("take off", controlState(ControlStateHovering))
+	c.pcmd(0.5, 0, 0, 0)
+	c.wait("flight right", controlState(ControlStateFlying))
+
+	// the drone hovers without commands
+	c.wait("watchdog", func(n navdata) bool {
+		return n.state&stateComWatchdog != 0 && n.controlState == ControlStateHovering
+	})
+	c.send("COMWDG")
+	c.wait("watchdog reset", func(n navdata) bool { return n.state&stateComWatchdog == 0 })
+}
+
+func TestSimulatorBattery(t *testing.T) {
+	s, c := connect(t)
+	defer s.Close()
+	defer c.Close()
+	c.ready()
+
+	c.send("REF", refTakeOff)
+	c.wait("take off", controlState(ControlStateHovering))
+
+	s.SetBattery(15)
+	c.wait("low battery", func(n navdata) bool {
+		return n.state&stateLowBattery != 0 && n.battery == 15
+	})
+
+	// the drone lands on a critical battery, and does not take off again
+	s.SetBattery(4)
+	c.wait("landing", controlState(ControlStateLanded))
+	c.send("REF", 0)
+	c.send("REF", refTakeOff)
+	eventually(t, "take off command", received(s, "REF", 3))
+	c.read()
+	gobottest.Assert(t, s.State().ControlState, ControlStateLanded)
+}
+
+func TestSimulatorConfig(t *testing.T) {
+	s, c := connect(t)
+	defer s.Close()
+	defer c.Close()
+	c.ready()
+
+	c.send("CONFIG", `"control:euler_angle_max"`, `"0.1"`)
+	c.send("CONFIG", `"control:control_vz_max"`, `"1000"`)
+	c.send("CONFIG", `"control:outdoor"`, `"TRUE"`)
+	eventually(t, "configuration", received(s, "CONFIG", 4))
+	settings := s.State().Settings
+	gobottest.Assert(t, settings.EulerAngleMax, 0.1)
+	gobottest.Assert(t, settings.ControlVzMax, 1000.0)
+	gobottest.Assert(t, settings.Outdoor, true)
+
+	c.send("REF", refTakeOff)
+	c.wait("take off", controlState(ControlStateHovering))
+	c.pcmd(0, 0, 1, 0)
+	c.wait("climb", func(n navdata) bool { return n.altitude > 1100 })
+	gobottest.Assert(t, s.State().Vz, 1.0)
+}
@@ -1,15 +1,22 @@
 package ardrone
 
-type testDrone struct{}
+type testDrone struct {
+	// moves holds the last speed of each movement
+	moves map[string]float64
+}
 
-func (t testDrone) Takeoff() bool              { return true }
-func (t testDrone) Land()                      {}
-func (t testDrone) Up(a float64)               {}
-func (t testDrone) Down(a float64)             {}
-func (t testDrone) Left(a float64)             {}
-func (t testDrone) Right(a float64)            {}
-func (t testDrone) Forward(a float64)          {}
-func (t testDrone) Backward(a float64)         {}
-func (t testDrone) Clockwise(a float64)        {}
-func (t testDrone) Counterclockwise(a float64) {}
-func (t testDrone) Hover()                     {}
+func newTestDrone() *testDrone {
+	return &testDrone{moves: map[string]float64{}}
+}
+
+func (t *testDrone) Takeoff() bool              { return true }
+func (t *testDrone) Land()                      {}
+func (t *testDrone) Up(a float64)               { t.moves["up"] = a }
+func (t *testDrone) Down(a float64)             { t.moves["down"] = a }
+func (t *testDrone) Left(a float64)             { t.moves["left"] = a }
+func (t *testDrone) Right(a float64)            { t.moves["right"] = a }
+func (t *testDrone) Forward(a float64)          { t.moves["forward"] = a }
+func (t *testDrone) Backward(a float64)         { t.moves["backward"] = a }
+func (t *testDrone) Clockwise(a float64)        { t.moves["clockwise"] = a }
+func (t *testDrone) Counterclockwise(a float64) { t.moves["counterclockwise"] = a }
+func (t *testDrone) Hover()                     {}
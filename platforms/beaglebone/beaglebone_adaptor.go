@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"github.com/talmai/gobot"
 	"github.com/talmai/gobot/platforms/gpio"
 	"github.com/talmai/gobot/platforms/i2c"
+	"github.com/talmai/gobot/platforms/spi"
 	"github.com/talmai/gobot/sysfs"
 )
 
@@ -24,16 +26,33 @@ var _ gpio.PwmWriter = (*BeagleboneAdaptor)(nil)
 var _ gpio.ServoWriter = (*BeagleboneAdaptor)(nil)
 
 var _ i2c.I2c = (*BeagleboneAdaptor)(nil)
+
+var _ spi.Spi = (*BeagleboneAdaptor)(nil)
 var _ i2c.I2cExtended = (*BeagleboneAdaptor)(nil)
+var _ i2c.I2cConnector = (*BeagleboneAdaptor)(nil)
 
 var slots = "/sys/devices/bone_capemgr.*"
 var ocp = "/sys/devices/ocp.*"
+var pwmChips = "/sys/devices/platform/ocp/%v/pwm/pwmchip*"
 var usrLed = "/sys/devices/ocp.3/gpio-leds.8/leds/beaglebone:green:"
 
 var glob = func(pattern string) (matches []string, err error) {
 	return filepath.Glob(pattern)
 }
 
+// configPin sets the pinmux of pin using the config-pin utility. Without
+// config-pin the pinmux is expected to be set by a device tree overlay.
+var configPin = func(pin string, mode string) (err error) {
+	out, err := exec.Command("config-pin", pin, mode).CombinedOutput()
+	if e, ok := err.(*exec.Error); ok && e.Err == exec.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("config-pin %v %v failed: %v %v", pin, mode, err, strings.TrimSpace(string(out)))
+	}
+	return
+}
+
 var pins = map[string]int{
 	"P8_3":  38,
 	"P8_4":  39,
@@ -102,18 +121,34 @@ var pins = map[string]int{
 	"P9_31": 110,
 }
 
-var pwmPins = map[string]string{
-	"P9_14": "P9_14",
-	"P9_21": "P9_21",
-	"P9_22": "P9_22",
-	"P9_29": "P9_29",
-	"P9_42": "P9_42",
-	"P8_13": "P8_13",
-	"P8_34": "P8_34",
-	"P8_45": "P8_45",
-	"P8_46": "P8_46",
+// pwmPin is the pwm chip, and the channel on that chip, driving a pin. The
+// chip is given by the directory of its pwm subsystem module.
+type pwmPin struct {
+	module  string
+	channel int
+}
+
+var pwmPins = map[string]pwmPin{
+	"P9_22": {"48300000.epwmss/48300200.*", 0},
+	"P9_31": {"48300000.epwmss/48300200.*", 0},
+	"P9_21": {"48300000.epwmss/48300200.*", 1},
+	"P9_29": {"48300000.epwmss/48300200.*", 1},
+	"P9_42": {"48300000.epwmss/48300100.ecap", 0},
+	"P9_14": {"48302000.epwmss/48302200.*", 0},
+	"P8_36": {"48302000.epwmss/48302200.*", 0},
+	"P9_16": {"48302000.epwmss/48302200.*", 1},
+	"P8_34": {"48302000.epwmss/48302200.*", 1},
+	"P8_19": {"48304000.epwmss/48304200.*", 0},
+	"P8_45": {"48304000.epwmss/48304200.*", 0},
+	"P8_13": {"48304000.epwmss/48304200.*", 1},
+	"P8_46": {"48304000.epwmss/48304200.*", 1},
+	"P9_28": {"48304000.epwmss/48304100.ecap", 0},
 }
 
+// DefaultPwmPeriod is the pwm period, in nanoseconds, used by PwmWrite
+// unless another frequency is set for the pin
+const DefaultPwmPeriod = 500000
+
 var analogPins = map[string]string{
 	"P9_39": "AIN0",
 	"P9_40": "AIN1",
@@ -128,10 +163,13 @@ var analogPins = map[string]string{
 type BeagleboneAdaptor struct {
 	name        string
 	digitalPins []sysfs.DigitalPin
-	pwmPins     map[string]*pwmPin
-	i2cDevice   sysfs.I2cDevice
+	pwmPins     map[string]sysfs.PwmPin
+	pwmPeriods  map[string]int
+	i2cBuses    map[int]*sysfs.I2cBus
+	spiDevices  map[string]sysfs.SpiDevice
 	ocp         string
 	helper      string
+	adc         *sysfs.IioDevice
 	slots       string
 }
 
@@ -140,13 +178,17 @@ func NewBeagleboneAdaptor(name string) *BeagleboneAdaptor {
 	b := &BeagleboneAdaptor{
 		name:        name,
 		digitalPins: make([]sysfs.DigitalPin, 120),
-		pwmPins:     make(map[string]*pwmPin),
+		pwmPins:     make(map[string]sysfs.PwmPin),
+		pwmPeriods:  make(map[string]int),
+		i2cBuses:    make(map[int]*sysfs.I2cBus),
+		spiDevices:  make(map[string]sysfs.SpiDevice),
 	}
 
 	g, _ := glob(ocp)
 	if len(g) > 0 {
 		b.ocp = g[0]
-		g, _ = glob(slots)
+	}
+	if g, _ = glob(slots); len(g) > 0 {
 		b.slots = fmt.Sprintf("%v/slots", g[0])
 	}
 	return b
@@ -157,21 +199,28 @@ func (b *BeagleboneAdaptor) Name() string { return b.name }
 
 func (b *BeagleboneAdaptor) IsPlatform() bool { return b.ocp != "" }
 
-// Connect initializes the pwm and analog dts.
+// Connect initializes the analog input, loading the analog dts on kernels
+// with a cape manager.
 func (b *BeagleboneAdaptor) Connect() (errs []error) {
-	if err := ensureSlot(b.slots, "cape-bone-iio"); err != nil {
-		return []error{err}
+	// newer kernels expose the adc as an iio device instead of the helper
+	if adc, err := sysfs.FindIioDevice("TI-am335x-adc"); err == nil {
+		b.adc = adc
+		return
 	}
 
-	if err := ensureSlot(b.slots, "am33xx_pwm"); err != nil {
+	if b.slots == "" {
+		return
+	}
+	if err := ensureSlot(b.slots, "cape-bone-iio"); err != nil {
 		return []error{err}
 	}
-
 	g, err := glob(fmt.Sprintf("%v/helper.*", b.ocp))
 	if err != nil {
 		return []error{err}
 	}
-	b.helper = g[0]
+	if len(g) > 0 {
+		b.helper = g[0]
+	}
 
 	return
 }
@@ -179,10 +228,11 @@ func (b *BeagleboneAdaptor) Connect() (errs []error) {
 // Finalize releases all i2c devices and exported analog, digital, pwm pins.
 func (b *BeagleboneAdaptor) Finalize() (errs []error) {
 	for _, pin := range b.pwmPins {
-		if pin != nil {
-			if err := pin.release(); err != nil {
-				errs = append(errs, err)
-			}
+		if err := pin.Enable(false); err != nil {
+			errs = append(errs, err)
+		}
+		if err := pin.Unexport(); err != nil {
+			errs = append(errs, err)
 		}
 	}
 	for _, pin := range b.digitalPins {
@@ -192,8 +242,13 @@ func (b *BeagleboneAdaptor) Finalize() (errs []error) {
 			}
 		}
 	}
-	if b.i2cDevice != nil {
-		if err := b.i2cDevice.Close(); err != nil {
+	for _, bus := range b.i2cBuses {
+		if err := bus.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, device := range b.spiDevices {
+		if err := device.Close(); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -202,18 +257,32 @@ func (b *BeagleboneAdaptor) Finalize() (errs []error) {
 
 // PwmWrite writes the 0-254 value to the specified pin
 func (b *BeagleboneAdaptor) PwmWrite(pin string, val byte) (err error) {
-	return b.pwmWrite(pin, val)
+	period := DefaultPwmPeriod
+	if v, ok := b.pwmPeriods[pin]; ok {
+		period = v
+	}
+	duty := gobot.FromScale(float64(val), 0, 255.0)
+	return b.pwmWrite(pin, period, int(float64(period)*duty))
+}
+
+// SetPwmFrequency sets the frequency, in Hz, used by PwmWrite on the
+// specified pin. It takes effect on the next PwmWrite.
+func (b *BeagleboneAdaptor) SetPwmFrequency(pin string, freq int) (err error) {
+	if _, err = b.translatePwmPin(pin); err != nil {
+		return
+	}
+	if freq <= 0 {
+		return errors.New("Not a valid frequency")
+	}
+	b.pwmPeriods[pin] = int(1e9 / freq)
+	return
 }
 
 // ServoWrite writes the 0-180 degree val to the specified pin.
 func (b *BeagleboneAdaptor) ServoWrite(pin string, val byte) (err error) {
-	i, err := b.pwmPin(pin)
-	if err != nil {
-		return err
-	}
 	period := 16666666.0
 	duty := (gobot.FromScale(float64(val), 0, 180.0) * 0.115) + 0.05
-	return b.pwmPins[i].pwmWrite(strconv.Itoa(int(period)), strconv.Itoa(int(period*duty)))
+	return b.pwmWrite(pin, int(period), int(period*duty))
 }
 
 // DigitalRead returns a digital value from specified pin
@@ -250,6 +319,9 @@ func (b *BeagleboneAdaptor) AnalogRead(pin string) (val int, err error) {
 	if err != nil {
 		return
 	}
+	if b.adc != nil {
+		return b.adc.ReadRaw("voltage" + strings.TrimPrefix(analogPin, "AIN"))
+	}
 	fi, err := sysfs.OpenFile(fmt.Sprintf("%v/%v", b.helper, analogPin), os.O_RDONLY, 0644)
 	defer fi.Close()
 
@@ -269,50 +341,216 @@ func (b *BeagleboneAdaptor) AnalogRead(pin string) (val int, err error) {
 
 // I2cStart starts a i2c device in specified address on i2c bus /dev/i2c-1
 func (b *BeagleboneAdaptor) I2cStart(address int) (err error) {
-	if b.i2cDevice == nil {
-		b.i2cDevice, err = sysfs.NewI2cDevice("/dev/i2c-1", address)
-	}
+	_, err = b.i2cDevice(address)
 	return
 }
 
+// I2cGetConnection returns a connection to the i2c device at address on the
+// bus /dev/i2c-N. The transactions of each connection are serialised with
+// those of the other devices on the bus.
+func (b *BeagleboneAdaptor) I2cGetConnection(address int, bus int) (connection i2c.I2cConnection, err error) {
+	device, err := b.i2cBus(bus).Device(address)
+	if err != nil {
+		return
+	}
+	return device, nil
+}
+
+// I2cDefaultBus returns the i2c bus used by drivers which do not specify one, 1
+func (b *BeagleboneAdaptor) I2cDefaultBus() int { return 1 }
+
+// i2cBus returns the i2c bus /dev/i2c-N, which is opened when its first
+// device is connected
+func (b *BeagleboneAdaptor) i2cBus(bus int) *sysfs.I2cBus {
+	if b.i2cBuses[bus] == nil {
+		b.i2cBuses[bus] = sysfs.NewI2cBus(fmt.Sprintf("/dev/i2c-%v", bus))
+	}
+	return b.i2cBuses[bus]
+}
+
+// i2cDevice returns the device at address on the default i2c bus
+func (b *BeagleboneAdaptor) i2cDevice(address int) (*sysfs.I2cBusDevice, error) {
+	return b.i2cBus(b.I2cDefaultBus()).Device(address)
+}
+
 // I2cWrite writes data to i2c device
 func (b *BeagleboneAdaptor) I2cWrite(address int, data []byte) (err error) {
-	if err = b.i2cDevice.SetAddress(address); err != nil {
+	device, err := b.i2cDevice(address)
+	if err != nil {
 		return
 	}
-	_, err = b.i2cDevice.Write(data)
+	_, err = device.Write(data)
 	return
 }
 
-// I2CWrite writes data to i2c device
+// I2cWriteWord writes a 16 bit value to a register of the i2c device
 func (b *BeagleboneAdaptor) I2cWriteWord(address int, register uint8, value uint16) (err error) {
-	if err = b.i2cDevice.SetAddress(address); err != nil {
+	device, err := b.i2cDevice(address)
+	if err != nil {
 		return
 	}
-	_, err = b.i2cDevice.WriteWord(register, value)
+	_, err = device.WriteWord(register, value)
 	return
 }
 
 // I2cRead returns size bytes from the i2c device
 func (b *BeagleboneAdaptor) I2cRead(address int, size int) (data []byte, err error) {
-	if err = b.i2cDevice.SetAddress(address); err != nil {
+	device, err := b.i2cDevice(address)
+	if err != nil {
 		return
 	}
 	data = make([]byte, size)
-	_, err = b.i2cDevice.Read(data)
+	_, err = device.Read(data)
 	return
 }
 
-// I2cRead returns value from i2c device using specified size
+// I2cReadRegister returns size bytes from the i2c device, address[0], starting
+// at the register address[1]
 func (b *BeagleboneAdaptor) I2cReadRegister(address []byte, size int) (data []byte, err error) {
-	if err = b.i2cDevice.SetAddress(int(address[0])); err != nil {
+	device, err := b.i2cDevice(int(address[0]))
+	if err != nil {
 		return
 	}
 	data = make([]byte, size)
-	_, err = b.i2cDevice.ReadRegister(address[1:], data)
+	_, err = device.ReadRegister(address[1], data)
 	return
 }
 
+// I2cWriteQuick sends the read/write bit to the i2c device without any data
+func (b *BeagleboneAdaptor) I2cWriteQuick(address int, bit uint8) (err error) {
+	device, err := b.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.WriteQuick(bit)
+}
+
+// I2cReadByte reads a byte from the i2c device without selecting a register
+func (b *BeagleboneAdaptor) I2cReadByte(address int) (value uint8, err error) {
+	device, err := b.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.ReadByte()
+}
+
+// I2cWriteByte writes a byte to the i2c device without selecting a register
+func (b *BeagleboneAdaptor) I2cWriteByte(address int, value uint8) (err error) {
+	device, err := b.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.WriteByte(value)
+}
+
+// I2cReadByteData reads a byte register of the i2c device
+func (b *BeagleboneAdaptor) I2cReadByteData(address int, register uint8) (value uint8, err error) {
+	device, err := b.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.ReadByteData(register)
+}
+
+// I2cWriteByteData writes a byte register of the i2c device
+func (b *BeagleboneAdaptor) I2cWriteByteData(address int, register uint8, value uint8) (err error) {
+	device, err := b.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.WriteByteData(register, value)
+}
+
+// I2cReadWordData reads a word register of the i2c device
+func (b *BeagleboneAdaptor) I2cReadWordData(address int, register uint8) (value uint16, err error) {
+	device, err := b.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.ReadWordData(register)
+}
+
+// I2cWriteWordData writes a word register of the i2c device
+func (b *BeagleboneAdaptor) I2cWriteWordData(address int, register uint8, value uint16) (err error) {
+	device, err := b.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.WriteWordData(register, value)
+}
+
+// I2cProcessCall writes a word to a register of the i2c device and reads
+// back a word
+func (b *BeagleboneAdaptor) I2cProcessCall(address int, register uint8, value uint16) (result uint16, err error) {
+	device, err := b.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.ProcessCall(register, value)
+}
+
+// I2cReadBlockData reads an SMBus block from a register of the i2c device
+func (b *BeagleboneAdaptor) I2cReadBlockData(address int, register uint8) (data []byte, err error) {
+	device, err := b.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	data = make([]byte, sysfs.I2C_SMBUS_BLOCK_MAX)
+	n, err := device.ReadBlockData(register, data)
+	return data[:n], err
+}
+
+// I2cWriteBlockData writes an SMBus block to a register of the i2c device
+func (b *BeagleboneAdaptor) I2cWriteBlockData(address int, register uint8, data []byte) (err error) {
+	device, err := b.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.WriteBlockData(register, data)
+}
+
+// I2cBlockProcessCall writes an SMBus block to a register of the i2c device
+// and reads back a block
+func (b *BeagleboneAdaptor) I2cBlockProcessCall(address int, register uint8, data []byte) (result []byte, err error) {
+	device, err := b.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	result = make([]byte, sysfs.I2C_SMBUS_BLOCK_MAX)
+	n, err := device.BlockProcessCall(register, data, result)
+	return result[:n], err
+}
+
+// I2cReadI2cBlockData returns size bytes from the i2c device starting at
+// register
+func (b *BeagleboneAdaptor) I2cReadI2cBlockData(address int, register uint8, size int) (data []byte, err error) {
+	device, err := b.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	data = make([]byte, size)
+	n, err := device.ReadI2cBlockData(register, data)
+	return data[:n], err
+}
+
+// I2cWriteI2cBlockData writes data to the i2c device starting at register
+func (b *BeagleboneAdaptor) I2cWriteI2cBlockData(address int, register uint8, data []byte) (err error) {
+	device, err := b.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.WriteI2cBlockData(register, data)
+}
+
+// I2cTx writes w to the i2c device and then reads len(r) bytes into r
+func (b *BeagleboneAdaptor) I2cTx(address int, w []byte, r []byte) (err error) {
+	device, err := b.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.Tx(w, r)
+}
+
 // translatePin converts digital pin name to pin position
 func (b *BeagleboneAdaptor) translatePin(pin string) (value int, err error) {
 	for key, value := range pins {
@@ -324,12 +562,10 @@ func (b *BeagleboneAdaptor) translatePin(pin string) (value int, err error) {
 	return
 }
 
-// translatePwmPin converts pwm pin name to pin position
-func (b *BeagleboneAdaptor) translatePwmPin(pin string) (value string, err error) {
-	for key, value := range pwmPins {
-		if key == pin {
-			return value, nil
-		}
+// translatePwmPin converts pwm pin name to its pwm chip and channel
+func (b *BeagleboneAdaptor) translatePwmPin(pin string) (value pwmPin, err error) {
+	if value, ok := pwmPins[pin]; ok {
+		return value, nil
 	}
 	err = errors.New("Not a valid pin")
 	return
@@ -365,34 +601,47 @@ func (b *BeagleboneAdaptor) digitalPin(pin string, dir string) (sysfsPin sysfs.D
 	return b.digitalPins[i], nil
 }
 
-// pwPin retrieves pwm pin value by name
-func (b *BeagleboneAdaptor) pwmPin(pin string) (i string, err error) {
-	i, err = b.translatePwmPin(pin)
+// pwmPin returns the exported pwm pin of the specified pin, setting its
+// pinmux the first time it is used
+func (b *BeagleboneAdaptor) pwmPin(pin string) (p sysfs.PwmPin, err error) {
+	if p, ok := b.pwmPins[pin]; ok {
+		return p, nil
+	}
+	i, err := b.translatePwmPin(pin)
 	if err != nil {
 		return
 	}
-	if b.pwmPins[i] == nil {
-		err = ensureSlot(b.slots, fmt.Sprintf("bone_pwm_%v", pin))
-		if err != nil {
-			return
-		}
-		b.pwmPins[i], err = newPwmPin(i, b.ocp)
-		if err != nil {
-			return
-		}
+	chips, err := glob(fmt.Sprintf(pwmChips, i.module))
+	if err != nil {
+		return
+	}
+	if len(chips) == 0 {
+		return nil, fmt.Errorf("No pwm chip found for pin %v, is its device tree overlay loaded?", pin)
 	}
+	if err = configPin(pin, "pwm"); err != nil {
+		return
+	}
+
+	p = sysfs.NewPwmPin(i.channel, chips[0])
+	if err = p.Export(); err != nil {
+		return
+	}
+	b.pwmPins[pin] = p
 	return
 }
 
-// pwmWrite writes pwm value to specified pin
-func (b *BeagleboneAdaptor) pwmWrite(pin string, val byte) (err error) {
-	i, err := b.pwmPin(pin)
+// pwmWrite sets the period and duty cycle, in nanoseconds, of the specified
+// pin and enables its output. A pwm output can not be enabled before it has
+// a period.
+func (b *BeagleboneAdaptor) pwmWrite(pin string, period int, duty int) (err error) {
+	p, err := b.pwmPin(pin)
 	if err != nil {
 		return
 	}
-	period := 500000.0
-	duty := gobot.FromScale(float64(val), 0, 255.0)
-	return b.pwmPins[i].pwmWrite(strconv.Itoa(int(period)), strconv.Itoa(int(period*duty)))
+	if err = sysfs.SetPwm(p, period, duty); err != nil {
+		return
+	}
+	return p.Enable(true)
 }
 
 func ensureSlot(slots, item string) (err error) {
@@ -427,3 +676,30 @@ func ensureSlot(slots, item string) (err error) {
 	}
 	return
 }
+
+// SpiStart opens the spi device /dev/spidevB.C given its bus and chip select
+func (b *BeagleboneAdaptor) SpiStart(bus int, chip int, mode int, bits int, maxSpeed int64) (err error) {
+	location := fmt.Sprintf("/dev/spidev%v.%v", bus, chip)
+	if b.spiDevices[location] != nil {
+		return
+	}
+	device, err := sysfs.NewSpiDevice(location, mode, bits, maxSpeed)
+	if err != nil {
+		return
+	}
+	b.spiDevices[location] = device
+	return
+}
+
+// SpiTransfer writes tx to the spi device while reading the same number of
+// bytes into rx
+func (b *BeagleboneAdaptor) SpiTransfer(bus int, chip int, tx []byte, rx []byte) (err error) {
+	device := b.spiDevices[fmt.Sprintf("/dev/spidev%v.%v", bus, chip)]
+	if device == nil {
+		return spi.ErrNotStarted
+	}
+	return device.Tx(tx, rx)
+}
+
+// SpiDefaultBus returns the spi bus of the SPI0 pins on header P9, 1
+func (b *BeagleboneAdaptor) SpiDefaultBus() int { return 1 }
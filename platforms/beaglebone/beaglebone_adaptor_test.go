@@ -6,35 +6,10 @@ import (
 	"testing"
 
 	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/platforms/spi"
 	"github.com/hybridgroup/gobot/sysfs"
 )
 
-type NullReadWriteCloser struct {
-	contents []byte
-}
-
-func (n *NullReadWriteCloser) SetAddress(int) error {
-	return nil
-}
-
-func (n *NullReadWriteCloser) Write(b []byte) (int, error) {
-	n.contents = make([]byte, len(b))
-	copy(n.contents[:], b[:])
-
-	return len(b), nil
-}
-
-func (n *NullReadWriteCloser) Read(b []byte) (int, error) {
-	copy(b, n.contents)
-	return len(b), nil
-}
-
-var closeErr error = nil
-
-func (n *NullReadWriteCloser) Close() error {
-	return closeErr
-}
-
 func TestBeagleboneAdaptor(t *testing.T) {
 	glob = func(pattern string) (matches []string, err error) {
 		return make([]string, 2), nil
@@ -46,11 +21,11 @@ func TestBeagleboneAdaptor(t *testing.T) {
 		"/sys/devices/ocp.3/gpio-leds.8/leds/beaglebone:green:usr1/brightness",
 		"/sys/devices/ocp.3/helper.5",
 		"/sys/devices/ocp.3/helper.5/AIN1",
-		"/sys/devices/ocp.3/pwm_test_P9_14.5",
-		"/sys/devices/ocp.3/pwm_test_P9_14.5/run",
-		"/sys/devices/ocp.3/pwm_test_P9_14.5/period",
-		"/sys/devices/ocp.3/pwm_test_P9_14.5/polarity",
-		"/sys/devices/ocp.3/pwm_test_P9_14.5/duty",
+		"/sys/devices/platform/ocp/48302000.epwmss/48302200.pwm/pwm/pwmchip3/export",
+		"/sys/devices/platform/ocp/48302000.epwmss/48302200.pwm/pwm/pwmchip3/unexport",
+		"/sys/devices/platform/ocp/48302000.epwmss/48302200.pwm/pwm/pwmchip3/pwm0/enable",
+		"/sys/devices/platform/ocp/48302000.epwmss/48302200.pwm/pwm/pwmchip3/pwm0/period",
+		"/sys/devices/platform/ocp/48302000.epwmss/48302200.pwm/pwm/pwmchip3/pwm0/duty_cycle",
 		"/sys/class/gpio/export",
 		"/sys/class/gpio/unexport",
 		"/sys/class/gpio/gpio60/value",
@@ -70,34 +45,42 @@ func TestBeagleboneAdaptor(t *testing.T) {
 
 	// PWM
 	glob = func(pattern string) (matches []string, err error) {
-		pattern = strings.TrimSuffix(pattern, "*")
-		return []string{pattern + "5"}, nil
+		if strings.Contains(pattern, "48302200") {
+			return []string{"/sys/devices/platform/ocp/48302000.epwmss/48302200.pwm/pwm/pwmchip3"}, nil
+		}
+		return []string{}, nil
 	}
+	configured := []string{}
+	configPin = func(pin string, mode string) error {
+		configured = append(configured, pin+" "+mode)
+		return nil
+	}
+	pwm := "/sys/devices/platform/ocp/48302000.epwmss/48302200.pwm/pwm/pwmchip3"
 
 	gobottest.Assert(t, a.PwmWrite("P9_99", 175), errors.New("Not a valid pin"))
-	a.PwmWrite("P9_14", 175)
-	gobottest.Assert(
-		t,
-		fs.Files["/sys/devices/ocp.3/pwm_test_P9_14.5/period"].Contents,
-		"500000",
-	)
-	gobottest.Assert(
-		t,
-		fs.Files["/sys/devices/ocp.3/pwm_test_P9_14.5/duty"].Contents,
-		"343137",
-	)
+	gobottest.Refute(t, a.PwmWrite("P9_22", 175), nil)
+
+	gobottest.Assert(t, a.PwmWrite("P9_14", 175), nil)
+	gobottest.Assert(t, configured, []string{"P9_14 pwm"})
+	gobottest.Assert(t, fs.Files[pwm+"/export"].Contents, "0")
+	gobottest.Assert(t, fs.Files[pwm+"/pwm0/period"].Contents, "500000")
+	gobottest.Assert(t, fs.Files[pwm+"/pwm0/duty_cycle"].Contents, "343137")
+	gobottest.Assert(t, fs.Files[pwm+"/pwm0/enable"].Contents, "1")
+
+	// the duty cycle is written first when it would exceed the period
+	fs.Files[pwm+"/pwm0/duty_cycle"].Contents = "343137\n"
+	gobottest.Assert(t, a.SetPwmFrequency("P9_14", 10000), nil)
+	gobottest.Assert(t, a.PwmWrite("P9_14", 255), nil)
+	gobottest.Assert(t, fs.Files[pwm+"/pwm0/period"].Contents, "100000")
+	gobottest.Assert(t, fs.Files[pwm+"/pwm0/duty_cycle"].Contents, "100000")
+	gobottest.Assert(t, fs.Files[pwm+"/pwm0/duty_cycle"].Seq < fs.Files[pwm+"/pwm0/period"].Seq, true)
+	gobottest.Assert(t, a.SetPwmFrequency("P9_14", 0), errors.New("Not a valid frequency"))
+	gobottest.Assert(t, a.SetPwmFrequency("P9_99", 50), errors.New("Not a valid pin"))
 
 	a.ServoWrite("P9_14", 100)
-	gobottest.Assert(
-		t,
-		fs.Files["/sys/devices/ocp.3/pwm_test_P9_14.5/period"].Contents,
-		"16666666",
-	)
-	gobottest.Assert(
-		t,
-		fs.Files["/sys/devices/ocp.3/pwm_test_P9_14.5/duty"].Contents,
-		"1898148",
-	)
+	gobottest.Assert(t, fs.Files[pwm+"/pwm0/period"].Contents, "16666666")
+	gobottest.Assert(t, fs.Files[pwm+"/pwm0/duty_cycle"].Contents, "1898148")
+	gobottest.Assert(t, len(configured), 1)
 
 	// Analog
 	fs.Files["/sys/devices/ocp.3/helper.5/AIN1"].Contents = "567\n"
@@ -107,6 +90,12 @@ func TestBeagleboneAdaptor(t *testing.T) {
 	i, err := a.AnalogRead("P9_99")
 	gobottest.Assert(t, err, errors.New("Not a valid pin"))
 
+	fs.Add("/sys/bus/iio/devices/iio:device0/in_voltage1_raw").Contents = "1234\n"
+	a.adc = sysfs.NewIioDevice("/sys/bus/iio/devices/iio:device0")
+	i, _ = a.AnalogRead("P9_40")
+	gobottest.Assert(t, i, 1234)
+	a.adc = nil
+
 	// DigitalIO
 	a.DigitalWrite("usr1", 1)
 	gobottest.Assert(t,
@@ -127,11 +116,36 @@ func TestBeagleboneAdaptor(t *testing.T) {
 	sysfs.SetSyscall(&sysfs.MockSyscall{})
 	a.I2cStart(0xff)
 
-	a.i2cDevice = &NullReadWriteCloser{}
-
 	a.I2cWrite(0xff, []byte{0x00, 0x01})
 	data, _ := a.I2cRead(0xff, 2)
 	gobottest.Assert(t, data, []byte{0x00, 0x01})
+	gobottest.Assert(t, a.I2cWriteWordData(0xff, 0x01, 0x0203), nil)
+	data, _ = a.I2cReadI2cBlockData(0xff, 0x04, 1)
+	gobottest.Assert(t, data, []byte{0x04})
+
+	gobottest.Assert(t, len(a.Finalize()), 0)
+	gobottest.Assert(t, fs.Files[pwm+"/pwm0/enable"].Contents, "0")
+	gobottest.Assert(t, fs.Files[pwm+"/unexport"].Contents, "0")
+}
+
+func TestBeagleboneAdaptorSpi(t *testing.T) {
+	glob = func(pattern string) (matches []string, err error) {
+		return []string{}, nil
+	}
+	a := NewBeagleboneAdaptor("myAdaptor")
+	fs := sysfs.NewMockFilesystem([]string{
+		"/dev/spidev1.0",
+	})
+	sysfs.SetFilesystem(fs)
+	sysfs.SetSyscall(&sysfs.MockSyscall{})
+
+	gobottest.Assert(t, a.SpiDefaultBus(), 1)
+	gobottest.Assert(t, a.SpiTransfer(1, 0, []byte{0x01}, nil), spi.ErrNotStarted)
+	gobottest.Refute(t, a.SpiStart(1, 1, spi.Mode0, 8, 1000000), nil)
 
+	gobottest.Assert(t, a.SpiStart(1, 0, spi.Mode0, 8, 1000000), nil)
+	gobottest.Assert(t, a.SpiStart(1, 0, spi.Mode0, 8, 1000000), nil)
+	gobottest.Assert(t, len(a.spiDevices), 1)
+	gobottest.Assert(t, a.SpiTransfer(1, 0, []byte{0x01}, make([]byte, 1)), nil)
 	gobottest.Assert(t, len(a.Finalize()), 0)
 }
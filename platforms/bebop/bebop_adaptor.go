@@ -1,6 +1,8 @@
 package bebop
 
 import (
+	"time"
+
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/platforms/bebop/client"
 )
@@ -22,10 +24,31 @@ type drone interface {
 	Stop() error
 	Connect() error
 	Video() chan []byte
+	SubscribeVideo(size int) *client.VideoSubscription
+	VideoStats() client.VideoStats
 	StartRecording() error
 	StopRecording() error
 	HullProtection(protect bool) error
 	Outdoor(outdoor bool) error
+	FlatTrim() error
+	Emergency() error
+	Flip(direction client.FlipDirection) error
+	MoveBy(dx, dy, dz, dpsi float64) error
+	NavigateHome(start bool) error
+	HomeType(home int) error
+	ReturnHomeDelay(delay time.Duration) error
+	MaxAltitude(altitude float64) error
+	MaxTilt(tilt float64) error
+	MaxVerticalSpeed(speed float64) error
+	MaxRotationSpeed(speed float64) error
+	CameraOrientation(tilt int, pan int) error
+	TakePicture() error
+	VideoResolution(resolution int) error
+	VideoFramerate(framerate int) error
+	RequireGPSFix(require bool)
+	State() client.State
+	OnEvent(f func(client.Event))
+	Close() error
 }
 
 // BebopAdaptor is gobot.Adaptor representation for the Bebop
@@ -59,5 +82,10 @@ func (a *BebopAdaptor) Connect() (errs []error) {
 	return
 }
 
-// Finalize terminates the connection to the ardrone
-func (a *BebopAdaptor) Finalize() (errs []error) { return }
+// Finalize terminates the connection to the bebop
+func (a *BebopAdaptor) Finalize() (errs []error) {
+	if err := a.drone.Close(); err != nil {
+		return []error{err}
+	}
+	return
+}
@@ -1,10 +1,50 @@
 package bebop
 
 import (
+	"time"
+
 	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/bebop/client"
+	"github.com/hybridgroup/gobot/platforms/flight"
+)
+
+var _ flight.Drone = (*BebopDriver)(nil)
+
+// Events of the state of the drone, published with the new value
+const (
+	// Battery event, with the remaining charge in percent
+	Battery = client.Battery
+	// FlyingState event, with a client.FlyingStateValue
+	FlyingState = client.FlyingState
+	// Alert event, with a client.AlertValue
+	Alert = client.Alert
+	// NavigateHome event, with a client.NavigateHomeValue
+	NavigateHome = client.NavigateHome
+	// Altitude event, with the altitude above the take off point in meters
+	Altitude = client.Altitude
+	// Attitude event, with a client.AttitudeValue
+	Attitude = client.Attitude
+	// Speed event, with a client.SpeedValue
+	Speed = client.Speed
+	// Position event, with the client.PositionValue of the GPS
+	Position = client.Position
+	// GPSFix event, with whether the GPS has a fix
+	GPSFix = client.GPSFix
+	// WifiSignal event, with the RSSI of the wifi in dBm
+	WifiSignal = client.WifiSignal
+	// VideoState event, with the client.MediaValue of the video recording
+	VideoState = client.VideoState
+	// PictureState event, with the client.MediaValue of the picture capture
+	PictureState = client.PictureState
+	// PictureTaken event, with a client.MediaValue whose State is 0 when the
+	// picture was taken and 1 when it failed
+	PictureTaken = client.PictureTaken
 )
 
-var _ gobot.Driver = (*BebopDriver)(nil)
+var stateEvents = []string{
+	Battery, FlyingState, Alert, NavigateHome, Altitude, Attitude, Speed,
+	Position, GPSFix, WifiSignal, VideoState, PictureState, PictureTaken,
+}
 
 // BebopDriver is gobot.Driver representation for the Bebop
 type BebopDriver struct {
@@ -14,6 +54,14 @@ type BebopDriver struct {
 }
 
 // NewBebopDriver creates an BebopDriver with specified name.
+//
+// It adds the following events:
+//	"flying" - Sent with the result of TakeOff
+//	"battery", "flyingstate", "alert", "navigatehome", "altitude",
+//	"attitude", "speed", "position", "gpsfix", "wifisignal", "videostate",
+//	"picturestate" and "picturetaken" - Sent with the new value when the
+//	state of the drone changes, see the constants of the same names
+//	"flightstate" - Sent with the flight.State when the flying state changes
 func NewBebopDriver(connection *BebopAdaptor, name string) *BebopDriver {
 	d := &BebopDriver{
 		name:       name,
@@ -21,6 +69,10 @@ func NewBebopDriver(connection *BebopAdaptor, name string) *BebopDriver {
 		Eventer:    gobot.NewEventer(),
 	}
 	d.AddEvent("flying")
+	d.AddEvent(flight.FlightState)
+	for _, event := range stateEvents {
+		d.AddEvent(event)
+	}
 	return d
 }
 
@@ -35,24 +87,97 @@ func (a *BebopDriver) adaptor() *BebopAdaptor {
 	return a.Connection().(*BebopAdaptor)
 }
 
-// Start starts the BebopDriver
+// Start starts the BebopDriver, which publishes the changes of the state of
+// the drone from then on
 func (a *BebopDriver) Start() (errs []error) {
+	a.adaptor().drone.OnEvent(func(e client.Event) {
+		gobot.Publish(a.Event(e.Name), e.Data)
+		if e.Name == client.FlyingState {
+			gobot.Publish(a.Event(flight.FlightState), flightState(e.Data.(client.FlyingStateValue)))
+		}
+	})
 	return
 }
 
 // Halt halts the BebopDriver
 func (a *BebopDriver) Halt() (errs []error) {
+	a.adaptor().drone.OnEvent(nil)
 	return
 }
 
+// State returns the last known state of the drone
+func (a *BebopDriver) State() client.State {
+	return a.adaptor().drone.State()
+}
+
+// FlightState returns the last known flight state of the drone
+func (a *BebopDriver) FlightState() flight.State {
+	return flightState(a.State().FlyingState)
+}
+
+// Battery returns the last known remaining battery in percent
+func (a *BebopDriver) Battery() int {
+	return a.State().Battery
+}
+
+// flightState returns the flight.State of the flying state s
+func flightState(s client.FlyingStateValue) flight.State {
+	switch s {
+	case client.TakingOff, client.UserTakeOff, client.MotorRamping:
+		return flight.TakingOff
+	case client.Hovering:
+		return flight.Hovering
+	case client.Flying:
+		return flight.Flying
+	case client.Landing:
+		return flight.Landing
+	case client.Emergency, client.EmergencyLanding:
+		return flight.Emergency
+	}
+	return flight.Landed
+}
+
 // TakeOff makes the drone start flying
-func (a *BebopDriver) TakeOff() {
-	gobot.Publish(a.Event("flying"), a.adaptor().drone.TakeOff())
+func (a *BebopDriver) TakeOff() error {
+	err := a.adaptor().drone.TakeOff()
+	gobot.Publish(a.Event("flying"), err)
+	return err
 }
 
 // Land causes the drone to land
-func (a *BebopDriver) Land() {
-	a.adaptor().drone.Land()
+func (a *BebopDriver) Land() error {
+	return a.adaptor().drone.Land()
+}
+
+// Hover makes the drone hover in place, same as Stop
+func (a *BebopDriver) Hover() error {
+	return a.adaptor().drone.Stop()
+}
+
+// Move moves the drone along all axes at once, see flight.Drone. The values
+// are in the range -1 to 1.
+func (a *BebopDriver) Move(roll, pitch, yaw, throttle float64) error {
+	drone := a.adaptor().drone
+	axes := []struct {
+		value              float64
+		positive, negative func(int) error
+	}{
+		{roll, drone.Right, drone.Left},
+		{pitch, drone.Forward, drone.Backward},
+		{yaw, drone.Clockwise, drone.CounterClockwise},
+		{throttle, drone.Up, drone.Down},
+	}
+	for _, axis := range axes {
+		speed := int(flight.Clamp(axis.value) * 100)
+		move := axis.positive
+		if speed < 0 {
+			speed, move = -speed, axis.negative
+		}
+		if err := move(speed); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Up makes the drone gain altitude.
@@ -115,6 +240,18 @@ func (a *BebopDriver) Video() chan []byte {
 	return a.adaptor().drone.Video()
 }
 
+// SubscribeVideo returns a subscription to the H.264 frames of the video,
+// which buffers size frames. Each subscription receives all the frames it
+// keeps up with.
+func (a *BebopDriver) SubscribeVideo(size int) *client.VideoSubscription {
+	return a.adaptor().drone.SubscribeVideo(size)
+}
+
+// VideoStats returns the frame loss and latency statistics of the video
+func (a *BebopDriver) VideoStats() client.VideoStats {
+	return a.adaptor().drone.VideoStats()
+}
+
 // StartRecording starts the recording video to the drones interal storage
 func (a *BebopDriver) StartRecording() error {
 	return a.adaptor().drone.StartRecording()
@@ -134,3 +271,105 @@ func (a *BebopDriver) HullProtection(protect bool) error {
 func (a *BebopDriver) Outdoor(outdoor bool) error {
 	return a.adaptor().drone.Outdoor(outdoor)
 }
+
+// FlatTrim calibrates the drone, which must be on a flat surface
+func (a *BebopDriver) FlatTrim() error {
+	return a.adaptor().drone.FlatTrim()
+}
+
+// Emergency cuts the motors of the drone, whatever it is doing
+func (a *BebopDriver) Emergency() error {
+	return a.adaptor().drone.Emergency()
+}
+
+// FrontFlip makes the drone flip forward
+func (a *BebopDriver) FrontFlip() error {
+	return a.adaptor().drone.Flip(client.FlipFront)
+}
+
+// BackFlip makes the drone flip backward
+func (a *BebopDriver) BackFlip() error {
+	return a.adaptor().drone.Flip(client.FlipBack)
+}
+
+// RightFlip makes the drone flip to the right
+func (a *BebopDriver) RightFlip() error {
+	return a.adaptor().drone.Flip(client.FlipRight)
+}
+
+// LeftFlip makes the drone flip to the left
+func (a *BebopDriver) LeftFlip() error {
+	return a.adaptor().drone.Flip(client.FlipLeft)
+}
+
+// MoveBy moves the hovering drone by dx meters forward, dy meters to the
+// right and dz meters down, and turns it by dpsi radians clockwise
+func (a *BebopDriver) MoveBy(dx, dy, dz, dpsi float64) error {
+	return a.adaptor().drone.MoveBy(dx, dy, dz, dpsi)
+}
+
+// NavigateHome starts or stops the return of the drone to its home
+func (a *BebopDriver) NavigateHome(start bool) error {
+	return a.adaptor().drone.NavigateHome(start)
+}
+
+// HomeType sets the home the drone returns to, client.HomeTakeOff or
+// client.HomePilot
+func (a *BebopDriver) HomeType(home int) error {
+	return a.adaptor().drone.HomeType(home)
+}
+
+// ReturnHomeDelay sets the delay after which the drone returns home when the
+// connection is lost
+func (a *BebopDriver) ReturnHomeDelay(delay time.Duration) error {
+	return a.adaptor().drone.ReturnHomeDelay(delay)
+}
+
+// MaxAltitude sets the maximum altitude of the drone, in meters
+func (a *BebopDriver) MaxAltitude(altitude float64) error {
+	return a.adaptor().drone.MaxAltitude(altitude)
+}
+
+// MaxTilt sets the maximum tilt of the drone, in degrees
+func (a *BebopDriver) MaxTilt(tilt float64) error {
+	return a.adaptor().drone.MaxTilt(tilt)
+}
+
+// MaxVerticalSpeed sets the maximum vertical speed of the drone, in m/s
+func (a *BebopDriver) MaxVerticalSpeed(speed float64) error {
+	return a.adaptor().drone.MaxVerticalSpeed(speed)
+}
+
+// MaxRotationSpeed sets the maximum rotation speed of the drone, in degrees/s
+func (a *BebopDriver) MaxRotationSpeed(speed float64) error {
+	return a.adaptor().drone.MaxRotationSpeed(speed)
+}
+
+// CameraOrientation points the camera, tilt and pan are in degrees
+func (a *BebopDriver) CameraOrientation(tilt int, pan int) error {
+	return a.adaptor().drone.CameraOrientation(tilt, pan)
+}
+
+// TakePicture takes a picture to the drones internal storage. The
+// "picturetaken" event tells whether it succeeded.
+func (a *BebopDriver) TakePicture() error {
+	return a.adaptor().drone.TakePicture()
+}
+
+// VideoResolution sets the resolutions of the recording and of the stream,
+// client.VideoRec1080Stream480 or client.VideoRec720Stream720
+func (a *BebopDriver) VideoResolution(resolution int) error {
+	return a.adaptor().drone.VideoResolution(resolution)
+}
+
+// VideoFramerate sets the framerate of the video, client.Framerate24,
+// client.Framerate25 or client.Framerate30
+func (a *BebopDriver) VideoFramerate(framerate int) error {
+	return a.adaptor().drone.VideoFramerate(framerate)
+}
+
+// RequireGPSFix makes TakeOff and NavigateHome fail with client.ErrNoGPSFix
+// while the GPS of the drone has no fix
+func (a *BebopDriver) RequireGPSFix(require bool) {
+	a.adaptor().drone.RequireGPSFix(require)
+}
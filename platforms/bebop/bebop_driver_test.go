@@ -0,0 +1,107 @@
+package bebop
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/platforms/bebop/client"
+	"github.com/hybridgroup/gobot/platforms/flight"
+)
+
+func initTestBebopDriver() (*BebopDriver, *testDrone) {
+	a := initTestBebopAdaptor()
+	d := NewBebopDriver(a, "drone")
+	a.Connect()
+	return d, a.drone.(*testDrone)
+}
+
+func TestBebopDriver(t *testing.T) {
+	d, _ := initTestBebopDriver()
+	gobottest.Assert(t, d.Name(), "drone")
+	gobottest.Assert(t, d.Connection().Name(), "bot")
+}
+
+func TestBebopDriverEvents(t *testing.T) {
+	d, drone := initTestBebopDriver()
+	gobottest.Assert(t, len(d.Start()), 0)
+
+	sem := make(chan interface{})
+	gobot.On(d.Event(FlyingState), func(data interface{}) {
+		sem <- data
+	})
+	drone.handler(client.Event{Name: client.FlyingState, Data: client.Hovering})
+
+	select {
+	case data := <-sem:
+		gobottest.Assert(t, data, client.Hovering)
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("FlyingState event was not published")
+	}
+
+	gobottest.Assert(t, len(d.Halt()), 0)
+	gobottest.Assert(t, drone.handler == nil, true)
+}
+
+func TestBebopDriverFlightStateEvent(t *testing.T) {
+	d, drone := initTestBebopDriver()
+	d.Start()
+
+	sem := make(chan interface{})
+	gobot.On(d.Event(flight.FlightState), func(data interface{}) {
+		sem <- data
+	})
+	drone.handler(client.Event{Name: client.FlyingState, Data: client.MotorRamping})
+
+	select {
+	case data := <-sem:
+		gobottest.Assert(t, data, flight.TakingOff)
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("FlightState event was not published")
+	}
+}
+
+func TestBebopDriverState(t *testing.T) {
+	d, drone := initTestBebopDriver()
+	drone.state = client.State{Battery: 42, FlyingState: client.Flying}
+	gobottest.Assert(t, d.State().Battery, 42)
+	gobottest.Assert(t, d.State().FlyingState, client.Flying)
+	gobottest.Assert(t, d.Battery(), 42)
+	gobottest.Assert(t, d.FlightState(), flight.Flying)
+
+	drone.state.FlyingState = client.EmergencyLanding
+	gobottest.Assert(t, d.FlightState(), flight.Emergency)
+}
+
+func TestBebopDriverMove(t *testing.T) {
+	d, drone := initTestBebopDriver()
+	gobottest.Assert(t, d.Move(0.5, -0.25, -2, 1), nil)
+	gobottest.Assert(t, drone.pcmd, client.Pcmd{Roll: 50, Pitch: -25, Yaw: -100, Gaz: 100})
+
+	gobottest.Assert(t, d.Hover(), nil)
+	gobottest.Assert(t, drone.pcmd, client.Pcmd{Flag: 1})
+}
+
+func TestBebopDriverCommands(t *testing.T) {
+	d, drone := initTestBebopDriver()
+	gobottest.Assert(t, d.FlatTrim(), nil)
+	gobottest.Assert(t, d.Emergency(), nil)
+	gobottest.Assert(t, d.FrontFlip(), nil)
+	gobottest.Assert(t, d.MoveBy(1, 0, -0.5, 0), nil)
+	gobottest.Assert(t, d.NavigateHome(true), nil)
+	gobottest.Assert(t, d.ReturnHomeDelay(30*time.Second), nil)
+	gobottest.Assert(t, d.MaxAltitude(20), nil)
+	gobottest.Assert(t, d.CameraOrientation(-30, 0), nil)
+	gobottest.Assert(t, d.TakePicture(), nil)
+	gobottest.Assert(t, d.VideoResolution(client.VideoRec720Stream720), nil)
+
+	d.RequireGPSFix(true)
+	gobottest.Assert(t, drone.requireGPSFix, true)
+}
+
+func TestBebopDriverVideoStats(t *testing.T) {
+	d, drone := initTestBebopDriver()
+	drone.videoStats = client.VideoStats{Frames: 30, Lost: 2}
+	gobottest.Assert(t, d.VideoStats(), client.VideoStats{Frames: 30, Lost: 2})
+}
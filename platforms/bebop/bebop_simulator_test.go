@@ -0,0 +1,80 @@
+package bebop
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/platforms/bebop/client"
+	"github.com/hybridgroup/gobot/platforms/bebop/simulator"
+	"github.com/hybridgroup/gobot/platforms/flight"
+)
+
+// initSimulatedBebopDriver returns a started simulator, and a started driver
+// of an adaptor connected to it
+func initSimulatedBebopDriver(t *testing.T) (*simulator.Simulator, *BebopDriver) {
+	s := simulator.New()
+	s.Interval = 10 * time.Millisecond
+	gobottest.Assert(t, s.Start("127.0.0.1"), nil)
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	gobottest.Assert(t, err, nil)
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	conn.Close()
+
+	a := NewBebopAdaptor("bot")
+	drone := a.drone.(*client.Bebop)
+	drone.IP = "127.0.0.1"
+	drone.DiscoveryPort = s.DiscoveryPort
+	drone.D2cPort = port
+
+	d := NewBebopDriver(a, "drone")
+	gobottest.Assert(t, len(a.Connect()), 0)
+	gobottest.Assert(t, len(d.Start()), 0)
+	return s, d
+}
+
+// waitFlightState waits for the flight state from states
+func waitFlightState(t *testing.T, states chan interface{}, state flight.State) {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case data := <-states:
+			if data == state {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("flight state %v was not published", state)
+		}
+	}
+}
+
+func TestBebopDriverSimulated(t *testing.T) {
+	s, d := initSimulatedBebopDriver(t)
+	defer s.Close()
+
+	states := make(chan interface{}, 100)
+	gobot.On(d.Event(flight.FlightState), func(data interface{}) {
+		states <- data
+	})
+
+	gobottest.Assert(t, d.TakeOff(), nil)
+	waitFlightState(t, states, flight.Hovering)
+	gobottest.Assert(t, s.State().Altitude, 1.0)
+
+	gobottest.Assert(t, d.Move(0, 0.5, 0, 0), nil)
+	waitFlightState(t, states, flight.Flying)
+	gobottest.Assert(t, d.Hover(), nil)
+	waitFlightState(t, states, flight.Hovering)
+	gobottest.Assert(t, s.State().North > 0, true)
+
+	gobottest.Assert(t, d.Land(), nil)
+	waitFlightState(t, states, flight.Landed)
+	gobottest.Assert(t, d.FlightState(), flight.Landed)
+
+	gobottest.Assert(t, len(d.Halt()), 0)
+	gobottest.Assert(t, len(d.adaptor().Finalize()), 0)
+	gobottest.Assert(t, d.TakeOff(), client.ErrClosed)
+}
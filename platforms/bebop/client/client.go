@@ -3,11 +3,17 @@ package client
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 )
 
+// ErrClosed is returned by the commands sent after Close
+var ErrClosed = errors.New("Connection to the drone is closed")
+
 func validatePitch(val int) int {
 	if val > 100 {
 		return 100
@@ -18,14 +24,6 @@ func validatePitch(val int) int {
 	return val
 }
 
-type tmpFrame struct {
-	arstreamACK   ARStreamACK
-	fragments     map[int][]byte
-	frame         []byte
-	waitForIframe bool
-	frameFlags    int
-}
-
 type ARStreamACK struct {
 	FrameNumber    int
 	HighPacketsAck uint64
@@ -86,6 +84,20 @@ type NetworkFrame struct {
 	Data []byte
 }
 
+// splitNetworkFrames returns the frames of a datagram, which may hold several
+// of them. A truncated frame ends the datagram.
+func splitNetworkFrames(buf []byte) (frames []NetworkFrame) {
+	for len(buf) >= 7 {
+		size := int(binary.LittleEndian.Uint32(buf[3:7]))
+		if size < 7 || size > len(buf) {
+			break
+		}
+		frames = append(frames, NewNetworkFrame(buf[:size]))
+		buf = buf[size:]
+	}
+	return
+}
+
 func NewNetworkFrame(buf []byte) NetworkFrame {
 	frame := NetworkFrame{
 		Type: int(buf[0]),
@@ -116,10 +128,14 @@ func networkFrameGenerator() func(*bytes.Buffer, byte, byte) *bytes.Buffer {
 
 	// each frame id has it's own sequence number
 	seq := make(map[byte]byte)
+	var mutex sync.Mutex
 
 	hlen := 7 // size of ARNETWORKAL_Frame_t header
 
 	return func(cmd *bytes.Buffer, frameType byte, id byte) *bytes.Buffer {
+		mutex.Lock()
+		defer mutex.Unlock()
+
 		if _, ok := seq[id]; !ok {
 			seq[id] = 0
 		}
@@ -158,7 +174,6 @@ type Bebop struct {
 	IP                    string
 	NavData               map[string]string
 	Pcmd                  Pcmd
-	tmpFrame              tmpFrame
 	C2dPort               int
 	D2cPort               int
 	DiscoveryPort         int
@@ -167,11 +182,20 @@ type Bebop struct {
 	discoveryClient       *net.TCPConn
 	networkFrameGenerator func(*bytes.Buffer, byte, byte) *bytes.Buffer
 	video                 chan []byte
+	videoStream           *videoStream
 	writeChan             chan []byte
+	state                 *droneState
+	pcmdLock              sync.Mutex
+	ackLock               sync.Mutex
+	acks                  chan NetworkFrame
+	ackTimeout            time.Duration
+	ackRetries            int
+	done                  chan struct{}
+	closeOnce             sync.Once
 }
 
 func New() *Bebop {
-	return &Bebop{
+	b := &Bebop{
 		IP:                    "192.168.42.1",
 		NavData:               make(map[string]string),
 		C2dPort:               54321,
@@ -186,15 +210,24 @@ func New() *Bebop {
 			Gaz:   0,
 			Psi:   0,
 		},
-		tmpFrame:  tmpFrame{},
-		video:     make(chan []byte),
-		writeChan: make(chan []byte),
+		writeChan:  make(chan []byte),
+		state:      newDroneState(),
+		acks:       make(chan NetworkFrame, 10),
+		ackTimeout: 150 * time.Millisecond,
+		ackRetries: 5,
 	}
+	b.video = make(chan []byte)
+	b.videoStream = newVideoStream(b.video)
+	return b
 }
 
 func (b *Bebop) write(buf []byte) (int, error) {
-	b.writeChan <- buf
-	return 0, nil
+	select {
+	case b.writeChan <- buf:
+		return 0, nil
+	case <-b.done:
+		return 0, ErrClosed
+	}
 }
 
 func (b *Bebop) Discover() error {
@@ -212,22 +245,31 @@ func (b *Bebop) Discover() error {
 
 	b.discoveryClient.Write(
 		[]byte(
-			`{
+			fmt.Sprintf(`{
 			"controller_type": "computer",
 			"controller_name": "go-bebop",
-			"d2c_port": "43210"
-			}`,
+			"d2c_port": "%d"
+			}`, b.D2cPort),
 		),
 	)
 
 	data := make([]byte, 10240)
 
-	_, err = b.discoveryClient.Read(data)
+	n, err := b.discoveryClient.Read(data)
 
 	if err != nil {
 		return err
 	}
 
+	// the drone tells the port it receives the commands on, the JSON is
+	// terminated by a null byte
+	var response struct {
+		C2dPort int `json:"c2d_port"`
+	}
+	if json.Unmarshal(bytes.TrimRight(data[:n], "\x00"), &response) == nil && response.C2dPort > 0 {
+		b.C2dPort = response.C2dPort
+	}
+
 	return b.discoveryClient.Close()
 }
 
@@ -260,12 +302,20 @@ func (b *Bebop) Connect() error {
 		return err
 	}
 
+	done := make(chan struct{})
+	b.done = done
+
 	go func() {
 		for {
-			_, err := b.c2dClient.Write(<-b.writeChan)
+			select {
+			case buf := <-b.writeChan:
+				_, err := b.c2dClient.Write(buf)
 
-			if err != nil {
-				fmt.Println(err)
+				if err != nil {
+					fmt.Println(err)
+				}
+			case <-done:
+				return
 			}
 		}
 	}()
@@ -275,6 +325,11 @@ func (b *Bebop) Connect() error {
 			data := make([]byte, 40960)
 			i, _, err := b.d2cClient.ReadFromUDP(data)
 			if err != nil {
+				select {
+				case <-done:
+					return
+				default:
+				}
 				fmt.Println("d2cClient error:", err)
 			}
 
@@ -285,9 +340,16 @@ func (b *Bebop) Connect() error {
 	// send pcmd values at 40hz
 	go func() {
 		// wait a little bit so that there is enough time to get some ACKs
-		<-time.After(500 * time.Millisecond)
+		select {
+		case <-time.After(500 * time.Millisecond):
+		case <-done:
+			return
+		}
 		for {
 			_, err := b.write(b.generatePcmd().Bytes())
+			if err == ErrClosed {
+				return
+			}
 			if err != nil {
 				fmt.Println("pcmd c2dClient.Write", err)
 			}
@@ -305,6 +367,23 @@ func (b *Bebop) Connect() error {
 	return nil
 }
 
+// Close stops sending commands to the drone, and closes the connection
+func (b *Bebop) Close() (err error) {
+	if b.done == nil {
+		return nil
+	}
+	b.closeOnce.Do(func() {
+		close(b.done)
+		if e := b.c2dClient.Close(); e != nil {
+			err = e
+		}
+		if e := b.d2cClient.Close(); e != nil {
+			err = e
+		}
+	})
+	return
+}
+
 func (b *Bebop) FlatTrim() error {
 	//
 	// ARCOMMANDS_Generator_GenerateARDrone3PilotingFlatTrim
@@ -320,8 +399,7 @@ func (b *Bebop) FlatTrim() error {
 
 	cmd.Write(tmp.Bytes())
 
-	_, err := b.write(b.networkFrameGenerator(cmd, ARNETWORKAL_FRAME_TYPE_DATA, BD_NET_CD_NONACK_ID).Bytes())
-	return err
+	return b.sendWithAck(cmd)
 }
 
 func (b *Bebop) GenerateAllStates() error {
@@ -339,8 +417,7 @@ func (b *Bebop) GenerateAllStates() error {
 
 	cmd.Write(tmp.Bytes())
 
-	_, err := b.write(b.networkFrameGenerator(cmd, ARNETWORKAL_FRAME_TYPE_DATA, BD_NET_CD_NONACK_ID).Bytes())
-	return err
+	return b.sendWithAck(cmd)
 }
 
 func (b *Bebop) TakeOff() error {
@@ -348,6 +425,10 @@ func (b *Bebop) TakeOff() error {
 	//  ARCOMMANDS_Generator_GenerateARDrone3PilotingTakeOff
 	//
 
+	if err := b.checkGPSFix(); err != nil {
+		return err
+	}
+
 	cmd := &bytes.Buffer{}
 
 	cmd.WriteByte(ARCOMMANDS_ID_PROJECT_ARDRONE3)
@@ -358,8 +439,7 @@ func (b *Bebop) TakeOff() error {
 
 	cmd.Write(tmp.Bytes())
 
-	_, err := b.write(b.networkFrameGenerator(cmd, ARNETWORKAL_FRAME_TYPE_DATA, BD_NET_CD_NONACK_ID).Bytes())
-	return err
+	return b.sendWithAck(cmd)
 }
 
 func (b *Bebop) Land() error {
@@ -377,51 +457,68 @@ func (b *Bebop) Land() error {
 
 	cmd.Write(tmp.Bytes())
 
-	_, err := b.write(b.networkFrameGenerator(cmd, ARNETWORKAL_FRAME_TYPE_DATA, BD_NET_CD_NONACK_ID).Bytes())
-	return err
+	return b.sendWithAck(cmd)
 }
 
 func (b *Bebop) Up(val int) error {
+	b.pcmdLock.Lock()
 	b.Pcmd.Gaz = validatePitch(val)
+	b.pcmdLock.Unlock()
 	return nil
 }
 
 func (b *Bebop) Down(val int) error {
+	b.pcmdLock.Lock()
 	b.Pcmd.Gaz = validatePitch(val) * -1
+	b.pcmdLock.Unlock()
 	return nil
 }
 
 func (b *Bebop) Forward(val int) error {
+	b.pcmdLock.Lock()
 	b.Pcmd.Pitch = validatePitch(val)
+	b.pcmdLock.Unlock()
 	return nil
 }
 
 func (b *Bebop) Backward(val int) error {
+	b.pcmdLock.Lock()
 	b.Pcmd.Pitch = validatePitch(val) * -1
+	b.pcmdLock.Unlock()
 	return nil
 }
 
 func (b *Bebop) Right(val int) error {
+	b.pcmdLock.Lock()
 	b.Pcmd.Roll = validatePitch(val)
+	b.pcmdLock.Unlock()
 	return nil
 }
 
 func (b *Bebop) Left(val int) error {
+	b.pcmdLock.Lock()
 	b.Pcmd.Roll = validatePitch(val) * -1
+	b.pcmdLock.Unlock()
 	return nil
 }
 
 func (b *Bebop) Clockwise(val int) error {
+	b.pcmdLock.Lock()
 	b.Pcmd.Yaw = validatePitch(val)
+	b.pcmdLock.Unlock()
 	return nil
 }
 
 func (b *Bebop) CounterClockwise(val int) error {
+	b.pcmdLock.Lock()
 	b.Pcmd.Yaw = validatePitch(val) * -1
+	b.pcmdLock.Unlock()
 	return nil
 }
 
 func (b *Bebop) Stop() error {
+	b.pcmdLock.Lock()
+	defer b.pcmdLock.Unlock()
 	b.Pcmd = Pcmd{
 		Flag:  1,
 		Roll:  0,
@@ -447,6 +544,10 @@ func (b *Bebop) generatePcmd() *bytes.Buffer {
 	//         controlling device (deg) [-180;180]
 	//
 
+	b.pcmdLock.Lock()
+	pcmd := b.Pcmd
+	b.pcmdLock.Unlock()
+
 	cmd := &bytes.Buffer{}
 	tmp := &bytes.Buffer{}
 
@@ -458,27 +559,27 @@ func (b *Bebop) generatePcmd() *bytes.Buffer {
 	cmd.Write(tmp.Bytes())
 
 	tmp = &bytes.Buffer{}
-	binary.Write(tmp, binary.LittleEndian, uint8(b.Pcmd.Flag))
+	binary.Write(tmp, binary.LittleEndian, uint8(pcmd.Flag))
 	cmd.Write(tmp.Bytes())
 
 	tmp = &bytes.Buffer{}
-	binary.Write(tmp, binary.LittleEndian, int8(b.Pcmd.Roll))
+	binary.Write(tmp, binary.LittleEndian, int8(pcmd.Roll))
 	cmd.Write(tmp.Bytes())
 
 	tmp = &bytes.Buffer{}
-	binary.Write(tmp, binary.LittleEndian, int8(b.Pcmd.Pitch))
+	binary.Write(tmp, binary.LittleEndian, int8(pcmd.Pitch))
 	cmd.Write(tmp.Bytes())
 
 	tmp = &bytes.Buffer{}
-	binary.Write(tmp, binary.LittleEndian, int8(b.Pcmd.Yaw))
+	binary.Write(tmp, binary.LittleEndian, int8(pcmd.Yaw))
 	cmd.Write(tmp.Bytes())
 
 	tmp = &bytes.Buffer{}
-	binary.Write(tmp, binary.LittleEndian, int8(b.Pcmd.Gaz))
+	binary.Write(tmp, binary.LittleEndian, int8(pcmd.Gaz))
 	cmd.Write(tmp.Bytes())
 
 	tmp = &bytes.Buffer{}
-	binary.Write(tmp, binary.LittleEndian, uint32(b.Pcmd.Psi))
+	binary.Write(tmp, binary.LittleEndian, uint32(pcmd.Psi))
 	cmd.Write(tmp.Bytes())
 
 	return b.networkFrameGenerator(cmd, ARNETWORKAL_FRAME_TYPE_DATA, BD_NET_CD_NONACK_ID)
@@ -507,11 +608,19 @@ func (b *Bebop) createPong(frame NetworkFrame) *bytes.Buffer {
 }
 
 func (b *Bebop) packetReceiver(buf []byte) {
-	frame := NewNetworkFrame(buf)
+	for _, frame := range splitNetworkFrames(buf) {
+		b.frameReceiver(frame)
+	}
+}
 
+func (b *Bebop) frameReceiver(frame NetworkFrame) {
 	//
 	// libARNetwork/Sources/ARNETWORK_Receiver.c#ARNETWORK_Receiver_ThreadRun
 	//
+	if frame.Type == int(ARNETWORKAL_FRAME_TYPE_ACK) {
+		b.ackReceiver(frame)
+	}
+
 	if frame.Type == int(ARNETWORKAL_FRAME_TYPE_DATA_WITH_ACK) {
 		ack := b.createAck(frame).Bytes()
 		_, err := b.write(ack)
@@ -521,8 +630,14 @@ func (b *Bebop) packetReceiver(buf []byte) {
 		}
 	}
 
+	if (frame.Type == int(ARNETWORKAL_FRAME_TYPE_DATA) ||
+		frame.Type == int(ARNETWORKAL_FRAME_TYPE_DATA_WITH_ACK)) &&
+		(frame.Id == int(BD_NET_DC_NAVDATA_ID) || frame.Id == int(BD_NET_DC_EVENT_ID)) {
+		b.decodeCommand(frame.Data)
+	}
+
 	if frame.Type == int(ARNETWORKAL_FRAME_TYPE_DATA_LOW_LATENCY) &&
-		frame.Id == int(BD_NET_DC_VIDEO_DATA_ID) {
+		frame.Id == int(BD_NET_DC_VIDEO_DATA_ID) && len(frame.Data) >= 5 {
 
 		arstreamFrame := NewARStreamFrame(frame.Data)
 
@@ -546,17 +661,11 @@ func (b *Bebop) packetReceiver(buf []byte) {
 }
 
 func (b *Bebop) StartRecording() error {
-	buf := b.videoRecord(ARCOMMANDS_ARDRONE3_MEDIARECORD_VIDEO_RECORD_START)
-
-	b.write(b.networkFrameGenerator(buf, ARNETWORKAL_FRAME_TYPE_DATA, BD_NET_CD_NONACK_ID).Bytes())
-	return nil
+	return b.sendWithAck(b.videoRecord(ARCOMMANDS_ARDRONE3_MEDIARECORD_VIDEO_RECORD_START))
 }
 
 func (b *Bebop) StopRecording() error {
-	buf := b.videoRecord(ARCOMMANDS_ARDRONE3_MEDIARECORD_VIDEO_RECORD_STOP)
-
-	b.write(b.networkFrameGenerator(buf, ARNETWORKAL_FRAME_TYPE_DATA, BD_NET_CD_NONACK_ID).Bytes())
-	return nil
+	return b.sendWithAck(b.videoRecord(ARCOMMANDS_ARDRONE3_MEDIARECORD_VIDEO_RECORD_STOP))
 }
 
 func (b *Bebop) videoRecord(state byte) *bytes.Buffer {
@@ -587,6 +696,8 @@ func (b *Bebop) videoRecord(state byte) *bytes.Buffer {
 	return cmd
 }
 
+// Video returns a channel of the Annex-B frames of the video stream, which
+// drops the frames while nobody receives them. SubscribeVideo does not.
 func (b *Bebop) Video() chan []byte {
 	return b.video
 }
@@ -613,8 +724,7 @@ func (b *Bebop) HullProtection(protect bool) error {
 	binary.Write(tmp, binary.LittleEndian, bool(protect))
 	cmd.Write(tmp.Bytes())
 
-	_, err := b.write(b.networkFrameGenerator(cmd, ARNETWORKAL_FRAME_TYPE_DATA, BD_NET_CD_NONACK_ID).Bytes())
-	return err
+	return b.sendWithAck(cmd)
 }
 
 func (b *Bebop) Outdoor(outdoor bool) error {
@@ -639,90 +749,5 @@ func (b *Bebop) Outdoor(outdoor bool) error {
 	binary.Write(tmp, binary.LittleEndian, bool(outdoor))
 	cmd.Write(tmp.Bytes())
 
-	_, err := b.write(b.networkFrameGenerator(cmd, ARNETWORKAL_FRAME_TYPE_DATA, BD_NET_CD_NONACK_ID).Bytes())
-	return err
-}
-
-func (b *Bebop) createARStreamACK(frame ARStreamFrame) *bytes.Buffer {
-	//
-	// ARSTREAM_NetworkHeaders_AckPacket_t;
-	//
-	// uint16_t frameNumber;    // id of the current frame
-	// uint64_t highPacketsAck; // Upper 64 packets bitfield
-	// uint64_t lowPacketsAck;  // Lower 64 packets bitfield
-	//
-	// libARStream/Sources/ARSTREAM_NetworkHeaders.c#ARSTREAM_NetworkHeaders_AckPacketSetFlag
-	//
-
-	//
-	// each bit in the highPacketsAck and lowPacketsAck correspond to the
-	// fragmentsPerFrame which have been received per frameNumber, so time to
-	// flip some bits!
-	//
-	if frame.FrameNumber != b.tmpFrame.arstreamACK.FrameNumber {
-		if len(b.tmpFrame.fragments) > 0 {
-			emit := false
-
-			// if we missed some frames, wait for the next iframe
-			if frame.FrameNumber != b.tmpFrame.arstreamACK.FrameNumber+1 {
-				b.tmpFrame.waitForIframe = true
-			}
-
-			// if it's an iframe
-			if b.tmpFrame.frameFlags == 1 {
-				b.tmpFrame.waitForIframe = false
-				emit = true
-			} else if !b.tmpFrame.waitForIframe {
-				emit = true
-			}
-
-			if emit {
-				skip := false
-
-				for i := 0; i < len(b.tmpFrame.fragments); i++ {
-					// check if any fragments are missing
-					if len(b.tmpFrame.fragments[i]) == 0 {
-						skip = true
-						break
-					}
-					b.tmpFrame.frame = append(b.tmpFrame.frame, b.tmpFrame.fragments[i]...)
-				}
-
-				if !skip {
-					select {
-					case b.video <- b.tmpFrame.frame:
-					default:
-					}
-				}
-			}
-		}
-
-		b.tmpFrame.fragments = make(map[int][]byte)
-		b.tmpFrame.frame = []byte{}
-		b.tmpFrame.arstreamACK.FrameNumber = frame.FrameNumber
-		b.tmpFrame.frameFlags = frame.FrameFlags
-	}
-	b.tmpFrame.fragments[frame.FragmentNumber] = frame.Frame
-
-	if frame.FragmentNumber < 64 {
-		b.tmpFrame.arstreamACK.LowPacketsAck |= uint64(1) << uint64(frame.FragmentNumber)
-	} else {
-		b.tmpFrame.arstreamACK.HighPacketsAck |= uint64(1) << uint64(frame.FragmentNumber-64)
-	}
-
-	ackPacket := &bytes.Buffer{}
-	tmp := &bytes.Buffer{}
-
-	binary.Write(tmp, binary.LittleEndian, uint16(b.tmpFrame.arstreamACK.FrameNumber))
-	ackPacket.Write(tmp.Bytes())
-
-	tmp = &bytes.Buffer{}
-	binary.Write(tmp, binary.LittleEndian, uint64(b.tmpFrame.arstreamACK.HighPacketsAck))
-	ackPacket.Write(tmp.Bytes())
-
-	tmp = &bytes.Buffer{}
-	binary.Write(tmp, binary.LittleEndian, uint64(b.tmpFrame.arstreamACK.LowPacketsAck))
-	ackPacket.Write(tmp.Bytes())
-
-	return b.networkFrameGenerator(ackPacket, ARNETWORKAL_FRAME_TYPE_DATA, BD_NET_CD_VIDEO_ACK_ID)
+	return b.sendWithAck(cmd)
 }
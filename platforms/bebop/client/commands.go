@@ -0,0 +1,300 @@
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNoAck is returned when the drone did not acknowledge a command
+	ErrNoAck = errors.New("Command was not acknowledged by the drone")
+	// ErrNoGPSFix is returned by the commands guarded by RequireGPSFix while
+	// the GPS of the drone has no fix
+	ErrNoGPSFix = errors.New("GPS has no fix")
+)
+
+// FlipDirection is the direction of a flip
+type FlipDirection int
+
+// eARCOMMANDS_ARDRONE3_ANIMATIONS_FLIP_DIRECTION
+const (
+	FlipFront FlipDirection = iota
+	FlipBack
+	FlipRight
+	FlipLeft
+)
+
+// eARCOMMANDS_ARDRONE3_GPSSETTINGS_HOMETYPE_TYPE
+const (
+	// HomeTakeOff returns the drone to its take off position
+	HomeTakeOff = 0
+	// HomePilot returns the drone to the position sent by the controller
+	HomePilot = 1
+)
+
+// eARCOMMANDS_ARDRONE3_PICTURESETTINGS_VIDEORESOLUTIONS_TYPE
+const (
+	// VideoRec1080Stream480 records in 1080p and streams in 480p
+	VideoRec1080Stream480 = 0
+	// VideoRec720Stream720 records and streams in 720p
+	VideoRec720Stream720 = 1
+)
+
+// eARCOMMANDS_ARDRONE3_PICTURESETTINGS_VIDEOFRAMERATE_FRAMERATE
+const (
+	Framerate24 = 0
+	Framerate25 = 1
+	Framerate30 = 2
+)
+
+// newCommand returns an ARCommand of project, class and id, followed by the
+// little endian args
+func newCommand(project byte, class byte, id uint16, args ...interface{}) *bytes.Buffer {
+	cmd := &bytes.Buffer{}
+
+	cmd.WriteByte(project)
+	cmd.WriteByte(class)
+	binary.Write(cmd, binary.LittleEndian, id)
+
+	for _, arg := range args {
+		binary.Write(cmd, binary.LittleEndian, arg)
+	}
+
+	return cmd
+}
+
+// sendWithAck sends cmd on the buffer of acknowledged commands, and waits
+// for the drone to acknowledge it
+func (b *Bebop) sendWithAck(cmd *bytes.Buffer) error {
+	return b.sendOnAckBuffer(cmd, BD_NET_CD_ACK_ID)
+}
+
+// sendOnAckBuffer sends cmd on the acknowledged buffer id, and sends it
+// again until the drone acknowledges it or the retries are exhausted. The
+// commands of all the acknowledged buffers are sent one at a time.
+func (b *Bebop) sendOnAckBuffer(cmd *bytes.Buffer, id byte) error {
+	b.ackLock.Lock()
+	defer b.ackLock.Unlock()
+
+	frame := b.networkFrameGenerator(cmd, ARNETWORKAL_FRAME_TYPE_DATA_WITH_ACK, id).Bytes()
+	ack := NetworkFrame{
+		Type: int(ARNETWORKAL_FRAME_TYPE_ACK),
+		Id:   int(uint16(id) + ARNETWORKAL_MANAGER_DEFAULT_ID_MAX/2),
+		Seq:  int(frame[2]),
+	}
+
+	// forget the acks of the previous commands
+	for len(b.acks) > 0 {
+		<-b.acks
+	}
+
+	for try := 0; try <= b.ackRetries; try++ {
+		if _, err := b.write(frame); err != nil {
+			return err
+		}
+		timeout := time.After(b.ackTimeout)
+	wait:
+		for {
+			select {
+			case f := <-b.acks:
+				if f.Id == ack.Id && len(f.Data) > 0 && int(f.Data[0]) == ack.Seq {
+					return nil
+				}
+			case <-timeout:
+				break wait
+			}
+		}
+	}
+	return ErrNoAck
+}
+
+// ackReceiver hands an ack frame to the command waiting for it
+func (b *Bebop) ackReceiver(frame NetworkFrame) {
+	select {
+	case b.acks <- frame:
+	default:
+	}
+}
+
+// RequireGPSFix makes TakeOff and NavigateHome fail with ErrNoGPSFix while
+// the GPS of the drone has no fix
+func (b *Bebop) RequireGPSFix(require bool) {
+	b.state.Lock()
+	defer b.state.Unlock()
+	b.state.requireGPSFix = require
+}
+
+// checkGPSFix returns ErrNoGPSFix when a fix is required and the GPS has none
+func (b *Bebop) checkGPSFix() error {
+	b.state.Lock()
+	defer b.state.Unlock()
+	if b.state.requireGPSFix && !b.state.state.GPSFix {
+		return ErrNoGPSFix
+	}
+	return nil
+}
+
+// Emergency cuts the motors of the drone, whatever it is doing
+func (b *Bebop) Emergency() error {
+	//
+	// ARCOMMANDS_Generator_GenerateARDrone3PilotingEmergency
+	//
+	cmd := newCommand(ARCOMMANDS_ID_PROJECT_ARDRONE3,
+		ARCOMMANDS_ID_ARDRONE3_CLASS_PILOTING,
+		uint16(ARCOMMANDS_ID_ARDRONE3_PILOTING_CMD_EMERGENCY),
+	)
+	return b.sendOnAckBuffer(cmd, BD_NET_CD_EMERGENCY_ID)
+}
+
+// Flip makes the drone flip in direction
+func (b *Bebop) Flip(direction FlipDirection) error {
+	//
+	// ARCOMMANDS_Generator_GenerateARDrone3AnimationsFlip
+	//
+	// uint32 - direction
+	//
+	return b.sendWithAck(newCommand(ARCOMMANDS_ID_PROJECT_ARDRONE3,
+		ARCOMMANDS_ID_ARDRONE3_CLASS_ANIMATIONS,
+		uint16(ARCOMMANDS_ID_ARDRONE3_ANIMATIONS_CMD_FLIP),
+		uint32(direction),
+	))
+}
+
+// MoveBy moves the drone by dx meters forward, dy meters to the right and
+// dz meters down, and turns it by dpsi radians clockwise. The drone must be
+// hovering.
+func (b *Bebop) MoveBy(dx, dy, dz, dpsi float64) error {
+	//
+	// ARCOMMANDS_Generator_GenerateARDrone3PilotingmoveBy
+	//
+	// float - dX
+	// float - dY
+	// float - dZ
+	// float - dPsi
+	//
+	return b.sendWithAck(newCommand(ARCOMMANDS_ID_PROJECT_ARDRONE3,
+		ARCOMMANDS_ID_ARDRONE3_CLASS_PILOTING,
+		uint16(ARCOMMANDS_ID_ARDRONE3_PILOTING_CMD_MOVEBY),
+		float32(dx), float32(dy), float32(dz), float32(dpsi),
+	))
+}
+
+// NavigateHome starts or stops the return of the drone to its home
+func (b *Bebop) NavigateHome(start bool) error {
+	//
+	// ARCOMMANDS_Generator_GenerateARDrone3PilotingNavigateHome
+	//
+	// uint8 - start
+	//
+	if start {
+		if err := b.checkGPSFix(); err != nil {
+			return err
+		}
+	}
+	return b.sendWithAck(newCommand(ARCOMMANDS_ID_PROJECT_ARDRONE3,
+		ARCOMMANDS_ID_ARDRONE3_CLASS_PILOTING,
+		uint16(ARCOMMANDS_ID_ARDRONE3_PILOTING_CMD_NAVIGATEHOME),
+		start,
+	))
+}
+
+// HomeType sets the home the drone returns to, HomeTakeOff or HomePilot
+func (b *Bebop) HomeType(home int) error {
+	return b.sendWithAck(newCommand(ARCOMMANDS_ID_PROJECT_ARDRONE3,
+		ARCOMMANDS_ID_ARDRONE3_CLASS_GPSSETTINGS,
+		uint16(ARCOMMANDS_ID_ARDRONE3_GPSSETTINGS_CMD_HOMETYPE),
+		uint32(home),
+	))
+}
+
+// ReturnHomeDelay sets the delay after which the drone returns home when
+// the connection is lost
+func (b *Bebop) ReturnHomeDelay(delay time.Duration) error {
+	return b.sendWithAck(newCommand(ARCOMMANDS_ID_PROJECT_ARDRONE3,
+		ARCOMMANDS_ID_ARDRONE3_CLASS_GPSSETTINGS,
+		uint16(ARCOMMANDS_ID_ARDRONE3_GPSSETTINGS_CMD_RETURNHOMEDELAY),
+		uint16(delay/time.Second),
+	))
+}
+
+// MaxAltitude sets the maximum altitude of the drone, in meters
+func (b *Bebop) MaxAltitude(altitude float64) error {
+	return b.sendWithAck(newCommand(ARCOMMANDS_ID_PROJECT_ARDRONE3,
+		ARCOMMANDS_ID_ARDRONE3_CLASS_PILOTINGSETTINGS,
+		uint16(ARCOMMANDS_ID_ARDRONE3_PILOTINGSETTINGS_CMD_MAXALTITUDE),
+		float32(altitude),
+	))
+}
+
+// MaxTilt sets the maximum tilt of the drone, in degrees
+func (b *Bebop) MaxTilt(tilt float64) error {
+	return b.sendWithAck(newCommand(ARCOMMANDS_ID_PROJECT_ARDRONE3,
+		ARCOMMANDS_ID_ARDRONE3_CLASS_PILOTINGSETTINGS,
+		uint16(ARCOMMANDS_ID_ARDRONE3_PILOTINGSETTINGS_CMD_MAXTILT),
+		float32(tilt),
+	))
+}
+
+// MaxVerticalSpeed sets the maximum vertical speed of the drone, in m/s
+func (b *Bebop) MaxVerticalSpeed(speed float64) error {
+	return b.sendWithAck(newCommand(ARCOMMANDS_ID_PROJECT_ARDRONE3,
+		ARCOMMANDS_ID_ARDRONE3_CLASS_SPEEDSETTINGS,
+		uint16(ARCOMMANDS_ID_ARDRONE3_SPEEDSETTINGS_CMD_MAXVERTICALSPEED),
+		float32(speed),
+	))
+}
+
+// MaxRotationSpeed sets the maximum rotation speed of the drone, in
+// degrees/s
+func (b *Bebop) MaxRotationSpeed(speed float64) error {
+	return b.sendWithAck(newCommand(ARCOMMANDS_ID_PROJECT_ARDRONE3,
+		ARCOMMANDS_ID_ARDRONE3_CLASS_SPEEDSETTINGS,
+		uint16(ARCOMMANDS_ID_ARDRONE3_SPEEDSETTINGS_CMD_MAXROTATIONSPEED),
+		float32(speed),
+	))
+}
+
+// CameraOrientation points the camera, tilt and pan are in degrees
+func (b *Bebop) CameraOrientation(tilt int, pan int) error {
+	//
+	// ARCOMMANDS_Generator_GenerateARDrone3CameraOrientation
+	//
+	// int8 - tilt
+	// int8 - pan
+	//
+	return b.sendWithAck(newCommand(ARCOMMANDS_ID_PROJECT_ARDRONE3,
+		ARCOMMANDS_ID_ARDRONE3_CLASS_CAMERA,
+		uint16(ARCOMMANDS_ID_ARDRONE3_CAMERA_CMD_ORIENTATION),
+		int8(tilt), int8(pan),
+	))
+}
+
+// TakePicture takes a picture to the internal storage of the drone
+func (b *Bebop) TakePicture() error {
+	return b.sendWithAck(newCommand(ARCOMMANDS_ID_PROJECT_ARDRONE3,
+		ARCOMMANDS_ID_ARDRONE3_CLASS_MEDIARECORD,
+		uint16(ARCOMMANDS_ID_ARDRONE3_MEDIARECORD_CMD_PICTUREV2),
+	))
+}
+
+// VideoResolution sets the resolutions of the recording and of the stream,
+// VideoRec1080Stream480 or VideoRec720Stream720
+func (b *Bebop) VideoResolution(resolution int) error {
+	return b.sendWithAck(newCommand(ARCOMMANDS_ID_PROJECT_ARDRONE3,
+		ARCOMMANDS_ID_ARDRONE3_CLASS_PICTURESETTINGS,
+		uint16(ARCOMMANDS_ID_ARDRONE3_PICTURESETTINGS_CMD_VIDEORESOLUTIONS),
+		uint32(resolution),
+	))
+}
+
+// VideoFramerate sets the framerate of the video, Framerate24, Framerate25
+// or Framerate30
+func (b *Bebop) VideoFramerate(framerate int) error {
+	return b.sendWithAck(newCommand(ARCOMMANDS_ID_PROJECT_ARDRONE3,
+		ARCOMMANDS_ID_ARDRONE3_CLASS_PICTURESETTINGS,
+		uint16(ARCOMMANDS_ID_ARDRONE3_PICTURESETTINGS_CMD_VIDEOFRAMERATE),
+		uint32(framerate),
+	))
+}
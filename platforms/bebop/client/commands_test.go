@@ -0,0 +1,100 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// ackFrame returns the frame by which the drone acknowledges the frame seq
+// of the buffer id
+func ackFrame(id byte, seq byte) []byte {
+	return []byte{0x01, id + 128, 0x00, 0x08, 0x00, 0x00, 0x00, seq}
+}
+
+// acker acknowledges each frame written by b, and returns them on frames
+func acker(b *Bebop, written chan []byte) chan []byte {
+	frames := make(chan []byte, 100)
+	go func() {
+		for frame := range written {
+			frames <- frame
+			b.packetReceiver(ackFrame(frame[1], frame[2]))
+		}
+	}()
+	return frames
+}
+
+func TestSendWithAck(t *testing.T) {
+	b, written := newTestBebop()
+	frames := acker(b, written)
+
+	gobottest.Assert(t, b.MoveBy(1, 0, -0.5, 0), nil)
+	gobottest.Assert(t, <-frames, []byte{
+		0x04, 0x0b, 0x01, 0x1b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x07, 0x00,
+		0x00, 0x00, 0x80, 0x3f, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xbf,
+		0x00, 0x00, 0x00, 0x00,
+	})
+
+	gobottest.Assert(t, b.Flip(FlipLeft), nil)
+	gobottest.Assert(t, <-frames, []byte{
+		0x04, 0x0b, 0x02, 0x0f, 0x00, 0x00, 0x00, 0x01, 0x05, 0x00, 0x00,
+		0x03, 0x00, 0x00, 0x00,
+	})
+
+	gobottest.Assert(t, b.CameraOrientation(-30, 10), nil)
+	gobottest.Assert(t, <-frames, []byte{
+		0x04, 0x0b, 0x03, 0x0d, 0x00, 0x00, 0x00, 0x01, 0x01, 0x00, 0x00,
+		0xe2, 0x0a,
+	})
+
+	gobottest.Assert(t, b.ReturnHomeDelay(2*time.Minute), nil)
+	gobottest.Assert(t, <-frames, []byte{
+		0x04, 0x0b, 0x04, 0x0d, 0x00, 0x00, 0x00, 0x01, 0x17, 0x04, 0x00,
+		0x78, 0x00,
+	})
+
+	// the emergency has its own buffer
+	gobottest.Assert(t, b.Emergency(), nil)
+	gobottest.Assert(t, <-frames, []byte{
+		0x04, 0x0c, 0x01, 0x0b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x04, 0x00,
+	})
+}
+
+func TestSendWithAckRetries(t *testing.T) {
+	b, written := newTestBebop()
+	b.ackTimeout = 5 * time.Millisecond
+	b.ackRetries = 2
+
+	// the first frame is lost, the second one is acknowledged
+	go func() {
+		<-written
+		frame := <-written
+		b.packetReceiver(ackFrame(frame[1], frame[2]))
+	}()
+	gobottest.Assert(t, b.TakePicture(), nil)
+
+	// an ack of another frame is ignored
+	go func() {
+		for frame := range written {
+			b.packetReceiver(ackFrame(frame[1], frame[2]+1))
+		}
+	}()
+	gobottest.Assert(t, b.MaxAltitude(20), ErrNoAck)
+}
+
+func TestRequireGPSFix(t *testing.T) {
+	b, written := newTestBebop()
+	acker(b, written)
+
+	gobottest.Assert(t, b.TakeOff(), nil)
+
+	b.RequireGPSFix(true)
+	gobottest.Assert(t, b.TakeOff(), ErrNoGPSFix)
+	gobottest.Assert(t, b.NavigateHome(true), ErrNoGPSFix)
+	gobottest.Assert(t, b.NavigateHome(false), nil)
+
+	b.packetReceiver(gpsFixFrame)
+	gobottest.Assert(t, b.TakeOff(), nil)
+	gobottest.Assert(t, b.NavigateHome(true), nil)
+}
@@ -109,7 +109,23 @@ const (
 	ARCOMMANDS_ID_ARDRONE3_PILOTING_CMD_EMERGENCY       byte = 4
 	ARCOMMANDS_ID_ARDRONE3_PILOTING_CMD_NAVIGATEHOME    byte = 5
 	ARCOMMANDS_ID_ARDRONE3_PILOTING_CMD_AUTOTAKEOFFMODE byte = 6
-	ARCOMMANDS_ID_ARDRONE3_PILOTING_CMD_MAX             byte = 7
+	ARCOMMANDS_ID_ARDRONE3_PILOTING_CMD_MOVEBY          byte = 7
+	ARCOMMANDS_ID_ARDRONE3_PILOTING_CMD_MAX             byte = 8
+
+	// eARCOMMANDS_ID_ARDRONE3_CAMERA_CMD
+	ARCOMMANDS_ID_ARDRONE3_CAMERA_CMD_ORIENTATION byte = 0
+
+	// eARCOMMANDS_ID_ARDRONE3_PILOTINGSETTINGS_CMD
+	ARCOMMANDS_ID_ARDRONE3_PILOTINGSETTINGS_CMD_MAXALTITUDE byte = 0
+	ARCOMMANDS_ID_ARDRONE3_PILOTINGSETTINGS_CMD_MAXTILT     byte = 1
+
+	// eARCOMMANDS_ID_ARDRONE3_GPSSETTINGS_CMD
+	ARCOMMANDS_ID_ARDRONE3_GPSSETTINGS_CMD_HOMETYPE        byte = 3
+	ARCOMMANDS_ID_ARDRONE3_GPSSETTINGS_CMD_RETURNHOMEDELAY byte = 4
+
+	// eARCOMMANDS_ID_ARDRONE3_PICTURESETTINGS_CMD
+	ARCOMMANDS_ID_ARDRONE3_PICTURESETTINGS_CMD_VIDEOFRAMERATE   byte = 8
+	ARCOMMANDS_ID_ARDRONE3_PICTURESETTINGS_CMD_VIDEORESOLUTIONS byte = 9
 
 	// eARCOMMANDS_ID_ARDRONE3_MEDIARECORD_CMD
 	ARCOMMANDS_ID_ARDRONE3_MEDIARECORD_CMD_PICTURE   byte = 0
@@ -138,9 +154,25 @@ const (
 	ARCOMMANDS_ID_COMMON_COMMONSTATE_CMD_CURRENTDATECHANGED                  byte = 4
 	ARCOMMANDS_ID_COMMON_COMMONSTATE_CMD_CURRENTTIMECHANGED                  byte = 5
 	ARCOMMANDS_ID_COMMON_COMMONSTATE_CMD_MASSSTORAGEINFOREMAININGLISTCHANGED byte = 6
-	ARCOMMANDS_ID_COMMON_COMMONSTATE_CMD_WIFISIGNALCHANGED                   byte = 6
-	ARCOMMANDS_ID_COMMON_COMMONSTATE_CMD_SENSORSSTATESLISTCHANGED            byte = 7
-	ARCOMMANDS_ID_COMMON_COMMONSTATE_CMD_MAX                                 byte = 8
+	ARCOMMANDS_ID_COMMON_COMMONSTATE_CMD_WIFISIGNALCHANGED                   byte = 7
+	ARCOMMANDS_ID_COMMON_COMMONSTATE_CMD_SENSORSSTATESLISTCHANGED            byte = 8
+	ARCOMMANDS_ID_COMMON_COMMONSTATE_CMD_MAX                                 byte = 9
+
+	// eARCOMMANDS_ID_ARDRONE3_MEDIARECORDSTATE_CMD
+	ARCOMMANDS_ID_ARDRONE3_MEDIARECORDSTATE_CMD_PICTURESTATECHANGED   byte = 0
+	ARCOMMANDS_ID_ARDRONE3_MEDIARECORDSTATE_CMD_VIDEOSTATECHANGED     byte = 1
+	ARCOMMANDS_ID_ARDRONE3_MEDIARECORDSTATE_CMD_PICTURESTATECHANGEDV2 byte = 2
+	ARCOMMANDS_ID_ARDRONE3_MEDIARECORDSTATE_CMD_VIDEOSTATECHANGEDV2   byte = 3
+
+	// eARCOMMANDS_ID_ARDRONE3_MEDIARECORDEVENT_CMD
+	ARCOMMANDS_ID_ARDRONE3_MEDIARECORDEVENT_CMD_PICTUREEVENTCHANGED byte = 0
+	ARCOMMANDS_ID_ARDRONE3_MEDIARECORDEVENT_CMD_VIDEOEVENTCHANGED   byte = 1
+
+	// eARCOMMANDS_ID_ARDRONE3_GPSSETTINGSSTATE_CMD
+	ARCOMMANDS_ID_ARDRONE3_GPSSETTINGSSTATE_CMD_HOMECHANGED           byte = 0
+	ARCOMMANDS_ID_ARDRONE3_GPSSETTINGSSTATE_CMD_RESETHOMECHANGED      byte = 1
+	ARCOMMANDS_ID_ARDRONE3_GPSSETTINGSSTATE_CMD_GPSFIXSTATECHANGED    byte = 2
+	ARCOMMANDS_ID_ARDRONE3_GPSSETTINGSSTATE_CMD_GPSUPDATESTATECHANGED byte = 3
 
 	// eARMEDIA_ENCAPSULER_CODEC
 	CODEC_UNKNNOWN     byte = 0
@@ -172,6 +204,6 @@ const (
 
 	ARCOMMANDS_ID_ARDRONE3_SPEEDSETTINGS_CMD_MAXVERTICALSPEED byte = 0
 	ARCOMMANDS_ID_ARDRONE3_SPEEDSETTINGS_CMD_MAXROTATIONSPEED byte = 1
-	ARCOMMANDS_ID_ARDRONE3_SPEEDSETTINGS_CMD_HULLPROTECTION byte = 2
-	ARCOMMANDS_ID_ARDRONE3_SPEEDSETTINGS_CMD_OUTDOOR byte = 3
+	ARCOMMANDS_ID_ARDRONE3_SPEEDSETTINGS_CMD_HULLPROTECTION   byte = 2
+	ARCOMMANDS_ID_ARDRONE3_SPEEDSETTINGS_CMD_OUTDOOR          byte = 3
 )
@@ -66,10 +66,8 @@ func main() {
 	}()
 
 	go func() {
-		for {
-			if _, err := ffmpegIn.Write(<-bebop.Video()); err != nil {
-				fmt.Println(err)
-			}
+		if err := bebop.SubscribeVideo(30).Pipe(client.NewAnnexBWriter(ffmpegIn)); err != nil {
+			fmt.Println(err)
 		}
 	}()
 
@@ -0,0 +1,247 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ErrInvalidSPS is returned when an H.264 sequence parameter set can not be
+// parsed
+var ErrInvalidSPS = errors.New("Invalid H.264 sequence parameter set")
+
+// Types of the H.264 NAL units
+const (
+	NALSlice = 1
+	NALIDR   = 5
+	NALSEI   = 6
+	NALSPS   = 7
+	NALPPS   = 8
+	NALAUD   = 9
+)
+
+var startCode = []byte{0x00, 0x00, 0x00, 0x01}
+
+// NALType returns the type of an H.264 NAL unit
+func NALType(nal []byte) int {
+	if len(nal) == 0 {
+		return 0
+	}
+	return int(nal[0] & 0x1f)
+}
+
+// SplitNALUnits splits an H.264 Annex-B byte stream into its NAL units,
+// without their start codes
+func SplitNALUnits(data []byte) [][]byte {
+	nals := [][]byte{}
+	start := -1
+	for i := 0; i+2 < len(data); i++ {
+		if data[i] != 0 || data[i+1] != 0 || data[i+2] != 1 {
+			continue
+		}
+		if start >= 0 {
+			nals = appendNAL(nals, data[start:i])
+		}
+		start = i + 3
+		i += 2
+	}
+	if start >= 0 {
+		nals = appendNAL(nals, data[start:])
+	}
+	return nals
+}
+
+// appendNAL appends nal to nals without the zero bytes which precede the
+// next start code
+func appendNAL(nals [][]byte, nal []byte) [][]byte {
+	for len(nal) > 0 && nal[len(nal)-1] == 0 {
+		nal = nal[:len(nal)-1]
+	}
+	if len(nal) == 0 {
+		return nals
+	}
+	return append(nals, nal)
+}
+
+// AnnexB joins NAL units into an Annex-B byte stream, each one prefixed with
+// a 4 bytes start code
+func AnnexB(nals [][]byte) []byte {
+	buf := &bytes.Buffer{}
+	for _, nal := range nals {
+		buf.Write(startCode)
+		buf.Write(nal)
+	}
+	return buf.Bytes()
+}
+
+// SPS is the part of an H.264 sequence parameter set needed to describe the
+// stream
+type SPS struct {
+	Profile     int
+	Constraints int
+	Level       int
+	ID          int
+	Width       int
+	Height      int
+}
+
+// ParseSPS parses the sequence parameter set NAL unit nal
+func ParseSPS(nal []byte) (SPS, error) {
+	//
+	// ITU-T H.264 7.3.2.1.1 Sequence parameter set data syntax
+	//
+	if NALType(nal) != NALSPS || len(nal) < 5 {
+		return SPS{}, ErrInvalidSPS
+	}
+	sps := SPS{Profile: int(nal[1]), Constraints: int(nal[2]), Level: int(nal[3])}
+	r := &bitReader{data: unescapeRBSP(nal[4:])}
+
+	sps.ID = r.ue()
+	chroma := 1
+	switch sps.Profile {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		chroma = r.ue()
+		if chroma == 3 && r.bit() == 1 {
+			// separate colour planes are coded as monochrome pictures
+			chroma = 0
+		}
+		r.ue()  // bit_depth_luma_minus8
+		r.ue()  // bit_depth_chroma_minus8
+		r.bit() // qpprime_y_zero_transform_bypass_flag
+		if r.bit() == 1 {
+			lists := 8
+			if chroma == 3 {
+				lists = 12
+			}
+			for i := 0; i < lists; i++ {
+				if r.bit() == 0 {
+					continue
+				}
+				size := 16
+				if i >= 6 {
+					size = 64
+				}
+				r.skipScalingList(size)
+			}
+		}
+	}
+
+	r.ue() // log2_max_frame_num_minus4
+	switch r.ue() {
+	case 0:
+		r.ue() // log2_max_pic_order_cnt_lsb_minus4
+	case 1:
+		r.bit() // delta_pic_order_always_zero_flag
+		r.se()  // offset_for_non_ref_pic
+		r.se()  // offset_for_top_to_bottom_field
+		for n := r.ue(); n > 0 && r.err == nil; n-- {
+			r.se() // offset_for_ref_frame
+		}
+	}
+	r.ue()  // max_num_ref_frames
+	r.bit() // gaps_in_frame_num_value_allowed_flag
+
+	widthMbs := r.ue() + 1
+	heightMapUnits := r.ue() + 1
+	frameMbsOnly := r.bit()
+	if frameMbsOnly == 0 {
+		r.bit() // mb_adaptive_frame_field_flag
+	}
+	r.bit() // direct_8x8_inference_flag
+
+	fieldFactor := 2 - frameMbsOnly
+	sps.Width = widthMbs * 16
+	sps.Height = fieldFactor * heightMapUnits * 16
+
+	if r.bit() == 1 {
+		left, right, top, bottom := r.ue(), r.ue(), r.ue(), r.ue()
+		cropX, cropY := 1, fieldFactor
+		switch chroma {
+		case 1:
+			cropX, cropY = 2, 2*fieldFactor
+		case 2:
+			cropX = 2
+		}
+		sps.Width -= (left + right) * cropX
+		sps.Height -= (top + bottom) * cropY
+	}
+
+	if r.err != nil || sps.Width <= 0 || sps.Height <= 0 {
+		return SPS{}, ErrInvalidSPS
+	}
+	return sps, nil
+}
+
+// unescapeRBSP removes the emulation prevention bytes of a NAL unit
+func unescapeRBSP(data []byte) []byte {
+	rbsp := make([]byte, 0, len(data))
+	zeros := 0
+	for _, b := range data {
+		if zeros >= 2 && b == 0x03 {
+			zeros = 0
+			continue
+		}
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+		rbsp = append(rbsp, b)
+	}
+	return rbsp
+}
+
+// bitReader reads the Exp-Golomb coded fields of an RBSP, and remembers
+// whether it ran out of bits
+type bitReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *bitReader) bit() int {
+	if r.pos >= len(r.data)*8 {
+		r.err = ErrInvalidSPS
+		return 0
+	}
+	b := (r.data[r.pos/8] >> uint(7-r.pos%8)) & 1
+	r.pos++
+	return int(b)
+}
+
+// ue reads an unsigned Exp-Golomb code
+func (r *bitReader) ue() int {
+	zeros := 0
+	for r.bit() == 0 {
+		if r.err != nil || zeros > 31 {
+			r.err = ErrInvalidSPS
+			return 0
+		}
+		zeros++
+	}
+	v := 0
+	for i := 0; i < zeros; i++ {
+		v = v<<1 | r.bit()
+	}
+	return (1 << uint(zeros)) - 1 + v
+}
+
+// se reads a signed Exp-Golomb code
+func (r *bitReader) se() int {
+	v := r.ue()
+	if v%2 == 0 {
+		return -v / 2
+	}
+	return (v + 1) / 2
+}
+
+func (r *bitReader) skipScalingList(size int) {
+	last, next := 8, 8
+	for i := 0; i < size && r.err == nil; i++ {
+		if next != 0 {
+			next = (last + r.se() + 256) % 256
+		}
+		if next != 0 {
+			last = next
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+var (
+	// baseline profile, 640x368
+	baselineSPS = []byte{0x67, 0x42, 0xc0, 0x1e, 0xda, 0x02, 0x80, 0xbe, 0x40}
+	// high profile, 1920x1088 cropped to 1080
+	highSPS = []byte{0x67, 0x64, 0x00, 0x28, 0xac, 0xe5, 0x01, 0xe0, 0x08, 0x9f, 0x95}
+	testPPS = []byte{0x68, 0xce, 0x3c, 0x80}
+	testIDR = []byte{0x65, 0x88, 0x84, 0x00, 0x33}
+	testP   = []byte{0x41, 0x9a, 0x02, 0x04}
+)
+
+func TestSplitNALUnits(t *testing.T) {
+	data := []byte{
+		0x00, 0x00, 0x00, 0x01, 0x67, 0x42,
+		0x00, 0x00, 0x01, 0x68, 0xce, 0x00,
+		0x00, 0x00, 0x01, 0x65, 0x88, 0x00, 0x00, 0x03, 0x01,
+	}
+	nals := SplitNALUnits(data)
+	gobottest.Assert(t, nals, [][]byte{
+		{0x67, 0x42},
+		{0x68, 0xce},
+		{0x65, 0x88, 0x00, 0x00, 0x03, 0x01},
+	})
+	gobottest.Assert(t, NALType(nals[2]), NALIDR)
+	gobottest.Assert(t, SplitNALUnits(AnnexB(nals)), nals)
+	gobottest.Assert(t, len(SplitNALUnits([]byte{0x65, 0x88})), 0)
+}
+
+func TestParseSPS(t *testing.T) {
+	sps, err := ParseSPS(baselineSPS)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, sps, SPS{Profile: 66, Constraints: 0xc0, Level: 30, Width: 640, Height: 368})
+
+	sps, err = ParseSPS(highSPS)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, sps, SPS{Profile: 100, Level: 40, Width: 1920, Height: 1080})
+
+	_, err = ParseSPS(testPPS)
+	gobottest.Assert(t, err, ErrInvalidSPS)
+	_, err = ParseSPS(baselineSPS[:6])
+	gobottest.Assert(t, err, ErrInvalidSPS)
+}
+
+func TestUnescapeRBSP(t *testing.T) {
+	gobottest.Assert(t,
+		unescapeRBSP([]byte{0x01, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x03, 0x01}),
+		[]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+	)
+}
@@ -0,0 +1,175 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// RTP payload of the H.264 video, RFC 6184
+const (
+	rtpPayloadType = 96
+	rtpHeaderSize  = 12
+	rtpFUA         = 28
+)
+
+// ErrInvalidMTU is returned when the MTU of an RTPWriter is too small for
+// the RTP header and the FU-A headers
+var ErrInvalidMTU = errors.New("MTU is too small for the RTP packets")
+
+// RTPWriter sends the video frames as RTP packets over UDP, which a player
+// such as ffplay or VLC receives with the session description of SDP
+type RTPWriter struct {
+	// MTU is the largest size of the UDP packets, bigger NAL units are
+	// fragmented. It must be bigger than the 12 bytes of the RTP header and
+	// the 2 bytes of the FU-A headers.
+	MTU int
+
+	conn      net.Conn
+	host      string
+	port      int
+	ssrc      uint32
+	seq       uint16
+	timestamp uint32
+	start     time.Time
+	started   bool
+	sps       []byte
+	pps       []byte
+}
+
+// NewRTPWriter returns an RTPWriter sending to the UDP address addr, such as
+// "127.0.0.1:5004"
+func NewRTPWriter(addr string) (*RTPWriter, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialUDP("udp", nil, udpAddr)
+	if err != nil {
+		return nil, err
+	}
+	return &RTPWriter{
+		MTU:       1400,
+		conn:      conn,
+		host:      udpAddr.IP.String(),
+		port:      udpAddr.Port,
+		ssrc:      rand.Uint32(),
+		seq:       uint16(rand.Uint32()),
+		timestamp: rand.Uint32(),
+	}, nil
+}
+
+// WriteFrame sends f, the frames before the first key frame are skipped
+func (r *RTPWriter) WriteFrame(f VideoFrame) error {
+	if !r.started {
+		if !f.Key {
+			return nil
+		}
+		r.started = true
+		r.start = f.Time
+	}
+	timestamp := r.timestamp + uint32(int64(f.Time.Sub(r.start))*90000/int64(time.Second))
+
+	nals := [][]byte{}
+	for _, nal := range f.NALUnits {
+		switch NALType(nal) {
+		case NALAUD:
+			continue
+		case NALSPS:
+			r.sps = nal
+		case NALPPS:
+			r.pps = nal
+		}
+		nals = append(nals, nal)
+	}
+
+	for i, nal := range nals {
+		last := i == len(nals)-1
+		if err := r.writeNAL(nal, timestamp, last); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeNAL sends nal in a single packet when it fits, or else in FU-A
+// fragments. The marker is set on the last packet of the frame.
+func (r *RTPWriter) writeNAL(nal []byte, timestamp uint32, last bool) error {
+	if r.MTU <= rtpHeaderSize+2 {
+		return ErrInvalidMTU
+	}
+	max := r.MTU - rtpHeaderSize
+	if len(nal) <= max {
+		return r.writePacket(nal, timestamp, last)
+	}
+
+	indicator := nal[0]&0xe0 | rtpFUA
+	payload := nal[1:]
+	for start := true; len(payload) > 0; start = false {
+		n := len(payload)
+		if n > max-2 {
+			n = max - 2
+		}
+		header := nal[0] & 0x1f
+		if start {
+			header |= 0x80
+		}
+		end := n == len(payload)
+		if end {
+			header |= 0x40
+		}
+		fragment := append([]byte{indicator, header}, payload[:n]...)
+		if err := r.writePacket(fragment, timestamp, last && end); err != nil {
+			return err
+		}
+		payload = payload[n:]
+	}
+	return nil
+}
+
+func (r *RTPWriter) writePacket(payload []byte, timestamp uint32, marker bool) error {
+	packet := make([]byte, rtpHeaderSize, rtpHeaderSize+len(payload))
+	packet[0] = 0x80
+	packet[1] = rtpPayloadType
+	if marker {
+		packet[1] |= 0x80
+	}
+	binary.BigEndian.PutUint16(packet[2:], r.seq)
+	binary.BigEndian.PutUint32(packet[4:], timestamp)
+	binary.BigEndian.PutUint32(packet[8:], r.ssrc)
+	r.seq++
+
+	_, err := r.conn.Write(append(packet, payload...))
+	return err
+}
+
+// SDP returns the session description of the stream, to be saved to a .sdp
+// file opened by the player. It describes the parameter sets once a key
+// frame was sent.
+func (r *RTPWriter) SDP() string {
+	fmtp := "packetization-mode=1"
+	if r.sps != nil && r.pps != nil && len(r.sps) >= 4 {
+		fmtp += fmt.Sprintf(";profile-level-id=%02x%02x%02x;sprop-parameter-sets=%s,%s",
+			r.sps[1], r.sps[2], r.sps[3],
+			base64.StdEncoding.EncodeToString(r.sps),
+			base64.StdEncoding.EncodeToString(r.pps),
+		)
+	}
+	return "v=0\r\n" +
+		"o=- 0 0 IN IP4 " + r.host + "\r\n" +
+		"s=Bebop\r\n" +
+		"c=IN IP4 " + r.host + "\r\n" +
+		"t=0 0\r\n" +
+		fmt.Sprintf("m=video %d RTP/AVP %d\r\n", r.port, rtpPayloadType) +
+		fmt.Sprintf("a=rtpmap:%d H264/90000\r\n", rtpPayloadType) +
+		fmt.Sprintf("a=fmtp:%d %s\r\n", rtpPayloadType, fmtp)
+}
+
+// Close closes the UDP connection
+func (r *RTPWriter) Close() error {
+	return r.conn.Close()
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestRTPWriter(t *testing.T) {
+	listener, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	gobottest.Assert(t, err, nil)
+	defer listener.Close()
+
+	w, err := NewRTPWriter(listener.LocalAddr().String())
+	gobottest.Assert(t, err, nil)
+	w.MTU = 12 + 9
+
+	bigP := append([]byte{0x41}, bytes.Repeat([]byte{0xaa}, 13)...)
+	frames := testFrames()
+	frames[2].NALUnits = [][]byte{bigP}
+	for _, f := range frames {
+		gobottest.Assert(t, w.WriteFrame(f), nil)
+	}
+	gobottest.Assert(t, w.Close(), nil)
+
+	packets := [][]byte{}
+	listener.SetReadDeadline(time.Now().Add(time.Second))
+	for len(packets) < 5 {
+		buf := make([]byte, 100)
+		n, err := listener.Read(buf)
+		gobottest.Assert(t, err, nil)
+		packets = append(packets, buf[:n])
+	}
+
+	// the P frame before the key frame is skipped, the SPS, PPS and IDR are
+	// single NAL unit packets, and the big P frame is fragmented
+	payloads := [][]byte{}
+	for _, p := range packets {
+		gobottest.Assert(t, p[0], byte(0x80))
+		payloads = append(payloads, p[12:])
+	}
+	gobottest.Assert(t, payloads, [][]byte{
+		baselineSPS,
+		testPPS,
+		testIDR,
+		append([]byte{0x5c, 0x81}, bigP[1:8]...),
+		append([]byte{0x5c, 0x41}, bigP[8:]...),
+	})
+
+	// the marker is set on the last packet of each frame
+	markers := []bool{}
+	for _, p := range packets {
+		markers = append(markers, p[1]&0x80 != 0)
+	}
+	gobottest.Assert(t, markers, []bool{false, false, true, false, true})
+
+	seq := binary.BigEndian.Uint16(packets[0][2:])
+	gobottest.Assert(t, binary.BigEndian.Uint16(packets[4][2:]), seq+4)
+	ts := binary.BigEndian.Uint32(packets[0][4:])
+	gobottest.Assert(t, binary.BigEndian.Uint32(packets[3][4:]), ts+3600)
+
+	sdp := w.SDP()
+	gobottest.Assert(t, strings.Contains(sdp, "m=video "), true)
+	gobottest.Assert(t, strings.Contains(sdp, "a=rtpmap:96 H264/90000\r\n"), true)
+	gobottest.Assert(t, strings.Contains(sdp, "profile-level-id=42c01e;sprop-parameter-sets=Z0LAHtoCgL5A,aM48gA=="), true)
+}
+
+func TestRTPWriterInvalidMTU(t *testing.T) {
+	listener, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	gobottest.Assert(t, err, nil)
+	defer listener.Close()
+
+	w, err := NewRTPWriter(listener.LocalAddr().String())
+	gobottest.Assert(t, err, nil)
+	defer w.Close()
+
+	for _, mtu := range []int{0, 12, 14} {
+		w.MTU = mtu
+		for _, f := range testFrames() {
+			if f.Key {
+				gobottest.Assert(t, w.WriteFrame(f), ErrInvalidMTU)
+			}
+		}
+	}
+
+	w.MTU = 15
+	for _, f := range testFrames() {
+		gobottest.Assert(t, w.WriteFrame(f), nil)
+	}
+}
@@ -0,0 +1,403 @@
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+)
+
+// Names of the events of the state decoded from the drone
+const (
+	Battery      = "battery"
+	FlyingState  = "flyingstate"
+	Alert        = "alert"
+	NavigateHome = "navigatehome"
+	Altitude     = "altitude"
+	Attitude     = "attitude"
+	Speed        = "speed"
+	Position     = "position"
+	GPSFix       = "gpsfix"
+	WifiSignal   = "wifisignal"
+	VideoState   = "videostate"
+	PictureState = "picturestate"
+	PictureTaken = "picturetaken"
+)
+
+// PositionUnset is the latitude, longitude and altitude of a position while
+// the GPS has no fix
+const PositionUnset = 500.0
+
+// FlyingStateValue is the flying state of the drone
+type FlyingStateValue int
+
+// eARCOMMANDS_ARDRONE3_PILOTINGSTATE_FLYINGSTATECHANGED_STATE
+const (
+	Landed FlyingStateValue = iota
+	TakingOff
+	Hovering
+	Flying
+	Landing
+	Emergency
+	UserTakeOff
+	MotorRamping
+	EmergencyLanding
+)
+
+var flyingStateNames = []string{
+	"landed", "takingoff", "hovering", "flying", "landing", "emergency",
+	"usertakeoff", "motorramping", "emergencylanding",
+}
+
+func (s FlyingStateValue) String() string {
+	if s < 0 || int(s) >= len(flyingStateNames) {
+		return "unknown"
+	}
+	return flyingStateNames[s]
+}
+
+// InFlight returns whether the drone is off the ground
+func (s FlyingStateValue) InFlight() bool {
+	return s == TakingOff || s == Hovering || s == Flying || s == Landing || s == EmergencyLanding
+}
+
+// AlertValue is the alert raised by the drone
+type AlertValue int
+
+// eARCOMMANDS_ARDRONE3_PILOTINGSTATE_ALERTSTATECHANGED_STATE
+const (
+	NoAlert AlertValue = iota
+	UserEmergencyAlert
+	CutOutAlert
+	CriticalBatteryAlert
+	LowBatteryAlert
+	TooMuchAngleAlert
+)
+
+var alertNames = []string{
+	"none", "user", "cutout", "criticalbattery", "lowbattery", "toomuchangle",
+}
+
+func (a AlertValue) String() string {
+	if a < 0 || int(a) >= len(alertNames) {
+		return "unknown"
+	}
+	return alertNames[a]
+}
+
+// NavigateHomeValue is the state of the return home of the drone, and the
+// reason of its last change
+type NavigateHomeValue struct {
+	// State is 0 when available, 1 in progress, 2 unavailable and 3 pending
+	State int
+	// Reason is 0 when requested by the user, 1 on a connection loss, 2 on
+	// a low battery, 3 when finished, 4 when stopped, 5 when disabled and 6
+	// when enabled
+	Reason int
+}
+
+// AttitudeValue is the attitude of the drone, in radians
+type AttitudeValue struct {
+	Roll  float64
+	Pitch float64
+	Yaw   float64
+}
+
+// SpeedValue is the speed of the drone in m/s, X to the north, Y to the east
+// and Z down
+type SpeedValue struct {
+	X float64
+	Y float64
+	Z float64
+}
+
+// PositionValue is the GPS position of the drone, in degrees and meters. Its
+// fields are PositionUnset while the GPS has no fix.
+type PositionValue struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+}
+
+// Valid returns whether the position is known
+func (p PositionValue) Valid() bool {
+	return p.Latitude != PositionUnset && p.Longitude != PositionUnset
+}
+
+// MediaValue is the state of the video recording or of the picture capture.
+// The video State is 0 when stopped, 1 when started and 2 when not
+// available. The picture State is 0 when ready, 1 when busy and 2 when not
+// available. Error is 0 when ok, 1 unknown, 2 for a camera failure, 3 for a
+// full memory and 4 for a low battery.
+type MediaValue struct {
+	State int
+	Error int
+}
+
+// State is the last known state of the drone
+type State struct {
+	Battery      int
+	FlyingState  FlyingStateValue
+	Alert        AlertValue
+	NavigateHome NavigateHomeValue
+	Altitude     float64
+	Attitude     AttitudeValue
+	Speed        SpeedValue
+	Position     PositionValue
+	GPSFix       bool
+	WifiSignal   int
+	Video        MediaValue
+	Picture      MediaValue
+}
+
+// Event is a change of the state of the drone, Data is the new value, such
+// as a FlyingStateValue for the FlyingState event
+type Event struct {
+	Name string
+	Data interface{}
+}
+
+// droneState is the state of the drone, updated by the decoded commands
+type droneState struct {
+	sync.Mutex
+	state         State
+	handler       func(Event)
+	requireGPSFix bool
+}
+
+func newDroneState() *droneState {
+	return &droneState{
+		state: State{
+			Position: PositionValue{
+				Latitude:  PositionUnset,
+				Longitude: PositionUnset,
+				Altitude:  PositionUnset,
+			},
+		},
+	}
+}
+
+// update applies f to the state, and emits the event of name with the value
+// returned by f
+func (s *droneState) update(name string, f func(*State) interface{}) {
+	s.Lock()
+	data := f(&s.state)
+	handler := s.handler
+	s.Unlock()
+	if handler != nil {
+		handler(Event{Name: name, Data: data})
+	}
+}
+
+// State returns the last known state of the drone
+func (b *Bebop) State() State {
+	b.state.Lock()
+	defer b.state.Unlock()
+	return b.state.state
+}
+
+// OnEvent sets the function called with each change of the state of the
+// drone. It is called from the receiving goroutine and must not block.
+func (b *Bebop) OnEvent(f func(Event)) {
+	b.state.Lock()
+	defer b.state.Unlock()
+	b.state.handler = f
+}
+
+// decodeCommand decodes an ARCommand received from the drone. Commands which
+// are unknown or too short are ignored.
+func (b *Bebop) decodeCommand(data []byte) {
+	//
+	// ARCOMMANDS_Decoder_DecodeBuffer
+	//
+	// uint8  - project
+	// uint8  - class
+	// uint16 - command
+	//
+	if len(data) < 4 || data[3] != 0 {
+		return
+	}
+	project, class, cmd := data[0], data[1], data[2]
+	args := &commandArgs{r: bytes.NewReader(data[4:])}
+
+	switch project {
+	case ARCOMMANDS_ID_PROJECT_COMMON:
+		b.decodeCommon(class, cmd, args)
+	case ARCOMMANDS_ID_PROJECT_ARDRONE3:
+		b.decodeARDrone3(class, cmd, args)
+	}
+}
+
+func (b *Bebop) decodeCommon(class byte, cmd byte, args *commandArgs) {
+	if class != ARCOMMANDS_ID_COMMON_CLASS_COMMONSTATE {
+		return
+	}
+	switch cmd {
+	case ARCOMMANDS_ID_COMMON_COMMONSTATE_CMD_BATTERYSTATECHANGED:
+		percent := args.uint8()
+		if args.ok() {
+			b.state.update(Battery, func(s *State) interface{} {
+				s.Battery = int(percent)
+				return s.Battery
+			})
+		}
+	case ARCOMMANDS_ID_COMMON_COMMONSTATE_CMD_WIFISIGNALCHANGED:
+		rssi := args.int16()
+		if args.ok() {
+			b.state.update(WifiSignal, func(s *State) interface{} {
+				s.WifiSignal = int(rssi)
+				return s.WifiSignal
+			})
+		}
+	}
+}
+
+func (b *Bebop) decodeARDrone3(class byte, cmd byte, args *commandArgs) {
+	switch class {
+	case ARCOMMANDS_ID_ARDRONE3_CLASS_PILOTINGSTATE:
+		b.decodePilotingState(cmd, args)
+	case ARCOMMANDS_ID_ARDRONE3_CLASS_GPSSETTINGSSTATE:
+		if cmd != ARCOMMANDS_ID_ARDRONE3_GPSSETTINGSSTATE_CMD_GPSFIXSTATECHANGED {
+			return
+		}
+		fixed := args.uint8()
+		if args.ok() {
+			b.state.update(GPSFix, func(s *State) interface{} {
+				s.GPSFix = fixed == 1
+				return s.GPSFix
+			})
+		}
+	case ARCOMMANDS_ID_ARDRONE3_CLASS_MEDIARECORDSTATE:
+		state, e := args.enum(), args.enum()
+		if !args.ok() {
+			return
+		}
+		media := MediaValue{State: state, Error: e}
+		switch cmd {
+		case ARCOMMANDS_ID_ARDRONE3_MEDIARECORDSTATE_CMD_VIDEOSTATECHANGEDV2:
+			b.state.update(VideoState, func(s *State) interface{} {
+				s.Video = media
+				return media
+			})
+		case ARCOMMANDS_ID_ARDRONE3_MEDIARECORDSTATE_CMD_PICTURESTATECHANGEDV2:
+			b.state.update(PictureState, func(s *State) interface{} {
+				s.Picture = media
+				return media
+			})
+		}
+	case ARCOMMANDS_ID_ARDRONE3_CLASS_MEDIARECORDEVENT:
+		if cmd != ARCOMMANDS_ID_ARDRONE3_MEDIARECORDEVENT_CMD_PICTUREEVENTCHANGED {
+			return
+		}
+		event, e := args.enum(), args.enum()
+		if args.ok() {
+			// the event is 0 when the picture was taken and 1 when it failed
+			b.state.update(PictureTaken, func(s *State) interface{} {
+				return MediaValue{State: event, Error: e}
+			})
+		}
+	}
+}
+
+func (b *Bebop) decodePilotingState(cmd byte, args *commandArgs) {
+	switch cmd {
+	case ARCOMMANDS_ID_ARDRONE3_PILOTINGSTATE_CMD_FLYINGSTATECHANGED:
+		state := args.enum()
+		if args.ok() {
+			b.state.update(FlyingState, func(s *State) interface{} {
+				s.FlyingState = FlyingStateValue(state)
+				return s.FlyingState
+			})
+		}
+	case ARCOMMANDS_ID_ARDRONE3_PILOTINGSTATE_CMD_ALERTSTATECHANGED:
+		alert := args.enum()
+		if args.ok() {
+			b.state.update(Alert, func(s *State) interface{} {
+				s.Alert = AlertValue(alert)
+				return s.Alert
+			})
+		}
+	case ARCOMMANDS_ID_ARDRONE3_PILOTINGSTATE_CMD_NAVIGATEHOMESTATECHANGED:
+		state, reason := args.enum(), args.enum()
+		if args.ok() {
+			b.state.update(NavigateHome, func(s *State) interface{} {
+				s.NavigateHome = NavigateHomeValue{State: state, Reason: reason}
+				return s.NavigateHome
+			})
+		}
+	case ARCOMMANDS_ID_ARDRONE3_PILOTINGSTATE_CMD_POSITIONCHANGED:
+		p := PositionValue{Latitude: args.float64(), Longitude: args.float64(), Altitude: args.float64()}
+		if args.ok() {
+			b.state.update(Position, func(s *State) interface{} {
+				s.Position = p
+				return p
+			})
+		}
+	case ARCOMMANDS_ID_ARDRONE3_PILOTINGSTATE_CMD_SPEEDCHANGED:
+		v := SpeedValue{X: args.float32(), Y: args.float32(), Z: args.float32()}
+		if args.ok() {
+			b.state.update(Speed, func(s *State) interface{} {
+				s.Speed = v
+				return v
+			})
+		}
+	case ARCOMMANDS_ID_ARDRONE3_PILOTINGSTATE_CMD_ATTITUDECHANGED:
+		a := AttitudeValue{Roll: args.float32(), Pitch: args.float32(), Yaw: args.float32()}
+		if args.ok() {
+			b.state.update(Attitude, func(s *State) interface{} {
+				s.Attitude = a
+				return a
+			})
+		}
+	case ARCOMMANDS_ID_ARDRONE3_PILOTINGSTATE_CMD_ALTITUDECHANGED:
+		altitude := args.float64()
+		if args.ok() {
+			b.state.update(Altitude, func(s *State) interface{} {
+				s.Altitude = altitude
+				return altitude
+			})
+		}
+	}
+}
+
+// commandArgs reads the little endian arguments of a command, and remembers
+// whether one of them was missing
+type commandArgs struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (a *commandArgs) read(v interface{}) {
+	if a.err == nil {
+		a.err = binary.Read(a.r, binary.LittleEndian, v)
+	}
+}
+
+func (a *commandArgs) ok() bool { return a.err == nil }
+
+func (a *commandArgs) uint8() (v uint8) {
+	a.read(&v)
+	return
+}
+
+func (a *commandArgs) int16() (v int16) {
+	a.read(&v)
+	return
+}
+
+// enum reads an enum argument, which is 4 bytes long
+func (a *commandArgs) enum() int {
+	var v int32
+	a.read(&v)
+	return int(v)
+}
+
+func (a *commandArgs) float32() float64 {
+	var v float32
+	a.read(&v)
+	return float64(v)
+}
+
+func (a *commandArgs) float64() (v float64) {
+	a.read(&v)
+	return
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// frames captured from a Bebop, on the event buffer (126) with acks and on
+// the navdata buffer (127) without
+var (
+	batteryFrame = []byte{
+		0x04, 0x7e, 0x12, 0x0c, 0x00, 0x00, 0x00, 0x00, 0x05, 0x01, 0x00, 0x57,
+	}
+	flyingStateFrame = []byte{
+		0x04, 0x7e, 0x13, 0x0f, 0x00, 0x00, 0x00, 0x01, 0x04, 0x01, 0x00, 0x02,
+		0x00, 0x00, 0x00,
+	}
+	// attitude, altitude and speed in a single datagram
+	navdataFrames = []byte{
+		0x02, 0x7f, 0x40, 0x17, 0x00, 0x00, 0x00, 0x01, 0x04, 0x06, 0x00, 0xcd,
+		0xcc, 0x4c, 0x3d, 0xcd, 0xcc, 0xcc, 0xbd, 0x00, 0x00, 0xc0, 0x3f, 0x02,
+		0x7f, 0x41, 0x13, 0x00, 0x00, 0x00, 0x01, 0x04, 0x08, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x29, 0x40, 0x02, 0x7f, 0x42, 0x17, 0x00, 0x00,
+		0x00, 0x01, 0x04, 0x05, 0x00, 0x00, 0x00, 0x80, 0x3f, 0x00, 0x00, 0x00,
+		0xbf, 0x00, 0x00, 0x80, 0x3e,
+	}
+	positionFrame = []byte{
+		0x02, 0x7f, 0x43, 0x23, 0x00, 0x00, 0x00, 0x01, 0x04, 0x04, 0x00, 0x9c,
+		0xc4, 0x20, 0xb0, 0x72, 0x70, 0x48, 0x40, 0x71, 0x3d, 0x0a, 0xd7, 0xa3,
+		0xf0, 0x02, 0x40, 0x9a, 0x99, 0x99, 0x99, 0x99, 0x99, 0x41, 0x40,
+	}
+	wifiSignalFrame = []byte{
+		0x04, 0x7e, 0x14, 0x0d, 0x00, 0x00, 0x00, 0x00, 0x05, 0x07, 0x00, 0xd3,
+		0xff,
+	}
+	gpsFixFrame = []byte{
+		0x04, 0x7e, 0x15, 0x0c, 0x00, 0x00, 0x00, 0x01, 0x18, 0x02, 0x00, 0x01,
+	}
+	videoStateFrame = []byte{
+		0x04, 0x7e, 0x16, 0x13, 0x00, 0x00, 0x00, 0x01, 0x08, 0x03, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	alertFrame = []byte{
+		0x04, 0x7e, 0x17, 0x0f, 0x00, 0x00, 0x00, 0x01, 0x04, 0x02, 0x00, 0x04,
+		0x00, 0x00, 0x00,
+	}
+	navigateHomeFrame = []byte{
+		0x04, 0x7e, 0x18, 0x13, 0x00, 0x00, 0x00, 0x01, 0x04, 0x03, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00,
+	}
+	pictureEventFrame = []byte{
+		0x04, 0x7e, 0x19, 0x13, 0x00, 0x00, 0x00, 0x01, 0x03, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+)
+
+// newTestBebop returns a Bebop whose written frames are sent to written
+func newTestBebop() (*Bebop, chan []byte) {
+	b := New()
+	written := make(chan []byte, 100)
+	go func() {
+		for buf := range b.writeChan {
+			written <- buf
+		}
+	}()
+	return b, written
+}
+
+func TestSplitNetworkFrames(t *testing.T) {
+	frames := splitNetworkFrames(navdataFrames)
+	gobottest.Assert(t, len(frames), 3)
+	gobottest.Assert(t, frames[1].Seq, 0x41)
+	gobottest.Assert(t, frames[1].Id, int(BD_NET_DC_NAVDATA_ID))
+	gobottest.Assert(t, frames[1].Data, navdataFrames[30:42])
+
+	// a truncated frame is dropped
+	gobottest.Assert(t, len(splitNetworkFrames(navdataFrames[:40])), 1)
+	gobottest.Assert(t, len(splitNetworkFrames([]byte{0x02, 0x7f, 0x00, 0x03, 0x00, 0x00, 0x00})), 0)
+}
+
+func TestDecodeState(t *testing.T) {
+	b, written := newTestBebop()
+
+	events := []Event{}
+	b.OnEvent(func(e Event) {
+		events = append(events, e)
+	})
+
+	gobottest.Assert(t, b.State().Position.Valid(), false)
+
+	b.packetReceiver(batteryFrame)
+	// the frame of the event buffer is acked
+	gobottest.Assert(t, <-written, []byte{0x01, 0xfe, 0x01, 0x08, 0x00, 0x00, 0x00, 0x12})
+
+	b.packetReceiver(flyingStateFrame)
+	<-written
+	b.packetReceiver(navdataFrames)
+	b.packetReceiver(positionFrame)
+	b.packetReceiver(wifiSignalFrame)
+	<-written
+	b.packetReceiver(gpsFixFrame)
+	<-written
+	b.packetReceiver(videoStateFrame)
+	<-written
+	b.packetReceiver(alertFrame)
+	<-written
+	b.packetReceiver(navigateHomeFrame)
+	<-written
+	b.packetReceiver(pictureEventFrame)
+	<-written
+
+	attitude := AttitudeValue{Roll: float64(float32(0.05)), Pitch: float64(float32(-0.1)), Yaw: 1.5}
+	speed := SpeedValue{X: 1, Y: -0.5, Z: 0.25}
+	position := PositionValue{Latitude: 48.8785, Longitude: 2.3675, Altitude: 35.2}
+
+	gobottest.Assert(t, b.State(), State{
+		Battery:      87,
+		FlyingState:  Hovering,
+		Alert:        LowBatteryAlert,
+		NavigateHome: NavigateHomeValue{State: 1, Reason: 2},
+		Altitude:     12.5,
+		Attitude:     attitude,
+		Speed:        speed,
+		Position:     position,
+		GPSFix:       true,
+		WifiSignal:   -45,
+		Video:        MediaValue{State: 1},
+	})
+	gobottest.Assert(t, b.State().Position.Valid(), true)
+	gobottest.Assert(t, b.State().FlyingState.String(), "hovering")
+	gobottest.Assert(t, b.State().Alert.String(), "lowbattery")
+
+	gobottest.Assert(t, events, []Event{
+		{Battery, 87},
+		{FlyingState, Hovering},
+		{Attitude, attitude},
+		{Altitude, 12.5},
+		{Speed, speed},
+		{Position, position},
+		{WifiSignal, -45},
+		{GPSFix, true},
+		{VideoState, MediaValue{State: 1}},
+		{Alert, LowBatteryAlert},
+		{NavigateHome, NavigateHomeValue{State: 1, Reason: 2}},
+		{PictureTaken, MediaValue{}},
+	})
+}
+
+func TestDecodeShortCommand(t *testing.T) {
+	b, written := newTestBebop()
+
+	events := 0
+	b.OnEvent(func(e Event) { events++ })
+
+	// a flying state without its argument
+	b.packetReceiver([]byte{0x04, 0x7e, 0x01, 0x0b, 0x00, 0x00, 0x00, 0x01, 0x04, 0x01, 0x00})
+	<-written
+	gobottest.Assert(t, events, 0)
+	gobottest.Assert(t, b.State().FlyingState, Landed)
+}
+
+func TestFlyingState(t *testing.T) {
+	gobottest.Assert(t, Landed.InFlight(), false)
+	gobottest.Assert(t, Flying.InFlight(), true)
+	gobottest.Assert(t, Emergency.InFlight(), false)
+	gobottest.Assert(t, FlyingStateValue(42).String(), "unknown")
+	gobottest.Assert(t, AlertValue(-1).String(), "unknown")
+}
@@ -0,0 +1,310 @@
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// VideoFrame is a frame of the H.264 stream of the drone. The frames are
+// shared by all the subscriptions and must not be modified.
+type VideoFrame struct {
+	// Number is the number of the frame in the stream of the drone
+	Number int
+	// Key is whether the frame is an IDR frame, which decodes on its own.
+	// Key frames always start with the last SPS and PPS of the stream.
+	Key bool
+	// Data is the frame as an Annex-B byte stream
+	Data []byte
+	// NALUnits are the NAL units of Data, without their start codes
+	NALUnits [][]byte
+	// Time is when the first fragment of the frame was received
+	Time time.Time
+	// Latency is the time it took to receive all the fragments of the frame
+	Latency time.Duration
+}
+
+// VideoStats are the statistics of the video stream of the drone
+type VideoStats struct {
+	// Frames is the number of complete frames received
+	Frames int
+	// Lost is the number of frames never received or received incomplete
+	Lost int
+	// Fragments is the number of fragments received
+	Fragments int
+	// Bytes is the size of the complete frames received
+	Bytes int
+	// Latency is the average time it took to receive all the fragments of a
+	// frame, and MaxLatency the longest
+	Latency    time.Duration
+	MaxLatency time.Duration
+	// Width and Height are the size of the video, once an SPS was received
+	Width  int
+	Height int
+}
+
+// VideoWriter writes the frames of a video subscription
+type VideoWriter interface {
+	WriteFrame(f VideoFrame) error
+	Close() error
+}
+
+// VideoSubscription receives the frames of the video stream. A subscription
+// which does not keep up loses frames without delaying the others, and then
+// waits for the next key frame.
+type VideoSubscription struct {
+	stream     *videoStream
+	frames     chan VideoFrame
+	waitForKey bool
+	dropped    int
+}
+
+// Frames returns the channel on which the frames are sent, starting with a
+// key frame. It is closed by Close.
+func (s *VideoSubscription) Frames() <-chan VideoFrame {
+	return s.frames
+}
+
+// Dropped returns the number of frames this subscription lost because it
+// did not keep up
+func (s *VideoSubscription) Dropped() int {
+	s.stream.Lock()
+	defer s.stream.Unlock()
+	return s.dropped
+}
+
+// Close stops the subscription and closes its channel
+func (s *VideoSubscription) Close() {
+	s.stream.Lock()
+	defer s.stream.Unlock()
+	if _, ok := s.stream.subscriptions[s]; ok {
+		delete(s.stream.subscriptions, s)
+		close(s.frames)
+	}
+}
+
+// Pipe writes the frames of the subscription to w until the subscription is
+// closed or w fails, and then closes w
+func (s *VideoSubscription) Pipe(w VideoWriter) error {
+	for f := range s.frames {
+		if err := w.WriteFrame(f); err != nil {
+			s.Close()
+			w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// videoStream reassembles the fragments of the ARStream frames of the drone,
+// and distributes the frames to the subscriptions
+type videoStream struct {
+	sync.Mutex
+	subscriptions map[*VideoSubscription]bool
+	// legacy is the channel of Video, which waits for a key frame after
+	// each lost frame
+	legacy        chan []byte
+	legacyWaitKey bool
+
+	stats        VideoStats
+	totalLatency time.Duration
+	sps          []byte
+	pps          []byte
+
+	started   bool
+	ack       ARStreamACK
+	flags     int
+	fragments [][]byte
+	received  int
+	first     time.Time
+	delivered bool
+}
+
+func newVideoStream(legacy chan []byte) *videoStream {
+	return &videoStream{
+		subscriptions: make(map[*VideoSubscription]bool),
+		legacy:        legacy,
+		legacyWaitKey: true,
+	}
+}
+
+// SubscribeVideo returns a subscription to the video stream, whose channel
+// buffers size frames
+func (b *Bebop) SubscribeVideo(size int) *VideoSubscription {
+	s := &VideoSubscription{
+		stream:     b.videoStream,
+		frames:     make(chan VideoFrame, size),
+		waitForKey: true,
+	}
+	b.videoStream.Lock()
+	defer b.videoStream.Unlock()
+	b.videoStream.subscriptions[s] = true
+	return s
+}
+
+// VideoStats returns the statistics of the video stream
+func (b *Bebop) VideoStats() VideoStats {
+	b.videoStream.Lock()
+	defer b.videoStream.Unlock()
+	return b.videoStream.stats
+}
+
+// fragment adds an ARStream fragment to its frame, delivers the frame once
+// complete, and returns the ack of the fragments received
+func (v *videoStream) fragment(frame ARStreamFrame) ARStreamACK {
+	v.Lock()
+	defer v.Unlock()
+
+	if !v.started || frame.FrameNumber != v.ack.FrameNumber {
+		// frame numbers are 16 bits and wrap around
+		diff := uint16(frame.FrameNumber - v.ack.FrameNumber)
+		if v.started && diff >= 0x8000 {
+			// a late fragment of a previous frame
+			return v.ack
+		}
+		if v.started {
+			lost := int(diff) - 1
+			if !v.delivered {
+				lost++
+			}
+			if lost > 0 {
+				v.lost(lost)
+			}
+		}
+
+		v.started = true
+		v.ack = ARStreamACK{FrameNumber: frame.FrameNumber}
+		v.flags = frame.FrameFlags
+		v.fragments = make([][]byte, frame.FragmentsPerFrame)
+		v.received = 0
+		v.first = time.Now()
+		v.delivered = false
+	}
+
+	if frame.FragmentNumber >= len(v.fragments) || v.fragments[frame.FragmentNumber] != nil {
+		return v.ack
+	}
+	v.fragments[frame.FragmentNumber] = frame.Frame
+	v.received++
+	v.stats.Fragments++
+
+	if frame.FragmentNumber < 64 {
+		v.ack.LowPacketsAck |= uint64(1) << uint64(frame.FragmentNumber)
+	} else {
+		v.ack.HighPacketsAck |= uint64(1) << uint64(frame.FragmentNumber-64)
+	}
+
+	if v.received == len(v.fragments) && !v.delivered {
+		v.delivered = true
+		v.deliver()
+	}
+	return v.ack
+}
+
+// lost counts n lost frames, after which the frames can not be decoded
+// until the next key frame
+func (v *videoStream) lost(n int) {
+	v.stats.Lost += n
+	v.legacyWaitKey = true
+	for s := range v.subscriptions {
+		s.waitForKey = true
+	}
+}
+
+// deliver builds the frame of the fragments and sends it to the
+// subscriptions
+func (v *videoStream) deliver() {
+	data := bytes.Join(v.fragments, nil)
+	nals := SplitNALUnits(data)
+
+	// the flush flag of the ARStream frame marks the key frames
+	key := v.flags&1 == 1
+	hasSPS, hasPPS := false, false
+	for _, nal := range nals {
+		switch NALType(nal) {
+		case NALIDR:
+			key = true
+		case NALSPS:
+			hasSPS = true
+			v.sps = append([]byte{}, nal...)
+			if sps, err := ParseSPS(nal); err == nil {
+				v.stats.Width, v.stats.Height = sps.Width, sps.Height
+			}
+		case NALPPS:
+			hasPPS = true
+			v.pps = append([]byte{}, nal...)
+		}
+	}
+	if key && (!hasSPS || !hasPPS) && v.sps != nil && v.pps != nil {
+		params := [][]byte{}
+		if !hasSPS {
+			params = append(params, v.sps)
+		}
+		if !hasPPS {
+			params = append(params, v.pps)
+		}
+		nals = append(params, nals...)
+		data = AnnexB(nals)
+	}
+
+	f := VideoFrame{
+		Number:   v.ack.FrameNumber,
+		Key:      key,
+		Data:     data,
+		NALUnits: nals,
+		Time:     v.first,
+		Latency:  time.Since(v.first),
+	}
+
+	v.stats.Frames++
+	v.stats.Bytes += len(data)
+	v.totalLatency += f.Latency
+	v.stats.Latency = v.totalLatency / time.Duration(v.stats.Frames)
+	if f.Latency > v.stats.MaxLatency {
+		v.stats.MaxLatency = f.Latency
+	}
+
+	for s := range v.subscriptions {
+		if s.waitForKey && !key {
+			continue
+		}
+		select {
+		case s.frames <- f:
+			s.waitForKey = false
+		default:
+			s.dropped++
+			s.waitForKey = true
+		}
+	}
+
+	if v.legacyWaitKey && !key {
+		return
+	}
+	select {
+	case v.legacy <- data:
+		v.legacyWaitKey = false
+	default:
+		v.legacyWaitKey = true
+	}
+}
+
+func (b *Bebop) createARStreamACK(frame ARStreamFrame) *bytes.Buffer {
+	//
+	// ARSTREAM_NetworkHeaders_AckPacket_t;
+	//
+	// uint16_t frameNumber;    // id of the current frame
+	// uint64_t highPacketsAck; // Upper 64 packets bitfield
+	// uint64_t lowPacketsAck;  // Lower 64 packets bitfield
+	//
+	// libARStream/Sources/ARSTREAM_NetworkHeaders.c#ARSTREAM_NetworkHeaders_AckPacketSetFlag
+	//
+	ack := b.videoStream.fragment(frame)
+
+	ackPacket := &bytes.Buffer{}
+	binary.Write(ackPacket, binary.LittleEndian, uint16(ack.FrameNumber))
+	binary.Write(ackPacket, binary.LittleEndian, ack.HighPacketsAck)
+	binary.Write(ackPacket, binary.LittleEndian, ack.LowPacketsAck)
+
+	return b.networkFrameGenerator(ackPacket, ARNETWORKAL_FRAME_TYPE_DATA, BD_NET_CD_VIDEO_ACK_ID)
+}
@@ -0,0 +1,295 @@
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// ErrNoVideoParameters is returned when a video is closed before a key
+// frame with its SPS and PPS was written
+var ErrNoVideoParameters = errors.New("No SPS and PPS were received")
+
+// closeWriter closes w when it is an io.Closer
+func closeWriter(w interface{}) error {
+	if c, ok := w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// AnnexBWriter writes the video frames as a raw H.264 Annex-B byte stream,
+// such as a .h264 file or the input of ffmpeg
+type AnnexBWriter struct {
+	w       io.Writer
+	started bool
+}
+
+// NewAnnexBWriter returns an AnnexBWriter writing to w, which is closed with
+// the writer when it is an io.Closer
+func NewAnnexBWriter(w io.Writer) *AnnexBWriter {
+	return &AnnexBWriter{w: w}
+}
+
+// WriteFrame writes f, the frames before the first key frame are skipped
+func (a *AnnexBWriter) WriteFrame(f VideoFrame) error {
+	if !a.started && !f.Key {
+		return nil
+	}
+	a.started = true
+	_, err := a.w.Write(f.Data)
+	return err
+}
+
+// Close closes the underlying writer
+func (a *AnnexBWriter) Close() error {
+	return closeWriter(a.w)
+}
+
+// mp4Timescale is the timescale of the MP4 files, the 90kHz of H.264
+const mp4Timescale = 90000
+
+// MP4Writer writes the video frames to an MP4 file. The samples are written
+// as they come, and the index of the file is written by Close.
+type MP4Writer struct {
+	w       io.WriteSeeker
+	sps     []byte
+	pps     []byte
+	width   int
+	height  int
+	start   time.Time
+	offset  int64
+	mdat    int64
+	times   []int64
+	sizes   []uint32
+	offsets []uint32
+	keys    []uint32
+}
+
+// NewMP4Writer returns an MP4Writer writing to w, which is closed with the
+// writer when it is an io.Closer
+func NewMP4Writer(w io.WriteSeeker) *MP4Writer {
+	return &MP4Writer{w: w}
+}
+
+// WriteFrame writes f, the frames before the first key frame with an SPS
+// and a PPS are skipped
+func (m *MP4Writer) WriteFrame(f VideoFrame) error {
+	if m.sps == nil {
+		if !f.Key || !m.parameters(f) {
+			return nil
+		}
+		if err := m.writeHeader(); err != nil {
+			return err
+		}
+		m.start = f.Time
+	}
+
+	// the samples are NAL units prefixed with their length, the parameter
+	// sets are in the avcC box
+	sample := &bytes.Buffer{}
+	for _, nal := range f.NALUnits {
+		switch NALType(nal) {
+		case NALSPS, NALPPS, NALAUD:
+			continue
+		}
+		binary.Write(sample, binary.BigEndian, uint32(len(nal)))
+		sample.Write(nal)
+	}
+	if sample.Len() == 0 {
+		return nil
+	}
+	if _, err := m.w.Write(sample.Bytes()); err != nil {
+		return err
+	}
+
+	m.times = append(m.times, int64(f.Time.Sub(m.start))*mp4Timescale/int64(time.Second))
+	m.sizes = append(m.sizes, uint32(sample.Len()))
+	m.offsets = append(m.offsets, uint32(m.offset))
+	if f.Key {
+		m.keys = append(m.keys, uint32(len(m.sizes)))
+	}
+	m.offset += int64(sample.Len())
+	return nil
+}
+
+// parameters reads the SPS and PPS of f
+func (m *MP4Writer) parameters(f VideoFrame) bool {
+	var sps, pps []byte
+	for _, nal := range f.NALUnits {
+		switch NALType(nal) {
+		case NALSPS:
+			sps = nal
+		case NALPPS:
+			pps = nal
+		}
+	}
+	if sps == nil || pps == nil {
+		return false
+	}
+	s, err := ParseSPS(sps)
+	if err != nil {
+		return false
+	}
+	m.sps = append([]byte{}, sps...)
+	m.pps = append([]byte{}, pps...)
+	m.width, m.height = s.Width, s.Height
+	return true
+}
+
+func (m *MP4Writer) writeHeader() error {
+	header := &bytes.Buffer{}
+	header.Write(mp4Box("ftyp", []byte("isom"), u32(0x200), []byte("isomiso2avc1mp41")))
+	m.mdat = int64(header.Len())
+	// the size of the mdat box is written by Close
+	header.Write(mp4Box("mdat"))
+	if _, err := m.w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	m.offset = int64(header.Len())
+	return nil
+}
+
+// Close writes the index of the file, and closes the underlying writer
+func (m *MP4Writer) Close() error {
+	if m.sps == nil {
+		closeWriter(m.w)
+		return ErrNoVideoParameters
+	}
+
+	if _, err := m.w.Seek(m.mdat, os.SEEK_SET); err != nil {
+		return err
+	}
+	if _, err := m.w.Write(u32(uint32(m.offset - m.mdat))); err != nil {
+		return err
+	}
+	if _, err := m.w.Seek(m.offset, os.SEEK_SET); err != nil {
+		return err
+	}
+	if _, err := m.w.Write(m.moov()); err != nil {
+		return err
+	}
+	return closeWriter(m.w)
+}
+
+// durations returns the runs of the durations of the samples, the last one
+// lasting as long as the one before it
+func (m *MP4Writer) durations() (runs [][2]uint32, total uint32) {
+	for i := range m.times {
+		d := uint32(mp4Timescale / 30)
+		if i+1 < len(m.times) {
+			d = uint32(m.times[i+1] - m.times[i])
+		} else if i > 0 {
+			d = uint32(m.times[i] - m.times[i-1])
+		}
+		total += d
+		if n := len(runs); n > 0 && runs[n-1][1] == d {
+			runs[n-1][0]++
+		} else {
+			runs = append(runs, [2]uint32{1, d})
+		}
+	}
+	return
+}
+
+func (m *MP4Writer) moov() []byte {
+	runs, duration := m.durations()
+	matrix := [][]byte{
+		u32(0x10000), u32(0), u32(0),
+		u32(0), u32(0x10000), u32(0),
+		u32(0), u32(0), u32(0x40000000),
+	}
+
+	mvhd := mp4FullBox("mvhd", 0, 0,
+		u32(0), u32(0), u32(mp4Timescale), u32(duration),
+		u32(0x10000), u16(0x100), make([]byte, 10),
+		bytes.Join(matrix, nil), make([]byte, 24), u32(2),
+	)
+	tkhd := mp4FullBox("tkhd", 0, 3,
+		u32(0), u32(0), u32(1), u32(0), u32(duration), make([]byte, 8),
+		u16(0), u16(0), u16(0), u16(0), bytes.Join(matrix, nil),
+		u32(uint32(m.width)<<16), u32(uint32(m.height)<<16),
+	)
+	mdhd := mp4FullBox("mdhd", 0, 0,
+		u32(0), u32(0), u32(mp4Timescale), u32(duration), u16(0x55c4), u16(0),
+	)
+	hdlr := mp4FullBox("hdlr", 0, 0,
+		u32(0), []byte("vide"), make([]byte, 12), []byte("VideoHandler\x00"),
+	)
+
+	avcC := mp4Box("avcC",
+		[]byte{1, m.sps[1], m.sps[2], m.sps[3], 0xff, 0xe1},
+		u16(uint16(len(m.sps))), m.sps,
+		[]byte{1}, u16(uint16(len(m.pps))), m.pps,
+	)
+	avc1 := mp4Box("avc1",
+		make([]byte, 6), u16(1), make([]byte, 16),
+		u16(uint16(m.width)), u16(uint16(m.height)),
+		u32(0x480000), u32(0x480000), u32(0), u16(1), make([]byte, 32),
+		u16(0x18), u16(0xffff), avcC,
+	)
+
+	stts := [][]byte{u32(uint32(len(runs)))}
+	for _, run := range runs {
+		stts = append(stts, u32(run[0]), u32(run[1]))
+	}
+	stss := [][]byte{u32(uint32(len(m.keys)))}
+	for _, key := range m.keys {
+		stss = append(stss, u32(key))
+	}
+	stsz := [][]byte{u32(0), u32(uint32(len(m.sizes)))}
+	for _, size := range m.sizes {
+		stsz = append(stsz, u32(size))
+	}
+	stco := [][]byte{u32(uint32(len(m.offsets)))}
+	for _, offset := range m.offsets {
+		stco = append(stco, u32(offset))
+	}
+
+	stbl := mp4Box("stbl",
+		mp4FullBox("stsd", 0, 0, u32(1), avc1),
+		mp4FullBox("stts", 0, 0, stts...),
+		mp4FullBox("stss", 0, 0, stss...),
+		mp4FullBox("stsc", 0, 0, u32(1), u32(1), u32(1), u32(1)),
+		mp4FullBox("stsz", 0, 0, stsz...),
+		mp4FullBox("stco", 0, 0, stco...),
+	)
+	minf := mp4Box("minf",
+		mp4FullBox("vmhd", 0, 1, make([]byte, 8)),
+		mp4Box("dinf", mp4FullBox("dref", 0, 0, u32(1), mp4FullBox("url ", 0, 1))),
+		stbl,
+	)
+
+	return mp4Box("moov",
+		mvhd,
+		mp4Box("trak", tkhd, mp4Box("mdia", mdhd, hdlr, minf)),
+	)
+}
+
+// mp4Box returns the box of type typ, whose content is the concatenation
+// of fields
+func mp4Box(typ string, fields ...[]byte) []byte {
+	content := bytes.Join(fields, nil)
+	return bytes.Join([][]byte{u32(uint32(8 + len(content))), []byte(typ), content}, nil)
+}
+
+// mp4FullBox returns the box of type typ with a version and flags
+func mp4FullBox(typ string, version byte, flags uint32, fields ...[]byte) []byte {
+	header := u32(uint32(version)<<24 | flags&0xffffff)
+	return mp4Box(typ, append([][]byte{header}, fields...)...)
+}
+
+func u32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func u16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return b
+}
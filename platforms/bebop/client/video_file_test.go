@@ -0,0 +1,147 @@
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+type closingBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closingBuffer) Close() error {
+	c.closed = true
+	return nil
+}
+
+// testFrames are a P frame, then a key frame and a P frame 40ms apart
+func testFrames() []VideoFrame {
+	start := time.Now()
+	frame := func(n int, key bool, nals ...[]byte) VideoFrame {
+		return VideoFrame{
+			Number:   n,
+			Key:      key,
+			Data:     AnnexB(nals),
+			NALUnits: nals,
+			Time:     start.Add(time.Duration(n) * 40 * time.Millisecond),
+		}
+	}
+	return []VideoFrame{
+		frame(0, false, testP),
+		frame(1, true, baselineSPS, testPPS, testIDR),
+		frame(2, false, testP),
+	}
+}
+
+// mp4Find returns the content of the box at path in data
+func mp4Find(data []byte, path ...string) []byte {
+	for len(data) >= 8 {
+		size := binary.BigEndian.Uint32(data)
+		if size < 8 || int(size) > len(data) {
+			return nil
+		}
+		if string(data[4:8]) == path[0] {
+			if len(path) == 1 {
+				return data[8:size]
+			}
+			return mp4Find(data[8:size], path[1:]...)
+		}
+		data = data[size:]
+	}
+	return nil
+}
+
+func TestAnnexBWriter(t *testing.T) {
+	buf := &closingBuffer{}
+	w := NewAnnexBWriter(buf)
+	frames := testFrames()
+	for _, f := range frames {
+		gobottest.Assert(t, w.WriteFrame(f), nil)
+	}
+	gobottest.Assert(t, w.Close(), nil)
+	gobottest.Assert(t, buf.closed, true)
+	gobottest.Assert(t, buf.Bytes(), append(frames[1].Data, frames[2].Data...))
+}
+
+func TestMP4Writer(t *testing.T) {
+	file, err := ioutil.TempFile("", "bebop")
+	gobottest.Assert(t, err, nil)
+	defer os.Remove(file.Name())
+
+	w := NewMP4Writer(file)
+	for _, f := range testFrames() {
+		gobottest.Assert(t, w.WriteFrame(f), nil)
+	}
+	gobottest.Assert(t, w.Close(), nil)
+
+	data, err := ioutil.ReadFile(file.Name())
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, string(data[4:8]), "ftyp")
+
+	// the mdat box holds the length prefixed IDR and P slices
+	mdat := mp4Find(data, "mdat")
+	gobottest.Assert(t, len(mdat), 4+len(testIDR)+4+len(testP))
+
+	stbl := []string{"moov", "trak", "mdia", "minf", "stbl"}
+	gobottest.Assert(t, mp4Find(data, append(stbl, "stsz")...), []byte{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 9, 0, 0, 0, 8,
+	})
+	gobottest.Assert(t, mp4Find(data, append(stbl, "stss")...), []byte{
+		0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 1,
+	})
+	// 40ms at 90kHz
+	gobottest.Assert(t, mp4Find(data, append(stbl, "stts")...), []byte{
+		0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0x0e, 0x10,
+	})
+
+	stco := mp4Find(data, append(stbl, "stco")...)
+	offset := binary.BigEndian.Uint32(stco[8:])
+	gobottest.Assert(t, data[offset:offset+4+uint32(len(testIDR))], append([]byte{0, 0, 0, 5}, testIDR...))
+
+	avc1 := mp4Find(data, append(stbl, "stsd")...)[8:]
+	gobottest.Assert(t, string(avc1[4:8]), "avc1")
+	gobottest.Assert(t, binary.BigEndian.Uint16(avc1[8+24:]), uint16(640))
+	gobottest.Assert(t, binary.BigEndian.Uint16(avc1[8+26:]), uint16(368))
+	avcC := mp4Find(avc1[8+78:], "avcC")
+	gobottest.Assert(t, avcC[8:8+len(baselineSPS)], baselineSPS)
+}
+
+func TestMP4WriterWithoutParameters(t *testing.T) {
+	file, err := ioutil.TempFile("", "bebop")
+	gobottest.Assert(t, err, nil)
+	defer os.Remove(file.Name())
+
+	w := NewMP4Writer(file)
+	gobottest.Assert(t, w.WriteFrame(testFrames()[0]), nil)
+	gobottest.Assert(t, w.Close(), ErrNoVideoParameters)
+}
+
+func TestVideoSubscriptionPipe(t *testing.T) {
+	b, written := newTestBebop()
+	go func() {
+		for {
+			<-written
+		}
+	}()
+	s := b.SubscribeVideo(10)
+	buf := &closingBuffer{}
+
+	done := make(chan error)
+	go func() {
+		done <- s.Pipe(NewAnnexBWriter(buf))
+	}()
+	data := sendVideoFrame(b, 1, true, baselineSPS, testPPS, testIDR)
+	// the frames received before Close are still written
+	s.Close()
+
+	gobottest.Assert(t, <-done, nil)
+	gobottest.Assert(t, buf.closed, true)
+	gobottest.Assert(t, buf.Bytes(), data)
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// videoFragment returns the network frame of a fragment of the video frame
+// number
+func videoFragment(number int, flags int, fragment int, fragments int, data []byte) []byte {
+	buf := &bytes.Buffer{}
+	buf.Write([]byte{ARNETWORKAL_FRAME_TYPE_DATA_LOW_LATENCY, BD_NET_DC_VIDEO_DATA_ID, 0})
+	binary.Write(buf, binary.LittleEndian, uint32(7+5+len(data)))
+	binary.Write(buf, binary.LittleEndian, uint16(number))
+	buf.Write([]byte{byte(flags), byte(fragment), byte(fragments)})
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// sendVideoFrame sends the video frame number in fragments of 4 bytes, and
+// returns its data
+func sendVideoFrame(b *Bebop, number int, key bool, nals ...[]byte) []byte {
+	data := AnnexB(nals)
+	flags := 0
+	if key {
+		flags = 1
+	}
+	fragments := (len(data) + 3) / 4
+	for i := 0; i < fragments; i++ {
+		end := (i + 1) * 4
+		if end > len(data) {
+			end = len(data)
+		}
+		b.packetReceiver(videoFragment(number, flags, i, fragments, data[i*4:end]))
+	}
+	return data
+}
+
+func TestVideoReassembly(t *testing.T) {
+	b, written := newTestBebop()
+	s := b.SubscribeVideo(10)
+
+	// the fragments are acked as they come, in any order
+	data := AnnexB([][]byte{baselineSPS, testPPS, testIDR})
+	b.packetReceiver(videoFragment(7, 1, 1, 2, data[10:]))
+	ack := <-written
+	gobottest.Assert(t, ack[:3], []byte{ARNETWORKAL_FRAME_TYPE_DATA, BD_NET_CD_VIDEO_ACK_ID, 0x01})
+	gobottest.Assert(t, ack[7:], []byte{
+		0x07, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	})
+	b.packetReceiver(videoFragment(7, 1, 0, 2, data[:10]))
+	gobottest.Assert(t, (<-written)[17], byte(0x03))
+
+	f := <-s.Frames()
+	gobottest.Assert(t, f.Number, 7)
+	gobottest.Assert(t, f.Key, true)
+	gobottest.Assert(t, f.Data, data)
+	gobottest.Assert(t, f.NALUnits, [][]byte{baselineSPS, testPPS, testIDR})
+
+	// a new frame resets the ack
+	b.packetReceiver(videoFragment(8, 0, 0, 2, []byte{0x00, 0x00, 0x00, 0x01}))
+	gobottest.Assert(t, (<-written)[7:], []byte{
+		0x08, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	})
+
+	stats := b.VideoStats()
+	gobottest.Assert(t, stats.Frames, 1)
+	gobottest.Assert(t, stats.Fragments, 3)
+	gobottest.Assert(t, stats.Bytes, len(data))
+	gobottest.Assert(t, stats.Width, 640)
+	gobottest.Assert(t, stats.Height, 368)
+	gobottest.Assert(t, stats.MaxLatency >= stats.Latency, true)
+}
+
+func TestVideoLoss(t *testing.T) {
+	b, written := newTestBebop()
+	go func() {
+		for {
+			<-written
+		}
+	}()
+	s := b.SubscribeVideo(10)
+
+	// P frames are not sent before the first key frame
+	sendVideoFrame(b, 1, false, testP)
+	sendVideoFrame(b, 2, true, baselineSPS, testPPS, testIDR)
+	sendVideoFrame(b, 3, false, testP)
+	gobottest.Assert(t, (<-s.Frames()).Number, 2)
+	gobottest.Assert(t, (<-s.Frames()).Number, 3)
+
+	// frame 4 is incomplete and frame 5 is missing
+	b.packetReceiver(videoFragment(4, 0, 0, 2, AnnexB([][]byte{testP})))
+	sendVideoFrame(b, 6, false, testP)
+	// the key frames start with the last parameter sets
+	data := sendVideoFrame(b, 7, true, testIDR)
+	f := <-s.Frames()
+	gobottest.Assert(t, f.Number, 7)
+	gobottest.Assert(t, f.NALUnits, [][]byte{baselineSPS, testPPS, testIDR})
+	gobottest.Assert(t, f.Data, AnnexB(f.NALUnits))
+	gobottest.Assert(t, bytes.HasSuffix(f.Data, data), true)
+
+	// a late fragment is ignored
+	b.packetReceiver(videoFragment(6, 0, 0, 1, AnnexB([][]byte{testP})))
+	sendVideoFrame(b, 8, false, testP)
+	gobottest.Assert(t, (<-s.Frames()).Number, 8)
+
+	stats := b.VideoStats()
+	gobottest.Assert(t, stats.Frames, 6)
+	gobottest.Assert(t, stats.Lost, 2)
+}
+
+func TestVideoSubscriptions(t *testing.T) {
+	b, written := newTestBebop()
+	go func() {
+		for {
+			<-written
+		}
+	}()
+	slow := b.SubscribeVideo(1)
+	fast := b.SubscribeVideo(10)
+
+	sendVideoFrame(b, 1, true, baselineSPS, testPPS, testIDR)
+	sendVideoFrame(b, 2, false, testP)
+	sendVideoFrame(b, 3, true, testIDR)
+
+	for _, n := range []int{1, 2, 3} {
+		gobottest.Assert(t, (<-fast.Frames()).Number, n)
+	}
+	gobottest.Assert(t, fast.Dropped(), 0)
+
+	// the slow subscription lost frame 2, and then waited for frame 3
+	gobottest.Assert(t, (<-slow.Frames()).Number, 1)
+	gobottest.Assert(t, slow.Dropped(), 2)
+	sendVideoFrame(b, 4, false, testP)
+	sendVideoFrame(b, 5, true, testIDR)
+	gobottest.Assert(t, (<-slow.Frames()).Number, 5)
+
+	slow.Close()
+	slow.Close()
+	_, ok := <-slow.Frames()
+	gobottest.Assert(t, ok, false)
+	sendVideoFrame(b, 6, false, testP)
+	gobottest.Assert(t, (<-fast.Frames()).Number, 4)
+}
+
+func TestVideoLegacyChannel(t *testing.T) {
+	b, written := newTestBebop()
+	go func() {
+		for {
+			<-written
+		}
+	}()
+
+	received := make(chan []byte, 1)
+	go func() {
+		received <- <-b.Video()
+	}()
+
+	// the frames are dropped until the channel is received from
+	for n := 1; ; n++ {
+		data := sendVideoFrame(b, n, true, baselineSPS, testPPS, testIDR)
+		select {
+		case frame := <-received:
+			gobottest.Assert(t, frame, data)
+			return
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package simulator
+
+import (
+	"math"
+	"time"
+
+	"github.com/hybridgroup/gobot/platforms/bebop/client"
+)
+
+const (
+	// takeOffAltitude is the altitude the drone hovers at after taking off
+	takeOffAltitude = 1.0
+	// speedPerDegree is the horizontal speed of the drone for each degree
+	// of tilt, in m/s
+	speedPerDegree = 0.25
+	// autoSpeed is the speed of MoveBy and of the return home, in m/s
+	autoSpeed = 2.0
+	// batteryDrain is the battery used per second of flight, in percent
+	batteryDrain = 0.15
+)
+
+// Settings are the piloting settings of the simulated drone
+type Settings struct {
+	// MaxAltitude is the maximum altitude, in meters
+	MaxAltitude float64
+	// MaxTilt is the maximum tilt, in degrees
+	MaxTilt float64
+	// MaxVerticalSpeed is the maximum vertical speed, in m/s
+	MaxVerticalSpeed float64
+	// MaxRotationSpeed is the maximum rotation speed, in degrees/s
+	MaxRotationSpeed float64
+	// HullProtection and Outdoor are the settings of the same names
+	HullProtection bool
+	Outdoor        bool
+}
+
+// DefaultSettings are the settings of a new simulator
+var DefaultSettings = Settings{
+	MaxAltitude:      150,
+	MaxTilt:          20,
+	MaxVerticalSpeed: 1,
+	MaxRotationSpeed: 100,
+}
+
+// State is the state of the simulated drone
+type State struct {
+	FlyingState client.FlyingStateValue
+	// North, East and Altitude are the position of the drone from its take
+	// off point, in meters
+	North    float64
+	East     float64
+	Altitude float64
+	// Heading is the yaw of the drone in radians, clockwise from north
+	Heading float64
+	// Roll and Pitch are the tilt of the drone in radians, positive to the
+	// right and nose down
+	Roll  float64
+	Pitch float64
+	// Speed is the speed of the drone to the north, the east and down, in
+	// m/s
+	Speed client.SpeedValue
+	// Battery is the remaining battery in percent
+	Battery float64
+	// Pcmd is the last piloting command
+	Pcmd     client.Pcmd
+	Settings Settings
+}
+
+// target is the destination of MoveBy or of the return home
+type target struct {
+	north, east, altitude, heading float64
+	home                           bool
+}
+
+// model is the kinematic model of the drone: it climbs, moves and turns at
+// the speeds commanded by the sticks and limited by the settings, or flies
+// straight to its target
+type model struct {
+	State
+	target *target
+}
+
+func newModel() model {
+	return model{State: State{Battery: 100, Settings: DefaultSettings}}
+}
+
+// inFlight returns whether the drone can be piloted
+func (m *model) inFlight() bool {
+	return m.FlyingState == client.Hovering || m.FlyingState == client.Flying
+}
+
+// step moves the drone for dt
+func (m *model) step(dt time.Duration) {
+	seconds := dt.Seconds()
+	vNorth, vEast, vUp := 0.0, 0.0, 0.0
+	m.Roll, m.Pitch = 0, 0
+
+	switch m.FlyingState {
+	case client.TakingOff:
+		vUp = m.Settings.MaxVerticalSpeed
+		if m.Altitude+vUp*seconds >= takeOffAltitude {
+			vUp = (takeOffAltitude - m.Altitude) / seconds
+			m.FlyingState = client.Hovering
+		}
+	case client.Landing:
+		vUp = -m.Settings.MaxVerticalSpeed
+		if m.Altitude+vUp*seconds <= 0 {
+			m.land()
+			return
+		}
+	case client.Emergency:
+		m.land()
+		return
+	case client.Hovering, client.Flying:
+		if m.target != nil {
+			vNorth, vEast, vUp = m.flyToTarget(seconds)
+			break
+		}
+		tilt := m.Settings.MaxTilt * math.Pi / 180
+		if m.Pcmd.Flag != 0 {
+			m.Roll = float64(m.Pcmd.Roll) / 100 * tilt
+			m.Pitch = float64(m.Pcmd.Pitch) / 100 * tilt
+		}
+		forward := m.Pitch * 180 / math.Pi * speedPerDegree
+		right := m.Roll * 180 / math.Pi * speedPerDegree
+		sin, cos := math.Sincos(m.Heading)
+		vNorth = forward*cos - right*sin
+		vEast = forward*sin + right*cos
+		vUp = float64(m.Pcmd.Gaz) / 100 * m.Settings.MaxVerticalSpeed
+		m.Heading = normalizeAngle(m.Heading +
+			float64(m.Pcmd.Yaw)/100*m.Settings.MaxRotationSpeed*math.Pi/180*seconds)
+
+		if vNorth != 0 || vEast != 0 || vUp != 0 || m.Pcmd.Yaw != 0 {
+			m.FlyingState = client.Flying
+		} else {
+			m.FlyingState = client.Hovering
+		}
+	default:
+		return
+	}
+
+	m.North += vNorth * seconds
+	m.East += vEast * seconds
+	m.Altitude = math.Min(m.Altitude+vUp*seconds, m.Settings.MaxAltitude)
+	if m.Altitude < 0 {
+		m.Altitude = 0
+	}
+	m.Speed = client.SpeedValue{X: vNorth, Y: vEast, Z: -vUp}
+	m.Battery = math.Max(m.Battery-batteryDrain*seconds, 0)
+}
+
+// flyToTarget returns the speeds flying to the target, which is reached in
+// the time of seconds. The drone lands once it returned home.
+func (m *model) flyToTarget(seconds float64) (vNorth, vEast, vUp float64) {
+	m.FlyingState = client.Flying
+	dNorth, dEast, dUp := m.target.north-m.North, m.target.east-m.East, m.target.altitude-m.Altitude
+	distance := math.Sqrt(dNorth*dNorth + dEast*dEast + dUp*dUp)
+	if distance <= autoSpeed*seconds {
+		m.Heading = m.target.heading
+		if m.target.home {
+			m.FlyingState = client.Landing
+		} else {
+			m.FlyingState = client.Hovering
+		}
+		m.target = nil
+		return dNorth / seconds, dEast / seconds, dUp / seconds
+	}
+	scale := autoSpeed / distance
+	return dNorth * scale, dEast * scale, dUp * scale
+}
+
+// land puts the drone on the ground
+func (m *model) land() {
+	m.FlyingState = client.Landed
+	m.Altitude = 0
+	m.Roll, m.Pitch = 0, 0
+	m.Speed = client.SpeedValue{}
+	m.target = nil
+}
+
+// moveBy sets the target of MoveBy, dx forward, dy right and dz down from
+// the drone, and dpsi clockwise
+func (m *model) moveBy(dx, dy, dz, dpsi float64) {
+	sin, cos := math.Sincos(m.Heading)
+	m.target = &target{
+		north:    m.North + dx*cos - dy*sin,
+		east:     m.East + dx*sin + dy*cos,
+		altitude: math.Max(m.Altitude-dz, 0),
+		heading:  normalizeAngle(m.Heading + dpsi),
+	}
+}
+
+// navigateHome sets the take off point as the target
+func (m *model) navigateHome() {
+	m.target = &target{altitude: m.Altitude, heading: m.Heading, home: true}
+}
+
+// normalizeAngle returns a in the range -Pi to Pi
+func normalizeAngle(a float64) float64 {
+	for a > math.Pi {
+		a -= 2 * math.Pi
+	}
+	for a < -math.Pi {
+		a += 2 * math.Pi
+	}
+	return a
+}
@@ -0,0 +1,673 @@
+// Package simulator provides a simulated Bebop, which speaks the discovery
+// handshake and the ARNetworkAL protocol of the drone over local sockets,
+// so that the client and the gobot drivers can be tested without a drone.
+package simulator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot/platforms/bebop/client"
+)
+
+// Latitude and longitude of the take off point of the simulated drone
+const (
+	HomeLatitude  = 48.8785
+	HomeLongitude = 2.3677
+)
+
+// metersPerDegree is the length of a degree of latitude
+const metersPerDegree = 111320.0
+
+// maxEventTries is the number of times an event is sent until the client
+// acknowledges it
+const maxEventTries = 5
+
+// command identifies an ARCommand
+type command struct {
+	project, class byte
+	id             uint16
+}
+
+// event is an event sent on the acknowledged buffer, until the client
+// acknowledges it
+type event struct {
+	frame []byte
+	tries int
+}
+
+// Simulator is a simulated Bebop. The client connects to it with the IP
+// and the ports of the simulator:
+//
+//	s := simulator.New()
+//	s.Start("127.0.0.1")
+//	drone := client.New()
+//	drone.IP, drone.DiscoveryPort = "127.0.0.1", s.DiscoveryPort
+type Simulator struct {
+	// Interval is the period of the model and of the navigation data, 50ms
+	// by default
+	Interval time.Duration
+	// PingInterval is the period of the pings, 1s by default
+	PingInterval time.Duration
+	// FragmentSize is the maximum size of the video fragments, 1000 bytes
+	// by default
+	FragmentSize int
+	// DiscoveryPort is the TCP port of the discovery handshake, and C2dPort
+	// is the UDP port of the commands. Start sets them.
+	DiscoveryPort int
+	C2dPort       int
+
+	mutex      sync.Mutex
+	model      model
+	gpsFix     bool
+	alert      client.AlertValue
+	home       client.NavigateHomeValue
+	video      client.MediaValue
+	received   map[command]int
+	dropAcks   int
+	pongs      int
+	seq        map[byte]byte
+	lastSeq    map[byte]int
+	events     map[byte]*event
+	videoFrame uint16
+	videoAcks  map[int]uint64
+	published  *published
+	d2cAddr    *net.UDPAddr
+	discovery  *net.TCPListener
+	conn       *net.UDPConn
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// New returns a landed Simulator with a full battery and a GPS fix
+func New() *Simulator {
+	return &Simulator{
+		Interval:     50 * time.Millisecond,
+		PingInterval: time.Second,
+		FragmentSize: 1000,
+		model:        newModel(),
+		gpsFix:       true,
+		received:     make(map[command]int),
+		seq:          make(map[byte]byte),
+		lastSeq:      make(map[byte]int),
+		events:       make(map[byte]*event),
+		videoAcks:    make(map[int]uint64),
+		done:         make(chan struct{}),
+	}
+}
+
+// Start listens for the client on free ports of ip, and starts the model
+func (s *Simulator) Start(ip string) (err error) {
+	s.discovery, err = net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP(ip)})
+	if err != nil {
+		return err
+	}
+	s.conn, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP(ip)})
+	if err != nil {
+		s.discovery.Close()
+		return err
+	}
+	s.DiscoveryPort = s.discovery.Addr().(*net.TCPAddr).Port
+	s.C2dPort = s.conn.LocalAddr().(*net.UDPAddr).Port
+
+	go s.discover()
+	go s.receive()
+	go s.run()
+	return nil
+}
+
+// Close stops the simulator
+func (s *Simulator) Close() (err error) {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.discovery.Close()
+		err = s.conn.Close()
+	})
+	return
+}
+
+// State returns the state of the simulated drone
+func (s *Simulator) State() State {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.model.State
+}
+
+// SetBattery sets the remaining battery, in percent
+func (s *Simulator) SetBattery(percent float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.model.Battery = percent
+}
+
+// SetGPSFix sets whether the GPS has a fix
+func (s *Simulator) SetGPSFix(fixed bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.gpsFix = fixed
+}
+
+// DropAcks makes the simulator ignore the next n acknowledged commands, as
+// if they were lost, so that the client sends them again
+func (s *Simulator) DropAcks(n int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.dropAcks = n
+}
+
+// Received returns the number of commands of project, class and id
+// received, the commands sent again are counted once
+func (s *Simulator) Received(project, class byte, id uint16) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.received[command{project, class, id}]
+}
+
+// Pongs returns the number of pongs received
+func (s *Simulator) Pongs() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.pongs
+}
+
+// SendVideoFrame sends the Annex-B frame data in fragments, and returns its
+// number
+func (s *Simulator) SendVideoFrame(data []byte, key bool) (int, error) {
+	s.mutex.Lock()
+	s.videoFrame++
+	number := s.videoFrame
+	s.mutex.Unlock()
+
+	flags := byte(0)
+	if key {
+		flags = 1
+	}
+	fragments := (len(data) + s.FragmentSize - 1) / s.FragmentSize
+	for i := 0; i < fragments; i++ {
+		end := (i + 1) * s.FragmentSize
+		if end > len(data) {
+			end = len(data)
+		}
+		buf := &bytes.Buffer{}
+		binary.Write(buf, binary.LittleEndian, number)
+		buf.Write([]byte{flags, byte(i), byte(fragments)})
+		buf.Write(data[i*s.FragmentSize : end])
+		if err := s.send(client.ARNETWORKAL_FRAME_TYPE_DATA_LOW_LATENCY, client.BD_NET_DC_VIDEO_DATA_ID, buf.Bytes()); err != nil {
+			return 0, err
+		}
+	}
+	return int(number), nil
+}
+
+// VideoAcks returns the number of fragments of the video frame number
+// acknowledged by the client
+func (s *Simulator) VideoAcks(number int) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	n := 0
+	for acks := s.videoAcks[number]; acks != 0; acks &= acks - 1 {
+		n++
+	}
+	return n
+}
+
+// discover answers the discovery handshakes, and sends to the d2c port of
+// the client from then on
+func (s *Simulator) discover() {
+	for {
+		conn, err := s.discovery.AcceptTCP()
+		if err != nil {
+			return
+		}
+		buf := make([]byte, 1024)
+		n, err := conn.Read(buf)
+		if err != nil {
+			conn.Close()
+			continue
+		}
+
+		// the d2c port is a string or a number depending on the client
+		var request map[string]interface{}
+		json.Unmarshal(bytes.TrimRight(buf[:n], "\x00"), &request)
+		port := 0
+		switch p := request["d2c_port"].(type) {
+		case string:
+			port, _ = strconv.Atoi(p)
+		case float64:
+			port = int(p)
+		}
+		if port == 0 {
+			conn.Close()
+			continue
+		}
+
+		s.mutex.Lock()
+		s.d2cAddr = &net.UDPAddr{IP: conn.RemoteAddr().(*net.TCPAddr).IP, Port: port}
+		s.mutex.Unlock()
+
+		response, _ := json.Marshal(map[string]int{
+			"status":                           0,
+			"c2d_port":                         s.C2dPort,
+			"arstream_fragment_size":           s.FragmentSize,
+			"arstream_fragment_maximum_number": 128,
+		})
+		conn.Write(append(response, 0))
+		conn.Close()
+	}
+}
+
+// receive handles the frames sent by the client
+func (s *Simulator) receive() {
+	buf := make([]byte, 65536)
+	for {
+		n, err := s.conn.Read(buf)
+		if err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
+				continue
+			}
+		}
+		data := buf[:n]
+		for len(data) >= 7 {
+			size := int(binary.LittleEndian.Uint32(data[3:7]))
+			if size < 7 || size > len(data) {
+				break
+			}
+			s.frameReceiver(client.NewNetworkFrame(data[:size]))
+			data = data[size:]
+		}
+	}
+}
+
+func (s *Simulator) frameReceiver(frame client.NetworkFrame) {
+	switch {
+	case frame.Type == int(client.ARNETWORKAL_FRAME_TYPE_ACK):
+		if len(frame.Data) > 0 {
+			s.mutex.Lock()
+			id := byte(frame.Id - int(client.ARNETWORKAL_MANAGER_DEFAULT_ID_MAX/2))
+			if id == client.BD_NET_DC_EVENT_ID {
+				delete(s.events, frame.Data[0])
+			}
+			s.mutex.Unlock()
+		}
+	case frame.Id == int(client.ARNETWORK_MANAGER_INTERNAL_BUFFER_ID_PONG):
+		s.mutex.Lock()
+		s.pongs++
+		s.mutex.Unlock()
+	case frame.Id == int(client.BD_NET_CD_VIDEO_ACK_ID):
+		if len(frame.Data) >= 18 {
+			number := int(binary.LittleEndian.Uint16(frame.Data))
+			s.mutex.Lock()
+			s.videoAcks[number] |= binary.LittleEndian.Uint64(frame.Data[10:])
+			s.mutex.Unlock()
+		}
+	case frame.Type == int(client.ARNETWORKAL_FRAME_TYPE_DATA_WITH_ACK):
+		s.mutex.Lock()
+		if s.dropAcks > 0 {
+			s.dropAcks--
+			s.mutex.Unlock()
+			return
+		}
+		// a command sent again is acknowledged again, but done once
+		repeated := s.lastSeq[byte(frame.Id)] == frame.Seq
+		s.lastSeq[byte(frame.Id)] = frame.Seq
+		s.mutex.Unlock()
+
+		s.send(client.ARNETWORKAL_FRAME_TYPE_ACK,
+			byte(uint16(frame.Id)+client.ARNETWORKAL_MANAGER_DEFAULT_ID_MAX/2),
+			[]byte{byte(frame.Seq)},
+		)
+		if !repeated {
+			s.decodeCommand(frame.Data)
+		}
+	case frame.Type == int(client.ARNETWORKAL_FRAME_TYPE_DATA):
+		s.decodeCommand(frame.Data)
+	}
+}
+
+// decodeCommand applies an ARCommand sent by the client
+func (s *Simulator) decodeCommand(data []byte) {
+	if len(data) < 4 {
+		return
+	}
+	cmd := command{data[0], data[1], binary.LittleEndian.Uint16(data[2:4])}
+	r := bytes.NewReader(data[4:])
+	read := func(v interface{}) bool {
+		return binary.Read(r, binary.LittleEndian, v) == nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.received[cmd]++
+	m := &s.model
+
+	switch cmd.project {
+	case client.ARCOMMANDS_ID_PROJECT_COMMON:
+		if cmd.class == client.ARCOMMANDS_ID_COMMON_CLASS_COMMON &&
+			cmd.id == uint16(client.ARCOMMANDS_ID_COMMON_COMMON_CMD_ALLSTATES) {
+			// the next step publishes all the states
+			s.published = nil
+		}
+	case client.ARCOMMANDS_ID_PROJECT_ARDRONE3:
+		switch cmd.class {
+		case client.ARCOMMANDS_ID_ARDRONE3_CLASS_PILOTING:
+			s.decodePiloting(cmd.id, read)
+		case client.ARCOMMANDS_ID_ARDRONE3_CLASS_PILOTINGSETTINGS:
+			var v float32
+			if !read(&v) {
+				return
+			}
+			switch byte(cmd.id) {
+			case client.ARCOMMANDS_ID_ARDRONE3_PILOTINGSETTINGS_CMD_MAXALTITUDE:
+				m.Settings.MaxAltitude = float64(v)
+			case client.ARCOMMANDS_ID_ARDRONE3_PILOTINGSETTINGS_CMD_MAXTILT:
+				m.Settings.MaxTilt = float64(v)
+			}
+		case client.ARCOMMANDS_ID_ARDRONE3_CLASS_SPEEDSETTINGS:
+			switch byte(cmd.id) {
+			case client.ARCOMMANDS_ID_ARDRONE3_SPEEDSETTINGS_CMD_MAXVERTICALSPEED,
+				client.ARCOMMANDS_ID_ARDRONE3_SPEEDSETTINGS_CMD_MAXROTATIONSPEED:
+				var v float32
+				if !read(&v) {
+					return
+				}
+				if byte(cmd.id) == client.ARCOMMANDS_ID_ARDRONE3_SPEEDSETTINGS_CMD_MAXVERTICALSPEED {
+					m.Settings.MaxVerticalSpeed = float64(v)
+				} else {
+					m.Settings.MaxRotationSpeed = float64(v)
+				}
+			case client.ARCOMMANDS_ID_ARDRONE3_SPEEDSETTINGS_CMD_HULLPROTECTION,
+				client.ARCOMMANDS_ID_ARDRONE3_SPEEDSETTINGS_CMD_OUTDOOR:
+				var v uint8
+				if !read(&v) {
+					return
+				}
+				if byte(cmd.id) == client.ARCOMMANDS_ID_ARDRONE3_SPEEDSETTINGS_CMD_HULLPROTECTION {
+					m.Settings.HullProtection = v == 1
+				} else {
+					m.Settings.Outdoor = v == 1
+				}
+			}
+		case client.ARCOMMANDS_ID_ARDRONE3_CLASS_MEDIARECORD:
+			s.decodeMediaRecord(cmd.id, read)
+		}
+	}
+}
+
+// decodePiloting applies a piloting command, with the lock held
+func (s *Simulator) decodePiloting(id uint16, read func(interface{}) bool) {
+	m := &s.model
+	switch byte(id) {
+	case client.ARCOMMANDS_ID_ARDRONE3_PILOTING_CMD_TAKEOFF:
+		if m.FlyingState == client.Landed {
+			m.FlyingState = client.TakingOff
+		}
+	case client.ARCOMMANDS_ID_ARDRONE3_PILOTING_CMD_LANDING:
+		if m.FlyingState != client.Landed && m.FlyingState != client.Emergency {
+			m.FlyingState = client.Landing
+			m.target = nil
+		}
+	case client.ARCOMMANDS_ID_ARDRONE3_PILOTING_CMD_EMERGENCY:
+		if m.FlyingState != client.Landed {
+			m.FlyingState = client.Emergency
+			s.alert = client.UserEmergencyAlert
+		}
+	case client.ARCOMMANDS_ID_ARDRONE3_PILOTING_CMD_PCMD:
+		var pcmd struct {
+			Flag                  uint8
+			Roll, Pitch, Yaw, Gaz int8
+		}
+		if read(&pcmd) {
+			m.Pcmd = client.Pcmd{
+				Flag:  int(pcmd.Flag),
+				Roll:  int(pcmd.Roll),
+				Pitch: int(pcmd.Pitch),
+				Yaw:   int(pcmd.Yaw),
+				Gaz:   int(pcmd.Gaz),
+			}
+		}
+	case client.ARCOMMANDS_ID_ARDRONE3_PILOTING_CMD_MOVEBY:
+		var d [4]float32
+		if read(&d) && m.inFlight() {
+			m.moveBy(float64(d[0]), float64(d[1]), float64(d[2]), float64(d[3]))
+		}
+	case client.ARCOMMANDS_ID_ARDRONE3_PILOTING_CMD_NAVIGATEHOME:
+		var start uint8
+		if !read(&start) {
+			return
+		}
+		switch {
+		case start == 1 && m.inFlight():
+			m.navigateHome()
+			s.home = client.NavigateHomeValue{State: 1, Reason: 0}
+		case start == 0 && m.target != nil && m.target.home:
+			m.target = nil
+			s.home = client.NavigateHomeValue{State: 0, Reason: 4}
+		}
+	}
+}
+
+// decodeMediaRecord takes the pictures and records the video, with the lock
+// held
+func (s *Simulator) decodeMediaRecord(id uint16, read func(interface{}) bool) {
+	switch byte(id) {
+	case client.ARCOMMANDS_ID_ARDRONE3_MEDIARECORD_CMD_PICTUREV2:
+		// the picture is taken at once
+		s.sendEvent(client.ARCOMMANDS_ID_ARDRONE3_CLASS_MEDIARECORDEVENT,
+			client.ARCOMMANDS_ID_ARDRONE3_MEDIARECORDEVENT_CMD_PICTUREEVENTCHANGED,
+			int32(0), int32(0))
+	case client.ARCOMMANDS_ID_ARDRONE3_MEDIARECORD_CMD_VIDEO:
+		var record uint32
+		if !read(&record) {
+			return
+		}
+		s.video = client.MediaValue{State: int(record)}
+		s.sendEvent(client.ARCOMMANDS_ID_ARDRONE3_CLASS_MEDIARECORDSTATE,
+			client.ARCOMMANDS_ID_ARDRONE3_MEDIARECORDSTATE_CMD_VIDEOSTATECHANGEDV2,
+			int32(s.video.State), int32(s.video.Error))
+	}
+}
+
+// run steps the model, and sends the navigation data and the pings
+func (s *Simulator) run() {
+	last := time.Now()
+	lastPing := last
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-time.After(s.Interval):
+		}
+		now := time.Now()
+
+		s.mutex.Lock()
+		s.model.step(now.Sub(last))
+		last = now
+		if s.d2cAddr != nil {
+			s.publish()
+			s.resendEvents()
+		}
+		s.mutex.Unlock()
+
+		if now.Sub(lastPing) >= s.PingInterval {
+			lastPing = now
+			ping := &bytes.Buffer{}
+			binary.Write(ping, binary.LittleEndian, uint32(now.Unix()))
+			binary.Write(ping, binary.LittleEndian, uint32(now.Nanosecond()))
+			s.send(client.ARNETWORKAL_FRAME_TYPE_DATA, client.ARNETWORK_MANAGER_INTERNAL_BUFFER_ID_PING, ping.Bytes())
+		}
+	}
+}
+
+// published are the states last sent to the client
+type published struct {
+	flyingState client.FlyingStateValue
+	battery     int
+	alert       client.AlertValue
+	gpsFix      bool
+	home        client.NavigateHomeValue
+}
+
+// publish sends the navigation data, and the events of the states which
+// changed since they were last published, with the lock held. All the
+// events are sent after AllStates.
+func (s *Simulator) publish() {
+	m := &s.model
+
+	battery := int(math.Ceil(m.Battery))
+	switch {
+	case battery <= 5:
+		s.setAlert(client.CriticalBatteryAlert)
+		// the drone lands on its own
+		if m.inFlight() {
+			m.FlyingState = client.Landing
+			m.target = nil
+		}
+	case battery <= 20:
+		s.setAlert(client.LowBatteryAlert)
+	}
+	if m.FlyingState == client.Landed && s.home.State == 1 {
+		s.home = client.NavigateHomeValue{State: 0, Reason: 3}
+	}
+
+	p, all := s.published, s.published == nil
+	if all {
+		p = &published{}
+		s.published = p
+	}
+	if all || m.FlyingState != p.flyingState {
+		p.flyingState = m.FlyingState
+		s.sendEvent(client.ARCOMMANDS_ID_ARDRONE3_CLASS_PILOTINGSTATE,
+			client.ARCOMMANDS_ID_ARDRONE3_PILOTINGSTATE_CMD_FLYINGSTATECHANGED,
+			int32(m.FlyingState))
+	}
+	if all || battery != p.battery {
+		p.battery = battery
+		s.sendEvent(client.ARCOMMANDS_ID_COMMON_CLASS_COMMONSTATE,
+			client.ARCOMMANDS_ID_COMMON_COMMONSTATE_CMD_BATTERYSTATECHANGED,
+			uint8(battery))
+	}
+	if all || s.alert != p.alert {
+		p.alert = s.alert
+		s.sendEvent(client.ARCOMMANDS_ID_ARDRONE3_CLASS_PILOTINGSTATE,
+			client.ARCOMMANDS_ID_ARDRONE3_PILOTINGSTATE_CMD_ALERTSTATECHANGED,
+			int32(s.alert))
+	}
+	if all || s.gpsFix != p.gpsFix {
+		p.gpsFix = s.gpsFix
+		fixed := uint8(0)
+		if s.gpsFix {
+			fixed = 1
+		}
+		s.sendEvent(client.ARCOMMANDS_ID_ARDRONE3_CLASS_GPSSETTINGSSTATE,
+			client.ARCOMMANDS_ID_ARDRONE3_GPSSETTINGSSTATE_CMD_GPSFIXSTATECHANGED,
+			fixed)
+	}
+	if all || s.home != p.home {
+		p.home = s.home
+		s.sendEvent(client.ARCOMMANDS_ID_ARDRONE3_CLASS_PILOTINGSTATE,
+			client.ARCOMMANDS_ID_ARDRONE3_PILOTINGSTATE_CMD_NAVIGATEHOMESTATECHANGED,
+			int32(s.home.State), int32(s.home.Reason))
+	}
+
+	s.sendNavdata(client.ARCOMMANDS_ID_ARDRONE3_PILOTINGSTATE_CMD_ALTITUDECHANGED, m.Altitude)
+	s.sendNavdata(client.ARCOMMANDS_ID_ARDRONE3_PILOTINGSTATE_CMD_ATTITUDECHANGED,
+		float32(m.Roll), float32(m.Pitch), float32(m.Heading))
+	s.sendNavdata(client.ARCOMMANDS_ID_ARDRONE3_PILOTINGSTATE_CMD_SPEEDCHANGED,
+		float32(m.Speed.X), float32(m.Speed.Y), float32(m.Speed.Z))
+	latitude, longitude, altitude := client.PositionUnset, client.PositionUnset, client.PositionUnset
+	if s.gpsFix {
+		latitude = HomeLatitude + m.North/metersPerDegree
+		longitude = HomeLongitude + m.East/(metersPerDegree*math.Cos(HomeLatitude*math.Pi/180))
+		altitude = m.Altitude
+	}
+	s.sendNavdata(client.ARCOMMANDS_ID_ARDRONE3_PILOTINGSTATE_CMD_POSITIONCHANGED, latitude, longitude, altitude)
+}
+
+// setAlert raises alert, unless a user emergency was raised
+func (s *Simulator) setAlert(alert client.AlertValue) {
+	if s.alert != client.UserEmergencyAlert {
+		s.alert = alert
+	}
+}
+
+// sendEvent sends the ARCommand of class and id with args on the
+// acknowledged event buffer, with the lock held. It is sent again until the
+// client acknowledges it.
+func (s *Simulator) sendEvent(class byte, id byte, args ...interface{}) {
+	project := client.ARCOMMANDS_ID_PROJECT_ARDRONE3
+	if class == client.ARCOMMANDS_ID_COMMON_CLASS_COMMONSTATE {
+		project = client.ARCOMMANDS_ID_PROJECT_COMMON
+	}
+	frame := s.frame(client.ARNETWORKAL_FRAME_TYPE_DATA_WITH_ACK, client.BD_NET_DC_EVENT_ID,
+		newCommand(project, class, id, args...))
+	s.events[frame[2]] = &event{frame: frame}
+	s.write(frame)
+}
+
+// resendEvents sends the events the client did not acknowledge again, with
+// the lock held
+func (s *Simulator) resendEvents() {
+	for seq, e := range s.events {
+		e.tries++
+		if e.tries >= maxEventTries {
+			delete(s.events, seq)
+			continue
+		}
+		s.write(e.frame)
+	}
+}
+
+// sendNavdata sends the piloting state of id with args on the navigation
+// data buffer, with the lock held
+func (s *Simulator) sendNavdata(id byte, args ...interface{}) {
+	s.write(s.frame(client.ARNETWORKAL_FRAME_TYPE_DATA, client.BD_NET_DC_NAVDATA_ID,
+		newCommand(client.ARCOMMANDS_ID_PROJECT_ARDRONE3, client.ARCOMMANDS_ID_ARDRONE3_CLASS_PILOTINGSTATE, id, args...)))
+}
+
+// send sends data in a frame of frameType on the buffer id
+func (s *Simulator) send(frameType byte, id byte, data []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.write(s.frame(frameType, id, data))
+}
+
+// frame returns the ARNetworkAL frame of data, with the next sequence
+// number of the buffer id, with the lock held
+func (s *Simulator) frame(frameType byte, id byte, data []byte) []byte {
+	s.seq[id]++
+	buf := &bytes.Buffer{}
+	buf.Write([]byte{frameType, id, s.seq[id]})
+	binary.Write(buf, binary.LittleEndian, uint32(7+len(data)))
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// write sends frame to the client, with the lock held
+func (s *Simulator) write(frame []byte) error {
+	if s.d2cAddr == nil {
+		return fmt.Errorf("No client is connected")
+	}
+	_, err := s.conn.WriteToUDP(frame, s.d2cAddr)
+	return err
+}
+
+// newCommand returns the ARCommand of project, class and id, followed by
+// the little endian args
+func newCommand(project, class, id byte, args ...interface{}) []byte {
+	buf := &bytes.Buffer{}
+	buf.Write([]byte{project, class, id, 0})
+	for _, arg := range args {
+		binary.Write(buf, binary.LittleEndian, arg)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,223 @@
+package simulator
+
+import (
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/platforms/bebop/client"
+)
+
+// freePort returns a free UDP port of the loopback interface
+func freePort(t *testing.T) int {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	gobottest.Assert(t, err, nil)
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// connect returns a started simulator, and a client connected to it
+func connect(t *testing.T) (*Simulator, *client.Bebop) {
+	s := New()
+	s.Interval = 10 * time.Millisecond
+	s.PingInterval = 50 * time.Millisecond
+	gobottest.Assert(t, s.Start("127.0.0.1"), nil)
+
+	b := client.New()
+	b.IP = "127.0.0.1"
+	b.DiscoveryPort = s.DiscoveryPort
+	b.D2cPort = freePort(t)
+	gobottest.Assert(t, b.Connect(), nil)
+	return s, b
+}
+
+// eventually fails the test unless f returns true within 5 seconds
+func eventually(t *testing.T, what string, f func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s did not happen", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func flyingState(b *client.Bebop, state client.FlyingStateValue) func() bool {
+	return func() bool { return b.State().FlyingState == state }
+}
+
+func TestSimulatorConnect(t *testing.T) {
+	s, b := connect(t)
+	defer s.Close()
+	defer b.Close()
+
+	gobottest.Assert(t, b.C2dPort, s.C2dPort)
+	gobottest.Assert(t, s.Received(client.ARCOMMANDS_ID_PROJECT_COMMON,
+		client.ARCOMMANDS_ID_COMMON_CLASS_COMMON, uint16(client.ARCOMMANDS_ID_COMMON_COMMON_CMD_ALLSTATES)), 1)
+	gobottest.Assert(t, s.Received(client.ARCOMMANDS_ID_PROJECT_ARDRONE3,
+		client.ARCOMMANDS_ID_ARDRONE3_CLASS_PILOTING, uint16(client.ARCOMMANDS_ID_ARDRONE3_PILOTING_CMD_FLATTRIM)), 1)
+
+	eventually(t, "state push", func() bool {
+		state := b.State()
+		return state.Battery == 100 && state.GPSFix && state.Position.Valid()
+	})
+	gobottest.Assert(t, b.State().Position.Latitude, HomeLatitude)
+	eventually(t, "pong", func() bool { return s.Pongs() > 0 })
+
+	gobottest.Assert(t, b.Close(), nil)
+	gobottest.Assert(t, b.TakeOff(), client.ErrClosed)
+}
+
+func TestSimulatorFlight(t *testing.T) {
+	s, b := connect(t)
+	defer s.Close()
+	defer b.Close()
+
+	gobottest.Assert(t, b.TakeOff(), nil)
+	eventually(t, "take off", flyingState(b, client.Hovering))
+	gobottest.Assert(t, s.State().Altitude, 1.0)
+
+	b.Forward(50)
+	eventually(t, "flight forward", func() bool {
+		return b.State().FlyingState == client.Flying && b.State().Speed.X > 0 && s.State().North > 0.1
+	})
+	b.Stop()
+	b.Clockwise(100)
+	eventually(t, "turn", func() bool { return s.State().Heading > 0.5 })
+	b.Stop()
+	eventually(t, "hover", flyingState(b, client.Hovering))
+
+	gobottest.Assert(t, b.Land(), nil)
+	eventually(t, "landing", flyingState(b, client.Landed))
+	gobottest.Assert(t, s.State().Altitude, 0.0)
+}
+
+func TestSimulatorMoveByAndNavigateHome(t *testing.T) {
+	s, b := connect(t)
+	defer s.Close()
+	defer b.Close()
+
+	gobottest.Assert(t, b.TakeOff(), nil)
+	eventually(t, "take off", flyingState(b, client.Hovering))
+
+	gobottest.Assert(t, b.MoveBy(1, 0, -0.5, math.Pi/2), nil)
+	eventually(t, "move by", func() bool {
+		state := s.State()
+		return state.FlyingState == client.Hovering && state.North > 0.99
+	})
+	state := s.State()
+	gobottest.Assert(t, math.Abs(state.Altitude-1.5) < 1e-9, true)
+	// MoveBy sends float32 values
+	gobottest.Assert(t, math.Abs(state.Heading-math.Pi/2) < 1e-6, true)
+
+	gobottest.Assert(t, b.NavigateHome(true), nil)
+	eventually(t, "return home", flyingState(b, client.Landed))
+	gobottest.Assert(t, math.Abs(s.State().North) < 1e-9, true)
+	eventually(t, "return home end", func() bool {
+		return b.State().NavigateHome == client.NavigateHomeValue{State: 0, Reason: 3}
+	})
+}
+
+func TestSimulatorAcks(t *testing.T) {
+	s, b := connect(t)
+	defer s.Close()
+	defer b.Close()
+
+	taken := make(chan client.MediaValue, 1)
+	b.OnEvent(func(e client.Event) {
+		if e.Name == client.PictureTaken {
+			taken <- e.Data.(client.MediaValue)
+		}
+	})
+
+	// the picture is taken once, although the command is sent three times
+	s.DropAcks(2)
+	gobottest.Assert(t, b.TakePicture(), nil)
+	gobottest.Assert(t, s.Received(client.ARCOMMANDS_ID_PROJECT_ARDRONE3,
+		client.ARCOMMANDS_ID_ARDRONE3_CLASS_MEDIARECORD, uint16(client.ARCOMMANDS_ID_ARDRONE3_MEDIARECORD_CMD_PICTUREV2)), 1)
+	select {
+	case picture := <-taken:
+		gobottest.Assert(t, picture, client.MediaValue{})
+	case <-time.After(time.Second):
+		t.Errorf("PictureTaken event was not received")
+	}
+
+	gobottest.Assert(t, b.MaxAltitude(10), nil)
+	gobottest.Assert(t, b.MaxVerticalSpeed(2), nil)
+	gobottest.Assert(t, b.HullProtection(true), nil)
+	settings := s.State().Settings
+	gobottest.Assert(t, settings.MaxAltitude, 10.0)
+	gobottest.Assert(t, settings.MaxVerticalSpeed, 2.0)
+	gobottest.Assert(t, settings.HullProtection, true)
+
+	s.DropAcks(10)
+	gobottest.Assert(t, b.MaxTilt(5), client.ErrNoAck)
+}
+
+func TestSimulatorBattery(t *testing.T) {
+	s, b := connect(t)
+	defer s.Close()
+	defer b.Close()
+
+	gobottest.Assert(t, b.TakeOff(), nil)
+	eventually(t, "take off", flyingState(b, client.Hovering))
+
+	s.SetBattery(15)
+	eventually(t, "low battery", func() bool {
+		return b.State().Alert == client.LowBatteryAlert && b.State().Battery == 15
+	})
+
+	// the drone lands on a critical battery
+	s.SetBattery(4)
+	eventually(t, "critical battery", func() bool {
+		return b.State().Alert == client.CriticalBatteryAlert
+	})
+	eventually(t, "landing", flyingState(b, client.Landed))
+}
+
+func TestSimulatorEmergency(t *testing.T) {
+	s, b := connect(t)
+	defer s.Close()
+	defer b.Close()
+
+	s.SetGPSFix(false)
+	eventually(t, "GPS fix loss", func() bool { return !b.State().GPSFix && !b.State().Position.Valid() })
+	b.RequireGPSFix(true)
+	gobottest.Assert(t, b.TakeOff(), client.ErrNoGPSFix)
+	b.RequireGPSFix(false)
+
+	gobottest.Assert(t, b.TakeOff(), nil)
+	eventually(t, "take off", flyingState(b, client.Hovering))
+	gobottest.Assert(t, b.Emergency(), nil)
+	eventually(t, "emergency", flyingState(b, client.Landed))
+	gobottest.Assert(t, b.State().Alert, client.UserEmergencyAlert)
+}
+
+func TestSimulatorVideo(t *testing.T) {
+	s, b := connect(t)
+	defer s.Close()
+	defer b.Close()
+
+	sps := []byte{0x67, 0x42, 0xc0, 0x1e, 0xda, 0x02, 0x80, 0xbe, 0x40}
+	pps := []byte{0x68, 0xce, 0x3c, 0x80}
+	idr := []byte{0x65, 0x88, 0x84, 0x00, 0x33}
+	data := client.AnnexB([][]byte{sps, pps, idr})
+
+	video := b.SubscribeVideo(1)
+	s.FragmentSize = 8
+	number, err := s.SendVideoFrame(data, true)
+	gobottest.Assert(t, err, nil)
+
+	select {
+	case frame := <-video.Frames():
+		gobottest.Assert(t, frame.Number, number)
+		gobottest.Assert(t, frame.Key, true)
+		gobottest.Assert(t, frame.Data, data)
+	case <-time.After(time.Second):
+		t.Fatalf("video frame was not received")
+	}
+	eventually(t, "video acks", func() bool { return s.VideoAcks(number) == 4 })
+	gobottest.Assert(t, b.VideoStats().Width, 640)
+}
@@ -1,22 +1,54 @@
 package bebop
 
-type testDrone struct{}
+import (
+	"time"
+
+	"github.com/hybridgroup/gobot/platforms/bebop/client"
+)
+
+type testDrone struct {
+	state         client.State
+	handler       func(client.Event)
+	requireGPSFix bool
+	videoStats    client.VideoStats
+	pcmd          client.Pcmd
+}
 
-//func (t testDrone) Close() {}
 func (t testDrone) TakeOff() error { return nil }
 func (t testDrone) Land() error { return nil }
-func (t testDrone) Up(n int) error { return nil }
-func (t testDrone) Down(n int) error { return nil }
-func (t testDrone) Left(n int) error { return nil}
-func (t testDrone) Right(n int) error { return nil }
-func (t testDrone) Forward(n int) error { return nil }
-func (t testDrone) Backward(n int) error { return nil }
-func (t testDrone) Clockwise(n int) error { return nil }
-func (t testDrone) CounterClockwise(n int) error { return nil }
-func (t testDrone) Stop() error { return nil }
+func (t *testDrone) Up(n int) error { t.pcmd.Gaz = n; return nil }
+func (t *testDrone) Down(n int) error { t.pcmd.Gaz = -n; return nil }
+func (t *testDrone) Left(n int) error { t.pcmd.Roll = -n; return nil }
+func (t *testDrone) Right(n int) error { t.pcmd.Roll = n; return nil }
+func (t *testDrone) Forward(n int) error { t.pcmd.Pitch = n; return nil }
+func (t *testDrone) Backward(n int) error { t.pcmd.Pitch = -n; return nil }
+func (t *testDrone) Clockwise(n int) error { t.pcmd.Yaw = n; return nil }
+func (t *testDrone) CounterClockwise(n int) error { t.pcmd.Yaw = -n; return nil }
+func (t *testDrone) Stop() error { t.pcmd = client.Pcmd{Flag: 1}; return nil }
 func (t testDrone) Connect() error { return nil }
+func (t testDrone) Close() error { return nil }
 func (t testDrone) Video() chan []byte { return nil }
+func (t testDrone) SubscribeVideo(size int) *client.VideoSubscription { return nil }
+func (t testDrone) VideoStats() client.VideoStats { return t.videoStats }
 func (t testDrone) StartRecording() error { return nil }
 func (t testDrone) StopRecording() error { return nil }
 func (t testDrone) HullProtection(protect bool) error { return nil }
 func (t testDrone) Outdoor(outdoor bool) error { return nil }
+func (t testDrone) FlatTrim() error { return nil }
+func (t testDrone) Emergency() error { return nil }
+func (t testDrone) Flip(direction client.FlipDirection) error { return nil }
+func (t testDrone) MoveBy(dx, dy, dz, dpsi float64) error { return nil }
+func (t testDrone) NavigateHome(start bool) error { return nil }
+func (t testDrone) HomeType(home int) error { return nil }
+func (t testDrone) ReturnHomeDelay(delay time.Duration) error { return nil }
+func (t testDrone) MaxAltitude(altitude float64) error { return nil }
+func (t testDrone) MaxTilt(tilt float64) error { return nil }
+func (t testDrone) MaxVerticalSpeed(speed float64) error { return nil }
+func (t testDrone) MaxRotationSpeed(speed float64) error { return nil }
+func (t testDrone) CameraOrientation(tilt int, pan int) error { return nil }
+func (t testDrone) TakePicture() error { return nil }
+func (t testDrone) VideoResolution(resolution int) error { return nil }
+func (t testDrone) VideoFramerate(framerate int) error { return nil }
+func (t *testDrone) RequireGPSFix(require bool) { t.requireGPSFix = require }
+func (t *testDrone) State() client.State { return t.state }
+func (t *testDrone) OnEvent(f func(client.Event)) { t.handler = f }
@@ -2,10 +2,12 @@ package chip
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/platforms/gpio"
 	"github.com/hybridgroup/gobot/platforms/i2c"
+	"github.com/hybridgroup/gobot/platforms/spi"
 	"github.com/hybridgroup/gobot/sysfs"
 )
 
@@ -13,13 +15,20 @@ var _ gobot.Adaptor = (*ChipAdaptor)(nil)
 
 var _ gpio.DigitalReader = (*ChipAdaptor)(nil)
 var _ gpio.DigitalWriter = (*ChipAdaptor)(nil)
+var _ gpio.PwmWriter = (*ChipAdaptor)(nil)
+var _ gpio.ServoWriter = (*ChipAdaptor)(nil)
 
 var _ i2c.I2c = (*ChipAdaptor)(nil)
+var _ i2c.I2cConnector = (*ChipAdaptor)(nil)
+
+var _ spi.Spi = (*ChipAdaptor)(nil)
 
 type ChipAdaptor struct {
 	name        string
 	digitalPins map[int]sysfs.DigitalPin
-	i2cDevice   sysfs.I2cDevice
+	pwmPins     map[int]sysfs.PwmPin
+	i2cBuses    map[int]*sysfs.I2cBus
+	spiDevices  map[string]sysfs.SpiDevice
 }
 
 var pins = map[string]int{
@@ -33,11 +42,20 @@ var pins = map[string]int{
 	"XIO-P7": 415,
 }
 
+// pwmPins maps pin names to channels of pwmchip0. PWM0 requires the pwm
+// device tree overlay to be loaded.
+var pwmPins = map[string]int{
+	"PWM0": 0,
+}
+
 // NewChipAdaptor creates a ChipAdaptor with the specified name
 func NewChipAdaptor(name string) *ChipAdaptor {
 	c := &ChipAdaptor{
 		name:        name,
 		digitalPins: make(map[int]sysfs.DigitalPin),
+		pwmPins:     make(map[int]sysfs.PwmPin),
+		i2cBuses:    make(map[int]*sysfs.I2cBus),
+		spiDevices:  make(map[string]sysfs.SpiDevice),
 	}
 	return c
 }
@@ -59,8 +77,21 @@ func (c *ChipAdaptor) Finalize() (errs []error) {
 			}
 		}
 	}
-	if c.i2cDevice != nil {
-		if err := c.i2cDevice.Close(); err != nil {
+	for _, pin := range c.pwmPins {
+		if err := pin.Enable(false); err != nil {
+			errs = append(errs, err)
+		}
+		if err := pin.Unexport(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, bus := range c.i2cBuses {
+		if err := bus.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, device := range c.spiDevices {
+		if err := device.Close(); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -118,31 +149,117 @@ func (c *ChipAdaptor) DigitalWrite(pin string, val byte) (err error) {
 	return sysfsPin.Write(int(val))
 }
 
+// PwmWrite writes the 0-254 value to the specified pin.
+// The only valid pin is PWM0 (pin 18 on header 13).
+func (c *ChipAdaptor) PwmWrite(pin string, val byte) (err error) {
+	period := 500000.0
+	duty := gobot.FromScale(float64(val), 0, 255.0)
+	return c.pwmWrite(pin, int(period), int(period*duty))
+}
+
+// ServoWrite writes the 0-180 degree val to the specified pin.
+// The only valid pin is PWM0 (pin 18 on header 13).
+func (c *ChipAdaptor) ServoWrite(pin string, val byte) (err error) {
+	period := 20000000.0
+	duty := (gobot.FromScale(float64(val), 0, 180.0) * 0.1) + 0.025
+	return c.pwmWrite(pin, int(period), int(period*duty))
+}
+
+// pwmWrite sets the period and duty cycle, in nanoseconds, of the specified
+// pin and enables its output
+func (c *ChipAdaptor) pwmWrite(pin string, period int, duty int) (err error) {
+	i, ok := pwmPins[pin]
+	if !ok {
+		return errors.New("Not a PWM pin")
+	}
+	if c.pwmPins[i] == nil {
+		p := sysfs.NewPwmPin(i)
+		if err = p.Export(); err != nil {
+			return
+		}
+		c.pwmPins[i] = p
+	}
+	if err = sysfs.SetPwm(c.pwmPins[i], period, duty); err != nil {
+		return
+	}
+	return c.pwmPins[i].Enable(true)
+}
+
 // I2cStart starts an i2c device in specified address.
 // This assumes that the bus used is /dev/i2c-1, which corresponds to
 // pins labeled TWI1-SDA and TW1-SCK (pins 9 and 11 on header 13).
 func (c *ChipAdaptor) I2cStart(address int) (err error) {
-	if c.i2cDevice == nil {
-		c.i2cDevice, err = sysfs.NewI2cDevice("/dev/i2c-1", address)
+	_, err = c.i2cBus(c.I2cDefaultBus()).Device(address)
+	return
+}
+
+// I2cGetConnection returns a connection to the i2c device at address on the
+// bus /dev/i2c-N. The transactions of each connection are serialised with
+// those of the other devices on the bus.
+func (c *ChipAdaptor) I2cGetConnection(address int, bus int) (connection i2c.I2cConnection, err error) {
+	device, err := c.i2cBus(bus).Device(address)
+	if err != nil {
+		return
+	}
+	return device, nil
+}
+
+// I2cDefaultBus returns the i2c bus on the U13 header pins, 1
+func (c *ChipAdaptor) I2cDefaultBus() int { return 1 }
+
+// i2cBus returns the i2c bus /dev/i2c-N, which is opened when its first
+// device is connected
+func (c *ChipAdaptor) i2cBus(bus int) *sysfs.I2cBus {
+	if c.i2cBuses[bus] == nil {
+		c.i2cBuses[bus] = sysfs.NewI2cBus(fmt.Sprintf("/dev/i2c-%v", bus))
 	}
-	return err
+	return c.i2cBuses[bus]
 }
 
 // I2cWrite writes data to i2c device
 func (c *ChipAdaptor) I2cWrite(address int, data []byte) (err error) {
-	if err = c.i2cDevice.SetAddress(address); err != nil {
+	device, err := c.i2cBus(c.I2cDefaultBus()).Device(address)
+	if err != nil {
 		return
 	}
-	_, err = c.i2cDevice.Write(data)
+	_, err = device.Write(data)
 	return
 }
 
-// I2cRead returns value from i2c device using specified size
+// I2cRead returns size bytes from the i2c device
 func (c *ChipAdaptor) I2cRead(address int, size int) (data []byte, err error) {
-	if err = c.i2cDevice.SetAddress(address); err != nil {
+	device, err := c.i2cBus(c.I2cDefaultBus()).Device(address)
+	if err != nil {
 		return
 	}
 	data = make([]byte, size)
-	_, err = c.i2cDevice.Read(data)
+	_, err = device.Read(data)
 	return
 }
+
+// SpiStart opens the spi device /dev/spidevB.C given its bus and chip select
+func (c *ChipAdaptor) SpiStart(bus int, chip int, mode int, bits int, maxSpeed int64) (err error) {
+	location := fmt.Sprintf("/dev/spidev%v.%v", bus, chip)
+	if c.spiDevices[location] != nil {
+		return
+	}
+	device, err := sysfs.NewSpiDevice(location, mode, bits, maxSpeed)
+	if err != nil {
+		return
+	}
+	c.spiDevices[location] = device
+	return
+}
+
+// SpiTransfer writes tx to the spi device while reading the same number of
+// bytes into rx
+func (c *ChipAdaptor) SpiTransfer(bus int, chip int, tx []byte, rx []byte) (err error) {
+	device := c.spiDevices[fmt.Sprintf("/dev/spidev%v.%v", bus, chip)]
+	if device == nil {
+		return spi.ErrNotStarted
+	}
+	return device.Tx(tx, rx)
+}
+
+// SpiDefaultBus returns the spi bus of the SPI2 pins on header U14, 32766
+func (c *ChipAdaptor) SpiDefaultBus() int { return 32766 }
@@ -5,35 +5,10 @@ import (
 	"testing"
 
 	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/platforms/spi"
 	"github.com/hybridgroup/gobot/sysfs"
 )
 
-type NullReadWriteCloser struct {
-	contents []byte
-}
-
-func (n *NullReadWriteCloser) SetAddress(int) error {
-	return nil
-}
-
-func (n *NullReadWriteCloser) Write(b []byte) (int, error) {
-	n.contents = make([]byte, len(b))
-	copy(n.contents[:], b[:])
-
-	return len(b), nil
-}
-
-func (n *NullReadWriteCloser) Read(b []byte) (int, error) {
-	copy(b, n.contents)
-	return len(b), nil
-}
-
-var closeErr error = nil
-
-func (n *NullReadWriteCloser) Close() error {
-	return closeErr
-}
-
 func initTestChipAdaptor() *ChipAdaptor {
 	a := NewChipAdaptor("myAdaptor")
 	a.Connect()
@@ -71,7 +46,6 @@ func TestChipAdaptorI2c(t *testing.T) {
 	sysfs.SetFilesystem(fs)
 	sysfs.SetSyscall(&sysfs.MockSyscall{})
 	a.I2cStart(0xff)
-	a.i2cDevice = &NullReadWriteCloser{}
 
 	a.I2cWrite(0xff, []byte{0x00, 0x01})
 	data, _ := a.I2cRead(0xff, 2)
@@ -79,3 +53,50 @@ func TestChipAdaptorI2c(t *testing.T) {
 
 	gobottest.Assert(t, len(a.Finalize()), 0)
 }
+
+func TestChipAdaptorSpi(t *testing.T) {
+	a := initTestChipAdaptor()
+	fs := sysfs.NewMockFilesystem([]string{
+		"/dev/spidev32766.0",
+	})
+	sysfs.SetFilesystem(fs)
+	sysfs.SetSyscall(&sysfs.MockSyscall{})
+
+	gobottest.Assert(t, a.SpiDefaultBus(), 32766)
+	gobottest.Assert(t, a.SpiTransfer(32766, 0, []byte{0x01}, nil), spi.ErrNotStarted)
+	gobottest.Refute(t, a.SpiStart(32766, 1, spi.Mode0, 8, 1000000), nil)
+
+	gobottest.Assert(t, a.SpiStart(32766, 0, spi.Mode0, 8, 1000000), nil)
+	gobottest.Assert(t, a.SpiStart(32766, 0, spi.Mode0, 8, 1000000), nil)
+	gobottest.Assert(t, len(a.spiDevices), 1)
+	gobottest.Assert(t, a.SpiTransfer(32766, 0, []byte{0x01}, make([]byte, 1)), nil)
+	gobottest.Assert(t, len(a.Finalize()), 0)
+}
+
+func TestChipAdaptorPwm(t *testing.T) {
+	a := initTestChipAdaptor()
+	fs := sysfs.NewMockFilesystem([]string{
+		"/sys/class/pwm/pwmchip0/export",
+		"/sys/class/pwm/pwmchip0/unexport",
+		"/sys/class/pwm/pwmchip0/pwm0/enable",
+		"/sys/class/pwm/pwmchip0/pwm0/period",
+		"/sys/class/pwm/pwmchip0/pwm0/duty_cycle",
+	})
+	sysfs.SetFilesystem(fs)
+
+	gobottest.Assert(t, a.PwmWrite("PWM0", 100), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/export"].Contents, "0")
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm0/period"].Contents, "500000")
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm0/duty_cycle"].Contents, "196078")
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm0/enable"].Contents, "1")
+
+	gobottest.Assert(t, a.ServoWrite("PWM0", 90), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm0/period"].Contents, "20000000")
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm0/duty_cycle"].Contents, "1500000")
+
+	gobottest.Assert(t, a.PwmWrite("XIO-P0", 100), errors.New("Not a PWM pin"))
+
+	gobottest.Assert(t, len(a.Finalize()), 0)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm0/enable"].Contents, "0")
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/unexport"].Contents, "0")
+}
@@ -6,16 +6,44 @@ import (
 
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/platforms/gpio"
+	"github.com/hybridgroup/gobot/platforms/i2c"
+	"github.com/hybridgroup/gobot/platforms/spi"
+	"github.com/hybridgroup/gobot/sysfs"
 )
 
 var _ gobot.Adaptor = (*DigisparkAdaptor)(nil)
 
+var _ gpio.DigitalReader = (*DigisparkAdaptor)(nil)
 var _ gpio.DigitalWriter = (*DigisparkAdaptor)(nil)
+var _ gpio.AnalogReader = (*DigisparkAdaptor)(nil)
 var _ gpio.PwmWriter = (*DigisparkAdaptor)(nil)
 var _ gpio.ServoWriter = (*DigisparkAdaptor)(nil)
 
-// ErrConnection is the error resulting of a connection error with the digispark
-var ErrConnection = errors.New("connection error")
+var _ i2c.I2c = (*DigisparkAdaptor)(nil)
+
+var _ spi.Spi = (*DigisparkAdaptor)(nil)
+
+var (
+	// ErrConnection is the error resulting of a connection error with the digispark
+	ErrConnection = errors.New("connection error")
+	// ErrInvalidAnalogPin is returned when reading a pin without an ADC
+	ErrInvalidAnalogPin = errors.New("Invalid analog pin")
+	// ErrI2cNoAck is returned when the i2c device does not acknowledge its
+	// address
+	ErrI2cNoAck = errors.New("I2c device did not acknowledge")
+	// ErrInvalidSpiDevice is returned when starting a spi device other than
+	// the chip 0 of the bus 0
+	ErrInvalidSpiDevice = errors.New("Invalid spi bus or chip")
+	// ErrSpiMode is returned when starting a spi device with a mode other
+	// than 0, or words of other than 8 bits
+	ErrSpiMode = errors.New("Only spi mode 0 with 8 bits words is supported")
+)
+
+// analogChannels are the ADC channels of the pins
+var analogChannels = map[string]uint8{
+	"5": 0,
+	"2": 1,
+}
 
 // DigisparkAdaptor is the Gobot Adaptor for the Digispark
 type DigisparkAdaptor struct {
@@ -23,6 +51,9 @@ type DigisparkAdaptor struct {
 	littleWire lw
 	servo      bool
 	pwm        bool
+	analog     bool
+	i2c        bool
+	spi        bool
 	connect    func(*DigisparkAdaptor) (err error)
 }
 
@@ -31,10 +62,11 @@ func NewDigisparkAdaptor(name string) *DigisparkAdaptor {
 	return &DigisparkAdaptor{
 		name: name,
 		connect: func(d *DigisparkAdaptor) (err error) {
-			d.littleWire = littleWireConnect()
-			if d.littleWire.(*littleWire).lwHandle == nil {
-				return ErrConnection
+			littleWire, err := littleWireConnect()
+			if err != nil {
+				return
 			}
+			d.littleWire = littleWire
 			return
 		},
 	}
@@ -51,8 +83,16 @@ func (d *DigisparkAdaptor) Connect() (errs []error) {
 	return
 }
 
-// Finalize implements the Adaptor interface
-func (d *DigisparkAdaptor) Finalize() (errs []error) { return }
+// Finalize closes the connection to the digispark
+func (d *DigisparkAdaptor) Finalize() (errs []error) {
+	if d.littleWire == nil {
+		return
+	}
+	if err := d.littleWire.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	return
+}
 
 // DigitalWrite writes a value to the pin. Acceptable values are 1 or 0.
 func (d *DigisparkAdaptor) DigitalWrite(pin string, level byte) (err error) {
@@ -62,13 +102,53 @@ func (d *DigisparkAdaptor) DigitalWrite(pin string, level byte) (err error) {
 		return
 	}
 
-	if err = d.littleWire.pinMode(uint8(p), 0); err != nil {
+	if err = d.littleWire.pinMode(uint8(p), output); err != nil {
 		return
 	}
 
 	return d.littleWire.digitalWrite(uint8(p), level)
 }
 
+// DigitalRead reads the value of the pin, 1 or 0
+func (d *DigisparkAdaptor) DigitalRead(pin string) (val int, err error) {
+	p, err := strconv.Atoi(pin)
+	if err != nil {
+		return
+	}
+
+	if err = d.littleWire.pinMode(uint8(p), input); err != nil {
+		return
+	}
+
+	level, err := d.littleWire.digitalRead(uint8(p))
+	if err != nil {
+		return
+	}
+	if level != 0 {
+		val = 1
+	}
+	return
+}
+
+// AnalogRead reads the 10 bit value of the pin 2 or 5, with the supply
+// voltage as reference
+func (d *DigisparkAdaptor) AnalogRead(pin string) (val int, err error) {
+	channel, ok := analogChannels[pin]
+	if !ok {
+		return 0, ErrInvalidAnalogPin
+	}
+
+	if d.analog == false {
+		if err = d.littleWire.analogInit(vrefVcc); err != nil {
+			return
+		}
+		d.analog = true
+	}
+
+	value, err := d.littleWire.analogRead(channel)
+	return int(value), err
+}
+
 // PwmWrite writes the 0-254 value to the specified pin
 func (d *DigisparkAdaptor) PwmWrite(pin string, value byte) (err error) {
 	if d.pwm == false {
@@ -95,3 +175,86 @@ func (d *DigisparkAdaptor) ServoWrite(pin string, angle uint8) (err error) {
 	}
 	return d.littleWire.servoUpdateLocation(angle, angle)
 }
+
+// I2cStart starts the i2c bus of the pins 0 (SDA) and 2 (SCL)
+func (d *DigisparkAdaptor) I2cStart(address int) (err error) {
+	if d.i2c == false {
+		if err = d.littleWire.i2cInit(); err != nil {
+			return
+		}
+		d.i2c = true
+	}
+	return
+}
+
+// I2cWrite writes data to the i2c device at address
+func (d *DigisparkAdaptor) I2cWrite(address int, data []byte) (err error) {
+	if err = d.i2cStart(address, i2cDirWrite); err != nil {
+		return
+	}
+	return d.littleWire.i2cWrite(data, true)
+}
+
+// I2cRead returns size bytes from the i2c device at address
+func (d *DigisparkAdaptor) I2cRead(address int, size int) (data []byte, err error) {
+	if err = d.i2cStart(address, i2cDirRead); err != nil {
+		return
+	}
+	data = make([]byte, size)
+	if err = d.littleWire.i2cRead(data, true); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// i2cStart starts a transfer with the i2c device at address
+func (d *DigisparkAdaptor) i2cStart(address int, direction uint8) (err error) {
+	if err = d.I2cStart(address); err != nil {
+		return
+	}
+	ack, err := d.littleWire.i2cStart(uint8(address), direction)
+	if err != nil {
+		return
+	}
+	if !ack {
+		return ErrI2cNoAck
+	}
+	return
+}
+
+// SpiStart starts the spi bus of the pins 0 (MOSI), 1 (MISO) and 2 (SCK),
+// with the chip select on pin 5. Little Wire only supports the mode 0 with
+// 8 bits words at its own speed, so maxSpeed is ignored.
+func (d *DigisparkAdaptor) SpiStart(bus int, chip int, mode int, bits int, maxSpeed int64) (err error) {
+	if bus != 0 || chip != 0 {
+		return ErrInvalidSpiDevice
+	}
+	if mode != spi.Mode0 || bits != 8 {
+		return ErrSpiMode
+	}
+	if d.spi == false {
+		if err = d.littleWire.spiInit(); err != nil {
+			return
+		}
+		d.spi = true
+	}
+	return
+}
+
+// SpiTransfer writes tx to the spi device while reading the same number of
+// bytes into rx
+func (d *DigisparkAdaptor) SpiTransfer(bus int, chip int, tx []byte, rx []byte) (err error) {
+	if bus != 0 || chip != 0 {
+		return ErrInvalidSpiDevice
+	}
+	if d.spi == false {
+		return spi.ErrNotStarted
+	}
+	if rx != nil && len(rx) != len(tx) {
+		return sysfs.ErrSpiBufferLength
+	}
+	return d.littleWire.spiTransfer(tx, rx)
+}
+
+// SpiDefaultBus returns the only spi bus, 0
+func (d *DigisparkAdaptor) SpiDefaultBus() int { return 0 }
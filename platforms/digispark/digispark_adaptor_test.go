@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/platforms/spi"
+	"github.com/hybridgroup/gobot/sysfs"
 )
 
 type mock struct {
@@ -16,6 +18,20 @@ type mock struct {
 	pin               uint8
 	mode              uint8
 	state             uint8
+	level             uint8
+	vref              uint8
+	channel           uint8
+	analogValue       uint16
+	i2cAddress        uint8
+	i2cDirection      uint8
+	i2cNoAck          bool
+	i2cWritten        []byte
+	i2cData           []byte
+	i2cStop           bool
+	spiTx             []byte
+	spiRx             []byte
+	inits             []string
+	closed            bool
 }
 
 func (l *mock) digitalWrite(pin uint8, state uint8) error {
@@ -49,6 +65,52 @@ func (l *mock) servoUpdateLocation(locationA uint8, locationB uint8) error {
 	return l.error()
 }
 
+func (l *mock) digitalRead(pin uint8) (uint8, error) {
+	l.pin = pin
+	return l.level, l.error()
+}
+func (l *mock) analogInit(vref uint8) error {
+	l.vref = vref
+	l.inits = append(l.inits, "analog")
+	return l.error()
+}
+func (l *mock) analogRead(channel uint8) (uint16, error) {
+	l.channel = channel
+	return l.analogValue, l.error()
+}
+func (l *mock) i2cInit() error {
+	l.inits = append(l.inits, "i2c")
+	return l.error()
+}
+func (l *mock) i2cStart(address uint8, direction uint8) (bool, error) {
+	l.i2cAddress = address
+	l.i2cDirection = direction
+	return !l.i2cNoAck, l.error()
+}
+func (l *mock) i2cWrite(data []byte, stop bool) error {
+	l.i2cWritten = data
+	l.i2cStop = stop
+	return l.error()
+}
+func (l *mock) i2cRead(data []byte, stop bool) error {
+	copy(data, l.i2cData)
+	l.i2cStop = stop
+	return l.error()
+}
+func (l *mock) spiInit() error {
+	l.inits = append(l.inits, "spi")
+	return l.error()
+}
+func (l *mock) spiTransfer(tx []byte, rx []byte) error {
+	l.spiTx = tx
+	copy(rx, l.spiRx)
+	return l.error()
+}
+func (l *mock) Close() error {
+	l.closed = true
+	return l.error()
+}
+
 var errorFunc = func() error { return nil }
 
 func (l *mock) error() error { return errorFunc() }
@@ -68,6 +130,9 @@ func TestDigisparkAdaptor(t *testing.T) {
 }
 
 func TestDigisparkAdaptorConnect(t *testing.T) {
+	sysfs.SetFilesystem(sysfs.NewMockFilesystem([]string{}))
+	defer sysfs.SetFilesystem(&sysfs.NativeFilesystem{})
+
 	a := NewDigisparkAdaptor("bot")
 	gobottest.Assert(t, a.Connect()[0], ErrConnection)
 
@@ -78,6 +143,10 @@ func TestDigisparkAdaptorConnect(t *testing.T) {
 func TestDigisparkAdaptorFinalize(t *testing.T) {
 	a := initTestDigisparkAdaptor()
 	gobottest.Assert(t, len(a.Finalize()), 0)
+	gobottest.Assert(t, a.littleWire.(*mock).closed, true)
+
+	a = NewDigisparkAdaptor("bot")
+	gobottest.Assert(t, len(a.Finalize()), 0)
 }
 
 func TestDigisparkAdaptorDigitalWrite(t *testing.T) {
@@ -125,3 +194,102 @@ func TestDigisparkAdaptorPwmWrite(t *testing.T) {
 	err = a.PwmWrite("1", uint8(100))
 	gobottest.Assert(t, err, errors.New("pwm error"))
 }
+
+func TestDigisparkAdaptorDigitalRead(t *testing.T) {
+	a := initTestDigisparkAdaptor()
+	a.littleWire.(*mock).level = 0x04
+	val, err := a.DigitalRead("2")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, 1)
+	gobottest.Assert(t, a.littleWire.(*mock).pin, uint8(2))
+	gobottest.Assert(t, a.littleWire.(*mock).mode, uint8(input))
+
+	a.littleWire.(*mock).level = 0
+	val, _ = a.DigitalRead("2")
+	gobottest.Assert(t, val, 0)
+
+	_, err = a.DigitalRead("?")
+	gobottest.Refute(t, err, nil)
+
+	errorFunc = func() error { return errors.New("read error") }
+	_, err = a.DigitalRead("2")
+	gobottest.Assert(t, err, errors.New("read error"))
+}
+
+func TestDigisparkAdaptorAnalogRead(t *testing.T) {
+	a := initTestDigisparkAdaptor()
+	a.littleWire.(*mock).analogValue = 1023
+	val, err := a.AnalogRead("2")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, 1023)
+	gobottest.Assert(t, a.littleWire.(*mock).channel, uint8(1))
+	gobottest.Assert(t, a.littleWire.(*mock).vref, uint8(vrefVcc))
+
+	a.AnalogRead("5")
+	gobottest.Assert(t, a.littleWire.(*mock).channel, uint8(0))
+	gobottest.Assert(t, a.littleWire.(*mock).inits, []string{"analog"})
+
+	_, err = a.AnalogRead("1")
+	gobottest.Assert(t, err, ErrInvalidAnalogPin)
+
+	a = initTestDigisparkAdaptor()
+	errorFunc = func() error { return errors.New("analog error") }
+	_, err = a.AnalogRead("2")
+	gobottest.Assert(t, err, errors.New("analog error"))
+}
+
+func TestDigisparkAdaptorI2c(t *testing.T) {
+	a := initTestDigisparkAdaptor()
+	gobottest.Assert(t, a.I2cStart(0x40), nil)
+	gobottest.Assert(t, a.I2cStart(0x41), nil)
+	gobottest.Assert(t, a.littleWire.(*mock).inits, []string{"i2c"})
+
+	gobottest.Assert(t, a.I2cWrite(0x40, []byte{0x01, 0x02}), nil)
+	gobottest.Assert(t, a.littleWire.(*mock).i2cAddress, uint8(0x40))
+	gobottest.Assert(t, a.littleWire.(*mock).i2cDirection, uint8(i2cDirWrite))
+	gobottest.Assert(t, a.littleWire.(*mock).i2cWritten, []byte{0x01, 0x02})
+	gobottest.Assert(t, a.littleWire.(*mock).i2cStop, true)
+
+	a.littleWire.(*mock).i2cData = []byte{0x03, 0x04, 0x05}
+	data, err := a.I2cRead(0x41, 3)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, data, []byte{0x03, 0x04, 0x05})
+	gobottest.Assert(t, a.littleWire.(*mock).i2cAddress, uint8(0x41))
+	gobottest.Assert(t, a.littleWire.(*mock).i2cDirection, uint8(i2cDirRead))
+
+	a.littleWire.(*mock).i2cNoAck = true
+	gobottest.Assert(t, a.I2cWrite(0x40, []byte{0x01}), ErrI2cNoAck)
+	_, err = a.I2cRead(0x40, 1)
+	gobottest.Assert(t, err, ErrI2cNoAck)
+
+	a = initTestDigisparkAdaptor()
+	errorFunc = func() error { return errors.New("i2c error") }
+	gobottest.Assert(t, a.I2cStart(0x40), errors.New("i2c error"))
+	_, err = a.I2cRead(0x40, 1)
+	gobottest.Assert(t, err, errors.New("i2c error"))
+}
+
+func TestDigisparkAdaptorSpi(t *testing.T) {
+	a := initTestDigisparkAdaptor()
+	gobottest.Assert(t, a.SpiDefaultBus(), 0)
+	gobottest.Assert(t, a.SpiTransfer(0, 0, []byte{0x01}, nil), spi.ErrNotStarted)
+	gobottest.Assert(t, a.SpiStart(0, 1, spi.Mode0, 8, 1000000), ErrInvalidSpiDevice)
+	gobottest.Assert(t, a.SpiStart(0, 0, spi.Mode1, 8, 1000000), ErrSpiMode)
+	gobottest.Assert(t, a.SpiStart(0, 0, spi.Mode0, 16, 1000000), ErrSpiMode)
+
+	gobottest.Assert(t, a.SpiStart(0, 0, spi.Mode0, 8, 1000000), nil)
+	gobottest.Assert(t, a.SpiStart(0, 0, spi.Mode0, 8, 1000000), nil)
+	gobottest.Assert(t, a.littleWire.(*mock).inits, []string{"spi"})
+
+	a.littleWire.(*mock).spiRx = []byte{0x0a, 0x0b, 0x0c}
+	rx := make([]byte, 3)
+	gobottest.Assert(t, a.SpiTransfer(0, 0, []byte{0x01, 0x80, 0x00}, rx), nil)
+	gobottest.Assert(t, a.littleWire.(*mock).spiTx, []byte{0x01, 0x80, 0x00})
+	gobottest.Assert(t, rx, []byte{0x0a, 0x0b, 0x0c})
+
+	gobottest.Assert(t, a.SpiTransfer(0, 0, []byte{0x01}, rx), sysfs.ErrSpiBufferLength)
+	gobottest.Assert(t, a.SpiTransfer(1, 0, []byte{0x01}, nil), ErrInvalidSpiDevice)
+
+	errorFunc = func() error { return errors.New("spi error") }
+	gobottest.Assert(t, a.SpiTransfer(0, 0, []byte{0x01}, nil), errors.New("spi error"))
+}
@@ -3,8 +3,8 @@ Package digispark provides the Gobot adaptor for the Digispark ATTiny-based USB
 
 Installing:
 
-This package requires installing `libusb`.
-Then you can install the package with:
+The package talks to the Little Wire firmware through the USB device file
+system of Linux, without cgo or libusb. Install the package with:
 
 	go get github.com/hybridgroup/gobot/platforms/digispark
 
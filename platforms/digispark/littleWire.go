@@ -1,79 +1,350 @@
 package digispark
 
-//#cgo LDFLAGS: -lusb
-//#include "littleWire.h"
-//#include "littleWire_servo.h"
-//typedef usb_dev_handle littleWire;
-import "C"
+// The Little Wire protocol is ported from the Little Wire library
+// (http://littlewire.cc), Copyright (C) 2013 ihsan Kehribar and Omer Kilic,
+// released under the MIT license.
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
+
+// ErrShortResponse is returned when the device answers a request with less
+// data than expected
+var ErrShortResponse = errors.New("Short response from the Little Wire device")
+
+const (
+	vendorID  = 0x1781
+	productID = 0x0c9f
+
+	// requestType is a vendor request from the device to the host
+	requestType = 0xC0
+
+	// the requests of the Little Wire firmware
+	requestPinInput       = 13
+	requestPinOutput      = 14
+	requestAnalogRead     = 15
+	requestPwmInit        = 16
+	requestPwmCompare     = 17
+	requestDigitalHigh    = 18
+	requestDigitalLow     = 19
+	requestDigitalRead    = 20
+	requestPwmPrescaler   = 22
+	requestSpiInit        = 23
+	requestPwmStop        = 32
+	requestAnalogInit     = 35
+	requestResult         = 40
+	requestI2cInit        = 44
+	requestI2cStart       = 45
+	requestI2cRead        = 46
+	requestI2cWrite       = 0xE0
+	requestSpiSendMessage = 0xF0
+
+	// the maximum lengths of the messages of a request
+	i2cWriteLength = 4
+	i2cReadLength  = 8
+	spiLength      = 4
+)
+
+// pin modes, voltage references, I2C directions and SPI chip select modes
+// of the firmware
+const (
+	output       = 0
+	input        = 1
+	vrefVcc      = 0
+	i2cDirWrite  = 0
+	i2cDirRead   = 1
+	manualCS     = 0
+	autoCS       = 1
+	pwmA         = 0
+	pwmB         = 1
+	spiCSPin     = 5
+	i2cReadDelay = 3 * time.Millisecond
+)
+
+// servo pulse limits in milliseconds
+const (
+	servoMin   = 0.45
+	servoMax   = 2.45
+	servoStep  = 0.062
+	servoRange = 180.0
+)
 
 type lw interface {
 	digitalWrite(uint8, uint8) error
+	digitalRead(uint8) (uint8, error)
 	pinMode(uint8, uint8) error
+	analogInit(uint8) error
+	analogRead(uint8) (uint16, error)
 	pwmInit() error
 	pwmStop() error
 	pwmUpdateCompare(uint8, uint8) error
 	pwmUpdatePrescaler(uint) error
 	servoInit() error
 	servoUpdateLocation(uint8, uint8) error
-	error() error
+	i2cInit() error
+	i2cStart(uint8, uint8) (bool, error)
+	i2cWrite([]byte, bool) error
+	i2cRead([]byte, bool) error
+	spiInit() error
+	spiTransfer([]byte, []byte) error
+	Close() error
 }
 
+// littleWire talks to the Little Wire firmware with USB control transfers
 type littleWire struct {
-	lwHandle *C.littleWire
+	transport transport
+}
+
+// littleWireConnect connects to the first Little Wire device
+func littleWireConnect() (*littleWire, error) {
+	device, err := openUsbDevice(vendorID, productID)
+	if err != nil {
+		return nil, err
+	}
+	return &littleWire{transport: device}, nil
+}
+
+// request sends a request to the firmware, and returns its answer
+func (l *littleWire) request(request uint8, value uint16, index uint16) ([]byte, error) {
+	buf := make([]byte, 8)
+	n, err := l.transport.controlTransfer(requestType, request, value, index, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// result returns the result of the previous SPI, I2C or 1-Wire request
+func (l *littleWire) result(length int) ([]byte, error) {
+	buf, err := l.request(requestResult, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) < length {
+		return nil, ErrShortResponse
+	}
+	return buf[:length], nil
 }
 
-func littleWireConnect() *littleWire {
-	return &littleWire{
-		lwHandle: C.littleWire_connect(),
+func (l *littleWire) digitalWrite(pin uint8, state uint8) (err error) {
+	if state != 0 {
+		_, err = l.request(requestDigitalHigh, uint16(pin), 0)
+	} else {
+		_, err = l.request(requestDigitalLow, uint16(pin), 0)
 	}
+	return
 }
 
-func (l *littleWire) digitalWrite(pin uint8, state uint8) error {
-	C.digitalWrite(l.lwHandle, C.uchar(pin), C.uchar(state))
-	return l.error()
+func (l *littleWire) digitalRead(pin uint8) (uint8, error) {
+	buf, err := l.request(requestDigitalRead, uint16(pin), 0)
+	if err != nil {
+		return 0, err
+	}
+	if len(buf) < 1 {
+		return 0, ErrShortResponse
+	}
+	return buf[0], nil
 }
 
-func (l *littleWire) pinMode(pin uint8, mode uint8) error {
-	C.pinMode(l.lwHandle, C.uchar(pin), C.uchar(mode))
-	return l.error()
+func (l *littleWire) pinMode(pin uint8, mode uint8) (err error) {
+	if mode == input {
+		_, err = l.request(requestPinInput, uint16(pin), 0)
+	} else {
+		_, err = l.request(requestPinOutput, uint16(pin), 0)
+	}
+	return
 }
 
-func (l *littleWire) pwmInit() error {
-	C.pwm_init(l.lwHandle)
-	return l.error()
+func (l *littleWire) analogInit(voltageRef uint8) (err error) {
+	_, err = l.request(requestAnalogInit, uint16(voltageRef)<<8|0x07, 0)
+	return
 }
 
-func (l *littleWire) pwmStop() error {
-	C.pwm_stop(l.lwHandle)
-	return l.error()
+func (l *littleWire) analogRead(channel uint8) (uint16, error) {
+	buf, err := l.request(requestAnalogRead, uint16(channel), 0)
+	if err != nil {
+		return 0, err
+	}
+	if len(buf) < 2 {
+		return 0, ErrShortResponse
+	}
+	return uint16(buf[1])<<8 | uint16(buf[0]), nil
 }
 
-func (l *littleWire) pwmUpdateCompare(channelA uint8, channelB uint8) error {
-	C.pwm_updateCompare(l.lwHandle, C.uchar(channelA), C.uchar(channelB))
-	return l.error()
+func (l *littleWire) pwmInit() (err error) {
+	_, err = l.request(requestPwmInit, 0, 0)
+	return
 }
 
-func (l *littleWire) pwmUpdatePrescaler(value uint) error {
-	C.pwm_updatePrescaler(l.lwHandle, C.uint(value))
-	return l.error()
+func (l *littleWire) pwmStop() (err error) {
+	_, err = l.request(requestPwmStop, 0, 0)
+	return
 }
 
-func (l *littleWire) servoInit() error {
-	C.servo_init(l.lwHandle)
-	return l.error()
+func (l *littleWire) pwmUpdateCompare(channelA uint8, channelB uint8) (err error) {
+	_, err = l.request(requestPwmCompare, uint16(channelA), uint16(channelB))
+	return
+}
+
+func (l *littleWire) pwmUpdatePrescaler(value uint) (err error) {
+	prescalers := map[uint]uint16{1: 0, 8: 1, 64: 2, 256: 3, 1024: 4}
+	if prescaler, ok := prescalers[value]; ok {
+		_, err = l.request(requestPwmPrescaler, prescaler, 0)
+	}
+	return
+}
+
+func (l *littleWire) servoInit() (err error) {
+	if err = l.pwmInit(); err != nil {
+		return
+	}
+	if err = l.pinMode(pwmA, output); err != nil {
+		return
+	}
+	if err = l.pinMode(pwmB, output); err != nil {
+		return
+	}
+	return l.pwmUpdatePrescaler(1024)
 }
 
 func (l *littleWire) servoUpdateLocation(locationA uint8, locationB uint8) error {
-	C.servo_updateLocation(l.lwHandle, C.uchar(locationA), C.uchar(locationB))
-	return l.error()
+	return l.pwmUpdateCompare(servoCompare(locationA), servoCompare(locationB))
 }
 
-func (l *littleWire) error() error {
-	str := C.GoString(C.littleWire_errorName())
-	if str != "" {
-		return errors.New(str)
+// servoCompare returns the PWM compare value of a servo angle
+func servoCompare(angle uint8) uint8 {
+	return uint8(((float32(angle)/servoRange)*(servoMax-servoMin) + servoMin) / servoStep)
+}
+
+func (l *littleWire) i2cInit() (err error) {
+	_, err = l.request(requestI2cInit, 0, 0)
+	return
+}
+
+// i2cStart starts a transfer with the device at address, and returns whether
+// it acknowledged it
+func (l *littleWire) i2cStart(address uint8, direction uint8) (bool, error) {
+	if _, err := l.request(requestI2cStart, uint16(address<<1|direction), 0); err != nil {
+		return false, err
+	}
+	buf, err := l.result(1)
+	if err != nil {
+		return false, err
+	}
+	return buf[0] == 0, nil
+}
+
+// i2cWrite writes data to the started device, in messages of at most 4
+// bytes, and ends the transfer when stop is true
+func (l *littleWire) i2cWrite(data []byte, stop bool) error {
+	for {
+		n := len(data)
+		if n > i2cWriteLength {
+			n = i2cWriteLength
+		}
+		last := n == len(data)
+		request := uint8(requestI2cWrite + n)
+		if last && stop {
+			request += 1 << 3
+		}
+		value, index := messageWords(data[:n])
+		if _, err := l.request(request, value, index); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		data = data[n:]
+	}
+}
+
+// i2cRead reads len(data) bytes from the started device, in messages of at
+// most 8 bytes, and ends the transfer when stop is true
+func (l *littleWire) i2cRead(data []byte, stop bool) error {
+	for len(data) > 0 {
+		n := len(data)
+		if n > i2cReadLength {
+			n = i2cReadLength
+		}
+		value, index := uint16(n)<<8, uint16(0)
+		if n == len(data) && stop {
+			value, index = value|1, 1
+		}
+		if _, err := l.request(requestI2cRead, value, index); err != nil {
+			return err
+		}
+		time.Sleep(i2cReadDelay)
+		buf, err := l.result(n)
+		if err != nil {
+			return err
+		}
+		copy(data, buf)
+		data = data[n:]
+	}
+	return nil
+}
+
+func (l *littleWire) spiInit() (err error) {
+	_, err = l.request(requestSpiInit, 0, 0)
+	return
+}
+
+// spiTransfer writes tx while reading the same number of bytes into rx,
+// which may be nil. The firmware selects the device for the messages of 4
+// bytes at most, so the chip select pin is driven for the longer transfers.
+func (l *littleWire) spiTransfer(tx []byte, rx []byte) (err error) {
+	if len(tx) <= spiLength {
+		return l.spiSendMessage(tx, rx, autoCS)
+	}
+
+	if err = l.pinMode(spiCSPin, output); err != nil {
+		return
 	}
+	if err = l.digitalWrite(spiCSPin, 0); err != nil {
+		return
+	}
+	for i := 0; i < len(tx) && err == nil; i += spiLength {
+		end := i + spiLength
+		if end > len(tx) {
+			end = len(tx)
+		}
+		var r []byte
+		if rx != nil {
+			r = rx[i:end]
+		}
+		err = l.spiSendMessage(tx[i:end], r, manualCS)
+	}
+	if csErr := l.digitalWrite(spiCSPin, 1); err == nil {
+		err = csErr
+	}
+	return
+}
+
+// spiSendMessage sends a message of at most 4 bytes
+func (l *littleWire) spiSendMessage(tx []byte, rx []byte, mode uint8) error {
+	value, index := messageWords(tx)
+	request := uint8(requestSpiSendMessage + len(tx) + int(mode)<<3)
+	if _, err := l.request(request, value, index); err != nil {
+		return err
+	}
+	buf, err := l.result(len(tx))
+	if err != nil {
+		return err
+	}
+	copy(rx, buf)
 	return nil
 }
+
+// Close closes the connection to the device
+func (l *littleWire) Close() error {
+	return l.transport.Close()
+}
+
+// messageWords returns the value and index words of a request carrying a
+// message of at most 4 bytes
+func messageWords(message []byte) (value uint16, index uint16) {
+	buf := make([]byte, 4)
+	copy(buf, message)
+	return uint16(buf[1])<<8 | uint16(buf[0]), uint16(buf[3])<<8 | uint16(buf[2])
+}
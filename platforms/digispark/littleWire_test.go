@@ -0,0 +1,221 @@
+package digispark
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// controlRequest is a control transfer sent to the fake transport
+type controlRequest struct {
+	requestType uint8
+	request     uint8
+	value       uint16
+	index       uint16
+}
+
+// fakeTransport records the control transfers, and answers them with the
+// replies of their requests
+type fakeTransport struct {
+	requests []controlRequest
+	replies  map[uint8][][]byte
+	err      error
+	closed   bool
+}
+
+func newFakeTransport() *fakeTransport {
+	return &fakeTransport{replies: make(map[uint8][][]byte)}
+}
+
+// reply queues an answer to the next transfer of request
+func (f *fakeTransport) reply(request uint8, data ...byte) {
+	f.replies[request] = append(f.replies[request], data)
+}
+
+func (f *fakeTransport) controlTransfer(requestType uint8, request uint8, value uint16, index uint16, data []byte) (int, error) {
+	f.requests = append(f.requests, controlRequest{requestType, request, value, index})
+	if f.err != nil {
+		return 0, f.err
+	}
+	replies := f.replies[request]
+	if len(replies) == 0 {
+		return 0, nil
+	}
+	f.replies[request] = replies[1:]
+	return copy(data, replies[0]), nil
+}
+
+func (f *fakeTransport) Close() error {
+	f.closed = true
+	return nil
+}
+
+func initTestLittleWire() (*littleWire, *fakeTransport) {
+	f := newFakeTransport()
+	return &littleWire{transport: f}, f
+}
+
+func TestLittleWireDigital(t *testing.T) {
+	l, f := initTestLittleWire()
+
+	gobottest.Assert(t, l.pinMode(1, output), nil)
+	gobottest.Assert(t, l.pinMode(2, input), nil)
+	gobottest.Assert(t, l.digitalWrite(1, 1), nil)
+	gobottest.Assert(t, l.digitalWrite(1, 0), nil)
+
+	f.reply(requestDigitalRead, 0x04)
+	level, err := l.digitalRead(2)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, level, uint8(0x04))
+
+	gobottest.Assert(t, f.requests, []controlRequest{
+		{0xC0, 14, 1, 0},
+		{0xC0, 13, 2, 0},
+		{0xC0, 18, 1, 0},
+		{0xC0, 19, 1, 0},
+		{0xC0, 20, 2, 0},
+	})
+
+	_, err = l.digitalRead(2)
+	gobottest.Assert(t, err, ErrShortResponse)
+}
+
+func TestLittleWireAnalog(t *testing.T) {
+	l, f := initTestLittleWire()
+
+	gobottest.Assert(t, l.analogInit(vrefVcc), nil)
+	f.reply(requestAnalogRead, 0xff, 0x03)
+	value, err := l.analogRead(1)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, value, uint16(1023))
+
+	gobottest.Assert(t, f.requests, []controlRequest{
+		{0xC0, 35, 0x07, 0},
+		{0xC0, 15, 1, 0},
+	})
+
+	_, err = l.analogRead(1)
+	gobottest.Assert(t, err, ErrShortResponse)
+}
+
+func TestLittleWirePwm(t *testing.T) {
+	l, f := initTestLittleWire()
+
+	gobottest.Assert(t, l.pwmInit(), nil)
+	gobottest.Assert(t, l.pwmUpdatePrescaler(1024), nil)
+	gobottest.Assert(t, l.pwmUpdatePrescaler(3), nil)
+	gobottest.Assert(t, l.pwmUpdateCompare(10, 20), nil)
+	gobottest.Assert(t, l.pwmStop(), nil)
+
+	gobottest.Assert(t, f.requests, []controlRequest{
+		{0xC0, 16, 0, 0},
+		{0xC0, 22, 4, 0},
+		{0xC0, 17, 10, 20},
+		{0xC0, 32, 0, 0},
+	})
+}
+
+func TestLittleWireServo(t *testing.T) {
+	l, f := initTestLittleWire()
+
+	gobottest.Assert(t, l.servoInit(), nil)
+	gobottest.Assert(t, l.servoUpdateLocation(0, 180), nil)
+
+	gobottest.Assert(t, f.requests, []controlRequest{
+		{0xC0, 16, 0, 0},
+		{0xC0, 14, 0, 0},
+		{0xC0, 14, 1, 0},
+		{0xC0, 22, 4, 0},
+		{0xC0, 17, 7, 39},
+	})
+}
+
+func TestLittleWireI2c(t *testing.T) {
+	l, f := initTestLittleWire()
+
+	gobottest.Assert(t, l.i2cInit(), nil)
+	f.reply(requestResult, 0)
+	ack, err := l.i2cStart(0x40, i2cDirWrite)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, ack, true)
+	gobottest.Assert(t, l.i2cWrite([]byte{1, 2, 3, 4, 5, 6}, true), nil)
+
+	f.reply(requestResult, 1)
+	ack, _ = l.i2cStart(0x40, i2cDirRead)
+	gobottest.Assert(t, ack, false)
+
+	f.reply(requestResult, 1, 2, 3, 4, 5, 6, 7, 8)
+	f.reply(requestResult, 9, 10)
+	data := make([]byte, 10)
+	gobottest.Assert(t, l.i2cRead(data, true), nil)
+	gobottest.Assert(t, data, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+
+	gobottest.Assert(t, f.requests, []controlRequest{
+		{0xC0, 44, 0, 0},
+		{0xC0, 45, 0x80, 0},
+		{0xC0, 40, 0, 0},
+		{0xC0, 0xE4, 0x0201, 0x0403},
+		{0xC0, 0xEA, 0x0605, 0},
+		{0xC0, 45, 0x81, 0},
+		{0xC0, 40, 0, 0},
+		{0xC0, 46, 0x0800, 0},
+		{0xC0, 40, 0, 0},
+		{0xC0, 46, 0x0201, 1},
+		{0xC0, 40, 0, 0},
+	})
+
+	gobottest.Assert(t, l.i2cRead(make([]byte, 1), false), ErrShortResponse)
+}
+
+func TestLittleWireSpi(t *testing.T) {
+	l, f := initTestLittleWire()
+
+	gobottest.Assert(t, l.spiInit(), nil)
+	f.reply(requestResult, 0xa, 0xb, 0xc, 0, 0, 0, 0, 0)
+	rx := make([]byte, 3)
+	gobottest.Assert(t, l.spiTransfer([]byte{1, 2, 3}, rx), nil)
+	gobottest.Assert(t, rx, []byte{0xa, 0xb, 0xc})
+
+	gobottest.Assert(t, f.requests, []controlRequest{
+		{0xC0, 23, 0, 0},
+		{0xC0, 0xFB, 0x0201, 0x0003},
+		{0xC0, 40, 0, 0},
+	})
+
+	f.requests = nil
+	f.reply(requestResult, 1, 2, 3, 4)
+	f.reply(requestResult, 5)
+	rx = make([]byte, 5)
+	gobottest.Assert(t, l.spiTransfer([]byte{6, 7, 8, 9, 10}, rx), nil)
+	gobottest.Assert(t, rx, []byte{1, 2, 3, 4, 5})
+
+	gobottest.Assert(t, f.requests, []controlRequest{
+		{0xC0, 14, spiCSPin, 0},
+		{0xC0, 19, spiCSPin, 0},
+		{0xC0, 0xF4, 0x0706, 0x0908},
+		{0xC0, 40, 0, 0},
+		{0xC0, 0xF1, 0x000a, 0},
+		{0xC0, 40, 0, 0},
+		{0xC0, 18, spiCSPin, 0},
+	})
+
+	f.reply(requestResult, 1, 2, 3, 4)
+	gobottest.Assert(t, l.spiTransfer([]byte{1, 2, 3, 4, 5}, nil), ErrShortResponse)
+	gobottest.Assert(t, f.requests[len(f.requests)-1], controlRequest{0xC0, 18, spiCSPin, 0})
+}
+
+func TestLittleWireError(t *testing.T) {
+	l, f := initTestLittleWire()
+	f.err = errors.New("usb error")
+
+	gobottest.Assert(t, l.digitalWrite(1, 1), errors.New("usb error"))
+	_, err := l.analogRead(1)
+	gobottest.Assert(t, err, errors.New("usb error"))
+	_, err = l.i2cStart(0x40, i2cDirWrite)
+	gobottest.Assert(t, err, errors.New("usb error"))
+	gobottest.Assert(t, l.spiTransfer([]byte{1}, nil), errors.New("usb error"))
+
+	gobottest.Assert(t, l.Close(), nil)
+	gobottest.Assert(t, f.closed, true)
+}
@@ -0,0 +1,122 @@
+package digispark
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/hybridgroup/gobot/sysfs"
+)
+
+// transport sends USB control transfers to the device
+type transport interface {
+	// controlTransfer sends a control request to the device, and returns
+	// the number of bytes of data sent or received
+	controlTransfer(requestType uint8, request uint8, value uint16, index uint16, data []byte) (n int, err error)
+	Close() error
+}
+
+// usbCtrlTransfer mirrors struct usbdevfs_ctrltransfer
+type usbCtrlTransfer struct {
+	requestType uint8
+	request     uint8
+	value       uint16
+	index       uint16
+	length      uint16
+	timeout     uint32
+	data        uintptr
+}
+
+// usbdevfsControl is the USBDEVFS_CONTROL ioctl of
+// include/uapi/linux/usbdevice_fs.h
+var usbdevfsControl = uintptr(0xC0000000 | unsafe.Sizeof(usbCtrlTransfer{})<<16 | 'U'<<8)
+
+// usbTimeout is the timeout of the control transfers in milliseconds
+const usbTimeout = 5000
+
+const usbDevices = "/sys/bus/usb/devices"
+
+// usbDevice is a USB device opened with usbfs
+type usbDevice struct {
+	file sysfs.File
+}
+
+// openUsbDevice opens the first USB device with vendorID and productID
+func openUsbDevice(vendorID uint16, productID uint16) (*usbDevice, error) {
+	devices, err := sysfs.Glob(usbDevices + "/*")
+	if err != nil {
+		return nil, err
+	}
+	for _, device := range devices {
+		if readUsbAttr(device, "idVendor", 16) != int(vendorID) ||
+			readUsbAttr(device, "idProduct", 16) != int(productID) {
+			continue
+		}
+		bus, dev := readUsbAttr(device, "busnum", 10), readUsbAttr(device, "devnum", 10)
+		if bus < 0 || dev < 0 {
+			continue
+		}
+		file, err := sysfs.OpenFile(fmt.Sprintf("/dev/bus/usb/%03d/%03d", bus, dev), os.O_RDWR, 0644)
+		if err != nil {
+			return nil, err
+		}
+		return &usbDevice{file: file}, nil
+	}
+	return nil, ErrConnection
+}
+
+// readUsbAttr returns the value of the sysfs attribute name of a USB device
+// in base, or -1 when it can not be read
+func readUsbAttr(device string, name string, base int) int {
+	file, err := sysfs.OpenFile(path.Join(device, name), os.O_RDONLY, 0644)
+	if err != nil {
+		return -1
+	}
+	defer file.Close()
+
+	buf := make([]byte, 16)
+	n, err := file.Read(buf)
+	if err != nil && n == 0 {
+		return -1
+	}
+	value, err := strconv.ParseUint(strings.TrimSpace(string(buf[:n])), base, 16)
+	if err != nil {
+		return -1
+	}
+	return int(value)
+}
+
+// controlTransfer implements the transport interface
+func (d *usbDevice) controlTransfer(requestType uint8, request uint8, value uint16, index uint16, data []byte) (n int, err error) {
+	transfer := usbCtrlTransfer{
+		requestType: requestType,
+		request:     request,
+		value:       value,
+		index:       index,
+		length:      uint16(len(data)),
+		timeout:     usbTimeout,
+	}
+	if len(data) > 0 {
+		transfer.data = uintptr(unsafe.Pointer(&data[0]))
+	}
+
+	r, _, errno := sysfs.Syscall(
+		syscall.SYS_IOCTL,
+		d.file.Fd(),
+		usbdevfsControl,
+		uintptr(unsafe.Pointer(&transfer)),
+	)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(r), nil
+}
+
+// Close closes the device
+func (d *usbDevice) Close() error {
+	return d.file.Close()
+}
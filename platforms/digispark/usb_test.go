@@ -0,0 +1,102 @@
+package digispark
+
+import (
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/sysfs"
+)
+
+func ptr(a uintptr) unsafe.Pointer { return *(*unsafe.Pointer)(unsafe.Pointer(&a)) }
+
+func initTestUsbFilesystem() *sysfs.MockFilesystem {
+	fs := sysfs.NewMockFilesystem([]string{
+		"/sys/bus/usb/devices/1-1/idVendor",
+		"/sys/bus/usb/devices/1-1/idProduct",
+		"/sys/bus/usb/devices/1-1/busnum",
+		"/sys/bus/usb/devices/1-1/devnum",
+		"/sys/bus/usb/devices/1-2/idVendor",
+		"/sys/bus/usb/devices/1-2/idProduct",
+		"/sys/bus/usb/devices/1-2/busnum",
+		"/sys/bus/usb/devices/1-2/devnum",
+		"/dev/bus/usb/001/002",
+		"/dev/bus/usb/001/005",
+	})
+	fs.Files["/sys/bus/usb/devices/1-1/idVendor"].Contents = "1d6b\n"
+	fs.Files["/sys/bus/usb/devices/1-1/idProduct"].Contents = "0002\n"
+	fs.Files["/sys/bus/usb/devices/1-1/busnum"].Contents = "1\n"
+	fs.Files["/sys/bus/usb/devices/1-1/devnum"].Contents = "2\n"
+	fs.Files["/sys/bus/usb/devices/1-2/idVendor"].Contents = "1781\n"
+	fs.Files["/sys/bus/usb/devices/1-2/idProduct"].Contents = "0c9f\n"
+	fs.Files["/sys/bus/usb/devices/1-2/busnum"].Contents = "1\n"
+	fs.Files["/sys/bus/usb/devices/1-2/devnum"].Contents = "5\n"
+	sysfs.SetFilesystem(fs)
+	return fs
+}
+
+func TestUsbDevice(t *testing.T) {
+	fs := initTestUsbFilesystem()
+	defer sysfs.SetFilesystem(&sysfs.NativeFilesystem{})
+
+	var fd, request uintptr
+	var transfer usbCtrlTransfer
+	sysfs.SetSyscall(&sysfs.MockSyscall{
+		Impl: func(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+			fd, request = a1, a2
+			transfer = *(*usbCtrlTransfer)(ptr(a3))
+			*(*byte)(ptr(transfer.data)) = 0x42
+			return 1, 0, 0
+		},
+	})
+	defer sysfs.SetSyscall(&sysfs.NativeSyscall{})
+
+	l, err := littleWireConnect()
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, fs.Files["/dev/bus/usb/001/005"].Opened, true)
+	gobottest.Assert(t, fs.Files["/dev/bus/usb/001/002"].Opened, false)
+
+	level, err := l.digitalRead(3)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, level, uint8(0x42))
+	gobottest.Assert(t, fd, fs.Files["/dev/bus/usb/001/005"].Fd())
+	gobottest.Assert(t, request, usbdevfsControl)
+	gobottest.Assert(t, transfer.requestType, uint8(0xC0))
+	gobottest.Assert(t, transfer.request, uint8(requestDigitalRead))
+	gobottest.Assert(t, transfer.value, uint16(3))
+	gobottest.Assert(t, transfer.length, uint16(8))
+	gobottest.Assert(t, transfer.timeout, uint32(usbTimeout))
+
+	sysfs.SetSyscall(&sysfs.MockSyscall{
+		Impl: func(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+			return 0, 0, syscall.ENODEV
+		},
+	})
+	_, err = l.digitalRead(3)
+	gobottest.Assert(t, err, syscall.ENODEV)
+
+	gobottest.Assert(t, l.Close(), nil)
+}
+
+func TestUsbDeviceNotFound(t *testing.T) {
+	fs := initTestUsbFilesystem()
+	defer sysfs.SetFilesystem(&sysfs.NativeFilesystem{})
+
+	fs.Files["/sys/bus/usb/devices/1-2/idProduct"].Contents = "0753\n"
+	_, err := littleWireConnect()
+	gobottest.Assert(t, err, ErrConnection)
+
+	fs.Files["/sys/bus/usb/devices/1-2/idProduct"].Contents = "0c9f\n"
+	delete(fs.Files, "/dev/bus/usb/001/005")
+	_, err = littleWireConnect()
+	gobottest.Refute(t, err, nil)
+}
+
+func TestUsbdevfsControl(t *testing.T) {
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		gobottest.Assert(t, usbdevfsControl, uintptr(0xC0185500))
+	} else {
+		gobottest.Assert(t, usbdevfsControl, uintptr(0xC0105500))
+	}
+}
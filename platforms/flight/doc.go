@@ -0,0 +1,12 @@
+/*
+Package flight provides the interface shared by the Gobot drone drivers, so
+that the same piloting code flies an ARDrone, a Bebop or a MAVLink airframe.
+
+Installing:
+
+	go get github.com/hybridgroup/gobot/platforms/flight
+
+For further information refer to flight README:
+https://github.com/hybridgroup/gobot/blob/master/platforms/flight/README.md
+*/
+package flight
@@ -0,0 +1,73 @@
+package flight
+
+import "github.com/hybridgroup/gobot"
+
+const (
+	// FlightState event, its data is the new State of the drone
+	FlightState = "flightstate"
+	// Battery event, its data is the remaining battery in percent
+	Battery = "battery"
+)
+
+// State is the flight state of a drone
+type State int
+
+const (
+	// Landed is the state of a drone on the ground
+	Landed State = iota
+	// TakingOff is the state of a drone until it hovers
+	TakingOff
+	// Hovering is the state of a drone flying in place
+	Hovering
+	// Flying is the state of a drone moving in the air
+	Flying
+	// Landing is the state of a drone until it is on the ground
+	Landing
+	// Emergency is the state of a drone which cut its motors or is landing
+	// on its own
+	Emergency
+)
+
+var stateNames = []string{"landed", "takingoff", "hovering", "flying", "landing", "emergency"}
+
+func (s State) String() string {
+	if s < 0 || int(s) >= len(stateNames) {
+		return "unknown"
+	}
+	return stateNames[s]
+}
+
+// Drone is implemented by the drone drivers. The drivers publish the
+// FlightState event when the state changes, and the Battery event when the
+// battery is read.
+type Drone interface {
+	gobot.Driver
+	gobot.Eventer
+
+	// TakeOff takes off and hovers
+	TakeOff() error
+	// Land lands
+	Land() error
+	// Hover stops moving and hovers in place
+	Hover() error
+	// Move moves along all axes at once. The values are in the range -1 to
+	// 1, and are clamped to it: roll is positive to the right, pitch is
+	// positive forward, yaw is positive clockwise and throttle is positive
+	// up.
+	Move(roll, pitch, yaw, throttle float64) error
+	// FlightState returns the last known flight state
+	FlightState() State
+	// Battery returns the last known remaining battery in percent
+	Battery() int
+}
+
+// Clamp returns v limited to the range -1 to 1
+func Clamp(v float64) float64 {
+	if v > 1 {
+		return 1
+	}
+	if v < -1 {
+		return -1
+	}
+	return v
+}
@@ -0,0 +1,21 @@
+package flight
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestClamp(t *testing.T) {
+	gobottest.Assert(t, Clamp(0.5), 0.5)
+	gobottest.Assert(t, Clamp(-0.25), -0.25)
+	gobottest.Assert(t, Clamp(1.5), 1.0)
+	gobottest.Assert(t, Clamp(-3), -1.0)
+}
+
+func TestStateString(t *testing.T) {
+	gobottest.Assert(t, Landed.String(), "landed")
+	gobottest.Assert(t, TakingOff.String(), "takingoff")
+	gobottest.Assert(t, Emergency.String(), "emergency")
+	gobottest.Assert(t, State(42).String(), "unknown")
+}
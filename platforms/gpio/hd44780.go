@@ -0,0 +1,458 @@
+package gpio
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInvalidPosition is returned when a position is outside of the
+	// display
+	ErrInvalidPosition = errors.New("Invalid position value")
+	// ErrInvalidSize is returned by Init when the controller can not
+	// address the columns and rows of the display
+	ErrInvalidSize = errors.New("Invalid display size")
+)
+
+// HD44780 instructions and their flags
+const (
+	HD44780_CLEARDISPLAY        = 0x01
+	HD44780_RETURNHOME          = 0x02
+	HD44780_ENTRYMODESET        = 0x04
+	HD44780_DISPLAYCONTROL      = 0x08
+	HD44780_CURSORSHIFT         = 0x10
+	HD44780_FUNCTIONSET         = 0x20
+	HD44780_SETCGRAMADDR        = 0x40
+	HD44780_SETDDRAMADDR        = 0x80
+	HD44780_ENTRYLEFT           = 0x02
+	HD44780_ENTRYSHIFTDECREMENT = 0x00
+	HD44780_DISPLAYON           = 0x04
+	HD44780_CURSORON            = 0x02
+	HD44780_BLINKON             = 0x01
+	HD44780_DISPLAYMOVE         = 0x08
+	HD44780_MOVERIGHT           = 0x04
+	HD44780_MOVELEFT            = 0x00
+	HD44780_8BITMODE            = 0x10
+	HD44780_2LINE               = 0x08
+)
+
+// Character ROMs of the HD44780
+const (
+	// HD44780_ROM_A00 is the Japanese ROM, with katakana and greek letters
+	HD44780_ROM_A00 = iota
+	// HD44780_ROM_A02 is the European ROM, with the latin-1 letters
+	HD44780_ROM_A02
+)
+
+// hd44780A00 maps the characters of the A00 ROM which are not ASCII
+var hd44780A00 = map[rune]byte{
+	'¥': 0x5c, '→': 0x7e, '←': 0x7f, '·': 0xa5, '°': 0xdf,
+	'α': 0xe0, 'ä': 0xe1, 'β': 0xe2, 'ε': 0xe3, 'μ': 0xe4, 'µ': 0xe4,
+	'σ': 0xe5, 'ρ': 0xe6, '√': 0xe8, '¢': 0xec, 'ñ': 0xee, 'ö': 0xef,
+	'θ': 0xf2, '∞': 0xf3, 'Ω': 0xf4, 'ü': 0xf5, 'Σ': 0xf6, 'π': 0xf7,
+	'÷': 0xfd, '█': 0xff,
+}
+
+// HD44780Bus writes the instructions and data of an HD44780 controller
+type HD44780Bus interface {
+	// WriteCommand writes an instruction
+	WriteCommand(b byte) error
+	// WriteData writes a byte to the display or character RAM
+	WriteData(b byte) error
+}
+
+// hd44780Resetter is an HD44780Bus which must be reset into its interface
+// mode before the function set
+type hd44780Resetter interface {
+	Reset() error
+}
+
+// hd44780FourBitBus is the 4 bit interface of an HD44780
+type hd44780FourBitBus struct {
+	writeNibble func(rs bool, nibble byte) error
+}
+
+// NewHD44780FourBitBus returns the HD44780Bus of a controller using its 4 bit
+// interface. writeNibble sets the register select and data pins D4 to D7 to
+// the lower 4 bits of nibble, and pulses the enable pin.
+func NewHD44780FourBitBus(writeNibble func(rs bool, nibble byte) error) HD44780Bus {
+	return &hd44780FourBitBus{writeNibble: writeNibble}
+}
+
+func (b *hd44780FourBitBus) WriteCommand(c byte) error { return b.write(false, c) }
+func (b *hd44780FourBitBus) WriteData(d byte) error    { return b.write(true, d) }
+
+func (b *hd44780FourBitBus) write(rs bool, val byte) (err error) {
+	if err = b.writeNibble(rs, val>>4); err != nil {
+		return
+	}
+	return b.writeNibble(rs, val&0x0f)
+}
+
+// Reset switches the controller to its 4 bit interface, whatever interface
+// it was in, as described by the initialization by instruction of the
+// datasheet
+func (b *hd44780FourBitBus) Reset() (err error) {
+	<-time.After(50 * time.Millisecond)
+	waits := []time.Duration{4500 * time.Microsecond, 150 * time.Microsecond, 150 * time.Microsecond}
+	for _, wait := range waits {
+		if err = b.writeNibble(false, 0x03); err != nil {
+			return
+		}
+		<-time.After(wait)
+	}
+	return b.writeNibble(false, 0x02)
+}
+
+// HD44780 drives the character display of an HD44780 controller, or of a
+// compatible one, through an HD44780Bus. Text is either written at the
+// cursor with Write, or buffered with SetText and sent with Flush, which
+// only sends the characters that changed.
+type HD44780 struct {
+	bus     HD44780Bus
+	cols    int
+	rows    int
+	rom     int
+	control byte
+	// addr is the DDRAM address of the cursor, -1 when the cursor is in
+	// the CGRAM
+	addr   int
+	custom map[rune]byte
+	buffer [][]byte
+	shadow [][]byte
+}
+
+// NewHD44780 returns an HD44780 display of cols columns and rows rows, such
+// as 16x2 or 20x4, written through bus. The display has 1 to 4 rows, and at
+// most 80 characters, or 20 columns when it has more than 2 rows.
+func NewHD44780(bus HD44780Bus, cols int, rows int) *HD44780 {
+	h := &HD44780{
+		bus:     bus,
+		cols:    cols,
+		rows:    rows,
+		rom:     HD44780_ROM_A00,
+		control: HD44780_DISPLAYON,
+		custom:  make(map[rune]byte),
+	}
+	if !h.validSize() {
+		return h
+	}
+	h.buffer = make([][]byte, rows)
+	h.shadow = make([][]byte, rows)
+	for row := 0; row < rows; row++ {
+		h.buffer[row] = make([]byte, cols)
+		h.shadow[row] = make([]byte, cols)
+	}
+	h.fill(' ')
+	return h
+}
+
+// Cols returns the number of columns of the display
+func (h *HD44780) Cols() int { return h.cols }
+
+// Rows returns the number of rows of the display
+func (h *HD44780) Rows() int { return h.rows }
+
+// Init initializes the controller, turns the display on and clears it. It
+// returns ErrInvalidSize when the controller can not address the display.
+func (h *HD44780) Init() (err error) {
+	if !h.validSize() {
+		return ErrInvalidSize
+	}
+	if r, ok := h.bus.(hd44780Resetter); ok {
+		if err = r.Reset(); err != nil {
+			return
+		}
+	}
+
+	function := byte(HD44780_FUNCTIONSET)
+	if h.rows > 1 {
+		function |= HD44780_2LINE
+	}
+	if err = h.bus.WriteCommand(function); err != nil {
+		return
+	}
+	<-time.After(100 * time.Microsecond)
+	if err = h.bus.WriteCommand(HD44780_DISPLAYCONTROL | h.control); err != nil {
+		return
+	}
+	<-time.After(100 * time.Microsecond)
+	if err = h.Clear(); err != nil {
+		return
+	}
+	return h.bus.WriteCommand(HD44780_ENTRYMODESET | HD44780_ENTRYLEFT | HD44780_ENTRYSHIFTDECREMENT)
+}
+
+// SetROM sets the character ROM of the controller, HD44780_ROM_A00 or
+// HD44780_ROM_A02, used to map the characters which are not ASCII
+func (h *HD44780) SetROM(rom int) { h.rom = rom }
+
+// Clear clears the text of the display, and of the buffer.
+func (h *HD44780) Clear() (err error) {
+	if err = h.bus.WriteCommand(HD44780_CLEARDISPLAY); err != nil {
+		return
+	}
+	<-time.After(2 * time.Millisecond)
+	h.fill(' ')
+	h.addr = 0
+	return
+}
+
+// Home sets the cursor to the origin position on the display.
+func (h *HD44780) Home() (err error) {
+	if err = h.bus.WriteCommand(HD44780_RETURNHOME); err != nil {
+		return
+	}
+	<-time.After(2 * time.Millisecond)
+	h.addr = 0
+	return
+}
+
+// SetPosition sets the cursor to pos, counted from the first column of the
+// first row and continuing on the following rows.
+func (h *HD44780) SetPosition(pos int) (err error) {
+	if pos < 0 || pos >= h.cols*h.rows {
+		return ErrInvalidPosition
+	}
+	return h.SetCursor(pos%h.cols, pos/h.cols)
+}
+
+// SetCursor sets the cursor to a column and a row.
+func (h *HD44780) SetCursor(col int, row int) (err error) {
+	addr, err := h.address(col, row)
+	if err != nil {
+		return
+	}
+	return h.setAddress(addr)
+}
+
+// ShowCursor shows or hides the underline cursor.
+func (h *HD44780) ShowCursor(show bool) error {
+	return h.setControl(HD44780_CURSORON, show)
+}
+
+// Blink turns the blinking of the cursor on or off.
+func (h *HD44780) Blink(blink bool) error {
+	return h.setControl(HD44780_BLINKON, blink)
+}
+
+// Display turns the display on or off, without losing its text.
+func (h *HD44780) Display(on bool) error {
+	return h.setControl(HD44780_DISPLAYON, on)
+}
+
+// Scroll scrolls the text of the display by one column.
+func (h *HD44780) Scroll(leftToRight bool) error {
+	if leftToRight {
+		return h.bus.WriteCommand(HD44780_CURSORSHIFT | HD44780_DISPLAYMOVE | HD44780_MOVELEFT)
+	}
+	return h.bus.WriteCommand(HD44780_CURSORSHIFT | HD44780_DISPLAYMOVE | HD44780_MOVERIGHT)
+}
+
+// Write displays message at the cursor. A new line moves the cursor to the
+// first column of the next row.
+func (h *HD44780) Write(message string) (err error) {
+	for _, r := range message {
+		if r == '\n' {
+			row := 0
+			if _, current, ok := h.position(h.addr); ok {
+				row = (current + 1) % h.rows
+			}
+			if err = h.SetCursor(0, row); err != nil {
+				return
+			}
+			continue
+		}
+
+		c := h.encode(r)
+		if err = h.bus.WriteData(c); err != nil {
+			return
+		}
+		if col, row, ok := h.position(h.addr); ok {
+			h.buffer[row][col] = c
+			h.shadow[row][col] = c
+		}
+		if h.addr >= 0 {
+			h.addr++
+		}
+	}
+	return
+}
+
+// SetCustomChar sets one of the 8 CGRAM locations with a custom character.
+// The custom character can be used by writing a byte of value 0 to 7.
+// When you are using LCD as 5x8 dots in function set then you can define a total of 8 user defined patterns
+// (1 Byte for each row and 8 rows for each pattern).
+// The cursor must be set before writing text again.
+func (h *HD44780) SetCustomChar(pos int, charMap [8]byte) (err error) {
+	if pos < 0 || pos > 7 {
+		return errors.New("can't set a custom character at a position greater than 7")
+	}
+	if err = h.bus.WriteCommand(HD44780_SETCGRAMADDR | byte(pos)<<3); err != nil {
+		return
+	}
+	h.addr = -1
+	for _, line := range charMap {
+		if err = h.bus.WriteData(line); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// SetCustomRune sets one of the 8 CGRAM locations with a custom character,
+// which is then displayed for r.
+func (h *HD44780) SetCustomRune(r rune, pos int, charMap [8]byte) (err error) {
+	if err = h.SetCustomChar(pos, charMap); err != nil {
+		return
+	}
+	h.custom[r] = byte(pos)
+	return
+}
+
+// SetText sets the text of the buffer from a column of a row on. The text
+// is cut at the end of the row, and displayed by Flush.
+func (h *HD44780) SetText(col int, row int, text string) error {
+	if _, err := h.address(col, row); err != nil {
+		return err
+	}
+	for _, r := range text {
+		if col >= h.cols {
+			break
+		}
+		h.buffer[row][col] = h.encode(r)
+		col++
+	}
+	return nil
+}
+
+// ClearText clears the text of the buffer.
+func (h *HD44780) ClearText() {
+	for row := range h.buffer {
+		for col := range h.buffer[row] {
+			h.buffer[row][col] = ' '
+		}
+	}
+}
+
+// Flush sends the characters of the buffer which differ from the display.
+func (h *HD44780) Flush() (err error) {
+	for row := range h.buffer {
+		for col, c := range h.buffer[row] {
+			if h.shadow[row][col] == c {
+				continue
+			}
+			addr, err := h.address(col, row)
+			if err != nil {
+				return err
+			}
+			if h.addr != addr {
+				if err = h.setAddress(addr); err != nil {
+					return err
+				}
+			}
+			if err = h.bus.WriteData(c); err != nil {
+				return err
+			}
+			h.shadow[row][col] = c
+			h.addr++
+		}
+	}
+	return
+}
+
+func (h *HD44780) setAddress(addr int) (err error) {
+	if err = h.bus.WriteCommand(HD44780_SETDDRAMADDR | byte(addr)); err != nil {
+		return
+	}
+	h.addr = addr
+	return
+}
+
+func (h *HD44780) setControl(flag byte, on bool) (err error) {
+	control := h.control &^ flag
+	if on {
+		control |= flag
+	}
+	if err = h.bus.WriteCommand(HD44780_DISPLAYCONTROL | control); err != nil {
+		return
+	}
+	h.control = control
+	return
+}
+
+// validSize returns whether the controller can address the columns and
+// rows of the display
+func (h *HD44780) validSize() bool {
+	switch {
+	case h.cols < 1 || h.rows < 1 || h.rows > 4:
+		return false
+	case h.rows > 2:
+		return h.cols <= 20
+	}
+	return h.cols*h.rows <= 80
+}
+
+// rowOffset returns the DDRAM address of the first column of a row, or
+// ErrInvalidPosition when the display has no such row. The third and fourth
+// rows of 4 row displays continue the first and second.
+func (h *HD44780) rowOffset(row int) (int, error) {
+	if row < 0 || row >= h.rows || !h.validSize() {
+		return 0, ErrInvalidPosition
+	}
+	return []int{0x00, 0x40, h.cols, 0x40 + h.cols}[row], nil
+}
+
+// address returns the DDRAM address of a column of a row, or
+// ErrInvalidPosition when it is outside of the display
+func (h *HD44780) address(col int, row int) (int, error) {
+	offset, err := h.rowOffset(row)
+	if err != nil || col < 0 || col >= h.cols {
+		return 0, ErrInvalidPosition
+	}
+	return offset + col, nil
+}
+
+// position returns the column and row of a DDRAM address, and whether it is
+// displayed
+func (h *HD44780) position(addr int) (col int, row int, ok bool) {
+	for row = 0; row < h.rows; row++ {
+		offset, err := h.rowOffset(row)
+		if err != nil {
+			break
+		}
+		col = addr - offset
+		if col >= 0 && col < h.cols {
+			return col, row, true
+		}
+	}
+	return 0, 0, false
+}
+
+// encode returns the character code of r, which is its custom character,
+// its ASCII code, or its code in the ROM. Unknown characters are '?'.
+func (h *HD44780) encode(r rune) byte {
+	if c, ok := h.custom[r]; ok {
+		return c
+	}
+	if r < 0x80 {
+		return byte(r)
+	}
+	if h.rom == HD44780_ROM_A02 {
+		if r >= 0xa0 && r <= 0xff {
+			return byte(r)
+		}
+	} else if c, ok := hd44780A00[r]; ok {
+		return c
+	}
+	return '?'
+}
+
+func (h *HD44780) fill(c byte) {
+	for row := range h.buffer {
+		for col := range h.buffer[row] {
+			h.buffer[row][col] = c
+			h.shadow[row][col] = c
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package gpio
+
+import "github.com/hybridgroup/gobot"
+
+var _ gobot.Driver = (*HD44780Driver)(nil)
+
+// HD44780Pins are the pins of a DigitalWriter wired to an HD44780 display in
+// 4 bit mode. Its RW pin must be tied to the ground.
+type HD44780Pins struct {
+	RS   string
+	EN   string
+	Data [4]string // D4 to D7
+}
+
+// HD44780Driver is a driver for the HD44780 character displays wired to the
+// pins of a DigitalWriter, such as the pins of a board or of an MCP23017
+// expander.
+type HD44780Driver struct {
+	name       string
+	connection DigitalWriter
+	pins       HD44780Pins
+	*HD44780
+}
+
+// NewHD44780Driver returns a new HD44780Driver of cols columns and rows rows
+// given a DigitalWriter, name and pins.
+func NewHD44780Driver(a DigitalWriter, name string, cols int, rows int, pins HD44780Pins) *HD44780Driver {
+	h := &HD44780Driver{
+		name:       name,
+		connection: a,
+		pins:       pins,
+	}
+	h.HD44780 = NewHD44780(NewHD44780FourBitBus(h.writeNibble), cols, rows)
+	return h
+}
+
+// Start initializes the display
+func (h *HD44780Driver) Start() (errs []error) {
+	if err := h.connection.DigitalWrite(h.pins.EN, 0); err != nil {
+		return []error{err}
+	}
+	if err := h.Init(); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// Halt implements the Driver interface
+func (h *HD44780Driver) Halt() (errs []error) { return }
+
+// Name returns the HD44780Drivers name
+func (h *HD44780Driver) Name() string { return h.name }
+
+// Connection returns the HD44780Drivers Connection
+func (h *HD44780Driver) Connection() gobot.Connection { return h.connection.(gobot.Connection) }
+
+func (h *HD44780Driver) writeNibble(rs bool, nibble byte) (err error) {
+	var level byte
+	if rs {
+		level = 1
+	}
+	if err = h.connection.DigitalWrite(h.pins.RS, level); err != nil {
+		return
+	}
+	for i, pin := range h.pins.Data {
+		if err = h.connection.DigitalWrite(pin, (nibble>>uint(i))&0x01); err != nil {
+			return
+		}
+	}
+	// the data is latched on the falling edge of the enable pin
+	if err = h.connection.DigitalWrite(h.pins.EN, 1); err != nil {
+		return
+	}
+	return h.connection.DigitalWrite(h.pins.EN, 0)
+}
@@ -0,0 +1,47 @@
+package gpio
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+type hd44780TestWriter struct {
+	gpioTestBareAdaptor
+	levels map[string]byte
+	// latched are the levels of RS and D7 to D4 when EN falls
+	latched []string
+}
+
+func (w *hd44780TestWriter) DigitalWrite(pin string, level byte) (err error) {
+	if pin == "en" && level == 0 && w.levels["en"] == 1 {
+		latched := ""
+		for _, p := range []string{"rs", "d7", "d6", "d5", "d4"} {
+			latched += string('0' + w.levels[p])
+		}
+		w.latched = append(w.latched, latched)
+	}
+	w.levels[pin] = level
+	return
+}
+
+func TestHD44780Driver(t *testing.T) {
+	w := &hd44780TestWriter{levels: make(map[string]byte)}
+	d := NewHD44780Driver(w, "lcd", 16, 2, HD44780Pins{
+		RS:   "rs",
+		EN:   "en",
+		Data: [4]string{"d4", "d5", "d6", "d7"},
+	})
+	gobottest.Assert(t, d.Name(), "lcd")
+	gobottest.Assert(t, d.Connection(), w)
+	gobottest.Assert(t, len(d.Start()), 0)
+	gobottest.Assert(t, w.latched, []string{
+		"00011", "00011", "00011", "00010",
+		"00010", "01000", "00000", "01100", "00000", "00001", "00000", "00110",
+	})
+
+	w.latched = nil
+	gobottest.Assert(t, d.Write("A"), nil)
+	gobottest.Assert(t, w.latched, []string{"10100", "10001"})
+	gobottest.Assert(t, len(d.Halt()), 0)
+}
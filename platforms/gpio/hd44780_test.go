@@ -0,0 +1,189 @@
+package gpio
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// hd44780TestBus records the instructions and data written to it, data
+// bytes are recorded with 0x100 added
+type hd44780TestBus struct {
+	written []int
+	err     error
+}
+
+func (b *hd44780TestBus) WriteCommand(c byte) error {
+	b.written = append(b.written, int(c))
+	return b.err
+}
+
+func (b *hd44780TestBus) WriteData(d byte) error {
+	b.written = append(b.written, 0x100|int(d))
+	return b.err
+}
+
+func data(s string) (d []int) {
+	for i := 0; i < len(s); i++ {
+		d = append(d, 0x100|int(s[i]))
+	}
+	return
+}
+
+func TestHD44780Init(t *testing.T) {
+	bus := &hd44780TestBus{}
+	h := NewHD44780(bus, 16, 2)
+	gobottest.Assert(t, h.Cols(), 16)
+	gobottest.Assert(t, h.Rows(), 2)
+	gobottest.Assert(t, h.Init(), nil)
+	gobottest.Assert(t, bus.written, []int{0x28, 0x0c, 0x01, 0x06})
+
+	bus = &hd44780TestBus{err: errors.New("write error")}
+	gobottest.Assert(t, NewHD44780(bus, 16, 2).Init(), errors.New("write error"))
+}
+
+func TestHD44780InvalidSize(t *testing.T) {
+	for _, size := range [][2]int{{16, 0}, {16, -1}, {0, 2}, {-1, 2}, {16, 5}, {24, 4}, {41, 2}} {
+		bus := &hd44780TestBus{}
+		h := NewHD44780(bus, size[0], size[1])
+		gobottest.Assert(t, h.Init(), ErrInvalidSize)
+		gobottest.Assert(t, h.SetCursor(0, 0), ErrInvalidPosition)
+		gobottest.Assert(t, h.SetCursor(0, 4), ErrInvalidPosition)
+		gobottest.Assert(t, h.SetPosition(1), ErrInvalidPosition)
+		gobottest.Assert(t, h.SetText(0, 0, "a"), ErrInvalidPosition)
+		gobottest.Assert(t, h.Write("a\nb"), ErrInvalidPosition)
+		gobottest.Assert(t, h.Flush(), nil)
+		gobottest.Assert(t, bus.written, data("a"))
+	}
+
+	gobottest.Assert(t, NewHD44780(&hd44780TestBus{}, 80, 1).Init(), nil)
+	gobottest.Assert(t, NewHD44780(&hd44780TestBus{}, 20, 3).Init(), nil)
+}
+
+func TestHD44780FourBitBus(t *testing.T) {
+	var nibbles []byte
+	bus := NewHD44780FourBitBus(func(rs bool, nibble byte) error {
+		if rs {
+			nibble |= 0x10
+		}
+		nibbles = append(nibbles, nibble)
+		return nil
+	})
+	gobottest.Assert(t, bus.WriteCommand(0x28), nil)
+	gobottest.Assert(t, bus.WriteData(0x41), nil)
+	gobottest.Assert(t, nibbles, []byte{0x02, 0x08, 0x14, 0x11})
+
+	nibbles = nil
+	gobottest.Assert(t, NewHD44780(bus, 8, 1).Init(), nil)
+	gobottest.Assert(t, nibbles, []byte{
+		0x03, 0x03, 0x03, 0x02,
+		0x02, 0x00, 0x00, 0x0c, 0x00, 0x01, 0x00, 0x06,
+	})
+}
+
+func TestHD44780Write(t *testing.T) {
+	bus := &hd44780TestBus{}
+	h := NewHD44780(bus, 20, 4)
+	gobottest.Assert(t, h.Write("ab\ncd"), nil)
+	expected := append(data("ab"), 0x80|0x40)
+	gobottest.Assert(t, bus.written, append(expected, data("cd")...))
+
+	bus.written = nil
+	gobottest.Assert(t, h.SetCursor(0, 2), nil)
+	gobottest.Assert(t, h.SetCursor(3, 3), nil)
+	gobottest.Assert(t, h.SetPosition(21), nil)
+	gobottest.Assert(t, bus.written, []int{0x80 | 20, 0x80 | 0x57, 0x80 | 0x41})
+
+	gobottest.Assert(t, h.SetCursor(20, 0), ErrInvalidPosition)
+	gobottest.Assert(t, h.SetCursor(0, 4), ErrInvalidPosition)
+	gobottest.Assert(t, h.SetCursor(0, -1), ErrInvalidPosition)
+	gobottest.Assert(t, h.SetCursor(-1, 0), ErrInvalidPosition)
+	gobottest.Assert(t, h.SetPosition(80), ErrInvalidPosition)
+	gobottest.Assert(t, h.SetPosition(-1), ErrInvalidPosition)
+	gobottest.Assert(t, h.SetText(0, -1, "a"), ErrInvalidPosition)
+}
+
+func TestHD44780Encode(t *testing.T) {
+	bus := &hd44780TestBus{}
+	h := NewHD44780(bus, 16, 2)
+	gobottest.Assert(t, h.Write("25°C π é"), nil)
+	gobottest.Assert(t, bus.written, []int{
+		0x100 | '2', 0x100 | '5', 0x1df, 0x100 | 'C', 0x100 | ' ', 0x1f7, 0x100 | ' ', 0x100 | '?',
+	})
+
+	bus.written = nil
+	h.SetROM(HD44780_ROM_A02)
+	gobottest.Assert(t, h.Write("é"), nil)
+	gobottest.Assert(t, bus.written, []int{0x1e9})
+
+	bus.written = nil
+	heart := [8]byte{0x00, 0x0a, 0x1f, 0x1f, 0x0e, 0x04, 0x00, 0x00}
+	gobottest.Assert(t, h.SetCustomRune('♥', 2, heart), nil)
+	gobottest.Assert(t, h.SetCursor(0, 0), nil)
+	gobottest.Assert(t, h.Write("♥"), nil)
+	gobottest.Assert(t, bus.written, []int{
+		0x50,
+		0x100, 0x10a, 0x11f, 0x11f, 0x10e, 0x104, 0x100, 0x100,
+		0x80, 0x102,
+	})
+
+	gobottest.Refute(t, h.SetCustomChar(8, heart), nil)
+}
+
+func TestHD44780Flush(t *testing.T) {
+	bus := &hd44780TestBus{}
+	h := NewHD44780(bus, 16, 2)
+	gobottest.Assert(t, h.SetText(0, 0, "Temp 21"), nil)
+	gobottest.Assert(t, h.SetText(10, 1, "a longer text"), nil)
+	gobottest.Assert(t, h.Flush(), nil)
+	// the cursor is already at the origin and spaces are skipped
+	expected := append(data("Temp"), 0x80|5)
+	expected = append(expected, data("21")...)
+	expected = append(expected, 0x80|0x4a)
+	expected = append(expected, data("a")...)
+	expected = append(expected, 0x80|0x4c)
+	gobottest.Assert(t, bus.written, append(expected, data("long")...))
+
+	// only the changed characters are sent
+	bus.written = nil
+	gobottest.Assert(t, h.SetText(5, 0, "22"), nil)
+	gobottest.Assert(t, h.Flush(), nil)
+	gobottest.Assert(t, bus.written, append([]int{0x80 | 6}, data("2")...))
+
+	bus.written = nil
+	gobottest.Assert(t, h.Flush(), nil)
+	gobottest.Assert(t, len(bus.written), 0)
+
+	bus.written = nil
+	h.ClearText()
+	gobottest.Assert(t, h.SetText(0, 0, "Temp 22"), nil)
+	gobottest.Assert(t, h.Flush(), nil)
+	expected = append([]int{0x80 | 0x4a}, data(" ")...)
+	expected = append(expected, 0x80|0x4c)
+	gobottest.Assert(t, bus.written, append(expected, data("    ")...))
+
+	// text written at the cursor is known to the buffer
+	bus.written = nil
+	gobottest.Assert(t, h.SetCursor(0, 1), nil)
+	gobottest.Assert(t, h.Write("x"), nil)
+	gobottest.Assert(t, h.SetText(0, 1, "x"), nil)
+	gobottest.Assert(t, h.Flush(), nil)
+	gobottest.Assert(t, bus.written, append([]int{0x80 | 0x40}, data("x")...))
+
+	gobottest.Assert(t, h.SetText(16, 0, "x"), ErrInvalidPosition)
+}
+
+func TestHD44780Control(t *testing.T) {
+	bus := &hd44780TestBus{}
+	h := NewHD44780(bus, 16, 2)
+	gobottest.Assert(t, h.ShowCursor(true), nil)
+	gobottest.Assert(t, h.Blink(true), nil)
+	gobottest.Assert(t, h.ShowCursor(false), nil)
+	gobottest.Assert(t, h.Display(false), nil)
+	gobottest.Assert(t, h.Scroll(true), nil)
+	gobottest.Assert(t, h.Scroll(false), nil)
+	gobottest.Assert(t, h.Home(), nil)
+	gobottest.Assert(t, h.Clear(), nil)
+	gobottest.Assert(t, bus.written, []int{0x0e, 0x0f, 0x0d, 0x09, 0x18, 0x1c, 0x02, 0x01})
+}
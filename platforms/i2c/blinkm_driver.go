@@ -13,6 +13,8 @@ const blinkmAddress = 0x09
 type BlinkMDriver struct {
 	name       string
 	connection I2c
+	device     I2cConnection
+	Config
 	gobot.Commander
 }
 
@@ -23,10 +25,13 @@ type BlinkMDriver struct {
 //	Fade - fades the RGB color
 //	FirmwareVersion - returns the version of the current Frimware
 //	Color - returns the color of the LED.
-func NewBlinkMDriver(a I2c, name string) *BlinkMDriver {
+//
+// Optionally accepts the options WithBus and WithAddress
+func NewBlinkMDriver(a I2c, name string, options ...Option) *BlinkMDriver {
 	b := &BlinkMDriver{
 		name:       name,
 		connection: a,
+		Config:     newConfig(blinkmAddress, options...),
 		Commander:  gobot.NewCommander(),
 	}
 
@@ -60,55 +65,60 @@ func (b *BlinkMDriver) Connection() gobot.Connection { return b.connection.(gobo
 
 // Start writes start bytes
 func (b *BlinkMDriver) Start() (errs []error) {
-	if err := b.connection.I2cStart(blinkmAddress); err != nil {
+	device, err := b.connect(b.connection, b)
+	if err != nil {
 		return []error{err}
 	}
-	if err := b.connection.I2cWrite(blinkmAddress, []byte("o")); err != nil {
+	b.device = device
+	if _, err := b.device.Write([]byte("o")); err != nil {
 		return []error{err}
 	}
 	return
 }
 
 // Halt returns true if device is halted successfully
-func (b *BlinkMDriver) Halt() (errs []error) { return }
+func (b *BlinkMDriver) Halt() (errs []error) {
+	releaseAddresses(b.connection, b)
+	return
+}
 
 // Rgb sets color using r,g,b params
 func (b *BlinkMDriver) Rgb(red byte, green byte, blue byte) (err error) {
-	if err = b.connection.I2cWrite(blinkmAddress, []byte("n")); err != nil {
+	if _, err = b.device.Write([]byte("n")); err != nil {
 		return
 	}
-	err = b.connection.I2cWrite(blinkmAddress, []byte{red, green, blue})
+	_, err = b.device.Write([]byte{red, green, blue})
 	return
 }
 
 // Fade removes color using r,g,b params
 func (b *BlinkMDriver) Fade(red byte, green byte, blue byte) (err error) {
-	if err = b.connection.I2cWrite(blinkmAddress, []byte("c")); err != nil {
+	if _, err = b.device.Write([]byte("c")); err != nil {
 		return
 	}
-	err = b.connection.I2cWrite(blinkmAddress, []byte{red, green, blue})
+	_, err = b.device.Write([]byte{red, green, blue})
 	return
 }
 
 // FirmwareVersion returns version with MAYOR.minor format
 func (b *BlinkMDriver) FirmwareVersion() (version string, err error) {
-	if err = b.connection.I2cWrite(blinkmAddress, []byte("Z")); err != nil {
+	data := make([]byte, 2)
+	if _, err = b.device.Write([]byte("Z")); err != nil {
 		return
 	}
-	data, err := b.connection.I2cRead(blinkmAddress, 2)
-	if len(data) != 2 || err != nil {
-		return
+	if n, err := b.device.Read(data); n != 2 || err != nil {
+		return "", err
 	}
 	return fmt.Sprintf("%v.%v", data[0], data[1]), nil
 }
 
 // Color returns an array with current rgb color
 func (b *BlinkMDriver) Color() (color []byte, err error) {
-	if err = b.connection.I2cWrite(blinkmAddress, []byte("g")); err != nil {
+	data := make([]byte, 3)
+	if _, err = b.device.Write([]byte("g")); err != nil {
 		return
 	}
-	data, err := b.connection.I2cRead(blinkmAddress, 3)
-	if len(data) != 3 || err != nil {
+	if n, err := b.device.Read(data); n != 3 || err != nil {
 		return []byte{}, err
 	}
 	return []byte{data[0], data[1], data[2]}, nil
@@ -15,7 +15,9 @@ func initTestBlinkMDriver() (driver *BlinkMDriver) {
 
 func initTestBlinkDriverWithStubbedAdaptor() (*BlinkMDriver, *i2cTestAdaptor) {
 	adaptor := newI2cTestAdaptor("adaptor")
-	return NewBlinkMDriver(adaptor, "bot"), adaptor
+	driver := NewBlinkMDriver(adaptor, "bot")
+	driver.Start()
+	return driver, adaptor
 }
 
 // --------- TESTS
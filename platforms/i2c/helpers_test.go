@@ -1,5 +1,7 @@
 package i2c
 
+import "sync"
+
 var rgb = map[string]interface{}{
 	"red":   1.0,
 	"green": 1.0,
@@ -19,6 +21,7 @@ type i2cTestAdaptor struct {
 	i2cReadImpl  func() ([]byte, error)
 	i2cWriteImpl func() error
 	i2cStartImpl func() error
+	written      [][]byte
 }
 
 func (t *i2cTestAdaptor) I2cStart(int) (err error) {
@@ -27,7 +30,8 @@ func (t *i2cTestAdaptor) I2cStart(int) (err error) {
 func (t *i2cTestAdaptor) I2cRead(int, int) (data []byte, err error) {
 	return t.i2cReadImpl()
 }
-func (t *i2cTestAdaptor) I2cWrite(int, []byte) (err error) {
+func (t *i2cTestAdaptor) I2cWrite(address int, b []byte) (err error) {
+	t.written = append(t.written, b)
 	return t.i2cWriteImpl()
 }
 func (t *i2cTestAdaptor) Name() string             { return t.name }
@@ -48,3 +52,100 @@ func newI2cTestAdaptor(name string) *i2cTestAdaptor {
 		},
 	}
 }
+
+// i2cTestRegisters is an I2cConnector with a single device made of
+// registers. A write selects the register of its first byte and writes the
+// other bytes from there on, a read reads from the selected register on.
+// Reading a register with a queue in fifos pops the queue instead.
+type i2cTestRegisters struct {
+	*i2cTestAdaptor
+	mutex     sync.Mutex
+	registers [256]byte
+	fifos     map[uint8][]byte
+	reg       uint8
+	writes    [][]byte
+	err       error
+}
+
+func newI2cTestRegisters(name string) *i2cTestRegisters {
+	return &i2cTestRegisters{
+		i2cTestAdaptor: newI2cTestAdaptor(name),
+		fifos:          make(map[uint8][]byte),
+	}
+}
+
+func (r *i2cTestRegisters) I2cGetConnection(address int, bus int) (I2cConnection, error) {
+	return r, nil
+}
+func (r *i2cTestRegisters) I2cDefaultBus() int { return 1 }
+
+// register returns the value of register reg
+func (r *i2cTestRegisters) register(reg uint8) byte {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.registers[reg]
+}
+
+// setRegisters sets the registers from reg on to values
+func (r *i2cTestRegisters) setRegisters(reg uint8, values ...byte) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	copy(r.registers[reg:], values)
+}
+
+func (r *i2cTestRegisters) Read(b []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.err != nil {
+		return 0, r.err
+	}
+	if fifo, ok := r.fifos[r.reg]; ok {
+		n := copy(b, fifo)
+		r.fifos[r.reg] = fifo[n:]
+		return len(b), nil
+	}
+	for i := range b {
+		b[i] = r.registers[r.reg]
+		r.reg++
+	}
+	return len(b), nil
+}
+func (r *i2cTestRegisters) Write(b []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.err != nil {
+		return 0, r.err
+	}
+	r.writes = append(r.writes, append([]byte{}, b...))
+	if len(b) == 0 {
+		return 0, nil
+	}
+	r.reg = b[0]
+	for _, v := range b[1:] {
+		r.registers[r.reg] = v
+		r.reg++
+	}
+	return len(b), nil
+}
+func (r *i2cTestRegisters) ReadRegister(reg uint8, b []byte) (int, error) {
+	if _, err := r.Write([]byte{reg}); err != nil {
+		return 0, err
+	}
+	return r.Read(b)
+}
+func (r *i2cTestRegisters) WriteWord(reg uint8, val uint16) (int, error) {
+	return r.Write([]byte{reg, byte(val), byte(val >> 8)})
+}
+func (r *i2cTestRegisters) Tx(w []byte, b []byte) error {
+	if len(w) > 0 {
+		if _, err := r.Write(w); err != nil {
+			return err
+		}
+	}
+	if len(b) > 0 {
+		_, err := r.Read(b)
+		return err
+	}
+	return nil
+}
+func (r *i2cTestRegisters) Close() error { return nil }
@@ -9,13 +9,18 @@ const hmc6352Address = 0x21
 type HMC6352Driver struct {
 	name       string
 	connection I2c
+	device     I2cConnection
+	Config
 }
 
-// NewHMC6352Driver creates a new driver with specified name and i2c interface
-func NewHMC6352Driver(a I2c, name string) *HMC6352Driver {
+// NewHMC6352Driver creates a new driver with specified name and i2c interface.
+//
+// Optionally accepts the options WithBus and WithAddress
+func NewHMC6352Driver(a I2c, name string, options ...Option) *HMC6352Driver {
 	return &HMC6352Driver{
 		name:       name,
 		connection: a,
+		Config:     newConfig(hmc6352Address, options...),
 	}
 }
 
@@ -24,28 +29,34 @@ func (h *HMC6352Driver) Connection() gobot.Connection { return h.connection.(gob
 
 // Start initialized the hmc6352
 func (h *HMC6352Driver) Start() (errs []error) {
-	if err := h.connection.I2cStart(hmc6352Address); err != nil {
+	device, err := h.connect(h.connection, h)
+	if err != nil {
 		return []error{err}
 	}
-	if err := h.connection.I2cWrite(hmc6352Address, []byte("A")); err != nil {
+	h.device = device
+	if _, err := h.device.Write([]byte("A")); err != nil {
 		return []error{err}
 	}
 	return
 }
 
 // Halt returns true if devices is halted successfully
-func (h *HMC6352Driver) Halt() (errs []error) { return }
+func (h *HMC6352Driver) Halt() (errs []error) {
+	releaseAddresses(h.connection, h)
+	return
+}
 
 // Heading returns the current heading
 func (h *HMC6352Driver) Heading() (heading uint16, err error) {
-	if err = h.connection.I2cWrite(hmc6352Address, []byte("A")); err != nil {
+	if _, err = h.device.Write([]byte("A")); err != nil {
 		return
 	}
-	ret, err := h.connection.I2cRead(hmc6352Address, 2)
+	ret := make([]byte, 2)
+	n, err := h.device.Read(ret)
 	if err != nil {
 		return
 	}
-	if len(ret) == 2 {
+	if n == 2 {
 		heading = (uint16(ret[1]) + uint16(ret[0])*256) / 10
 		return
 	} else {
@@ -15,7 +15,9 @@ func initTestHMC6352Driver() (driver *HMC6352Driver) {
 
 func initTestHMC6352DriverWithStubbedAdaptor() (*HMC6352Driver, *i2cTestAdaptor) {
 	adaptor := newI2cTestAdaptor("adaptor")
-	return NewHMC6352Driver(adaptor, "bot"), adaptor
+	driver := NewHMC6352Driver(adaptor, "bot")
+	driver.Start()
+	return driver, adaptor
 }
 
 // --------- TESTS
@@ -2,22 +2,39 @@ package i2c
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"sync"
 
 	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/gpio"
 )
 
 var (
 	ErrEncryptedBytes  = errors.New("Encrypted bytes")
 	ErrNotEnoughBytes  = errors.New("Not enough bytes read")
 	ErrNotReady        = errors.New("Device is not ready")
-	ErrInvalidPosition = errors.New("Invalid position value")
+	ErrInvalidPosition = gpio.ErrInvalidPosition
+	ErrInvalidBus      = errors.New("Invalid i2c bus")
+	ErrInvalidGain     = errors.New("Invalid gain")
+	ErrInvalidRange    = errors.New("Invalid range")
+	ErrInvalidTime     = errors.New("Invalid integration time")
+	ErrInvalidSamples  = errors.New("Invalid number of samples")
+	ErrSaturated       = errors.New("Sensor is saturated")
+	ErrInvalidPin      = errors.New("Invalid pin")
+	ErrWiiExtension    = errors.New("Wrong Wii extension controller")
 )
 
 const (
-	Error    = "error"
-	Joystick = "joystick"
-	C        = "c"
-	Z        = "z"
+	Error         = "error"
+	Data          = "data"
+	Joystick      = "joystick"
+	C             = "c"
+	Z             = "z"
+	Interrupt     = "interrupt"
+	Accelerometer = "accelerometer"
+	Push          = "push"
+	Release       = "release"
 )
 
 type I2cStarter interface {
@@ -39,8 +56,220 @@ type I2c interface {
 	I2cWriter
 }
 
+// I2cExtended is an I2c adaptor which provides the SMBus commands and
+// combined transfers of the i2c device at address on its default bus
 type I2cExtended interface {
 	I2c
 	I2cWriteWord(address int, register uint8, value uint16) (err error)
 	I2cReadRegister(address []byte, size int) (data []byte, err error)
+	I2cWriteQuick(address int, bit uint8) (err error)
+	I2cReadByte(address int) (value uint8, err error)
+	I2cWriteByte(address int, value uint8) (err error)
+	I2cReadByteData(address int, register uint8) (value uint8, err error)
+	I2cWriteByteData(address int, register uint8, value uint8) (err error)
+	I2cReadWordData(address int, register uint8) (value uint16, err error)
+	I2cWriteWordData(address int, register uint8, value uint16) (err error)
+	I2cProcessCall(address int, register uint8, value uint16) (result uint16, err error)
+	I2cReadBlockData(address int, register uint8) (data []byte, err error)
+	I2cWriteBlockData(address int, register uint8, data []byte) (err error)
+	I2cBlockProcessCall(address int, register uint8, data []byte) (result []byte, err error)
+	I2cReadI2cBlockData(address int, register uint8, size int) (data []byte, err error)
+	I2cWriteI2cBlockData(address int, register uint8, data []byte) (err error)
+	// I2cTx writes w and then reads len(r) bytes into r, with a repeated
+	// start when the bus supports it
+	I2cTx(address int, w []byte, r []byte) (err error)
 }
+
+// I2cConnection is a connection to the i2c device at a fixed address. Each
+// method is a single transaction which is not interleaved with the
+// transactions of other devices on the same bus.
+type I2cConnection interface {
+	io.ReadWriteCloser
+	// ReadRegister reads len(b) bytes starting at register reg
+	ReadRegister(reg uint8, b []byte) (int, error)
+	// WriteWord writes the 16 bit val to register reg
+	WriteWord(reg uint8, val uint16) (int, error)
+	// Tx writes w and then reads len(r) bytes into r as one transaction
+	Tx(w []byte, r []byte) error
+}
+
+// I2cConnector is implemented by adaptors which provide a connection to
+// each i2c device, instead of addressing the device on every call. Such
+// adaptors may have several numbered buses.
+type I2cConnector interface {
+	// I2cGetConnection returns a connection to the device at address on bus
+	I2cGetConnection(address int, bus int) (I2cConnection, error)
+	// I2cDefaultBus returns the bus used by drivers which do not specify one
+	I2cDefaultBus() int
+}
+
+// Config is the bus and address of the device of an i2c driver. A bus of -1
+// is the default bus of the adaptor.
+type Config struct {
+	bus     int
+	address int
+}
+
+// Option overrides the bus or the address of the device of an i2c driver
+type Option func(*Config)
+
+// WithBus sets the bus the device is connected to
+func WithBus(bus int) Option {
+	return func(c *Config) { c.bus = bus }
+}
+
+// WithAddress sets the address of the device
+func WithAddress(address int) Option {
+	return func(c *Config) { c.address = address }
+}
+
+// newConfig returns the Config of a device at its default address on the
+// default bus of the adaptor, with options applied
+func newConfig(address int, options ...Option) Config {
+	c := Config{bus: -1, address: address}
+	for _, option := range options {
+		option(&c)
+	}
+	return c
+}
+
+// Bus returns the bus of the device, or -1 for the default bus of the adaptor
+func (c *Config) Bus() int { return c.bus }
+
+// Address returns the address of the device
+func (c *Config) Address() int { return c.address }
+
+// connect claims the address of the device for driver d and returns a
+// connection to the device
+func (c *Config) connect(a I2c, d gobot.Driver) (I2cConnection, error) {
+	return getI2cConnection(a, d, c.bus, c.address)
+}
+
+type i2cClaim struct {
+	adaptor I2c
+	bus     int
+	address int
+}
+
+// i2cClaims are the drivers using each address of the buses of the adaptors
+var i2cClaims = struct {
+	sync.Mutex
+	drivers map[i2cClaim]gobot.Driver
+}{drivers: make(map[i2cClaim]gobot.Driver)}
+
+// claimAddress claims address on bus of a for driver d. An address can not be
+// claimed by two drivers, until the driver which claimed it is halted.
+func claimAddress(a I2c, bus int, address int, d gobot.Driver) error {
+	i2cClaims.Lock()
+	defer i2cClaims.Unlock()
+
+	claim := i2cClaim{adaptor: a, bus: bus, address: address}
+	if owner, ok := i2cClaims.drivers[claim]; ok && owner != d {
+		return fmt.Errorf("i2c address 0x%02x on bus %v is already used by %v",
+			address, bus, owner.Name())
+	}
+	i2cClaims.drivers[claim] = d
+	return nil
+}
+
+// releaseAddresses releases the addresses claimed on the buses of a by
+// driver d, so that other drivers may use them once d is halted
+func releaseAddresses(a I2c, d gobot.Driver) {
+	i2cClaims.Lock()
+	defer i2cClaims.Unlock()
+
+	for claim, owner := range i2cClaims.drivers {
+		if claim.adaptor == a && owner == d {
+			delete(i2cClaims.drivers, claim)
+		}
+	}
+}
+
+// getI2cConnection claims address on bus for driver d and returns a
+// connection to the device, given by the adaptor when it is an I2cConnector.
+// Otherwise the connection uses the I2c methods of the adaptor, which only
+// has bus 0. A bus of -1 is the default bus of the adaptor.
+func getI2cConnection(a I2c, d gobot.Driver, bus int, address int) (I2cConnection, error) {
+	c, connector := a.(I2cConnector)
+	if bus < 0 {
+		bus = 0
+		if connector {
+			bus = c.I2cDefaultBus()
+		}
+	}
+	if !connector && bus != 0 {
+		return nil, ErrInvalidBus
+	}
+	if err := claimAddress(a, bus, address, d); err != nil {
+		return nil, err
+	}
+
+	if connector {
+		return c.I2cGetConnection(address, bus)
+	}
+	if err := a.I2cStart(address); err != nil {
+		return nil, err
+	}
+	return &adaptorConnection{adaptor: a, address: address}, nil
+}
+
+// adaptorConnection is an I2cConnection using the I2c methods of an adaptor
+// which is not an I2cConnector. Its transactions are only as safe as the
+// adaptor is.
+type adaptorConnection struct {
+	adaptor I2c
+	address int
+}
+
+func (c *adaptorConnection) Read(b []byte) (n int, err error) {
+	data, err := c.adaptor.I2cRead(c.address, len(b))
+	if err != nil {
+		return
+	}
+	return copy(b, data), nil
+}
+
+func (c *adaptorConnection) Write(b []byte) (n int, err error) {
+	if err = c.adaptor.I2cWrite(c.address, b); err != nil {
+		return
+	}
+	return len(b), nil
+}
+
+func (c *adaptorConnection) ReadRegister(reg uint8, b []byte) (n int, err error) {
+	if e, ok := c.adaptor.(I2cExtended); ok {
+		data, err := e.I2cReadRegister([]byte{byte(c.address), reg}, len(b))
+		if err != nil {
+			return 0, err
+		}
+		return copy(b, data), nil
+	}
+	if err = c.Tx([]byte{reg}, b); err != nil {
+		return
+	}
+	return len(b), nil
+}
+
+func (c *adaptorConnection) WriteWord(reg uint8, val uint16) (n int, err error) {
+	if e, ok := c.adaptor.(I2cExtended); ok {
+		return 0, e.I2cWriteWord(c.address, reg, val)
+	}
+	return c.Write([]byte{reg, byte(val), byte(val >> 8)})
+}
+
+func (c *adaptorConnection) Tx(w []byte, r []byte) (err error) {
+	if e, ok := c.adaptor.(I2cExtended); ok {
+		return e.I2cTx(c.address, w, r)
+	}
+	if len(w) > 0 {
+		if _, err = c.Write(w); err != nil {
+			return
+		}
+	}
+	if len(r) > 0 {
+		_, err = c.Read(r)
+	}
+	return
+}
+
+func (c *adaptorConnection) Close() error { return nil }
@@ -0,0 +1,156 @@
+package i2c
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+type i2cTestConnector struct {
+	*i2cTestAdaptor
+	address int
+	bus     int
+}
+
+func (t *i2cTestConnector) I2cGetConnection(address int, bus int) (I2cConnection, error) {
+	t.address = address
+	t.bus = bus
+	return &adaptorConnection{adaptor: t.i2cTestAdaptor, address: address}, nil
+}
+
+func (t *i2cTestConnector) I2cDefaultBus() int { return 1 }
+
+func TestGetI2cConnection(t *testing.T) {
+	adaptor := newI2cTestAdaptor("adaptor")
+	writes := 0
+	adaptor.i2cWriteImpl = func() error {
+		writes++
+		return nil
+	}
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return []byte{0x01, 0x02}, nil
+	}
+
+	c, err := getI2cConnection(adaptor, NewHMC6352Driver(adaptor, "hmc"), -1, 0x10)
+	gobottest.Assert(t, err, nil)
+
+	buf := make([]byte, 3)
+	n, err := c.Read(buf)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, n, 2)
+	gobottest.Assert(t, buf, []byte{0x01, 0x02, 0x00})
+
+	n, _ = c.Write([]byte{0x01, 0x02})
+	gobottest.Assert(t, n, 2)
+
+	// without I2cExtended, register access is a write followed by a read
+	n, err = c.ReadRegister(0x01, buf[:2])
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, n, 2)
+	c.WriteWord(0x01, 0x0203)
+	gobottest.Assert(t, writes, 3)
+
+	gobottest.Assert(t, c.Tx([]byte{0x01}, buf), nil)
+	gobottest.Assert(t, writes, 4)
+	gobottest.Assert(t, c.Close(), nil)
+
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return nil, errors.New("read error")
+	}
+	gobottest.Assert(t, c.Tx([]byte{0x01}, buf), errors.New("read error"))
+	adaptor.i2cWriteImpl = func() error {
+		return errors.New("write error")
+	}
+	gobottest.Assert(t, c.Tx([]byte{0x01}, buf), errors.New("write error"))
+
+	adaptor.i2cStartImpl = func() error {
+		return errors.New("start error")
+	}
+	_, err = getI2cConnection(adaptor, NewHMC6352Driver(adaptor, "hmc"), 0, 0x11)
+	gobottest.Assert(t, err, errors.New("start error"))
+
+	_, err = getI2cConnection(adaptor, NewHMC6352Driver(adaptor, "hmc"), 1, 0x12)
+	gobottest.Assert(t, err, ErrInvalidBus)
+}
+
+func TestGetI2cConnectionFromConnector(t *testing.T) {
+	adaptor := &i2cTestConnector{i2cTestAdaptor: newI2cTestAdaptor("adaptor")}
+	adaptor.i2cStartImpl = func() error {
+		return errors.New("I2cStart should not be called")
+	}
+
+	_, err := getI2cConnection(adaptor, NewHMC6352Driver(adaptor, "hmc"), -1, 0x52)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, adaptor.address, 0x52)
+	gobottest.Assert(t, adaptor.bus, 1)
+
+	_, err = getI2cConnection(adaptor, NewHMC6352Driver(adaptor, "hmc"), 2, 0x52)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, adaptor.bus, 2)
+}
+
+func TestI2cConfig(t *testing.T) {
+	c := newConfig(0x10)
+	gobottest.Assert(t, c.Bus(), -1)
+	gobottest.Assert(t, c.Address(), 0x10)
+
+	c = newConfig(0x10, WithBus(2), WithAddress(0x11))
+	gobottest.Assert(t, c.Bus(), 2)
+	gobottest.Assert(t, c.Address(), 0x11)
+}
+
+func TestI2cAddressConflict(t *testing.T) {
+	adaptor := &i2cTestConnector{i2cTestAdaptor: newI2cTestAdaptor("adaptor")}
+
+	first := NewHMC6352Driver(adaptor, "first")
+	gobottest.Assert(t, len(first.Start()), 0)
+	// a driver may start again
+	gobottest.Assert(t, len(first.Start()), 0)
+
+	second := NewHMC6352Driver(adaptor, "second")
+	gobottest.Assert(t, second.Start()[0],
+		errors.New("i2c address 0x21 on bus 1 is already used by first"))
+
+	// an explicit default bus is the same bus
+	second = NewHMC6352Driver(adaptor, "second", WithBus(1))
+	gobottest.Refute(t, len(second.Start()), 0)
+
+	second = NewHMC6352Driver(adaptor, "second", WithBus(2))
+	gobottest.Assert(t, len(second.Start()), 0)
+	gobottest.Assert(t, adaptor.bus, 2)
+
+	third := NewHMC6352Driver(adaptor, "third", WithAddress(0x22))
+	gobottest.Assert(t, len(third.Start()), 0)
+	gobottest.Assert(t, adaptor.address, 0x22)
+
+	// halting a driver releases its addresses
+	second = NewHMC6352Driver(adaptor, "second")
+	gobottest.Refute(t, len(second.Start()), 0)
+	gobottest.Assert(t, len(first.Halt()), 0)
+	gobottest.Assert(t, len(second.Start()), 0)
+	gobottest.Assert(t, first.Start()[0],
+		errors.New("i2c address 0x21 on bus 1 is already used by second"))
+
+	// the claims are per adaptor
+	other := &i2cTestConnector{i2cTestAdaptor: newI2cTestAdaptor("other")}
+	gobottest.Assert(t, len(NewHMC6352Driver(other, "first").Start()), 0)
+}
+
+func TestI2cAddressRelease(t *testing.T) {
+	adaptor := &i2cTestConnector{i2cTestAdaptor: newI2cTestAdaptor("adaptor")}
+
+	// all the addresses of a driver are released
+	jhd := NewJHD1313M1Driver(adaptor, "jhd")
+	_, err := jhd.connect(adaptor, jhd)
+	gobottest.Assert(t, err, nil)
+	_, err = getI2cConnection(adaptor, jhd, -1, jhd.rgbAddress)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, len(jhd.Halt()), 0)
+
+	for _, address := range []int{0x3E, 0x62} {
+		d := NewHMC6352Driver(adaptor, "hmc", WithAddress(address))
+		gobottest.Assert(t, len(d.Start()), 0)
+		gobottest.Assert(t, len(d.Halt()), 0)
+	}
+}
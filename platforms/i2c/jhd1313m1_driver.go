@@ -1,10 +1,10 @@
 package i2c
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/gpio"
 )
 
 const (
@@ -48,7 +48,8 @@ var _ gobot.Driver = (*JHD1313M1Driver)(nil)
 // as the Grove screen (jhd1313m1) isn't loaded with latin 1 characters.
 // It's up to the developer to load the set up to 8 custom characters and
 // update the input text so the character is swapped by a byte reflecting
-// the position of the custom character to use.
+// the position of the custom character to use, or to load them with
+// SetCustomRune so they are swapped when written.
 // See SetCustomChar
 var CustomLCDChars = map[string][8]byte{
 	"é":       [8]byte{130, 132, 142, 145, 159, 144, 142, 128},
@@ -72,21 +73,32 @@ var CustomLCDChars = map[string][8]byte{
 // one belongs to a controller and the other controls solely the backlight.
 // This module was tested with the Seed Grove LCD RGB Backlight v2.0 display which requires 5V to operate.
 // http://www.seeedstudio.com/wiki/Grove_-_LCD_RGB_Backlight
+//
+// The 16x2 display is an HD44780 compatible controller, see gpio.HD44780
+// for the text functions.
 type JHD1313M1Driver struct {
 	name       string
 	connection I2c
-	lcdAddress int
+	lcd        I2cConnection
+	rgb        I2cConnection
 	rgbAddress int
+	Config
+	*gpio.HD44780
 }
 
 // NewJHD1313M1Driver creates a new driver with specified name and i2c interface.
-func NewJHD1313M1Driver(a I2c, name string) *JHD1313M1Driver {
-	return &JHD1313M1Driver{
+//
+// Optionally accepts the options WithBus and WithAddress. The address is the
+// address of the LCD controller, the backlight is always at 0x62 on the same bus.
+func NewJHD1313M1Driver(a I2c, name string, options ...Option) *JHD1313M1Driver {
+	h := &JHD1313M1Driver{
 		name:       name,
 		connection: a,
-		lcdAddress: 0x3E,
 		rgbAddress: 0x62,
+		Config:     newConfig(0x3E, options...),
 	}
+	h.HD44780 = gpio.NewHD44780(&jhd1313m1Bus{h}, 16, 2)
+	return h
 }
 
 // Name returns the name the JHD1313M1 Driver was given when created.
@@ -99,36 +111,26 @@ func (h *JHD1313M1Driver) Connection() gobot.Connection {
 
 // Start starts the backlit and the screen and initializes the states.
 func (h *JHD1313M1Driver) Start() []error {
-	if err := h.connection.I2cStart(h.lcdAddress); err != nil {
+	lcd, err := h.connect(h.connection, h)
+	if err != nil {
 		return []error{err}
 	}
+	h.lcd = lcd
 
-	if err := h.connection.I2cStart(h.rgbAddress); err != nil {
+	rgb, err := getI2cConnection(h.connection, h, h.Bus(), h.rgbAddress)
+	if err != nil {
 		return []error{err}
 	}
+	h.rgb = rgb
 
 	<-time.After(50000 * time.Microsecond)
-	payload := []byte{LCD_CMD, LCD_FUNCTIONSET | LCD_2LINE}
-	if err := h.connection.I2cWrite(h.lcdAddress, payload); err != nil {
-		if err := h.connection.I2cWrite(h.lcdAddress, payload); err != nil {
+	if err := h.Init(); err != nil {
+		// the controller may miss the first command after power on
+		if err := h.Init(); err != nil {
 			return []error{err}
 		}
 	}
 
-	<-time.After(100 * time.Microsecond)
-	if err := h.connection.I2cWrite(h.lcdAddress, []byte{LCD_CMD, LCD_DISPLAYCONTROL | LCD_DISPLAYON}); err != nil {
-		return []error{err}
-	}
-
-	<-time.After(100 * time.Microsecond)
-	if err := h.Clear(); err != nil {
-		return []error{err}
-	}
-
-	if err := h.connection.I2cWrite(h.lcdAddress, []byte{LCD_CMD, LCD_ENTRYMODESET | LCD_ENTRYLEFT | LCD_ENTRYSHIFTDECREMENT}); err != nil {
-		return []error{err}
-	}
-
 	if err := h.setReg(0, 0); err != nil {
 		return []error{err}
 	}
@@ -157,93 +159,34 @@ func (h *JHD1313M1Driver) SetRGB(r, g, b int) error {
 	return h.setReg(REG_BLUE, b)
 }
 
-// Clear clears the text on the lCD display.
-func (h *JHD1313M1Driver) Clear() error {
-	err := h.command([]byte{LCD_CLEARDISPLAY})
-	return err
-}
-
-// Home sets the cursor to the origin position on the display.
-func (h *JHD1313M1Driver) Home() error {
-	err := h.command([]byte{LCD_RETURNHOME})
-	// This wait fixes a race condition when calling home and clear back to back.
-	<-time.After(2 * time.Millisecond)
-	return err
-}
-
-// Write displays the passed message on the screen.
-func (h *JHD1313M1Driver) Write(message string) error {
-	// This wait fixes an odd bug where the clear function doesn't always work properly.
-	<-time.After(1 * time.Millisecond)
-	for _, val := range message {
-		if val == '\n' {
-			if err := h.SetPosition(16); err != nil {
-				return err
-			}
-			continue
-		}
-		if err := h.connection.I2cWrite(h.lcdAddress, []byte{LCD_DATA, byte(val)}); err != nil {
-			return err
-		}
-	}
+// Halt is a noop function.
+func (h *JHD1313M1Driver) Halt() []error {
+	releaseAddresses(h.connection, h)
 	return nil
 }
 
-// SetPosition sets the cursor and the data display to pos.
-// 0..15 are the positions in the first display line.
-// 16..32 are the positions in the second display line.
-func (h *JHD1313M1Driver) SetPosition(pos int) (err error) {
-	if pos < 0 || pos > 31 {
-		err = ErrInvalidPosition
-		return
-	}
-	offset := byte(pos)
-	if pos >= 16 {
-		offset -= 16
-		offset |= LCD_2NDLINEOFFSET
-	}
-	err = h.command([]byte{LCD_SETDDRAMADDR | offset})
-	return
-}
-
-func (h *JHD1313M1Driver) Scroll(leftToRight bool) error {
-	if leftToRight {
-		return h.connection.I2cWrite(h.lcdAddress, []byte{LCD_CMD, LCD_CURSORSHIFT | LCD_DISPLAYMOVE | LCD_MOVELEFT})
-	}
-
-	return h.connection.I2cWrite(h.lcdAddress, []byte{LCD_CMD, LCD_CURSORSHIFT | LCD_DISPLAYMOVE | LCD_MOVERIGHT})
-}
-
-// Halt is a noop function.
-func (h *JHD1313M1Driver) Halt() []error { return nil }
-
 func (h *JHD1313M1Driver) setReg(command int, data int) error {
-	if err := h.connection.I2cWrite(h.rgbAddress, []byte{byte(command), byte(data)}); err != nil {
+	if err := h.write(h.rgb, []byte{byte(command), byte(data)}); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (h *JHD1313M1Driver) command(buf []byte) error {
-	return h.connection.I2cWrite(h.lcdAddress, append([]byte{LCD_CMD}, buf...))
+func (h *JHD1313M1Driver) write(device I2cConnection, buf []byte) error {
+	_, err := device.Write(buf)
+	return err
 }
 
-// CustomChar sets one of the 8 CGRAM locations with a custom character.
-// The custom character can be used by writing a byte of value 0 to 7.
-// When you are using LCD as 5x8 dots in function set then you can define a total of 8 user defined patterns
-// (1 Byte for each row and 8 rows for each pattern).
-// Use http://www.8051projects.net/lcd-interfacing/lcd-custom-character.php to create your own
-// characters.
-// To use a custom character, write byte value of the custom character position as a string after
-// having setup the custom character.
-func (h *JHD1313M1Driver) SetCustomChar(pos int, charMap [8]byte) error {
-	if pos > 7 {
-		return fmt.Errorf("can't set a custom character at a position greater than 7")
-	}
-	location := uint8(pos)
-	if err := h.command([]byte{LCD_SETCGRAMADDR | (location << 3)}); err != nil {
-		return err
-	}
+// jhd1313m1Bus writes the instructions and data of the controller, each
+// prefixed by its control byte
+type jhd1313m1Bus struct {
+	h *JHD1313M1Driver
+}
+
+func (b *jhd1313m1Bus) WriteCommand(c byte) error {
+	return b.h.write(b.h.lcd, []byte{LCD_CMD, c})
+}
 
-	return h.connection.I2cWrite(h.lcdAddress, append([]byte{LCD_DATA}, charMap[:]...))
+func (b *jhd1313m1Bus) WriteData(d byte) error {
+	return b.h.write(b.h.lcd, []byte{LCD_DATA, d})
 }
@@ -0,0 +1,45 @@
+package i2c
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestJHD1313M1Driver(t *testing.T) {
+	adaptor := newI2cTestAdaptor("adaptor")
+	d := NewJHD1313M1Driver(adaptor, "lcd")
+	gobottest.Assert(t, d.Name(), "lcd")
+	gobottest.Assert(t, d.Connection().Name(), "adaptor")
+	gobottest.Assert(t, d.Address(), 0x3E)
+
+	gobottest.Assert(t, len(d.Start()), 0)
+	gobottest.Assert(t, adaptor.written, [][]byte{
+		{LCD_CMD, 0x28}, {LCD_CMD, 0x0c}, {LCD_CMD, 0x01}, {LCD_CMD, 0x06},
+		{0x00, 0x00}, {0x01, 0x00}, {0x08, 0xAA},
+		{REG_RED, 0xff}, {REG_GREEN, 0xff}, {REG_BLUE, 0xff},
+	})
+
+	adaptor.written = nil
+	gobottest.Assert(t, d.Write("hi\nyo"), nil)
+	gobottest.Assert(t, adaptor.written, [][]byte{
+		{LCD_DATA, 'h'}, {LCD_DATA, 'i'}, {LCD_CMD, 0xc0}, {LCD_DATA, 'y'}, {LCD_DATA, 'o'},
+	})
+
+	adaptor.written = nil
+	gobottest.Assert(t, d.SetCustomRune('é', 0, CustomLCDChars["é"]), nil)
+	gobottest.Assert(t, d.SetPosition(16), nil)
+	gobottest.Assert(t, d.Write("é"), nil)
+	gobottest.Assert(t, adaptor.written[0], []byte{LCD_CMD, 0x40})
+	gobottest.Assert(t, adaptor.written[9:], [][]byte{{LCD_CMD, 0xc0}, {LCD_DATA, 0x00}})
+	gobottest.Assert(t, d.SetPosition(32), ErrInvalidPosition)
+
+	adaptor.written = nil
+	gobottest.Assert(t, d.SetRGB(1, 2, 3), nil)
+	gobottest.Assert(t, adaptor.written, [][]byte{{REG_RED, 1}, {REG_GREEN, 2}, {REG_BLUE, 3}})
+	gobottest.Assert(t, len(d.Halt()), 0)
+
+	adaptor.i2cWriteImpl = func() error { return errors.New("write error") }
+	gobottest.Assert(t, d.Clear(), errors.New("write error"))
+}
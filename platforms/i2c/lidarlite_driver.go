@@ -13,13 +13,18 @@ const lidarliteAddress = 0x62
 type LIDARLiteDriver struct {
 	name       string
 	connection I2c
+	device     I2cConnection
+	Config
 }
 
-// NewLIDARLiteDriver creates a new driver with specified name and i2c interface
-func NewLIDARLiteDriver(a I2c, name string) *LIDARLiteDriver {
+// NewLIDARLiteDriver creates a new driver with specified name and i2c interface.
+//
+// Optionally accepts the options WithBus and WithAddress
+func NewLIDARLiteDriver(a I2c, name string, options ...Option) *LIDARLiteDriver {
 	return &LIDARLiteDriver{
 		name:       name,
 		connection: a,
+		Config:     newConfig(lidarliteAddress, options...),
 	}
 }
 
@@ -28,46 +33,53 @@ func (h *LIDARLiteDriver) Connection() gobot.Connection { return h.connection.(g
 
 // Start initialized the LIDAR
 func (h *LIDARLiteDriver) Start() (errs []error) {
-	if err := h.connection.I2cStart(lidarliteAddress); err != nil {
+	device, err := h.connect(h.connection, h)
+	if err != nil {
 		return []error{err}
 	}
+	h.device = device
 	return
 }
 
 // Halt returns true if devices is halted successfully
-func (h *LIDARLiteDriver) Halt() (errs []error) { return }
+func (h *LIDARLiteDriver) Halt() (errs []error) {
+	releaseAddresses(h.connection, h)
+	return
+}
 
 // Distance returns the current distance in cm
 func (h *LIDARLiteDriver) Distance() (distance int, err error) {
-	if err = h.connection.I2cWrite(lidarliteAddress, []byte{0x00, 0x04}); err != nil {
+	if _, err = h.device.Write([]byte{0x00, 0x04}); err != nil {
 		return
 	}
 	<-time.After(20 * time.Millisecond)
 
-	if err = h.connection.I2cWrite(lidarliteAddress, []byte{0x0F}); err != nil {
+	if _, err = h.device.Write([]byte{0x0F}); err != nil {
 		return
 	}
 
-	upper, err := h.connection.I2cRead(lidarliteAddress, 1)
+	upper := make([]byte, 1)
+	n, err := h.device.Read(upper)
 	if err != nil {
 		return
 	}
 
-	if len(upper) != 1 {
+	if n != 1 {
 		err = ErrNotEnoughBytes
 		return
 	}
 
-	if err = h.connection.I2cWrite(lidarliteAddress, []byte{0x10}); err != nil {
+	if _, err = h.device.Write([]byte{0x10}); err != nil {
 		return
 	}
 
-	lower, err := h.connection.I2cRead(lidarliteAddress, 1)
+	lower := make([]byte, 1)
+	n, err = h.device.Read(lower)
 	if err != nil {
 		return
 	}
 
-	if len(lower) != 1 {
+	if n != 1 {
 		err = ErrNotEnoughBytes
 		return
 	}
@@ -15,7 +15,9 @@ func initTestLIDARLiteDriver() (driver *LIDARLiteDriver) {
 
 func initTestLIDARLiteDriverWithStubbedAdaptor() (*LIDARLiteDriver, *i2cTestAdaptor) {
 	adaptor := newI2cTestAdaptor("adaptor")
-	return NewLIDARLiteDriver(adaptor, "bot"), adaptor
+	driver := NewLIDARLiteDriver(adaptor, "bot")
+	driver.Start()
+	return driver, adaptor
 }
 
 // --------- TESTS
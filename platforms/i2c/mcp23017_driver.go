@@ -17,19 +17,23 @@ limitations under the License.
 package i2c
 
 import (
-	"bytes"
-	"encoding/binary"
 	"log"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/gpio"
 )
 
 var (
-	Debug = true // Set this to true to see debugging information
+	Debug = false // Set this to true to see debugging information
 	// Register this Driver
 	_ gobot.Driver = (*MCP23017Driver)(nil)
+	// The expander is a connection for gpio drivers
+	_ gpio.DigitalReader = (*MCP23017Driver)(nil)
+	_ gpio.DigitalWriter = (*MCP23017Driver)(nil)
 )
 
 // Port contains all the registers for the device.
@@ -73,33 +77,81 @@ type MCP23017Config struct {
 	Intpol uint8
 }
 
-// getBytes returns the mcp23017 configuration as a slice of bytes.
-func (conf *MCP23017Config) getBytes() []byte {
-	return []byte{conf.Bank, conf.Mirror, conf.Seqop, conf.Disslw, conf.Haen, conf.Odr, conf.Intpol}
+// iocon returns the mcp23017 configuration as the value of the IOCON register.
+func (conf *MCP23017Config) iocon() byte {
+	return conf.Bank<<7 | conf.Mirror<<6 | conf.Seqop<<5 | conf.Disslw<<4 |
+		conf.Haen<<3 | conf.Odr<<2 | conf.Intpol<<1
+}
+
+// MCP23017Interrupt is published with the Interrupt event when pins with an
+// interrupt on change enabled changed.
+type MCP23017Interrupt struct {
+	Port   string // A or B
+	Flags  uint8  // pins which caused the interrupt
+	Values uint8  // values of the pins of the port
 }
 
 // MCP23107Driver contains the driver configuration parameters.
 type MCP23017Driver struct {
-	name            string
-	connection      I2c
-	conf            MCP23017Config
-	mcp23017Address int
-	interval        time.Duration
+	name       string
+	connection I2c
+	device     I2cConnection
+	conf       MCP23017Config
+	interval   time.Duration
+	halt       chan bool
+	started    bool
+	mutex      sync.Mutex
+	iodir      [2]uint8 // cached IODIR registers of port A and B
+	olat       [2]uint8 // cached OLAT registers of port A and B
+	gpinten    [2]uint8 // cached GPINTEN registers of port A and B
+	gpio       [2]uint8 // values of the pins read by the last interrupt
+	intReader  gpio.DigitalReader
+	intPins    [2]string
+	Config
 	gobot.Commander
 	gobot.Eventer
 }
 
 // NewMCP23017Driver creates a new driver with specified name and i2c interface.
-func NewMCP23017Driver(a I2c, name string, conf MCP23017Config, deviceAddress int, v ...time.Duration) *MCP23017Driver {
+// The pins of the expander are named A0 to A7 and B0 to B7, so that it can be
+// the connection of gpio drivers.
+//
+// Optionally accepts:
+//
+//	time.Duration: interval at which the driver polls the interrupts of the
+//	expander, defaults to 10ms
+//	Option: WithBus or WithAddress, which overrides deviceAddress
+//
+// Adds the following API Commands:
+//
+//	"WriteGPIO" - See MCP23017Driver.WriteGPIO
+//	"ReadGPIO" - See MCP23017Driver.ReadGPIO
+//	"WritePort" - See MCP23017Driver.WritePort
+//	"ReadPort" - See MCP23017Driver.ReadPort
+func NewMCP23017Driver(a I2c, name string, conf MCP23017Config, deviceAddress int, v ...interface{}) *MCP23017Driver {
 	m := &MCP23017Driver{
-		name:            name,
-		connection:      a,
-		conf:            conf,
-		mcp23017Address: deviceAddress,
-		Commander:       gobot.NewCommander(),
-		Eventer:         gobot.NewEventer(),
+		name:       name,
+		connection: a,
+		conf:       conf,
+		interval:   10 * time.Millisecond,
+		halt:       make(chan bool),
+		Config:     newConfig(deviceAddress),
+		Commander:  gobot.NewCommander(),
+		Eventer:    gobot.NewEventer(),
+	}
+
+	for _, arg := range v {
+		switch arg := arg.(type) {
+		case time.Duration:
+			m.interval = arg
+		case Option:
+			arg(&m.Config)
+		}
 	}
 
+	m.AddEvent(Interrupt)
+	m.AddEvent(Error)
+
 	m.AddCommand("WriteGPIO", func(params map[string]interface{}) interface{} {
 		pin := params["pin"].(float64)
 		val := params["val"].(float64)
@@ -114,6 +166,18 @@ func NewMCP23017Driver(a I2c, name string, conf MCP23017Config, deviceAddress in
 		return map[string]interface{}{"val": val, "err": err}
 	})
 
+	m.AddCommand("WritePort", func(params map[string]interface{}) interface{} {
+		port := params["port"].(string)
+		val := params["val"].(float64)
+		return m.WritePort(port, uint8(val))
+	})
+
+	m.AddCommand("ReadPort", func(params map[string]interface{}) interface{} {
+		port := params["port"].(string)
+		val, err := m.ReadPort(port)
+		return map[string]interface{}{"val": val, "err": err}
+	})
+
 	return m
 }
 
@@ -121,40 +185,134 @@ func (m *MCP23017Driver) Name() string { return m.name }
 
 func (m *MCP23017Driver) Connection() gobot.Connection { return m.connection.(gobot.Connection) }
 
-func (m *MCP23017Driver) Halt() (err []error) { return }
+// Connect initializes the expander when it is the connection of gpio
+// drivers.
+func (m *MCP23017Driver) Connect() (errs []error) {
+	if err := m.initialize(); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// Finalize stops polling the interrupts of the expander.
+func (m *MCP23017Driver) Finalize() (errs []error) { return m.Halt() }
+
+// Halt stops polling the interrupts of the expander.
+func (m *MCP23017Driver) Halt() (errs []error) {
+	if m.started {
+		m.halt <- true
+		m.started = false
+	}
+	releaseAddresses(m.connection, m)
+	return
+}
 
-// Start writes initialization bytes and reads.
+// Start writes initialization bytes, and polls the interrupts of the
+// expander every interval.
+// Emits the Events:
+//
+//	Interrupt MCP23017Interrupt - pins with an interrupt on change enabled
+//	changed
+//	Error error - error reading the interrupts
 func (m *MCP23017Driver) Start() (errs []error) {
-	if err := m.connection.I2cStart(m.mcp23017Address); err != nil {
+	if err := m.initialize(); err != nil {
 		return []error{err}
 	}
-	// Set IOCON register with the given configuration.
-	selectedPort := m.getPort("A") // IOCON address is the same for Port A or B.
-	var ioval uint8
-	buf := bytes.NewReader(m.conf.getBytes())
-	err := binary.Read(buf, binary.LittleEndian, &ioval)
+	if m.started {
+		return
+	}
+
+	m.started = true
+	go func() {
+		for {
+			if err := m.pollInterrupts(); err != nil {
+				gobot.Publish(m.Event(Error), err)
+			}
+			select {
+			case <-time.After(m.interval):
+			case <-m.halt:
+				return
+			}
+		}
+	}()
+	return
+}
+
+// initialize connects to the expander, writes its configuration and resets
+// the cached registers and the expander to their power on values, so that
+// they agree when the expander was not power cycled since it was last used.
+func (m *MCP23017Driver) initialize() (err error) {
+	device, err := m.connect(m.connection, m)
 	if err != nil {
-		return []error{err}
+		return
 	}
-	if err := m.connection.I2cWrite(m.mcp23017Address, []byte{selectedPort.IOCON, ioval}); err != nil {
-		return []error{err}
+	m.device = device
+
+	// IOCON is written at its address in bank 0, the bank of the expander
+	// at power on.
+	if _, err = m.device.Write([]byte{getBank(0).PortA.IOCON, m.conf.iocon()}); err != nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.iodir = [2]uint8{0xff, 0xff}
+	m.olat = [2]uint8{}
+	m.gpinten = [2]uint8{}
+	for p := range m.iodir {
+		port := m.port(p)
+		for _, reg := range [][]byte{
+			{port.GPINTEN, m.gpinten[p]},
+			{port.OLAT, m.olat[p]},
+			{port.IODIR, m.iodir[p]},
+		} {
+			if _, err = m.device.Write(reg); err != nil {
+				return
+			}
+		}
 	}
 	return
 }
 
+// DigitalWrite writes a value to a pin (A0-A7 or B0-B7), which is made an
+// output.
+func (m *MCP23017Driver) DigitalWrite(pin string, val byte) (err error) {
+	p, bit, err := m.parsePin(pin)
+	if err != nil {
+		return
+	}
+	return m.writePin(p, bit, val)
+}
+
+// DigitalRead reads the value of a pin (A0-A7 or B0-B7), which is made an
+// input. Pins with an interrupt on change enabled return the value read by
+// the last interrupt when the interrupts are polled.
+func (m *MCP23017Driver) DigitalRead(pin string) (val int, err error) {
+	p, bit, err := m.parsePin(pin)
+	if err != nil {
+		return
+	}
+	if err = m.setDirection(p, 1<<bit, 1<<bit); err != nil {
+		return
+	}
+
+	m.mutex.Lock()
+	cached := m.started && m.gpinten[p]&(1<<bit) != 0
+	gpio := m.gpio[p]
+	m.mutex.Unlock()
+
+	if !cached {
+		if gpio, err = m.read(m.port(p).GPIO); err != nil {
+			return
+		}
+	}
+	return int(gpio>>bit) & 0x01, nil
+}
+
 // WriteGPIO writes a value to a gpio pin (0-7) and a
 // port (A or B).
 func (m *MCP23017Driver) WriteGPIO(pin float64, val float64, portStr string) (err error) {
-	selectedPort := m.getPort(portStr)
-	// Set IODIR register bit for given pin to an output.
-	if err := m.write(selectedPort.IODIR, uint8(pin), 0); err != nil {
-		return err
-	}
-	// Set OLAT register to write a value to the given pin.
-	if err := m.write(selectedPort.OLAT, uint8(pin), uint8(val)); err != nil {
-		return err
-	}
-	return nil
+	return m.writePin(portIndex(portStr), uint8(pin), uint8(val))
 }
 
 // ReadGPIO reads a value from a given gpio pin (0-7) and a
@@ -168,6 +326,164 @@ func (m *MCP23017Driver) ReadGPIO(pin float64, portStr string) (val bool, err er
 	return ((1 << uint8(pin) & gpio) != 0), nil
 }
 
+// WritePort writes the values of the 8 pins of a port (A or B), which are
+// made outputs.
+func (m *MCP23017Driver) WritePort(portStr string, val uint8) (err error) {
+	p := portIndex(portStr)
+	if err = m.setDirection(p, 0xff, 0x00); err != nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, err = m.device.Write([]byte{m.port(p).OLAT, val}); err != nil {
+		return
+	}
+	m.olat[p] = val
+	return
+}
+
+// ReadPort reads the values of the 8 pins of a port (A or B).
+func (m *MCP23017Driver) ReadPort(portStr string) (val uint8, err error) {
+	return m.read(m.getPort(portStr).GPIO)
+}
+
+// SetPortDirection sets the direction of the 8 pins of a port (A or B):
+// bit set to 1 = input / bit set to 0 = output.
+func (m *MCP23017Driver) SetPortDirection(portStr string, iodir uint8) (err error) {
+	return m.setDirection(portIndex(portStr), 0xff, iodir)
+}
+
+// EnableInterrupt enables the interrupt on change of a pin (A0-A7 or B0-B7),
+// which is made an input. The interrupts are polled from the INTA and INTB
+// pins when they are watched, and from the registers of the expander
+// otherwise.
+func (m *MCP23017Driver) EnableInterrupt(pin string) (err error) {
+	p, bit, err := m.parsePin(pin)
+	if err != nil {
+		return
+	}
+	if err = m.setDirection(p, 1<<bit, 1<<bit); err != nil {
+		return
+	}
+	// compare the pin to its previous value
+	if err = m.write(m.port(p).INTCON, bit, 0); err != nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.gpio[p], err = m.read(m.port(p).GPIO); err != nil {
+		return
+	}
+	return m.writeInterrupts(p, setBit(m.gpinten[p], bit))
+}
+
+// DisableInterrupt disables the interrupt on change of a pin (A0-A7 or
+// B0-B7).
+func (m *MCP23017Driver) DisableInterrupt(pin string) (err error) {
+	p, bit, err := m.parsePin(pin)
+	if err != nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.writeInterrupts(p, clearBit(m.gpinten[p], bit))
+}
+
+// WatchInterrupts polls the interrupts from the pins intA and intB of the
+// adaptor a, which are wired to the INTA and INTB pins of the expander. intB
+// may be empty when the interrupt pins are mirrored, or port B has no
+// interrupt enabled.
+func (m *MCP23017Driver) WatchInterrupts(a gpio.DigitalReader, intA string, intB string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.intReader = a
+	m.intPins = [2]string{intA, intB}
+}
+
+// writeInterrupts writes the GPINTEN register of a port, with the mutex held.
+func (m *MCP23017Driver) writeInterrupts(p int, gpinten uint8) (err error) {
+	if _, err = m.device.Write([]byte{m.port(p).GPINTEN, gpinten}); err != nil {
+		return
+	}
+	m.gpinten[p] = gpinten
+	return
+}
+
+// pollInterrupts reads the interrupts of the ports with interrupts enabled,
+// and publishes the pins which changed.
+func (m *MCP23017Driver) pollInterrupts() (err error) {
+	m.mutex.Lock()
+	gpinten := m.gpinten
+	reader := m.intReader
+	pins := m.intPins
+	m.mutex.Unlock()
+
+	for p := range gpinten {
+		if gpinten[p] == 0 {
+			continue
+		}
+		if reader != nil {
+			active, err := m.interruptActive(reader, pins, p)
+			if err != nil {
+				return err
+			}
+			if !active {
+				continue
+			}
+		}
+		if err = m.readInterrupt(p); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// interruptActive returns whether the interrupt pin of port p is active.
+func (m *MCP23017Driver) interruptActive(reader gpio.DigitalReader, pins [2]string, p int) (bool, error) {
+	pin := pins[p]
+	if pin == "" {
+		if m.conf.Mirror == 0 {
+			return false, nil
+		}
+		pin = pins[0]
+	}
+	val, err := reader.DigitalRead(pin)
+	if err != nil {
+		return false, err
+	}
+	if m.conf.Intpol == 1 && m.conf.Odr == 0 {
+		return val == 1, nil
+	}
+	return val == 0, nil
+}
+
+// readInterrupt reads the interrupt flags and the values of port p, which
+// clears its interrupt.
+func (m *MCP23017Driver) readInterrupt(p int) (err error) {
+	flags, err := m.read(m.port(p).INTF)
+	if err != nil || flags == 0 {
+		return
+	}
+	values, err := m.read(m.port(p).GPIO)
+	if err != nil {
+		return
+	}
+
+	m.mutex.Lock()
+	m.gpio[p] = values
+	m.mutex.Unlock()
+
+	gobot.Publish(m.Event(Interrupt), MCP23017Interrupt{
+		Port:   string("AB"[p]),
+		Flags:  flags,
+		Values: values,
+	})
+	return
+}
+
 // SetPullUp sets the pull up state of a given pin based on the value:
 // val = 1 pull up enabled.
 // val = 0 pull up disabled.
@@ -190,6 +506,42 @@ func (m *MCP23017Driver) SetGPIOPolarity(pin uint8, val byte, portStr string) (e
 	return nil
 }
 
+// writePin makes a pin of port p an output and writes val to it, from the
+// cached IODIR and OLAT registers.
+func (m *MCP23017Driver) writePin(p int, pin uint8, val uint8) (err error) {
+	if err = m.setDirection(p, 1<<pin, 0); err != nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	olat := clearBit(m.olat[p], pin)
+	if val != 0 {
+		olat = setBit(olat, pin)
+	}
+	if _, err = m.device.Write([]byte{m.port(p).OLAT, olat}); err != nil {
+		return
+	}
+	m.olat[p] = olat
+	return
+}
+
+// setDirection sets the bits of mask of the IODIR register of port p to
+// iodir. The register is only written when its cached value differs.
+func (m *MCP23017Driver) setDirection(p int, mask uint8, iodir uint8) (err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	val := m.iodir[p]&^mask | iodir&mask
+	if val == m.iodir[p] {
+		return
+	}
+	if _, err = m.device.Write([]byte{m.port(p).IODIR, val}); err != nil {
+		return
+	}
+	m.iodir[p] = val
+	return
+}
+
 // write gets the value of the passed in register, and then overwrites
 // the bit specified by the pin, with the given value.
 func (m *MCP23017Driver) write(reg byte, pin uint8, val byte) (err error) {
@@ -203,7 +555,7 @@ func (m *MCP23017Driver) write(reg byte, pin uint8, val byte) (err error) {
 	} else if val == 1 {
 		ioval = setBit(iodir, uint8(pin))
 	}
-	if err = m.connection.I2cWrite(m.mcp23017Address, []byte{reg, ioval}); err != nil {
+	if _, err = m.device.Write([]byte{reg, ioval}); err != nil {
 		return err
 	}
 	return nil
@@ -211,29 +563,49 @@ func (m *MCP23017Driver) write(reg byte, pin uint8, val byte) (err error) {
 
 // Read returns the values in the given register.
 func (m *MCP23017Driver) read(reg byte) (val uint8, err error) {
-	bytesToRead := int(reg)
-	v, err := m.connection.I2cRead(m.mcp23017Address, bytesToRead+1)
-	if err != nil {
+	buf := make([]byte, 1)
+	if _, err = m.device.ReadRegister(reg, buf); err != nil {
 		return val, err
 	}
 	if Debug {
-		log.Printf("Register addr:0x%X val: 0x%X\n", reg, v[bytesToRead])
+		log.Printf("Register addr:0x%X val: 0x%X\n", reg, buf[0])
+	}
+	return buf[0], nil
+}
+
+// parsePin returns the port index and the bit of a pin named A0-A7 or B0-B7.
+func (m *MCP23017Driver) parsePin(pin string) (p int, bit uint8, err error) {
+	pin = strings.ToUpper(pin)
+	if len(pin) != 2 || (pin[0] != 'A' && pin[0] != 'B') {
+		return 0, 0, ErrInvalidPin
+	}
+	n, err := strconv.Atoi(pin[1:])
+	if err != nil || n > 7 {
+		return 0, 0, ErrInvalidPin
 	}
-	return v[bytesToRead], nil
+	return int(pin[0] - 'A'), uint8(n), nil
+}
+
+// port returns the registers of port index p (0 for A, 1 for B).
+func (m *MCP23017Driver) port(p int) port {
+	if p == 1 {
+		return getBank(m.conf.Bank).PortB
+	}
+	return getBank(m.conf.Bank).PortA
 }
 
 // getPort return the port (A or B) given a string and the bank.
 // Port A is the default if an incorrect or no port is specified.
 func (m *MCP23017Driver) getPort(portStr string) (selectedPort port) {
-	portStr = strings.ToUpper(portStr)
-	switch {
-	case portStr == "A":
-		return getBank(m.conf.Bank).PortA
-	case portStr == "B":
-		return getBank(m.conf.Bank).PortB
-	default:
-		return getBank(m.conf.Bank).PortA
+	return m.port(portIndex(portStr))
+}
+
+// portIndex returns the index of a port (A or B), A is the default.
+func portIndex(portStr string) int {
+	if strings.ToUpper(portStr) == "B" {
+		return 1
 	}
+	return 0
 }
 
 // setBit is used to set a bit at a given position to 1.
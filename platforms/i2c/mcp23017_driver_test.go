@@ -5,9 +5,13 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/platforms/gpio"
 )
 
 func initTestMCP23017Driver(b uint8) (driver *MCP23017Driver) {
@@ -17,7 +21,9 @@ func initTestMCP23017Driver(b uint8) (driver *MCP23017Driver) {
 
 func initTestMCP23017DriverWithStubbedAdaptor(b uint8) (*MCP23017Driver, *i2cTestAdaptor) {
 	adaptor := newI2cTestAdaptor("adaptor")
-	return NewMCP23017Driver(adaptor, "bot", MCP23017Config{Bank: b}, 0x20), adaptor
+	driver := NewMCP23017Driver(adaptor, "bot", MCP23017Config{Bank: b}, 0x20)
+	driver.Start()
+	return driver, adaptor
 }
 
 func TestNewMCP23017Driver(t *testing.T) {
@@ -59,7 +65,7 @@ func TestMCP23017DriverHalt(t *testing.T) {
 func TestMCP23017DriverWriteGPIO(t *testing.T) {
 	mcp, adaptor := initTestMCP23017DriverWithStubbedAdaptor(0)
 	adaptor.i2cReadImpl = func() ([]byte, error) {
-		return []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 128}, nil
+		return []byte{128}, nil
 	}
 	adaptor.i2cWriteImpl = func() error {
 		return nil
@@ -70,7 +76,7 @@ func TestMCP23017DriverWriteGPIO(t *testing.T) {
 	// write error
 	mcp, adaptor = initTestMCP23017DriverWithStubbedAdaptor(0)
 	adaptor.i2cReadImpl = func() ([]byte, error) {
-		return []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 128}, nil
+		return []byte{128}, nil
 	}
 	adaptor.i2cWriteImpl = func() error {
 		return errors.New("write error")
@@ -82,7 +88,7 @@ func TestMCP23017DriverWriteGPIO(t *testing.T) {
 func TestMCP23017DriverReadGPIO(t *testing.T) {
 	mcp, adaptor := initTestMCP23017DriverWithStubbedAdaptor(0)
 	adaptor.i2cReadImpl = func() ([]byte, error) {
-		return []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 128}, nil
+		return []byte{128}, nil
 	}
 	val, _ := mcp.ReadGPIO(7, "A")
 	gobottest.Assert(t, val, true)
@@ -99,7 +105,7 @@ func TestMCP23017DriverReadGPIO(t *testing.T) {
 func TestMCP23017DriverSetPullUp(t *testing.T) {
 	mcp, adaptor := initTestMCP23017DriverWithStubbedAdaptor(0)
 	adaptor.i2cReadImpl = func() ([]byte, error) {
-		return []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 128}, nil
+		return []byte{128}, nil
 	}
 	adaptor.i2cWriteImpl = func() error {
 		return nil
@@ -110,7 +116,7 @@ func TestMCP23017DriverSetPullUp(t *testing.T) {
 	// write error
 	mcp, adaptor = initTestMCP23017DriverWithStubbedAdaptor(0)
 	adaptor.i2cReadImpl = func() ([]byte, error) {
-		return []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 128}, nil
+		return []byte{128}, nil
 	}
 	adaptor.i2cWriteImpl = func() error {
 		return errors.New("write error")
@@ -122,7 +128,7 @@ func TestMCP23017DriverSetPullUp(t *testing.T) {
 func TestMCP23017DriverSetGPIOPolarity(t *testing.T) {
 	mcp, adaptor := initTestMCP23017DriverWithStubbedAdaptor(0)
 	adaptor.i2cReadImpl = func() ([]byte, error) {
-		return []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 128}, nil
+		return []byte{128}, nil
 	}
 	adaptor.i2cWriteImpl = func() error {
 		return nil
@@ -133,7 +139,7 @@ func TestMCP23017DriverSetGPIOPolarity(t *testing.T) {
 	// write error
 	mcp, adaptor = initTestMCP23017DriverWithStubbedAdaptor(0)
 	adaptor.i2cReadImpl = func() ([]byte, error) {
-		return []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 128}, nil
+		return []byte{128}, nil
 	}
 	adaptor.i2cWriteImpl = func() error {
 		return errors.New("write error")
@@ -148,7 +154,7 @@ func TestMCP23017DriverWrite(t *testing.T) {
 	mcp, adaptor := initTestMCP23017DriverWithStubbedAdaptor(0)
 	port := mcp.getPort("A")
 	adaptor.i2cReadImpl = func() ([]byte, error) {
-		return []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 128}, nil
+		return []byte{128}, nil
 	}
 	adaptor.i2cWriteImpl = func() error {
 		return nil
@@ -160,7 +166,7 @@ func TestMCP23017DriverWrite(t *testing.T) {
 	mcp, adaptor = initTestMCP23017DriverWithStubbedAdaptor(0)
 	port = mcp.getPort("B")
 	adaptor.i2cReadImpl = func() ([]byte, error) {
-		return []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 128}, nil
+		return []byte{128}, nil
 	}
 	adaptor.i2cWriteImpl = func() error {
 		return nil
@@ -171,7 +177,7 @@ func TestMCP23017DriverWrite(t *testing.T) {
 	// write error
 	mcp, adaptor = initTestMCP23017DriverWithStubbedAdaptor(0)
 	adaptor.i2cReadImpl = func() ([]byte, error) {
-		return []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 128}, nil
+		return []byte{128}, nil
 	}
 	adaptor.i2cWriteImpl = func() error {
 		return errors.New("write error")
@@ -194,7 +200,7 @@ func TestMCP23017DriverReadPort(t *testing.T) {
 	port := mcp.getPort("A")
 
 	adaptor.i2cReadImpl = func() ([]byte, error) {
-		return []byte{255, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, nil
+		return []byte{255}, nil
 	}
 	val, _ := mcp.read(port.IODIR)
 	gobottest.Assert(t, val, uint8(255))
@@ -216,7 +222,7 @@ func TestMCP23017DriverReadPort(t *testing.T) {
 	port = mcp.getPort("A")
 
 	adaptor.i2cReadImpl = func() ([]byte, error) {
-		return []byte{255, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, nil
+		return []byte{255}, nil
 	}
 
 	val, _ = mcp.read(port.IODIR)
@@ -262,3 +268,247 @@ func TestClearBit(t *testing.T) {
 	actualVal := clearBit(128, 7)
 	gobottest.Assert(t, expectedVal, actualVal)
 }
+
+// mcp23017Registers returns the registers of bank 0 with the given GPIO and
+// INTF registers
+func mcp23017Registers(gpioA, gpioB, intfA uint8) []byte {
+	registers := make([]byte, 0x16)
+	registers[0x0E] = intfA
+	registers[0x12] = gpioA
+	registers[0x13] = gpioB
+	return registers
+}
+
+// selectedRegister returns the value of the register selected by the last
+// write to adaptor
+func selectedRegister(adaptor *i2cTestAdaptor, registers []byte) []byte {
+	reg := adaptor.written[len(adaptor.written)-1][0]
+	return []byte{registers[reg]}
+}
+
+// gpioTestAdaptor is the adaptor wired to the interrupt pins of the expander
+type gpioTestAdaptor struct {
+	mutex  sync.Mutex
+	values map[string]int
+}
+
+func (g *gpioTestAdaptor) Name() string             { return "gpio" }
+func (g *gpioTestAdaptor) Connect() (errs []error)  { return }
+func (g *gpioTestAdaptor) Finalize() (errs []error) { return }
+func (g *gpioTestAdaptor) DigitalRead(pin string) (int, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.values[pin], nil
+}
+func (g *gpioTestAdaptor) set(pin string, val int) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.values[pin] = val
+}
+
+func TestMCP23017DriverConfiguration(t *testing.T) {
+	adaptor := newI2cTestAdaptor("adaptor")
+	mcp := NewMCP23017Driver(adaptor, "bot", MCP23017Config{Mirror: 1, Intpol: 1}, 0x20)
+	gobottest.Assert(t, len(mcp.Connect()), 0)
+	gobottest.Assert(t, adaptor.written, [][]byte{
+		{0x0A, 0x42},
+		{0x04, 0x00}, {0x14, 0x00}, {0x00, 0xff},
+		{0x05, 0x00}, {0x15, 0x00}, {0x01, 0xff},
+	})
+
+	// IOCON is at its bank 0 address before switching to bank 1
+	adaptor = newI2cTestAdaptor("adaptor")
+	mcp = NewMCP23017Driver(adaptor, "bot", MCP23017Config{Bank: 1}, 0x20)
+	gobottest.Assert(t, len(mcp.Connect()), 0)
+	gobottest.Assert(t, adaptor.written, [][]byte{
+		{0x0A, 0x80},
+		{0x02, 0x00}, {0x0A, 0x00}, {0x00, 0xff},
+		{0x12, 0x00}, {0x1A, 0x00}, {0x10, 0xff},
+	})
+	gobottest.Assert(t, len(mcp.Finalize()), 0)
+}
+
+func TestMCP23017DriverDigitalWrite(t *testing.T) {
+	mcp, adaptor := initTestMCP23017DriverWithStubbedAdaptor(0)
+	adaptor.written = nil
+
+	gobottest.Assert(t, mcp.DigitalWrite("A3", 1), nil)
+	gobottest.Assert(t, mcp.DigitalWrite("a3", 0), nil)
+	gobottest.Assert(t, mcp.DigitalWrite("B1", 1), nil)
+	gobottest.Assert(t, adaptor.written, [][]byte{
+		{0x00, 0xf7}, {0x14, 0x08},
+		{0x14, 0x00},
+		{0x01, 0xfd}, {0x15, 0x02},
+	})
+
+	gobottest.Assert(t, mcp.DigitalWrite("C1", 1), ErrInvalidPin)
+	gobottest.Assert(t, mcp.DigitalWrite("A8", 1), ErrInvalidPin)
+	gobottest.Assert(t, mcp.DigitalWrite("A", 1), ErrInvalidPin)
+
+	// write error
+	adaptor.i2cWriteImpl = func() error {
+		return errors.New("write error")
+	}
+	gobottest.Assert(t, mcp.DigitalWrite("A4", 1), errors.New("write error"))
+}
+
+func TestMCP23017DriverDigitalRead(t *testing.T) {
+	mcp, adaptor := initTestMCP23017DriverWithStubbedAdaptor(0)
+	registers := mcp23017Registers(0x08, 0x00, 0x00)
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return selectedRegister(adaptor, registers), nil
+	}
+	gobottest.Assert(t, mcp.DigitalWrite("A3", 1), nil)
+	adaptor.written = nil
+
+	val, err := mcp.DigitalRead("A3")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, 1)
+	val, err = mcp.DigitalRead("A2")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, 0)
+	// A3 is an input again, and GPIOA is read
+	gobottest.Assert(t, adaptor.written, [][]byte{{0x00, 0xff}, {0x12}, {0x12}})
+
+	_, err = mcp.DigitalRead("B9")
+	gobottest.Assert(t, err, ErrInvalidPin)
+
+	// read error
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return nil, errors.New("read error")
+	}
+	_, err = mcp.DigitalRead("A3")
+	gobottest.Assert(t, err, errors.New("read error"))
+}
+
+func TestMCP23017DriverPort(t *testing.T) {
+	mcp, adaptor := initTestMCP23017DriverWithStubbedAdaptor(0)
+	registers := mcp23017Registers(0x00, 0x5a, 0x00)
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return selectedRegister(adaptor, registers), nil
+	}
+	adaptor.written = nil
+
+	gobottest.Assert(t, mcp.WritePort("B", 0xa5), nil)
+	gobottest.Assert(t, mcp.SetPortDirection("A", 0x0f), nil)
+	gobottest.Assert(t, mcp.SetPortDirection("A", 0x0f), nil)
+	gobottest.Assert(t, adaptor.written, [][]byte{{0x01, 0x00}, {0x15, 0xa5}, {0x00, 0x0f}})
+
+	adaptor.written = nil
+	val, err := mcp.ReadPort("B")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, uint8(0x5a))
+	gobottest.Assert(t, adaptor.written, [][]byte{{0x13}})
+
+	result := mcp.Command("ReadPort")(map[string]interface{}{"port": "B"})
+	gobottest.Assert(t, result.(map[string]interface{})["val"], uint8(0x5a))
+	gobottest.Assert(t, mcp.Command("WritePort")(map[string]interface{}{"port": "A", "val": 3.0}), nil)
+}
+
+func TestMCP23017DriverInterrupt(t *testing.T) {
+	var mutex sync.Mutex
+	registers := mcp23017Registers(0x00, 0x00, 0x00)
+
+	adaptor := newI2cTestAdaptor("adaptor")
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return selectedRegister(adaptor, registers), nil
+	}
+	host := &gpioTestAdaptor{values: map[string]int{"7": 1}}
+
+	mcp := NewMCP23017Driver(adaptor, "bot", MCP23017Config{}, 0x20, 1*time.Millisecond)
+	mcp.WatchInterrupts(host, "7", "")
+	button := gpio.NewButtonDriver(mcp, "button", "A3", 1*time.Millisecond)
+
+	gobottest.Assert(t, len(mcp.Start()), 0)
+	adaptor.written = nil
+	gobottest.Assert(t, mcp.EnableInterrupt("A3"), nil)
+	// INTCON and GPIOA are read before GPINTEN is written
+	gobottest.Assert(t, adaptor.written, [][]byte{{0x08}, {0x08, 0x00}, {0x12}, {0x04, 0x08}})
+
+	interrupts := make(chan MCP23017Interrupt, 1)
+	gobot.Once(mcp.Event(Interrupt), func(data interface{}) {
+		interrupts <- data.(MCP23017Interrupt)
+	})
+	pushed := make(chan bool, 1)
+	gobot.Once(button.Event(gpio.Push), func(data interface{}) {
+		pushed <- true
+	})
+	gobottest.Assert(t, len(button.Start()), 0)
+
+	// the flags are only read once INTA is active
+	mutex.Lock()
+	registers[0x0E] = 0x08
+	registers[0x12] = 0x08
+	mutex.Unlock()
+	<-time.After(10 * time.Millisecond)
+	val, _ := mcp.DigitalRead("A3")
+	gobottest.Assert(t, val, 0)
+
+	host.set("7", 0)
+	select {
+	case i := <-interrupts:
+		gobottest.Assert(t, i, MCP23017Interrupt{Port: "A", Flags: 0x08, Values: 0x08})
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("MCP23017 interrupt event not published")
+	}
+	select {
+	case <-pushed:
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Button push event not published")
+	}
+
+	gobottest.Assert(t, len(button.Halt()), 0)
+	gobottest.Assert(t, len(mcp.Halt()), 0)
+	gobottest.Assert(t, mcp.DisableInterrupt("A3"), nil)
+}
+
+func TestMCP23017DriverPolledInterrupt(t *testing.T) {
+	adaptor := newI2cTestAdaptor("adaptor")
+	registers := mcp23017Registers(0x00, 0x80, 0x00)
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return selectedRegister(adaptor, registers), nil
+	}
+	mcp := NewMCP23017Driver(adaptor, "bot", MCP23017Config{}, 0x20, 1*time.Hour)
+	gobottest.Assert(t, mcp.initialize(), nil)
+	gobottest.Assert(t, mcp.EnableInterrupt("B7"), nil)
+
+	// no flag raised
+	gobottest.Assert(t, mcp.pollInterrupts(), nil)
+
+	interrupts := make(chan MCP23017Interrupt, 1)
+	gobot.Once(mcp.Event(Interrupt), func(data interface{}) {
+		interrupts <- data.(MCP23017Interrupt)
+	})
+	// INTFB is at 0x0F
+	registers[0x0F] = 0x80
+	registers[0x13] = 0x00
+	adaptor.written = nil
+	gobottest.Assert(t, mcp.pollInterrupts(), nil)
+	gobottest.Assert(t, adaptor.written, [][]byte{{0x0F}, {0x13}})
+	select {
+	case i := <-interrupts:
+		gobottest.Assert(t, i, MCP23017Interrupt{Port: "B", Flags: 0x80, Values: 0x00})
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("MCP23017 interrupt event not published")
+	}
+}
+
+func TestMCP23017DriverRestart(t *testing.T) {
+	mcp, adaptor := initTestMCP23017DriverWithStubbedAdaptor(0)
+	gobottest.Assert(t, mcp.DigitalWrite("A3", 1), nil)
+	gobottest.Assert(t, len(mcp.Halt()), 0)
+
+	// the expander is reset along with the cache, so that the pin is made
+	// an output again
+	adaptor.written = nil
+	gobottest.Assert(t, len(mcp.Start()), 0)
+	gobottest.Assert(t, mcp.DigitalWrite("A3", 1), nil)
+	gobottest.Assert(t, adaptor.written[1:], [][]byte{
+		{0x04, 0x00}, {0x14, 0x00}, {0x00, 0xff},
+		{0x05, 0x00}, {0x15, 0x00}, {0x01, 0xff},
+		{0x00, 0xf7}, {0x14, 0x08},
+	})
+	gobottest.Assert(t, len(mcp.Halt()), 0)
+}
@@ -33,13 +33,18 @@ const (
 type MMA7660Driver struct {
 	name       string
 	connection I2c
+	device     I2cConnection
+	Config
 }
 
-// NewMMA7660Driver creates a new driver with specified name and i2c interface
-func NewMMA7660Driver(a I2c, name string) *MMA7660Driver {
+// NewMMA7660Driver creates a new driver with specified name and i2c interface.
+//
+// Optionally accepts the options WithBus and WithAddress
+func NewMMA7660Driver(a I2c, name string, options ...Option) *MMA7660Driver {
 	return &MMA7660Driver{
 		name:       name,
 		connection: a,
+		Config:     newConfig(mma7660Address, options...),
 	}
 }
 
@@ -48,19 +53,21 @@ func (h *MMA7660Driver) Connection() gobot.Connection { return h.connection.(gob
 
 // Start initialized the mma7660
 func (h *MMA7660Driver) Start() (errs []error) {
-	if err := h.connection.I2cStart(mma7660Address); err != nil {
+	device, err := h.connect(h.connection, h)
+	if err != nil {
 		return []error{err}
 	}
+	h.device = device
 
-	if err := h.connection.I2cWrite(mma7660Address, []byte{MMA7660_MODE, MMA7660_STAND_BY}); err != nil {
+	if _, err := h.device.Write([]byte{MMA7660_MODE, MMA7660_STAND_BY}); err != nil {
 		return []error{err}
 	}
 
-	if err := h.connection.I2cWrite(mma7660Address, []byte{MMA7660_SR, MMA7660_AUTO_SLEEP_32}); err != nil {
+	if _, err := h.device.Write([]byte{MMA7660_SR, MMA7660_AUTO_SLEEP_32}); err != nil {
 		return []error{err}
 	}
 
-	if err := h.connection.I2cWrite(mma7660Address, []byte{MMA7660_MODE, MMA7660_ACTIVE}); err != nil {
+	if _, err := h.device.Write([]byte{MMA7660_MODE, MMA7660_ACTIVE}); err != nil {
 		return []error{err}
 	}
 
@@ -68,7 +75,10 @@ func (h *MMA7660Driver) Start() (errs []error) {
 }
 
 // Halt returns true if devices is halted successfully
-func (h *MMA7660Driver) Halt() (errs []error) { return }
+func (h *MMA7660Driver) Halt() (errs []error) {
+	releaseAddresses(h.connection, h)
+	return
+}
 
 // Acceleration returns the acceleration  of the provided x, y, z
 func (h *MMA7660Driver) Acceleration(x, y, z float64) (ax, ay, az float64) {
@@ -77,12 +87,13 @@ func (h *MMA7660Driver) Acceleration(x, y, z float64) (ax, ay, az float64) {
 
 // XYZ returns the raw x,y and z axis from the  mma7660
 func (h *MMA7660Driver) XYZ() (x float64, y float64, z float64, err error) {
-	ret, err := h.connection.I2cRead(mma7660Address, 3)
+	ret := make([]byte, 3)
+	n, err := h.device.Read(ret)
 	if err != nil {
 		return
 	}
 
-	if len(ret) != 3 {
+	if n != 3 {
 		err = ErrNotEnoughBytes
 		return
 	}
@@ -29,7 +29,9 @@ const MPL115A2_REGISTER_STARTCONVERSION = 0x12
 type MPL115A2Driver struct {
 	name       string
 	connection I2c
+	device     I2cConnection
 	interval   time.Duration
+	Config
 	gobot.Eventer
 	A0          float32
 	B1          float32
@@ -39,17 +41,28 @@ type MPL115A2Driver struct {
 	Temperature float32
 }
 
-// NewMPL115A2Driver creates a new driver with specified name and i2c interface
-func NewMPL115A2Driver(a I2c, name string, v ...time.Duration) *MPL115A2Driver {
+// NewMPL115A2Driver creates a new driver with specified name and i2c interface.
+//
+// Optionally accepts:
+//
+//	time.Duration: interval at which the driver reads the sensor, defaults to 10ms
+//	Option: WithBus or WithAddress
+func NewMPL115A2Driver(a I2c, name string, v ...interface{}) *MPL115A2Driver {
 	m := &MPL115A2Driver{
 		name:       name,
 		connection: a,
+		Config:     newConfig(mpl115a2Address),
 		Eventer:    gobot.NewEventer(),
 		interval:   10 * time.Millisecond,
 	}
 
-	if len(v) > 0 {
-		m.interval = v[0]
+	for _, arg := range v {
+		switch arg := arg.(type) {
+		case time.Duration:
+			m.interval = arg
+		case Option:
+			arg(&m.Config)
+		}
 	}
 	m.AddEvent(Error)
 	return m
@@ -71,35 +84,28 @@ func (h *MPL115A2Driver) Start() (errs []error) {
 
 	go func() {
 		for {
-			if err := h.connection.I2cWrite(mpl115a2Address, []byte{MPL115A2_REGISTER_STARTCONVERSION, 0}); err != nil {
+			if _, err := h.device.Write([]byte{MPL115A2_REGISTER_STARTCONVERSION, 0}); err != nil {
 				gobot.Publish(h.Event(Error), err)
 				continue
 
 			}
 			<-time.After(5 * time.Millisecond)
 
-			if err := h.connection.I2cWrite(mpl115a2Address, []byte{MPL115A2_REGISTER_PRESSURE_MSB}); err != nil {
-				gobot.Publish(h.Event(Error), err)
-				continue
-			}
-
-			ret, err := h.connection.I2cRead(mpl115a2Address, 4)
-			if err != nil {
+			ret := make([]byte, 4)
+			if err := h.device.Tx([]byte{MPL115A2_REGISTER_PRESSURE_MSB}, ret); err != nil {
 				gobot.Publish(h.Event(Error), err)
 				continue
 			}
-			if len(ret) == 4 {
-				buf := bytes.NewBuffer(ret)
-				binary.Read(buf, binary.BigEndian, &pressure)
-				binary.Read(buf, binary.BigEndian, &temperature)
+			buf := bytes.NewBuffer(ret)
+			binary.Read(buf, binary.BigEndian, &pressure)
+			binary.Read(buf, binary.BigEndian, &temperature)
 
-				temperature = temperature >> 6
-				pressure = pressure >> 6
+			temperature = temperature >> 6
+			pressure = pressure >> 6
 
-				pressureComp = float32(h.A0) + (float32(h.B1)+float32(h.C12)*float32(temperature))*float32(pressure) + float32(h.B2)*float32(temperature)
-				h.Pressure = (65.0/1023.0)*pressureComp + 50.0
-				h.Temperature = ((float32(temperature) - 498.0) / -5.35) + 25.0
-			}
+			pressureComp = float32(h.A0) + (float32(h.B1)+float32(h.C12)*float32(temperature))*float32(pressure) + float32(h.B2)*float32(temperature)
+			h.Pressure = (65.0/1023.0)*pressureComp + 50.0
+			h.Temperature = ((float32(temperature) - 498.0) / -5.35) + 25.0
 			<-time.After(h.interval)
 		}
 	}()
@@ -107,7 +113,10 @@ func (h *MPL115A2Driver) Start() (errs []error) {
 }
 
 // Halt returns true if devices is halted successfully
-func (h *MPL115A2Driver) Halt() (err []error) { return }
+func (h *MPL115A2Driver) Halt() (err []error) {
+	releaseAddresses(h.connection, h)
+	return
+}
 
 func (h *MPL115A2Driver) initialization() (err error) {
 	var coA0 int16
@@ -115,14 +124,11 @@ func (h *MPL115A2Driver) initialization() (err error) {
 	var coB2 int16
 	var coC12 int16
 
-	if err = h.connection.I2cStart(mpl115a2Address); err != nil {
-		return
-	}
-	if err = h.connection.I2cWrite(mpl115a2Address, []byte{MPL115A2_REGISTER_A0_COEFF_MSB}); err != nil {
+	if h.device, err = h.connect(h.connection, h); err != nil {
 		return
 	}
-	ret, err := h.connection.I2cRead(mpl115a2Address, 8)
-	if err != nil {
+	ret := make([]byte, 8)
+	if err = h.device.Tx([]byte{MPL115A2_REGISTER_A0_COEFF_MSB}, ret); err != nil {
 		return
 	}
 	buf := bytes.NewBuffer(ret)
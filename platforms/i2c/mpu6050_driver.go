@@ -3,6 +3,8 @@ package i2c
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/hybridgroup/gobot"
@@ -10,6 +12,10 @@ import (
 
 var _ gobot.Driver = (*MPU6050Driver)(nil)
 
+// ErrFIFOOverflow is published when the FIFO of the MPU6050 overflowed
+// before it was read, and samples were lost
+var ErrFIFOOverflow = errors.New("MPU6050 FIFO overflow")
+
 const mpu6050Address = 0x68
 
 const MPU6050_RA_ACCEL_XOUT_H = 0x3B
@@ -28,35 +34,121 @@ const MPU6050_ACCEL_FS_2 = 0x00
 const MPU6050_PWR1_SLEEP_BIT = 6
 const MPU6050_PWR1_ENABLE_BIT = 0
 
+const MPU6050_RA_SMPLRT_DIV = 0x19
+const MPU6050_RA_CONFIG = 0x1A
+const MPU6050_RA_FIFO_EN = 0x23
+const MPU6050_RA_INT_PIN_CFG = 0x37
+const MPU6050_RA_INT_ENABLE = 0x38
+const MPU6050_RA_INT_STATUS = 0x3A
+const MPU6050_RA_USER_CTRL = 0x6A
+const MPU6050_RA_FIFO_COUNTH = 0x72
+const MPU6050_RA_FIFO_R_W = 0x74
+const MPU6050_RA_WHO_AM_I = 0x75
+
+const MPU6050_GYRO_FS_500 = 0x01
+const MPU6050_GYRO_FS_1000 = 0x02
+const MPU6050_GYRO_FS_2000 = 0x03
+const MPU6050_ACCEL_FS_4 = 0x01
+const MPU6050_ACCEL_FS_8 = 0x02
+const MPU6050_ACCEL_FS_16 = 0x03
+
+// digital low pass filter bandwidths of the accelerometer
+const MPU6050_DLPF_BW_256 = 0x00
+const MPU6050_DLPF_BW_188 = 0x01
+const MPU6050_DLPF_BW_98 = 0x02
+const MPU6050_DLPF_BW_42 = 0x03
+const MPU6050_DLPF_BW_20 = 0x04
+const MPU6050_DLPF_BW_10 = 0x05
+const MPU6050_DLPF_BW_5 = 0x06
+
+const MPU6050_FIFO_TEMP_ACCEL_GYRO = 0xF8
+const MPU6050_USERCTRL_FIFO_EN = 0x40
+const MPU6050_USERCTRL_FIFO_RESET = 0x04
+const MPU6050_INTCFG_LATCH_INT_EN = 0x20
+const MPU6050_INTERRUPT_DATA_RDY = 0x01
+const MPU6050_INTERRUPT_FIFO_OFLOW = 0x10
+
+// mpu6050FrameSize is the size of a sample in the data registers and the FIFO
+const mpu6050FrameSize = 14
+
+// sensitivities of the full scale ranges, in LSB per g and per degree/s
+var mpu6050AccelSensitivity = [...]float64{16384, 8192, 4096, 2048}
+var mpu6050GyroSensitivity = [...]float64{131, 65.5, 32.8, 16.4}
+
 type ThreeDData struct {
 	X int16
 	Y int16
 	Z int16
 }
 
+// Vector is a scaled three axis value
+type Vector struct {
+	X float64
+	Y float64
+	Z float64
+}
+
+// MPU6050Sample is a sample of the MPU6050, with the acceleration in g, the
+// angular velocity in degrees/s and the temperature in degrees Celsius
+type MPU6050Sample struct {
+	Time          time.Time
+	Accelerometer Vector
+	Gyroscope     Vector
+	Temperature   float64
+}
+
 type MPU6050Driver struct {
 	name          string
 	connection    I2c
+	device        I2cConnection
 	interval      time.Duration
+	halt          chan bool
+	gyroRange     uint8
+	accelRange    uint8
+	divider       uint8
+	dlpf          uint8
+	fifo          bool
+	interrupt     bool
+	accelOffset   Vector
+	gyroOffset    Vector
 	Accelerometer ThreeDData
 	Gyroscope     ThreeDData
-	Temperature   int16
+	Temperature   float64
+	mutex         sync.Mutex
+	sample        MPU6050Sample
+	Config
 	gobot.Eventer
 }
 
-// NewMPU6050Driver creates a new driver with specified name and i2c interface
-func NewMPU6050Driver(a I2c, name string, v ...time.Duration) *MPU6050Driver {
+// NewMPU6050Driver creates a new driver with specified name and i2c interface.
+//
+// Optionally accepts:
+//
+//	time.Duration: interval at which the driver reads the sensor, defaults to 10ms
+//	Option: WithBus or WithAddress
+func NewMPU6050Driver(a I2c, name string, v ...interface{}) *MPU6050Driver {
 	m := &MPU6050Driver{
 		name:       name,
 		connection: a,
+		Config:     newConfig(mpu6050Address),
 		interval:   10 * time.Millisecond,
+		halt:       make(chan bool),
+		gyroRange:  MPU6050_GYRO_FS_250,
+		accelRange: MPU6050_ACCEL_FS_2,
+		dlpf:       MPU6050_DLPF_BW_256,
 		Eventer:    gobot.NewEventer(),
 	}
 
-	if len(v) > 0 {
-		m.interval = v[0]
+	for _, arg := range v {
+		switch arg := arg.(type) {
+		case time.Duration:
+			m.interval = arg
+		case Option:
+			arg(&m.Config)
+		}
 	}
 
+	m.AddEvent(Data)
 	m.AddEvent(Error)
 	return m
 }
@@ -65,7 +157,12 @@ func (h *MPU6050Driver) Name() string                 { return h.name }
 func (h *MPU6050Driver) Connection() gobot.Connection { return h.connection.(gobot.Connection) }
 
 // Start writes initialization bytes and reads from adaptor
-// using specified interval to accelerometer andtemperature data
+// using specified interval to accelerometer andtemperature data.
+// Emits the Events:
+//
+//	Data MPU6050Sample - a sample of the sensor
+//	Error error - error reading the sensor, ErrFIFOOverflow when samples of
+//	the FIFO were lost
 func (h *MPU6050Driver) Start() (errs []error) {
 	if err := h.initialize(); err != nil {
 		return []error{err}
@@ -73,74 +170,289 @@ func (h *MPU6050Driver) Start() (errs []error) {
 
 	go func() {
 		for {
-			if err := h.connection.I2cWrite(mpu6050Address, []byte{MPU6050_RA_ACCEL_XOUT_H}); err != nil {
+			if err := h.read(); err != nil {
 				gobot.Publish(h.Event(Error), err)
-				continue
 			}
-
-			ret, err := h.connection.I2cRead(mpu6050Address, 14)
-			if err != nil {
-				gobot.Publish(h.Event(Error), err)
-				continue
+			select {
+			case <-time.After(h.interval):
+			case <-h.halt:
+				return
 			}
-			buf := bytes.NewBuffer(ret)
-			binary.Read(buf, binary.BigEndian, &h.Accelerometer)
-			binary.Read(buf, binary.BigEndian, &h.Temperature)
-			binary.Read(buf, binary.BigEndian, &h.Gyroscope)
-			h.convertToCelsius()
-			<-time.After(h.interval)
 		}
 	}()
 	return
 }
 
-// Halt returns true if devices is halted successfully
-func (h *MPU6050Driver) Halt() (errs []error) { return }
+// Halt stops reading the sensor
+func (h *MPU6050Driver) Halt() (errs []error) {
+	if h.device != nil {
+		h.halt <- true
+		h.device = nil
+	}
+	releaseAddresses(h.connection, h)
+	return
+}
 
 func (h *MPU6050Driver) initialize() (err error) {
-	if err = h.connection.I2cStart(mpu6050Address); err != nil {
+	device, err := h.connect(h.connection, h)
+	if err != nil {
 		return
 	}
 
-	// setClockSource
-	if err = h.connection.I2cWrite(mpu6050Address, []byte{MPU6050_RA_PWR_MGMT_1,
-		MPU6050_PWR1_CLKSEL_BIT,
-		MPU6050_PWR1_CLKSEL_LENGTH,
-		MPU6050_CLOCK_PLL_XGYRO}); err != nil {
+	// wake up with the x gyro as clock source
+	if _, err = device.Write([]byte{MPU6050_RA_PWR_MGMT_1, MPU6050_CLOCK_PLL_XGYRO}); err != nil {
+		return
+	}
+	if _, err = device.Write([]byte{MPU6050_RA_SMPLRT_DIV,
+		h.divider,
+		h.dlpf,
+		h.gyroRange << 3,
+		h.accelRange << 3}); err != nil {
 		return
 	}
 
-	// setFullScaleGyroRange
-  if err = h.connection.I2cWrite(mpu6050Address, []byte{MPU6050_RA_GYRO_CONFIG,
-    MPU6050_GCONFIG_FS_SEL_BIT,
-    MPU6050_GCONFIG_FS_SEL_LENGTH,
-    MPU6050_GYRO_FS_250}); err != nil {
-    return
-  }
+	var interrupts byte
+	if h.interrupt {
+		interrupts |= MPU6050_INTERRUPT_DATA_RDY
+	}
+	if h.fifo {
+		interrupts |= MPU6050_INTERRUPT_FIFO_OFLOW
+	}
+	if _, err = device.Write([]byte{MPU6050_RA_INT_PIN_CFG, MPU6050_INTCFG_LATCH_INT_EN, interrupts}); err != nil {
+		return
+	}
 
-	// setFullScaleAccelRange
-	if err = h.connection.I2cWrite(mpu6050Address, []byte{MPU6050_RA_ACCEL_CONFIG,
-		MPU6050_ACONFIG_AFS_SEL_BIT,
-		MPU6050_ACONFIG_AFS_SEL_LENGTH,
-		MPU6050_ACCEL_FS_2}); err != nil {
+	if h.fifo {
+		if _, err = device.Write([]byte{MPU6050_RA_USER_CTRL, MPU6050_USERCTRL_FIFO_RESET}); err != nil {
+			return
+		}
+		if _, err = device.Write([]byte{MPU6050_RA_FIFO_EN, MPU6050_FIFO_TEMP_ACCEL_GYRO}); err != nil {
+			return
+		}
+		if _, err = device.Write([]byte{MPU6050_RA_USER_CTRL, MPU6050_USERCTRL_FIFO_EN}); err != nil {
+			return
+		}
+	}
+
+	h.device = device
+	return nil
+}
+
+// SetGyroRange sets the full scale range of the gyroscope to one of
+// MPU6050_GYRO_FS_250 to MPU6050_GYRO_FS_2000 degrees/s
+func (h *MPU6050Driver) SetGyroRange(fs uint8) (err error) {
+	if fs > MPU6050_GYRO_FS_2000 {
+		return ErrInvalidRange
+	}
+	h.gyroRange = fs
+	return h.writeRegister(MPU6050_RA_GYRO_CONFIG, fs<<3)
+}
+
+// SetAccelRange sets the full scale range of the accelerometer to one of
+// MPU6050_ACCEL_FS_2 to MPU6050_ACCEL_FS_16 g
+func (h *MPU6050Driver) SetAccelRange(fs uint8) (err error) {
+	if fs > MPU6050_ACCEL_FS_16 {
+		return ErrInvalidRange
+	}
+	h.accelRange = fs
+	return h.writeRegister(MPU6050_RA_ACCEL_CONFIG, fs<<3)
+}
+
+// SetSampleRateDivider sets the sample rate to the gyroscope output rate,
+// 8kHz without low pass filter and 1kHz with it, divided by 1 + divider
+func (h *MPU6050Driver) SetSampleRateDivider(divider uint8) (err error) {
+	h.divider = divider
+	return h.writeRegister(MPU6050_RA_SMPLRT_DIV, divider)
+}
+
+// SetDLPF sets the digital low pass filter to one of MPU6050_DLPF_BW_256 to
+// MPU6050_DLPF_BW_5
+func (h *MPU6050Driver) SetDLPF(dlpf uint8) (err error) {
+	if dlpf > MPU6050_DLPF_BW_5 {
+		return ErrInvalidRange
+	}
+	h.dlpf = dlpf
+	return h.writeRegister(MPU6050_RA_CONFIG, dlpf)
+}
+
+// SetFIFO sets whether the samples are read in bursts from the FIFO of the
+// sensor, so that no sample is lost between two reads. Takes effect on Start.
+func (h *MPU6050Driver) SetFIFO(enabled bool) { h.fifo = enabled }
+
+// SetDataReadyInterrupt sets whether the INT pin of the sensor signals new
+// samples. Each sample is then read once, when it is ready. Takes effect on
+// Start.
+func (h *MPU6050Driver) SetDataReadyInterrupt(enabled bool) { h.interrupt = enabled }
+
+// SetOffsets sets the offsets subtracted from the scaled accelerometer and
+// gyroscope values
+func (h *MPU6050Driver) SetOffsets(accel Vector, gyro Vector) {
+	h.accelOffset = accel
+	h.gyroOffset = gyro
+}
+
+// Offsets returns the offsets subtracted from the scaled accelerometer and
+// gyroscope values
+func (h *MPU6050Driver) Offsets() (accel Vector, gyro Vector) {
+	return h.accelOffset, h.gyroOffset
+}
+
+// Calibrate averages samples samples of the sensor lying still and level,
+// and sets the offsets so that it then reads 1g on the Z axis and no
+// rotation
+func (h *MPU6050Driver) Calibrate(samples int) (err error) {
+	if h.device == nil {
+		return ErrNotReady
+	}
+
+	var accel, gyro Vector
+	frame := make([]byte, mpu6050FrameSize)
+	for i := 0; i < samples; i++ {
+		if err = h.device.Tx([]byte{MPU6050_RA_ACCEL_XOUT_H}, frame); err != nil {
+			return
+		}
+		a, g, _ := parseMPU6050Frame(frame)
+		accel = accel.add(h.scale(a, mpu6050AccelSensitivity[h.accelRange]))
+		gyro = gyro.add(h.scale(g, mpu6050GyroSensitivity[h.gyroRange]))
+		<-time.After(h.samplePeriod())
+	}
+
+	n := float64(samples)
+	h.SetOffsets(
+		Vector{X: accel.X / n, Y: accel.Y / n, Z: accel.Z/n - 1},
+		Vector{X: gyro.X / n, Y: gyro.Y / n, Z: gyro.Z / n},
+	)
+	return
+}
+
+// samplePeriod returns the period of the samples of the sensor
+func (h *MPU6050Driver) samplePeriod() time.Duration {
+	rate := 1000
+	if h.dlpf == MPU6050_DLPF_BW_256 {
+		rate = 8000
+	}
+	return time.Second * time.Duration(1+int(h.divider)) / time.Duration(rate)
+}
+
+// writeRegister writes a configuration register once the driver is started
+func (h *MPU6050Driver) writeRegister(reg uint8, value uint8) (err error) {
+	if h.device == nil {
 		return
 	}
+	_, err = h.device.Write([]byte{reg, value})
+	return
+}
+
+// read reads the samples of the sensor
+func (h *MPU6050Driver) read() (err error) {
+	if h.fifo {
+		return h.readFIFO()
+	}
+
+	if h.interrupt {
+		status := make([]byte, 1)
+		if err = h.device.Tx([]byte{MPU6050_RA_INT_STATUS}, status); err != nil {
+			return
+		}
+		if status[0]&MPU6050_INTERRUPT_DATA_RDY == 0 {
+			return
+		}
+	}
 
-	// setSleepEnabled
-	if err = h.connection.I2cWrite(mpu6050Address, []byte{MPU6050_RA_PWR_MGMT_1,
-		MPU6050_PWR1_ENABLE_BIT,
-		0}); err != nil {
+	frame := make([]byte, mpu6050FrameSize)
+	if err = h.device.Tx([]byte{MPU6050_RA_ACCEL_XOUT_H}, frame); err != nil {
 		return
 	}
+	h.publish(frame, time.Now())
+	return
+}
 
-	return nil
+// readFIFO reads the complete samples in the FIFO
+func (h *MPU6050Driver) readFIFO() (err error) {
+	status := make([]byte, 1)
+	if err = h.device.Tx([]byte{MPU6050_RA_INT_STATUS}, status); err != nil {
+		return
+	}
+	if status[0]&MPU6050_INTERRUPT_FIFO_OFLOW != 0 {
+		if _, err = h.device.Write([]byte{MPU6050_RA_USER_CTRL, MPU6050_USERCTRL_FIFO_EN | MPU6050_USERCTRL_FIFO_RESET}); err != nil {
+			return
+		}
+		return ErrFIFOOverflow
+	}
+
+	count := make([]byte, 2)
+	if err = h.device.Tx([]byte{MPU6050_RA_FIFO_COUNTH}, count); err != nil {
+		return
+	}
+	frames := int(binary.BigEndian.Uint16(count)) / mpu6050FrameSize
+	if frames == 0 {
+		return
+	}
+
+	data := make([]byte, frames*mpu6050FrameSize)
+	if err = h.device.Tx([]byte{MPU6050_RA_FIFO_R_W}, data); err != nil {
+		return
+	}
+	// the last sample was taken last, the others one period apart before it
+	now := time.Now()
+	for i := 0; i < frames; i++ {
+		h.publish(data[i*mpu6050FrameSize:(i+1)*mpu6050FrameSize],
+			now.Add(-time.Duration(frames-1-i)*h.samplePeriod()))
+	}
+	return
+}
+
+// publish stores the sample in frame and publishes it
+func (h *MPU6050Driver) publish(frame []byte, t time.Time) {
+	var temperature int16
+	h.Accelerometer, h.Gyroscope, temperature = parseMPU6050Frame(frame)
+	h.Temperature = mpu6050Celsius(temperature)
+
+	sample := MPU6050Sample{
+		Time:          t,
+		Accelerometer: h.scale(h.Accelerometer, mpu6050AccelSensitivity[h.accelRange]).sub(h.accelOffset),
+		Gyroscope:     h.scale(h.Gyroscope, mpu6050GyroSensitivity[h.gyroRange]).sub(h.gyroOffset),
+		Temperature:   h.Temperature,
+	}
+	h.mutex.Lock()
+	h.sample = sample
+	h.mutex.Unlock()
+	gobot.Publish(h.Event(Data), sample)
+}
+
+// Sample returns the last sample read by the driver
+func (h *MPU6050Driver) Sample() MPU6050Sample {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.sample
+}
+
+func (h *MPU6050Driver) scale(d ThreeDData, sensitivity float64) Vector {
+	return Vector{
+		X: float64(d.X) / sensitivity,
+		Y: float64(d.Y) / sensitivity,
+		Z: float64(d.Z) / sensitivity,
+	}
+}
+
+func (v Vector) add(o Vector) Vector { return Vector{X: v.X + o.X, Y: v.Y + o.Y, Z: v.Z + o.Z} }
+func (v Vector) sub(o Vector) Vector { return Vector{X: v.X - o.X, Y: v.Y - o.Y, Z: v.Z - o.Z} }
+
+// parseMPU6050Frame returns the accelerometer, gyroscope and temperature
+// counts of a frame of the data registers or the FIFO
+func parseMPU6050Frame(frame []byte) (accel ThreeDData, gyro ThreeDData, temperature int16) {
+	buf := bytes.NewBuffer(frame)
+	binary.Read(buf, binary.BigEndian, &accel)
+	binary.Read(buf, binary.BigEndian, &temperature)
+	binary.Read(buf, binary.BigEndian, &gyro)
+	return
 }
 
 // The temperature sensor is -40 to +85 degrees Celsius.
 // It is a signed integer.
 // According to the datasheet:
-//   340 per degrees Celsius, -512 at 35 degrees.
-// At 0 degrees: -512 - (340 * 35) = -12412
-func (h *MPU6050Driver) convertToCelsius() {
-	h.Temperature = (h.Temperature + 12412) / 340
+//
+//	340 per degrees Celsius, 36.53 degrees at 0.
+func mpu6050Celsius(temperature int16) float64 {
+	return float64(temperature)/340.0 + 36.53
 }
@@ -1,9 +1,11 @@
 package i2c
 
 import (
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/gobottest"
 )
 
@@ -51,3 +53,129 @@ func TestMPU6050DriverHalt(t *testing.T) {
 
 	gobottest.Assert(t, len(mpu.Halt()), 0)
 }
+
+func TestMPU6050DriverData(t *testing.T) {
+	adaptor := newI2cTestRegisters("adaptor")
+	mpu := NewMPU6050Driver(adaptor, "bot", 1*time.Millisecond)
+	gobottest.Assert(t, mpu.SetGyroRange(MPU6050_GYRO_FS_500), nil)
+	gobottest.Assert(t, mpu.SetAccelRange(MPU6050_ACCEL_FS_4), nil)
+	gobottest.Assert(t, mpu.SetSampleRateDivider(9), nil)
+	gobottest.Assert(t, mpu.SetDLPF(MPU6050_DLPF_BW_42), nil)
+	gobottest.Assert(t, mpu.SetAccelRange(0x04), ErrInvalidRange)
+	gobottest.Assert(t, mpu.samplePeriod(), 10*time.Millisecond)
+
+	// 1g on z, 65.5 degrees/s around x, 36.53 degrees
+	adaptor.setRegisters(MPU6050_RA_ACCEL_XOUT_H,
+		0x00, 0x00, 0x00, 0x00, 0x20, 0x00,
+		0x00, 0x00,
+		0x00, 0x83, 0x00, 0x00, 0x00, 0x00)
+
+	sem := make(chan MPU6050Sample)
+	gobot.Once(mpu.Event(Data), func(data interface{}) {
+		sem <- data.(MPU6050Sample)
+	})
+	gobottest.Assert(t, len(mpu.Start()), 0)
+	gobottest.Assert(t, adaptor.register(MPU6050_RA_PWR_MGMT_1), byte(MPU6050_CLOCK_PLL_XGYRO))
+	gobottest.Assert(t, adaptor.register(MPU6050_RA_SMPLRT_DIV), byte(9))
+	gobottest.Assert(t, adaptor.register(MPU6050_RA_CONFIG), byte(MPU6050_DLPF_BW_42))
+	gobottest.Assert(t, adaptor.register(MPU6050_RA_GYRO_CONFIG), byte(0x08))
+	gobottest.Assert(t, adaptor.register(MPU6050_RA_ACCEL_CONFIG), byte(0x08))
+
+	select {
+	case sample := <-sem:
+		gobottest.Assert(t, sample.Accelerometer, Vector{X: 0, Y: 0, Z: 1})
+		gobottest.Assert(t, sample.Gyroscope, Vector{X: 2, Y: 0, Z: 0})
+		gobottest.Assert(t, sample.Temperature, 36.53)
+		gobottest.Refute(t, sample.Time, time.Time{})
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("MPU6050 data event not published")
+	}
+	gobottest.Assert(t, len(mpu.Halt()), 0)
+	gobottest.Assert(t, mpu.Accelerometer, ThreeDData{X: 0, Y: 0, Z: 0x2000})
+	gobottest.Assert(t, mpu.Sample().Accelerometer, Vector{X: 0, Y: 0, Z: 1})
+	gobottest.Assert(t, mpu.Sample().Gyroscope, Vector{X: 2, Y: 0, Z: 0})
+
+	// range changes are written once started
+	gobottest.Assert(t, len(mpu.Start()), 0)
+	gobottest.Assert(t, mpu.SetGyroRange(MPU6050_GYRO_FS_2000), nil)
+	gobottest.Assert(t, adaptor.register(MPU6050_RA_GYRO_CONFIG), byte(0x18))
+	gobottest.Assert(t, len(mpu.Halt()), 0)
+}
+
+func TestMPU6050DriverCalibrate(t *testing.T) {
+	adaptor := newI2cTestRegisters("adaptor")
+	mpu := NewMPU6050Driver(adaptor, "bot", 1*time.Hour)
+	gobottest.Assert(t, mpu.Calibrate(2), ErrNotReady)
+
+	// 0.5g on x, 1.5g on z, -1 degree/s around z
+	adaptor.setRegisters(MPU6050_RA_ACCEL_XOUT_H,
+		0x20, 0x00, 0x00, 0x00, 0x60, 0x00,
+		0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0xff, 0x7d)
+	gobottest.Assert(t, mpu.initialize(), nil)
+	gobottest.Assert(t, mpu.Calibrate(2), nil)
+
+	accel, gyro := mpu.Offsets()
+	gobottest.Assert(t, accel, Vector{X: 0.5, Y: 0, Z: 0.5})
+	gobottest.Assert(t, gyro, Vector{X: 0, Y: 0, Z: -1})
+}
+
+func TestMPU6050DriverFIFO(t *testing.T) {
+	adaptor := newI2cTestRegisters("adaptor")
+	mpu := NewMPU6050Driver(adaptor, "bot", 1*time.Hour)
+	mpu.SetFIFO(true)
+	gobottest.Assert(t, mpu.initialize(), nil)
+	gobottest.Assert(t, adaptor.register(MPU6050_RA_FIFO_EN), byte(MPU6050_FIFO_TEMP_ACCEL_GYRO))
+	gobottest.Assert(t, adaptor.register(MPU6050_RA_USER_CTRL), byte(MPU6050_USERCTRL_FIFO_EN))
+	gobottest.Assert(t, adaptor.register(MPU6050_RA_INT_ENABLE), byte(MPU6050_INTERRUPT_FIFO_OFLOW))
+
+	// two samples and the start of a third one
+	adaptor.setRegisters(MPU6050_RA_FIFO_COUNTH, 0x00, 30)
+	frames := make([]byte, 2*mpu6050FrameSize)
+	frames[0] = 0x40
+	frames[mpu6050FrameSize] = 0xc0
+	adaptor.fifos[MPU6050_RA_FIFO_R_W] = frames
+
+	// callbacks run concurrently, so samples may arrive in any order
+	var mutex sync.Mutex
+	var samples []MPU6050Sample
+	gobot.On(mpu.Event(Data), func(data interface{}) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		samples = append(samples, data.(MPU6050Sample))
+	})
+	gobottest.Assert(t, mpu.read(), nil)
+	<-time.After(10 * time.Millisecond)
+	mutex.Lock()
+	defer mutex.Unlock()
+	gobottest.Assert(t, len(samples), 2)
+	if samples[1].Time.Before(samples[0].Time) {
+		samples[0], samples[1] = samples[1], samples[0]
+	}
+	gobottest.Assert(t, samples[0].Accelerometer.X, 1.0)
+	gobottest.Assert(t, samples[1].Accelerometer.X, -1.0)
+	gobottest.Assert(t, samples[1].Time.Sub(samples[0].Time), mpu.samplePeriod())
+
+	adaptor.setRegisters(MPU6050_RA_INT_STATUS, MPU6050_INTERRUPT_FIFO_OFLOW)
+	gobottest.Assert(t, mpu.read(), ErrFIFOOverflow)
+	gobottest.Assert(t, adaptor.register(MPU6050_RA_USER_CTRL),
+		byte(MPU6050_USERCTRL_FIFO_EN|MPU6050_USERCTRL_FIFO_RESET))
+}
+
+func TestMPU6050DriverDataReadyInterrupt(t *testing.T) {
+	adaptor := newI2cTestRegisters("adaptor")
+	mpu := NewMPU6050Driver(adaptor, "bot", 1*time.Hour)
+	mpu.SetDataReadyInterrupt(true)
+	gobottest.Assert(t, mpu.initialize(), nil)
+	gobottest.Assert(t, adaptor.register(MPU6050_RA_INT_PIN_CFG), byte(MPU6050_INTCFG_LATCH_INT_EN))
+	gobottest.Assert(t, adaptor.register(MPU6050_RA_INT_ENABLE), byte(MPU6050_INTERRUPT_DATA_RDY))
+
+	adaptor.setRegisters(MPU6050_RA_ACCEL_XOUT_H, 0x40)
+	adaptor.setRegisters(MPU6050_RA_INT_STATUS, 0x00)
+	gobottest.Assert(t, mpu.read(), nil)
+	gobottest.Assert(t, mpu.Accelerometer.X, int16(0))
+
+	adaptor.setRegisters(MPU6050_RA_INT_STATUS, MPU6050_INTERRUPT_DATA_RDY)
+	gobottest.Assert(t, mpu.read(), nil)
+	gobottest.Assert(t, mpu.Accelerometer.X, int16(0x4000))
+}
@@ -0,0 +1,100 @@
+package i2c
+
+import (
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/gpio"
+)
+
+var _ gobot.Driver = (*PCF8574LcdDriver)(nil)
+
+const pcf8574LcdAddress = 0x27
+
+// pins of the PCF8574 wired to the display by the common backpacks, the
+// data pins D4 to D7 are P4 to P7
+const (
+	PCF8574_LCD_RS        = 0x01
+	PCF8574_LCD_RW        = 0x02
+	PCF8574_LCD_EN        = 0x04
+	PCF8574_LCD_BACKLIGHT = 0x08
+)
+
+// PCF8574LcdDriver is a driver for the HD44780 character displays with a
+// PCF8574 i2c backpack. Backpacks with a PCF8574A answer at 0x3F instead
+// of 0x27.
+//
+// See gpio.HD44780 for the text functions.
+type PCF8574LcdDriver struct {
+	name       string
+	connection I2c
+	device     I2cConnection
+	backlight  byte
+	Config
+	*gpio.HD44780
+}
+
+// NewPCF8574LcdDriver creates a new driver of cols columns and rows rows,
+// such as 16x2 or 20x4, with specified name and i2c interface.
+//
+// Optionally accepts the options WithBus and WithAddress
+func NewPCF8574LcdDriver(a I2c, name string, cols int, rows int, options ...Option) *PCF8574LcdDriver {
+	h := &PCF8574LcdDriver{
+		name:       name,
+		connection: a,
+		backlight:  PCF8574_LCD_BACKLIGHT,
+		Config:     newConfig(pcf8574LcdAddress, options...),
+	}
+	h.HD44780 = gpio.NewHD44780(gpio.NewHD44780FourBitBus(h.writeNibble), cols, rows)
+	return h
+}
+
+// Name returns the name of the driver
+func (h *PCF8574LcdDriver) Name() string { return h.name }
+
+// Connection returns the driver connection to the device.
+func (h *PCF8574LcdDriver) Connection() gobot.Connection {
+	return h.connection.(gobot.Connection)
+}
+
+// Start initializes the display and turns its backlight on
+func (h *PCF8574LcdDriver) Start() (errs []error) {
+	device, err := h.connect(h.connection, h)
+	if err != nil {
+		return []error{err}
+	}
+	h.device = device
+
+	if _, err := h.device.Write([]byte{h.backlight}); err != nil {
+		return []error{err}
+	}
+	if err := h.Init(); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// Halt is a noop function.
+func (h *PCF8574LcdDriver) Halt() (errs []error) {
+	releaseAddresses(h.connection, h)
+	return
+}
+
+// Backlight turns the backlight on or off
+func (h *PCF8574LcdDriver) Backlight(on bool) (err error) {
+	h.backlight = 0
+	if on {
+		h.backlight = PCF8574_LCD_BACKLIGHT
+	}
+	_, err = h.device.Write([]byte{h.backlight})
+	return
+}
+
+// writeNibble writes the pins with the enable pin high and then low, in a
+// single transaction
+func (h *PCF8574LcdDriver) writeNibble(rs bool, nibble byte) (err error) {
+	pins := nibble<<4 | h.backlight
+	if rs {
+		pins |= PCF8574_LCD_RS
+	}
+	_, err = h.device.Write([]byte{pins | PCF8574_LCD_EN, pins})
+	return
+}
@@ -0,0 +1,40 @@
+package i2c
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestPCF8574LcdDriver(t *testing.T) {
+	adaptor := newI2cTestAdaptor("adaptor")
+	d := NewPCF8574LcdDriver(adaptor, "lcd", 20, 4)
+	gobottest.Assert(t, d.Name(), "lcd")
+	gobottest.Assert(t, d.Connection().Name(), "adaptor")
+	gobottest.Assert(t, d.Address(), 0x27)
+	gobottest.Assert(t, d.Cols(), 20)
+	gobottest.Assert(t, d.Rows(), 4)
+	gobottest.Assert(t, NewPCF8574LcdDriver(adaptor, "lcd", 16, 2, WithAddress(0x3F)).Address(), 0x3F)
+
+	gobottest.Assert(t, len(d.Start()), 0)
+	gobottest.Assert(t, adaptor.written[:5], [][]byte{
+		{0x08}, {0x3c, 0x38}, {0x3c, 0x38}, {0x3c, 0x38}, {0x2c, 0x28},
+	})
+
+	// the nibbles of the data are latched with RS high
+	adaptor.written = nil
+	gobottest.Assert(t, d.Write("A"), nil)
+	gobottest.Assert(t, adaptor.written, [][]byte{{0x4d, 0x49}, {0x1d, 0x19}})
+
+	adaptor.written = nil
+	gobottest.Assert(t, d.Backlight(false), nil)
+	gobottest.Assert(t, d.Write("A"), nil)
+	gobottest.Assert(t, adaptor.written, [][]byte{{0x00}, {0x45, 0x41}, {0x15, 0x11}})
+	gobottest.Assert(t, len(d.Halt()), 0)
+
+	adaptor = newI2cTestAdaptor("adaptor")
+	adaptor.i2cWriteImpl = func() error { return errors.New("write error") }
+	d = NewPCF8574LcdDriver(adaptor, "lcd", 16, 2)
+	gobottest.Assert(t, d.Start(), []error{errors.New("write error")})
+}
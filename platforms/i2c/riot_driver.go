@@ -46,15 +46,25 @@ const (
 type RIoTDriver struct {
 	name       string
 	connection I2cExtended
+	device     I2cConnection
+	dac        I2cConnection
+	adc        I2cConnection
+	Config
 	gobot.Commander
 	initialized          bool
 	digitalIoInitialized bool
 }
 
-func NewRIoTDriver(i I2cExtended, name string) *RIoTDriver {
+// NewRIoTDriver creates a new driver with specified name and i2c interface.
+//
+// Optionally accepts the options WithBus and WithAddress. The address is the
+// address of the digital I/O expander, the DAC and the ADC are always at their
+// default addresses on the same bus.
+func NewRIoTDriver(i I2cExtended, name string, options ...Option) *RIoTDriver {
 	b := &RIoTDriver{
 		name:                 name,
 		connection:           i,
+		Config:               newConfig(RIOT_ADDRESS, options...),
 		Commander:            gobot.NewCommander(),
 		initialized:          false,
 		digitalIoInitialized: false,
@@ -144,7 +154,15 @@ func (b *RIoTDriver) Connection() gobot.Connection { return b.connection.(gobot.
 // Start writes start bytes
 func (b *RIoTDriver) Start() (errs []error) {
 	if !b.initialized {
-		if err := b.connection.I2cStart(RIOT_ADDRESS); err != nil {
+		device, err := b.connect(b.connection, b)
+		if err != nil {
+			return []error{err}
+		}
+		b.device = device
+		if b.dac, err = getI2cConnection(b.connection, b, b.Bus(), RIOT_DIGITAL_TO_ANALOG_CONVERTER_SLAVE_ADDRESS_TWO); err != nil {
+			return []error{err}
+		}
+		if b.adc, err = getI2cConnection(b.connection, b, b.Bus(), RIOT_ANALOG_TO_DIGITAL_CONVERTER_SLAVE_ADDRESS); err != nil {
 			return []error{err}
 		}
 		b.initialized = true
@@ -153,17 +171,20 @@ func (b *RIoTDriver) Start() (errs []error) {
 }
 
 // Halt returns true if device is halted successfully
-func (b *RIoTDriver) Halt() (errs []error) { return }
+func (b *RIoTDriver) Halt() (errs []error) {
+	releaseAddresses(b.connection, b)
+	return
+}
 
 // initializes RIoT board
 func (b *RIoTDriver) initializeRIoTInterfaceBoard() (errs []error) {
 	if !b.digitalIoInitialized {
 		// Digital I/O initialization:
 		// i2c.writeByteSync(0x20, 0x00, 0x0F);    i2c.writeByteSync(0x20, 0x01, 0x00);
-		if err := b.connection.I2cWrite(RIOT_ADDRESS, []byte{RIOT_INITIALIZATION_ADDRESS_01, 0x0F}); err != nil {
+		if _, err := b.device.Write([]byte{RIOT_INITIALIZATION_ADDRESS_01, 0x0F}); err != nil {
 			return []error{err}
 		}
-		if err := b.connection.I2cWrite(RIOT_ADDRESS, []byte{RIOT_INITIALIZATION_ADDRESS_02, 0x00}); err != nil {
+		if _, err := b.device.Write([]byte{RIOT_INITIALIZATION_ADDRESS_02, 0x00}); err != nil {
 			return []error{err}
 		}
 		b.digitalIoInitialized = true
@@ -177,7 +198,8 @@ func (b *RIoTDriver) ReadDigitalInput() (data []byte, errs []error) {
 		return
 	}
 	// The lower four bits of “input” corresponding to digital input channel 0-3
-	data, err := b.connection.I2cReadRegister([]byte{RIOT_ADDRESS, RIOT_DIGITAL_INPUT_REGISTER}, 1)
+	data = make([]byte, 1)
+	_, err := b.device.ReadRegister(RIOT_DIGITAL_INPUT_REGISTER, data)
 	return data, []error{err}
 }
 
@@ -190,8 +212,8 @@ func (b *RIoTDriver) SetDigitalOutput(channel byte) (errs []error) {
 	data, _ := b.ReadDigitalInput()
 
 	// fmt.Printf("[0]-> %X %X %X %X %X\n", data, data[0]&0X01, data[0]&0X02>>1, data[0]&0X04>>2, data[0]&0X08>>3) // fmt.Printf("[1]-> %X %X %X %X %X\n", data, data[0]&0X10>>4, data[0]&0X20>>5, data[0]&0X40>>6, data[0]&0X80>>7) // fmt.Printf("[ch]-> %X %X %X\n", channel, data[0]|channel)
-	b.connection.I2cWriteWord(RIOT_ADDRESS, RIOT_DIGITAL_OUTPUT_REGISTER, uint16(data[0]|channel))
-	// if err := b.connection.I2cWrite(RIOT_ADDRESS, []byte{RIOT_DIGITAL_OUTPUT_REGISTER, data[0] | channel}); err != nil {
+	b.device.WriteWord(RIOT_DIGITAL_OUTPUT_REGISTER, uint16(data[0]|channel))
+	// if _, err := b.device.Write([]byte{RIOT_DIGITAL_OUTPUT_REGISTER, data[0] | channel}); err != nil {
 	// 	return
 	// }
 	return
@@ -209,8 +231,8 @@ func (b *RIoTDriver) ResetDigitalOutput(channel byte) (errs []error) {
 	fmt.Printf("[1]-> %X %X %X %X %X\n", data, data[0]&0X10>>4, data[0]&0X20>>5, data[0]&0X40>>6, data[0]&0X80>>7)
 	fmt.Printf("[ch]-> %X %X %X\n", channel, data[0]&channel)
 
-	b.connection.I2cWriteWord(RIOT_ADDRESS, RIOT_DIGITAL_OUTPUT_REGISTER, uint16(data[0]&channel))
-	// if err := b.connection.I2cWrite(RIOT_ADDRESS, []byte{RIOT_DIGITAL_OUTPUT_REGISTER, data[0] | channel}); err != nil {
+	b.device.WriteWord(RIOT_DIGITAL_OUTPUT_REGISTER, uint16(data[0]&channel))
+	// if _, err := b.device.Write([]byte{RIOT_DIGITAL_OUTPUT_REGISTER, data[0] | channel}); err != nil {
 	// 	return
 	// }
 	return
@@ -222,8 +244,8 @@ func (b *RIoTDriver) SetDigitalAnalogConverter(value01 byte, value02 uint16) (er
 		return
 	}
 
-	b.connection.I2cWriteWord(RIOT_DIGITAL_TO_ANALOG_CONVERTER_SLAVE_ADDRESS_TWO, value01, value02)
-	// if err := b.connection.I2cWrite(RIOT_ADDRESS, []byte{RIOT_DIGITAL_OUTPUT_REGISTER, data[0] | channel}); err != nil {
+	b.dac.WriteWord(value01, value02)
+	// if _, err := b.device.Write([]byte{RIOT_DIGITAL_OUTPUT_REGISTER, data[0] | channel}); err != nil {
 	// 	return
 	// }
 	return
@@ -234,9 +256,10 @@ func (b *RIoTDriver) ReadADC(value01 byte, value02 uint16) (data []byte, errs []
 	if err := b.initializeRIoTInterfaceBoard(); err != nil {
 		return
 	}
-	b.connection.I2cWriteWord(RIOT_ANALOG_TO_DIGITAL_CONVERTER_SLAVE_ADDRESS, value01, value02)
+	b.adc.WriteWord(value01, value02)
 
-	data, err := b.connection.I2cReadRegister([]byte{RIOT_ANALOG_TO_DIGITAL_CONVERTER_SLAVE_ADDRESS, RIOT_ANALOG_TO_DIGITAL_OUTPUT_REGISTER}, 2) // 2 == 2 bytes == word
+	data = make([]byte, 2) // 2 == 2 bytes == word
+	_, err := b.adc.ReadRegister(RIOT_ANALOG_TO_DIGITAL_OUTPUT_REGISTER, data)
 
 	// for now, prints out WORD (but returns full byte array)
 	fmt.Printf("data -> %X %X %X %X %X\n", data, data[0]&0X01, data[0]&0X02>>1, data[0]&0X04>>2, data[0]&0X08>>3)
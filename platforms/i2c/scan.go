@@ -0,0 +1,136 @@
+package i2c
+
+// ScanResult is a device which responded to Scan
+type ScanResult struct {
+	Address int    `json:"address"`
+	Chip    string `json:"chip,omitempty"`
+}
+
+// i2cProber is an I2cConnection which can probe its device with the SMBus
+// commands used by i2cdetect
+type i2cProber interface {
+	WriteQuick(bit uint8) error
+	ReadByte() (byte, error)
+}
+
+// chip is a known chip, identified by the addresses it answers to and, when
+// it has one, the content of an ID register
+type chip struct {
+	name      string
+	addresses []int
+	identify  func(c I2cConnection) bool
+}
+
+// knownChips is the order in which chips sharing an address are tried. Chips
+// without an ID register only match by address, so they come last and are
+// only possibly the chip found at their address. Identification only reads
+// registers, it never writes to the devices.
+var knownChips = []chip{
+	{"MPU6050", []int{0x68, 0x69}, registerIs(0x75, 0x68)},
+	{"TCS34725", []int{TCS34725_ADDR}, registerIs(TCS34725_ID_R, 0x44, 0x4d)},
+	{"TSL2591", []int{TSL2591_ADDRESS}, registerIs(TSL2591_COMMAND|TSL2591_NORMAL_OP|0x12, 0x50)},
+	{"TMP007", addressRange(TMP007_ADDRESS, 0x47), wordRegisterIs(0x1f, 0x0078)},
+	{"HMC6352", []int{hmc6352Address}, nil},
+	{"MMA7660", []int{mma7660Address}, nil},
+	{"MPL115A2", []int{mpl115a2Address}, nil},
+	{"MCP23017", addressRange(0x20, 0x27), nil},
+}
+
+// Scan probes the addresses 0x03 to 0x77 of bus, like i2cdetect does, and
+// returns the devices which respond. When identify is true, the chips known
+// to this package are identified by their address and ID registers. An error
+// is returned when no address of the bus can be connected.
+func Scan(a I2cConnector, bus int, identify bool) (devices []ScanResult, err error) {
+	if bus == -1 {
+		bus = a.I2cDefaultBus()
+	}
+
+	devices = []ScanResult{}
+	connected := false
+	for address := 0x03; address <= 0x77; address++ {
+		c, e := a.I2cGetConnection(address, bus)
+		if e != nil {
+			err = e
+			continue
+		}
+		connected = true
+		if !probe(c, address) {
+			continue
+		}
+		device := ScanResult{Address: address}
+		if identify {
+			device.Chip = Identify(c, address)
+		}
+		devices = append(devices, device)
+	}
+
+	if connected {
+		err = nil
+	}
+	return
+}
+
+// Identify returns the name of the known chip at address, or an empty string
+// when the chip is unknown
+func Identify(c I2cConnection, address int) string {
+	for _, k := range knownChips {
+		for _, a := range k.addresses {
+			if a == address && (k.identify == nil || k.identify(c)) {
+				return k.name
+			}
+		}
+	}
+	return ""
+}
+
+// probe returns whether a device responds at address. EEPROMs and write
+// protected chips are probed with a read, others with a quick write.
+func probe(c I2cConnection, address int) bool {
+	p, ok := c.(i2cProber)
+	if !ok {
+		_, err := c.Read(make([]byte, 1))
+		return err == nil
+	}
+	if (address >= 0x30 && address <= 0x37) || (address >= 0x50 && address <= 0x5f) {
+		_, err := p.ReadByte()
+		return err == nil
+	}
+	if p.WriteQuick(0) == nil {
+		return true
+	}
+	// the bus may not support quick writes
+	_, err := p.ReadByte()
+	return err == nil
+}
+
+func registerIs(reg uint8, ids ...byte) func(c I2cConnection) bool {
+	return func(c I2cConnection) bool {
+		b := make([]byte, 1)
+		if _, err := c.ReadRegister(reg, b); err != nil {
+			return false
+		}
+		for _, id := range ids {
+			if b[0] == id {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func wordRegisterIs(reg uint8, id uint16) func(c I2cConnection) bool {
+	return func(c I2cConnection) bool {
+		b := make([]byte, 2)
+		if _, err := c.ReadRegister(reg, b); err != nil {
+			return false
+		}
+		return uint16(b[0])<<8|uint16(b[1]) == id
+	}
+}
+
+func addressRange(from int, to int) (addresses []int) {
+	for address := from; address <= to; address++ {
+		addresses = append(addresses, address)
+	}
+	return
+}
@@ -0,0 +1,123 @@
+package i2c
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+var errNoDevice = errors.New("no device")
+
+// i2cScanConnector answers at the addresses of devices, whose registers
+// hold the given bytes
+type i2cScanConnector struct {
+	devices     map[int]map[uint8][]byte
+	buses       []int
+	connections []*i2cScanConnection
+}
+
+func (s *i2cScanConnector) I2cGetConnection(address int, bus int) (I2cConnection, error) {
+	if bus != 1 {
+		return nil, ErrInvalidBus
+	}
+	s.buses = append(s.buses, bus)
+	c := &i2cScanConnection{registers: s.devices[address]}
+	s.connections = append(s.connections, c)
+	return c, nil
+}
+
+func (s *i2cScanConnector) I2cDefaultBus() int { return 1 }
+
+type i2cScanConnection struct {
+	registers map[uint8][]byte
+	reg       uint8
+	quick     bool
+	written   bool
+}
+
+func (c *i2cScanConnection) WriteQuick(bit uint8) error {
+	c.quick = true
+	if c.registers == nil {
+		return errNoDevice
+	}
+	return nil
+}
+func (c *i2cScanConnection) ReadByte() (byte, error) {
+	if c.registers == nil {
+		return 0, errNoDevice
+	}
+	return 0, nil
+}
+func (c *i2cScanConnection) Read(b []byte) (int, error) {
+	if c.registers == nil {
+		return 0, errNoDevice
+	}
+	return copy(b, c.registers[c.reg]), nil
+}
+func (c *i2cScanConnection) Write(b []byte) (int, error) {
+	if c.registers == nil {
+		return 0, errNoDevice
+	}
+	c.reg = b[0]
+	c.written = true
+	return len(b), nil
+}
+func (c *i2cScanConnection) ReadRegister(reg uint8, b []byte) (int, error) {
+	c.reg = reg
+	return c.Read(b)
+}
+func (c *i2cScanConnection) WriteWord(reg uint8, val uint16) (int, error) {
+	return c.Write([]byte{reg, byte(val), byte(val >> 8)})
+}
+func (c *i2cScanConnection) Tx(w []byte, r []byte) error { return nil }
+func (c *i2cScanConnection) Close() error                { return nil }
+
+func TestI2cScan(t *testing.T) {
+	a := &i2cScanConnector{devices: map[int]map[uint8][]byte{
+		0x20: {},
+		0x21: {},
+		0x29: {TCS34725_ID_R: {0x44}},
+		0x40: {0x1f: {0x00, 0x78}},
+		0x50: {},
+		0x68: {0x75: {0x68}},
+		0x69: {0x75: {0x00}},
+	}}
+
+	devices, err := Scan(a, -1, false)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, len(a.buses), 0x75)
+	gobottest.Assert(t, a.buses[0], 1)
+	gobottest.Assert(t, len(devices), 7)
+	gobottest.Assert(t, devices[0], ScanResult{Address: 0x20})
+
+	devices, err = Scan(a, 1, true)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, devices, []ScanResult{
+		{Address: 0x20, Chip: "MCP23017"},
+		{Address: 0x21, Chip: "HMC6352"},
+		{Address: 0x29, Chip: "TCS34725"},
+		{Address: 0x40, Chip: "TMP007"},
+		{Address: 0x50},
+		{Address: 0x68, Chip: "MPU6050"},
+		{Address: 0x69},
+	})
+
+	// the scan and the identification never write to the devices
+	for _, c := range a.connections {
+		gobottest.Assert(t, c.written, false)
+	}
+
+	_, err = Scan(a, 2, false)
+	gobottest.Assert(t, err, ErrInvalidBus)
+}
+
+func TestI2cIdentify(t *testing.T) {
+	c := &i2cScanConnection{registers: map[uint8][]byte{
+		TSL2591_COMMAND | TSL2591_NORMAL_OP | 0x12: {0x50},
+	}}
+	gobottest.Assert(t, Identify(c, 0x29), "TSL2591")
+	gobottest.Assert(t, Identify(c, 0x4c), "MMA7660")
+	gobottest.Assert(t, Identify(c, 0x60), "MPL115A2")
+	gobottest.Assert(t, Identify(c, 0x10), "")
+}
@@ -1,7 +1,10 @@
 package i2c
 
 import (
-	"fmt"
+	"encoding/binary"
+	"math"
+	"time"
+
 	"github.com/talmai/gobot"
 )
 
@@ -37,26 +40,105 @@ const (
 	TCS34725_GDATAH_R = TCS34725_COMMAND | TCS34725_TYPE_AUTO | 0x19
 	TCS34725_BDATAL_R = TCS34725_COMMAND | TCS34725_TYPE_AUTO | 0x1A
 	TCS34725_BDATAH_R = TCS34725_COMMAND | TCS34725_TYPE_AUTO | 0x1B
+
+	// ENABLE register bits
+	TCS34725_ENABLE_PON = 0x01
+	TCS34725_ENABLE_AEN = 0x02
+
+	// CONTROL register gains
+	TCS34725_GAIN_1X  = 0x00
+	TCS34725_GAIN_4X  = 0x01
+	TCS34725_GAIN_16X = 0x02
+	TCS34725_GAIN_60X = 0x03
 )
 
+// tcs34725Cycle is the duration of an integration cycle of the TCS34725
+const tcs34725Cycle = 2400 * time.Microsecond
+
+// tcs34725LuxDF is the device factor of the TCS34725 lux calculation, for a
+// sensor without glass over it
+const tcs34725LuxDF = 310.0
+
+// tcs34725Gains are the factors of the CONTROL register gains
+var tcs34725Gains = map[uint8]float64{
+	TCS34725_GAIN_1X:  1,
+	TCS34725_GAIN_4X:  4,
+	TCS34725_GAIN_16X: 16,
+	TCS34725_GAIN_60X: 60,
+}
+
+// Tcs34725Reading is a reading of the TCS34725. The colour temperature is in
+// Kelvin and the illuminance in lux.
+type Tcs34725Reading struct {
+	Clear            uint16
+	Red              uint16
+	Green            uint16
+	Blue             uint16
+	ColorTemperature float64
+	Lux              float64
+}
+
 type Tcs34725Driver struct {
-	name       string
-	connection I2cExtended
+	name            string
+	connection      I2c
+	device          I2cConnection
+	interval        time.Duration
+	halt            chan bool
+	gain            uint8
+	integrationTime time.Duration
+	Config
+	gobot.Eventer
 	gobot.Commander
 	initialized bool
 }
 
-func NewTcs34725Driver(i I2cExtended, name string) *Tcs34725Driver {
+// NewTcs34725Driver creates a new driver with specified name and i2c interface.
+//
+// Optionally accepts:
+//
+//	time.Duration: interval at which the driver reads the sensor, defaults to 1s
+//	Option: WithBus or WithAddress
+//
+// Adds the following API Commands:
+//
+//	"ColorSensorInput" - See Tcs34725Driver.ReadSensor
+func NewTcs34725Driver(i I2c, name string, v ...interface{}) *Tcs34725Driver {
 	b := &Tcs34725Driver{
-		name:        name,
-		connection:  i,
-		Commander:   gobot.NewCommander(),
-		initialized: false,
+		name:            name,
+		connection:      i,
+		Config:          newConfig(TCS34725_ADDR),
+		Eventer:         gobot.NewEventer(),
+		Commander:       gobot.NewCommander(),
+		interval:        1 * time.Second,
+		halt:            make(chan bool),
+		gain:            TCS34725_GAIN_1X,
+		integrationTime: 256 * tcs34725Cycle,
+		initialized:     false,
+	}
+
+	for _, arg := range v {
+		switch arg := arg.(type) {
+		case time.Duration:
+			b.interval = arg
+		case Option:
+			arg(&b.Config)
+		}
 	}
 
+	b.AddEvent(Data)
+	b.AddEvent(Error)
+
 	b.AddCommand("ColorSensorInput", func(params map[string]interface{}) interface{} {
-		redData, greenData, blueData, err := b.ReadSensor()
-		return map[string]interface{}{"Red": fmt.Sprintf("%X", redData), "Green": fmt.Sprintf("%X", greenData), "Blue": fmt.Sprintf("%X", blueData), "err": err}
+		reading, err := b.ReadSensor()
+		return map[string]interface{}{
+			"Clear":            reading.Clear,
+			"Red":              reading.Red,
+			"Green":            reading.Green,
+			"Blue":             reading.Blue,
+			"ColorTemperature": reading.ColorTemperature,
+			"Lux":              reading.Lux,
+			"err":              err,
+		}
 	})
 
 	return b
@@ -65,67 +147,144 @@ func NewTcs34725Driver(i I2cExtended, name string) *Tcs34725Driver {
 func (b *Tcs34725Driver) Name() string                 { return b.name }
 func (b *Tcs34725Driver) Connection() gobot.Connection { return b.connection.(gobot.Connection) }
 
-// Start writes start bytes
+// Start powers on the sensor and reads it at the driver interval.
+// Emits the Events:
+//
+//	Data Tcs34725Reading - the reading of the sensor
+//	Error error - error reading the sensor
 func (b *Tcs34725Driver) Start() (errs []error) {
-	if !b.initialized {
-		// Enable sensor
-		b.connection.I2cWriteWord(TCS34725_ADDR, TCS34725_ENABLE_RW, 0x03)
-		// Set integration time to be 700 ms
-		b.connection.I2cWriteWord(TCS34725_ADDR, TCS34725_ATIME_RW, 0x00)
-		// Set the gain to be 1x
-		b.connection.I2cWriteWord(TCS34725_ADDR, TCS34725_CONTROL_RW, 0x00)
+	if b.initialized {
+		return
+	}
+	device, err := b.connect(b.connection, b)
+	if err != nil {
+		return []error{err}
+	}
+	b.device = device
+	if err := b.initialization(); err != nil {
+		return []error{err}
+	}
+	b.initialized = true
+
+	go func() {
+		for {
+			if reading, err := b.ReadSensor(); err != nil {
+				gobot.Publish(b.Event(Error), err)
+			} else {
+				gobot.Publish(b.Event(Data), reading)
+			}
+			select {
+			case <-time.After(b.interval):
+			case <-b.halt:
+				return
+			}
+		}
+	}()
+	return
+}
 
-		b.initialized = true
+// Halt stops reading the sensor
+func (b *Tcs34725Driver) Halt() (errs []error) {
+	if b.initialized {
+		b.halt <- true
+		b.initialized = false
 	}
+	releaseAddresses(b.connection, b)
 	return
 }
 
-// Halt returns true if device is halted successfully
-func (b *Tcs34725Driver) Halt() (errs []error) { return }
+func (b *Tcs34725Driver) initialization() (err error) {
+	if err = b.writeRegister(TCS34725_ENABLE_RW, TCS34725_ENABLE_PON); err != nil {
+		return
+	}
+	// the oscillator needs 2.4ms before the ADC can be enabled
+	<-time.After(3 * time.Millisecond)
+	if err = b.writeRegister(TCS34725_ENABLE_RW, TCS34725_ENABLE_PON|TCS34725_ENABLE_AEN); err != nil {
+		return
+	}
+	if err = b.writeRegister(TCS34725_ATIME_RW, b.atime()); err != nil {
+		return
+	}
+	return b.writeRegister(TCS34725_CONTROL_RW, b.gain)
+}
+
+// SetGain sets the gain of the sensor to one of TCS34725_GAIN_1X,
+// TCS34725_GAIN_4X, TCS34725_GAIN_16X or TCS34725_GAIN_60X
+func (b *Tcs34725Driver) SetGain(gain uint8) (err error) {
+	if gain > TCS34725_GAIN_60X {
+		return ErrInvalidGain
+	}
+	b.gain = gain
+	if b.device == nil {
+		return
+	}
+	return b.writeRegister(TCS34725_CONTROL_RW, gain)
+}
 
-// Color Sensor input
-func (b *Tcs34725Driver) ReadSensor() (redB []byte, greenB []byte, blueB []byte, errs []error) {
+// SetIntegrationTime sets the integration time of the sensor, between 2.4ms
+// and 614.4ms in steps of 2.4ms. Longer times are more sensitive.
+func (b *Tcs34725Driver) SetIntegrationTime(t time.Duration) (err error) {
+	if t < tcs34725Cycle || t > 256*tcs34725Cycle {
+		return ErrInvalidTime
+	}
+	b.integrationTime = t / tcs34725Cycle * tcs34725Cycle
+	if b.device == nil {
+		return
+	}
+	return b.writeRegister(TCS34725_ATIME_RW, b.atime())
+}
 
-	// Read red data
-	redData, errRed := b.connection.I2cReadRegister([]byte{TCS34725_ADDR, TCS34725_RDATAL_R}, 2)
-	fmt.Printf("redData %X [%X]\n", redData, errRed)
+// atime returns the ATIME register value of the integration time
+func (b *Tcs34725Driver) atime() uint8 {
+	return uint8(256 - b.integrationTime/tcs34725Cycle)
+}
 
-	// Read green data
-	greenData, errGreen := b.connection.I2cReadRegister([]byte{TCS34725_ADDR, TCS34725_GDATAL_R}, 2)
-	fmt.Printf("greenData %X [%X]\n", greenData, errRed)
+func (b *Tcs34725Driver) writeRegister(reg uint8, value uint8) (err error) {
+	_, err = b.device.Write([]byte{reg, value})
+	return
+}
 
-	// Read blue data
-	blueData, errBlue := b.connection.I2cReadRegister([]byte{TCS34725_ADDR, TCS34725_BDATAL_R}, 2)
-	fmt.Printf("blueData %X [%X]\n", blueData, errRed)
+// ReadSensor returns the clear, red, green and blue counts of the sensor
+// along with the colour temperature and illuminance they amount to
+func (b *Tcs34725Driver) ReadSensor() (reading Tcs34725Reading, err error) {
+	if b.device == nil {
+		return reading, ErrNotReady
+	}
 
-	return redData, greenData, blueData, []error{errRed, errGreen, errBlue}
+	data := make([]byte, 8)
+	if _, err = b.device.ReadRegister(TCS34725_CDATAL_R, data); err != nil {
+		return
+	}
+	reading.Clear = binary.LittleEndian.Uint16(data[0:])
+	reading.Red = binary.LittleEndian.Uint16(data[2:])
+	reading.Green = binary.LittleEndian.Uint16(data[4:])
+	reading.Blue = binary.LittleEndian.Uint16(data[6:])
+	reading.ColorTemperature, reading.Lux = tcs34725Convert(reading.Red, reading.Green, reading.Blue, b.gain, b.integrationTime)
+	return
 }
 
-/*
-
-def init():
-    # Enable sensor
-    bus.write_byte_data(TCS34725_ADDR, TCS34725_ENABLE_RW, 0x03)
-    # Set integration time to be 700 ms
-    bus.write_byte_data(TCS34725_ADDR, TCS34725_ATIME_RW, 0x00)
-    # Set the gain to be 1x
-    bus.write_byte_data(TCS34725_ADDR, TCS34725_CONTROL_RW, 0x00)
-
-def read_lux():
-    # Read red data
-    r = bus.read_word_data(TCS34725_ADDR, TCS34725_RDATAL_R)
-    # Read green data
-    g = bus.read_word_data(TCS34725_ADDR, TCS34725_GDATAL_R)
-    # Read blue data
-    b = bus.read_word_data(TCS34725_ADDR, TCS34725_BDATAL_R)
-    # Calculated lux value based on RGB data
-    lux = (-0.32466 * r) + (1.57837 * g) + (-0.73191 * b)
-
-    # print "red is: " + r + "\nblue is : " + b + "\ngreen is: " + g + "\nlux is: " + lux
-
-    if lux < 0:
-        return 0
-    else:
-        return lux
-
-*/
+// tcs34725Convert returns the correlated colour temperature and illuminance
+// of the red, green and blue counts taken at gain and integration time t, by
+// way of the CIE XYZ colour space and McCamy's formula
+func tcs34725Convert(red, green, blue uint16, gain uint8, t time.Duration) (cct float64, lux float64) {
+	r, g, b := float64(red), float64(green), float64(blue)
+
+	x := -0.14282*r + 1.54924*g - 0.95641*b
+	y := -0.32466*r + 1.57837*g - 0.73191*b
+	z := -0.68202*r + 0.77073*g + 0.56332*b
+
+	// counts per lux
+	cpl := t.Seconds() * 1000 * tcs34725Gains[gain] / tcs34725LuxDF
+	lux = math.Max(y, 0) / cpl
+	if x+y+z == 0 {
+		return
+	}
+	xc := x / (x + y + z)
+	yc := y / (x + y + z)
+	if yc == 0.1858 {
+		return
+	}
+	n := (xc - 0.3320) / (0.1858 - yc)
+	cct = 449.0*math.Pow(n, 3) + 3525.0*math.Pow(n, 2) + 6823.3*n + 5520.33
+	return
+}
@@ -0,0 +1,111 @@
+package i2c
+
+import (
+	"errors"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// --------- HELPERS
+func initTestTcs34725DriverWithStubbedAdaptor() (*Tcs34725Driver, *i2cTestAdaptor) {
+	adaptor := newI2cTestAdaptor("adaptor")
+	return NewTcs34725Driver(adaptor, "bot"), adaptor
+}
+
+// --------- TESTS
+
+func TestNewTcs34725Driver(t *testing.T) {
+	tcs := NewTcs34725Driver(newI2cTestAdaptor("adaptor"), "bot")
+	gobottest.Assert(t, tcs.Name(), "bot")
+	gobottest.Assert(t, tcs.Connection().Name(), "adaptor")
+	gobottest.Assert(t, tcs.interval, 1*time.Second)
+	gobottest.Assert(t, tcs.Address(), TCS34725_ADDR)
+
+	tcs = NewTcs34725Driver(newI2cTestAdaptor("adaptor"), "bot", 100*time.Millisecond, WithBus(1))
+	gobottest.Assert(t, tcs.interval, 100*time.Millisecond)
+	gobottest.Assert(t, tcs.Bus(), 1)
+}
+
+func TestTcs34725DriverStart(t *testing.T) {
+	tcs, adaptor := initTestTcs34725DriverWithStubbedAdaptor()
+	tcs.interval = 1 * time.Millisecond
+	var writes int32
+	adaptor.i2cWriteImpl = func() error {
+		atomic.AddInt32(&writes, 1)
+		return nil
+	}
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return []byte{0x00, 0x01, 100, 0, 200, 0, 50, 0}, nil
+	}
+
+	sem := make(chan Tcs34725Reading)
+	gobot.Once(tcs.Event(Data), func(data interface{}) {
+		sem <- data.(Tcs34725Reading)
+	})
+	gobottest.Assert(t, len(tcs.Start()), 0)
+	// enable, enable, integration time and gain
+	gobottest.Assert(t, atomic.LoadInt32(&writes) >= 4, true)
+
+	select {
+	case reading := <-sem:
+		gobottest.Assert(t, reading.Clear, uint16(0x0100))
+		gobottest.Assert(t, reading.Red, uint16(100))
+		gobottest.Assert(t, reading.Green, uint16(200))
+		gobottest.Assert(t, reading.Blue, uint16(50))
+		gobottest.Assert(t, math.Abs(reading.Lux-124.430135) < 1e-6, true)
+		gobottest.Assert(t, math.Abs(reading.ColorTemperature-3579.1526) < 1e-3, true)
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Tcs34725 data event not published")
+	}
+	gobottest.Assert(t, len(tcs.Halt()), 0)
+
+	tcs, adaptor = initTestTcs34725DriverWithStubbedAdaptor()
+	adaptor.i2cWriteImpl = func() error {
+		return errors.New("write error")
+	}
+	gobottest.Assert(t, tcs.Start()[0], errors.New("write error"))
+}
+
+func TestTcs34725DriverSettings(t *testing.T) {
+	tcs, _ := initTestTcs34725DriverWithStubbedAdaptor()
+
+	gobottest.Assert(t, tcs.SetGain(TCS34725_GAIN_16X), nil)
+	gobottest.Assert(t, tcs.gain, uint8(TCS34725_GAIN_16X))
+	gobottest.Assert(t, tcs.SetGain(0x04), ErrInvalidGain)
+
+	gobottest.Assert(t, tcs.atime(), uint8(0x00))
+	gobottest.Assert(t, tcs.SetIntegrationTime(24*time.Millisecond), nil)
+	gobottest.Assert(t, tcs.atime(), uint8(0xf6))
+	gobottest.Assert(t, tcs.SetIntegrationTime(time.Second), ErrInvalidTime)
+
+	_, err := tcs.ReadSensor()
+	gobottest.Assert(t, err, ErrNotReady)
+}
+
+func TestTcs34725Convert(t *testing.T) {
+	cct, lux := tcs34725Convert(0, 0, 0, TCS34725_GAIN_1X, 256*tcs34725Cycle)
+	gobottest.Assert(t, cct, 0.0)
+	gobottest.Assert(t, lux, 0.0)
+
+	_, lux = tcs34725Convert(1000, 0, 1000, TCS34725_GAIN_1X, 256*tcs34725Cycle)
+	gobottest.Assert(t, lux, 0.0)
+
+	// the counts scale with the gain and the integration time, the
+	// illuminance does not
+	for gain, factor := range map[uint8]uint16{
+		TCS34725_GAIN_1X:  1,
+		TCS34725_GAIN_4X:  4,
+		TCS34725_GAIN_16X: 16,
+		TCS34725_GAIN_60X: 60,
+	} {
+		_, lux = tcs34725Convert(100*factor, 200*factor, 50*factor, gain, 256*tcs34725Cycle)
+		gobottest.Assert(t, math.Abs(lux-124.430135) < 1e-6, true)
+	}
+	_, lux = tcs34725Convert(50, 100, 25, TCS34725_GAIN_1X, 128*tcs34725Cycle)
+	gobottest.Assert(t, math.Abs(lux-124.430135) < 1e-6, true)
+}
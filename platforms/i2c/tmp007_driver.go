@@ -1,7 +1,9 @@
 package i2c
 
 import (
-	"fmt"
+	"encoding/binary"
+	"time"
+
 	"github.com/talmai/gobot"
 )
 
@@ -12,26 +14,77 @@ const (
 	TMP007_ADDRESS            = 0x40
 	TMP007_LOCAL_TEMPERATURE  = 0x01
 	TMP007_OBJECT_TEMPERATURE = 0x03
+	TMP007_CONFIGURATION      = 0x02
+
+	// CONFIGURATION register bits
+	TMP007_CFG_MODEON = 0x1000
+
+	// CONFIGURATION register conversion rates, the number of samples averaged
+	// by each conversion
+	TMP007_CFG_1SAMPLE  = 0x0000 // 0.26s
+	TMP007_CFG_2SAMPLE  = 0x0200 // 0.51s
+	TMP007_CFG_4SAMPLE  = 0x0400 // 1.01s
+	TMP007_CFG_8SAMPLE  = 0x0600 // 2.01s
+	TMP007_CFG_16SAMPLE = 0x0800 // 4.01s
 )
 
+// Tmp007Reading is a reading of the TMP007, in degrees Celsius
+type Tmp007Reading struct {
+	Die    float64
+	Object float64
+}
+
 type Tmp007Driver struct {
 	name       string
-	connection I2cExtended
+	connection I2c
+	device     I2cConnection
+	interval   time.Duration
+	halt       chan bool
+	samples    uint16
+	Config
+	gobot.Eventer
 	gobot.Commander
 	initialized bool
 }
 
-func NewTmp007Driver(i I2cExtended, name string) *Tmp007Driver {
+// NewTmp007Driver creates a new driver with specified name and i2c interface.
+//
+// Optionally accepts:
+//
+//	time.Duration: interval at which the driver reads the sensor, defaults to 1s
+//	Option: WithBus or WithAddress
+//
+// Adds the following API Commands:
+//
+//	"ThermopileInput" - See Tmp007Driver.ReadSensor
+func NewTmp007Driver(i I2c, name string, v ...interface{}) *Tmp007Driver {
 	b := &Tmp007Driver{
 		name:        name,
 		connection:  i,
+		Config:      newConfig(TMP007_ADDRESS),
+		Eventer:     gobot.NewEventer(),
 		Commander:   gobot.NewCommander(),
+		interval:    1 * time.Second,
+		halt:        make(chan bool),
+		samples:     TMP007_CFG_4SAMPLE,
 		initialized: false,
 	}
 
+	for _, arg := range v {
+		switch arg := arg.(type) {
+		case time.Duration:
+			b.interval = arg
+		case Option:
+			arg(&b.Config)
+		}
+	}
+
+	b.AddEvent(Data)
+	b.AddEvent(Error)
+
 	b.AddCommand("ThermopileInput", func(params map[string]interface{}) interface{} {
-		local, object, err := b.ReadSensor()
-		return map[string]interface{}{"local": fmt.Sprintf("%X", local), "object": fmt.Sprintf("%X", object), "err": err}
+		reading, err := b.ReadSensor()
+		return map[string]interface{}{"local": reading.Die, "object": reading.Object, "err": err}
 	})
 
 	return b
@@ -40,48 +93,105 @@ func NewTmp007Driver(i I2cExtended, name string) *Tmp007Driver {
 func (b *Tmp007Driver) Name() string                 { return b.name }
 func (b *Tmp007Driver) Connection() gobot.Connection { return b.connection.(gobot.Connection) }
 
-// Start writes start bytes
+// Start configures the sensor and reads it at the driver interval.
+// Emits the Events:
+//
+//	Data Tmp007Reading - the reading of the sensor
+//	Error error - error reading the sensor
 func (b *Tmp007Driver) Start() (errs []error) {
+	if b.initialized {
+		return
+	}
+	device, err := b.connect(b.connection, b)
+	if err != nil {
+		return []error{err}
+	}
+	b.device = device
+	if err := b.writeConfig(); err != nil {
+		return []error{err}
+	}
 	b.initialized = true
+
+	go func() {
+		for {
+			if reading, err := b.ReadSensor(); err != nil {
+				gobot.Publish(b.Event(Error), err)
+			} else {
+				gobot.Publish(b.Event(Data), reading)
+			}
+			select {
+			case <-time.After(b.interval):
+			case <-b.halt:
+				return
+			}
+		}
+	}()
 	return
 }
 
-// Halt returns true if device is halted successfully
-func (b *Tmp007Driver) Halt() (errs []error) { return }
-
-// Digital input
-func (b *Tmp007Driver) ReadSensor() (local []byte, object []byte, errs []error) {
-	localTemp, errLocal := b.connection.I2cReadRegister([]byte{TMP007_ADDRESS, TMP007_LOCAL_TEMPERATURE}, 2)
-	//	v := fmt.Fscanf(b.swap16(localTemp), "%f", &v)
-	//	fmt.Printf("The local temperature is:  [%X] %X degree C\n", localTemp, v/128.0)
-	// fmt.Printf("local %X \n", localTemp)
-
-	objectTemp, errObject := b.connection.I2cReadRegister([]byte{TMP007_ADDRESS, TMP007_OBJECT_TEMPERATURE}, 2)
-	// v = fmt.Fscanf(b.swap16(objectTemp), "%f", &v)
-	// fmt.Printf("The object temperature is:  [%X] %X degree C\n", objectTemp, v/128.0)
-	// fmt.Printf("object %X \n", objectTemp)
+// Halt stops reading the sensor
+func (b *Tmp007Driver) Halt() (errs []error) {
+	if b.initialized {
+		b.halt <- true
+		b.initialized = false
+	}
+	releaseAddresses(b.connection, b)
+	return
+}
 
-	return localTemp, objectTemp, []error{errLocal, errObject}
+// SetSamples sets the number of samples averaged by each conversion to one of
+// TMP007_CFG_1SAMPLE to TMP007_CFG_16SAMPLE. More samples lower the noise of
+// the readings and the rate at which they change.
+func (b *Tmp007Driver) SetSamples(samples uint16) (err error) {
+	if samples&^0x0e00 != 0 || samples > TMP007_CFG_16SAMPLE {
+		return ErrInvalidSamples
+	}
+	b.samples = samples
+	return b.writeConfig()
 }
 
-/*
-// Swap bytes to change endianness
-func (b *Tmp007Driver) swap16(val []byte) (data []byte) {
-	return ((val & 0xFF) << 8) | ((val >> 8) & 0xFF)
+// writeConfig writes the conversion rate once the driver is connected
+func (b *Tmp007Driver) writeConfig() (err error) {
+	if b.device == nil {
+		return
+	}
+	config := TMP007_CFG_MODEON | b.samples
+	_, err = b.device.Write([]byte{TMP007_CONFIGURATION, byte(config >> 8), byte(config)})
+	return
 }
-*/
 
-/*
+// ReadSensor returns the die and object temperatures. ErrNotReady is
+// returned when the object temperature is not valid.
+func (b *Tmp007Driver) ReadSensor() (reading Tmp007Reading, err error) {
+	if b.device == nil {
+		return reading, ErrNotReady
+	}
 
-// Read TMP007 thermopile sensor
-function readTempopile() {
-    var localTemp = i2c.readWordSync(TMP007_ADDR, TMP007_LOCAL_TEMP);
-    localTemp = (swap16(localTemp))/128.0;
-    console.log("The local temperature is:  " + localTemp.toString() + " degree C");
+	die, err := b.readTemperature(TMP007_LOCAL_TEMPERATURE)
+	if err != nil {
+		return
+	}
+	object, err := b.readTemperature(TMP007_OBJECT_TEMPERATURE)
+	if err != nil {
+		return
+	}
+	// the lowest bit of the object temperature flags invalid data
+	if object&0x0001 != 0 {
+		return reading, ErrNotReady
+	}
+	return Tmp007Reading{Die: tmp007Celsius(die), Object: tmp007Celsius(object)}, nil
+}
 
-    var objectTemp = i2c.readWordSync(TMP007_ADDR, TMP007_OBJ_TEMP);
-    objectTemp = (swap16(objectTemp))/128.0;
-    console.log("The object temperature is: " + objectTemp.toString() + " degree C");
+func (b *Tmp007Driver) readTemperature(reg uint8) (value uint16, err error) {
+	data := make([]byte, 2)
+	if _, err = b.device.ReadRegister(reg, data); err != nil {
+		return
+	}
+	return binary.BigEndian.Uint16(data), nil
 }
 
-*/
+// tmp007Celsius converts a temperature register, a signed 14 bit value in
+// 1/32 degrees left aligned in 16 bits
+func tmp007Celsius(value uint16) float64 {
+	return float64(int16(value)>>2) / 32.0
+}
@@ -0,0 +1,68 @@
+package i2c
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// --------- HELPERS
+func initTestTmp007DriverWithStubbedAdaptor() (*Tmp007Driver, *i2cTestAdaptor) {
+	adaptor := newI2cTestAdaptor("adaptor")
+	return NewTmp007Driver(adaptor, "bot"), adaptor
+}
+
+// --------- TESTS
+
+func TestNewTmp007Driver(t *testing.T) {
+	tmp := NewTmp007Driver(newI2cTestAdaptor("adaptor"), "bot", 100*time.Millisecond)
+	gobottest.Assert(t, tmp.Name(), "bot")
+	gobottest.Assert(t, tmp.Connection().Name(), "adaptor")
+	gobottest.Assert(t, tmp.interval, 100*time.Millisecond)
+	gobottest.Assert(t, tmp.Address(), TMP007_ADDRESS)
+}
+
+func TestTmp007DriverStart(t *testing.T) {
+	tmp, adaptor := initTestTmp007DriverWithStubbedAdaptor()
+	tmp.interval = 1 * time.Millisecond
+	// 25 degrees
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return []byte{0x0c, 0x80}, nil
+	}
+
+	sem := make(chan Tmp007Reading)
+	gobot.Once(tmp.Event(Data), func(data interface{}) {
+		sem <- data.(Tmp007Reading)
+	})
+	gobottest.Assert(t, len(tmp.Start()), 0)
+
+	select {
+	case reading := <-sem:
+		gobottest.Assert(t, reading, Tmp007Reading{Die: 25, Object: 25})
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Tmp007 data event not published")
+	}
+	gobottest.Assert(t, len(tmp.Halt()), 0)
+
+	// invalid object temperature
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return []byte{0x0c, 0x81}, nil
+	}
+	_, err := tmp.ReadSensor()
+	gobottest.Assert(t, err, ErrNotReady)
+}
+
+func TestTmp007DriverSettings(t *testing.T) {
+	tmp, _ := initTestTmp007DriverWithStubbedAdaptor()
+	gobottest.Assert(t, tmp.SetSamples(TMP007_CFG_16SAMPLE), nil)
+	gobottest.Assert(t, tmp.SetSamples(0x0a00), ErrInvalidSamples)
+	gobottest.Assert(t, tmp.SetSamples(0x0001), ErrInvalidSamples)
+}
+
+func TestTmp007Celsius(t *testing.T) {
+	gobottest.Assert(t, tmp007Celsius(0x0c80), 25.0)
+	gobottest.Assert(t, tmp007Celsius(0xff80), -1.0)
+	gobottest.Assert(t, tmp007Celsius(0x0000), 0.0)
+}
@@ -1,7 +1,9 @@
 package i2c
 
 import (
-	"fmt"
+	"encoding/binary"
+	"time"
+
 	"github.com/talmai/gobot"
 )
 
@@ -16,26 +18,105 @@ const (
 	TSL2591_CONFIG_RW  = TSL2591_COMMAND | TSL2591_NORMAL_OP | 0x01
 	TSL2591_C0_DATA_LR = TSL2591_COMMAND | TSL2591_NORMAL_OP | 0x14
 	TSL2591_C1_DATA_LR = TSL2591_COMMAND | TSL2591_NORMAL_OP | 0x16
+
+	// ENABLE register bits
+	TSL2591_ENABLE_PON = 0x01
+	TSL2591_ENABLE_AEN = 0x02
+
+	// CONFIG register gains
+	TSL2591_GAIN_LOW  = 0x00 // 1x
+	TSL2591_GAIN_MED  = 0x10 // 25x
+	TSL2591_GAIN_HIGH = 0x20 // 428x
+	TSL2591_GAIN_MAX  = 0x30 // 9876x
+
+	// CONFIG register integration times
+	TSL2591_INTEGRATIONTIME_100MS = 0x00
+	TSL2591_INTEGRATIONTIME_200MS = 0x01
+	TSL2591_INTEGRATIONTIME_300MS = 0x02
+	TSL2591_INTEGRATIONTIME_400MS = 0x03
+	TSL2591_INTEGRATIONTIME_500MS = 0x04
+	TSL2591_INTEGRATIONTIME_600MS = 0x05
 )
 
+// tsl2591LuxDF is the device factor of the TSL2591 lux calculation
+const tsl2591LuxDF = 408.0
+
+// tsl2591Gains are the factors of the CONFIG register gains
+var tsl2591Gains = map[uint8]float64{
+	TSL2591_GAIN_LOW:  1,
+	TSL2591_GAIN_MED:  25,
+	TSL2591_GAIN_HIGH: 428,
+	TSL2591_GAIN_MAX:  9876,
+}
+
+// Tsl2591Reading is a reading of the TSL2591. Visible is the full spectrum
+// count without the infrared count, and Lux the illuminance in lux.
+type Tsl2591Reading struct {
+	FullSpectrum uint16
+	Infrared     uint16
+	Visible      uint16
+	Lux          float64
+}
+
 type Tsl2591Driver struct {
-	name       string
-	connection I2cExtended
+	name            string
+	connection      I2c
+	device          I2cConnection
+	interval        time.Duration
+	halt            chan bool
+	gain            uint8
+	integrationTime uint8
+	Config
+	gobot.Eventer
 	gobot.Commander
 	initialized bool
 }
 
-func NewTsl2591Driver(i I2cExtended, name string) *Tsl2591Driver {
+// NewTsl2591Driver creates a new driver with specified name and i2c interface.
+//
+// Optionally accepts:
+//
+//	time.Duration: interval at which the driver reads the sensor, defaults to 1s
+//	Option: WithBus or WithAddress
+//
+// Adds the following API Commands:
+//
+//	"LuxInput" - See Tsl2591Driver.ReadSensor
+func NewTsl2591Driver(i I2c, name string, v ...interface{}) *Tsl2591Driver {
 	b := &Tsl2591Driver{
-		name:        name,
-		connection:  i,
-		Commander:   gobot.NewCommander(),
-		initialized: false,
+		name:            name,
+		connection:      i,
+		Config:          newConfig(TSL2591_ADDRESS),
+		Eventer:         gobot.NewEventer(),
+		Commander:       gobot.NewCommander(),
+		interval:        1 * time.Second,
+		halt:            make(chan bool),
+		gain:            TSL2591_GAIN_LOW,
+		integrationTime: TSL2591_INTEGRATIONTIME_400MS,
+		initialized:     false,
+	}
+
+	for _, arg := range v {
+		switch arg := arg.(type) {
+		case time.Duration:
+			b.interval = arg
+		case Option:
+			arg(&b.Config)
+		}
 	}
 
+	b.AddEvent(Data)
+	b.AddEvent(Error)
+
 	b.AddCommand("LuxInput", func(params map[string]interface{}) interface{} {
-		ambIR, iR, err := b.ReadSensor()
-		return map[string]interface{}{"AmbientIR": fmt.Sprintf("%X", ambIR), "IR": fmt.Sprintf("%X", iR), "err": err}
+		reading, err := b.ReadSensor()
+		return map[string]interface{}{
+			"FullSpectrum": reading.FullSpectrum,
+			"Infrared":     reading.Infrared,
+			"Visible":      reading.Visible,
+			"Lux":          reading.Lux,
+			"err":          err,
+		}
 	})
 
 	return b
@@ -44,52 +125,131 @@ func NewTsl2591Driver(i I2cExtended, name string) *Tsl2591Driver {
 func (b *Tsl2591Driver) Name() string                 { return b.name }
 func (b *Tsl2591Driver) Connection() gobot.Connection { return b.connection.(gobot.Connection) }
 
-// Start writes start bytes
+// Start powers on the sensor and reads it at the driver interval.
+// Emits the Events:
+//
+//	Data Tsl2591Reading - the reading of the sensor
+//	Error error - error reading the sensor, ErrSaturated when the gain or
+//	integration time is too high for the light
 func (b *Tsl2591Driver) Start() (errs []error) {
-	if !b.initialized {
-		b.connection.I2cWriteWord(TSL2591_ADDRESS, TSL2591_ENABLE_RW, 0x03)
-		// TSL2591_CONFIG_RW = 0x11: Medium gain mode, 200ms integration time
-		//		b.connection.I2cWriteWord(TSL2591_ADDRESS, TSL2591_CONFIG_RW, 0x11)
-		b.connection.I2cWriteWord(TSL2591_ADDRESS, TSL2591_CONFIG_RW, 0x03)
+	if b.initialized {
+		return
+	}
+	device, err := b.connect(b.connection, b)
+	if err != nil {
+		return []error{err}
+	}
+	b.device = device
+	if err := b.writeRegister(TSL2591_ENABLE_RW, TSL2591_ENABLE_PON|TSL2591_ENABLE_AEN); err != nil {
+		return []error{err}
+	}
+	if err := b.writeConfig(); err != nil {
+		return []error{err}
+	}
+	b.initialized = true
+
+	go func() {
+		for {
+			if reading, err := b.ReadSensor(); err != nil {
+				gobot.Publish(b.Event(Error), err)
+			} else {
+				gobot.Publish(b.Event(Data), reading)
+			}
+			select {
+			case <-time.After(b.interval):
+			case <-b.halt:
+				return
+			}
+		}
+	}()
+	return
+}
 
-		b.initialized = true
+// Halt stops reading the sensor
+func (b *Tsl2591Driver) Halt() (errs []error) {
+	if b.initialized {
+		b.halt <- true
+		b.initialized = false
 	}
+	releaseAddresses(b.connection, b)
 	return
 }
 
-// Halt returns true if device is halted successfully
-func (b *Tsl2591Driver) Halt() (errs []error) { return }
+// SetGain sets the gain of the sensor to one of TSL2591_GAIN_LOW,
+// TSL2591_GAIN_MED, TSL2591_GAIN_HIGH or TSL2591_GAIN_MAX
+func (b *Tsl2591Driver) SetGain(gain uint8) (err error) {
+	if _, ok := tsl2591Gains[gain]; !ok {
+		return ErrInvalidGain
+	}
+	b.gain = gain
+	return b.writeConfig()
+}
+
+// SetIntegrationTime sets the integration time of the sensor to one of
+// TSL2591_INTEGRATIONTIME_100MS to TSL2591_INTEGRATIONTIME_600MS
+func (b *Tsl2591Driver) SetIntegrationTime(t uint8) (err error) {
+	if t > TSL2591_INTEGRATIONTIME_600MS {
+		return ErrInvalidTime
+	}
+	b.integrationTime = t
+	return b.writeConfig()
+}
 
-// Digital input
-func (b *Tsl2591Driver) ReadSensor() (local []byte, object []byte, errs []error) {
-	ambIR, errAmbIR := b.connection.I2cReadRegister([]byte{TSL2591_ADDRESS, TSL2591_C0_DATA_LR}, 2)
-	fmt.Printf("ambIR %X \n", ambIR)
+// writeConfig writes the gain and integration time once the driver is
+// connected
+func (b *Tsl2591Driver) writeConfig() (err error) {
+	if b.device == nil {
+		return
+	}
+	return b.writeRegister(TSL2591_CONFIG_RW, b.gain|b.integrationTime)
+}
 
-	iR, errIR := b.connection.I2cReadRegister([]byte{TSL2591_ADDRESS, TSL2591_C1_DATA_LR}, 2)
-	fmt.Printf("ir %X \n", iR)
+func (b *Tsl2591Driver) writeRegister(reg uint8, value uint8) (err error) {
+	_, err = b.device.Write([]byte{reg, value})
+	return
+}
 
-	return ambIR, iR, []error{errAmbIR, errIR}
+// ReadSensor returns the full spectrum and infrared counts of the sensor,
+// along with the illuminance they amount to
+func (b *Tsl2591Driver) ReadSensor() (reading Tsl2591Reading, err error) {
+	if b.device == nil {
+		return reading, ErrNotReady
+	}
+
+	data := make([]byte, 4)
+	if _, err = b.device.ReadRegister(TSL2591_C0_DATA_LR, data); err != nil {
+		return
+	}
+	reading.FullSpectrum = binary.LittleEndian.Uint16(data[0:])
+	reading.Infrared = binary.LittleEndian.Uint16(data[2:])
+	max := tsl2591MaxCount(b.integrationTime)
+	if reading.FullSpectrum >= max || reading.Infrared >= max {
+		return reading, ErrSaturated
+	}
+	if reading.Infrared < reading.FullSpectrum {
+		reading.Visible = reading.FullSpectrum - reading.Infrared
+	}
+	reading.Lux = tsl2591Lux(reading.FullSpectrum, reading.Infrared, b.gain, b.integrationTime)
+	return
 }
 
-/*
-
-// Read TSL2591 ALS
-function readAls() {
-    var dataCh0 = i2c.readWordSync(TSL2591_ADDR, TSL2591_C0DATAL_R);
-    console.log("Ambient light and IR");
-    console.log(dataCh0);
-    //localTemp = (swap16(localTemp))/128.0;
-
-    var dataCh1 = i2c.readWordSync(TSL2591_ADDR, TSL2591_C1DATAL_R);
-    console.log("IR only");
-    console.log(dataCh1);
-    //localTemp = (swap16(localTemp))/128.0;
-
-    // CPL = Count per lux = integration time / gain
-    cpl = 200/25.0;
-    // Calculate lux
-    lux = (dataCh0 - 2*dataCh1)/cpl;
-    console.log("The detected ambient light is "+ lux + " lx");
+// tsl2591MaxCount returns the count at which the channels saturate at
+// integration time t, which is lower than 0xffff at 100ms
+func tsl2591MaxCount(t uint8) uint16 {
+	if t == TSL2591_INTEGRATIONTIME_100MS {
+		return 37888
+	}
+	return 0xffff
 }
 
-*/
+// tsl2591Lux returns the illuminance of the full spectrum and infrared
+// counts, taken at gain and integration time t
+func tsl2591Lux(full, ir uint16, gain uint8, t uint8) float64 {
+	if full == 0 || ir >= full {
+		return 0
+	}
+	// counts per lux
+	cpl := float64(t+1) * 100 * tsl2591Gains[gain] / tsl2591LuxDF
+	ch0, ch1 := float64(full), float64(ir)
+	return (ch0 - ch1) * (1 - ch1/ch0) / cpl
+}
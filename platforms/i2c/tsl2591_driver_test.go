@@ -0,0 +1,99 @@
+package i2c
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// --------- HELPERS
+func initTestTsl2591DriverWithStubbedAdaptor() (*Tsl2591Driver, *i2cTestAdaptor) {
+	adaptor := newI2cTestAdaptor("adaptor")
+	return NewTsl2591Driver(adaptor, "bot"), adaptor
+}
+
+// --------- TESTS
+
+func TestNewTsl2591Driver(t *testing.T) {
+	tsl := NewTsl2591Driver(newI2cTestAdaptor("adaptor"), "bot", 100*time.Millisecond, WithAddress(0x30))
+	gobottest.Assert(t, tsl.Name(), "bot")
+	gobottest.Assert(t, tsl.Connection().Name(), "adaptor")
+	gobottest.Assert(t, tsl.interval, 100*time.Millisecond)
+	gobottest.Assert(t, tsl.Address(), 0x30)
+}
+
+func TestTsl2591DriverStart(t *testing.T) {
+	tsl, adaptor := initTestTsl2591DriverWithStubbedAdaptor()
+	tsl.interval = 1 * time.Millisecond
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return []byte{0xe8, 0x03, 0xc8, 0x00}, nil
+	}
+
+	sem := make(chan Tsl2591Reading)
+	gobot.Once(tsl.Event(Data), func(data interface{}) {
+		sem <- data.(Tsl2591Reading)
+	})
+	gobottest.Assert(t, len(tsl.Start()), 0)
+
+	select {
+	case reading := <-sem:
+		gobottest.Assert(t, reading.FullSpectrum, uint16(1000))
+		gobottest.Assert(t, reading.Infrared, uint16(200))
+		gobottest.Assert(t, reading.Visible, uint16(800))
+		gobottest.Assert(t, math.Abs(reading.Lux-652.8) < 1e-6, true)
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Tsl2591 data event not published")
+	}
+	gobottest.Assert(t, len(tsl.Halt()), 0)
+
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return []byte{0xff, 0xff, 0xc8, 0x00}, nil
+	}
+	_, err := tsl.ReadSensor()
+	gobottest.Assert(t, err, ErrSaturated)
+}
+
+func TestTsl2591DriverSettings(t *testing.T) {
+	tsl, _ := initTestTsl2591DriverWithStubbedAdaptor()
+
+	gobottest.Assert(t, tsl.SetGain(TSL2591_GAIN_HIGH), nil)
+	gobottest.Assert(t, tsl.SetGain(0x01), ErrInvalidGain)
+	gobottest.Assert(t, tsl.SetIntegrationTime(TSL2591_INTEGRATIONTIME_100MS), nil)
+	gobottest.Assert(t, tsl.SetIntegrationTime(0x06), ErrInvalidTime)
+
+	_, err := tsl.ReadSensor()
+	gobottest.Assert(t, err, ErrNotReady)
+
+	// 100ms at 428x is 104.9 counts per lux
+	gobottest.Assert(t, math.Abs(tsl2591Lux(1000, 200, tsl.gain, tsl.integrationTime)-6.1) < 1e-2, true)
+	gobottest.Assert(t, tsl2591Lux(0, 0, tsl.gain, tsl.integrationTime), 0.0)
+}
+
+func TestTsl2591DriverSaturation(t *testing.T) {
+	tsl, adaptor := initTestTsl2591DriverWithStubbedAdaptor()
+	tsl.interval = time.Hour
+	gobottest.Assert(t, tsl.SetIntegrationTime(TSL2591_INTEGRATIONTIME_100MS), nil)
+	gobottest.Assert(t, len(tsl.Start()), 0)
+	gobottest.Assert(t, len(tsl.Halt()), 0)
+
+	// the channels saturate at 37888 counts at 100ms, and 0xffff otherwise
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return []byte{0x00, 0x94, 0xc8, 0x00}, nil
+	}
+	_, err := tsl.ReadSensor()
+	gobottest.Assert(t, err, ErrSaturated)
+
+	gobottest.Assert(t, tsl.SetIntegrationTime(TSL2591_INTEGRATIONTIME_200MS), nil)
+	reading, err := tsl.ReadSensor()
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, reading.FullSpectrum, uint16(37888))
+
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return []byte{0xc8, 0x00, 0xff, 0xff}, nil
+	}
+	_, err = tsl.ReadSensor()
+	gobottest.Assert(t, err, ErrSaturated)
+}
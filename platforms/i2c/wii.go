@@ -0,0 +1,79 @@
+package i2c
+
+import (
+	"bytes"
+	"time"
+)
+
+// wiiAddress is the address of the controllers plugged in the extension
+// port of a Wii Remote
+const wiiAddress = 0x52
+
+// Wii extension controllers, identified by their extension ID
+const (
+	WiiNunchuck = "nunchuck"
+	WiiClassic  = "classic"
+)
+
+// WiiStick is the position of a joystick, relative to its position in the
+// first reading of the controller
+type WiiStick struct {
+	X float64
+	Y float64
+}
+
+// WiiExtension returns the extension controller of an extension ID, or an
+// empty string when the ID is unknown. Some third party controllers have
+// IDs of their own.
+func WiiExtension(id []byte) string {
+	if len(id) != 6 || !bytes.Equal(id[2:4], []byte{0xa4, 0x20}) {
+		return ""
+	}
+	switch {
+	case id[4] == 0x00 && id[5] == 0x00:
+		return WiiNunchuck
+	case id[4] == 0x01 && id[5] == 0x01:
+		return WiiClassic
+	}
+	return ""
+}
+
+// wiiInit initializes the controller without encryption, which works with
+// the original controllers and third party ones alike, and returns its
+// extension ID
+func wiiInit(device I2cConnection, pause time.Duration) (id []byte, err error) {
+	for _, b := range [][]byte{{0xf0, 0x55}, {0xfb, 0x00}} {
+		if _, err = device.Write(b); err != nil {
+			return
+		}
+		<-time.After(pause)
+	}
+	return wiiReadAt(device, 0xfa, pause)
+}
+
+// wiiRead reads the 6 bytes of the state of the controller
+func wiiRead(device I2cConnection, pause time.Duration) ([]byte, error) {
+	return wiiReadAt(device, 0x00, pause)
+}
+
+func wiiReadAt(device I2cConnection, reg byte, pause time.Duration) (data []byte, err error) {
+	if _, err = device.Write([]byte{reg}); err != nil {
+		return
+	}
+	<-time.After(pause)
+	data = make([]byte, 6)
+	n, err := device.Read(data)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(data) {
+		return nil, ErrNotEnoughBytes
+	}
+	return
+}
+
+// wiiReady returns whether data is a state, an unplugged or uninitialized
+// controller reads as 0xff
+func wiiReady(data []byte) bool {
+	return !bytes.Equal(data, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+}
@@ -8,13 +8,25 @@ import (
 
 var _ gobot.Driver = (*WiichuckDriver)(nil)
 
-const wiichuckAddress = 0x52
+// WiichuckReading is a reading of the Nunchuck. The accelerometer values are
+// 10 bit, around 512 when an axis is level and about 200 more per g.
+type WiichuckReading struct {
+	Joystick      WiiStick
+	Accelerometer ThreeDData
+	C             bool
+	Z             bool
+}
 
 type WiichuckDriver struct {
 	name       string
 	connection I2c
+	device     I2cConnection
 	interval   time.Duration
 	pauseTime  time.Duration
+	halt       chan bool
+	started    bool
+	extension  string
+	Config
 	gobot.Eventer
 	joystick map[string]float64
 	data     map[string]float64
@@ -26,13 +38,21 @@ type WiichuckDriver struct {
 //	"z"- Gets triggered every interval amount of time if the z button is pressed
 //	"c" - Gets triggered every interval amount of time if the c button is pressed
 //	"joystick" - Gets triggered every "interval" amount of time if a joystick event occured, you can access values x, y
+//	"accelerometer" - Gets triggered every interval amount of time with the ThreeDData of the accelerometer
+//	"data" - Gets triggered every interval amount of time with a WiichuckReading
 //	"error" - Gets triggered whenever the WiichuckDriver encounters an error
-func NewWiichuckDriver(a I2c, name string, v ...time.Duration) *WiichuckDriver {
+//
+// Optionally accepts:
+//	time.Duration: interval at which the driver reads the controller, defaults to 10ms
+//	Option: WithBus or WithAddress
+func NewWiichuckDriver(a I2c, name string, v ...interface{}) *WiichuckDriver {
 	w := &WiichuckDriver{
 		name:       name,
 		connection: a,
+		Config:     newConfig(wiiAddress),
 		interval:   10 * time.Millisecond,
 		pauseTime:  1 * time.Millisecond,
+		halt:       make(chan bool),
 		Eventer:    gobot.NewEventer(),
 		joystick: map[string]float64{
 			"sy_origin": -1,
@@ -41,74 +61,103 @@ func NewWiichuckDriver(a I2c, name string, v ...time.Duration) *WiichuckDriver {
 		data: map[string]float64{
 			"sx": 0,
 			"sy": 0,
+			"ax": 0,
+			"ay": 0,
+			"az": 0,
 			"z":  0,
 			"c":  0,
 		},
 	}
 
-	if len(v) > 0 {
-		w.interval = v[0]
+	for _, arg := range v {
+		switch arg := arg.(type) {
+		case time.Duration:
+			w.interval = arg
+		case Option:
+			arg(&w.Config)
+		}
 	}
 
 	w.AddEvent(Z)
 	w.AddEvent(C)
 	w.AddEvent(Joystick)
+	w.AddEvent(Accelerometer)
+	w.AddEvent(Data)
 	w.AddEvent(Error)
 	return w
 }
 func (w *WiichuckDriver) Name() string                 { return w.name }
 func (w *WiichuckDriver) Connection() gobot.Connection { return w.connection.(gobot.Connection) }
 
+// Extension returns the extension controller detected by Start, or an empty
+// string when its ID is unknown
+func (w *WiichuckDriver) Extension() string { return w.extension }
+
 // Start initilizes i2c and reads from adaptor
 // using specified interval to update with new value
 func (w *WiichuckDriver) Start() (errs []error) {
-	if err := w.connection.I2cStart(wiichuckAddress); err != nil {
+	if w.started {
+		return
+	}
+	device, err := w.connect(w.connection, w)
+	if err != nil {
+		return []error{err}
+	}
+
+	id, err := wiiInit(device, w.pauseTime)
+	if err != nil {
 		return []error{err}
 	}
+	w.extension = WiiExtension(id)
+	if w.extension != "" && w.extension != WiiNunchuck {
+		return []error{ErrWiiExtension}
+	}
+	w.device = device
 
 	go func() {
 		for {
-			if err := w.connection.I2cWrite(wiichuckAddress, []byte{0x40, 0x00}); err != nil {
-				gobot.Publish(w.Event(Error), err)
-				continue
+			value, err := wiiRead(w.device, w.pauseTime)
+			if err == nil {
+				err = w.update(value)
 			}
-			<-time.After(w.pauseTime)
-			if err := w.connection.I2cWrite(wiichuckAddress, []byte{0x00}); err != nil {
-				gobot.Publish(w.Event(Error), err)
-				continue
-			}
-			<-time.After(w.pauseTime)
-			newValue, err := w.connection.I2cRead(wiichuckAddress, 6)
 			if err != nil {
 				gobot.Publish(w.Event(Error), err)
-				continue
+				// the controller may have been plugged again
+				wiiInit(w.device, w.pauseTime)
 			}
-			if len(newValue) == 6 {
-				if err = w.update(newValue); err != nil {
-					gobot.Publish(w.Event(Error), err)
-					continue
-				}
+			select {
+			case <-time.After(w.interval):
+			case <-w.halt:
+				return
 			}
-			<-time.After(w.interval)
 		}
 	}()
+	w.started = true
 	return
 }
 
-// Halt returns true if driver is halted successfully
-func (w *WiichuckDriver) Halt() (errs []error) { return }
+// Halt stops reading the controller
+func (w *WiichuckDriver) Halt() (errs []error) {
+	if w.started {
+		w.halt <- true
+		w.started = false
+	}
+	releaseAddresses(w.connection, w)
+	return
+}
 
-// update parses value to update buttons and joystick.
-// If value is encrypted, warning message is printed
+// update parses value to update buttons, joystick and accelerometer.
+// ErrNotReady is returned when the controller is not initialized
 func (w *WiichuckDriver) update(value []byte) (err error) {
-	if w.isEncrypted(value) {
-		return ErrEncryptedBytes
-	} else {
-		w.parse(value)
-		w.adjustOrigins()
-		w.updateButtons()
-		w.updateJoystick()
+	if !wiiReady(value) {
+		return ErrNotReady
 	}
+	w.parse(value)
+	w.adjustOrigins()
+	w.updateButtons()
+	w.updateJoystick()
+	w.updateAccelerometer()
+	gobot.Publish(w.Event(Data), w.reading())
 	return
 }
 
@@ -124,19 +173,6 @@ func (w *WiichuckDriver) calculateJoystickValue(axis float64, origin float64) fl
 	return float64(axis - origin)
 }
 
-// isEncrypted returns true if value is encrypted
-func (w *WiichuckDriver) isEncrypted(value []byte) bool {
-	if value[0] == value[1] && value[2] == value[3] && value[4] == value[5] {
-		return true
-	}
-	return false
-}
-
-// decode removes encoding from `x` byte
-func (w *WiichuckDriver) decode(x byte) float64 {
-	return float64((x ^ 0x17) + 0x17)
-}
-
 // adjustOrigins sets sy_origin and sx_origin with values from data
 func (w *WiichuckDriver) adjustOrigins() {
 	w.setJoystickDefaultValue("sy_origin", w.data["sy"])
@@ -161,10 +197,37 @@ func (w *WiichuckDriver) updateJoystick() {
 	})
 }
 
-// parse sets driver values based on parsed value
+// updateAccelerometer publishes event with current values of accelerometer
+func (w *WiichuckDriver) updateAccelerometer() {
+	gobot.Publish(w.Event(Accelerometer), w.reading().Accelerometer)
+}
+
+// reading returns the reading of the current values
+func (w *WiichuckDriver) reading() WiichuckReading {
+	return WiichuckReading{
+		Joystick: WiiStick{
+			X: w.calculateJoystickValue(w.data["sx"], w.joystick["sx_origin"]),
+			Y: w.calculateJoystickValue(w.data["sy"], w.joystick["sy_origin"]),
+		},
+		Accelerometer: ThreeDData{
+			X: int16(w.data["ax"]),
+			Y: int16(w.data["ay"]),
+			Z: int16(w.data["az"]),
+		},
+		C: w.data["c"] == 0,
+		Z: w.data["z"] == 0,
+	}
+}
+
+// parse sets driver values based on parsed value. The buttons are 0 when
+// they are pressed, and the 2 low bits of each axis of the accelerometer
+// are in the last byte.
 func (w *WiichuckDriver) parse(value []byte) {
-	w.data["sx"] = w.decode(value[0])
-	w.data["sy"] = w.decode(value[1])
-	w.data["z"] = float64(uint8(w.decode(value[5])) & 0x01)
-	w.data["c"] = float64(uint8(w.decode(value[5])) & 0x02)
+	w.data["sx"] = float64(value[0])
+	w.data["sy"] = float64(value[1])
+	w.data["ax"] = float64(uint16(value[2])<<2 | uint16(value[5]>>2)&0x03)
+	w.data["ay"] = float64(uint16(value[3])<<2 | uint16(value[5]>>4)&0x03)
+	w.data["az"] = float64(uint16(value[4])<<2 | uint16(value[5]>>6)&0x03)
+	w.data["z"] = float64(value[5] & 0x01)
+	w.data["c"] = float64(value[5] >> 1 & 0x01)
 }
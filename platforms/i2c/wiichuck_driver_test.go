@@ -1,6 +1,7 @@
 package i2c
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -55,8 +56,8 @@ func TestWiichuckDriverStart(t *testing.T) {
 	go func() {
 		for {
 			<-time.After(time.Duration(numberOfCyclesForEvery) * time.Millisecond)
-			if (wii.joystick["sy_origin"] == float64(44)) &&
-				(wii.joystick["sx_origin"] == float64(45)) {
+			if (wii.joystick["sy_origin"] == float64(2)) &&
+				(wii.joystick["sx_origin"] == float64(1)) {
 				sem <- true
 			}
 		}
@@ -68,30 +69,64 @@ func TestWiichuckDriverStart(t *testing.T) {
 		t.Errorf("origin not read correctly")
 	}
 
+	gobottest.Assert(t, len(wii.Halt()), 0)
+	gobottest.Assert(t, adaptor.written[:4], [][]byte{{0xf0, 0x55}, {0xfb, 0x00}, {0xfa}, {0x00}})
+	gobottest.Assert(t, wii.Extension(), "")
+}
+
+func TestWiichuckDriverStartExtension(t *testing.T) {
+	wii, adaptor := initTestWiichuckDriverWithStubbedAdaptor()
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return []byte{0x00, 0x00, 0xa4, 0x20, 0x00, 0x00}, nil
+	}
+	gobottest.Assert(t, len(wii.Start()), 0)
+	gobottest.Assert(t, wii.Extension(), WiiNunchuck)
+	gobottest.Assert(t, len(wii.Halt()), 0)
+
+	wii, adaptor = initTestWiichuckDriverWithStubbedAdaptor()
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return []byte{0x00, 0x00, 0xa4, 0x20, 0x01, 0x01}, nil
+	}
+	gobottest.Assert(t, wii.Start(), []error{ErrWiiExtension})
+	gobottest.Assert(t, len(wii.Halt()), 0)
+
+	wii, adaptor = initTestWiichuckDriverWithStubbedAdaptor()
+	adaptor.i2cWriteImpl = func() error { return errors.New("write error") }
+	gobottest.Assert(t, wii.Start(), []error{errors.New("write error")})
 }
 
 func TestWiichuckDriverHalt(t *testing.T) {
 	wii := initTestWiichuckDriver()
 
 	gobottest.Assert(t, len(wii.Halt()), 0)
+
+	wii, adaptor := initTestWiichuckDriverWithStubbedAdaptor()
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return []byte{0x00, 0x00, 0xa4, 0x20, 0x00, 0x00}, nil
+	}
+	gobottest.Assert(t, len(wii.Start()), 0)
+	gobottest.Assert(t, len(wii.Halt()), 0)
+	gobottest.Assert(t, len(wii.Halt()), 0)
+	gobottest.Assert(t, len(wii.Start()), 0)
+	gobottest.Assert(t, len(wii.Halt()), 0)
 }
 
 func TestWiichuckDriverUpdate(t *testing.T) {
 	wii := initTestWiichuckDriver()
 
-	// ------ When value is not encrypted
-	decryptedValue := []byte{1, 2, 3, 4, 5, 6}
-	wii.update(decryptedValue)
+	// ------ When the controller is ready
+	decryptedValue := []byte{1, 2, 3, 4, 5, 4}
+	gobottest.Assert(t, wii.update(decryptedValue), nil)
 
 	// - This should be done by WiichuckDriver.parse
-	gobottest.Assert(t, wii.data["sx"], float64(45))
-	gobottest.Assert(t, wii.data["sy"], float64(44))
+	gobottest.Assert(t, wii.data["sx"], float64(1))
+	gobottest.Assert(t, wii.data["sy"], float64(2))
 	gobottest.Assert(t, wii.data["z"], float64(0))
 	gobottest.Assert(t, wii.data["c"], float64(0))
 
 	// - This should be done by WiichuckDriver.adjustOrigins
-	gobottest.Assert(t, wii.joystick["sx_origin"], float64(45))
-	gobottest.Assert(t, wii.joystick["sy_origin"], float64(44))
+	gobottest.Assert(t, wii.joystick["sx_origin"], float64(1))
+	gobottest.Assert(t, wii.joystick["sy_origin"], float64(2))
 
 	// - This should be done by WiichuckDriver.updateButtons
 	chann := make(chan bool)
@@ -141,11 +176,23 @@ func TestWiichuckDriverUpdate(t *testing.T) {
 		t.Errorf("Did not recieve 'Joystick' event")
 	}
 
-	// ------ When value is encrypted
-	wii = initTestWiichuckDriver()
-	encryptedValue := []byte{1, 1, 2, 2, 3, 3}
+	// - This should be done by WiichuckDriver.updateAccelerometer
+	gobot.On(wii.Event(Accelerometer), func(data interface{}) {
+		gobottest.Assert(t, data, ThreeDData{X: 13, Y: 16, Z: 20})
+		chann <- true
+	})
 
-	wii.update(encryptedValue)
+	wii.update(decryptedValue)
+
+	select {
+	case <-chann:
+	case <-time.After(10 * time.Second):
+		t.Errorf("Did not recieve 'Accelerometer' event")
+	}
+
+	// ------ When the controller is not initialized
+	wii = initTestWiichuckDriver()
+	gobottest.Assert(t, wii.update([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}), ErrNotReady)
 
 	gobottest.Assert(t, wii.data["sx"], float64(0))
 	gobottest.Assert(t, wii.data["sy"], float64(0))
@@ -182,34 +229,6 @@ func TestWiichuckDriverCalculateJoystickValue(t *testing.T) {
 	gobottest.Assert(t, wii.calculateJoystickValue(float64(5), float64(10)), float64(-5))
 }
 
-func TestWiichuckDriverIsEncrypted(t *testing.T) {
-	wii := initTestWiichuckDriver()
-
-	encryptedValue := []byte{1, 1, 2, 2, 3, 3}
-	gobottest.Assert(t, wii.isEncrypted(encryptedValue), true)
-
-	encryptedValue = []byte{42, 42, 24, 24, 30, 30}
-	gobottest.Assert(t, wii.isEncrypted(encryptedValue), true)
-
-	decryptedValue := []byte{1, 2, 3, 4, 5, 6}
-	gobottest.Assert(t, wii.isEncrypted(decryptedValue), false)
-
-	decryptedValue = []byte{1, 1, 2, 2, 5, 6}
-	gobottest.Assert(t, wii.isEncrypted(decryptedValue), false)
-
-	decryptedValue = []byte{1, 1, 2, 3, 3, 3}
-	gobottest.Assert(t, wii.isEncrypted(decryptedValue), false)
-}
-
-func TestWiichuckDriverDecode(t *testing.T) {
-	wii := initTestWiichuckDriver()
-
-	gobottest.Assert(t, wii.decode(byte(0)), float64(46))
-	gobottest.Assert(t, wii.decode(byte(100)), float64(138))
-	gobottest.Assert(t, wii.decode(byte(200)), float64(246))
-	gobottest.Assert(t, wii.decode(byte(254)), float64(0))
-}
-
 func TestWiichuckDriverParse(t *testing.T) {
 	wii := initTestWiichuckDriver()
 
@@ -221,20 +240,41 @@ func TestWiichuckDriverParse(t *testing.T) {
 	// First pass
 	wii.parse([]byte{12, 23, 34, 45, 56, 67})
 
-	gobottest.Assert(t, wii.data["sx"], float64(50))
+	gobottest.Assert(t, wii.data["sx"], float64(12))
 	gobottest.Assert(t, wii.data["sy"], float64(23))
+	gobottest.Assert(t, wii.data["ax"], float64(136))
+	gobottest.Assert(t, wii.data["ay"], float64(180))
+	gobottest.Assert(t, wii.data["az"], float64(225))
 	gobottest.Assert(t, wii.data["z"], float64(1))
-	gobottest.Assert(t, wii.data["c"], float64(2))
+	gobottest.Assert(t, wii.data["c"], float64(1))
 
 	// Second pass
 	wii.parse([]byte{70, 81, 92, 103, 204, 205})
 
-	gobottest.Assert(t, wii.data["sx"], float64(104))
-	gobottest.Assert(t, wii.data["sy"], float64(93))
+	gobottest.Assert(t, wii.data["sx"], float64(70))
+	gobottest.Assert(t, wii.data["sy"], float64(81))
+	gobottest.Assert(t, wii.data["ax"], float64(371))
+	gobottest.Assert(t, wii.data["ay"], float64(412))
+	gobottest.Assert(t, wii.data["az"], float64(819))
 	gobottest.Assert(t, wii.data["z"], float64(1))
 	gobottest.Assert(t, wii.data["c"], float64(0))
 }
 
+func TestWiichuckDriverReading(t *testing.T) {
+	wii := initTestWiichuckDriver()
+
+	wii.parse([]byte{130, 120, 128, 128, 179, 0xfe})
+	wii.adjustOrigins()
+	wii.parse([]byte{140, 110, 128, 128, 179, 0xfe})
+
+	gobottest.Assert(t, wii.reading(), WiichuckReading{
+		Joystick:      WiiStick{X: 10, Y: -10},
+		Accelerometer: ThreeDData{X: 515, Y: 515, Z: 719},
+		C:             false,
+		Z:             true,
+	})
+}
+
 func TestWiichuckDriverAdjustOrigins(t *testing.T) {
 	wii := initTestWiichuckDriver()
 
@@ -245,8 +285,8 @@ func TestWiichuckDriverAdjustOrigins(t *testing.T) {
 	wii.parse([]byte{1, 2, 3, 4, 5, 6})
 	wii.adjustOrigins()
 
-	gobottest.Assert(t, wii.joystick["sy_origin"], float64(44))
-	gobottest.Assert(t, wii.joystick["sx_origin"], float64(45))
+	gobottest.Assert(t, wii.joystick["sy_origin"], float64(2))
+	gobottest.Assert(t, wii.joystick["sx_origin"], float64(1))
 
 	// Second pass
 	wii = initTestWiichuckDriver()
@@ -254,8 +294,8 @@ func TestWiichuckDriverAdjustOrigins(t *testing.T) {
 	wii.parse([]byte{61, 72, 83, 94, 105, 206})
 	wii.adjustOrigins()
 
-	gobottest.Assert(t, wii.joystick["sy_origin"], float64(118))
-	gobottest.Assert(t, wii.joystick["sx_origin"], float64(65))
+	gobottest.Assert(t, wii.joystick["sy_origin"], float64(72))
+	gobottest.Assert(t, wii.joystick["sx_origin"], float64(61))
 }
 
 func TestWiichuckDriverUpdateButtons(t *testing.T) {
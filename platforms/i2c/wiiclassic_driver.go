@@ -0,0 +1,207 @@
+package i2c
+
+import (
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var _ gobot.Driver = (*WiiClassicDriver)(nil)
+
+// buttons of the Wii Classic Controller, by their bit in the last two
+// bytes of a state
+var wiiClassicButtons = map[string]uint16{
+	"right": 1 << 15,
+	"down":  1 << 14,
+	"l":     1 << 13,
+	"minus": 1 << 12,
+	"home":  1 << 11,
+	"plus":  1 << 10,
+	"r":     1 << 9,
+	"zl":    1 << 7,
+	"b":     1 << 6,
+	"y":     1 << 5,
+	"a":     1 << 4,
+	"x":     1 << 3,
+	"zr":    1 << 2,
+	"left":  1 << 1,
+	"up":    1 << 0,
+}
+
+// WiiClassicReading is a reading of the Wii Classic Controller. Both sticks
+// have a range of about -32 to 32 and the triggers a range of 0 to 31.
+// Buttons are the names of the pressed buttons, "a", "b", "x", "y", "l",
+// "r", "zl", "zr", "up", "down", "left", "right", "minus", "plus" and "home".
+type WiiClassicReading struct {
+	LeftStick    WiiStick
+	RightStick   WiiStick
+	LeftTrigger  int
+	RightTrigger int
+	Buttons      map[string]bool
+}
+
+// WiiClassicDriver is a driver for the Wii Classic Controller, and the Wii
+// Classic Controller Pro, plugged in an extension adapter
+type WiiClassicDriver struct {
+	name       string
+	connection I2c
+	device     I2cConnection
+	interval   time.Duration
+	pauseTime  time.Duration
+	halt       chan bool
+	started    bool
+	extension  string
+	origin     *WiiClassicReading
+	buttons    uint16
+	Config
+	gobot.Eventer
+}
+
+// NewWiiClassicDriver creates a WiiClassicDriver with specified i2c interface and name.
+//
+// It adds the following events:
+//	"data" - Gets triggered every interval amount of time with a WiiClassicReading
+//	"push" - Gets triggered with the name of a button when it is pressed
+//	"release" - Gets triggered with the name of a button when it is released
+//	"error" - Gets triggered whenever the WiiClassicDriver encounters an error
+//
+// Optionally accepts:
+//	time.Duration: interval at which the driver reads the controller, defaults to 10ms
+//	Option: WithBus or WithAddress
+func NewWiiClassicDriver(a I2c, name string, v ...interface{}) *WiiClassicDriver {
+	w := &WiiClassicDriver{
+		name:       name,
+		connection: a,
+		Config:     newConfig(wiiAddress),
+		interval:   10 * time.Millisecond,
+		pauseTime:  1 * time.Millisecond,
+		halt:       make(chan bool),
+		Eventer:    gobot.NewEventer(),
+	}
+
+	for _, arg := range v {
+		switch arg := arg.(type) {
+		case time.Duration:
+			w.interval = arg
+		case Option:
+			arg(&w.Config)
+		}
+	}
+
+	w.AddEvent(Data)
+	w.AddEvent(Push)
+	w.AddEvent(Release)
+	w.AddEvent(Error)
+	return w
+}
+
+func (w *WiiClassicDriver) Name() string                 { return w.name }
+func (w *WiiClassicDriver) Connection() gobot.Connection { return w.connection.(gobot.Connection) }
+
+// Extension returns the extension controller detected by Start, or an empty
+// string when its ID is unknown
+func (w *WiiClassicDriver) Extension() string { return w.extension }
+
+// Start initializes the controller and reads it every interval
+func (w *WiiClassicDriver) Start() (errs []error) {
+	if w.started {
+		return
+	}
+	device, err := w.connect(w.connection, w)
+	if err != nil {
+		return []error{err}
+	}
+
+	id, err := wiiInit(device, w.pauseTime)
+	if err != nil {
+		return []error{err}
+	}
+	w.extension = WiiExtension(id)
+	if w.extension != "" && w.extension != WiiClassic {
+		return []error{ErrWiiExtension}
+	}
+	w.device = device
+
+	go func() {
+		for {
+			value, err := wiiRead(w.device, w.pauseTime)
+			if err == nil {
+				err = w.update(value)
+			}
+			if err != nil {
+				gobot.Publish(w.Event(Error), err)
+				// the controller may have been plugged again
+				wiiInit(w.device, w.pauseTime)
+			}
+			select {
+			case <-time.After(w.interval):
+			case <-w.halt:
+				return
+			}
+		}
+	}()
+	w.started = true
+	return
+}
+
+// Halt stops reading the controller
+func (w *WiiClassicDriver) Halt() (errs []error) {
+	if w.started {
+		w.halt <- true
+		w.started = false
+	}
+	releaseAddresses(w.connection, w)
+	return
+}
+
+// update parses value and publishes the reading, and the buttons which were
+// pressed or released since the previous reading
+func (w *WiiClassicDriver) update(value []byte) (err error) {
+	if !wiiReady(value) {
+		return ErrNotReady
+	}
+	r, buttons := w.parse(value)
+	if w.origin == nil {
+		w.origin = &WiiClassicReading{LeftStick: r.LeftStick, RightStick: r.RightStick}
+	}
+	r.LeftStick.X -= w.origin.LeftStick.X
+	r.LeftStick.Y -= w.origin.LeftStick.Y
+	r.RightStick.X -= w.origin.RightStick.X
+	r.RightStick.Y -= w.origin.RightStick.Y
+
+	for name, bit := range wiiClassicButtons {
+		switch {
+		case buttons&bit != 0 && w.buttons&bit == 0:
+			gobot.Publish(w.Event(Push), name)
+		case buttons&bit == 0 && w.buttons&bit != 0:
+			gobot.Publish(w.Event(Release), name)
+		}
+	}
+	w.buttons = buttons
+
+	gobot.Publish(w.Event(Data), r)
+	return
+}
+
+// parse returns the reading of value, with the raw position of the sticks,
+// and its pressed buttons. The right stick has half the resolution of the
+// left one and is scaled to its range.
+func (w *WiiClassicDriver) parse(value []byte) (r WiiClassicReading, buttons uint16) {
+	r.LeftStick.X = float64(value[0] & 0x3f)
+	r.LeftStick.Y = float64(value[1] & 0x3f)
+	rx := value[0]>>3&0x18 | value[1]>>5&0x06 | value[2]>>7
+	r.RightStick.X = float64(rx) * 2
+	r.RightStick.Y = float64(value[2]&0x1f) * 2
+	r.LeftTrigger = int(value[2]>>2&0x18 | value[3]>>5)
+	r.RightTrigger = int(value[3] & 0x1f)
+
+	// the buttons are 0 when they are pressed
+	buttons = ^(uint16(value[4])<<8 | uint16(value[5]))
+	r.Buttons = make(map[string]bool)
+	for name, bit := range wiiClassicButtons {
+		if buttons&bit != 0 {
+			r.Buttons[name] = true
+		}
+	}
+	return
+}
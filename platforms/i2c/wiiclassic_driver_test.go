@@ -0,0 +1,161 @@
+package i2c
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func initTestWiiClassicDriverWithStubbedAdaptor() (*WiiClassicDriver, *i2cTestAdaptor) {
+	adaptor := newI2cTestAdaptor("adaptor")
+	return NewWiiClassicDriver(adaptor, "classic"), adaptor
+}
+
+// states of the controller with its sticks centered, then moved with a and
+// home pressed, then with a released
+var (
+	wiiClassicCentered = []byte{0xa0, 0x20, 0x10, 0x00, 0xff, 0xff}
+	wiiClassicMoved    = []byte{0xff, 0xc0, 0xe0, 0xf4, 0xf7, 0xef}
+	wiiClassicReleased = []byte{0xff, 0xc0, 0xe0, 0xf4, 0xf7, 0xff}
+)
+
+func TestWiiExtension(t *testing.T) {
+	gobottest.Assert(t, WiiExtension([]byte{0x00, 0x00, 0xa4, 0x20, 0x00, 0x00}), WiiNunchuck)
+	gobottest.Assert(t, WiiExtension([]byte{0x00, 0x00, 0xa4, 0x20, 0x01, 0x01}), WiiClassic)
+	gobottest.Assert(t, WiiExtension([]byte{0x01, 0x00, 0xa4, 0x20, 0x01, 0x01}), WiiClassic)
+	gobottest.Assert(t, WiiExtension([]byte{0x00, 0x00, 0xa4, 0x20, 0x01, 0x03}), "")
+	gobottest.Assert(t, WiiExtension([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}), "")
+	gobottest.Assert(t, WiiExtension([]byte{}), "")
+}
+
+func TestWiiClassicDriver(t *testing.T) {
+	w, _ := initTestWiiClassicDriverWithStubbedAdaptor()
+	gobottest.Assert(t, w.Name(), "classic")
+	gobottest.Assert(t, w.Connection().Name(), "adaptor")
+	gobottest.Assert(t, w.interval, 10*time.Millisecond)
+	gobottest.Assert(t, w.Address(), 0x52)
+
+	w = NewWiiClassicDriver(newI2cTestAdaptor("adaptor"), "classic", 100*time.Millisecond)
+	gobottest.Assert(t, w.interval, 100*time.Millisecond)
+}
+
+func TestWiiClassicDriverStart(t *testing.T) {
+	w, adaptor := initTestWiiClassicDriverWithStubbedAdaptor()
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return []byte{0x00, 0x00, 0xa4, 0x20, 0x01, 0x01}, nil
+	}
+	w.interval = 1 * time.Millisecond
+	gobottest.Assert(t, len(w.Start()), 0)
+	gobottest.Assert(t, w.Extension(), WiiClassic)
+	gobottest.Assert(t, len(w.Halt()), 0)
+	gobottest.Assert(t, adaptor.written[:4], [][]byte{{0xf0, 0x55}, {0xfb, 0x00}, {0xfa}, {0x00}})
+
+	w, adaptor = initTestWiiClassicDriverWithStubbedAdaptor()
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return []byte{0x00, 0x00, 0xa4, 0x20, 0x00, 0x00}, nil
+	}
+	gobottest.Assert(t, w.Start(), []error{ErrWiiExtension})
+	gobottest.Assert(t, len(w.Halt()), 0)
+
+	w, adaptor = initTestWiiClassicDriverWithStubbedAdaptor()
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return []byte{0x00}, nil
+	}
+	gobottest.Assert(t, w.Start(), []error{ErrNotEnoughBytes})
+
+	w, adaptor = initTestWiiClassicDriverWithStubbedAdaptor()
+	adaptor.i2cWriteImpl = func() error { return errors.New("write error") }
+	gobottest.Assert(t, w.Start(), []error{errors.New("write error")})
+}
+
+func TestWiiClassicDriverHalt(t *testing.T) {
+	w, adaptor := initTestWiiClassicDriverWithStubbedAdaptor()
+	gobottest.Assert(t, len(w.Halt()), 0)
+
+	adaptor.i2cReadImpl = func() ([]byte, error) {
+		return []byte{0x00, 0x00, 0xa4, 0x20, 0x01, 0x01}, nil
+	}
+	gobottest.Assert(t, len(w.Start()), 0)
+	gobottest.Assert(t, len(w.Halt()), 0)
+	gobottest.Assert(t, len(w.Halt()), 0)
+	gobottest.Assert(t, len(w.Start()), 0)
+	gobottest.Assert(t, len(w.Halt()), 0)
+}
+
+func TestWiiClassicDriverParse(t *testing.T) {
+	w, _ := initTestWiiClassicDriverWithStubbedAdaptor()
+
+	r, buttons := w.parse(wiiClassicCentered)
+	gobottest.Assert(t, r, WiiClassicReading{
+		LeftStick:  WiiStick{X: 32, Y: 32},
+		RightStick: WiiStick{X: 32, Y: 32},
+		Buttons:    map[string]bool{},
+	})
+	gobottest.Assert(t, buttons&0xfeff, uint16(0))
+
+	r, _ = w.parse(wiiClassicMoved)
+	gobottest.Assert(t, r, WiiClassicReading{
+		LeftStick:    WiiStick{X: 63, Y: 0},
+		RightStick:   WiiStick{X: 62, Y: 0},
+		LeftTrigger:  31,
+		RightTrigger: 20,
+		Buttons:      map[string]bool{"a": true, "home": true},
+	})
+}
+
+func TestWiiClassicDriverUpdate(t *testing.T) {
+	w, _ := initTestWiiClassicDriverWithStubbedAdaptor()
+
+	readings := make(chan interface{}, 1)
+	gobot.On(w.Event(Data), func(data interface{}) {
+		readings <- data
+	})
+	gobottest.Assert(t, w.update(wiiClassicCentered), nil)
+	gobottest.Assert(t, (<-readings).(WiiClassicReading).LeftStick, WiiStick{})
+
+	pushed := make(chan string, 2)
+	released := make(chan string, 1)
+	gobot.On(w.Event(Push), func(data interface{}) {
+		pushed <- data.(string)
+	})
+	gobot.On(w.Event(Release), func(data interface{}) {
+		released <- data.(string)
+	})
+
+	gobottest.Assert(t, w.update(wiiClassicMoved), nil)
+	r := (<-readings).(WiiClassicReading)
+	gobottest.Assert(t, r.LeftStick, WiiStick{X: 31, Y: -32})
+	gobottest.Assert(t, r.RightStick, WiiStick{X: 30, Y: -32})
+
+	names := []string{}
+	for i := 0; i < 2; i++ {
+		select {
+		case name := <-pushed:
+			names = append(names, name)
+		case <-time.After(time.Second):
+			t.Errorf("Did not receive 'push' event")
+		}
+	}
+	sort.Strings(names)
+	gobottest.Assert(t, names, []string{"a", "home"})
+
+	gobottest.Assert(t, w.update(wiiClassicReleased), nil)
+	<-readings
+	select {
+	case name := <-released:
+		gobottest.Assert(t, name, "a")
+	case <-time.After(time.Second):
+		t.Errorf("Did not receive 'release' event")
+	}
+	select {
+	case name := <-pushed:
+		t.Errorf("Received 'push' event for %v", name)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	gobottest.Assert(t, w.update([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}), ErrNotReady)
+}
@@ -19,6 +19,7 @@ var _ gpio.AnalogReader = (*EdisonAdaptor)(nil)
 var _ gpio.PwmWriter = (*EdisonAdaptor)(nil)
 
 var _ i2c.I2c = (*EdisonAdaptor)(nil)
+var _ i2c.I2cConnector = (*EdisonAdaptor)(nil)
 
 func writeFile(path string, data []byte) (i int, err error) {
 	file, err := sysfs.OpenFile(path, os.O_WRONLY, 0644)
@@ -63,8 +64,8 @@ type EdisonAdaptor struct {
 	name        string
 	tristate    sysfs.DigitalPin
 	digitalPins map[int]sysfs.DigitalPin
-	pwmPins     map[int]*pwmPin
-	i2cDevice   sysfs.I2cDevice
+	pwmPins     map[int]sysfs.PwmPin
+	i2cBuses    map[int]*sysfs.I2cBus
 	connect     func(e *EdisonAdaptor) (err error)
 }
 
@@ -192,9 +193,8 @@ func changePinMode(pin, mode string) (err error) {
 // NewEdisonAdaptor returns a new EdisonAdaptor with specified name
 func NewEdisonAdaptor(name string) *EdisonAdaptor {
 	return &EdisonAdaptor{
-		name: name,
-		//i2cDevices: make(map[int]io.ReadWriteCloser),
-		//i2cDevices: make(map[int]io.ReadWriteCloser),
+		name:     name,
+		i2cBuses: make(map[int]*sysfs.I2cBus),
 		connect: func(e *EdisonAdaptor) (err error) {
 			e.tristate = sysfs.NewDigitalPin(214)
 			if err = e.tristate.Export(); err != nil {
@@ -264,7 +264,7 @@ func (e *EdisonAdaptor) Name() string { return e.name }
 // Connect initializes the Edison for use with the Arduino beakout board
 func (e *EdisonAdaptor) Connect() (errs []error) {
 	e.digitalPins = make(map[int]sysfs.DigitalPin)
-	e.pwmPins = make(map[int]*pwmPin)
+	e.pwmPins = make(map[int]sysfs.PwmPin)
 	if err := e.connect(e); err != nil {
 		return []error{err}
 	}
@@ -285,16 +285,16 @@ func (e *EdisonAdaptor) Finalize() (errs []error) {
 	}
 	for _, pin := range e.pwmPins {
 		if pin != nil {
-			if err := pin.enable("0"); err != nil {
+			if err := pin.Enable(false); err != nil {
 				errs = append(errs, err)
 			}
-			if err := pin.unexport(); err != nil {
+			if err := pin.Unexport(); err != nil {
 				errs = append(errs, err)
 			}
 		}
 	}
-	if e.i2cDevice != nil {
-		if err := e.i2cDevice.Close(); errs != nil {
+	for _, bus := range e.i2cBuses {
+		if err := bus.Close(); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -410,48 +410,64 @@ func (e *EdisonAdaptor) PwmWrite(pin string, val byte) (err error) {
 			if err = changePinMode(strconv.Itoa(int(sysPin.pin)), "1"); err != nil {
 				return
 			}
-			e.pwmPins[sysPin.pwmPin] = newPwmPin(sysPin.pwmPin)
-			if err = e.pwmPins[sysPin.pwmPin].export(); err != nil {
+			e.pwmPins[sysPin.pwmPin] = sysfs.NewPwmPin(sysPin.pwmPin)
+			if err = e.pwmPins[sysPin.pwmPin].Export(); err != nil {
 				return
 			}
-			if err = e.pwmPins[sysPin.pwmPin].enable("1"); err != nil {
+			if err = e.pwmPins[sysPin.pwmPin].Enable(true); err != nil {
 				return
 			}
 		}
-		p, err := e.pwmPins[sysPin.pwmPin].period()
-		if err != nil {
-			return err
-		}
-		period, err := strconv.Atoi(p)
+		period, err := e.pwmPins[sysPin.pwmPin].Period()
 		if err != nil {
 			return err
 		}
 		duty := gobot.FromScale(float64(val), 0, 255.0)
-		return e.pwmPins[sysPin.pwmPin].writeDuty(strconv.Itoa(int(float64(period) * duty)))
+		return e.pwmPins[sysPin.pwmPin].SetDutyCycle(int(float64(period) * duty))
 	}
 	return errors.New("Not a PWM pin")
 }
 
 // AnalogRead returns value from analog reading of specified pin
 func (e *EdisonAdaptor) AnalogRead(pin string) (val int, err error) {
-	buf, err := readFile(
-		"/sys/bus/iio/devices/iio:device1/in_voltage" + pin + "_raw",
-	)
-	if err != nil {
-		return
-	}
-
-	val, err = strconv.Atoi(string(buf[0 : len(buf)-1]))
-
+	val, err = sysfs.NewIioDevice(sysfs.IIOPATH + "/iio:device1").ReadRaw("voltage" + pin)
 	return val / 4, err
 }
 
 // I2cStart initializes i2c device for addresss
 func (e *EdisonAdaptor) I2cStart(address int) (err error) {
-	if e.i2cDevice != nil {
+	_, err = e.I2cGetConnection(address, e.I2cDefaultBus())
+	return
+}
+
+// I2cGetConnection returns a connection to the i2c device at address on bus
+// 6, the bus of the arduino breakout board i2c pins, or on bus 1. The i2c pins
+// are set up the first time bus 6 is used. The transactions of each connection
+// are serialised with those of the other devices on the bus.
+func (e *EdisonAdaptor) I2cGetConnection(address int, bus int) (connection i2c.I2cConnection, err error) {
+	if bus != 1 && bus != 6 {
+		return nil, i2c.ErrInvalidBus
+	}
+	if e.i2cBuses[bus] == nil {
+		if bus == 6 {
+			if err = e.i2cSetup(); err != nil {
+				return
+			}
+		}
+		e.i2cBuses[bus] = sysfs.NewI2cBus("/dev/i2c-" + strconv.Itoa(bus))
+	}
+	device, err := e.i2cBuses[bus].Device(address)
+	if err != nil {
 		return
 	}
+	return device, nil
+}
+
+// I2cDefaultBus returns the bus of the arduino breakout board i2c pins, 6
+func (e *EdisonAdaptor) I2cDefaultBus() int { return 6 }
 
+// i2cSetup sets the pinmux of the i2c pins of the arduino breakout board
+func (e *EdisonAdaptor) i2cSetup() (err error) {
 	if err = e.tristate.Write(sysfs.LOW); err != nil {
 		return
 	}
@@ -491,29 +507,26 @@ func (e *EdisonAdaptor) I2cStart(address int) (err error) {
 		}
 	}
 
-	if err = e.tristate.Write(sysfs.HIGH); err != nil {
-		return
-	}
-
-	e.i2cDevice, err = sysfs.NewI2cDevice("/dev/i2c-6", address)
-	return
+	return e.tristate.Write(sysfs.HIGH)
 }
 
 // I2cWrite writes data to i2c device
 func (e *EdisonAdaptor) I2cWrite(address int, data []byte) (err error) {
-	if err = e.i2cDevice.SetAddress(address); err != nil {
-		return err
+	device, err := e.I2cGetConnection(address, e.I2cDefaultBus())
+	if err != nil {
+		return
 	}
-	_, err = e.i2cDevice.Write(data)
+	_, err = device.Write(data)
 	return
 }
 
 // I2cRead returns size bytes from the i2c device
 func (e *EdisonAdaptor) I2cRead(address int, size int) (data []byte, err error) {
-	data = make([]byte, size)
-	if err = e.i2cDevice.SetAddress(address); err != nil {
+	device, err := e.I2cGetConnection(address, e.I2cDefaultBus())
+	if err != nil {
 		return
 	}
-	_, err = e.i2cDevice.Read(data)
+	data = make([]byte, size)
+	_, err = device.Read(data)
 	return
 }
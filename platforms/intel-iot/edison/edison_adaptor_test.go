@@ -5,35 +5,10 @@ import (
 	"testing"
 
 	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/platforms/i2c"
 	"github.com/hybridgroup/gobot/sysfs"
 )
 
-type NullReadWriteCloser struct {
-	contents []byte
-}
-
-func (n *NullReadWriteCloser) SetAddress(int) error {
-	return nil
-}
-
-func (n *NullReadWriteCloser) Write(b []byte) (int, error) {
-	n.contents = make([]byte, len(b))
-	copy(n.contents[:], b[:])
-
-	return len(b), nil
-}
-
-func (n *NullReadWriteCloser) Read(b []byte) (int, error) {
-	copy(b, n.contents)
-	return len(b), nil
-}
-
-var closeErr error = nil
-
-func (n *NullReadWriteCloser) Close() error {
-	return closeErr
-}
-
 func initTestEdisonAdaptor() (*EdisonAdaptor, *sysfs.MockFilesystem) {
 	a := NewEdisonAdaptor("myAdaptor")
 	fs := sysfs.NewMockFilesystem([]string{
@@ -134,7 +109,6 @@ func TestEdisonAdaptorFinalize(t *testing.T) {
 
 	gobottest.Assert(t, len(a.Finalize()), 0)
 
-	closeErr = errors.New("close error")
 	sysfs.SetFilesystem(sysfs.NewMockFilesystem([]string{}))
 	gobottest.Refute(t, len(a.Finalize()), 0)
 }
@@ -157,11 +131,13 @@ func TestEdisonAdaptorI2c(t *testing.T) {
 	sysfs.SetSyscall(&sysfs.MockSyscall{})
 	a.I2cStart(0xff)
 
-	a.i2cDevice = &NullReadWriteCloser{}
 	a.I2cWrite(0xff, []byte{0x00, 0x01})
 
 	data, _ := a.I2cRead(0xff, 2)
 	gobottest.Assert(t, data, []byte{0x00, 0x01})
+
+	_, err := a.I2cGetConnection(0xff, 2)
+	gobottest.Assert(t, err, i2c.ErrInvalidBus)
 }
 
 func TestEdisonAdaptorPwm(t *testing.T) {
@@ -0,0 +1,8 @@
+/*
+Package linux contains a generic Gobot adaptor for linux single board
+computers which provide the gpio character device interface (/dev/gpiochipN).
+
+For further information refer to linux README:
+https://github.com/hybridgroup/gobot/blob/master/platforms/linux/README.md
+*/
+package linux
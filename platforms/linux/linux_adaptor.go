@@ -0,0 +1,308 @@
+package linux
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gpiochip"
+	"github.com/hybridgroup/gobot/platforms/gpio"
+	"github.com/hybridgroup/gobot/sysfs"
+)
+
+var _ gobot.Adaptor = (*LinuxAdaptor)(nil)
+
+var _ gpio.DigitalReader = (*LinuxAdaptor)(nil)
+var _ gpio.DigitalWriter = (*LinuxAdaptor)(nil)
+var _ gpio.AnalogReader = (*LinuxAdaptor)(nil)
+
+var (
+	// ErrInvalidPin is returned when a pin is not found on the board
+	ErrInvalidPin = errors.New("Not a valid pin")
+	// ErrPinWatched is returned when a pin is already being watched
+	ErrPinWatched = errors.New("Pin is already being watched")
+)
+
+// Pin describes the gpio line behind a named pin
+type Pin struct {
+	// Chip is the gpio character device providing the line, eg. "gpiochip0"
+	Chip string
+	// Offset is the offset of the line on the chip
+	Offset int
+	// ActiveLow inverts the logical value of the pin
+	ActiveLow bool
+	// Bias is the pull resistor configuration used when the pin is an input
+	Bias gpiochip.Bias
+	// Drive is the drive configuration used when the pin is an output
+	Drive gpiochip.Drive
+}
+
+// AnalogPin describes the industrial I/O channel behind a named analog pin
+type AnalogPin struct {
+	// Device is the name or the sysfs directory name of the iio device,
+	// eg. "TI-am335x-adc" or "iio:device0"
+	Device string
+	// Channel is the name of the input channel, eg. "voltage0"
+	Channel string
+}
+
+// Board describes the pins of a linux single board computer
+type Board struct {
+	// Pins maps pin names to gpio lines
+	Pins map[string]Pin
+	// Chips are searched, in order, for a line with a matching name when
+	// a pin is not found in Pins
+	Chips []string
+	// Analog maps analog pin names to iio channels. An analog pin not found
+	// in Analog may be given as "device/channel", eg. "iio:device0/voltage0"
+	Analog map[string]AnalogPin
+}
+
+// LinuxAdaptor is the gobot.Adaptor representation for any linux board which
+// provides the gpio character device interface
+type LinuxAdaptor struct {
+	name  string
+	board Board
+	chips map[string]*gpiochip.Chip
+	lines map[string]*requestedLine
+	iio   map[string]*sysfs.IioDevice
+	mutex sync.Mutex
+}
+
+// requestedLine is a line in use by the adaptor and its current configuration
+type requestedLine struct {
+	pin     Pin
+	line    gpiochip.Line
+	config  gpiochip.LineConfig
+	watched bool
+}
+
+// NewLinuxAdaptor returns a new LinuxAdaptor with the specified name and
+// board description
+func NewLinuxAdaptor(name string, board Board) *LinuxAdaptor {
+	return &LinuxAdaptor{
+		name:  name,
+		board: board,
+		chips: make(map[string]*gpiochip.Chip),
+		lines: make(map[string]*requestedLine),
+		iio:   make(map[string]*sysfs.IioDevice),
+	}
+}
+
+// Name returns the LinuxAdaptors name
+func (l *LinuxAdaptor) Name() string { return l.name }
+
+// Board returns the LinuxAdaptors board description
+func (l *LinuxAdaptor) Board() Board { return l.board }
+
+// Connect opens every gpio chip used by the board
+func (l *LinuxAdaptor) Connect() (errs []error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, pin := range l.board.Pins {
+		if _, err := l.chip(pin.Chip); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, name := range l.board.Chips {
+		if _, err := l.chip(name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return
+}
+
+// Finalize releases all requested lines and closes the gpio chips
+func (l *LinuxAdaptor) Finalize() (errs []error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for pin, r := range l.lines {
+		if err := r.line.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(l.lines, pin)
+	}
+	for name, chip := range l.chips {
+		if err := chip.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(l.chips, name)
+	}
+	return
+}
+
+// DigitalRead reads the digital value of the specified pin
+func (l *LinuxAdaptor) DigitalRead(pin string) (val int, err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// keep detecting edges on a watched pin
+	edge := gpiochip.EdgeNone
+	if r, ok := l.lines[pin]; ok && r.config.Direction == sysfs.IN {
+		edge = r.config.Edge
+	}
+	line, err := l.line(pin, sysfs.IN, edge, 0)
+	if err != nil {
+		return
+	}
+	return line.Read()
+}
+
+// DigitalWrite writes a digital value to the specified pin
+func (l *LinuxAdaptor) DigitalWrite(pin string, val byte) (err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	line, err := l.line(pin, sysfs.OUT, gpiochip.EdgeNone, int(val))
+	if err != nil {
+		return
+	}
+	return line.Write(int(val))
+}
+
+// AnalogRead reads the unscaled value of the iio channel of the specified
+// analog pin
+func (l *LinuxAdaptor) AnalogRead(pin string) (val int, err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	p, err := l.translateAnalogPin(pin)
+	if err != nil {
+		return
+	}
+	d, err := l.iioDevice(p.Device)
+	if err != nil {
+		return
+	}
+	return d.ReadRaw(p.Channel)
+}
+
+// WatchPin configures the specified pin as an input detecting edge
+// transitions, and calls f with each detected event until the adaptor is
+// finalized.
+func (l *LinuxAdaptor) WatchPin(pin string, edge gpiochip.Edge, f func(gpiochip.LineEvent)) (err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if r, ok := l.lines[pin]; ok && r.watched {
+		return ErrPinWatched
+	}
+	line, err := l.line(pin, sysfs.IN, edge, 0)
+	if err != nil {
+		return
+	}
+	l.lines[pin].watched = true
+
+	go func() {
+		for {
+			e, err := line.ReadEvent()
+			if err != nil {
+				return
+			}
+			f(e)
+		}
+	}()
+	return
+}
+
+// chip returns the named gpio chip, opening it if needed
+func (l *LinuxAdaptor) chip(name string) (chip *gpiochip.Chip, err error) {
+	if chip, ok := l.chips[name]; ok {
+		return chip, nil
+	}
+	if chip, err = gpiochip.OpenChip(name); err != nil {
+		return
+	}
+	l.chips[name] = chip
+	return
+}
+
+// iioDevice returns the named iio device, looking it up if needed
+func (l *LinuxAdaptor) iioDevice(name string) (d *sysfs.IioDevice, err error) {
+	if d, ok := l.iio[name]; ok {
+		return d, nil
+	}
+	if d, err = sysfs.FindIioDevice(name); err != nil {
+		return
+	}
+	l.iio[name] = d
+	return
+}
+
+// translateAnalogPin finds the iio device and channel of the specified pin
+func (l *LinuxAdaptor) translateAnalogPin(pin string) (p AnalogPin, err error) {
+	if p, ok := l.board.Analog[pin]; ok {
+		return p, nil
+	}
+	if parts := strings.SplitN(pin, "/", 2); len(parts) == 2 {
+		return AnalogPin{Device: parts[0], Channel: parts[1]}, nil
+	}
+	return p, ErrInvalidPin
+}
+
+// translatePin finds the chip and line offset of the specified pin
+func (l *LinuxAdaptor) translatePin(pin string) (p Pin, err error) {
+	if p, ok := l.board.Pins[pin]; ok {
+		return p, nil
+	}
+	for _, name := range l.board.Chips {
+		chip, err := l.chip(name)
+		if err != nil {
+			return p, err
+		}
+		if offset, err := chip.FindLine(pin); err == nil {
+			return Pin{Chip: name, Offset: offset}, nil
+		}
+	}
+	return p, ErrInvalidPin
+}
+
+// line returns the requested line for the specified pin, requesting it or
+// changing its direction and edge detection as needed
+func (l *LinuxAdaptor) line(pin string, dir string, edge gpiochip.Edge, val int) (line gpiochip.Line, err error) {
+	r, ok := l.lines[pin]
+	if !ok {
+		p, err := l.translatePin(pin)
+		if err != nil {
+			return nil, err
+		}
+		chip, err := l.chip(p.Chip)
+		if err != nil {
+			return nil, err
+		}
+		config := p.lineConfig(dir, edge, val)
+		if line, err = chip.RequestLine(p.Offset, l.name, config); err != nil {
+			return nil, err
+		}
+		l.lines[pin] = &requestedLine{pin: p, line: line, config: config}
+		return line, nil
+	}
+
+	if r.config.Direction != dir || r.config.Edge != edge {
+		config := r.pin.lineConfig(dir, edge, val)
+		if err = r.line.Reconfigure(config); err != nil {
+			return
+		}
+		r.config = config
+	}
+	return r.line, nil
+}
+
+// lineConfig returns the line configuration for the pin used in direction dir
+func (p Pin) lineConfig(dir string, edge gpiochip.Edge, val int) gpiochip.LineConfig {
+	config := gpiochip.LineConfig{
+		Direction: dir,
+		ActiveLow: p.ActiveLow,
+		Bias:      p.Bias,
+	}
+	if dir == sysfs.OUT {
+		config.Drive = p.Drive
+		config.Value = val
+	} else {
+		config.Edge = edge
+	}
+	return config
+}
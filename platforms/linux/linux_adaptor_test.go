@@ -0,0 +1,188 @@
+package linux
+
+import (
+	"encoding/binary"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/gpiochip"
+	"github.com/hybridgroup/gobot/sysfs"
+)
+
+// fakeGpiochip emulates the ioctls of a gpio character device. Line
+// requests are backed by a pipe, so edge events can be written to them.
+type fakeGpiochip struct {
+	lines   []string
+	values  uint64
+	flags   uint64
+	offset  uint32
+	events  int
+	configs int
+}
+
+func (f *fakeGpiochip) Syscall(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+	p := *(*unsafe.Pointer)(unsafe.Pointer(&a3))
+	switch a2 {
+	case gpiochip.GPIO_GET_CHIPINFO_IOCTL:
+		// struct gpiochip_info { char name[32]; char label[32]; u32 lines; }
+		b := (*[68]byte)(p)
+		copy(b[:], "gpiochip0")
+		binary.LittleEndian.PutUint32(b[64:], uint32(len(f.lines)))
+	case gpiochip.GPIO_V2_GET_LINEINFO_IOCTL:
+		// struct gpio_v2_line_info { char name[32]; char consumer[32]; u32 offset; ... }
+		b := (*[256]byte)(p)
+		copy(b[:], f.lines[binary.LittleEndian.Uint32(b[64:])])
+	case gpiochip.GPIO_V2_GET_LINE_IOCTL:
+		// struct gpio_v2_line_request { u32 offsets[64]; char consumer[32];
+		//   struct gpio_v2_line_config config; ...; s32 fd; }
+		b := (*[592]byte)(p)
+		f.offset = binary.LittleEndian.Uint32(b[0:])
+		f.flags = binary.LittleEndian.Uint64(b[288:])
+		fds := make([]int, 2)
+		syscall.Pipe(fds)
+		f.events = fds[1]
+		binary.LittleEndian.PutUint32(b[588:], uint32(fds[0]))
+	case gpiochip.GPIO_V2_LINE_SET_CONFIG_IOCTL:
+		f.flags = *(*uint64)(p)
+		f.configs++
+	case gpiochip.GPIO_V2_LINE_GET_VALUES_IOCTL:
+		v := (*[2]uint64)(p)
+		v[0] = f.values & v[1]
+	case gpiochip.GPIO_V2_LINE_SET_VALUES_IOCTL:
+		v := (*[2]uint64)(p)
+		f.values = (f.values &^ v[1]) | (v[0] & v[1])
+	default:
+		return 0, 0, syscall.ENOTTY
+	}
+	return 0, 0, 0
+}
+
+func initTestLinuxAdaptor() (*LinuxAdaptor, *fakeGpiochip) {
+	fs := sysfs.NewMockFilesystem([]string{
+		"/dev/gpiochip0",
+	})
+	sysfs.SetFilesystem(fs)
+	fake := &fakeGpiochip{lines: []string{"ID_SDA", "ID_SCL", "GPIO2", "GPIO3"}}
+	sysfs.SetSyscall(fake)
+
+	a := NewLinuxAdaptor("myAdaptor", Board{
+		Pins: map[string]Pin{
+			"LED":    Pin{Chip: "gpiochip0", Offset: 1, Drive: gpiochip.DriveOpenDrain},
+			"BUTTON": Pin{Chip: "gpiochip0", Offset: 2, Bias: gpiochip.BiasPullUp, ActiveLow: true},
+		},
+		Chips: []string{"gpiochip0"},
+		Analog: map[string]AnalogPin{
+			"A0": AnalogPin{Device: "TI-am335x-adc", Channel: "voltage0"},
+		},
+	})
+	a.Connect()
+	return a, fake
+}
+
+func TestLinuxAdaptor(t *testing.T) {
+	a, _ := initTestLinuxAdaptor()
+	gobottest.Assert(t, a.Name(), "myAdaptor")
+	gobottest.Assert(t, a.Board().Chips, []string{"gpiochip0"})
+	gobottest.Assert(t, len(a.Finalize()), 0)
+
+	a = NewLinuxAdaptor("myAdaptor", Board{Chips: []string{"gpiochip1"}})
+	gobottest.Assert(t, len(a.Connect()), 1)
+}
+
+func TestLinuxAdaptorDigitalIO(t *testing.T) {
+	a, fake := initTestLinuxAdaptor()
+
+	gobottest.Assert(t, a.DigitalWrite("LED", 1), nil)
+	gobottest.Assert(t, fake.offset, uint32(1))
+	gobottest.Assert(t, fake.flags, uint64(gpiochip.GPIO_V2_LINE_FLAG_OUTPUT|gpiochip.GPIO_V2_LINE_FLAG_OPEN_DRAIN))
+	gobottest.Assert(t, fake.values, uint64(1))
+
+	i, err := a.DigitalRead("LED")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, i, 1)
+	gobottest.Assert(t, fake.flags, uint64(gpiochip.GPIO_V2_LINE_FLAG_INPUT))
+
+	// the line is not reconfigured while its direction is unchanged
+	a.DigitalRead("LED")
+	gobottest.Assert(t, fake.configs, 1)
+
+	a.DigitalRead("BUTTON")
+	gobottest.Assert(t, fake.offset, uint32(2))
+	gobottest.Assert(t, fake.flags, uint64(gpiochip.GPIO_V2_LINE_FLAG_INPUT|
+		gpiochip.GPIO_V2_LINE_FLAG_ACTIVE_LOW|gpiochip.GPIO_V2_LINE_FLAG_BIAS_PULL_UP))
+
+	// lines may also be found by their kernel name
+	a.DigitalWrite("GPIO3", 0)
+	gobottest.Assert(t, fake.offset, uint32(3))
+
+	gobottest.Assert(t, a.DigitalWrite("GPIO17", 1), ErrInvalidPin)
+	gobottest.Assert(t, len(a.Finalize()), 0)
+}
+
+func TestLinuxAdaptorAnalogRead(t *testing.T) {
+	a, _ := initTestLinuxAdaptor()
+	fs := sysfs.NewMockFilesystem([]string{
+		"/sys/bus/iio/devices/iio:device0/name",
+		"/sys/bus/iio/devices/iio:device0/in_voltage0_raw",
+		"/sys/bus/iio/devices/iio:device0/in_voltage1_raw",
+	})
+	fs.Files["/sys/bus/iio/devices/iio:device0/name"].Contents = "TI-am335x-adc\n"
+	fs.Files["/sys/bus/iio/devices/iio:device0/in_voltage0_raw"].Contents = "1234\n"
+	fs.Files["/sys/bus/iio/devices/iio:device0/in_voltage1_raw"].Contents = "42\n"
+	sysfs.SetFilesystem(fs)
+
+	i, err := a.AnalogRead("A0")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, i, 1234)
+
+	i, err = a.AnalogRead("iio:device0/voltage1")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, i, 42)
+
+	_, err = a.AnalogRead("A1")
+	gobottest.Assert(t, err, ErrInvalidPin)
+
+	_, err = a.AnalogRead("bmp180/pressure")
+	gobottest.Assert(t, err, sysfs.ErrIioDeviceNotFound)
+
+	_, err = a.AnalogRead("TI-am335x-adc/voltage7")
+	gobottest.Refute(t, err, nil)
+}
+
+func TestLinuxAdaptorWatchPin(t *testing.T) {
+	a, fake := initTestLinuxAdaptor()
+
+	events := make(chan gpiochip.LineEvent, 1)
+	err := a.WatchPin("BUTTON", gpiochip.EdgeFalling, func(e gpiochip.LineEvent) {
+		events <- e
+	})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, fake.flags&gpiochip.GPIO_V2_LINE_FLAG_EDGE_FALLING != 0, true)
+
+	gobottest.Assert(t, a.WatchPin("BUTTON", gpiochip.EdgeFalling, nil), ErrPinWatched)
+
+	// reading the pin keeps its edge detection
+	a.DigitalRead("BUTTON")
+	gobottest.Assert(t, fake.configs, 0)
+
+	buf := make([]byte, 48)
+	binary.LittleEndian.PutUint64(buf[0:], uint64(time.Second))
+	binary.LittleEndian.PutUint32(buf[8:], gpiochip.GPIO_V2_LINE_EVENT_FALLING_EDGE)
+	binary.LittleEndian.PutUint32(buf[12:], 2)
+	syscall.Write(fake.events, buf)
+
+	select {
+	case e := <-events:
+		gobottest.Assert(t, e.Offset, 2)
+		gobottest.Assert(t, e.Edge, gpiochip.EdgeFalling)
+		gobottest.Assert(t, e.Timestamp, time.Second)
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("WatchPin event was not received")
+	}
+
+	gobottest.Assert(t, len(a.Finalize()), 0)
+	syscall.Close(fake.events)
+}
@@ -1,19 +1,31 @@
 package mavlink
 
 import (
+	"sync"
 	"time"
 
 	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/flight"
 	common "github.com/hybridgroup/gobot/platforms/mavlink/common"
 )
 
-var _ gobot.Driver = (*MavlinkDriver)(nil)
+var _ flight.Drone = (*MavlinkDriver)(nil)
+
+// gcsSystemID is the system id of the packets sent by the driver, as a
+// ground control station
+const gcsSystemID = 255
 
 type MavlinkDriver struct {
 	name       string
 	connection gobot.Connection
 	interval   time.Duration
 	gobot.Eventer
+	mutex           sync.Mutex
+	target          uint8
+	targetLatched   bool
+	state           flight.State
+	battery         int
+	takeOffAltitude float32
 }
 
 type MavlinkInterface interface {
@@ -24,12 +36,18 @@ type MavlinkInterface interface {
 // It add the following events:
 //	"packet" - triggered when a new packet is read
 //	"message" - triggered when a new valid message is processed
+//	"flightstate" - triggered with the flight.State when a heartbeat changes it
+//	"battery" - triggered with the remaining battery in percent when a
+//	system status changes it
 func NewMavlinkDriver(a *MavlinkAdaptor, name string, v ...time.Duration) *MavlinkDriver {
 	m := &MavlinkDriver{
-		name:       name,
-		connection: a,
-		Eventer:    gobot.NewEventer(),
-		interval:   10 * time.Millisecond,
+		name:            name,
+		connection:      a,
+		Eventer:         gobot.NewEventer(),
+		interval:        10 * time.Millisecond,
+		target:          1,
+		battery:         -1,
+		takeOffAltitude: 2,
 	}
 
 	if len(v) > 0 {
@@ -40,6 +58,8 @@ func NewMavlinkDriver(a *MavlinkAdaptor, name string, v ...time.Duration) *Mavli
 	m.AddEvent("message")
 	m.AddEvent("errorIO")
 	m.AddEvent("errorMAVLink")
+	m.AddEvent(flight.FlightState)
+	m.AddEvent(flight.Battery)
 
 	return m
 }
@@ -68,6 +88,7 @@ func (m *MavlinkDriver) Start() (errs []error) {
 				gobot.Publish(m.Event("errorMAVLink"), err)
 				continue
 			}
+			m.update(packet, message)
 			gobot.Publish(m.Event("message"), message)
 			<-time.After(m.interval)
 		}
@@ -83,3 +104,145 @@ func (m *MavlinkDriver) SendPacket(packet *common.MAVLinkPacket) (err error) {
 	_, err = m.adaptor().sp.Write(packet.Pack())
 	return err
 }
+
+// update tracks the flight state and the battery of the vehicle from its
+// heartbeats and system status messages, and publishes their changes. The
+// vehicle is the system set with TargetSystem, or else the system of the
+// first heartbeat of an autopilot.
+func (m *MavlinkDriver) update(packet *common.MAVLinkPacket, message common.MAVLinkMessage) {
+	switch message := message.(type) {
+	case *common.Heartbeat:
+		autopilot := message.AUTOPILOT != common.MAV_AUTOPILOT_INVALID &&
+			message.TYPE != common.MAV_TYPE_GCS
+		m.mutex.Lock()
+		if autopilot && !m.targetLatched {
+			m.target = packet.SystemID
+			m.targetLatched = true
+		}
+		vehicle := m.targetLatched && packet.SystemID == m.target
+		m.mutex.Unlock()
+		if !autopilot || !vehicle {
+			return
+		}
+
+		state := flight.Landed
+		switch {
+		case message.SYSTEM_STATUS == common.MAV_STATE_EMERGENCY:
+			state = flight.Emergency
+		case message.SYSTEM_STATUS == common.MAV_STATE_ACTIVE &&
+			message.BASE_MODE&common.MAV_MODE_FLAG_SAFETY_ARMED != 0:
+			state = flight.Flying
+		}
+		m.mutex.Lock()
+		changed := state != m.state
+		m.state = state
+		m.mutex.Unlock()
+		if changed {
+			gobot.Publish(m.Event(flight.FlightState), state)
+		}
+	case *common.SysStatus:
+		battery := int(message.BATTERY_REMAINING)
+		if battery < 0 {
+			return
+		}
+		m.mutex.Lock()
+		if !m.targetLatched || packet.SystemID != m.target {
+			m.mutex.Unlock()
+			return
+		}
+		changed := battery != m.battery
+		m.battery = battery
+		m.mutex.Unlock()
+		if changed {
+			gobot.Publish(m.Event(flight.Battery), battery)
+		}
+	}
+}
+
+// FlightState returns the flight state of the last heartbeat of the
+// vehicle, which is only Landed, Flying or Emergency
+func (m *MavlinkDriver) FlightState() flight.State {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.state
+}
+
+// Battery returns the remaining battery of the last system status of the
+// vehicle in percent, or -1 until it is received
+func (m *MavlinkDriver) Battery() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.battery
+}
+
+// TakeOffAltitude sets the altitude in meters TakeOff climbs to
+func (m *MavlinkDriver) TakeOffAltitude(altitude float64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.takeOffAltitude = float32(altitude)
+}
+
+// TargetSystem sets the system id of the vehicle, which the driver tracks
+// and sends the commands to, instead of the system of the first autopilot
+// heartbeat
+func (m *MavlinkDriver) TargetSystem(id uint8) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.target = id
+	m.targetLatched = true
+}
+
+// sendMessage sends message from the component 0 of the ground control
+// station system
+func (m *MavlinkDriver) sendMessage(message common.MAVLinkMessage) error {
+	return m.SendPacket(common.CraftMAVLinkPacket(gcsSystemID, 0, message))
+}
+
+// sendCommand sends the COMMAND_LONG command to the vehicle
+func (m *MavlinkDriver) sendCommand(command uint16, param1, param7 float32) error {
+	m.mutex.Lock()
+	target := m.target
+	m.mutex.Unlock()
+	return m.sendMessage(common.NewCommandLong(param1, 0, 0, 0, 0, 0, param7, command, target, 0, 0))
+}
+
+// TakeOff arms the vehicle, and makes it take off to the altitude set by
+// TakeOffAltitude. The vehicle must be in a mode accepting the commands,
+// such as GUIDED for ArduCopter.
+func (m *MavlinkDriver) TakeOff() error {
+	if err := m.sendCommand(common.MAV_CMD_COMPONENT_ARM_DISARM, 1, 0); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	altitude := m.takeOffAltitude
+	m.mutex.Unlock()
+	return m.sendCommand(common.MAV_CMD_NAV_TAKEOFF, 0, altitude)
+}
+
+// Land makes the vehicle land where it is
+func (m *MavlinkDriver) Land() error {
+	return m.sendCommand(common.MAV_CMD_NAV_LAND, 0, 0)
+}
+
+// Hover centers the sticks of the manual control
+func (m *MavlinkDriver) Hover() error {
+	return m.Move(0, 0, 0, 0)
+}
+
+// Move sends the manual control of the sticks, see flight.Drone. The values
+// are in the range -1 to 1, the throttle is centered at 500 like the
+// ArduPilot and PX4 autopilots expect.
+func (m *MavlinkDriver) Move(roll, pitch, yaw, throttle float64) error {
+	m.mutex.Lock()
+	target := m.target
+	m.mutex.Unlock()
+	return m.sendMessage(common.NewManualControl(
+		int16(flight.Clamp(pitch)*1000),
+		int16(flight.Clamp(roll)*1000),
+		int16(500+flight.Clamp(throttle)*500),
+		// counter clockwise is positive
+		int16(-flight.Clamp(yaw)*1000),
+		0,
+		target,
+	))
+}
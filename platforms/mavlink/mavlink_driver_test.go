@@ -1,6 +1,7 @@
 package mavlink
 
 import (
+	"bytes"
 	"errors"
 	"io"
 	"testing"
@@ -8,9 +9,31 @@ import (
 
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/platforms/flight"
 	common "github.com/hybridgroup/gobot/platforms/mavlink/common"
 )
 
+// writeBuffer holds what the driver writes
+type writeBuffer struct {
+	bytes.Buffer
+}
+
+func (w *writeBuffer) Close() error { return nil }
+
+// assertSent asserts that the packets written to w hold the messages
+func assertSent(t *testing.T, w *writeBuffer, messages ...common.MAVLinkMessage) {
+	data := w.Next(w.Len())
+	for _, message := range messages {
+		length := int(message.Len())
+		gobottest.Assert(t, len(data) >= 6+length+2, true)
+		gobottest.Assert(t, data[3], uint8(gcsSystemID))
+		gobottest.Assert(t, data[5], message.Id())
+		gobottest.Assert(t, data[6:6+length], message.Pack())
+		data = data[6+length+2:]
+	}
+	gobottest.Assert(t, len(data), 0)
+}
+
 func initTestMavlinkDriver() *MavlinkDriver {
 	m := NewMavlinkAdaptor("myAdaptor", "/dev/null")
 	m.connect = func(port string) (io.ReadWriteCloser, error) { return nil, nil }
@@ -86,3 +109,117 @@ func TestMavlinkDriverHalt(t *testing.T) {
 	d := initTestMavlinkDriver()
 	gobottest.Assert(t, len(d.Halt()), 0)
 }
+
+func TestMavlinkDriverFlightState(t *testing.T) {
+	d := initTestMavlinkDriver()
+	gobottest.Assert(t, d.FlightState(), flight.Landed)
+	gobottest.Assert(t, d.Battery(), -1)
+
+	states := make(chan interface{}, 1)
+	gobot.On(d.Event(flight.FlightState), func(data interface{}) {
+		states <- data
+	})
+	batteries := make(chan interface{}, 1)
+	gobot.On(d.Event(flight.Battery), func(data interface{}) {
+		batteries <- data
+	})
+
+	heartbeat := common.NewHeartbeat(0, 2, 3, common.MAV_MODE_FLAG_SAFETY_ARMED, common.MAV_STATE_ACTIVE, 3)
+	d.update(common.CraftMAVLinkPacket(7, 1, heartbeat), heartbeat)
+	select {
+	case data := <-states:
+		gobottest.Assert(t, data, flight.Flying)
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("FlightState event was not published")
+	}
+	gobottest.Assert(t, d.FlightState(), flight.Flying)
+	gobottest.Assert(t, d.target, uint8(7))
+
+	status := common.NewSysStatus(0, 0, 0, 0, 11100, 0, 0, 0, 0, 0, 0, 0, 64)
+	d.update(common.CraftMAVLinkPacket(7, 1, status), status)
+	select {
+	case data := <-batteries:
+		gobottest.Assert(t, data, 64)
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Battery event was not published")
+	}
+	gobottest.Assert(t, d.Battery(), 64)
+
+	// the autopilots not estimating the battery send -1
+	status = common.NewSysStatus(0, 0, 0, 0, 11100, 0, 0, 0, 0, 0, 0, 0, -1)
+	d.update(common.CraftMAVLinkPacket(7, 1, status), status)
+	gobottest.Assert(t, d.Battery(), 64)
+
+	heartbeat = common.NewHeartbeat(0, 2, 3, common.MAV_MODE_FLAG_SAFETY_ARMED, common.MAV_STATE_EMERGENCY, 3)
+	d.update(common.CraftMAVLinkPacket(7, 1, heartbeat), heartbeat)
+	gobottest.Assert(t, d.FlightState(), flight.Emergency)
+}
+
+func TestMavlinkDriverTarget(t *testing.T) {
+	d := initTestMavlinkDriver()
+
+	// the heartbeats of ground control stations and of components which
+	// are not autopilots are ignored
+	gcs := common.NewHeartbeat(0, common.MAV_TYPE_GCS, common.MAV_AUTOPILOT_INVALID, 0, common.MAV_STATE_ACTIVE, 3)
+	d.update(common.CraftMAVLinkPacket(255, 190, gcs), gcs)
+	gimbal := common.NewHeartbeat(0, 26, common.MAV_AUTOPILOT_INVALID, 0, common.MAV_STATE_ACTIVE, 3)
+	d.update(common.CraftMAVLinkPacket(3, 154, gimbal), gimbal)
+	gobottest.Assert(t, d.target, uint8(1))
+	gobottest.Assert(t, d.targetLatched, false)
+
+	// the status of other systems is ignored until the vehicle is known
+	status := common.NewSysStatus(0, 0, 0, 0, 11100, 0, 0, 0, 0, 0, 0, 0, 64)
+	d.update(common.CraftMAVLinkPacket(3, 1, status), status)
+	gobottest.Assert(t, d.Battery(), -1)
+
+	// the first autopilot heartbeat latches the vehicle
+	heartbeat := common.NewHeartbeat(0, 2, 3, common.MAV_MODE_FLAG_SAFETY_ARMED, common.MAV_STATE_ACTIVE, 3)
+	d.update(common.CraftMAVLinkPacket(7, 1, heartbeat), heartbeat)
+	gobottest.Assert(t, d.target, uint8(7))
+	gobottest.Assert(t, d.FlightState(), flight.Flying)
+
+	emergency := common.NewHeartbeat(0, 2, 3, 0, common.MAV_STATE_EMERGENCY, 3)
+	d.update(common.CraftMAVLinkPacket(8, 1, emergency), emergency)
+	d.update(common.CraftMAVLinkPacket(8, 1, status), status)
+	gobottest.Assert(t, d.target, uint8(7))
+	gobottest.Assert(t, d.FlightState(), flight.Flying)
+	gobottest.Assert(t, d.Battery(), -1)
+
+	// a configured system is tracked instead
+	d = initTestMavlinkDriver()
+	d.TargetSystem(8)
+	d.update(common.CraftMAVLinkPacket(7, 1, heartbeat), heartbeat)
+	gobottest.Assert(t, d.FlightState(), flight.Landed)
+	d.update(common.CraftMAVLinkPacket(8, 1, emergency), emergency)
+	gobottest.Assert(t, d.target, uint8(8))
+	gobottest.Assert(t, d.FlightState(), flight.Emergency)
+
+	w := &writeBuffer{}
+	d.adaptor().sp = w
+	gobottest.Assert(t, d.Land(), nil)
+	assertSent(t, w,
+		common.NewCommandLong(0, 0, 0, 0, 0, 0, 0, common.MAV_CMD_NAV_LAND, 8, 0, 0),
+	)
+}
+
+func TestMavlinkDriverCommands(t *testing.T) {
+	d := initTestMavlinkDriver()
+	w := &writeBuffer{}
+	d.adaptor().sp = w
+
+	d.TakeOffAltitude(5)
+	gobottest.Assert(t, d.TakeOff(), nil)
+	gobottest.Assert(t, d.Land(), nil)
+	assertSent(t, w,
+		common.NewCommandLong(1, 0, 0, 0, 0, 0, 0, common.MAV_CMD_COMPONENT_ARM_DISARM, 1, 0, 0),
+		common.NewCommandLong(0, 0, 0, 0, 0, 0, 5, common.MAV_CMD_NAV_TAKEOFF, 1, 0, 0),
+		common.NewCommandLong(0, 0, 0, 0, 0, 0, 0, common.MAV_CMD_NAV_LAND, 1, 0, 0),
+	)
+
+	gobottest.Assert(t, d.Move(0.5, -0.25, 2, -1), nil)
+	gobottest.Assert(t, d.Hover(), nil)
+	assertSent(t, w,
+		common.NewManualControl(-250, 500, 0, -1000, 0, 1),
+		common.NewManualControl(0, 0, 500, 0, 0, 1),
+	)
+}
@@ -1,32 +1,97 @@
 package mqtt
 
 import (
+	"crypto/tls"
+	"errors"
+	"sync"
+	"time"
+
 	"git.eclipse.org/gitroot/paho/org.eclipse.paho.mqtt.golang.git"
 	"github.com/hybridgroup/gobot"
 )
 
 var _ gobot.Adaptor = (*MqttAdaptor)(nil)
+var _ Messenger = (*MqttAdaptor)(nil)
+
+// ErrNotConnected is returned by Publish and On when the adaptor is not
+// connected to the broker
+var ErrNotConnected = errors.New("Not connected to the MQTT broker")
+
+// MqttOptions are the options of the connection to the broker. The zero
+// value connects without credentials, publishes and subscribes at QoS 0 and
+// does not reconnect.
+type MqttOptions struct {
+	// QoS is the quality of service of Publish and On, 0 to 2
+	QoS byte
+	// Retained makes the broker keep the last message published on each
+	// topic for the later subscribers
+	Retained bool
+	// Username and Password are the credentials of the client
+	Username string
+	Password string
+	// TLSConfig is the TLS configuration of the ssl:// and tls:// brokers
+	TLSConfig *tls.Config
+	// KeepAlive is the time between the pings to the broker, the default
+	// of the client when 0
+	KeepAlive time.Duration
+	// PersistentSession makes the broker keep the subscriptions and the
+	// messages of the client while it is disconnected
+	PersistentSession bool
+	// AutoReconnect reconnects to the broker when the connection is lost,
+	// and subscribes again to the topics of On
+	AutoReconnect bool
+	// WillTopic is the topic of the Last Will message, which the broker
+	// publishes when the connection of the client is lost. OnlineMessage is
+	// published on WillTopic on every connection, so that the topic holds
+	// the status of the client.
+	WillTopic     string
+	WillMessage   []byte
+	OnlineMessage []byte
+	// WillQoS and WillRetained apply to the Last Will and online messages
+	WillQoS      byte
+	WillRetained bool
+}
+
+// subscription is a topic subscribed to with On
+type subscription struct {
+	topic   string
+	handler func([]byte)
+}
 
 type MqttAdaptor struct {
-	name     string
-	Host     string
-	clientID string
-	client   *mqtt.Client
+	name          string
+	Host          string
+	clientID      string
+	options       MqttOptions
+	client        *mqtt.Client
+	mutex         sync.Mutex
+	subscriptions []subscription
 }
 
 // NewMqttAdaptor creates a new mqtt adaptor with specified name, host and client id
 func NewMqttAdaptor(name string, host string, clientID string) *MqttAdaptor {
+	return NewMqttAdaptorWithOptions(name, host, clientID, MqttOptions{})
+}
+
+// NewMqttAdaptorWithOptions creates a new mqtt adaptor with specified name,
+// host, client id and options
+func NewMqttAdaptorWithOptions(name string, host string, clientID string, options MqttOptions) *MqttAdaptor {
 	return &MqttAdaptor{
 		name:     name,
 		Host:     host,
 		clientID: clientID,
+		options:  options,
 	}
 }
+
 func (a *MqttAdaptor) Name() string { return a.name }
 
+// Options returns the options of the connection to the broker
+func (a *MqttAdaptor) Options() MqttOptions { return a.options }
+
 // Connect returns true if connection to mqtt is established
 func (a *MqttAdaptor) Connect() (errs []error) {
-	a.client = mqtt.NewClient(createClientOptions(a.clientID, a.Host))
+	a.client = mqtt.NewClient(a.createClientOptions())
 	if token := a.client.Connect(); token.Wait() && token.Error() != nil {
 		errs = append(errs, token.Error())
 	}
@@ -48,30 +113,89 @@ func (a *MqttAdaptor) Finalize() (errs []error) {
 	return
 }
 
-// Publish a message under a specific topic
-func (a *MqttAdaptor) Publish(topic string, message []byte) bool {
-	if a.client == nil {
-		return false
+// Publish publishes message under topic with the QoS and retain options of
+// the adaptor, and waits until the broker received it
+func (a *MqttAdaptor) Publish(topic string, message []byte) error {
+	return a.publish(topic, message, a.options.Retained)
+}
+
+// PublishRetained publishes message under topic as a retained message,
+// which the broker keeps for the later subscribers
+func (a *MqttAdaptor) PublishRetained(topic string, message []byte) error {
+	return a.publish(topic, message, true)
+}
+
+func (a *MqttAdaptor) publish(topic string, message []byte, retained bool) error {
+	if a.client == nil || !a.client.IsConnected() {
+		return ErrNotConnected
 	}
-	a.client.Publish(topic, 0, false, message)
-	return true
+	token := a.client.Publish(topic, a.options.QoS, retained, message)
+	token.Wait()
+	return token.Error()
 }
 
-// Subscribe to a topic, and then call the message handler function when data is received
-func (a *MqttAdaptor) On(event string, f func(s []byte)) bool {
-	if a.client == nil {
-		return false
+// On subscribes to a topic with the QoS option of the adaptor, and then
+// calls f with the payload of the messages received. The adaptor subscribes
+// again when it reconnects.
+func (a *MqttAdaptor) On(event string, f func(s []byte)) error {
+	if a.client == nil || !a.client.IsConnected() {
+		return ErrNotConnected
 	}
-	a.client.Subscribe(event, 0, func(client *mqtt.Client, msg mqtt.Message) {
-		f(msg.Payload())
+	s := subscription{topic: event, handler: f}
+	token := a.subscribe(a.client, s)
+	token.Wait()
+	if err := token.Error(); err != nil {
+		return err
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.subscriptions = append(a.subscriptions, s)
+	return nil
+}
+
+// subscribe subscribes client to the topic of s
+func (a *MqttAdaptor) subscribe(client *mqtt.Client, s subscription) mqtt.Token {
+	return client.Subscribe(s.topic, a.options.QoS, func(client *mqtt.Client, msg mqtt.Message) {
+		s.handler(msg.Payload())
 	})
-	return true
 }
 
-func createClientOptions(clientId, raw string) *mqtt.ClientOptions {
+// onConnect publishes the online message, and subscribes again to the
+// topics of On after a reconnection. It runs in the goroutine of the
+// client, so it does not wait for the broker.
+func (a *MqttAdaptor) onConnect(client *mqtt.Client) {
+	if a.options.WillTopic != "" && a.options.OnlineMessage != nil {
+		client.Publish(a.options.WillTopic, a.options.WillQoS, a.options.WillRetained, a.options.OnlineMessage)
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	for _, s := range a.subscriptions {
+		a.subscribe(client, s)
+	}
+}
+
+// createClientOptions returns the options of the client of the adaptor
+func (a *MqttAdaptor) createClientOptions() *mqtt.ClientOptions {
 	opts := mqtt.NewClientOptions()
-	opts.AddBroker(raw)
-	opts.SetClientID(clientId)
-	opts.AutoReconnect = false
+	opts.AddBroker(a.Host)
+	opts.SetClientID(a.clientID)
+	opts.SetAutoReconnect(a.options.AutoReconnect)
+	opts.SetCleanSession(!a.options.PersistentSession)
+	opts.SetOnConnectHandler(a.onConnect)
+	if a.options.Username != "" {
+		opts.SetUsername(a.options.Username)
+		opts.SetPassword(a.options.Password)
+	}
+	if a.options.TLSConfig != nil {
+		opts.SetTLSConfig(a.options.TLSConfig)
+	}
+	if a.options.KeepAlive > 0 {
+		opts.SetKeepAlive(a.options.KeepAlive)
+	}
+	if a.options.WillTopic != "" {
+		opts.SetBinaryWill(a.options.WillTopic, a.options.WillMessage, a.options.WillQoS, a.options.WillRetained)
+	}
 	return opts
 }
@@ -3,6 +3,7 @@ package mqtt
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/hybridgroup/gobot/gobottest"
 )
@@ -24,27 +25,69 @@ func TestMqttAdaptorFinalize(t *testing.T) {
 func TestMqttAdaptorCannotPublishUnlessConnected(t *testing.T) {
 	a := initTestMqttAdaptor()
 	data := []byte("o")
-	gobottest.Assert(t, a.Publish("test", data), false)
+	gobottest.Assert(t, a.Publish("test", data), ErrNotConnected)
 }
 
-func TestMqttAdaptorPublishWhenConnected(t *testing.T) {
+func TestMqttAdaptorCannotPublishAfterConnectionError(t *testing.T) {
 	a := initTestMqttAdaptor()
 	a.Connect()
 	data := []byte("o")
-	gobottest.Assert(t, a.Publish("test", data), true)
+	gobottest.Assert(t, a.Publish("test", data), ErrNotConnected)
 }
 
 func TestMqttAdaptorCannotOnUnlessConnected(t *testing.T) {
 	a := initTestMqttAdaptor()
 	gobottest.Assert(t, a.On("hola", func(data []byte) {
 		fmt.Println("hola")
-	}), false)
+	}), ErrNotConnected)
 }
 
-func TestMqttAdaptorOnWhenConnected(t *testing.T) {
+func TestMqttAdaptorCannotOnAfterConnectionError(t *testing.T) {
 	a := initTestMqttAdaptor()
 	a.Connect()
 	gobottest.Assert(t, a.On("hola", func(data []byte) {
 		fmt.Println("hola")
-	}), true)
+	}), ErrNotConnected)
+	gobottest.Assert(t, len(a.subscriptions), 0)
+}
+
+func TestMqttAdaptorDefaultOptions(t *testing.T) {
+	a := initTestMqttAdaptor()
+	gobottest.Assert(t, a.Options(), MqttOptions{})
+
+	opts := a.createClientOptions()
+	gobottest.Assert(t, opts.ClientID, "client")
+	gobottest.Assert(t, opts.AutoReconnect, false)
+	gobottest.Assert(t, opts.CleanSession, true)
+	gobottest.Assert(t, opts.Username, "")
+	gobottest.Assert(t, opts.WillEnabled, false)
+}
+
+func TestMqttAdaptorOptions(t *testing.T) {
+	a := NewMqttAdaptorWithOptions("mqtt", "tcp://localhost:1883", "client", MqttOptions{
+		QoS:               1,
+		Retained:          true,
+		Username:          "user",
+		Password:          "secret",
+		KeepAlive:         10 * time.Second,
+		PersistentSession: true,
+		AutoReconnect:     true,
+		WillTopic:         "robots/client/status",
+		WillMessage:       []byte("offline"),
+		OnlineMessage:     []byte("online"),
+		WillQoS:           1,
+		WillRetained:      true,
+	})
+	gobottest.Assert(t, a.Options().QoS, byte(1))
+
+	opts := a.createClientOptions()
+	gobottest.Assert(t, opts.AutoReconnect, true)
+	gobottest.Assert(t, opts.CleanSession, false)
+	gobottest.Assert(t, opts.Username, "user")
+	gobottest.Assert(t, opts.Password, "secret")
+	gobottest.Assert(t, opts.WillEnabled, true)
+	gobottest.Assert(t, opts.WillTopic, "robots/client/status")
+	gobottest.Assert(t, opts.WillPayload, []byte("offline"))
+	gobottest.Assert(t, opts.WillQos, byte(1))
+	gobottest.Assert(t, opts.WillRetained, true)
 }
@@ -0,0 +1,176 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// DefaultPrefix is the root of the topics of a Bridge
+const DefaultPrefix = "gobot"
+
+// Bridge exposes the robots of a Gobot over MQTT, with the model of the api
+// package. From the Prefix of the bridge:
+//
+//	<robot>                                  retained JSON description of the robot
+//	<robot>/events/<event>                   JSON data of the events of the robot
+//	<robot>/<device>/events/<event>          JSON data of the events of the device
+//	commands/<command>                       global commands
+//	<robot>/commands/<command>               robot commands
+//	<robot>/<device>/commands/<command>      device commands
+//
+// A command request is a JSON object with the optional params of the command,
+// an id and a reply_to topic:
+//
+//	{"id": 42, "params": {"level": 10}, "reply_to": "clients/cli"}
+//
+// The response is published under reply_to, or under the topic of the
+// command followed by /response, with the id of the request:
+//
+//	{"id": 42, "result": true}
+//	{"id": 42, "error": "..."}
+type Bridge struct {
+	// Prefix is the root of the topics, DefaultPrefix by default
+	Prefix    string
+	gobot     *gobot.Gobot
+	messenger Messenger
+}
+
+// request is a command request
+type request struct {
+	ID      interface{}            `json:"id,omitempty"`
+	Params  map[string]interface{} `json:"params"`
+	ReplyTo string                 `json:"reply_to"`
+}
+
+// response is the response to a command request
+type response struct {
+	ID     interface{} `json:"id,omitempty"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// NewBridge returns a Bridge exposing the robots of g over m, which is
+// usually a connected MqttAdaptor
+func NewBridge(g *gobot.Gobot, m Messenger) *Bridge {
+	return &Bridge{
+		Prefix:    DefaultPrefix,
+		gobot:     g,
+		messenger: m,
+	}
+}
+
+// Start publishes the descriptions of the robots, subscribes to the topics
+// of their commands and publishes their events from then on. The robots,
+// devices, commands and events added later are not exposed.
+func (b *Bridge) Start() (err error) {
+	if err = b.handleCommands(b.Prefix, b.gobot); err != nil {
+		return
+	}
+	b.gobot.Robots().Each(func(r *gobot.Robot) {
+		if err != nil {
+			return
+		}
+		err = b.exposeRobot(r)
+	})
+	return
+}
+
+// exposeRobot publishes the description of r, and exposes its commands and
+// events and those of its devices
+func (b *Bridge) exposeRobot(r *gobot.Robot) (err error) {
+	root := b.Prefix + "/" + r.Name
+	description, err := json.Marshal(gobot.NewJSONRobot(r))
+	if err != nil {
+		return err
+	}
+	if err = b.messenger.PublishRetained(root, description); err != nil {
+		return err
+	}
+	if err = b.handleCommands(root, r); err != nil {
+		return err
+	}
+	b.publishEvents(root, r)
+
+	r.Devices().Each(func(d gobot.Device) {
+		if err != nil {
+			return
+		}
+		topic := root + "/" + d.Name()
+		if commander, ok := d.(gobot.Commander); ok {
+			err = b.handleCommands(topic, commander)
+		}
+		if eventer, ok := d.(gobot.Eventer); ok {
+			b.publishEvents(topic, eventer)
+		}
+	})
+	return
+}
+
+// handleCommands subscribes to the topics of the commands of c under root
+func (b *Bridge) handleCommands(root string, c gobot.Commander) error {
+	for name, command := range c.Commands() {
+		topic := root + "/commands/" + name
+		if err := b.messenger.On(topic, b.commandHandler(topic, command)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commandHandler returns the handler of the requests of command, which
+// publishes the responses. The responses are published from another
+// goroutine, since the handler runs in the goroutine of the client, which
+// would wait for itself to acknowledge them.
+func (b *Bridge) commandHandler(topic string, command func(map[string]interface{}) interface{}) func([]byte) {
+	return func(data []byte) {
+		req := request{}
+		res := response{}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &req); err != nil {
+				res.Error = "Invalid command request: " + err.Error()
+			}
+		}
+		if req.Params == nil {
+			req.Params = map[string]interface{}{}
+		}
+		if res.Error == "" {
+			res.Result, res.Error = runCommand(command, req.Params)
+		}
+		res.ID = req.ID
+
+		replyTo := req.ReplyTo
+		if replyTo == "" {
+			replyTo = topic + "/response"
+		}
+		payload, err := json.Marshal(res)
+		if err != nil {
+			payload, _ = json.Marshal(response{ID: req.ID, Error: err.Error()})
+		}
+		go b.messenger.Publish(replyTo, payload)
+	}
+}
+
+// runCommand returns the result of command, or the error of its panic, such
+// as the failed type assertion of an invalid param
+func runCommand(command func(map[string]interface{}) interface{}, params map[string]interface{}) (result interface{}, err string) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Sprintf("Command failed: %v", r)
+		}
+	}()
+	return command(params), ""
+}
+
+// publishEvents publishes the JSON data of the events of e under root
+func (b *Bridge) publishEvents(root string, e gobot.Eventer) {
+	for name, event := range e.Events() {
+		topic := root + "/events/" + name
+		gobot.On(event, func(data interface{}) {
+			if payload, err := json.Marshal(data); err == nil {
+				b.messenger.Publish(topic, payload)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+type testAdaptor struct{}
+
+func (t *testAdaptor) Name() string             { return "conn" }
+func (t *testAdaptor) Connect() (errs []error)  { return }
+func (t *testAdaptor) Finalize() (errs []error) { return }
+
+type testDriver struct {
+	name       string
+	connection gobot.Connection
+	gobot.Commander
+	gobot.Eventer
+}
+
+func (t *testDriver) Name() string                 { return t.name }
+func (t *testDriver) Connection() gobot.Connection { return t.connection }
+func (t *testDriver) Start() (errs []error)        { return }
+func (t *testDriver) Halt() (errs []error)         { return }
+
+func newTestDriver(a *testAdaptor, name string) *testDriver {
+	d := &testDriver{
+		name:       name,
+		connection: a,
+		Commander:  gobot.NewCommander(),
+		Eventer:    gobot.NewEventer(),
+	}
+	d.AddCommand("Add", func(params map[string]interface{}) interface{} {
+		return params["a"].(float64) + params["b"].(float64)
+	})
+	d.AddEvent("level")
+	return d
+}
+
+func initTestBridge() (*Bridge, *Broker, *testDriver) {
+	g := gobot.NewGobot()
+	g.AddCommand("Version", func(params map[string]interface{}) interface{} {
+		return gobot.Version()
+	})
+	a := &testAdaptor{}
+	d := newTestDriver(a, "sensor")
+	r := gobot.NewRobot("bot", []gobot.Connection{a}, []gobot.Device{d})
+	r.AddCommand("Hello", func(params map[string]interface{}) interface{} {
+		return "hello " + params["name"].(string)
+	})
+	g.AddRobot(r)
+
+	broker := NewBroker()
+	return NewBridge(g, broker), broker, d
+}
+
+// receive subscribes to topic, and returns the channel of its messages
+func receive(b *Broker, topic string) chan map[string]interface{} {
+	messages := make(chan map[string]interface{}, 10)
+	b.On(topic, func(data []byte) {
+		message := map[string]interface{}{}
+		json.Unmarshal(data, &message)
+		messages <- message
+	})
+	return messages
+}
+
+func waitMessage(t *testing.T, messages chan map[string]interface{}) map[string]interface{} {
+	select {
+	case message := <-messages:
+		return message
+	case <-time.After(time.Second):
+		t.Fatalf("message was not published")
+	}
+	return nil
+}
+
+func TestBridgeDescription(t *testing.T) {
+	bridge, broker, _ := initTestBridge()
+	gobottest.Assert(t, bridge.Start(), nil)
+
+	robot := gobot.JSONRobot{}
+	gobottest.Assert(t, json.Unmarshal(broker.Retained("gobot/bot"), &robot), nil)
+	gobottest.Assert(t, robot.Name, "bot")
+	gobottest.Assert(t, robot.Commands, []string{"Hello"})
+	gobottest.Assert(t, robot.Devices[0].Name, "sensor")
+	gobottest.Assert(t, robot.Devices[0].Commands, []string{"Add"})
+}
+
+func TestBridgeCommands(t *testing.T) {
+	bridge, broker, _ := initTestBridge()
+	bridge.Prefix = "fleet"
+	gobottest.Assert(t, bridge.Start(), nil)
+
+	responses := receive(broker, "fleet/bot/sensor/commands/Add/response")
+	broker.Publish("fleet/bot/sensor/commands/Add", []byte(`{"id": 7, "params": {"a": 1, "b": 2}}`))
+	gobottest.Assert(t, waitMessage(t, responses), map[string]interface{}{"id": 7.0, "result": 3.0})
+
+	replies := receive(broker, "clients/cli")
+	broker.Publish("fleet/bot/commands/Hello", []byte(`{"id": "a", "params": {"name": "gobot"}, "reply_to": "clients/cli"}`))
+	gobottest.Assert(t, waitMessage(t, replies), map[string]interface{}{"id": "a", "result": "hello gobot"})
+
+	versions := receive(broker, "fleet/commands/Version/response")
+	broker.Publish("fleet/commands/Version", nil)
+	gobottest.Assert(t, waitMessage(t, versions), map[string]interface{}{"result": gobot.Version()})
+
+	broker.Publish("fleet/bot/sensor/commands/Add", []byte(`{"id": 8`))
+	response := waitMessage(t, responses)
+	gobottest.Assert(t, response["error"] != nil, true)
+	gobottest.Assert(t, response["result"], nil)
+
+	broker.Publish("fleet/bot/sensor/commands/Add", []byte(`{"id": 9, "params": {"a": "1"}}`))
+	response = waitMessage(t, responses)
+	gobottest.Assert(t, response["id"], 9.0)
+	gobottest.Assert(t, strings.HasPrefix(response["error"].(string), "Command failed: "), true)
+	gobottest.Assert(t, response["result"], nil)
+}
+
+func TestBridgeEvents(t *testing.T) {
+	bridge, broker, d := initTestBridge()
+	gobottest.Assert(t, bridge.Start(), nil)
+
+	events := make(chan string, 1)
+	broker.On("gobot/bot/sensor/events/level", func(data []byte) {
+		events <- string(data)
+	})
+	gobot.Publish(d.Event("level"), map[string]int{"value": 42})
+
+	select {
+	case data := <-events:
+		gobottest.Assert(t, data, `{"value":42}`)
+	case <-time.After(time.Second):
+		t.Errorf("level event was not published")
+	}
+}
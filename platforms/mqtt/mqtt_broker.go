@@ -0,0 +1,117 @@
+package mqtt
+
+import (
+	"strings"
+	"sync"
+)
+
+var _ Messenger = (*Broker)(nil)
+
+// Messenger publishes and subscribes to MQTT topics. It is implemented by
+// MqttAdaptor, and by Broker in process.
+type Messenger interface {
+	// Publish publishes message under topic
+	Publish(topic string, message []byte) error
+	// PublishRetained publishes message under topic as a retained message
+	PublishRetained(topic string, message []byte) error
+	// On calls f with the payload of the messages published under the
+	// topics matching filter
+	On(filter string, f func([]byte)) error
+}
+
+// brokerSubscription is a topic filter subscribed to with On
+type brokerSubscription struct {
+	filter  string
+	handler func([]byte)
+}
+
+// Broker is an in process MQTT broker, which delivers the messages to the
+// subscribers of the same process synchronously. It supports the + and #
+// wildcards and the retained messages, so that the code using a Messenger
+// can be tested without a broker.
+type Broker struct {
+	mutex         sync.Mutex
+	subscriptions []brokerSubscription
+	retained      map[string][]byte
+}
+
+// NewBroker returns a new Broker without subscribers
+func NewBroker() *Broker {
+	return &Broker{retained: make(map[string][]byte)}
+}
+
+// Publish calls the handlers of the filters matching topic with message
+func (b *Broker) Publish(topic string, message []byte) error {
+	for _, handler := range b.handlers(topic) {
+		handler(message)
+	}
+	return nil
+}
+
+// PublishRetained publishes message under topic, and keeps it for the later
+// subscribers. An empty message removes the retained message of topic.
+func (b *Broker) PublishRetained(topic string, message []byte) error {
+	b.mutex.Lock()
+	if len(message) == 0 {
+		delete(b.retained, topic)
+	} else {
+		b.retained[topic] = message
+	}
+	b.mutex.Unlock()
+	return b.Publish(topic, message)
+}
+
+// On calls f with the messages published under the topics matching filter
+// from now on, and with the retained messages of these topics
+func (b *Broker) On(filter string, f func([]byte)) error {
+	b.mutex.Lock()
+	b.subscriptions = append(b.subscriptions, brokerSubscription{filter: filter, handler: f})
+	retained := [][]byte{}
+	for topic, message := range b.retained {
+		if matchTopic(filter, topic) {
+			retained = append(retained, message)
+		}
+	}
+	b.mutex.Unlock()
+
+	for _, message := range retained {
+		f(message)
+	}
+	return nil
+}
+
+// Retained returns the retained message of topic, or nil when there is none
+func (b *Broker) Retained(topic string) []byte {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.retained[topic]
+}
+
+// handlers returns the handlers of the filters matching topic
+func (b *Broker) handlers(topic string) []func([]byte) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	handlers := []func([]byte){}
+	for _, s := range b.subscriptions {
+		if matchTopic(s.filter, topic) {
+			handlers = append(handlers, s.handler)
+		}
+	}
+	return handlers
+}
+
+// matchTopic returns whether topic matches filter, where + matches a level
+// of the topic and a trailing # matches the remaining levels
+func matchTopic(filter, topic string) bool {
+	filters := strings.Split(filter, "/")
+	topics := strings.Split(topic, "/")
+	for i, f := range filters {
+		if f == "#" {
+			return i == len(filters)-1
+		}
+		if i >= len(topics) || (f != "+" && f != topics[i]) {
+			return false
+		}
+	}
+	return len(filters) == len(topics)
+}
@@ -0,0 +1,53 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestMatchTopic(t *testing.T) {
+	matches := []struct {
+		filter, topic string
+		match         bool
+	}{
+		{"a/b", "a/b", true},
+		{"a/b", "a/c", false},
+		{"a/+", "a/b", true},
+		{"a/+", "a/b/c", false},
+		{"a/+/c", "a/b/c", true},
+		{"a/#", "a/b/c", true},
+		{"a/#", "a", true},
+		{"#", "a/b", true},
+		{"a/b/c", "a/b", false},
+		{"a/#/c", "a/b/c", false},
+	}
+	for _, m := range matches {
+		gobottest.Assert(t, matchTopic(m.filter, m.topic), m.match)
+	}
+}
+
+func TestBroker(t *testing.T) {
+	b := NewBroker()
+	received := []string{}
+	gobottest.Assert(t, b.On("robots/+/status", func(data []byte) {
+		received = append(received, string(data))
+	}), nil)
+
+	gobottest.Assert(t, b.Publish("robots/a/status", []byte("online")), nil)
+	gobottest.Assert(t, b.Publish("robots/a/other", []byte("ignored")), nil)
+	gobottest.Assert(t, b.PublishRetained("robots/b/status", []byte("offline")), nil)
+	gobottest.Assert(t, received, []string{"online", "offline"})
+	gobottest.Assert(t, b.Retained("robots/a/status") == nil, true)
+	gobottest.Assert(t, b.Retained("robots/b/status"), []byte("offline"))
+
+	// the later subscribers receive the retained messages
+	late := []string{}
+	b.On("robots/#", func(data []byte) {
+		late = append(late, string(data))
+	})
+	gobottest.Assert(t, late, []string{"offline"})
+
+	b.PublishRetained("robots/b/status", nil)
+	gobottest.Assert(t, b.Retained("robots/b/status") == nil, true)
+}
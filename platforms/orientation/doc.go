@@ -0,0 +1,12 @@
+/*
+Package orientation provides a Gobot driver estimating the orientation of
+accelerometers, gyroscopes and magnetometers.
+
+Installing:
+
+	go get github.com/hybridgroup/gobot/platforms/orientation
+
+For further information refer to orientation README:
+https://github.com/hybridgroup/gobot/blob/master/platforms/orientation/README.md
+*/
+package orientation
@@ -0,0 +1,199 @@
+package orientation
+
+import "math"
+
+// Filter fuses the readings of the sensors into an orientation
+type Filter interface {
+	// Update returns q updated with the angular velocity gyro in radians/s,
+	// the acceleration accel and the magnetic field mag, dt seconds after
+	// the previous update. mag is zero without a magnetometer.
+	Update(q Quaternion, gyro Vector, accel Vector, mag Vector, dt float64) Quaternion
+}
+
+// ComplementaryFilter integrates the gyroscope, and corrects its drift with
+// the orientation measured by the accelerometer and the magnetometer
+type ComplementaryFilter struct {
+	// Alpha is the weight of the gyroscope, from 0 to 1
+	Alpha float64
+}
+
+// NewComplementaryFilter returns a ComplementaryFilter which weights the
+// gyroscope with alpha
+func NewComplementaryFilter(alpha float64) *ComplementaryFilter {
+	return &ComplementaryFilter{Alpha: alpha}
+}
+
+// Update implements Filter
+func (f *ComplementaryFilter) Update(q Quaternion, gyro Vector, accel Vector, mag Vector, dt float64) Quaternion {
+	q = q.integrate(gyro, dt)
+	if accel.zero() {
+		return q
+	}
+
+	m := measured(q, accel, mag)
+	// take the shortest path between both rotations
+	if q.W*m.W+q.X*m.X+q.Y*m.Y+q.Z*m.Z < 0 {
+		m = Quaternion{W: -m.W, X: -m.X, Y: -m.Y, Z: -m.Z}
+	}
+	return Quaternion{
+		W: f.Alpha*q.W + (1-f.Alpha)*m.W,
+		X: f.Alpha*q.X + (1-f.Alpha)*m.X,
+		Y: f.Alpha*q.Y + (1-f.Alpha)*m.Y,
+		Z: f.Alpha*q.Z + (1-f.Alpha)*m.Z,
+	}.normalize()
+}
+
+// MadgwickFilter is the gradient descent filter of Sebastian Madgwick
+type MadgwickFilter struct {
+	// Beta is the gain of the gradient descent step
+	Beta float64
+}
+
+// NewMadgwickFilter returns a MadgwickFilter with the gain beta
+func NewMadgwickFilter(beta float64) *MadgwickFilter {
+	return &MadgwickFilter{Beta: beta}
+}
+
+// Update implements Filter
+func (f *MadgwickFilter) Update(q Quaternion, gyro Vector, accel Vector, mag Vector, dt float64) Quaternion {
+	q0, q1, q2, q3 := q.W, q.X, q.Y, q.Z
+
+	// rate of change of the quaternion from the gyroscope
+	qDot := Quaternion{
+		W: 0.5 * (-q1*gyro.X - q2*gyro.Y - q3*gyro.Z),
+		X: 0.5 * (q0*gyro.X + q2*gyro.Z - q3*gyro.Y),
+		Y: 0.5 * (q0*gyro.Y - q1*gyro.Z + q3*gyro.X),
+		Z: 0.5 * (q0*gyro.Z + q1*gyro.Y - q2*gyro.X),
+	}
+
+	if !accel.zero() {
+		var s Quaternion
+		if mag.zero() {
+			s = madgwickIMUStep(q, accel.normalize())
+		} else {
+			s = madgwickMARGStep(q, accel.normalize(), mag.normalize())
+		}
+		if s != (Quaternion{}) {
+			s = s.normalize()
+		}
+		qDot.W -= f.Beta * s.W
+		qDot.X -= f.Beta * s.X
+		qDot.Y -= f.Beta * s.Y
+		qDot.Z -= f.Beta * s.Z
+	}
+
+	return Quaternion{
+		W: q0 + qDot.W*dt,
+		X: q1 + qDot.X*dt,
+		Y: q2 + qDot.Y*dt,
+		Z: q3 + qDot.Z*dt,
+	}.normalize()
+}
+
+// madgwickIMUStep returns the gradient of the error between the measured and
+// the estimated direction of the gravity
+func madgwickIMUStep(q Quaternion, a Vector) Quaternion {
+	q0, q1, q2, q3 := q.W, q.X, q.Y, q.Z
+	q0q0, q1q1, q2q2, q3q3 := q0*q0, q1*q1, q2*q2, q3*q3
+
+	return Quaternion{
+		W: 4*q0*q2q2 + 2*q2*a.X + 4*q0*q1q1 - 2*q1*a.Y,
+		X: 4*q1*q3q3 - 2*q3*a.X + 4*q0q0*q1 - 2*q0*a.Y - 4*q1 + 8*q1*q1q1 + 8*q1*q2q2 + 4*q1*a.Z,
+		Y: 4*q0q0*q2 + 2*q0*a.X + 4*q2*q3q3 - 2*q3*a.Y - 4*q2 + 8*q2*q1q1 + 8*q2*q2q2 + 4*q2*a.Z,
+		Z: 4*q1q1*q3 - 2*q1*a.X + 4*q2q2*q3 - 2*q2*a.Y,
+	}
+}
+
+// madgwickMARGStep returns the gradient of the error between the measured and
+// the estimated directions of the gravity and of the magnetic field
+func madgwickMARGStep(q Quaternion, a Vector, m Vector) Quaternion {
+	q0, q1, q2, q3 := q.W, q.X, q.Y, q.Z
+	q0q0, q0q1, q0q2, q0q3 := q0*q0, q0*q1, q0*q2, q0*q3
+	q1q1, q1q2, q1q3 := q1*q1, q1*q2, q1*q3
+	q2q2, q2q3, q3q3 := q2*q2, q2*q3, q3*q3
+
+	// direction of the magnetic field in the frame of the earth, bx and bz
+	// are twice its horizontal and vertical components
+	hx := m.X*q0q0 - 2*q0*m.Y*q3 + 2*q0*m.Z*q2 + m.X*q1q1 + 2*q1*m.Y*q2 + 2*q1*m.Z*q3 - m.X*q2q2 - m.X*q3q3
+	hy := 2*q0*m.X*q3 + m.Y*q0q0 - 2*q0*m.Z*q1 + 2*q1*m.X*q2 - m.Y*q1q1 + m.Y*q2q2 + 2*q2*m.Z*q3 - m.Y*q3q3
+	bx := 2 * math.Hypot(hx, hy)
+	bz := 2 * (2*m.X*(q1q3-q0q2) + 2*m.Y*(q0q1+q2q3) + m.Z*(q0q0-q1q1-q2q2+q3q3))
+
+	// errors of the estimated gravity and magnetic field
+	fx := 2*(q1q3-q0q2) - a.X
+	fy := 2*(q0q1+q2q3) - a.Y
+	fz := 1 - 2*(q1q1+q2q2) - a.Z
+	mx := bx*(0.5-q2q2-q3q3) + bz*(q1q3-q0q2) - m.X
+	my := bx*(q1q2-q0q3) + bz*(q0q1+q2q3) - m.Y
+	mz := bx*(q0q2+q1q3) + bz*(0.5-q1q1-q2q2) - m.Z
+
+	return Quaternion{
+		W: -2*q2*fx + 2*q1*fy - bz*q2*mx + (-bx*q3+bz*q1)*my + bx*q2*mz,
+		X: 2*q3*fx + 2*q0*fy - 4*q1*fz + bz*q3*mx + (bx*q2+bz*q0)*my + (bx*q3-2*bz*q1)*mz,
+		Y: -2*q0*fx + 2*q3*fy - 4*q2*fz + (-2*bx*q2-bz*q0)*mx + (bx*q1+bz*q3)*my + (bx*q0-2*bz*q2)*mz,
+		Z: 2*q1*fx + 2*q2*fy + (-2*bx*q3+bz*q1)*mx + (-bx*q0+bz*q2)*my + bx*q1*mz,
+	}
+}
+
+// MahonyFilter is the nonlinear complementary filter of Robert Mahony, which
+// corrects the gyroscope with a proportional and integral feedback
+type MahonyFilter struct {
+	// Kp is the proportional gain
+	Kp float64
+	// Ki is the integral gain
+	Ki       float64
+	integral Vector
+}
+
+// NewMahonyFilter returns a MahonyFilter with the gains kp and ki
+func NewMahonyFilter(kp float64, ki float64) *MahonyFilter {
+	return &MahonyFilter{Kp: kp, Ki: ki}
+}
+
+// Update implements Filter
+func (f *MahonyFilter) Update(q Quaternion, gyro Vector, accel Vector, mag Vector, dt float64) Quaternion {
+	if !accel.zero() {
+		q0, q1, q2, q3 := q.W, q.X, q.Y, q.Z
+		a := accel.normalize()
+
+		// estimated direction of the gravity
+		v := Vector{
+			X: 2 * (q1*q3 - q0*q2),
+			Y: 2 * (q0*q1 + q2*q3),
+			Z: q0*q0 - q1*q1 - q2*q2 + q3*q3,
+		}
+		e := a.cross(v)
+
+		if !mag.zero() {
+			m := mag.normalize()
+			// reference direction of the magnetic field in the frame of the
+			// earth, and its estimated direction in the frame of the sensor
+			hx := 2 * (m.X*(0.5-q2*q2-q3*q3) + m.Y*(q1*q2-q0*q3) + m.Z*(q1*q3+q0*q2))
+			hy := 2 * (m.X*(q1*q2+q0*q3) + m.Y*(0.5-q1*q1-q3*q3) + m.Z*(q2*q3-q0*q1))
+			bx := math.Hypot(hx, hy)
+			bz := 2 * (m.X*(q1*q3-q0*q2) + m.Y*(q2*q3+q0*q1) + m.Z*(0.5-q1*q1-q2*q2))
+			w := Vector{
+				X: 2 * (bx*(0.5-q2*q2-q3*q3) + bz*(q1*q3-q0*q2)),
+				Y: 2 * (bx*(q1*q2-q0*q3) + bz*(q0*q1+q2*q3)),
+				Z: 2 * (bx*(q0*q2+q1*q3) + bz*(0.5-q1*q1-q2*q2)),
+			}
+			c := m.cross(w)
+			e = Vector{X: e.X + c.X, Y: e.Y + c.Y, Z: e.Z + c.Z}
+		}
+
+		if f.Ki > 0 {
+			f.integral.X += f.Ki * e.X * dt
+			f.integral.Y += f.Ki * e.Y * dt
+			f.integral.Z += f.Ki * e.Z * dt
+		} else {
+			f.integral = Vector{}
+		}
+		gyro = Vector{
+			X: gyro.X + f.Kp*e.X + f.integral.X,
+			Y: gyro.Y + f.Kp*e.Y + f.integral.Y,
+			Z: gyro.Z + f.Kp*e.Z + f.integral.Z,
+		}
+	}
+
+	return q.integrate(gyro, dt)
+}
@@ -0,0 +1,121 @@
+package orientation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// earth magnetic field with a dip of 60 degrees
+var earthField = Vector{X: 0.5, Y: 0, Z: -math.Sqrt(3) / 2}
+
+// inSensorFrame returns the earth vector v in the frame of a sensor with the
+// orientation q
+func inSensorFrame(q Quaternion, v Vector) Vector {
+	c := Quaternion{W: q.W, X: -q.X, Y: -q.Y, Z: -q.Z}
+	r := multiply(multiply(c, Quaternion{X: v.X, Y: v.Y, Z: v.Z}), q)
+	return Vector{X: r.X, Y: r.Y, Z: r.Z}
+}
+
+func multiply(a Quaternion, b Quaternion) Quaternion {
+	return Quaternion{
+		W: a.W*b.W - a.X*b.X - a.Y*b.Y - a.Z*b.Z,
+		X: a.W*b.X + a.X*b.W + a.Y*b.Z - a.Z*b.Y,
+		Y: a.W*b.Y - a.X*b.Z + a.Y*b.W + a.Z*b.X,
+		Z: a.W*b.Z + a.X*b.Y - a.Y*b.X + a.Z*b.W,
+	}
+}
+
+func round(v float64) float64 { return math.Floor(v*10+0.5) / 10 }
+
+func roundEuler(e Euler) Euler {
+	return Euler{Roll: round(e.Roll), Pitch: round(e.Pitch), Yaw: round(e.Yaw)}
+}
+
+// near returns whether the angles of a and b are within half a degree, the
+// gradient descent steps of the filters make them oscillate around the truth
+func near(a Euler, b Euler) bool {
+	return math.Abs(a.Roll-b.Roll) < 0.5 && math.Abs(a.Pitch-b.Pitch) < 0.5 && math.Abs(a.Yaw-b.Yaw) < 0.5
+}
+
+func TestEuler(t *testing.T) {
+	e := Euler{Roll: 10, Pitch: -20, Yaw: 30}
+	gobottest.Assert(t, roundEuler(e.Quaternion().Euler()), e)
+	gobottest.Assert(t, Euler{}.Quaternion(), Quaternion{W: 1})
+}
+
+func TestHeading(t *testing.T) {
+	gobottest.Assert(t, round(Heading(Vector{Z: 1}, Vector{X: 1})), 0.0)
+	gobottest.Assert(t, round(Heading(Vector{Z: 1}, Vector{Y: -1})), 270.0)
+
+	// tilted sensors, heading 30 degrees east
+	q := Euler{Roll: 15, Pitch: -25, Yaw: -30}.Quaternion()
+	accel := inSensorFrame(q, Vector{Z: 1})
+	mag := inSensorFrame(q, earthField)
+	gobottest.Assert(t, round(Heading(accel, mag)), 30.0)
+
+	gobottest.Assert(t, roundEuler(measured(Quaternion{W: 1}, accel, mag).Euler()),
+		Euler{Roll: 15, Pitch: -25, Yaw: -30})
+	gobottest.Assert(t, roundEuler(measured(Quaternion{W: 1}, accel, Vector{}).Euler()),
+		Euler{Roll: 15, Pitch: -25, Yaw: 0})
+}
+
+func TestFiltersConverge(t *testing.T) {
+	truth := Euler{Roll: -10, Pitch: 20, Yaw: 40}
+	q := truth.Quaternion()
+	accel := inSensorFrame(q, Vector{Z: 1})
+	mag := inSensorFrame(q, earthField)
+
+	filters := map[string]Filter{
+		"complementary": NewComplementaryFilter(0.98),
+		"madgwick":      NewMadgwickFilter(0.1),
+		"mahony":        NewMahonyFilter(2, 0.1),
+	}
+	for name, f := range filters {
+		estimate := Quaternion{W: 1}
+		for i := 0; i < 20000; i++ {
+			estimate = f.Update(estimate, Vector{}, accel, mag, 0.01)
+		}
+		if e := roundEuler(estimate.Euler()); !near(e, truth) {
+			t.Errorf("%v filter converged to %+v instead of %+v", name, e, truth)
+		}
+	}
+}
+
+func TestFiltersWithoutMagnetometer(t *testing.T) {
+	filters := map[string]Filter{
+		"complementary": NewComplementaryFilter(0.98),
+		"madgwick":      NewMadgwickFilter(0.1),
+		"mahony":        NewMahonyFilter(2, 0),
+	}
+	for name, f := range filters {
+		// the yaw is kept while the tilt converges
+		estimate := Euler{Yaw: 50}.Quaternion()
+		accel := inSensorFrame(Euler{Roll: 30, Yaw: 50}.Quaternion(), Vector{Z: 1})
+		for i := 0; i < 20000; i++ {
+			estimate = f.Update(estimate, Vector{}, accel, Vector{}, 0.01)
+		}
+		if e := roundEuler(estimate.Euler()); !near(e, Euler{Roll: 30, Yaw: 50}) {
+			t.Errorf("%v filter converged to %+v without magnetometer", name, e)
+		}
+	}
+}
+
+func TestFiltersIntegrateGyroscope(t *testing.T) {
+	filters := map[string]Filter{
+		"complementary": NewComplementaryFilter(1),
+		"madgwick":      NewMadgwickFilter(0),
+		"mahony":        NewMahonyFilter(0, 0),
+	}
+	for name, f := range filters {
+		// a quarter turn around Z in a second
+		estimate := Quaternion{W: 1}
+		for i := 0; i < 100; i++ {
+			estimate = f.Update(estimate, Vector{Z: math.Pi / 2}, Vector{Z: 1}, Vector{}, 0.01)
+		}
+		if e := roundEuler(estimate.Euler()); !near(e, Euler{Yaw: 90}) {
+			t.Errorf("%v filter integrated to %+v", name, e)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package orientation
+
+import (
+	"github.com/hybridgroup/gobot/platforms/i2c"
+)
+
+// MPU6050Source is the accelerometer and gyroscope of an MPU6050
+type MPU6050Source struct {
+	driver *i2c.MPU6050Driver
+}
+
+// MPU6050 returns the accelerometer and gyroscope of d. They are the last
+// sample read by d, which must be started.
+func MPU6050(d *i2c.MPU6050Driver) *MPU6050Source {
+	return &MPU6050Source{driver: d}
+}
+
+// ReadAccelerometer implements Accelerometer
+func (s *MPU6050Source) ReadAccelerometer() (Vector, error) {
+	accel, _, err := s.ReadInertial()
+	return accel, err
+}
+
+// ReadGyroscope implements Gyroscope
+func (s *MPU6050Source) ReadGyroscope() (Vector, error) {
+	_, gyro, err := s.ReadInertial()
+	return gyro, err
+}
+
+// ReadInertial returns the acceleration and the angular velocity of the same
+// sample
+func (s *MPU6050Source) ReadInertial() (accel Vector, gyro Vector, err error) {
+	sample := s.driver.Sample()
+	return Vector(sample.Accelerometer), Vector(sample.Gyroscope), nil
+}
+
+// MMA7660 returns the accelerometer of d, which must be started
+func MMA7660(d *i2c.MMA7660Driver) Accelerometer {
+	return AccelerometerFunc(func() (Vector, error) {
+		x, y, z, err := d.XYZ()
+		if err != nil {
+			return Vector{}, err
+		}
+		x, y, z = d.Acceleration(x, y, z)
+		return Vector{X: x, Y: y, Z: z}, nil
+	})
+}
+
+// HMC6352 returns the compass of d, which must be started
+func HMC6352(d *i2c.HMC6352Driver) Compass {
+	return CompassFunc(func() (float64, error) {
+		heading, err := d.Heading()
+		return float64(heading), err
+	})
+}
@@ -0,0 +1,219 @@
+package orientation
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrNoAccelerometer is returned by Start when the driver has no
+// accelerometer to read the gravity from
+var ErrNoAccelerometer = errors.New("No accelerometer")
+
+const (
+	// Data event
+	Data = "data"
+	// Error event
+	Error = "error"
+)
+
+// Vector is a three axis value in the frame of the sensor: X forward, Y left
+// and Z up
+type Vector struct {
+	X float64
+	Y float64
+	Z float64
+}
+
+// Quaternion is the rotation from the frame of the sensor to the frame of
+// the earth: X magnetic north, Y west and Z up
+type Quaternion struct {
+	W float64
+	X float64
+	Y float64
+	Z float64
+}
+
+// Euler are the roll, pitch and yaw angles in degrees, applied in yaw,
+// pitch, roll order. The yaw is counterclockwise from magnetic north.
+type Euler struct {
+	Roll  float64
+	Pitch float64
+	Yaw   float64
+}
+
+// Orientation is an estimation of the orientation of the sensors, published
+// with the Data event. Heading is clockwise from magnetic north, in degrees.
+type Orientation struct {
+	Time       time.Time
+	Quaternion Quaternion
+	Euler      Euler
+	Heading    float64
+}
+
+// Accelerometer reads the acceleration in g
+type Accelerometer interface {
+	ReadAccelerometer() (Vector, error)
+}
+
+// Gyroscope reads the angular velocity in degrees/s
+type Gyroscope interface {
+	ReadGyroscope() (Vector, error)
+}
+
+// Magnetometer reads the magnetic field, in any unit
+type Magnetometer interface {
+	ReadMagnetometer() (Vector, error)
+}
+
+// Compass reads a heading in degrees clockwise from magnetic north, of a
+// compass lying level
+type Compass interface {
+	ReadHeading() (float64, error)
+}
+
+// AccelerometerFunc reads an accelerometer with a function
+type AccelerometerFunc func() (Vector, error)
+
+// ReadAccelerometer calls f
+func (f AccelerometerFunc) ReadAccelerometer() (Vector, error) { return f() }
+
+// GyroscopeFunc reads a gyroscope with a function
+type GyroscopeFunc func() (Vector, error)
+
+// ReadGyroscope calls f
+func (f GyroscopeFunc) ReadGyroscope() (Vector, error) { return f() }
+
+// MagnetometerFunc reads a magnetometer with a function
+type MagnetometerFunc func() (Vector, error)
+
+// ReadMagnetometer calls f
+func (f MagnetometerFunc) ReadMagnetometer() (Vector, error) { return f() }
+
+// CompassFunc reads a compass with a function
+type CompassFunc func() (float64, error)
+
+// ReadHeading calls f
+func (f CompassFunc) ReadHeading() (float64, error) { return f() }
+
+// compassMagnetometer is the horizontal magnetic field of a compass lying
+// level
+type compassMagnetometer struct {
+	Compass
+}
+
+func (c compassMagnetometer) ReadMagnetometer() (Vector, error) {
+	heading, err := c.ReadHeading()
+	if err != nil {
+		return Vector{}, err
+	}
+	heading = radians(heading)
+	return Vector{X: math.Cos(heading), Y: math.Sin(heading)}, nil
+}
+
+// Heading returns the tilt compensated heading in degrees, clockwise from
+// magnetic north, of the accelerometer and magnetometer readings
+func Heading(accel Vector, mag Vector) float64 {
+	north, west, _ := earthAxes(accel, mag)
+	return normalizeHeading(degrees(math.Atan2(-west.X, north.X)))
+}
+
+// Euler returns the roll, pitch and yaw angles of q
+func (q Quaternion) Euler() Euler {
+	sinPitch := 2 * (q.W*q.Y - q.Z*q.X)
+	if sinPitch > 1 {
+		sinPitch = 1
+	} else if sinPitch < -1 {
+		sinPitch = -1
+	}
+	return Euler{
+		Roll:  degrees(math.Atan2(2*(q.W*q.X+q.Y*q.Z), 1-2*(q.X*q.X+q.Y*q.Y))),
+		Pitch: degrees(math.Asin(sinPitch)),
+		Yaw:   degrees(math.Atan2(2*(q.W*q.Z+q.X*q.Y), 1-2*(q.Y*q.Y+q.Z*q.Z))),
+	}
+}
+
+// Quaternion returns the rotation of the roll, pitch and yaw angles of e
+func (e Euler) Quaternion() Quaternion {
+	cr, sr := math.Cos(radians(e.Roll)/2), math.Sin(radians(e.Roll)/2)
+	cp, sp := math.Cos(radians(e.Pitch)/2), math.Sin(radians(e.Pitch)/2)
+	cy, sy := math.Cos(radians(e.Yaw)/2), math.Sin(radians(e.Yaw)/2)
+	return Quaternion{
+		W: cr*cp*cy + sr*sp*sy,
+		X: sr*cp*cy - cr*sp*sy,
+		Y: cr*sp*cy + sr*cp*sy,
+		Z: cr*cp*sy - sr*sp*cy,
+	}
+}
+
+// measured returns the orientation measured by the accelerometer and the
+// magnetometer. Without a magnetometer, the yaw is the one of q.
+func measured(q Quaternion, accel Vector, mag Vector) Quaternion {
+	a := accel.normalize()
+	e := Euler{
+		Roll:  degrees(math.Atan2(a.Y, a.Z)),
+		Pitch: degrees(math.Atan2(-a.X, math.Hypot(a.Y, a.Z))),
+		Yaw:   q.Euler().Yaw,
+	}
+	if !mag.zero() {
+		e.Yaw = -Heading(accel, mag)
+	}
+	return e.Quaternion()
+}
+
+// earthAxes returns the north, west and up axes of the earth in the frame of
+// the sensor
+func earthAxes(accel Vector, mag Vector) (north Vector, west Vector, up Vector) {
+	up = accel.normalize()
+	west = up.cross(mag).normalize()
+	north = west.cross(up)
+	return
+}
+
+func (v Vector) zero() bool { return v.X == 0 && v.Y == 0 && v.Z == 0 }
+
+func (v Vector) normalize() Vector {
+	n := math.Sqrt(v.X*v.X + v.Y*v.Y + v.Z*v.Z)
+	if n == 0 {
+		return v
+	}
+	return Vector{X: v.X / n, Y: v.Y / n, Z: v.Z / n}
+}
+
+func (v Vector) cross(o Vector) Vector {
+	return Vector{
+		X: v.Y*o.Z - v.Z*o.Y,
+		Y: v.Z*o.X - v.X*o.Z,
+		Z: v.X*o.Y - v.Y*o.X,
+	}
+}
+
+func (q Quaternion) normalize() Quaternion {
+	n := math.Sqrt(q.W*q.W + q.X*q.X + q.Y*q.Y + q.Z*q.Z)
+	if n == 0 {
+		return Quaternion{W: 1}
+	}
+	return Quaternion{W: q.W / n, X: q.X / n, Y: q.Y / n, Z: q.Z / n}
+}
+
+// integrate returns q rotated by the angular velocity gyro, in radians/s,
+// during dt seconds
+func (q Quaternion) integrate(gyro Vector, dt float64) Quaternion {
+	return Quaternion{
+		W: q.W + 0.5*dt*(-q.X*gyro.X-q.Y*gyro.Y-q.Z*gyro.Z),
+		X: q.X + 0.5*dt*(q.W*gyro.X+q.Y*gyro.Z-q.Z*gyro.Y),
+		Y: q.Y + 0.5*dt*(q.W*gyro.Y-q.X*gyro.Z+q.Z*gyro.X),
+		Z: q.Z + 0.5*dt*(q.W*gyro.Z+q.X*gyro.Y-q.Y*gyro.X),
+	}.normalize()
+}
+
+func radians(degrees float64) float64 { return degrees * math.Pi / 180 }
+func degrees(radians float64) float64 { return radians * 180 / math.Pi }
+
+func normalizeHeading(heading float64) float64 {
+	heading = math.Mod(heading, 360)
+	if heading < 0 {
+		heading += 360
+	}
+	return heading
+}
@@ -0,0 +1,191 @@
+package orientation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var _ gobot.Driver = (*OrientationDriver)(nil)
+
+// inertialSensor is an accelerometer and gyroscope which are read together
+type inertialSensor interface {
+	ReadInertial() (accel Vector, gyro Vector, err error)
+}
+
+// OrientationDriver estimates the orientation of an accelerometer, and
+// optionally of a gyroscope and a magnetometer or compass mounted with the
+// same axes
+type OrientationDriver struct {
+	name          string
+	interval      time.Duration
+	halt          chan bool
+	started       bool
+	filter        Filter
+	accelerometer Accelerometer
+	gyroscope     Gyroscope
+	magnetometer  Magnetometer
+	mutex         sync.Mutex
+	orientation   Orientation
+	gobot.Eventer
+	gobot.Commander
+}
+
+// NewOrientationDriver creates a new driver with specified name, which
+// reads its sensors from the given sources.
+//
+// Accepts:
+//
+//	Accelerometer, Gyroscope, Magnetometer or Compass: the sensors, a source
+//	may implement several of them
+//	Filter: the filter fusing the sensors, defaults to a ComplementaryFilter
+//	weighting the gyroscope with 0.98
+//	time.Duration: interval at which the sensors are read, defaults to 10ms
+//
+// Adds the following API Commands:
+//
+//	"Orientation" - See OrientationDriver.Orientation
+func NewOrientationDriver(name string, v ...interface{}) *OrientationDriver {
+	d := &OrientationDriver{
+		name:        name,
+		interval:    10 * time.Millisecond,
+		halt:        make(chan bool),
+		filter:      NewComplementaryFilter(0.98),
+		orientation: Orientation{Quaternion: Quaternion{W: 1}},
+		Eventer:     gobot.NewEventer(),
+		Commander:   gobot.NewCommander(),
+	}
+
+	for _, arg := range v {
+		if duration, ok := arg.(time.Duration); ok {
+			d.interval = duration
+		}
+		if filter, ok := arg.(Filter); ok {
+			d.filter = filter
+		}
+		if accelerometer, ok := arg.(Accelerometer); ok {
+			d.accelerometer = accelerometer
+		}
+		if gyroscope, ok := arg.(Gyroscope); ok {
+			d.gyroscope = gyroscope
+		}
+		if compass, ok := arg.(Compass); ok {
+			d.magnetometer = compassMagnetometer{compass}
+		}
+		if magnetometer, ok := arg.(Magnetometer); ok {
+			d.magnetometer = magnetometer
+		}
+	}
+
+	d.AddEvent(Data)
+	d.AddEvent(Error)
+
+	d.AddCommand("Orientation", func(params map[string]interface{}) interface{} {
+		return d.Orientation()
+	})
+
+	return d
+}
+
+func (d *OrientationDriver) Name() string                 { return d.name }
+func (d *OrientationDriver) Connection() gobot.Connection { return nil }
+
+// Start reads the sensors every interval, and fuses them into an
+// orientation.
+// Emits the Events:
+//
+//	Data Orientation - the estimated orientation
+//	Error error - error reading a sensor
+func (d *OrientationDriver) Start() (errs []error) {
+	if d.accelerometer == nil {
+		return []error{ErrNoAccelerometer}
+	}
+
+	d.started = true
+	go func() {
+		for {
+			if err := d.update(time.Now()); err != nil {
+				gobot.Publish(d.Event(Error), err)
+			} else {
+				gobot.Publish(d.Event(Data), d.Orientation())
+			}
+			select {
+			case <-time.After(d.interval):
+			case <-d.halt:
+				return
+			}
+		}
+	}()
+	return
+}
+
+// Halt stops reading the sensors
+func (d *OrientationDriver) Halt() (errs []error) {
+	if d.started {
+		d.halt <- true
+		d.started = false
+	}
+	return
+}
+
+// Orientation returns the last estimated orientation
+func (d *OrientationDriver) Orientation() Orientation {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.orientation
+}
+
+// Reset restarts the estimation from the orientation measured by the
+// accelerometer and the magnetometer
+func (d *OrientationDriver) Reset() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.orientation = Orientation{Quaternion: Quaternion{W: 1}}
+}
+
+// update reads the sensors and updates the orientation with the filter. The
+// first update takes the measured orientation.
+func (d *OrientationDriver) update(t time.Time) (err error) {
+	var gyro, accel, mag Vector
+	if s, ok := d.accelerometer.(inertialSensor); ok && interface{}(d.gyroscope) == interface{}(d.accelerometer) {
+		// both are read from the same sample
+		if accel, gyro, err = s.ReadInertial(); err != nil {
+			return
+		}
+	} else {
+		if accel, err = d.accelerometer.ReadAccelerometer(); err != nil {
+			return
+		}
+		if d.gyroscope != nil {
+			if gyro, err = d.gyroscope.ReadGyroscope(); err != nil {
+				return
+			}
+		}
+	}
+	if d.magnetometer != nil {
+		if mag, err = d.magnetometer.ReadMagnetometer(); err != nil {
+			return
+		}
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	q := d.orientation.Quaternion
+	if d.orientation.Time.IsZero() {
+		q = measured(q, accel, mag)
+	} else {
+		gyro = Vector{X: radians(gyro.X), Y: radians(gyro.Y), Z: radians(gyro.Z)}
+		q = d.filter.Update(q, gyro, accel, mag, t.Sub(d.orientation.Time).Seconds())
+	}
+
+	e := q.Euler()
+	d.orientation = Orientation{
+		Time:       t,
+		Quaternion: q,
+		Euler:      e,
+		Heading:    normalizeHeading(-e.Yaw),
+	}
+	return
+}
@@ -0,0 +1,120 @@
+package orientation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestOrientationDriver(t *testing.T) {
+	accel := AccelerometerFunc(func() (Vector, error) { return Vector{Z: 1}, nil })
+	filter := NewMadgwickFilter(0.1)
+
+	d := NewOrientationDriver("imu", accel, filter, 100*time.Millisecond)
+	gobottest.Assert(t, d.Name(), "imu")
+	gobottest.Assert(t, d.Connection(), (gobot.Connection)(nil))
+	gobottest.Assert(t, d.interval, 100*time.Millisecond)
+	gobottest.Assert(t, d.filter, Filter(filter))
+	gobottest.Refute(t, d.accelerometer, nil)
+	gobottest.Assert(t, d.gyroscope, nil)
+	gobottest.Assert(t, d.magnetometer, nil)
+	gobottest.Assert(t, d.Orientation().Quaternion, Quaternion{W: 1})
+	gobottest.Refute(t, d.Command("Orientation"), nil)
+
+	d = NewOrientationDriver("imu")
+	gobottest.Assert(t, d.interval, 10*time.Millisecond)
+	gobottest.Assert(t, d.Start(), []error{ErrNoAccelerometer})
+	gobottest.Assert(t, len(d.Halt()), 0)
+}
+
+func TestOrientationDriverUpdate(t *testing.T) {
+	q := Euler{Roll: 10, Yaw: -90}.Quaternion()
+	compass := CompassFunc(func() (float64, error) { return 90, nil })
+	accel := AccelerometerFunc(func() (Vector, error) {
+		return inSensorFrame(q, Vector{Z: 1}), nil
+	})
+	gyroErr := errors.New("gyroscope error")
+	gyroErrors := false
+	gyro := GyroscopeFunc(func() (Vector, error) {
+		if gyroErrors {
+			return Vector{}, gyroErr
+		}
+		return Vector{}, nil
+	})
+	d := NewOrientationDriver("imu", accel, gyro, compass)
+
+	// the first update measures the orientation
+	now := time.Now()
+	gobottest.Assert(t, d.update(now), nil)
+	o := d.Orientation()
+	gobottest.Assert(t, o.Time, now)
+	gobottest.Assert(t, roundEuler(o.Euler), Euler{Roll: 10, Yaw: -90})
+	gobottest.Assert(t, round(o.Heading), 90.0)
+
+	gobottest.Assert(t, d.update(now.Add(10*time.Millisecond)), nil)
+	o = d.Command("Orientation")(map[string]interface{}{}).(Orientation)
+	gobottest.Assert(t, o.Time, now.Add(10*time.Millisecond))
+	gobottest.Assert(t, round(o.Heading), 90.0)
+
+	gyroErrors = true
+	gobottest.Assert(t, d.update(now.Add(20*time.Millisecond)), gyroErr)
+	gobottest.Assert(t, d.Orientation().Time, now.Add(10*time.Millisecond))
+
+	d.Reset()
+	gobottest.Assert(t, d.Orientation().Time, time.Time{})
+}
+
+// inertialTestSensor is an accelerometer and gyroscope which must be read
+// together
+type inertialTestSensor struct {
+	reads int
+}
+
+func (s *inertialTestSensor) ReadAccelerometer() (Vector, error) {
+	return Vector{}, errors.New("accelerometer read alone")
+}
+func (s *inertialTestSensor) ReadGyroscope() (Vector, error) {
+	return Vector{}, errors.New("gyroscope read alone")
+}
+func (s *inertialTestSensor) ReadInertial() (accel Vector, gyro Vector, err error) {
+	s.reads++
+	return Vector{Z: 1}, Vector{}, nil
+}
+
+func TestOrientationDriverInertial(t *testing.T) {
+	s := &inertialTestSensor{}
+	d := NewOrientationDriver("imu", s)
+
+	now := time.Now()
+	gobottest.Assert(t, d.update(now), nil)
+	gobottest.Assert(t, d.update(now.Add(10*time.Millisecond)), nil)
+	gobottest.Assert(t, s.reads, 2)
+	gobottest.Assert(t, roundEuler(d.Orientation().Euler), Euler{})
+
+	// a separate gyroscope is read on its own
+	gyro := GyroscopeFunc(func() (Vector, error) { return Vector{}, nil })
+	d = NewOrientationDriver("imu", s, gyro)
+	gobottest.Refute(t, d.update(now), nil)
+}
+
+func TestOrientationDriverStart(t *testing.T) {
+	accel := AccelerometerFunc(func() (Vector, error) { return Vector{Z: 1}, nil })
+	d := NewOrientationDriver("imu", accel, 1*time.Millisecond)
+
+	sem := make(chan Orientation)
+	gobot.Once(d.Event(Data), func(data interface{}) {
+		sem <- data.(Orientation)
+	})
+	gobottest.Assert(t, len(d.Start()), 0)
+
+	select {
+	case o := <-sem:
+		gobottest.Assert(t, roundEuler(o.Euler), Euler{})
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Orientation data event not published")
+	}
+	gobottest.Assert(t, len(d.Halt()), 0)
+}
@@ -1,55 +1,113 @@
 package pebble
 
 import (
+	"encoding/json"
+	"sync"
+	"time"
+
 	"github.com/hybridgroup/gobot"
 )
 
 var _ gobot.Driver = (*PebbleDriver)(nil)
 
+const (
+	// DefaultMaxMessages is the default size of the queue of messages
+	DefaultMaxMessages = 32
+	// DefaultMessageTTL is the default time to live of the messages
+	DefaultMessageTTL = time.Minute
+)
+
 type PebbleDriver struct {
 	name       string
 	connection gobot.Connection
 	gobot.Commander
 	gobot.Eventer
-	Messages []string
+	// MaxMessages is the size of the queue of the messages to be sent to
+	// the watch. The oldest message is dropped when the queue is full, and
+	// the queue is unbounded when it is 0.
+	MaxMessages int
+	// MessageTTL is the time after which a message which was not
+	// acknowledged is dropped
+	MessageTTL time.Duration
+	mutex      sync.Mutex
+	nextID     int
+	queue      []*Message
+	sent       map[int]*Message
 }
 
 // NewPebbleDriver creates a new pebble driver with specified name
 // Adds following events:
 //		button - Sent when a pebble button is pressed
 //		accel - Pebble watch acceleromenter data
+//		accel_data - Batch of accelerometer samples, parsed from accel data
 //		tab - When a pebble watch tap event is detected
+//		message - Message sent by the watch app
 // And the following API commands:
 //		"publish_event"
+//		"publish_message"
 //		"send_notification"
+//		"send_message"
 //		"pending_message"
+//		"next_message"
+//		"ack_message"
 func NewPebbleDriver(adaptor *PebbleAdaptor, name string) *PebbleDriver {
 	p := &PebbleDriver{
-		name:       name,
-		connection: adaptor,
-		Messages:   []string{},
-		Eventer:    gobot.NewEventer(),
-		Commander:  gobot.NewCommander(),
+		name:        name,
+		connection:  adaptor,
+		MaxMessages: DefaultMaxMessages,
+		MessageTTL:  DefaultMessageTTL,
+		nextID:      1,
+		sent:        make(map[int]*Message),
+		Eventer:     gobot.NewEventer(),
+		Commander:   gobot.NewCommander(),
 	}
 
 	p.AddEvent("button")
 	p.AddEvent("accel")
+	p.AddEvent("accel_data")
 	p.AddEvent("tap")
+	p.AddEvent("message")
 
 	p.AddCommand("publish_event", func(params map[string]interface{}) interface{} {
-		p.PublishEvent(params["name"].(string), params["data"].(string))
+		p.PublishEvent(params["name"].(string), textParam(params["data"]))
 		return nil
 	})
 
+	p.AddCommand("publish_message", func(params map[string]interface{}) interface{} {
+		m := &Message{ID: intParam(params["id"])}
+		m.Type, _ = params["type"].(string)
+		m.Data = params["data"]
+		p.PublishMessage(m)
+		return map[string]interface{}{"id": m.ID}
+	})
+
 	p.AddCommand("send_notification", func(params map[string]interface{}) interface{} {
-		p.SendNotification(params["message"].(string))
-		return nil
+		return p.SendMessage(NotificationMessage, params["message"].(string)).ID
+	})
+
+	p.AddCommand("send_message", func(params map[string]interface{}) interface{} {
+		msgType, _ := params["type"].(string)
+		if msgType == "" {
+			msgType = DataMessage
+		}
+		return p.SendMessage(msgType, params["data"]).ID
 	})
 
 	p.AddCommand("pending_message", func(params map[string]interface{}) interface{} {
 		return p.PendingMessage()
 	})
 
+	p.AddCommand("next_message", func(params map[string]interface{}) interface{} {
+		if m := p.NextMessage(); m != nil {
+			return m
+		}
+		return nil
+	})
+
+	p.AddCommand("ack_message", func(params map[string]interface{}) interface{} {
+		return p.Ack(intParam(params["id"]))
+	})
+
 	return p
 }
 func (d *PebbleDriver) Name() string                 { return d.name }
@@ -58,28 +116,201 @@ func (d *PebbleDriver) Connection() gobot.Connection { return d.connection }
 // Start returns true if driver is initialized correctly
 func (d *PebbleDriver) Start() (errs []error) { return }
 
-// Halt returns true if driver is halted succesfully
-func (d *PebbleDriver) Halt() (errs []error) { return }
+// Halt drops the messages which were not acknowledged
+func (d *PebbleDriver) Halt() (errs []error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for _, m := range d.queue {
+		m.finish(ErrMessageDropped)
+	}
+	for _, m := range d.sent {
+		m.finish(ErrMessageDropped)
+	}
+	d.queue = nil
+	d.sent = make(map[int]*Message)
+	return
+}
 
-// PublishEvent publishes event with specified name and data in gobot
+// PublishEvent publishes event with specified name and data in gobot. The
+// data of accel events is also published as []AccelData on accel_data when
+// it is a batch of samples.
 func (d *PebbleDriver) PublishEvent(name string, data string) {
 	gobot.Publish(d.Event(name), data)
+	if name == "accel" {
+		if samples, err := ParseAccelData(data); err == nil {
+			gobot.Publish(d.Event("accel_data"), samples)
+		}
+	}
+}
+
+// PublishMessage publishes a message sent by the watch app on the message
+// event
+func (d *PebbleDriver) PublishMessage(m *Message) {
+	gobot.Publish(d.Event("message"), m)
 }
 
 // SendNotification appends message to list of notifications to be sent to watch
 func (d *PebbleDriver) SendNotification(message string) string {
-	d.Messages = append(d.Messages, message)
+	d.SendMessage(NotificationMessage, message)
 	return message
 }
 
-// PendingMessages returns messages to be sent as notifications to pebble
+// SendNotificationWithTimeout sends message as a notification, and waits
+// until the watch acknowledges it, for at most timeout. The notification is
+// dropped when it is not acknowledged in time.
+func (d *PebbleDriver) SendNotificationWithTimeout(message string, timeout time.Duration) error {
+	m := d.SendMessage(NotificationMessage, message)
+	if err := m.Wait(timeout); err != ErrAckTimeout {
+		return err
+	}
+	d.drop(m, ErrAckTimeout)
+	<-m.done
+	return m.err
+}
+
+// SendMessage appends a message of type msgType with data to the queue of
+// the messages to be sent to the watch, and returns it
+func (d *PebbleDriver) SendMessage(msgType string, data interface{}) *Message {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.expire()
+
+	m := newMessage(d.nextID, msgType, data, d.MessageTTL)
+	d.nextID++
+	if d.MaxMessages > 0 && len(d.queue) >= d.MaxMessages {
+		d.queue[0].finish(ErrMessageDropped)
+		d.queue = d.queue[1:]
+	}
+	d.queue = append(d.queue, m)
+	return m
+}
+
+// Messages returns the messages waiting to be sent to the watch
+func (d *PebbleDriver) Messages() []*Message {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.expire()
+	return append([]*Message{}, d.queue...)
+}
+
+// NextMessage removes the next message from the queue and returns it, or
+// returns nil when there is none. The message is delivered when the watch
+// acknowledges it with Ack.
+// (Not intented to be used directly)
+func (d *PebbleDriver) NextMessage() *Message {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.expire()
+
+	if len(d.queue) < 1 {
+		return nil
+	}
+	m := d.queue[0]
+	d.queue = d.queue[1:]
+	d.sent[m.ID] = m
+	return m
+}
+
+// PendingMessages returns messages to be sent as notifications to pebble,
+// and considers them delivered, for the watch apps which do not acknowledge
+// the messages
 // (Not intented to be used directly)
 func (d *PebbleDriver) PendingMessage() string {
-	if len(d.Messages) < 1 {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.expire()
+
+	if len(d.queue) < 1 {
 		return ""
 	}
-	m := d.Messages[0]
-	d.Messages = d.Messages[1:]
+	m := d.queue[0]
+	d.queue = d.queue[1:]
+	m.finish(nil)
 
-	return m
+	return m.Text()
+}
+
+// Ack acknowledges the delivery of the message with id, and returns false
+// when there is no such message
+// (Not intented to be used directly)
+func (d *PebbleDriver) Ack(id int) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if m, ok := d.sent[id]; ok {
+		delete(d.sent, id)
+		m.finish(nil)
+		return true
+	}
+	for i, m := range d.queue {
+		if m.ID == id {
+			d.queue = append(d.queue[:i], d.queue[i+1:]...)
+			m.finish(nil)
+			return true
+		}
+	}
+	return false
+}
+
+// drop removes m from the queue and the sent messages, and finishes it with
+// err unless it was delivered meanwhile
+func (d *PebbleDriver) drop(m *Message, err error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.sent[m.ID] == m {
+		delete(d.sent, m.ID)
+	}
+	for i, q := range d.queue {
+		if q == m {
+			d.queue = append(d.queue[:i], d.queue[i+1:]...)
+			break
+		}
+	}
+	m.finish(err)
+}
+
+// expire drops the expired messages, with the lock held
+func (d *PebbleDriver) expire() {
+	now := time.Now()
+	queue := d.queue[:0]
+	for _, m := range d.queue {
+		if m.expired(now) {
+			m.finish(ErrMessageExpired)
+		} else {
+			queue = append(queue, m)
+		}
+	}
+	for i := len(queue); i < len(d.queue); i++ {
+		d.queue[i] = nil
+	}
+	d.queue = queue
+
+	for id, m := range d.sent {
+		if m.expired(now) {
+			m.finish(ErrMessageExpired)
+			delete(d.sent, id)
+		}
+	}
+}
+
+// textParam returns a command param as text, encoding it in JSON when it is
+// not a string
+func textParam(param interface{}) string {
+	if s, ok := param.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(param)
+	return string(b)
+}
+
+// intParam returns a numeric command param as an int
+func intParam(param interface{}) int {
+	switch n := param.(type) {
+	case float64:
+		return int(n)
+	case int:
+		return n
+	}
+	return 0
 }
@@ -32,7 +32,7 @@ func TestPebbleDriver(t *testing.T) {
 	d.SendNotification("Hello")
 	d.SendNotification("World")
 
-	gobottest.Assert(t, d.Messages[0], "Hello")
+	gobottest.Assert(t, d.Messages()[0].Data, "Hello")
 	gobottest.Assert(t, d.PendingMessage(), "Hello")
 	gobottest.Assert(t, d.PendingMessage(), "World")
 	gobottest.Assert(t, d.PendingMessage(), "")
@@ -62,9 +62,165 @@ func TestPebbleDriver(t *testing.T) {
 	}
 
 	d.Command("send_notification")(map[string]interface{}{"message": "Hey buddy!"})
-	gobottest.Assert(t, d.Messages[0], "Hey buddy!")
+	gobottest.Assert(t, d.Messages()[0].Data, "Hey buddy!")
 
 	message := d.Command("pending_message")(map[string]interface{}{})
 	gobottest.Assert(t, message, "Hey buddy!")
 
 }
+
+func TestPebbleDriverMessages(t *testing.T) {
+	d := initTestPebbleDriver()
+
+	id := d.Command("send_message")(map[string]interface{}{"data": map[string]interface{}{"speed": 10}})
+	d.SendNotification("Hello")
+	gobottest.Assert(t, len(d.Messages()), 2)
+
+	m := d.Command("next_message")(map[string]interface{}{}).(*Message)
+	gobottest.Assert(t, m.ID, id)
+	gobottest.Assert(t, m.Type, DataMessage)
+	gobottest.Assert(t, m.Text(), `{"speed":10}`)
+	gobottest.Assert(t, len(d.Messages()), 1)
+
+	gobottest.Assert(t, d.Command("ack_message")(map[string]interface{}{"id": float64(m.ID)}), true)
+	gobottest.Assert(t, m.Wait(10*time.Millisecond), nil)
+	gobottest.Assert(t, d.Ack(m.ID), false)
+
+	m = d.NextMessage()
+	gobottest.Assert(t, m.Type, NotificationMessage)
+	gobottest.Assert(t, m.Data, "Hello")
+	gobottest.Assert(t, m.Wait(10*time.Millisecond), ErrAckTimeout)
+	gobottest.Assert(t, d.NextMessage(), (*Message)(nil))
+	gobottest.Assert(t, d.Command("next_message")(map[string]interface{}{}), nil)
+}
+
+func TestPebbleDriverSendNotificationWithTimeout(t *testing.T) {
+	d := initTestPebbleDriver()
+
+	go func() {
+		for {
+			if m := d.NextMessage(); m != nil {
+				d.Ack(m.ID)
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	gobottest.Assert(t, d.SendNotificationWithTimeout("Hello", time.Second), nil)
+
+	gobottest.Assert(t, d.SendNotificationWithTimeout("World", 10*time.Millisecond), ErrAckTimeout)
+	gobottest.Assert(t, len(d.Messages()), 0)
+	gobottest.Assert(t, d.NextMessage(), (*Message)(nil))
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			if m := d.NextMessage(); m != nil {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	gobottest.Assert(t, d.SendNotificationWithTimeout("Hey", 50*time.Millisecond), ErrAckTimeout)
+	d.mutex.Lock()
+	gobottest.Assert(t, len(d.sent), 0)
+	d.mutex.Unlock()
+	gobottest.Assert(t, d.Ack(3), false)
+}
+
+func TestPebbleDriverPendingMessageAck(t *testing.T) {
+	d := initTestPebbleDriver()
+
+	m := d.SendMessage(DataMessage, []int{1, 2})
+	gobottest.Assert(t, d.PendingMessage(), "[1,2]")
+	gobottest.Assert(t, m.Wait(10*time.Millisecond), nil)
+}
+
+func TestPebbleDriverQueueBound(t *testing.T) {
+	d := initTestPebbleDriver()
+	d.MaxMessages = 2
+
+	first := d.SendMessage(NotificationMessage, "1")
+	d.SendMessage(NotificationMessage, "2")
+	d.SendMessage(NotificationMessage, "3")
+
+	gobottest.Assert(t, first.Wait(10*time.Millisecond), ErrMessageDropped)
+	gobottest.Assert(t, d.PendingMessage(), "2")
+	gobottest.Assert(t, d.PendingMessage(), "3")
+	gobottest.Assert(t, d.PendingMessage(), "")
+}
+
+func TestPebbleDriverMessageExpiry(t *testing.T) {
+	d := initTestPebbleDriver()
+	d.MessageTTL = 10 * time.Millisecond
+
+	queued := d.SendMessage(NotificationMessage, "queued")
+	sent := d.SendMessage(NotificationMessage, "sent")
+	gobottest.Assert(t, d.Ack(queued.ID), true)
+	gobottest.Assert(t, d.NextMessage(), sent)
+
+	d.SendMessage(NotificationMessage, "expired")
+	<-time.After(20 * time.Millisecond)
+
+	gobottest.Assert(t, len(d.Messages()), 0)
+	gobottest.Assert(t, sent.Wait(10*time.Millisecond), ErrMessageExpired)
+	gobottest.Assert(t, d.Ack(sent.ID), false)
+}
+
+func TestPebbleDriverHaltDropsMessages(t *testing.T) {
+	d := initTestPebbleDriver()
+
+	queued := d.SendMessage(NotificationMessage, "queued")
+	d.SendMessage(NotificationMessage, "sent")
+	sent := d.NextMessage()
+	d.Halt()
+
+	gobottest.Assert(t, queued.Wait(10*time.Millisecond), ErrMessageDropped)
+	gobottest.Assert(t, sent.Wait(10*time.Millisecond), ErrMessageDropped)
+	gobottest.Assert(t, len(d.Messages()), 0)
+}
+
+func TestPebbleDriverPublishMessage(t *testing.T) {
+	d := initTestPebbleDriver()
+	sem := make(chan *Message, 1)
+
+	gobot.On(d.Event("message"), func(data interface{}) {
+		sem <- data.(*Message)
+	})
+
+	ack := d.Command("publish_message")(map[string]interface{}{
+		"id":   float64(7),
+		"type": "status",
+		"data": map[string]interface{}{"battery": float64(80)},
+	})
+	gobottest.Assert(t, ack, map[string]interface{}{"id": 7})
+
+	select {
+	case m := <-sem:
+		gobottest.Assert(t, m.ID, 7)
+		gobottest.Assert(t, m.Type, "status")
+		gobottest.Assert(t, m.Data, map[string]interface{}{"battery": float64(80)})
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Message Event was not published")
+	}
+}
+
+func TestPebbleDriverAccelData(t *testing.T) {
+	d := initTestPebbleDriver()
+	sem := make(chan []AccelData, 1)
+
+	gobot.On(d.Event("accel_data"), func(data interface{}) {
+		sem <- data.([]AccelData)
+	})
+
+	d.Command("publish_event")(map[string]interface{}{"name": "accel", "data": "1,2,3;4,5,6,1,1420070400000"})
+
+	select {
+	case samples := <-sem:
+		gobottest.Assert(t, samples, []AccelData{
+			{X: 1, Y: 2, Z: 3},
+			{X: 4, Y: 5, Z: 6, DidVibrate: true, Timestamp: 1420070400000},
+		})
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("Accel data Event was not published")
+	}
+}
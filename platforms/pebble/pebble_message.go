@@ -0,0 +1,150 @@
+package pebble
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message types of the messages sent to the watch
+const (
+	// NotificationMessage is a notification, whose data is its text
+	NotificationMessage = "notification"
+	// DataMessage is data for the watch app, whose data is any JSON value
+	DataMessage = "data"
+)
+
+var (
+	// ErrAckTimeout is returned by Wait when the watch does not acknowledge
+	// the message in time
+	ErrAckTimeout = errors.New("Pebble message was not acknowledged in time")
+	// ErrMessageExpired is returned by Wait when the message expires before
+	// the watch acknowledges it
+	ErrMessageExpired = errors.New("Pebble message expired")
+	// ErrMessageDropped is returned by Wait when the message is dropped
+	// from the full queue, or when the driver is halted
+	ErrMessageDropped = errors.New("Pebble message dropped")
+)
+
+// Message is a message exchanged with the watch app, encoded in JSON
+type Message struct {
+	ID   int         `json:"id"`
+	Type string      `json:"type"`
+	Data interface{} `json:"data,omitempty"`
+	// Expires is the time after which the message is dropped when the watch
+	// has not acknowledged it
+	Expires time.Time `json:"-"`
+
+	done chan struct{}
+	err  error
+}
+
+// newMessage returns a new message, which expires after ttl
+func newMessage(id int, msgType string, data interface{}, ttl time.Duration) *Message {
+	return &Message{
+		ID:      id,
+		Type:    msgType,
+		Data:    data,
+		Expires: time.Now().Add(ttl),
+		done:    make(chan struct{}),
+	}
+}
+
+// Wait waits until the watch acknowledges the message, for at most timeout.
+// It returns nil when the message is acknowledged, ErrAckTimeout when it is
+// not in time, and ErrMessageExpired or ErrMessageDropped when the message
+// was removed from the queue.
+func (m *Message) Wait(timeout time.Duration) error {
+	select {
+	case <-m.done:
+		return m.err
+	case <-time.After(timeout):
+		return ErrAckTimeout
+	}
+}
+
+// Text returns the data of the message as text: the data itself when it is
+// a string, or its JSON encoding
+func (m *Message) Text() string {
+	if s, ok := m.Data.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(m.Data)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// finish ends the delivery of the message with err, once
+func (m *Message) finish(err error) {
+	select {
+	case <-m.done:
+	default:
+		m.err = err
+		close(m.done)
+	}
+}
+
+// expired returns whether the message is expired at now
+func (m *Message) expired(now time.Time) bool {
+	return now.After(m.Expires)
+}
+
+// AccelData is an accelerometer sample of the watch, in milli-G
+type AccelData struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	Z int `json:"z"`
+	// DidVibrate is true when the watch vibrated during the sample
+	DidVibrate bool `json:"did_vibrate"`
+	// Timestamp is the time of the sample in milliseconds since the epoch
+	Timestamp int64 `json:"timestamp"`
+}
+
+// ParseAccelData parses a batch of accelerometer samples sent by the watch,
+// either as a JSON array of samples:
+//
+//	[{"x": 10, "y": -20, "z": -1000, "timestamp": 1420070400000}, ...]
+//
+// or as text, with the samples separated by semicolons and the fields of a
+// sample by commas, where the vibration flag and the timestamp are optional:
+//
+//	10,-20,-1000,0,1420070400000;12,-18,-1004
+func ParseAccelData(data string) (samples []AccelData, err error) {
+	data = strings.TrimSpace(data)
+	if strings.HasPrefix(data, "[") {
+		err = json.Unmarshal([]byte(data), &samples)
+		return
+	}
+
+	for _, s := range strings.Split(data, ";") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		fields := strings.Split(s, ",")
+		if len(fields) < 3 || len(fields) > 5 {
+			return nil, errors.New("Invalid accelerometer sample: " + s)
+		}
+		values := make([]int64, len(fields))
+		for i, f := range fields {
+			if values[i], err = strconv.ParseInt(strings.TrimSpace(f), 10, 64); err != nil {
+				return nil, errors.New("Invalid accelerometer sample: " + s)
+			}
+		}
+		sample := AccelData{X: int(values[0]), Y: int(values[1]), Z: int(values[2])}
+		if len(values) > 3 {
+			sample.DidVibrate = values[3] != 0
+		}
+		if len(values) > 4 {
+			sample.Timestamp = values[4]
+		}
+		samples = append(samples, sample)
+	}
+	if len(samples) == 0 {
+		return nil, errors.New("No accelerometer samples")
+	}
+	return
+}
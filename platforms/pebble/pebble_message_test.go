@@ -0,0 +1,44 @@
+package pebble
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestParseAccelData(t *testing.T) {
+	samples, err := ParseAccelData("10,-20,-1000")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, samples, []AccelData{{X: 10, Y: -20, Z: -1000}})
+
+	samples, err = ParseAccelData(" 1,2,3,0,100; 4, 5, 6 ;")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, samples, []AccelData{
+		{X: 1, Y: 2, Z: 3, Timestamp: 100},
+		{X: 4, Y: 5, Z: 6},
+	})
+
+	samples, err = ParseAccelData(`[{"x": 1, "y": 2, "z": 3, "did_vibrate": true, "timestamp": 100}]`)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, samples, []AccelData{{X: 1, Y: 2, Z: 3, DidVibrate: true, Timestamp: 100}})
+}
+
+func TestParseAccelDataError(t *testing.T) {
+	_, err := ParseAccelData("100")
+	gobottest.Assert(t, err.Error(), "Invalid accelerometer sample: 100")
+
+	_, err = ParseAccelData("1,2,z")
+	gobottest.Assert(t, err.Error(), "Invalid accelerometer sample: 1,2,z")
+
+	_, err = ParseAccelData("")
+	gobottest.Assert(t, err.Error(), "No accelerometer samples")
+
+	_, err = ParseAccelData("[{")
+	gobottest.Refute(t, err, nil)
+}
+
+func TestMessageText(t *testing.T) {
+	gobottest.Assert(t, (&Message{Data: "Hello"}).Text(), "Hello")
+	gobottest.Assert(t, (&Message{Data: map[string]int{"a": 1}}).Text(), `{"a":1}`)
+	gobottest.Assert(t, (&Message{}).Text(), "null")
+}
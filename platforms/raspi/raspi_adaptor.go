@@ -1,16 +1,14 @@
 package raspi
 
 import (
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
-	"strconv"
-	"strings"
 
 	"github.com/talmai/gobot"
 	"github.com/talmai/gobot/platforms/gpio"
 	"github.com/talmai/gobot/platforms/i2c"
+	"github.com/talmai/gobot/platforms/spi"
 	"github.com/talmai/gobot/sysfs"
 )
 
@@ -20,138 +18,53 @@ var _ gpio.DigitalReader = (*RaspiAdaptor)(nil)
 var _ gpio.DigitalWriter = (*RaspiAdaptor)(nil)
 
 var _ i2c.I2c = (*RaspiAdaptor)(nil)
+
+var _ spi.Spi = (*RaspiAdaptor)(nil)
 var _ i2c.I2cExtended = (*RaspiAdaptor)(nil)
+var _ i2c.I2cConnector = (*RaspiAdaptor)(nil)
 
 var readFile = func() ([]byte, error) {
 	return ioutil.ReadFile("/proc/cpuinfo")
 }
 
+// RaspiAdaptor is the gobot.Adaptor representation for the Raspberry Pi
 type RaspiAdaptor struct {
-	name        string
-	revision    string
-	i2cLocation string
-	digitalPins map[int]sysfs.DigitalPin
-	pwmPins     []int
-	i2cDevice   sysfs.I2cDevice
-}
-
-var pins = map[string]map[string]int{
-	"3": map[string]int{
-		"1": 0,
-		"2": 2,
-		"3": 2,
-	},
-	"5": map[string]int{
-		"1": 1,
-		"2": 3,
-		"3": 3,
-	},
-	"7": map[string]int{
-		"*": 4,
-	},
-	"8": map[string]int{
-		"*": 14,
-	},
-	"10": map[string]int{
-		"*": 15,
-	},
-	"11": map[string]int{
-		"*": 17,
-	},
-	"12": map[string]int{
-		"*": 18,
-	},
-	"13": map[string]int{
-		"1": 21,
-		"2": 27,
-		"3": 27,
-	},
-	"15": map[string]int{
-		"*": 22,
-	},
-	"16": map[string]int{
-		"*": 23,
-	},
-	"18": map[string]int{
-		"*": 24,
-	},
-	"19": map[string]int{
-		"*": 10,
-	},
-	"21": map[string]int{
-		"*": 9,
-	},
-	"22": map[string]int{
-		"*": 25,
-	},
-	"23": map[string]int{
-		"*": 11,
-	},
-	"24": map[string]int{
-		"*": 8,
-	},
-	"26": map[string]int{
-		"*": 7,
-	},
-	"29": map[string]int{
-		"3": 5,
-	},
-	"31": map[string]int{
-		"3": 6,
-	},
-	"32": map[string]int{
-		"3": 12,
-	},
-	"33": map[string]int{
-		"3": 13,
-	},
-	"35": map[string]int{
-		"3": 19,
-	},
-	"36": map[string]int{
-		"3": 16,
-	},
-	"37": map[string]int{
-		"3": 26,
-	},
-	"38": map[string]int{
-		"3": 20,
-	},
-	"40": map[string]int{
-		"3": 21,
-	},
-}
-
-// NewRaspiAdaptor creates a RaspiAdaptor with specified name and
+	name          string
+	board         *Board
+	i2cDefaultBus int
+	digitalPins   map[int]sysfs.DigitalPin
+	pwmPins       []int
+	i2cBuses      map[int]*sysfs.I2cBus
+	spiDevices    map[string]sysfs.SpiDevice
+}
+
+// NewRaspiAdaptor creates a RaspiAdaptor with specified name and detects
+// the board model from /proc/cpuinfo
 func NewRaspiAdaptor(name string) *RaspiAdaptor {
 	r := &RaspiAdaptor{
 		name:        name,
 		digitalPins: make(map[int]sysfs.DigitalPin),
 		pwmPins:     []int{},
+		i2cBuses:    make(map[int]*sysfs.I2cBus),
+		spiDevices:  make(map[string]sysfs.SpiDevice),
 	}
 	content, _ := readFile()
-	for _, v := range strings.Split(string(content), "\n") {
-		if strings.Contains(v, "Revision") {
-			s := strings.Split(string(v), " ")
-			version, _ := strconv.ParseInt("0x"+s[len(s)-1], 0, 64)
-			r.i2cLocation = "/dev/i2c-1"
-			if version <= 3 {
-				r.revision = "1"
-				r.i2cLocation = "/dev/i2c-0"
-			} else if version <= 15 {
-				r.revision = "2"
-			} else {
-				r.revision = "3"
-			}
-		}
+	board, err := parseCpuinfo(content)
+	if err != nil {
+		board = defaultBoard()
 	}
+	r.board = board
+	r.i2cDefaultBus = board.I2cBus
 
 	return r
 }
 
 func (r *RaspiAdaptor) Name() string { return r.name }
 
-func (r *RaspiAdaptor) IsPlatform() bool { return r.i2cLocation != "/dev/i2c-0" }
+// Board returns the Board model detected from /proc/cpuinfo
+func (r *RaspiAdaptor) Board() *Board { return r.board }
+
+func (r *RaspiAdaptor) IsPlatform() bool { return r.i2cDefaultBus != 0 }
 
 // Connect starts conection with board and creates
 // digitalPins and pwmPins adaptor maps
@@ -173,24 +86,22 @@ func (r *RaspiAdaptor) Finalize() (errs []error) {
 			errs = append(errs, err)
 		}
 	}
-	if r.i2cDevice != nil {
-		if err := r.i2cDevice.Close(); err != nil {
+	for _, bus := range r.i2cBuses {
+		if err := bus.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, device := range r.spiDevices {
+		if err := device.Close(); err != nil {
 			errs = append(errs, err)
 		}
 	}
 	return errs
 }
 
+// translatePin converts a header pin number or gpio name to its BCM number
 func (r *RaspiAdaptor) translatePin(pin string) (i int, err error) {
-	if val, ok := pins[pin][r.revision]; ok {
-		i = val
-	} else if val, ok := pins[pin]["*"]; ok {
-		i = val
-	} else {
-		err = errors.New("Not a valid pin")
-		return
-	}
-	return
+	return r.board.Pin(pin)
 }
 
 func (r *RaspiAdaptor) pwmPin(pin string) (i int, err error) {
@@ -256,50 +167,216 @@ func (r *RaspiAdaptor) DigitalWrite(pin string, val byte) (err error) {
 
 // I2cStart starts a i2c device in specified address
 func (r *RaspiAdaptor) I2cStart(address int) (err error) {
-	if r.i2cDevice == nil {
-		r.i2cDevice, err = sysfs.NewI2cDevice(r.i2cLocation, address)
+	_, err = r.i2cDevice(address)
+	return
+}
+
+// I2cGetConnection returns a connection to the i2c device at address on the
+// bus /dev/i2c-N. The transactions of each connection are serialised with
+// those of the other devices on the bus.
+func (r *RaspiAdaptor) I2cGetConnection(address int, bus int) (connection i2c.I2cConnection, err error) {
+	device, err := r.i2cBus(bus).Device(address)
+	if err != nil {
+		return
+	}
+	return device, nil
+}
+
+// I2cDefaultBus returns the i2c bus on the header pins of the board model
+func (r *RaspiAdaptor) I2cDefaultBus() int { return r.i2cDefaultBus }
+
+// i2cBus returns the i2c bus /dev/i2c-N, which is opened when its first
+// device is connected
+func (r *RaspiAdaptor) i2cBus(bus int) *sysfs.I2cBus {
+	if r.i2cBuses[bus] == nil {
+		r.i2cBuses[bus] = sysfs.NewI2cBus(fmt.Sprintf("/dev/i2c-%v", bus))
 	}
-	return err
+	return r.i2cBuses[bus]
+}
+
+// i2cDevice returns the device at address on the default i2c bus
+func (r *RaspiAdaptor) i2cDevice(address int) (*sysfs.I2cBusDevice, error) {
+	return r.i2cBus(r.I2cDefaultBus()).Device(address)
 }
 
-// I2CWrite writes data to i2c device
+// I2cWrite writes data to i2c device
 func (r *RaspiAdaptor) I2cWrite(address int, data []byte) (err error) {
-	if err = r.i2cDevice.SetAddress(address); err != nil {
+	device, err := r.i2cDevice(address)
+	if err != nil {
 		return
 	}
-	_, err = r.i2cDevice.Write(data)
+	_, err = device.Write(data)
 	return
 }
 
-// I2CWrite writes data to i2c device
+// I2cWriteWord writes a 16 bit value to a register of the i2c device
 func (r *RaspiAdaptor) I2cWriteWord(address int, register uint8, value uint16) (err error) {
-	if err = r.i2cDevice.SetAddress(address); err != nil {
+	device, err := r.i2cDevice(address)
+	if err != nil {
 		return
 	}
-	_, err = r.i2cDevice.WriteWord(register, value)
+	_, err = device.WriteWord(register, value)
 	return
 }
 
-// I2cRead returns value from i2c device using specified size
+// I2cRead returns size bytes from the i2c device
 func (r *RaspiAdaptor) I2cRead(address int, size int) (data []byte, err error) {
-	if err = r.i2cDevice.SetAddress(address); err != nil {
+	device, err := r.i2cDevice(address)
+	if err != nil {
 		return
 	}
 	data = make([]byte, size)
-	_, err = r.i2cDevice.Read(data)
+	_, err = device.Read(data)
 	return
 }
 
-// I2cRead returns value from i2c device using specified size
+// I2cReadRegister returns size bytes from the i2c device, address[0], starting
+// at the register address[1]
 func (r *RaspiAdaptor) I2cReadRegister(address []byte, size int) (data []byte, err error) {
-	if err = r.i2cDevice.SetAddress(int(address[0])); err != nil {
+	device, err := r.i2cDevice(int(address[0]))
+	if err != nil {
 		return
 	}
 	data = make([]byte, size)
-	_, err = r.i2cDevice.ReadRegister(address[1:], data)
+	_, err = device.ReadRegister(address[1], data)
 	return
 }
 
+// I2cWriteQuick sends the read/write bit to the i2c device without any data
+func (r *RaspiAdaptor) I2cWriteQuick(address int, bit uint8) (err error) {
+	device, err := r.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.WriteQuick(bit)
+}
+
+// I2cReadByte reads a byte from the i2c device without selecting a register
+func (r *RaspiAdaptor) I2cReadByte(address int) (value uint8, err error) {
+	device, err := r.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.ReadByte()
+}
+
+// I2cWriteByte writes a byte to the i2c device without selecting a register
+func (r *RaspiAdaptor) I2cWriteByte(address int, value uint8) (err error) {
+	device, err := r.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.WriteByte(value)
+}
+
+// I2cReadByteData reads a byte register of the i2c device
+func (r *RaspiAdaptor) I2cReadByteData(address int, register uint8) (value uint8, err error) {
+	device, err := r.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.ReadByteData(register)
+}
+
+// I2cWriteByteData writes a byte register of the i2c device
+func (r *RaspiAdaptor) I2cWriteByteData(address int, register uint8, value uint8) (err error) {
+	device, err := r.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.WriteByteData(register, value)
+}
+
+// I2cReadWordData reads a word register of the i2c device
+func (r *RaspiAdaptor) I2cReadWordData(address int, register uint8) (value uint16, err error) {
+	device, err := r.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.ReadWordData(register)
+}
+
+// I2cWriteWordData writes a word register of the i2c device
+func (r *RaspiAdaptor) I2cWriteWordData(address int, register uint8, value uint16) (err error) {
+	device, err := r.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.WriteWordData(register, value)
+}
+
+// I2cProcessCall writes a word to a register of the i2c device and reads
+// back a word
+func (r *RaspiAdaptor) I2cProcessCall(address int, register uint8, value uint16) (result uint16, err error) {
+	device, err := r.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.ProcessCall(register, value)
+}
+
+// I2cReadBlockData reads an SMBus block from a register of the i2c device
+func (r *RaspiAdaptor) I2cReadBlockData(address int, register uint8) (data []byte, err error) {
+	device, err := r.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	data = make([]byte, sysfs.I2C_SMBUS_BLOCK_MAX)
+	n, err := device.ReadBlockData(register, data)
+	return data[:n], err
+}
+
+// I2cWriteBlockData writes an SMBus block to a register of the i2c device
+func (r *RaspiAdaptor) I2cWriteBlockData(address int, register uint8, data []byte) (err error) {
+	device, err := r.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.WriteBlockData(register, data)
+}
+
+// I2cBlockProcessCall writes an SMBus block to a register of the i2c device
+// and reads back a block
+func (r *RaspiAdaptor) I2cBlockProcessCall(address int, register uint8, data []byte) (result []byte, err error) {
+	device, err := r.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	result = make([]byte, sysfs.I2C_SMBUS_BLOCK_MAX)
+	n, err := device.BlockProcessCall(register, data, result)
+	return result[:n], err
+}
+
+// I2cReadI2cBlockData returns size bytes from the i2c device starting at
+// register
+func (r *RaspiAdaptor) I2cReadI2cBlockData(address int, register uint8, size int) (data []byte, err error) {
+	device, err := r.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	data = make([]byte, size)
+	n, err := device.ReadI2cBlockData(register, data)
+	return data[:n], err
+}
+
+// I2cWriteI2cBlockData writes data to the i2c device starting at register
+func (r *RaspiAdaptor) I2cWriteI2cBlockData(address int, register uint8, data []byte) (err error) {
+	device, err := r.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.WriteI2cBlockData(register, data)
+}
+
+// I2cTx writes w to the i2c device and then reads len(rd) bytes into rd
+func (r *RaspiAdaptor) I2cTx(address int, w []byte, rd []byte) (err error) {
+	device, err := r.i2cDevice(address)
+	if err != nil {
+		return
+	}
+	return device.Tx(w, rd)
+}
+
 func (r *RaspiAdaptor) PwmWrite(pin string, val byte) (err error) {
 	sysfsPin, err := r.pwmPin(pin)
 	if err != nil {
@@ -330,3 +407,30 @@ func (r *RaspiAdaptor) piBlaster(data string) (err error) {
 	_, err = fi.WriteString(data)
 	return
 }
+
+// SpiStart opens the spi device /dev/spidevB.C given its bus and chip select
+func (r *RaspiAdaptor) SpiStart(bus int, chip int, mode int, bits int, maxSpeed int64) (err error) {
+	location := fmt.Sprintf("/dev/spidev%v.%v", bus, chip)
+	if r.spiDevices[location] != nil {
+		return
+	}
+	device, err := sysfs.NewSpiDevice(location, mode, bits, maxSpeed)
+	if err != nil {
+		return
+	}
+	r.spiDevices[location] = device
+	return
+}
+
+// SpiTransfer writes tx to the spi device while reading the same number of
+// bytes into rx
+func (r *RaspiAdaptor) SpiTransfer(bus int, chip int, tx []byte, rx []byte) (err error) {
+	device := r.spiDevices[fmt.Sprintf("/dev/spidev%v.%v", bus, chip)]
+	if device == nil {
+		return spi.ErrNotStarted
+	}
+	return device.Tx(tx, rx)
+}
+
+// SpiDefaultBus returns the spi bus on the header pins, 0
+func (r *RaspiAdaptor) SpiDefaultBus() int { return 0 }
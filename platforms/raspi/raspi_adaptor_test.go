@@ -5,35 +5,10 @@ import (
 	"testing"
 
 	"github.com/hybridgroup/gobot/gobottest"
+	"github.com/hybridgroup/gobot/platforms/spi"
 	"github.com/hybridgroup/gobot/sysfs"
 )
 
-type NullReadWriteCloser struct {
-	contents []byte
-}
-
-func (n *NullReadWriteCloser) SetAddress(int) error {
-	return nil
-}
-
-func (n *NullReadWriteCloser) Write(b []byte) (int, error) {
-	n.contents = make([]byte, len(b))
-	copy(n.contents[:], b[:])
-
-	return len(b), nil
-}
-
-func (n *NullReadWriteCloser) Read(b []byte) (int, error) {
-	copy(b, n.contents)
-	return len(b), nil
-}
-
-var closeErr error = nil
-
-func (n *NullReadWriteCloser) Close() error {
-	return closeErr
-}
-
 func initTestRaspiAdaptor() *RaspiAdaptor {
 	readFile = func() ([]byte, error) {
 		return []byte(`
@@ -57,8 +32,9 @@ Serial          : 000000003bc748ea
 	}
 	a := NewRaspiAdaptor("myAdaptor")
 	gobottest.Assert(t, a.Name(), "myAdaptor")
-	gobottest.Assert(t, a.i2cLocation, "/dev/i2c-1")
-	gobottest.Assert(t, a.revision, "3")
+	gobottest.Assert(t, a.I2cDefaultBus(), 1)
+	gobottest.Assert(t, a.Board().Model, "B+")
+	gobottest.Assert(t, a.Board().Header, Header40)
 
 	readFile = func() ([]byte, error) {
 		return []byte(`
@@ -68,8 +44,9 @@ Serial          : 000000003bc748ea
 `), nil
 	}
 	a = NewRaspiAdaptor("myAdaptor")
-	gobottest.Assert(t, a.i2cLocation, "/dev/i2c-1")
-	gobottest.Assert(t, a.revision, "2")
+	gobottest.Assert(t, a.I2cDefaultBus(), 1)
+	gobottest.Assert(t, a.Board().Model, "B")
+	gobottest.Assert(t, a.Board().Header, Header26)
 
 	readFile = func() ([]byte, error) {
 		return []byte(`
@@ -79,9 +56,16 @@ Serial          : 000000003bc748ea
 `), nil
 	}
 	a = NewRaspiAdaptor("myAdaptor")
-	gobottest.Assert(t, a.i2cLocation, "/dev/i2c-0")
-	gobottest.Assert(t, a.revision, "1")
+	gobottest.Assert(t, a.I2cDefaultBus(), 0)
+	gobottest.Assert(t, a.Board().Model, "B")
+	gobottest.Assert(t, a.Board().PCB, "1.0")
 
+	readFile = func() ([]byte, error) {
+		return []byte{}, nil
+	}
+	a = NewRaspiAdaptor("myAdaptor")
+	gobottest.Assert(t, a.I2cDefaultBus(), 1)
+	gobottest.Assert(t, a.Board().Model, "Unknown")
 }
 func TestRaspiAdaptorFinalize(t *testing.T) {
 	a := initTestRaspiAdaptor()
@@ -147,14 +131,62 @@ func TestRaspiAdaptorDigitalIO(t *testing.T) {
 func TestRaspiAdaptorI2c(t *testing.T) {
 	a := initTestRaspiAdaptor()
 	fs := sysfs.NewMockFilesystem([]string{
+		"/dev/i2c-0",
 		"/dev/i2c-1",
 	})
 	sysfs.SetFilesystem(fs)
 	sysfs.SetSyscall(&sysfs.MockSyscall{})
 	a.I2cStart(0xff)
-	a.i2cDevice = &NullReadWriteCloser{}
 
 	a.I2cWrite(0xff, []byte{0x00, 0x01})
 	data, _ := a.I2cRead(0xff, 2)
 	gobottest.Assert(t, data, []byte{0x00, 0x01})
+
+	// without smbus support the commands are plain reads and writes
+	gobottest.Assert(t, a.I2cWriteByteData(0xff, 0x01, 0x02), nil)
+	gobottest.Assert(t, fs.Files["/dev/i2c-1"].Contents, "\x01\x02")
+	val, err := a.I2cReadByteData(0xff, 0x03)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, uint8(0x03))
+	gobottest.Assert(t, a.I2cTx(0xff, []byte{0x04}, data[:1]), nil)
+	gobottest.Assert(t, data[0], uint8(0x04))
+	_, err = a.I2cProcessCall(0xff, 0x01, 0x0203)
+	gobottest.Assert(t, err, sysfs.ErrI2cNotSupported)
+
+	con, err := a.I2cGetConnection(0xff, 1)
+	gobottest.Assert(t, err, nil)
+	con.Write([]byte{0x00, 0x01})
+	data = make([]byte, 2)
+	gobottest.Assert(t, con.Tx([]byte{0x00, 0x01}, data), nil)
+	gobottest.Assert(t, data, []byte{0x00, 0x01})
+
+	// the other bus is a separate device
+	con, err = a.I2cGetConnection(0xff, 0)
+	gobottest.Assert(t, err, nil)
+	con.Write([]byte{0x02})
+	gobottest.Assert(t, fs.Files["/dev/i2c-0"].Contents, "\x02")
+	gobottest.Assert(t, len(a.i2cBuses), 2)
+
+	_, err = a.I2cGetConnection(0xff, 3)
+	gobottest.Refute(t, err, nil)
+	gobottest.Assert(t, len(a.Finalize()), 0)
+}
+
+func TestRaspiAdaptorSpi(t *testing.T) {
+	a := initTestRaspiAdaptor()
+	fs := sysfs.NewMockFilesystem([]string{
+		"/dev/spidev0.0",
+	})
+	sysfs.SetFilesystem(fs)
+	sysfs.SetSyscall(&sysfs.MockSyscall{})
+
+	gobottest.Assert(t, a.SpiDefaultBus(), 0)
+	gobottest.Assert(t, a.SpiTransfer(0, 0, []byte{0x01}, nil), spi.ErrNotStarted)
+	gobottest.Refute(t, a.SpiStart(0, 1, spi.Mode0, 8, 1000000), nil)
+
+	gobottest.Assert(t, a.SpiStart(0, 0, spi.Mode0, 8, 1000000), nil)
+	gobottest.Assert(t, a.SpiStart(0, 0, spi.Mode0, 8, 1000000), nil)
+	gobottest.Assert(t, len(a.spiDevices), 1)
+	gobottest.Assert(t, a.SpiTransfer(0, 0, []byte{0x01}, make([]byte, 1)), nil)
+	gobottest.Assert(t, len(a.Finalize()), 0)
 }
@@ -0,0 +1,287 @@
+package raspi
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Header layouts found on the Raspberry Pi family
+const (
+	// HeaderNone is used by the Compute Modules, which expose GPIO on a SODIMM
+	// edge connector instead of a pin header
+	HeaderNone = 0
+	// Header26 is the 26 pin P1 header of the original Model A and Model B
+	Header26 = 26
+	// Header40 is the 40 pin J8 header of every board since the Model B+
+	Header40 = 40
+)
+
+// ErrUnknownRevision is returned when a revision code can not be decoded
+var ErrUnknownRevision = errors.New("Unknown Raspberry Pi revision")
+
+// Board describes a Raspberry Pi model as decoded from its revision code
+type Board struct {
+	// Revision is the revision code as reported by /proc/cpuinfo
+	Revision string
+	// Model is the board name, eg. "3B+" or "CM4"
+	Model string
+	// PCB is the board revision, eg. "1.2"
+	PCB string
+	// Processor is the SoC on the board, eg. "BCM2837"
+	Processor string
+	// Manufacturer is the company which manufactured the board
+	Manufacturer string
+	// Memory is the installed RAM in megabytes
+	Memory int
+	// I2cBus is the i2c bus routed to the SDA/SCL header pins
+	I2cBus int
+	// Header is the header layout, one of HeaderNone, Header26 or Header40
+	Header int
+	// PwmPins are the BCM numbers of the hardware PWM capable header pins
+	PwmPins []int
+
+	// header maps a physical header pin to its BCM number
+	header map[int]int
+}
+
+var (
+	header26Rev1 = map[int]int{
+		3: 0, 5: 1, 7: 4, 8: 14, 10: 15, 11: 17, 12: 18, 13: 21, 15: 22,
+		16: 23, 18: 24, 19: 10, 21: 9, 22: 25, 23: 11, 24: 8, 26: 7,
+	}
+
+	header26Rev2 = map[int]int{
+		3: 2, 5: 3, 7: 4, 8: 14, 10: 15, 11: 17, 12: 18, 13: 27, 15: 22,
+		16: 23, 18: 24, 19: 10, 21: 9, 22: 25, 23: 11, 24: 8, 26: 7,
+	}
+
+	header40 = map[int]int{
+		3: 2, 5: 3, 7: 4, 8: 14, 10: 15, 11: 17, 12: 18, 13: 27, 15: 22,
+		16: 23, 18: 24, 19: 10, 21: 9, 22: 25, 23: 11, 24: 8, 26: 7,
+		27: 0, 28: 1, 29: 5, 31: 6, 32: 12, 33: 13, 35: 19, 36: 16,
+		37: 26, 38: 20, 40: 21,
+	}
+)
+
+type oldRevision struct {
+	model        string
+	pcb          string
+	memory       int
+	manufacturer string
+}
+
+// oldRevisions are the revision codes used before the new style encoding
+// was introduced with the Raspberry Pi 2
+var oldRevisions = map[uint32]oldRevision{
+	0x0002: {"B", "1.0", 256, "Egoman"},
+	0x0003: {"B", "1.0", 256, "Egoman"},
+	0x0004: {"B", "2.0", 256, "Sony UK"},
+	0x0005: {"B", "2.0", 256, "Qisda"},
+	0x0006: {"B", "2.0", 256, "Egoman"},
+	0x0007: {"A", "2.0", 256, "Egoman"},
+	0x0008: {"A", "2.0", 256, "Sony UK"},
+	0x0009: {"A", "2.0", 256, "Qisda"},
+	0x000d: {"B", "2.0", 512, "Egoman"},
+	0x000e: {"B", "2.0", 512, "Sony UK"},
+	0x000f: {"B", "2.0", 512, "Egoman"},
+	0x0010: {"B+", "1.2", 512, "Sony UK"},
+	0x0011: {"CM1", "1.0", 512, "Sony UK"},
+	0x0012: {"A+", "1.1", 256, "Sony UK"},
+	0x0013: {"B+", "1.2", 512, "Embest"},
+	0x0014: {"CM1", "1.0", 512, "Embest"},
+	0x0015: {"A+", "1.1", 256, "Embest"},
+}
+
+var newModels = map[uint32]string{
+	0x00: "A",
+	0x01: "B",
+	0x02: "A+",
+	0x03: "B+",
+	0x04: "2B",
+	0x05: "Alpha",
+	0x06: "CM1",
+	0x08: "3B",
+	0x09: "Zero",
+	0x0a: "CM3",
+	0x0c: "Zero W",
+	0x0d: "3B+",
+	0x0e: "3A+",
+	0x10: "CM3+",
+	0x11: "4B",
+	0x12: "Zero 2 W",
+	0x13: "400",
+	0x14: "CM4",
+	0x15: "CM4S",
+	0x17: "5",
+	0x18: "CM5",
+	0x19: "500",
+	0x1a: "CM5 Lite",
+}
+
+var newProcessors = []string{"BCM2835", "BCM2836", "BCM2837", "BCM2711", "BCM2712"}
+
+var newManufacturers = []string{"Sony UK", "Egoman", "Embest", "Sony Japan", "Embest", "Stadium"}
+
+// newStyleFlag is set in revision codes using the new style encoding
+const newStyleFlag = 1 << 23
+
+// ParseRevision decodes an old or new style revision code, as found in the
+// "Revision" line of /proc/cpuinfo, into a Board
+func ParseRevision(revision string) (b *Board, err error) {
+	revision = strings.TrimSpace(revision)
+	code, err := strconv.ParseUint(revision, 16, 32)
+	if err != nil {
+		return nil, ErrUnknownRevision
+	}
+
+	if code&newStyleFlag != 0 {
+		b, err = parseNewRevision(uint32(code))
+	} else {
+		// the overvoltage bit is set on old style boards with a voided warranty
+		b, err = parseOldRevision(uint32(code) & 0xffffff)
+	}
+	if err != nil {
+		return nil, err
+	}
+	b.Revision = revision
+	return b, nil
+}
+
+func parseOldRevision(code uint32) (*Board, error) {
+	rev, ok := oldRevisions[code]
+	if !ok {
+		return nil, ErrUnknownRevision
+	}
+
+	b := &Board{
+		Model:        rev.model,
+		PCB:          rev.pcb,
+		Processor:    "BCM2835",
+		Manufacturer: rev.manufacturer,
+		Memory:       rev.memory,
+		I2cBus:       1,
+	}
+
+	switch {
+	case code <= 0x0003:
+		b.I2cBus = 0
+		b.setHeader(Header26, header26Rev1)
+	case code <= 0x000f:
+		b.setHeader(Header26, header26Rev2)
+	default:
+		b.setHeader(Header40, header40)
+	}
+	if b.IsComputeModule() {
+		b.Header = HeaderNone
+	}
+	return b, nil
+}
+
+func parseNewRevision(code uint32) (*Board, error) {
+	model, ok := newModels[(code>>4)&0xff]
+	if !ok {
+		return nil, ErrUnknownRevision
+	}
+	processor := int((code >> 12) & 0xf)
+	manufacturer := int((code >> 16) & 0xf)
+	if processor >= len(newProcessors) || manufacturer >= len(newManufacturers) {
+		return nil, ErrUnknownRevision
+	}
+
+	b := &Board{
+		Model:        model,
+		PCB:          fmt.Sprintf("1.%v", code&0xf),
+		Processor:    newProcessors[processor],
+		Manufacturer: newManufacturers[manufacturer],
+		Memory:       256 << ((code >> 20) & 0x7),
+		I2cBus:       1,
+	}
+	b.setHeader(Header40, header40)
+	if b.IsComputeModule() {
+		b.Header = HeaderNone
+	}
+	return b, nil
+}
+
+// defaultBoard is used when the revision code can not be determined, and
+// assumes the common 40 pin header layout
+func defaultBoard() *Board {
+	b := &Board{
+		Model:  "Unknown",
+		I2cBus: 1,
+	}
+	b.setHeader(Header40, header40)
+	return b
+}
+
+func (b *Board) setHeader(layout int, header map[int]int) {
+	b.Header = layout
+	b.header = header
+	if layout == Header26 {
+		b.PwmPins = []int{18}
+	} else {
+		b.PwmPins = []int{12, 13, 18, 19}
+	}
+}
+
+// IsComputeModule returns true if the board is a Compute Module
+func (b *Board) IsComputeModule() bool {
+	return strings.HasPrefix(b.Model, "CM")
+}
+
+// IsPwmPin returns true if the BCM numbered pin supports hardware PWM
+func (b *Board) IsPwmPin(bcm int) bool {
+	for _, p := range b.PwmPins {
+		if p == bcm {
+			return true
+		}
+	}
+	return false
+}
+
+// maxGpio returns the highest BCM gpio number available to the user
+func (b *Board) maxGpio() int {
+	if b.Header == HeaderNone {
+		return 53
+	}
+	return 27
+}
+
+// Pin translates a pin into its BCM gpio number. The pin may be a physical
+// header pin number such as "11", or a BCM number prefixed with either
+// "GPIO" or "BCM" such as "GPIO17".
+func (b *Board) Pin(pin string) (bcm int, err error) {
+	name := strings.ToUpper(strings.TrimSpace(pin))
+	for _, prefix := range []string{"GPIO", "BCM"} {
+		if strings.HasPrefix(name, prefix) {
+			bcm, err = strconv.Atoi(name[len(prefix):])
+			if err != nil || bcm < 0 || bcm > b.maxGpio() {
+				return -1, fmt.Errorf("Not a valid pin: %v", pin)
+			}
+			return bcm, nil
+		}
+	}
+
+	n, err := strconv.Atoi(name)
+	if err != nil || b.Header == HeaderNone {
+		return -1, fmt.Errorf("Not a valid pin: %v", pin)
+	}
+	bcm, ok := b.header[n]
+	if !ok {
+		return -1, fmt.Errorf("Not a valid pin: %v", pin)
+	}
+	return bcm, nil
+}
+
+// parseCpuinfo returns the Board described by the contents of /proc/cpuinfo
+func parseCpuinfo(content []byte) (*Board, error) {
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) == 2 && strings.TrimSpace(fields[0]) == "Revision" {
+			return ParseRevision(fields[1])
+		}
+	}
+	return nil, ErrUnknownRevision
+}
@@ -0,0 +1,119 @@
+package raspi
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func cpuinfo(revision string) []byte {
+	return []byte(`
+processor       : 0
+model name      : ARMv7 Processor rev 4 (v7l)
+Hardware        : BCM2835
+Revision        : ` + revision + `
+Serial          : 000000003bc748ea
+`)
+}
+
+func TestParseCpuinfo(t *testing.T) {
+	var tests = []struct {
+		revision     string
+		model        string
+		pcb          string
+		processor    string
+		manufacturer string
+		memory       int
+		i2cBus       int
+		header       int
+	}{
+		{"0002", "B", "1.0", "BCM2835", "Egoman", 256, 0, Header26},
+		{"000e", "B", "2.0", "BCM2835", "Sony UK", 512, 1, Header26},
+		{"0010", "B+", "1.2", "BCM2835", "Sony UK", 512, 1, Header40},
+		{"1000012", "A+", "1.1", "BCM2835", "Sony UK", 256, 1, Header40},
+		{"0014", "CM1", "1.0", "BCM2835", "Embest", 512, 1, HeaderNone},
+		{"a01041", "2B", "1.1", "BCM2836", "Sony UK", 1024, 1, Header40},
+		{"a02082", "3B", "1.2", "BCM2837", "Sony UK", 1024, 1, Header40},
+		{"a020d3", "3B+", "1.3", "BCM2837", "Sony UK", 1024, 1, Header40},
+		{"9000c1", "Zero W", "1.1", "BCM2835", "Sony UK", 512, 1, Header40},
+		{"c03111", "4B", "1.1", "BCM2711", "Sony UK", 4096, 1, Header40},
+		{"d03140", "CM4", "1.0", "BCM2711", "Sony UK", 8192, 1, HeaderNone},
+		{"c04170", "5", "1.0", "BCM2712", "Sony UK", 4096, 1, Header40},
+	}
+
+	for _, tt := range tests {
+		b, err := parseCpuinfo(cpuinfo(tt.revision))
+		gobottest.Assert(t, err, nil)
+		gobottest.Assert(t, b.Revision, tt.revision)
+		gobottest.Assert(t, b.Model, tt.model)
+		gobottest.Assert(t, b.PCB, tt.pcb)
+		gobottest.Assert(t, b.Processor, tt.processor)
+		gobottest.Assert(t, b.Manufacturer, tt.manufacturer)
+		gobottest.Assert(t, b.Memory, tt.memory)
+		gobottest.Assert(t, b.I2cBus, tt.i2cBus)
+		gobottest.Assert(t, b.Header, tt.header)
+	}
+}
+
+func TestParseCpuinfoUnknown(t *testing.T) {
+	for _, content := range [][]byte{
+		[]byte("Hardware        : BCM2835\n"),
+		cpuinfo("zzzz"),
+		cpuinfo("0001"),
+		cpuinfo("a0ff41"),
+	} {
+		_, err := parseCpuinfo(content)
+		gobottest.Assert(t, err, ErrUnknownRevision)
+	}
+}
+
+func TestBoardPin(t *testing.T) {
+	var tests = []struct {
+		revision string
+		pin      string
+		bcm      int
+		valid    bool
+	}{
+		{"0002", "3", 0, true},
+		{"0002", "13", 21, true},
+		{"000e", "3", 2, true},
+		{"000e", "13", 27, true},
+		{"000e", "29", 0, false},
+		{"a02082", "13", 27, true},
+		{"a02082", "29", 5, true},
+		{"a02082", "40", 21, true},
+		{"a02082", "1", 0, false},
+		{"a02082", "41", 0, false},
+		{"a02082", "GPIO17", 17, true},
+		{"a02082", "gpio4", 4, true},
+		{"a02082", "BCM27", 27, true},
+		{"a02082", "GPIO28", 0, false},
+		{"a02082", "GPIOx", 0, false},
+		{"a02082", "foo", 0, false},
+		{"d03140", "11", 0, false},
+		{"d03140", "GPIO44", 44, true},
+	}
+
+	for _, tt := range tests {
+		b, _ := ParseRevision(tt.revision)
+		bcm, err := b.Pin(tt.pin)
+		if tt.valid {
+			gobottest.Assert(t, err, nil)
+			gobottest.Assert(t, bcm, tt.bcm)
+		} else {
+			gobottest.Refute(t, err, nil)
+		}
+	}
+}
+
+func TestBoardPwmPins(t *testing.T) {
+	b, _ := ParseRevision("000e")
+	gobottest.Assert(t, b.PwmPins, []int{18})
+	gobottest.Assert(t, b.IsPwmPin(18), true)
+	gobottest.Assert(t, b.IsPwmPin(12), false)
+
+	b, _ = ParseRevision("a02082")
+	gobottest.Assert(t, b.PwmPins, []int{12, 13, 18, 19})
+	gobottest.Assert(t, b.IsPwmPin(12), true)
+	gobottest.Assert(t, b.IsPwmPin(17), false)
+}
@@ -8,8 +8,9 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync"
 
-	"github.com/donovanhide/eventsource"
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/platforms/gpio"
 )
@@ -21,35 +22,107 @@ var _ gpio.DigitalWriter = (*SparkCoreAdaptor)(nil)
 var _ gpio.AnalogReader = (*SparkCoreAdaptor)(nil)
 var _ gpio.PwmWriter = (*SparkCoreAdaptor)(nil)
 
-type SparkCoreAdaptor struct {
-	name        string
-	DeviceID    string
-	AccessToken string
-	APIServer   string
+// DefaultAPIServer is the API server of the Particle cloud
+const DefaultAPIServer = "https://api.particle.io"
+
+// DefaultClientID is the OAuth client of the token requests, whose secret is
+// the same
+const DefaultClientID = "particle"
+
+// ErrInvalidResponse is returned when the response of the cloud does not
+// hold the expected value
+var ErrInvalidResponse = errors.New("Invalid response from the particle cloud")
+
+// ErrNoRefreshToken is returned by RefreshAccessToken without a refresh token
+var ErrNoRefreshToken = errors.New("No refresh token to refresh the access token")
+
+// APIError is the error of a request rejected by the cloud
+type APIError struct {
+	// StatusCode is the HTTP status code of the response, which is 200 for
+	// the errors of the functions and variables of the device
+	StatusCode int
+	// Code and Description are the error and the description of the error
+	// returned by the cloud, if any
+	Code        string
+	Description string
 }
 
-type Event struct {
-	Name  string
-	Data  string
-	Error error
+func (e *APIError) Error() string {
+	switch {
+	case e.Code != "" && e.Description != "":
+		return e.Code + ": " + e.Description
+	case e.Code != "":
+		return e.Code
+	}
+	return fmt.Sprintf("%v %v: error communicating to the particle cloud",
+		e.StatusCode, http.StatusText(e.StatusCode))
 }
 
-var eventSource = func(url string) (chan eventsource.Event, chan error, error) {
-	stream, err := eventsource.Subscribe(url, "")
-	if err != nil {
-		return nil, nil, err
+// newAPIError returns the APIError of the response with statusCode and the
+// decoded body m
+func newAPIError(statusCode int, m map[string]interface{}) *APIError {
+	e := &APIError{StatusCode: statusCode}
+	switch code := m["error"].(type) {
+	case string:
+		e.Code = code
+	case nil:
+	default:
+		e.Code = fmt.Sprint(code)
 	}
-	return stream.Events, stream.Errors, nil
+	for _, key := range []string{"error_description", "info"} {
+		if description, ok := m[key].(string); ok && e.Description == "" {
+			e.Description = description
+		}
+	}
+	return e
+}
+
+// Token is an OAuth token of the cloud
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresIn is the lifetime of the access token, in seconds
+	ExpiresIn int `json:"expires_in"`
+}
+
+// Device is a device of the account, as listed by Devices
+type Device struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Connected     bool   `json:"connected"`
+	LastHeard     string `json:"last_heard"`
+	LastIPAddress string `json:"last_ip_address"`
+	ProductID     int    `json:"product_id"`
+	PlatformID    int    `json:"platform_id"`
+}
+
+type SparkCoreAdaptor struct {
+	name        string
+	DeviceID    string
+	AccessToken string
+	// RefreshToken refreshes the access token when the cloud rejects it
+	RefreshToken string
+	// ClientID and ClientSecret are the OAuth client of the token requests,
+	// DefaultClientID by default
+	ClientID     string
+	ClientSecret string
+	// OnTokenRefresh is called with the new token when the access token is
+	// refreshed, to store it
+	OnTokenRefresh func(Token)
+	APIServer      string
+	mutex          sync.Mutex
 }
 
 // NewSparkCoreAdaptor creates new spark core adaptor with deviceId and accessToken
-// using api.spark.io server as default
+// using the Particle cloud api.particle.io server as default
 func NewSparkCoreAdaptor(name string, deviceID string, accessToken string) *SparkCoreAdaptor {
 	return &SparkCoreAdaptor{
-		name:        name,
-		DeviceID:    deviceID,
-		AccessToken: accessToken,
-		APIServer:   "https://api.spark.io",
+		name:         name,
+		DeviceID:     deviceID,
+		AccessToken:  accessToken,
+		ClientID:     DefaultClientID,
+		ClientSecret: DefaultClientID,
+		APIServer:    DefaultAPIServer,
 	}
 }
 func (s *SparkCoreAdaptor) Name() string { return s.name }
@@ -67,16 +140,14 @@ func (s *SparkCoreAdaptor) Finalize() (errs []error) {
 // AnalogRead reads analog ping value using spark cloud api
 func (s *SparkCoreAdaptor) AnalogRead(pin string) (val int, err error) {
 	params := url.Values{
-		"params":       {pin},
-		"access_token": {s.AccessToken},
+		"params": {pin},
 	}
 
 	url := fmt.Sprintf("%v/analogread", s.deviceURL())
 
 	resp, err := s.requestToSpark("POST", url, params)
 	if err == nil {
-		val = int(resp["return_value"].(float64))
-		return
+		return returnValue(resp)
 	}
 
 	return 0, err
@@ -90,8 +161,7 @@ func (s *SparkCoreAdaptor) PwmWrite(pin string, level byte) (err error) {
 // AnalogWrite writes analog pin with specified level using spark cloud api
 func (s *SparkCoreAdaptor) AnalogWrite(pin string, level byte) (err error) {
 	params := url.Values{
-		"params":       {fmt.Sprintf("%v,%v", pin, level)},
-		"access_token": {s.AccessToken},
+		"params": {fmt.Sprintf("%v,%v", pin, level)},
 	}
 	url := fmt.Sprintf("%v/analogwrite", s.deviceURL())
 	_, err = s.requestToSpark("POST", url, params)
@@ -101,8 +171,7 @@ func (s *SparkCoreAdaptor) AnalogWrite(pin string, level byte) (err error) {
 // DigitalWrite writes to a digital pin using spark cloud api
 func (s *SparkCoreAdaptor) DigitalWrite(pin string, level byte) (err error) {
 	params := url.Values{
-		"params":       {fmt.Sprintf("%v,%v", pin, s.pinLevel(level))},
-		"access_token": {s.AccessToken},
+		"params": {fmt.Sprintf("%v,%v", pin, s.pinLevel(level))},
 	}
 	url := fmt.Sprintf("%v/digitalwrite", s.deviceURL())
 	_, err = s.requestToSpark("POST", url, params)
@@ -112,40 +181,27 @@ func (s *SparkCoreAdaptor) DigitalWrite(pin string, level byte) (err error) {
 // DigitalRead reads from digital pin using spark cloud api
 func (s *SparkCoreAdaptor) DigitalRead(pin string) (val int, err error) {
 	params := url.Values{
-		"params":       {pin},
-		"access_token": {s.AccessToken},
+		"params": {pin},
 	}
 	url := fmt.Sprintf("%v/digitalread", s.deviceURL())
 	resp, err := s.requestToSpark("POST", url, params)
 	if err == nil {
-		val = int(resp["return_value"].(float64))
-		return
+		if val, err = returnValue(resp); err == nil {
+			return
+		}
 	}
 	return -1, err
 }
 
 // EventStream returns a gobot.Event based on the following params:
 //
-// * source - "all"/"devices"/"device" (More info at: http://docs.spark.io/api/#reading-data-from-a-core-events)
+// * source - "all"/"devices"/"device" (More info at: https://docs.particle.io/reference/api/#get-a-stream-of-events)
 // * name  - Event name to subscribe for, leave blank to subscribe to all events.
 //
-// A new event is emitted as a spark.Event struct
-func (s *SparkCoreAdaptor) EventStream(source string, name string) (event *gobot.Event, err error) {
-	var url string
-
-	switch source {
-	case "all":
-		url = fmt.Sprintf("%s/v1/events/%s?access_token=%s", s.APIServer, name, s.AccessToken)
-	case "devices":
-		url = fmt.Sprintf("%s/v1/devices/events/%s?access_token=%s", s.APIServer, name, s.AccessToken)
-	case "device":
-		url = fmt.Sprintf("%s/events/%s?access_token=%s", s.deviceURL(), name, s.AccessToken)
-	default:
-		err = errors.New("source param should be: all, devices or device")
-		return
-	}
-
-	events, errors, err := eventSource(url)
+// A new event is emitted as a spark.Event struct. The events are published
+// until the returned stream is closed.
+func (s *SparkCoreAdaptor) EventStream(source string, name string) (event *gobot.Event, stream *Subscription, err error) {
+	stream, err = s.Subscribe(source, name)
 	if err != nil {
 		return
 	}
@@ -155,12 +211,12 @@ func (s *SparkCoreAdaptor) EventStream(source string, name string) (event *gobot
 	go func() {
 		for {
 			select {
-			case ev := <-events:
-				if ev.Event() != "" && ev.Data() != "" {
-					gobot.Publish(event, Event{Name: ev.Event(), Data: ev.Data()})
-				}
-			case ev := <-errors:
-				gobot.Publish(event, Event{Error: ev})
+			case ev := <-stream.Events:
+				gobot.Publish(event, ev)
+			case err := <-stream.Errors:
+				gobot.Publish(event, Event{Error: err})
+			case <-stream.done:
+				return
 			}
 		}
 	}()
@@ -169,7 +225,7 @@ func (s *SparkCoreAdaptor) EventStream(source string, name string) (event *gobot
 
 // Variable returns a core variable value as a string
 func (s *SparkCoreAdaptor) Variable(name string) (result string, err error) {
-	url := fmt.Sprintf("%v/%s?access_token=%s", s.deviceURL(), name, s.AccessToken)
+	url := fmt.Sprintf("%v/%s", s.deviceURL(), name)
 	resp, err := s.requestToSpark("GET", url, nil)
 
 	if err != nil {
@@ -184,6 +240,8 @@ func (s *SparkCoreAdaptor) Variable(name string) (result string, err error) {
 		result = strconv.FormatFloat(val.(float64), 'f', -1, 64)
 	case string:
 		result = val.(string)
+	default:
+		err = ErrInvalidResponse
 	}
 
 	return
@@ -195,8 +253,7 @@ func (s *SparkCoreAdaptor) Variable(name string) (result string, err error) {
 // If function is not defined in core, it will time out
 func (s *SparkCoreAdaptor) Function(name string, args string) (val int, err error) {
 	params := url.Values{
-		"args":         {args},
-		"access_token": {s.AccessToken},
+		"args": {args},
 	}
 
 	url := fmt.Sprintf("%s/%s", s.deviceURL(), name)
@@ -206,11 +263,91 @@ func (s *SparkCoreAdaptor) Function(name string, args string) (val int, err erro
 		return -1, err
 	}
 
-	val = int(resp["return_value"].(float64))
+	if val, err = returnValue(resp); err != nil {
+		return -1, err
+	}
+	return
+}
+
+// Devices lists the devices of the account
+func (s *SparkCoreAdaptor) Devices() (devices []Device, err error) {
+	err = s.request("GET", s.APIServer+"/v1/devices", nil, &devices)
+	return
+}
+
+// PublishEvent publishes an event with name and data to the devices
+// subscribed to it. A private event is only published to the devices of the
+// account.
+func (s *SparkCoreAdaptor) PublishEvent(name string, data string, private bool) (err error) {
+	params := url.Values{
+		"name":    {name},
+		"data":    {data},
+		"private": {strconv.FormatBool(private)},
+	}
+	_, err = s.requestToSpark("POST", s.APIServer+"/v1/devices/events", params)
+	return
+}
+
+// Login gets a new token with the username and password of the account
+func (s *SparkCoreAdaptor) Login(username string, password string) (Token, error) {
+	return s.requestToken(url.Values{
+		"grant_type": {"password"},
+		"username":   {username},
+		"password":   {password},
+	})
+}
+
+// RefreshAccessToken gets a new access token with the refresh token. The
+// adaptor refreshes the access token itself when the cloud rejects it.
+func (s *SparkCoreAdaptor) RefreshAccessToken() (Token, error) {
+	_, refreshToken := s.tokens()
+	if refreshToken == "" {
+		return Token{}, ErrNoRefreshToken
+	}
+	return s.requestToken(url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+// requestToken requests a token with the OAuth grant of params, and uses it
+// from then on
+func (s *SparkCoreAdaptor) requestToken(params url.Values) (token Token, err error) {
+	req, err := http.NewRequest("POST", s.APIServer+"/oauth/token", strings.NewReader(params.Encode()))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(s.ClientID, s.ClientSecret)
+	if err = s.send(req, &token); err != nil {
+		return
+	}
+	if token.AccessToken == "" {
+		return token, ErrInvalidResponse
+	}
+
+	s.mutex.Lock()
+	s.AccessToken = token.AccessToken
+	if token.RefreshToken != "" {
+		s.RefreshToken = token.RefreshToken
+	}
+	token.RefreshToken = s.RefreshToken
+	s.mutex.Unlock()
+
+	if s.OnTokenRefresh != nil {
+		s.OnTokenRefresh(token)
+	}
 	return
 }
 
-// setAPIServer sets spark cloud api server, this can be used to change from default api.spark.io
+// tokens returns the access and refresh tokens
+func (s *SparkCoreAdaptor) tokens() (accessToken string, refreshToken string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.AccessToken, s.RefreshToken
+}
+
+// setAPIServer sets spark cloud api server, this can be used to change from default api.particle.io
 func (s *SparkCoreAdaptor) setAPIServer(server string) {
 	s.APIServer = server
 }
@@ -218,7 +355,7 @@ func (s *SparkCoreAdaptor) setAPIServer(server string) {
 // deviceURL constructs device url to make requests from spark cloud api
 func (s *SparkCoreAdaptor) deviceURL() string {
 	if len(s.APIServer) <= 0 {
-		s.setAPIServer("https://api.spark.io")
+		s.setAPIServer(DefaultAPIServer)
 	}
 	return fmt.Sprintf("%v/v1/devices/%v", s.APIServer, s.DeviceID)
 }
@@ -231,34 +368,93 @@ func (s *SparkCoreAdaptor) pinLevel(level byte) string {
 	return "LOW"
 }
 
+// returnValue returns the return_value of the response m
+func returnValue(m map[string]interface{}) (int, error) {
+	val, ok := m["return_value"].(float64)
+	if !ok {
+		return 0, ErrInvalidResponse
+	}
+	return int(val), nil
+}
+
 // requestToSpark makes request to spark cloud server, return err != nil if there is
 // any issue with the request.
 func (s *SparkCoreAdaptor) requestToSpark(method string, url string, params url.Values) (m map[string]interface{}, err error) {
-	var resp *http.Response
+	err = s.request(method, url, params, &m)
+	return
+}
+
+// request makes a request authorized with the access token, and decodes the
+// JSON response into v. The request is made again with a new access token
+// when the cloud rejects the access token and there is a refresh token.
+func (s *SparkCoreAdaptor) request(method string, url string, params url.Values, v interface{}) (err error) {
+	err = s.authorizedRequest(method, url, params, v)
+	if s.tokenRejected(err) {
+		if _, err = s.RefreshAccessToken(); err == nil {
+			err = s.authorizedRequest(method, url, params, v)
+		}
+	}
+	return
+}
+
+// tokenRejected returns whether err is the rejection of the access token,
+// and the access token can be refreshed
+func (s *SparkCoreAdaptor) tokenRejected(err error) bool {
+	apiErr, ok := err.(*APIError)
+	_, refreshToken := s.tokens()
+	return ok && apiErr.StatusCode == http.StatusUnauthorized && refreshToken != ""
+}
 
+// authorizedRequest makes a request authorized with the access token
+func (s *SparkCoreAdaptor) authorizedRequest(method string, url string, params url.Values, v interface{}) error {
+	var req *http.Request
+	var err error
 	if method == "POST" {
-		resp, err = http.PostForm(url, params)
-	} else if method == "GET" {
-		resp, err = http.Get(url)
+		req, err = http.NewRequest(method, url, strings.NewReader(params.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		req, err = http.NewRequest(method, url, nil)
+	}
+	if err != nil {
+		return err
 	}
+	s.authorize(req)
+	return s.send(req, v)
+}
+
+// authorize sets the access token of req
+func (s *SparkCoreAdaptor) authorize(req *http.Request) {
+	if accessToken, _ := s.tokens(); accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+}
 
+// send sends req, and decodes the JSON response into v. It returns an
+// APIError when the status is not 200, or when the response holds an error.
+func (s *SparkCoreAdaptor) send(req *http.Request, v interface{}) (err error) {
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return
 	}
+	defer resp.Body.Close()
 
 	buf, err := ioutil.ReadAll(resp.Body)
-
 	if err != nil {
 		return
 	}
 
+	m := map[string]interface{}{}
 	json.Unmarshal(buf, &m)
-
-	if resp.Status != "200 OK" {
-		err = errors.New(fmt.Sprintf("&v: error communicating to the spark cloud", resp.Status))
-	} else if _, ok := m["error"]; ok {
-		err = errors.New(m["error"].(string))
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp.StatusCode, m)
+	}
+	if _, ok := m["error"]; ok {
+		return newAPIError(resp.StatusCode, m)
+	}
+	if err = json.Unmarshal(buf, v); err != nil {
+		return ErrInvalidResponse
 	}
-
 	return
 }
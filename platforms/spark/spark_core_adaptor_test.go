@@ -1,14 +1,11 @@
 package spark
 
 import (
-	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
-	"time"
 
-	"github.com/donovanhide/eventsource"
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/gobottest"
 )
@@ -75,7 +72,7 @@ func TestNewSparkCoreAdaptor(t *testing.T) {
 		t.Errorf("NewSparkCoreAdaptor() should have returned a *SparkCoreAdaptor")
 	}
 
-	gobottest.Assert(t, spark.APIServer, "https://api.spark.io")
+	gobottest.Assert(t, spark.APIServer, "https://api.particle.io")
 	gobottest.Assert(t, spark.Name(), "bot")
 }
 
@@ -304,7 +301,7 @@ func TestSparkCoreAdaptorDeviceURL(t *testing.T) {
 	//When APIServer is not set
 	a = &SparkCoreAdaptor{name: "sparkie", DeviceID: "myDevice", AccessToken: "token"}
 
-	gobottest.Assert(t, a.deviceURL(), "https://api.spark.io/v1/devices/myDevice")
+	gobottest.Assert(t, a.deviceURL(), "https://api.particle.io/v1/devices/myDevice")
 }
 
 func TestSparkCoreAdaptorPinLevel(t *testing.T) {
@@ -325,7 +322,7 @@ func TestSparkCoreAdaptorPostToSpark(t *testing.T) {
 	vals.Add("error", "error")
 	resp, err := a.requestToSpark("POST", "http://invalid%20host.com", vals)
 	if err == nil {
-		t.Errorf("requestToSpark() should return an error when request was unsuccessful but returned", resp)
+		t.Errorf("requestToSpark() should return an error when request was unsuccessful but returned %v", resp)
 	}
 
 	// When error reading body
@@ -339,86 +336,163 @@ func TestSparkCoreAdaptorPostToSpark(t *testing.T) {
 
 	resp, err = a.requestToSpark("POST", testServer.URL+"/existent", vals)
 	if err == nil {
-		t.Errorf("requestToSpark() should return an error when status is not 200 but returned", resp)
+		t.Errorf("requestToSpark() should return an error when status is not 200 but returned %v", resp)
 	}
 
 }
 
-type testEventSource struct {
-	event string
-	data  string
-}
+func TestSparkCoreAdaptorAuthorization(t *testing.T) {
+	a := initTestSparkCoreAdaptor()
+	testServer := createTestServer(func(w http.ResponseWriter, r *http.Request) {
+		gobottest.Assert(t, r.Header.Get("Authorization"), "Bearer token")
+		w.Write([]byte(`{"return_value": 1}`))
+	})
+	defer testServer.Close()
+	a.setAPIServer(testServer.URL)
 
-func (testEventSource) Id() string      { return "" }
-func (t testEventSource) Event() string { return t.event }
-func (t testEventSource) Data() string  { return t.data }
+	val, err := a.DigitalRead("D7")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, 1)
+}
 
-func TestSparkCoreAdaptorEventStream(t *testing.T) {
+func TestSparkCoreAdaptorInvalidResponse(t *testing.T) {
 	a := initTestSparkCoreAdaptor()
-	var url string
-	eventSource = func(u string) (chan eventsource.Event, chan error, error) {
-		url = u
-		return nil, nil, nil
-	}
-	a.EventStream("all", "ping")
-	gobottest.Assert(t, url, "https://api.spark.io/v1/events/ping?access_token=token")
-	a.EventStream("devices", "ping")
-	gobottest.Assert(t, url, "https://api.spark.io/v1/devices/events/ping?access_token=token")
-	a.EventStream("device", "ping")
-	gobottest.Assert(t, url, "https://api.spark.io/v1/devices/myDevice/events/ping?access_token=token")
-	_, err := a.EventStream("nothing", "ping")
-	gobottest.Assert(t, err.Error(), "source param should be: all, devices or device")
-
-	eventSource = func(u string) (chan eventsource.Event, chan error, error) {
-		return nil, nil, errors.New("error connecting sse")
-	}
+	testServer := createTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"return_value": "high"}`))
+	})
+	defer testServer.Close()
+	a.setAPIServer(testServer.URL)
+
+	val, err := a.DigitalRead("D7")
+	gobottest.Assert(t, err, ErrInvalidResponse)
+	gobottest.Assert(t, val, -1)
+	_, err = a.Function("hello", "")
+	gobottest.Assert(t, err, ErrInvalidResponse)
+	_, err = a.Variable("hello")
+	gobottest.Assert(t, err, ErrInvalidResponse)
+}
 
-	_, err = a.EventStream("devices", "")
-	gobottest.Assert(t, err.Error(), "error connecting sse")
+func TestSparkCoreAdaptorAPIError(t *testing.T) {
+	a := initTestSparkCoreAdaptor()
+	testServer := createTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error": "Permission Denied", "info": "I didn't recognize that device name or ID"}`))
+	})
+	defer testServer.Close()
+	a.setAPIServer(testServer.URL)
 
-	eventChan := make(chan eventsource.Event, 0)
-	errorChan := make(chan error, 0)
+	err := a.DigitalWrite("D7", 1)
+	gobottest.Assert(t, err, &APIError{
+		StatusCode:  http.StatusForbidden,
+		Code:        "Permission Denied",
+		Description: "I didn't recognize that device name or ID",
+	})
+	gobottest.Assert(t, err.Error(), "Permission Denied: I didn't recognize that device name or ID")
+	gobottest.Assert(t, (&APIError{StatusCode: 404}).Error(), "404 Not Found: error communicating to the particle cloud")
+}
 
-	eventSource = func(u string) (chan eventsource.Event, chan error, error) {
-		return eventChan, errorChan, nil
+func TestSparkCoreAdaptorTokenRefresh(t *testing.T) {
+	a := initTestSparkCoreAdaptor()
+	refreshed := []Token{}
+	a.OnTokenRefresh = func(token Token) {
+		refreshed = append(refreshed, token)
 	}
+	testServer := createTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/token" {
+			user, password, _ := r.BasicAuth()
+			gobottest.Assert(t, user, "particle")
+			gobottest.Assert(t, password, "particle")
+			r.ParseForm()
+			gobottest.Assert(t, r.Form.Get("grant_type"), "refresh_token")
+			gobottest.Assert(t, r.Form.Get("refresh_token"), "refresh")
+			w.Write([]byte(`{"access_token": "new", "expires_in": 7776000}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer new" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "invalid_token", "error_description": "The access token provided is invalid."}`))
+			return
+		}
+		w.Write([]byte(`{"return_value": 1}`))
+	})
+	defer testServer.Close()
+	a.setAPIServer(testServer.URL)
 
-	sem := make(chan bool, 0)
-	stream, err := a.EventStream("devices", "")
+	// without a refresh token, the error is returned
+	_, err := a.DigitalRead("D7")
+	gobottest.Assert(t, err.Error(), "invalid_token: The access token provided is invalid.")
+	_, err = a.RefreshAccessToken()
+	gobottest.Assert(t, err, ErrNoRefreshToken)
+
+	a.RefreshToken = "refresh"
+	val, err := a.DigitalRead("D7")
 	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, 1)
+	gobottest.Assert(t, a.AccessToken, "new")
+	gobottest.Assert(t, refreshed, []Token{{AccessToken: "new", RefreshToken: "refresh", ExpiresIn: 7776000}})
+}
 
-	// stream message
-	gobot.Once(stream, func(data interface{}) {
-		e := data.(Event)
-		gobottest.Assert(t, e.Name, "event")
-		gobottest.Assert(t, e.Data, "sse event")
-		gobottest.Assert(t, e.Error, nil)
-		sem <- true
+func TestSparkCoreAdaptorLogin(t *testing.T) {
+	a := NewSparkCoreAdaptor("bot", "myDevice", "")
+	testServer := createTestServer(func(w http.ResponseWriter, r *http.Request) {
+		gobottest.Assert(t, r.URL.Path, "/oauth/token")
+		r.ParseForm()
+		gobottest.Assert(t, r.Form.Get("grant_type"), "password")
+		gobottest.Assert(t, r.Form.Get("username"), "user@example.com")
+		if r.Form.Get("password") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "invalid_grant", "error_description": "User credentials are invalid"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token": "token", "refresh_token": "refresh", "expires_in": 60}`))
 	})
+	defer testServer.Close()
+	a.setAPIServer(testServer.URL)
 
-	eventChan <- testEventSource{event: "event", data: "sse event"}
+	_, err := a.Login("user@example.com", "wrong")
+	gobottest.Assert(t, err.(*APIError).Code, "invalid_grant")
 
-	select {
-	case <-sem:
-	case <-time.After(1 * time.Second):
-		t.Errorf("Did not recieve stream")
-	}
+	token, err := a.Login("user@example.com", "secret")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, token, Token{AccessToken: "token", RefreshToken: "refresh", ExpiresIn: 60})
+	gobottest.Assert(t, a.AccessToken, "token")
+	gobottest.Assert(t, a.RefreshToken, "refresh")
+}
 
-	// stream error
-	gobot.Once(stream, func(data interface{}) {
-		e := data.(Event)
-		gobottest.Assert(t, e.Name, "")
-		gobottest.Assert(t, e.Data, "")
-		gobottest.Assert(t, e.Error.Error(), "stream error")
-		sem <- true
+func TestSparkCoreAdaptorDevices(t *testing.T) {
+	a := initTestSparkCoreAdaptor()
+	testServer := createTestServer(func(w http.ResponseWriter, r *http.Request) {
+		gobottest.Assert(t, r.Method, "GET")
+		gobottest.Assert(t, r.URL.Path, "/v1/devices")
+		w.Write([]byte(`[{"id": "myDevice", "name": "bot", "connected": true, "last_heard": "2016-05-01T12:00:00.000Z", "platform_id": 6}]`))
 	})
+	defer testServer.Close()
+	a.setAPIServer(testServer.URL)
 
-	errorChan <- errors.New("stream error")
+	devices, err := a.Devices()
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, devices, []Device{{
+		ID:         "myDevice",
+		Name:       "bot",
+		Connected:  true,
+		LastHeard:  "2016-05-01T12:00:00.000Z",
+		PlatformID: 6,
+	}})
+}
 
-	select {
-	case <-sem:
-	case <-time.After(1 * time.Second):
-		t.Errorf("Did not recieve stream error")
-	}
+func TestSparkCoreAdaptorPublishEvent(t *testing.T) {
+	a := initTestSparkCoreAdaptor()
+	testServer := createTestServer(func(w http.ResponseWriter, r *http.Request) {
+		gobottest.Assert(t, r.Method, "POST")
+		gobottest.Assert(t, r.URL.Path, "/v1/devices/events")
+		r.ParseForm()
+		gobottest.Assert(t, r.Form.Get("name"), "temperature")
+		gobottest.Assert(t, r.Form.Get("data"), "21.5")
+		gobottest.Assert(t, r.Form.Get("private"), "true")
+		w.Write([]byte(`{"ok": true}`))
+	})
+	defer testServer.Close()
+	a.setAPIServer(testServer.URL)
 
+	gobottest.Assert(t, a.PublishEvent("temperature", "21.5", true), nil)
 }
@@ -0,0 +1,277 @@
+package spark
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultRetry is the time before reconnecting a stream, unless the cloud
+// sets it
+var defaultRetry = 3 * time.Second
+
+// Event is an event of the cloud, or an error of the stream
+type Event struct {
+	Name string
+	// Data is the data of the server-sent event, the JSON object of the
+	// cloud
+	Data  string
+	Error error
+	// ID is the id of the server-sent event
+	ID string
+	// Value, CoreID, PublishedAt and TTL are decoded from Data: the data
+	// published by the device, the device, the time of the event and its
+	// time to live in seconds
+	Value       string
+	CoreID      string
+	PublishedAt time.Time
+	TTL         int
+}
+
+// decode decodes the JSON object of the cloud from the data of e
+func (e *Event) decode() {
+	var data struct {
+		Data        string      `json:"data"`
+		TTL         interface{} `json:"ttl"`
+		PublishedAt string      `json:"published_at"`
+		CoreID      string      `json:"coreid"`
+	}
+	if json.Unmarshal([]byte(e.Data), &data) != nil {
+		return
+	}
+	e.Value, e.CoreID = data.Data, data.CoreID
+	e.PublishedAt, _ = time.Parse(time.RFC3339, data.PublishedAt)
+	switch ttl := data.TTL.(type) {
+	case float64:
+		e.TTL = int(ttl)
+	case string:
+		e.TTL, _ = strconv.Atoi(ttl)
+	}
+}
+
+// Subscription is a stream of server-sent events of the cloud. When the
+// connection is lost, it reconnects with the id of the last event received,
+// so that the cloud sends the events missed meanwhile.
+type Subscription struct {
+	// Events receives the events
+	Events chan Event
+	// Errors receives the errors of the connection, which are dropped
+	// when they are not read
+	Errors chan error
+
+	adaptor     *SparkCoreAdaptor
+	url         string
+	lastEventID string
+	retry       time.Duration
+	mutex       sync.Mutex
+	body        io.ReadCloser
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// Subscribe returns a stream of the events based on the following params:
+//
+// * source - "all"/"devices"/"device" (More info at: https://docs.particle.io/reference/api/#get-a-stream-of-events)
+// * name  - Event name prefix to subscribe for, leave blank to subscribe to all events.
+//
+// It returns the error of the first connection.
+func (s *SparkCoreAdaptor) Subscribe(source string, name string) (*Subscription, error) {
+	var url string
+
+	switch source {
+	case "all":
+		url = s.APIServer + "/v1/events"
+	case "devices":
+		url = s.APIServer + "/v1/devices/events"
+	case "device":
+		url = s.deviceURL() + "/events"
+	default:
+		return nil, errors.New("source param should be: all, devices or device")
+	}
+	if name != "" {
+		url += "/" + name
+	}
+
+	sub := &Subscription{
+		Events:  make(chan Event),
+		Errors:  make(chan error, 1),
+		adaptor: s,
+		url:     url,
+		retry:   defaultRetry,
+		done:    make(chan struct{}),
+	}
+	body, err := sub.connect()
+	if err != nil {
+		return nil, err
+	}
+	go sub.run(body)
+	return sub, nil
+}
+
+// Close closes the stream
+func (sub *Subscription) Close() {
+	sub.closeOnce.Do(func() {
+		close(sub.done)
+		sub.mutex.Lock()
+		defer sub.mutex.Unlock()
+		if sub.body != nil {
+			sub.body.Close()
+		}
+	})
+}
+
+// connect opens the stream from the last event received, with a new
+// access token when the cloud rejects it
+func (sub *Subscription) connect() (body io.ReadCloser, err error) {
+	body, err = sub.open()
+	if sub.adaptor.tokenRejected(err) {
+		if _, err = sub.adaptor.RefreshAccessToken(); err == nil {
+			body, err = sub.open()
+		}
+	}
+	if err != nil {
+		return
+	}
+
+	sub.mutex.Lock()
+	defer sub.mutex.Unlock()
+	select {
+	case <-sub.done:
+		body.Close()
+		return nil, io.EOF
+	default:
+	}
+	sub.body = body
+	return
+}
+
+// open requests the stream
+func (sub *Subscription) open() (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", sub.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if sub.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", sub.lastEventID)
+	}
+	sub.adaptor.authorize(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		m := map[string]interface{}{}
+		json.NewDecoder(resp.Body).Decode(&m)
+		return nil, newAPIError(resp.StatusCode, m)
+	}
+	return resp.Body, nil
+}
+
+// run reads the stream, and reconnects until it is closed
+func (sub *Subscription) run(body io.ReadCloser) {
+	for {
+		err := sub.read(body)
+		body.Close()
+		if sub.closed() {
+			return
+		}
+		if err == nil {
+			err = io.EOF
+		}
+		sub.error(err)
+
+		for {
+			select {
+			case <-sub.done:
+				return
+			case <-time.After(sub.retry):
+			}
+			if body, err = sub.connect(); err == nil {
+				break
+			}
+			if sub.closed() {
+				return
+			}
+			sub.error(err)
+		}
+	}
+}
+
+// closed returns whether the stream was closed
+func (sub *Subscription) closed() bool {
+	select {
+	case <-sub.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// error sends err to Errors, unless the previous error was not read
+func (sub *Subscription) error(err error) {
+	select {
+	case sub.Errors <- err:
+	default:
+	}
+}
+
+// read dispatches the events of body until the end of the stream
+func (sub *Subscription) read(body io.Reader) error {
+	r := bufio.NewReader(body)
+	name, data, id := "", "", sub.lastEventID
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			sub.lastEventID = id
+			if data != "" {
+				if name == "" {
+					name = "message"
+				}
+				e := Event{Name: name, Data: strings.TrimSuffix(data, "\n"), ID: id}
+				e.decode()
+				select {
+				case sub.Events <- e:
+				case <-sub.done:
+					return nil
+				}
+			}
+			name, data = "", ""
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			name = value
+		case "data":
+			data += value + "\n"
+		case "id":
+			id = value
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil {
+				sub.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
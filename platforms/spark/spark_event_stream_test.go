@@ -0,0 +1,215 @@
+package spark
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// sseServer is an httptest stand-in of the event stream of the cloud
+type sseServer struct {
+	mutex        sync.Mutex
+	paths        []string
+	lastEventIDs []string
+	// streams are the events sent on each connection, which is closed
+	// once they are sent
+	streams []string
+	// ready holds the events until it is closed, when it is not nil
+	ready chan struct{}
+}
+
+func (s *sseServer) handler(t *testing.T) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gobottest.Assert(t, r.Header.Get("Accept"), "text/event-stream")
+		s.mutex.Lock()
+		s.paths = append(s.paths, r.URL.Path)
+		s.lastEventIDs = append(s.lastEventIDs, r.Header.Get("Last-Event-ID"))
+		stream := ""
+		if len(s.streams) > 0 {
+			stream, s.streams = s.streams[0], s.streams[1:]
+		}
+		s.mutex.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "invalid_token"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		if s.ready != nil {
+			<-s.ready
+		}
+		fmt.Fprint(w, stream)
+	}
+}
+
+func (s *sseServer) requests() ([]string, []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.paths, s.lastEventIDs
+}
+
+func waitEvent(t *testing.T, sub *Subscription) Event {
+	select {
+	case e := <-sub.Events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatalf("event was not received")
+	}
+	return Event{}
+}
+
+func TestSparkCoreAdaptorSubscribeURL(t *testing.T) {
+	server := &sseServer{}
+	testServer := createTestServer(server.handler(t))
+	defer testServer.Close()
+
+	a := initTestSparkCoreAdaptor()
+	a.setAPIServer(testServer.URL)
+	for _, source := range []string{"all", "devices", "device"} {
+		sub, err := a.Subscribe(source, "ping")
+		gobottest.Assert(t, err, nil)
+		sub.Close()
+	}
+	sub, err := a.Subscribe("all", "")
+	gobottest.Assert(t, err, nil)
+	sub.Close()
+
+	paths, _ := server.requests()
+	gobottest.Assert(t, paths, []string{
+		"/v1/events/ping",
+		"/v1/devices/events/ping",
+		"/v1/devices/myDevice/events/ping",
+		"/v1/events",
+	})
+
+	_, err = a.Subscribe("nothing", "ping")
+	gobottest.Assert(t, err.Error(), "source param should be: all, devices or device")
+
+	a.AccessToken = "wrong"
+	_, err = a.Subscribe("devices", "")
+	gobottest.Assert(t, err.(*APIError).Code, "invalid_token")
+}
+
+func TestSparkCoreAdaptorSubscribe(t *testing.T) {
+	server := &sseServer{streams: []string{
+		":ok\n\nretry: 10\n\n" +
+			"event: temperature\n" +
+			`data: {"data":"21.5","ttl":60,"published_at":"2016-05-01T12:00:00.000Z","coreid":"myDevice"}` + "\n" +
+			"id: 1\n\n" +
+			"data: first line\r\ndata: second line\r\nid: 2\r\n\r\n",
+		"event: humidity\ndata: 40\nid: 3\n\n",
+	}}
+	testServer := createTestServer(server.handler(t))
+	defer testServer.Close()
+
+	a := initTestSparkCoreAdaptor()
+	a.setAPIServer(testServer.URL)
+	sub, err := a.Subscribe("devices", "")
+	gobottest.Assert(t, err, nil)
+	defer sub.Close()
+
+	e := waitEvent(t, sub)
+	gobottest.Assert(t, e.Name, "temperature")
+	gobottest.Assert(t, e.ID, "1")
+	gobottest.Assert(t, e.Value, "21.5")
+	gobottest.Assert(t, e.CoreID, "myDevice")
+	gobottest.Assert(t, e.TTL, 60)
+	gobottest.Assert(t, e.PublishedAt, time.Date(2016, 5, 1, 12, 0, 0, 0, time.UTC))
+
+	e = waitEvent(t, sub)
+	gobottest.Assert(t, e.Name, "message")
+	gobottest.Assert(t, e.Data, "first line\nsecond line")
+	gobottest.Assert(t, e.Value, "")
+
+	// the stream reconnects from the last event
+	e = waitEvent(t, sub)
+	gobottest.Assert(t, e.Name, "humidity")
+	gobottest.Assert(t, e.Data, "40")
+	_, lastEventIDs := server.requests()
+	gobottest.Assert(t, lastEventIDs[:2], []string{"", "2"})
+
+	select {
+	case err := <-sub.Errors:
+		gobottest.Refute(t, err, nil)
+	case <-time.After(time.Second):
+		t.Errorf("stream error was not received")
+	}
+}
+
+func TestSparkCoreAdaptorSubscribeTokenRefresh(t *testing.T) {
+	server := &sseServer{streams: []string{"", "event: ping\ndata: pong\n\n"}}
+	handler := server.handler(t)
+	testServer := createTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/token" {
+			w.Write([]byte(`{"access_token": "token"}`))
+			return
+		}
+		handler(w, r)
+	})
+	defer testServer.Close()
+
+	a := NewSparkCoreAdaptor("bot", "myDevice", "expired")
+	a.RefreshToken = "refresh"
+	a.setAPIServer(testServer.URL)
+	sub, err := a.Subscribe("devices", "ping")
+	gobottest.Assert(t, err, nil)
+	defer sub.Close()
+
+	gobottest.Assert(t, waitEvent(t, sub).Data, "pong")
+	gobottest.Assert(t, a.AccessToken, "token")
+}
+
+func TestSparkCoreAdaptorEventStream(t *testing.T) {
+	server := &sseServer{
+		streams: []string{"retry: 10\n\nevent: event\ndata: sse event\n\n"},
+		ready:   make(chan struct{}),
+	}
+	testServer := createTestServer(server.handler(t))
+	defer testServer.Close()
+
+	a := initTestSparkCoreAdaptor()
+	a.setAPIServer(testServer.URL)
+
+	_, _, err := a.EventStream("nothing", "ping")
+	gobottest.Assert(t, err.Error(), "source param should be: all, devices or device")
+
+	sem := make(chan Event, 2)
+	event, stream, err := a.EventStream("devices", "")
+	gobottest.Assert(t, err, nil)
+	gobot.On(event, func(data interface{}) {
+		sem <- data.(Event)
+	})
+	close(server.ready)
+
+	// the stream message, and the stream error once the connection is
+	// closed, are published in any order
+	events := map[bool]Event{}
+	for len(events) < 2 {
+		select {
+		case e := <-sem:
+			events[e.Error == nil] = e
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Did not recieve stream")
+		}
+	}
+	gobottest.Assert(t, events[true].Name, "event")
+	gobottest.Assert(t, events[true].Data, "sse event")
+	gobottest.Assert(t, events[false].Name, "")
+	gobottest.Assert(t, events[false].Data, "")
+
+	// the stream stops reconnecting once it is closed
+	stream.Close()
+	<-time.After(50 * time.Millisecond)
+	paths, _ := server.requests()
+	<-time.After(50 * time.Millisecond)
+	after, _ := server.requests()
+	gobottest.Assert(t, len(after), len(paths))
+}
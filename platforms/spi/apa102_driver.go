@@ -0,0 +1,127 @@
+package spi
+
+import (
+	"errors"
+	"image/color"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var _ gobot.Driver = (*APA102Driver)(nil)
+
+// ErrInvalidLED is returned when setting an LED outside of the strip
+var ErrInvalidLED = errors.New("Invalid LED index")
+
+const apa102Speed = 4000000
+
+// APA102Driver is a driver for a strip of APA102 (DotStar) RGB LEDs
+type APA102Driver struct {
+	name       string
+	connection Spi
+	bus        int
+	chip       int
+	vals       []color.RGBA
+	brightness uint8
+	gobot.Commander
+}
+
+// NewAPA102Driver creates a new APA102Driver with specified name, for a
+// strip of count LEDs.
+//
+// Optionally accepts:
+//	int: chip select the strip is connected to, defaults to 0
+//	int: spi bus the strip is connected to, defaults to the adaptor's default bus
+//
+// Adds the following API commands:
+//	SetRGBA - sets the color of a single LED
+//	SetBrightness - sets the global brightness of the strip
+//	Draw - sends the colors to the strip
+func NewAPA102Driver(a Spi, name string, count int, v ...int) *APA102Driver {
+	d := &APA102Driver{
+		name:       name,
+		connection: a,
+		bus:        a.SpiDefaultBus(),
+		vals:       make([]color.RGBA, count),
+		brightness: 31,
+		Commander:  gobot.NewCommander(),
+	}
+	if len(v) > 0 {
+		d.chip = v[0]
+	}
+	if len(v) > 1 {
+		d.bus = v[1]
+	}
+
+	d.AddCommand("SetRGBA", func(params map[string]interface{}) interface{} {
+		i := int(params["led"].(float64))
+		c := color.RGBA{
+			R: uint8(params["red"].(float64)),
+			G: uint8(params["green"].(float64)),
+			B: uint8(params["blue"].(float64)),
+			A: 255,
+		}
+		return d.SetRGBA(i, c)
+	})
+	d.AddCommand("SetBrightness", func(params map[string]interface{}) interface{} {
+		d.SetBrightness(uint8(params["brightness"].(float64)))
+		return nil
+	})
+	d.AddCommand("Draw", func(params map[string]interface{}) interface{} {
+		return d.Draw()
+	})
+
+	return d
+}
+
+// Name returns the APA102Drivers name
+func (d *APA102Driver) Name() string { return d.name }
+
+// Connection returns the APA102Drivers Connection
+func (d *APA102Driver) Connection() gobot.Connection { return d.connection.(gobot.Connection) }
+
+// Start opens the spi device of the strip
+func (d *APA102Driver) Start() (errs []error) {
+	if err := d.connection.SpiStart(d.bus, d.chip, Mode0, 8, apa102Speed); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// Halt stops the driver
+func (d *APA102Driver) Halt() (errs []error) { return }
+
+// Len returns the number of LEDs in the strip
+func (d *APA102Driver) Len() int { return len(d.vals) }
+
+// SetRGBA sets the color of the LED at index i. The alpha channel scales
+// the brightness of the LED. The strip is not updated until Draw is called.
+func (d *APA102Driver) SetRGBA(i int, c color.RGBA) (err error) {
+	if i < 0 || i >= len(d.vals) {
+		return ErrInvalidLED
+	}
+	d.vals[i] = c
+	return
+}
+
+// SetBrightness sets the global brightness of the strip, 0-31
+func (d *APA102Driver) SetBrightness(b uint8) {
+	if b > 31 {
+		b = 31
+	}
+	d.brightness = b
+}
+
+// Draw sends the colors of all LEDs to the strip
+func (d *APA102Driver) Draw() (err error) {
+	// a start frame of 32 zero bits, followed by one 32 bit frame per LED
+	// and an end frame providing at least one extra clock edge per LED
+	tx := make([]byte, 4+4*len(d.vals)+(len(d.vals)+15)/16)
+	for i, c := range d.vals {
+		j := 4 + 4*i
+		tx[j] = 0xe0 | uint8(uint16(d.brightness)*uint16(c.A)/255)
+		tx[j+1] = c.B
+		tx[j+2] = c.G
+		tx[j+3] = c.R
+	}
+	return d.connection.SpiTransfer(d.bus, d.chip, tx, nil)
+}
@@ -0,0 +1,47 @@
+package spi
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestAPA102Driver(t *testing.T) {
+	adaptor := newSpiTestAdaptor("adaptor")
+	d := NewAPA102Driver(adaptor, "leds", 2)
+	gobottest.Assert(t, d.Name(), "leds")
+	gobottest.Assert(t, d.Connection().Name(), "adaptor")
+	gobottest.Assert(t, d.Len(), 2)
+	gobottest.Assert(t, len(d.Start()), 0)
+	gobottest.Assert(t, len(d.Halt()), 0)
+}
+
+func TestAPA102DriverDraw(t *testing.T) {
+	adaptor := newSpiTestAdaptor("adaptor")
+	d := NewAPA102Driver(adaptor, "leds", 2)
+
+	gobottest.Assert(t, d.SetRGBA(0, color.RGBA{R: 1, G: 2, B: 3, A: 255}), nil)
+	gobottest.Assert(t, d.SetRGBA(1, color.RGBA{R: 4, G: 5, B: 6, A: 0}), nil)
+	gobottest.Assert(t, d.SetRGBA(2, color.RGBA{}), ErrInvalidLED)
+
+	gobottest.Assert(t, d.Draw(), nil)
+	gobottest.Assert(t, adaptor.tx, []byte{
+		0x00, 0x00, 0x00, 0x00,
+		0xff, 0x03, 0x02, 0x01,
+		0xe0, 0x06, 0x05, 0x04,
+		0x00,
+	})
+
+	d.SetBrightness(100)
+	gobottest.Assert(t, d.brightness, uint8(31))
+	d.SetBrightness(10)
+	d.Draw()
+	gobottest.Assert(t, adaptor.tx[4], uint8(0xea))
+
+	d.Command("SetRGBA")(map[string]interface{}{
+		"led": 1.0, "red": 7.0, "green": 8.0, "blue": 9.0,
+	})
+	d.Command("Draw")(map[string]interface{}{})
+	gobottest.Assert(t, adaptor.tx[8:12], []byte{0xea, 0x09, 0x08, 0x07})
+}
@@ -0,0 +1,11 @@
+/*
+Package spi provides Gobot drivers for spi devices.
+
+Installing:
+
+	go get github.com/hybridgroup/gobot/platforms/spi
+
+For further information refer to spi README:
+https://github.com/hybridgroup/gobot/blob/master/platforms/spi/README.md
+*/
+package spi
@@ -0,0 +1,36 @@
+package spi
+
+type spiTestAdaptor struct {
+	name            string
+	bus             int
+	chip            int
+	tx              []byte
+	spiStartImpl    func() error
+	spiTransferImpl func(tx []byte, rx []byte) error
+}
+
+func (t *spiTestAdaptor) SpiStart(bus int, chip int, mode int, bits int, maxSpeed int64) (err error) {
+	t.bus = bus
+	t.chip = chip
+	return t.spiStartImpl()
+}
+func (t *spiTestAdaptor) SpiTransfer(bus int, chip int, tx []byte, rx []byte) (err error) {
+	t.tx = tx
+	return t.spiTransferImpl(tx, rx)
+}
+func (t *spiTestAdaptor) SpiDefaultBus() int       { return 0 }
+func (t *spiTestAdaptor) Name() string             { return t.name }
+func (t *spiTestAdaptor) Connect() (errs []error)  { return }
+func (t *spiTestAdaptor) Finalize() (errs []error) { return }
+
+func newSpiTestAdaptor(name string) *spiTestAdaptor {
+	return &spiTestAdaptor{
+		name: name,
+		spiStartImpl: func() error {
+			return nil
+		},
+		spiTransferImpl: func(tx []byte, rx []byte) error {
+			return nil
+		},
+	}
+}
@@ -0,0 +1,88 @@
+package spi
+
+import (
+	"strconv"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/gpio"
+)
+
+var _ gobot.Driver = (*MCP3008Driver)(nil)
+var _ gpio.AnalogReader = (*MCP3008Driver)(nil)
+
+const mcp3008Speed = 1000000
+
+// MCP3008Driver is a driver for the MCP3008 8 channel 10 bit ADC. As it
+// implements gpio.AnalogReader, it may also be used as the connection of
+// analog gpio drivers, such as the AnalogSensorDriver.
+type MCP3008Driver struct {
+	name       string
+	connection Spi
+	bus        int
+	chip       int
+}
+
+// NewMCP3008Driver creates a new MCP3008Driver with specified name.
+//
+// Optionally accepts:
+//	int: chip select the ADC is connected to, defaults to 0
+//	int: spi bus the ADC is connected to, defaults to the adaptor's default bus
+func NewMCP3008Driver(a Spi, name string, v ...int) *MCP3008Driver {
+	m := &MCP3008Driver{
+		name:       name,
+		connection: a,
+		bus:        a.SpiDefaultBus(),
+	}
+	if len(v) > 0 {
+		m.chip = v[0]
+	}
+	if len(v) > 1 {
+		m.bus = v[1]
+	}
+	return m
+}
+
+// Name returns the MCP3008Drivers name
+func (m *MCP3008Driver) Name() string { return m.name }
+
+// Connection returns the MCP3008Drivers Connection
+func (m *MCP3008Driver) Connection() gobot.Connection { return m.connection.(gobot.Connection) }
+
+// Start opens the spi device of the ADC
+func (m *MCP3008Driver) Start() (errs []error) {
+	if err := m.connection.SpiStart(m.bus, m.chip, Mode0, 8, mcp3008Speed); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// Halt stops the driver
+func (m *MCP3008Driver) Halt() (errs []error) { return }
+
+// Connect starts the driver when it is used as an AnalogReader connection
+func (m *MCP3008Driver) Connect() (errs []error) { return m.Start() }
+
+// Finalize halts the driver when it is used as an AnalogReader connection
+func (m *MCP3008Driver) Finalize() (errs []error) { return m.Halt() }
+
+// Read returns the 0-1023 value of the single ended channel 0-7
+func (m *MCP3008Driver) Read(channel int) (val int, err error) {
+	if channel < 0 || channel > 7 {
+		return 0, ErrInvalidChannel
+	}
+	tx := []byte{0x01, byte(0x08|channel) << 4, 0x00}
+	rx := make([]byte, 3)
+	if err = m.connection.SpiTransfer(m.bus, m.chip, tx, rx); err != nil {
+		return
+	}
+	return int(rx[1]&0x03)<<8 | int(rx[2]), nil
+}
+
+// AnalogRead returns the value of the channel given as pin, eg. "0"
+func (m *MCP3008Driver) AnalogRead(pin string) (val int, err error) {
+	channel, err := strconv.Atoi(pin)
+	if err != nil {
+		return 0, ErrInvalidChannel
+	}
+	return m.Read(channel)
+}
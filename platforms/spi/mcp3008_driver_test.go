@@ -0,0 +1,66 @@
+package spi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func initTestMCP3008DriverWithStubbedAdaptor() (*MCP3008Driver, *spiTestAdaptor) {
+	adaptor := newSpiTestAdaptor("adaptor")
+	return NewMCP3008Driver(adaptor, "adc"), adaptor
+}
+
+func TestNewMCP3008Driver(t *testing.T) {
+	m := NewMCP3008Driver(newSpiTestAdaptor("adaptor"), "adc")
+	gobottest.Assert(t, m.Name(), "adc")
+	gobottest.Assert(t, m.Connection().Name(), "adaptor")
+	gobottest.Assert(t, m.chip, 0)
+	gobottest.Assert(t, m.bus, 0)
+
+	m = NewMCP3008Driver(newSpiTestAdaptor("adaptor"), "adc", 1, 2)
+	gobottest.Assert(t, m.chip, 1)
+	gobottest.Assert(t, m.bus, 2)
+}
+
+func TestMCP3008DriverStart(t *testing.T) {
+	m, adaptor := initTestMCP3008DriverWithStubbedAdaptor()
+	gobottest.Assert(t, len(m.Start()), 0)
+	gobottest.Assert(t, len(m.Connect()), 0)
+	gobottest.Assert(t, len(m.Halt()), 0)
+	gobottest.Assert(t, len(m.Finalize()), 0)
+
+	adaptor.spiStartImpl = func() error {
+		return errors.New("start error")
+	}
+	gobottest.Assert(t, m.Start()[0], errors.New("start error"))
+}
+
+func TestMCP3008DriverRead(t *testing.T) {
+	m, adaptor := initTestMCP3008DriverWithStubbedAdaptor()
+	adaptor.spiTransferImpl = func(tx []byte, rx []byte) error {
+		copy(rx, []byte{0xff, 0xfe, 0x34})
+		return nil
+	}
+
+	val, err := m.Read(5)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, adaptor.tx, []byte{0x01, 0xd0, 0x00})
+	gobottest.Assert(t, val, 0x234)
+
+	val, err = m.AnalogRead("7")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, adaptor.tx, []byte{0x01, 0xf0, 0x00})
+
+	_, err = m.Read(8)
+	gobottest.Assert(t, err, ErrInvalidChannel)
+	_, err = m.AnalogRead("A0")
+	gobottest.Assert(t, err, ErrInvalidChannel)
+
+	adaptor.spiTransferImpl = func(tx []byte, rx []byte) error {
+		return errors.New("transfer error")
+	}
+	_, err = m.Read(0)
+	gobottest.Assert(t, err, errors.New("transfer error"))
+}
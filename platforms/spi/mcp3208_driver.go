@@ -0,0 +1,88 @@
+package spi
+
+import (
+	"strconv"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/platforms/gpio"
+)
+
+var _ gobot.Driver = (*MCP3208Driver)(nil)
+var _ gpio.AnalogReader = (*MCP3208Driver)(nil)
+
+const mcp3208Speed = 1000000
+
+// MCP3208Driver is a driver for the MCP3208 8 channel 12 bit ADC. As it
+// implements gpio.AnalogReader, it may also be used as the connection of
+// analog gpio drivers, such as the AnalogSensorDriver.
+type MCP3208Driver struct {
+	name       string
+	connection Spi
+	bus        int
+	chip       int
+}
+
+// NewMCP3208Driver creates a new MCP3208Driver with specified name.
+//
+// Optionally accepts:
+//	int: chip select the ADC is connected to, defaults to 0
+//	int: spi bus the ADC is connected to, defaults to the adaptor's default bus
+func NewMCP3208Driver(a Spi, name string, v ...int) *MCP3208Driver {
+	m := &MCP3208Driver{
+		name:       name,
+		connection: a,
+		bus:        a.SpiDefaultBus(),
+	}
+	if len(v) > 0 {
+		m.chip = v[0]
+	}
+	if len(v) > 1 {
+		m.bus = v[1]
+	}
+	return m
+}
+
+// Name returns the MCP3208Drivers name
+func (m *MCP3208Driver) Name() string { return m.name }
+
+// Connection returns the MCP3208Drivers Connection
+func (m *MCP3208Driver) Connection() gobot.Connection { return m.connection.(gobot.Connection) }
+
+// Start opens the spi device of the ADC
+func (m *MCP3208Driver) Start() (errs []error) {
+	if err := m.connection.SpiStart(m.bus, m.chip, Mode0, 8, mcp3208Speed); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// Halt stops the driver
+func (m *MCP3208Driver) Halt() (errs []error) { return }
+
+// Connect starts the driver when it is used as an AnalogReader connection
+func (m *MCP3208Driver) Connect() (errs []error) { return m.Start() }
+
+// Finalize halts the driver when it is used as an AnalogReader connection
+func (m *MCP3208Driver) Finalize() (errs []error) { return m.Halt() }
+
+// Read returns the 0-4095 value of the single ended channel 0-7
+func (m *MCP3208Driver) Read(channel int) (val int, err error) {
+	if channel < 0 || channel > 7 {
+		return 0, ErrInvalidChannel
+	}
+	tx := []byte{0x06 | byte(channel>>2), byte(channel&0x03) << 6, 0x00}
+	rx := make([]byte, 3)
+	if err = m.connection.SpiTransfer(m.bus, m.chip, tx, rx); err != nil {
+		return
+	}
+	return int(rx[1]&0x0f)<<8 | int(rx[2]), nil
+}
+
+// AnalogRead returns the value of the channel given as pin, eg. "0"
+func (m *MCP3208Driver) AnalogRead(pin string) (val int, err error) {
+	channel, err := strconv.Atoi(pin)
+	if err != nil {
+		return 0, ErrInvalidChannel
+	}
+	return m.Read(channel)
+}
@@ -0,0 +1,30 @@
+package spi
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestMCP3208DriverRead(t *testing.T) {
+	adaptor := newSpiTestAdaptor("adaptor")
+	m := NewMCP3208Driver(adaptor, "adc", 1)
+	gobottest.Assert(t, len(m.Start()), 0)
+	gobottest.Assert(t, adaptor.chip, 1)
+
+	adaptor.spiTransferImpl = func(tx []byte, rx []byte) error {
+		copy(rx, []byte{0xff, 0xfa, 0xbc})
+		return nil
+	}
+
+	val, err := m.Read(5)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, adaptor.tx, []byte{0x07, 0x40, 0x00})
+	gobottest.Assert(t, val, 0xabc)
+
+	val, _ = m.AnalogRead("2")
+	gobottest.Assert(t, adaptor.tx, []byte{0x06, 0x80, 0x00})
+
+	_, err = m.Read(-1)
+	gobottest.Assert(t, err, ErrInvalidChannel)
+}
@@ -0,0 +1,47 @@
+package spi
+
+import (
+	"errors"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var (
+	// ErrNotStarted is returned when transferring to a device which has not
+	// been started
+	ErrNotStarted = errors.New("SPI device has not been started")
+	// ErrInvalidChannel is returned when reading an unknown ADC channel
+	ErrInvalidChannel = errors.New("Invalid channel")
+)
+
+const (
+	// Mode0 samples on the rising edge with an idle low clock
+	Mode0 = 0
+	// Mode1 samples on the falling edge with an idle low clock
+	Mode1 = 1
+	// Mode2 samples on the falling edge with an idle high clock
+	Mode2 = 2
+	// Mode3 samples on the rising edge with an idle high clock
+	Mode3 = 3
+)
+
+// SpiStarter interface represents an Adaptor which can open a spi device
+// given its bus, chip select and transfer settings
+type SpiStarter interface {
+	SpiStart(bus int, chip int, mode int, bits int, maxSpeed int64) (err error)
+}
+
+// SpiTransferer interface represents an Adaptor which can transfer data to
+// and from a started spi device
+type SpiTransferer interface {
+	SpiTransfer(bus int, chip int, tx []byte, rx []byte) (err error)
+}
+
+// Spi interface represents an Adaptor which has spi capabilities
+type Spi interface {
+	gobot.Adaptor
+	SpiStarter
+	SpiTransferer
+	// SpiDefaultBus returns the bus used by drivers which do not specify one
+	SpiDefaultBus() int
+}
@@ -2,6 +2,7 @@ package sysfs
 
 import (
 	"os"
+	"path/filepath"
 )
 
 // A File represents basic IO interactions with the underlying file system
@@ -21,6 +22,12 @@ type Filesystem interface {
 	OpenFile(name string, flag int, perm os.FileMode) (file File, err error)
 }
 
+// Globber is implemented by a Filesystem which can list the files and
+// directories matching a pattern
+type Globber interface {
+	Glob(pattern string) (matches []string, err error)
+}
+
 // NativeFilesystem represents the native file system implementation
 type NativeFilesystem struct{}
 
@@ -41,3 +48,17 @@ func (fs *NativeFilesystem) OpenFile(name string, flag int, perm os.FileMode) (f
 func OpenFile(name string, flag int, perm os.FileMode) (file File, err error) {
 	return fs.OpenFile(name, flag, perm)
 }
+
+// Glob calls filepath.Glob().
+func (fs *NativeFilesystem) Glob(pattern string) (matches []string, err error) {
+	return filepath.Glob(pattern)
+}
+
+// Glob calls either the NativeFilesystem or user defined Glob. A user
+// defined Filesystem which does not implement Globber has no matches.
+func Glob(pattern string) (matches []string, err error) {
+	if g, ok := fs.(Globber); ok {
+		return g.Glob(pattern)
+	}
+	return
+}
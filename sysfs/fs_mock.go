@@ -3,11 +3,14 @@ package sysfs
 import (
 	"errors"
 	"os"
+	"path/filepath"
+	"sort"
 	"time"
 )
 
 var _ File = (*MockFile)(nil)
 var _ Filesystem = (*MockFilesystem)(nil)
+var _ Globber = (*MockFilesystem)(nil)
 
 // MockFilesystem represents  a filesystem of mock files.
 type MockFilesystem struct {
@@ -72,6 +75,9 @@ func (f *MockFile) Fd() uintptr {
 
 // Close implements the File interface Close function
 func (f *MockFile) Close() error {
+	if f != nil {
+		f.Closed = true
+	}
 	return nil
 }
 
@@ -99,6 +105,26 @@ func (fs *MockFilesystem) OpenFile(name string, flag int, perm os.FileMode) (fil
 	return (*MockFile)(nil), &os.PathError{Err: errors.New(name + ": No such file.")}
 }
 
+// Glob returns the mock files, and the directories containing them, which
+// match pattern
+func (fs *MockFilesystem) Glob(pattern string) (matches []string, err error) {
+	found := make(map[string]bool)
+	for name := range fs.Files {
+		for ; name != "/" && name != "." && !found[name]; name = filepath.Dir(name) {
+			ok, err := filepath.Match(pattern, name)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				found[name] = true
+				matches = append(matches, name)
+			}
+		}
+	}
+	sort.Strings(matches)
+	return
+}
+
 // Add adds a new file to fs.Files given a name, and returns the newly created file
 func (fs *MockFilesystem) Add(name string) *MockFile {
 	f := &MockFile{
@@ -62,3 +62,25 @@ func TestMockFilesystemRead(t *testing.T) {
 	n, err = f2.ReadAt(buffer, 10)
 	gobottest.Assert(t, n, 3)
 }
+
+func TestMockFilesystemGlob(t *testing.T) {
+	fs := NewMockFilesystem([]string{
+		"/sys/bus/iio/devices/iio:device0/name",
+		"/sys/bus/iio/devices/iio:device0/in_voltage0_raw",
+		"/sys/bus/iio/devices/iio:device1/name",
+		"/sys/bus/iio/devices/trigger0/name",
+	})
+
+	m, err := fs.Glob("/sys/bus/iio/devices/iio:device*")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, m, []string{
+		"/sys/bus/iio/devices/iio:device0",
+		"/sys/bus/iio/devices/iio:device1",
+	})
+
+	m, _ = fs.Glob("/sys/bus/iio/devices/*/name")
+	gobottest.Assert(t, len(m), 3)
+
+	_, err = fs.Glob("[")
+	gobottest.Refute(t, err, nil)
+}
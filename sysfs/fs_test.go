@@ -13,3 +13,10 @@ func TestFilesystemOpen(t *testing.T) {
 	gobottest.Assert(t, err, nil)
 	var _ File = file
 }
+
+func TestFilesystemGlob(t *testing.T) {
+	SetFilesystem(&NativeFilesystem{})
+	m, err := Glob(os.DevNull)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, m, []string{os.DevNull})
+}
@@ -0,0 +1,259 @@
+package sysfs
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrI2cBusClosed is returned when a device is used after its bus is closed
+var ErrI2cBusClosed = errors.New("i2c bus is closed")
+
+// I2cBus shares an i2c bus between the devices connected to it. Each
+// transaction of a device holds the bus, so that the transactions of
+// devices used from different goroutines are never interleaved.
+type I2cBus struct {
+	location string
+	device   I2cDevice
+	address  int
+	mutex    sync.Mutex
+}
+
+// NewI2cBus returns an I2cBus given an i2c bus location, such as
+// /dev/i2c-1. The bus is opened when the first device is connected.
+func NewI2cBus(location string) *I2cBus {
+	return &I2cBus{location: location, address: -1}
+}
+
+// Location returns the location of the bus
+func (b *I2cBus) Location() string { return b.location }
+
+// Device returns a connection to the device at address on the bus
+func (b *I2cBus) Device(address int) (d *I2cBusDevice, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.device == nil {
+		if b.device, err = NewI2cDevice(b.location, address); err != nil {
+			b.device = nil
+			return
+		}
+		b.address = address
+	}
+	return &I2cBusDevice{bus: b, address: address}, nil
+}
+
+// Close closes the bus. Devices connected to the bus can not be used once
+// it is closed.
+func (b *I2cBus) Close() (err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.device == nil {
+		return
+	}
+	err = b.device.Close()
+	b.device = nil
+	b.address = -1
+	return
+}
+
+// lock holds the bus and selects the device at address
+func (b *I2cBus) lock(address int) (device I2cDevice, err error) {
+	b.mutex.Lock()
+	if b.device == nil {
+		b.mutex.Unlock()
+		return nil, ErrI2cBusClosed
+	}
+	if b.address != address {
+		if err = b.device.SetAddress(address); err != nil {
+			b.mutex.Unlock()
+			return
+		}
+		b.address = address
+	}
+	return b.device, nil
+}
+
+func (b *I2cBus) unlock() { b.mutex.Unlock() }
+
+// I2cBusDevice is a device at a fixed address on an I2cBus. Each method is
+// a single transaction on the bus.
+type I2cBusDevice struct {
+	bus     *I2cBus
+	address int
+}
+
+// Address returns the address of the device
+func (d *I2cBusDevice) Address() int { return d.address }
+
+// do runs f as a single transaction on the bus
+func (d *I2cBusDevice) do(f func(device I2cDevice) error) (err error) {
+	device, err := d.bus.lock(d.address)
+	if err != nil {
+		return
+	}
+	defer d.bus.unlock()
+	return f(device)
+}
+
+// Read reads len(b) bytes from the device
+func (d *I2cBusDevice) Read(b []byte) (n int, err error) {
+	err = d.do(func(device I2cDevice) (err error) {
+		n, err = device.Read(b)
+		return
+	})
+	return
+}
+
+// Write writes b to the device
+func (d *I2cBusDevice) Write(b []byte) (n int, err error) {
+	err = d.do(func(device I2cDevice) (err error) {
+		n, err = device.Write(b)
+		return
+	})
+	return
+}
+
+// ReadRegister reads len(b) bytes from the device starting at register reg
+func (d *I2cBusDevice) ReadRegister(reg uint8, b []byte) (n int, err error) {
+	err = d.do(func(device I2cDevice) (err error) {
+		n, err = device.ReadRegister([]byte{reg}, b)
+		return
+	})
+	return
+}
+
+// WriteWord writes the 16 bit val to register reg of the device
+func (d *I2cBusDevice) WriteWord(reg uint8, val uint16) (n int, err error) {
+	err = d.do(func(device I2cDevice) (err error) {
+		n, err = device.WriteWord(reg, val)
+		return
+	})
+	return
+}
+
+// WriteQuick sends the read/write bit to the device without any data
+func (d *I2cBusDevice) WriteQuick(bit uint8) error {
+	return d.do(func(device I2cDevice) error {
+		return device.WriteQuick(bit)
+	})
+}
+
+// ReadByte reads a byte from the device without selecting a register
+func (d *I2cBusDevice) ReadByte() (value byte, err error) {
+	err = d.do(func(device I2cDevice) (err error) {
+		value, err = device.ReadByte()
+		return
+	})
+	return
+}
+
+// WriteByte writes a byte to the device without selecting a register
+func (d *I2cBusDevice) WriteByte(value byte) error {
+	return d.do(func(device I2cDevice) error {
+		return device.WriteByte(value)
+	})
+}
+
+// ReadByteData reads the byte register reg of the device
+func (d *I2cBusDevice) ReadByteData(reg uint8) (value uint8, err error) {
+	err = d.do(func(device I2cDevice) (err error) {
+		value, err = device.ReadByteData(reg)
+		return
+	})
+	return
+}
+
+// WriteByteData writes value to the byte register reg of the device
+func (d *I2cBusDevice) WriteByteData(reg uint8, value uint8) error {
+	return d.do(func(device I2cDevice) error {
+		return device.WriteByteData(reg, value)
+	})
+}
+
+// ReadWordData reads the word register reg of the device
+func (d *I2cBusDevice) ReadWordData(reg uint8) (value uint16, err error) {
+	err = d.do(func(device I2cDevice) (err error) {
+		value, err = device.ReadWordData(reg)
+		return
+	})
+	return
+}
+
+// WriteWordData writes value to the word register reg of the device
+func (d *I2cBusDevice) WriteWordData(reg uint8, value uint16) error {
+	return d.do(func(device I2cDevice) error {
+		return device.WriteWordData(reg, value)
+	})
+}
+
+// ProcessCall writes value to register reg of the device and reads back a
+// word
+func (d *I2cBusDevice) ProcessCall(reg uint8, value uint16) (result uint16, err error) {
+	err = d.do(func(device I2cDevice) (err error) {
+		result, err = device.ProcessCall(reg, value)
+		return
+	})
+	return
+}
+
+// ReadBlockData reads an SMBus block from register reg of the device into b
+func (d *I2cBusDevice) ReadBlockData(reg uint8, b []byte) (n int, err error) {
+	err = d.do(func(device I2cDevice) (err error) {
+		n, err = device.ReadBlockData(reg, b)
+		return
+	})
+	return
+}
+
+// WriteBlockData writes b as an SMBus block to register reg of the device
+func (d *I2cBusDevice) WriteBlockData(reg uint8, b []byte) error {
+	return d.do(func(device I2cDevice) error {
+		return device.WriteBlockData(reg, b)
+	})
+}
+
+// BlockProcessCall writes the block w to register reg of the device and
+// reads back a block into r
+func (d *I2cBusDevice) BlockProcessCall(reg uint8, w []byte, r []byte) (n int, err error) {
+	err = d.do(func(device I2cDevice) (err error) {
+		n, err = device.BlockProcessCall(reg, w, r)
+		return
+	})
+	return
+}
+
+// ReadI2cBlockData reads len(b) bytes from the device starting at register
+// reg
+func (d *I2cBusDevice) ReadI2cBlockData(reg uint8, b []byte) (n int, err error) {
+	err = d.do(func(device I2cDevice) (err error) {
+		n, err = device.ReadI2cBlockData(reg, b)
+		return
+	})
+	return
+}
+
+// WriteI2cBlockData writes b to the device starting at register reg
+func (d *I2cBusDevice) WriteI2cBlockData(reg uint8, b []byte) error {
+	return d.do(func(device I2cDevice) error {
+		return device.WriteI2cBlockData(reg, b)
+	})
+}
+
+// Transfer runs msgs on the device as one combined transfer
+func (d *I2cBusDevice) Transfer(msgs ...I2cMessage) error {
+	return d.do(func(device I2cDevice) error {
+		return device.Transfer(msgs...)
+	})
+}
+
+// Tx writes w to the device and then reads len(r) bytes into r, without any
+// other transaction on the bus in between
+func (d *I2cBusDevice) Tx(w []byte, r []byte) error {
+	return d.do(func(device I2cDevice) error {
+		return device.Tx(w, r)
+	})
+}
+
+// Close releases the device. The bus stays open for the other devices.
+func (d *I2cBusDevice) Close() error { return nil }
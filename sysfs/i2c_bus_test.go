@@ -0,0 +1,153 @@
+package sysfs
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// recordingI2cDevice records the address of each transaction, and fails
+// when a transaction starts before the previous one has finished
+type recordingI2cDevice struct {
+	I2cDevice
+	address  int
+	log      []int
+	busy     bool
+	conflict bool
+	closed   bool
+}
+
+func (r *recordingI2cDevice) begin() {
+	if r.busy {
+		r.conflict = true
+	}
+	r.busy = true
+	r.log = append(r.log, r.address)
+}
+
+func (r *recordingI2cDevice) Read(b []byte) (int, error) {
+	r.begin()
+	defer func() { r.busy = false }()
+	for i := range b {
+		b[i] = byte(r.address)
+	}
+	return len(b), nil
+}
+func (r *recordingI2cDevice) Write(b []byte) (int, error) {
+	r.begin()
+	defer func() { r.busy = false }()
+	return len(b), nil
+}
+func (r *recordingI2cDevice) ReadRegister(reg []byte, b []byte) (int, error) {
+	return r.Read(b)
+}
+func (r *recordingI2cDevice) WriteWord(reg uint8, val uint16) (int, error) {
+	return r.Write([]byte{reg, byte(val), byte(val >> 8)})
+}
+func (r *recordingI2cDevice) ReadByteData(reg uint8) (uint8, error) {
+	b := make([]byte, 1)
+	_, err := r.Read(b)
+	return b[0], err
+}
+func (r *recordingI2cDevice) Tx(w []byte, b []byte) error {
+	if _, err := r.Write(w); err != nil {
+		return err
+	}
+	_, err := r.Read(b)
+	return err
+}
+func (r *recordingI2cDevice) SetAddress(address int) error {
+	if address > 0x7f {
+		return errors.New("invalid address")
+	}
+	r.address = address
+	return nil
+}
+func (r *recordingI2cDevice) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestI2cBus(t *testing.T) {
+	SetFilesystem(NewMockFilesystem([]string{}))
+	b := NewI2cBus("/dev/i2c-1")
+	gobottest.Assert(t, b.Location(), "/dev/i2c-1")
+
+	_, err := b.Device(0x10)
+	gobottest.Refute(t, err, nil)
+
+	SetFilesystem(NewMockFilesystem([]string{"/dev/i2c-1"}))
+	SetSyscall(&MockSyscall{})
+	defer SetSyscall(&NativeSyscall{})
+	d, err := b.Device(0x10)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, d.Address(), 0x10)
+	gobottest.Assert(t, b.Close(), nil)
+
+	_, err = d.Write([]byte{0x01})
+	gobottest.Assert(t, err, ErrI2cBusClosed)
+}
+
+func TestI2cBusDevices(t *testing.T) {
+	b := NewI2cBus("/dev/i2c-1")
+	r := &recordingI2cDevice{}
+	b.device = r
+
+	d1 := &I2cBusDevice{bus: b, address: 0x10}
+	d2 := &I2cBusDevice{bus: b, address: 0x20}
+
+	buf := make([]byte, 2)
+	d1.Write([]byte{0x01})
+	d2.Read(buf)
+	gobottest.Assert(t, buf, []byte{0x20, 0x20})
+	d2.WriteWord(0x01, 0x0203)
+	d1.ReadRegister(0x01, buf)
+	gobottest.Assert(t, buf, []byte{0x10, 0x10})
+	gobottest.Assert(t, r.log, []int{0x10, 0x20, 0x20, 0x10})
+
+	gobottest.Assert(t, d1.Tx([]byte{0x01}, buf), nil)
+	gobottest.Assert(t, d2.Tx([]byte{0x01, 0x02}, buf), nil)
+	gobottest.Assert(t, buf, []byte{0x20, 0x20})
+
+	val, err := d1.ReadByteData(0x01)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, uint8(0x10))
+
+	d3 := &I2cBusDevice{bus: b, address: 0xff}
+	_, err = d3.Read(buf)
+	gobottest.Refute(t, err, nil)
+
+	// the bus is not left held after an error
+	_, err = d1.Read(buf)
+	gobottest.Assert(t, err, nil)
+
+	gobottest.Assert(t, d1.Close(), nil)
+	gobottest.Assert(t, r.closed, false)
+	gobottest.Assert(t, b.Close(), nil)
+	gobottest.Assert(t, r.closed, true)
+}
+
+func TestI2cBusConcurrentDevices(t *testing.T) {
+	b := NewI2cBus("/dev/i2c-1")
+	r := &recordingI2cDevice{}
+	b.device = r
+
+	var wg sync.WaitGroup
+	for _, address := range []int{0x10, 0x20, 0x30} {
+		wg.Add(1)
+		go func(d *I2cBusDevice) {
+			defer wg.Done()
+			buf := make([]byte, 1)
+			for i := 0; i < 100; i++ {
+				d.Tx([]byte{0x00, 0x01}, buf)
+				if buf[0] != byte(d.Address()) {
+					t.Errorf("read from 0x%x instead of 0x%x", buf[0], d.Address())
+				}
+			}
+		}(&I2cBusDevice{bus: b, address: address})
+	}
+	wg.Wait()
+	gobottest.Assert(t, r.conflict, false)
+}
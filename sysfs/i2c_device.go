@@ -1,6 +1,7 @@
 package sysfs
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -10,6 +11,8 @@ import (
 
 const (
 	I2C_SLAVE = 0x0703
+	I2C_FUNCS = 0x0705
+	I2C_RDWR  = 0x0707
 	I2C_SMBUS = 0x0720
 
 	/* i2c_smbus_xfer read or write markers */
@@ -28,10 +31,42 @@ const (
 	I2C_SMBUS_BLOCK_PROC_CALL  = 7
 	I2C_SMBUS_I2C_BLOCK_DATA   = 8
 
+	// I2C_SMBUS_BLOCK_MAX is the largest block of an SMBus transaction
+	I2C_SMBUS_BLOCK_MAX = 32
+
 	// Adapter functionality
-	I2C_FUNCS                       = 0x0705
+	I2C_FUNC_I2C                    = 0x00000001
+	I2C_FUNC_10BIT_ADDR             = 0x00000002
+	I2C_FUNC_PROTOCOL_MANGLING      = 0x00000004
+	I2C_FUNC_SMBUS_PEC              = 0x00000008
+	I2C_FUNC_NOSTART                = 0x00000010
+	I2C_FUNC_SMBUS_BLOCK_PROC_CALL  = 0x00008000
+	I2C_FUNC_SMBUS_QUICK            = 0x00010000
+	I2C_FUNC_SMBUS_READ_BYTE        = 0x00020000
+	I2C_FUNC_SMBUS_WRITE_BYTE       = 0x00040000
+	I2C_FUNC_SMBUS_READ_BYTE_DATA   = 0x00080000
+	I2C_FUNC_SMBUS_WRITE_BYTE_DATA  = 0x00100000
+	I2C_FUNC_SMBUS_READ_WORD_DATA   = 0x00200000
+	I2C_FUNC_SMBUS_WRITE_WORD_DATA  = 0x00400000
+	I2C_FUNC_SMBUS_PROC_CALL        = 0x00800000
 	I2C_FUNC_SMBUS_READ_BLOCK_DATA  = 0x01000000
 	I2C_FUNC_SMBUS_WRITE_BLOCK_DATA = 0x02000000
+	I2C_FUNC_SMBUS_READ_I2C_BLOCK   = 0x04000000
+	I2C_FUNC_SMBUS_WRITE_I2C_BLOCK  = 0x08000000
+
+	// I2cMessage flags
+	I2C_M_RD      = 0x0001
+	I2C_M_TEN     = 0x0010
+	I2C_M_NOSTART = 0x4000
+)
+
+var (
+	// ErrI2cNotSupported is returned when neither the i2c adapter nor a
+	// fallback supports a transaction
+	ErrI2cNotSupported = errors.New("i2c transaction is not supported by the adapter")
+	// ErrI2cBlockSize is returned when a block is longer than an SMBus
+	// transaction allows
+	ErrI2cBlockSize = errors.New("i2c block is longer than 32 bytes")
 )
 
 type i2cSmbusIoctlData struct {
@@ -41,16 +76,82 @@ type i2cSmbusIoctlData struct {
 	data      uintptr
 }
 
+// i2cSmbusData is the union i2c_smbus_data of the kernel, which is copied
+// whole for block transactions. Blocks start with their length.
+type i2cSmbusData [I2C_SMBUS_BLOCK_MAX + 2]byte
+
+// i2cMsg is the struct i2c_msg of the kernel
+type i2cMsg struct {
+	addr  uint16
+	flags uint16
+	len   uint16
+	buf   uintptr
+}
+
+type i2cRdwrIoctlData struct {
+	msgs  uintptr
+	nmsgs uint32
+}
+
+// I2cMessage is a message of a combined I2C_RDWR transfer. A message with
+// the I2C_M_RD flag reads len(Buf) bytes into Buf, other messages write Buf.
+type I2cMessage struct {
+	Flags uint16
+	Buf   []byte
+}
+
+// I2cDevice is a device on an i2c bus. Besides plain reads and writes it
+// provides the SMBus commands and combined I2C_RDWR transfers, falling back
+// to plain reads and writes when the adapter does not support them.
 type I2cDevice interface {
 	io.ReadWriteCloser
 	SetAddress(int) error
-	ReadRegister([]byte, []byte) (int, error)
-	WriteWord(uint8, uint16) (int, error)
+	// Functionality returns the I2C_FUNC mask of the adapter
+	Functionality() uint64
+	// ReadRegister reads len(b) bytes starting at the register reg[0]
+	ReadRegister(reg []byte, b []byte) (int, error)
+	// WriteWord writes the 16 bit value to register reg
+	WriteWord(reg uint8, value uint16) (int, error)
+	// WriteQuick sends the read/write bit without any data
+	WriteQuick(bit uint8) error
+	// ReadByte reads a byte without selecting a register
+	ReadByte() (byte, error)
+	// WriteByte writes a byte without selecting a register
+	WriteByte(value byte) error
+	// ReadByteData reads the byte register reg
+	ReadByteData(reg uint8) (uint8, error)
+	// WriteByteData writes value to the byte register reg
+	WriteByteData(reg uint8, value uint8) error
+	// ReadWordData reads the little endian word register reg
+	ReadWordData(reg uint8) (uint16, error)
+	// WriteWordData writes value to the little endian word register reg
+	WriteWordData(reg uint8, value uint16) error
+	// ProcessCall writes value to register reg and reads back a word
+	ProcessCall(reg uint8, value uint16) (uint16, error)
+	// ReadBlockData reads an SMBus block, whose length is sent by the
+	// device, from register reg into b
+	ReadBlockData(reg uint8, b []byte) (int, error)
+	// WriteBlockData writes b as an SMBus block, prefixed by its length, to
+	// register reg
+	WriteBlockData(reg uint8, b []byte) error
+	// BlockProcessCall writes the block w to register reg and reads back a
+	// block into r
+	BlockProcessCall(reg uint8, w []byte, r []byte) (int, error)
+	// ReadI2cBlockData reads len(b) bytes starting at register reg
+	ReadI2cBlockData(reg uint8, b []byte) (int, error)
+	// WriteI2cBlockData writes b starting at register reg
+	WriteI2cBlockData(reg uint8, b []byte) error
+	// Transfer runs msgs as one combined transfer with repeated starts
+	Transfer(msgs ...I2cMessage) error
+	// Tx writes w and then reads len(r) bytes into r, with a repeated start
+	// when the adapter supports it
+	Tx(w []byte, r []byte) error
 }
 
 type i2cDevice struct {
-	file  File
-	funcs uint64 // adapter functionality mask
+	file    File
+	funcs   uint64 // adapter functionality mask
+	address int
 }
 
 // NewI2cDevice returns an io.ReadWriteCloser with the proper ioctrl given
@@ -81,10 +182,13 @@ func (d *i2cDevice) queryFunctionality() (err error) {
 	if errno != 0 {
 		err = fmt.Errorf("Querying functionality failed with syscall.Errno %v", errno)
 	}
-	fmt.Printf("Functionality: 0x%x\n", d.funcs)
 	return
 }
 
+func (d *i2cDevice) Functionality() uint64 { return d.funcs }
+
+func (d *i2cDevice) supports(funcs uint64) bool { return d.funcs&funcs == funcs }
+
 func (d *i2cDevice) SetAddress(address int) (err error) {
 	_, _, errno := Syscall(
 		syscall.SYS_IOCTL,
@@ -95,7 +199,9 @@ func (d *i2cDevice) SetAddress(address int) (err error) {
 
 	if errno != 0 {
 		err = fmt.Errorf("Setting address failed with syscall.Errno %v", errno)
+		return
 	}
+	d.address = address
 
 	return
 }
@@ -104,126 +210,306 @@ func (d *i2cDevice) Close() (err error) {
 	return d.file.Close()
 }
 
+// Read reads len(b) bytes. Adapters which only speak SMBus read them one
+// byte at a time, since a block read would write a command byte first.
 func (d *i2cDevice) Read(b []byte) (n int, err error) {
-	if d.funcs&I2C_FUNC_SMBUS_READ_BLOCK_DATA == 0 {
-		// Adapter doesn't support SMBus block read
+	if d.supports(I2C_FUNC_I2C) || !d.supports(I2C_FUNC_SMBUS_READ_BYTE) {
 		return d.file.Read(b)
 	}
+	for n < len(b) {
+		var data i2cSmbusData
+		if err = d.smbusAccess(I2C_SMBUS_READ, 0, I2C_SMBUS_BYTE, &data); err != nil {
+			return
+		}
+		b[n] = data[0]
+		n++
+	}
+	return
+}
 
-	data := make([]byte, len(b)+1)
-	data[0] = byte(len(b))
-
-	smbus := &i2cSmbusIoctlData{
-		readWrite: I2C_SMBUS_READ,
-		command:   0,
-		size:      I2C_SMBUS_I2C_BLOCK_DATA,
-		data:      uintptr(unsafe.Pointer(&data[0])),
+// Write writes b. Adapters which only speak SMBus write a single byte as is,
+// and b[1:] as a block to the register b[0] otherwise, which is the same on
+// the wire.
+func (d *i2cDevice) Write(b []byte) (n int, err error) {
+	if len(b) == 0 || d.supports(I2C_FUNC_I2C) {
+		return d.file.Write(b)
 	}
-	_, _, errno := Syscall(
-		syscall.SYS_IOCTL,
-		d.file.Fd(),
-		I2C_SMBUS,
-		uintptr(unsafe.Pointer(smbus)),
-	)
+	if len(b) == 1 && d.supports(I2C_FUNC_SMBUS_WRITE_BYTE) {
+		if err = d.WriteByte(b[0]); err != nil {
+			return
+		}
+		return 1, nil
+	}
+	if !d.supports(I2C_FUNC_SMBUS_WRITE_I2C_BLOCK) {
+		return d.file.Write(b)
+	}
+	if err = d.WriteI2cBlockData(b[0], b[1:]); err != nil {
+		return
+	}
+	return len(b), nil
+}
 
-	if errno != 0 {
-		return n, fmt.Errorf("Read failed with syscall.Errno %v", errno)
+func (d *i2cDevice) ReadRegister(reg []byte, b []byte) (n int, err error) {
+	return d.ReadI2cBlockData(reg[0], b)
+}
+
+// ReadWordRegister reads the word register reg[0] into b[0:2], low byte
+// first. Use ReadWordData instead.
+func (d *i2cDevice) ReadWordRegister(reg []byte, b []byte) (n int, err error) {
+	value, err := d.ReadWordData(reg[0])
+	if err != nil {
+		return
 	}
+	return copy(b, []byte{byte(value), byte(value >> 8)}), nil
+}
 
-	copy(b, data[1:])
+func (d *i2cDevice) WriteWord(reg uint8, value uint16) (n int, err error) {
+	if err = d.WriteWordData(reg, value); err != nil {
+		return
+	}
+	return 2, nil
+}
 
-	return int(data[0]), nil
+func (d *i2cDevice) WriteQuick(bit uint8) (err error) {
+	if !d.supports(I2C_FUNC_SMBUS_QUICK) {
+		return ErrI2cNotSupported
+	}
+	return d.smbusAccess(bit, 0, I2C_SMBUS_QUICK, nil)
 }
 
-func (d *i2cDevice) ReadRegister(address []byte, b []byte) (n int, err error) {
-	// if d.funcs&I2C_FUNC_SMBUS_READ_BLOCK_DATA == 0 {
-	// 	// Adapter doesn't support SMBus block read
-	// 	return d.file.Read(b)
-	// }
+func (d *i2cDevice) ReadByte() (value byte, err error) {
+	if !d.supports(I2C_FUNC_SMBUS_READ_BYTE) {
+		b := make([]byte, 1)
+		if err = d.readFull(b); err != nil {
+			return
+		}
+		return b[0], nil
+	}
+	var data i2cSmbusData
+	err = d.smbusAccess(I2C_SMBUS_READ, 0, I2C_SMBUS_BYTE, &data)
+	return data[0], err
+}
 
-	register := byte(address[0])
+func (d *i2cDevice) WriteByte(value byte) (err error) {
+	if !d.supports(I2C_FUNC_SMBUS_WRITE_BYTE) {
+		_, err = d.file.Write([]byte{value})
+		return
+	}
+	// the byte is sent as the command of the transaction
+	return d.smbusAccess(I2C_SMBUS_WRITE, value, I2C_SMBUS_BYTE, nil)
+}
 
-	data := make([]byte, len(b)+1)
-	data[0] = byte(len(b))
+func (d *i2cDevice) ReadByteData(reg uint8) (value uint8, err error) {
+	if !d.supports(I2C_FUNC_SMBUS_READ_BYTE_DATA) {
+		b := make([]byte, 1)
+		if err = d.txFallback([]byte{reg}, b); err != nil {
+			return
+		}
+		return b[0], nil
+	}
+	var data i2cSmbusData
+	err = d.smbusAccess(I2C_SMBUS_READ, reg, I2C_SMBUS_BYTE_DATA, &data)
+	return data[0], err
+}
 
-	smbus := &i2cSmbusIoctlData{
-		readWrite: I2C_SMBUS_READ,
-		command:   register,
-		size:      I2C_SMBUS_I2C_BLOCK_DATA,
-		data:      uintptr(unsafe.Pointer(&data[0])),
+func (d *i2cDevice) WriteByteData(reg uint8, value uint8) (err error) {
+	if !d.supports(I2C_FUNC_SMBUS_WRITE_BYTE_DATA) {
+		_, err = d.file.Write([]byte{reg, value})
+		return
 	}
-	_, _, errno := Syscall(
-		syscall.SYS_IOCTL,
-		d.file.Fd(),
-		I2C_SMBUS,
-		uintptr(unsafe.Pointer(smbus)),
-	)
+	data := i2cSmbusData{value}
+	return d.smbusAccess(I2C_SMBUS_WRITE, reg, I2C_SMBUS_BYTE_DATA, &data)
+}
 
-	if errno != 0 {
-		return n, fmt.Errorf("Read failed with syscall.Errno %v", errno)
+func (d *i2cDevice) ReadWordData(reg uint8) (value uint16, err error) {
+	if !d.supports(I2C_FUNC_SMBUS_READ_WORD_DATA) {
+		b := make([]byte, 2)
+		if err = d.txFallback([]byte{reg}, b); err != nil {
+			return
+		}
+		return uint16(b[0]) | uint16(b[1])<<8, nil
 	}
+	var data i2cSmbusData
+	err = d.smbusAccess(I2C_SMBUS_READ, reg, I2C_SMBUS_WORD_DATA, &data)
+	return uint16(data[0]) | uint16(data[1])<<8, err
+}
 
-	copy(b, data[1:])
+func (d *i2cDevice) WriteWordData(reg uint8, value uint16) (err error) {
+	if !d.supports(I2C_FUNC_SMBUS_WRITE_WORD_DATA) {
+		_, err = d.file.Write([]byte{reg, byte(value), byte(value >> 8)})
+		return
+	}
+	data := i2cSmbusData{byte(value), byte(value >> 8)}
+	return d.smbusAccess(I2C_SMBUS_WRITE, reg, I2C_SMBUS_WORD_DATA, &data)
+}
 
-	return int(data[0]), nil
+func (d *i2cDevice) ProcessCall(reg uint8, value uint16) (result uint16, err error) {
+	if !d.supports(I2C_FUNC_SMBUS_PROC_CALL) {
+		return 0, ErrI2cNotSupported
+	}
+	data := i2cSmbusData{byte(value), byte(value >> 8)}
+	err = d.smbusAccess(I2C_SMBUS_WRITE, reg, I2C_SMBUS_PROC_CALL, &data)
+	return uint16(data[0]) | uint16(data[1])<<8, err
 }
 
-func (d *i2cDevice) ReadWordRegister(address []byte, b []byte) (n int, err error) {
-	if err = d.queryFunctionality(); err != nil {
-		fmt.Printf("QueryFunctionality: %v \n", err)
+func (d *i2cDevice) ReadBlockData(reg uint8, b []byte) (n int, err error) {
+	if !d.supports(I2C_FUNC_SMBUS_READ_BLOCK_DATA) {
+		return 0, ErrI2cNotSupported
+	}
+	var data i2cSmbusData
+	if err = d.smbusAccess(I2C_SMBUS_READ, reg, I2C_SMBUS_BLOCK_DATA, &data); err != nil {
+		return
 	}
+	return copy(b, data[1:1+blockLength(data[0])]), nil
+}
 
-	// if d.funcs&I2C_FUNC_SMBUS_READ_BLOCK_DATA == 0 {
-	// 	// Adapter doesn't support SMBus block read
-	// 	return d.file.Read(b)
-	// }
+func (d *i2cDevice) WriteBlockData(reg uint8, b []byte) (err error) {
+	if !d.supports(I2C_FUNC_SMBUS_WRITE_BLOCK_DATA) {
+		return ErrI2cNotSupported
+	}
+	data, err := newBlock(b)
+	if err != nil {
+		return
+	}
+	return d.smbusAccess(I2C_SMBUS_WRITE, reg, I2C_SMBUS_BLOCK_DATA, data)
+}
 
-	register := byte(address[0])
+func (d *i2cDevice) BlockProcessCall(reg uint8, w []byte, r []byte) (n int, err error) {
+	if !d.supports(I2C_FUNC_SMBUS_BLOCK_PROC_CALL) {
+		return 0, ErrI2cNotSupported
+	}
+	data, err := newBlock(w)
+	if err != nil {
+		return
+	}
+	if err = d.smbusAccess(I2C_SMBUS_WRITE, reg, I2C_SMBUS_BLOCK_PROC_CALL, data); err != nil {
+		return
+	}
+	return copy(r, data[1:1+blockLength(data[0])]), nil
+}
 
-	data := make([]byte, len(b)+1)
+func (d *i2cDevice) ReadI2cBlockData(reg uint8, b []byte) (n int, err error) {
+	if !d.supports(I2C_FUNC_SMBUS_READ_I2C_BLOCK) || len(b) > I2C_SMBUS_BLOCK_MAX {
+		if err = d.txFallback([]byte{reg}, b); err != nil {
+			return
+		}
+		return len(b), nil
+	}
+	var data i2cSmbusData
 	data[0] = byte(len(b))
+	if err = d.smbusAccess(I2C_SMBUS_READ, reg, I2C_SMBUS_I2C_BLOCK_DATA, &data); err != nil {
+		return
+	}
+	return copy(b, data[1:1+blockLength(data[0])]), nil
+}
 
-	smbus := &i2cSmbusIoctlData{
-		readWrite: I2C_SMBUS_READ,
-		command:   register,
-		size:      I2C_SMBUS_I2C_BLOCK_DATA,
-		data:      uintptr(unsafe.Pointer(&data[0])),
+func (d *i2cDevice) WriteI2cBlockData(reg uint8, b []byte) (err error) {
+	if !d.supports(I2C_FUNC_SMBUS_WRITE_I2C_BLOCK) || len(b) > I2C_SMBUS_BLOCK_MAX {
+		_, err = d.file.Write(append([]byte{reg}, b...))
+		return
+	}
+	data, err := newBlock(b)
+	if err != nil {
+		return
+	}
+	return d.smbusAccess(I2C_SMBUS_WRITE, reg, I2C_SMBUS_I2C_BLOCK_DATA, data)
+}
+
+// Transfer runs msgs as one I2C_RDWR transfer. Adapters without plain i2c
+// support can not run it.
+func (d *i2cDevice) Transfer(msgs ...I2cMessage) (err error) {
+	if !d.supports(I2C_FUNC_I2C) {
+		return ErrI2cNotSupported
 	}
+	if len(msgs) == 0 {
+		return
+	}
+
+	kmsgs := make([]i2cMsg, len(msgs))
+	for i, msg := range msgs {
+		kmsgs[i] = i2cMsg{
+			addr:  uint16(d.address),
+			flags: msg.Flags,
+			len:   uint16(len(msg.Buf)),
+		}
+		if len(msg.Buf) > 0 {
+			kmsgs[i].buf = uintptr(unsafe.Pointer(&msg.Buf[0]))
+		}
+	}
+	rdwr := &i2cRdwrIoctlData{
+		msgs:  uintptr(unsafe.Pointer(&kmsgs[0])),
+		nmsgs: uint32(len(kmsgs)),
+	}
+
 	_, _, errno := Syscall(
 		syscall.SYS_IOCTL,
 		d.file.Fd(),
-		I2C_SMBUS,
-		uintptr(unsafe.Pointer(smbus)),
+		I2C_RDWR,
+		uintptr(unsafe.Pointer(rdwr)),
 	)
 
 	if errno != 0 {
-		return n, fmt.Errorf("Read failed with syscall.Errno %v", errno)
+		err = fmt.Errorf("Transfer failed with syscall.Errno %v", errno)
 	}
-
-	copy(b, data[1:])
-
-	return int(data[0]), nil
+	return
 }
 
-func (d *i2cDevice) Write(b []byte) (n int, err error) {
-	if d.funcs&I2C_FUNC_SMBUS_WRITE_BLOCK_DATA == 0 {
-		// Adapter doesn't support SMBus block write
-		return d.file.Write(b)
+// Tx writes w and then reads r in one I2C_RDWR transfer. Without plain i2c
+// support, a one byte w selects the register of an SMBus read.
+func (d *i2cDevice) Tx(w []byte, r []byte) (err error) {
+	switch {
+	case len(r) == 0:
+		_, err = d.Write(w)
+		return
+	case len(w) == 0:
+		return d.readFull(r)
+	case d.supports(I2C_FUNC_I2C):
+		return d.Transfer(I2cMessage{Buf: w}, I2cMessage{Flags: I2C_M_RD, Buf: r})
+	case len(w) == 1 && len(r) == 1 && d.supports(I2C_FUNC_SMBUS_READ_BYTE_DATA):
+		r[0], err = d.ReadByteData(w[0])
+		return
+	case len(w) == 1 && len(r) == 2 && d.supports(I2C_FUNC_SMBUS_READ_WORD_DATA):
+		var value uint16
+		if value, err = d.ReadWordData(w[0]); err != nil {
+			return
+		}
+		r[0], r[1] = byte(value), byte(value>>8)
+		return
+	case len(w) == 1 && len(r) <= I2C_SMBUS_BLOCK_MAX && d.supports(I2C_FUNC_SMBUS_READ_I2C_BLOCK):
+		_, err = d.ReadI2cBlockData(w[0], r)
+		return
 	}
+	return d.txFallback(w, r)
+}
 
-	command := byte(b[0])
-	buf := b[1:]
-
-	data := make([]byte, len(buf)+1)
-	data[0] = byte(len(buf))
+// txFallback writes w and then reads r, as a combined transfer when the
+// adapter supports it, or else as a plain write followed by a plain read
+func (d *i2cDevice) txFallback(w []byte, r []byte) (err error) {
+	if d.supports(I2C_FUNC_I2C) {
+		return d.Transfer(I2cMessage{Buf: w}, I2cMessage{Flags: I2C_M_RD, Buf: r})
+	}
+	if _, err = d.file.Write(w); err != nil {
+		return
+	}
+	return d.readFull(r)
+}
 
-	copy(data[1:], buf)
+func (d *i2cDevice) readFull(b []byte) (err error) {
+	n, err := d.file.Read(b)
+	if err == nil && n < len(b) {
+		err = io.ErrUnexpectedEOF
+	}
+	return
+}
 
+func (d *i2cDevice) smbusAccess(readWrite byte, command byte, size uint32, data *i2cSmbusData) (err error) {
 	smbus := &i2cSmbusIoctlData{
-		readWrite: I2C_SMBUS_WRITE,
+		readWrite: readWrite,
 		command:   command,
-		size:      I2C_SMBUS_I2C_BLOCK_DATA,
-		data:      uintptr(unsafe.Pointer(&data[0])),
+		size:      size,
+	}
+	if data != nil {
+		smbus.data = uintptr(unsafe.Pointer(data))
 	}
 
 	_, _, errno := Syscall(
@@ -234,30 +520,28 @@ func (d *i2cDevice) Write(b []byte) (n int, err error) {
 	)
 
 	if errno != 0 {
-		err = fmt.Errorf("Write failed with syscall.Errno %v", errno)
+		if readWrite == I2C_SMBUS_READ {
+			return fmt.Errorf("Read failed with syscall.Errno %v", errno)
+		}
+		return fmt.Errorf("Write failed with syscall.Errno %v", errno)
 	}
-
-	return len(b), err
+	return
 }
 
-func (d *i2cDevice) WriteWord(register uint8, value uint16) (n int, err error) {
-	smbus := &i2cSmbusIoctlData{
-		readWrite: I2C_SMBUS_WRITE,
-		command:   register,
-		size:      I2C_SMBUS_WORD_DATA,
-		data:      uintptr(unsafe.Pointer(&value)),
+// newBlock returns the smbus data of the block b
+func newBlock(b []byte) (data *i2cSmbusData, err error) {
+	if len(b) > I2C_SMBUS_BLOCK_MAX {
+		return nil, ErrI2cBlockSize
 	}
+	data = &i2cSmbusData{byte(len(b))}
+	copy(data[1:], b)
+	return
+}
 
-	_, _, errno := Syscall(
-		syscall.SYS_IOCTL,
-		d.file.Fd(),
-		I2C_SMBUS,
-		uintptr(unsafe.Pointer(smbus)),
-	)
-
-	if errno != 0 {
-		err = fmt.Errorf("Write failed with syscall.Errno %v", errno)
+// blockLength bounds the length of a block received from a device
+func blockLength(n byte) int {
+	if n > I2C_SMBUS_BLOCK_MAX {
+		return I2C_SMBUS_BLOCK_MAX
 	}
-
-	return 0, err
+	return int(n)
 }
@@ -1,8 +1,11 @@
 package sysfs
 
 import (
+	"io"
 	"os"
+	"syscall"
 	"testing"
+	"unsafe"
 
 	"github.com/hybridgroup/gobot/gobottest"
 )
@@ -47,3 +50,238 @@ func TestNewI2cDevice(t *testing.T) {
 	gobottest.Assert(t, err, nil)
 
 }
+
+// ptr converts an address handed to the mock syscall back to a pointer
+func ptr(a uintptr) unsafe.Pointer { return *(*unsafe.Pointer)(unsafe.Pointer(&a)) }
+
+// i2cMockAdapter answers the ioctls of an i2c adapter with functionality
+// funcs, and records the transactions
+type i2cMockAdapter struct {
+	funcs   uint64
+	address uintptr
+	reply   i2cSmbusData
+	smbus   []i2cSmbusIoctlData
+	sent    []i2cSmbusData
+	msgs    []I2cMessage
+	addrs   []uint16
+	errno   syscall.Errno
+}
+
+func (m *i2cMockAdapter) syscall(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+	switch a2 {
+	case I2C_FUNCS:
+		*(*uint64)(ptr(a3)) = m.funcs
+	case I2C_SLAVE:
+		m.address = a3
+	case I2C_SMBUS:
+		s := *(*i2cSmbusIoctlData)(ptr(a3))
+		m.smbus = append(m.smbus, s)
+		if s.data != 0 {
+			data := (*i2cSmbusData)(ptr(s.data))
+			m.sent = append(m.sent, *data)
+			if s.readWrite == I2C_SMBUS_READ || s.size == I2C_SMBUS_PROC_CALL ||
+				s.size == I2C_SMBUS_BLOCK_PROC_CALL {
+				*data = m.reply
+			}
+		}
+	case I2C_RDWR:
+		rdwr := (*i2cRdwrIoctlData)(ptr(a3))
+		for i := uintptr(0); i < uintptr(rdwr.nmsgs); i++ {
+			msg := (*i2cMsg)(ptr(rdwr.msgs + i*unsafe.Sizeof(i2cMsg{})))
+			buf := make([]byte, msg.len)
+			for j := range buf {
+				b := (*byte)(ptr(msg.buf + uintptr(j)))
+				if msg.flags&I2C_M_RD != 0 {
+					*b = m.reply[j]
+				}
+				buf[j] = *b
+			}
+			m.addrs = append(m.addrs, msg.addr)
+			m.msgs = append(m.msgs, I2cMessage{Flags: msg.flags, Buf: buf})
+		}
+	}
+	return 0, 0, m.errno
+}
+
+func initTestI2cDevice(funcs uint64) (*i2cDevice, *i2cMockAdapter, *MockFile) {
+	fs := NewMockFilesystem([]string{"/dev/i2c-1"})
+	SetFilesystem(fs)
+	m := &i2cMockAdapter{funcs: funcs}
+	SetSyscall(&MockSyscall{Impl: m.syscall})
+
+	d, _ := NewI2cDevice("/dev/i2c-1", 0x42)
+	return d, m, fs.Files["/dev/i2c-1"]
+}
+
+func TestI2cDeviceSmbus(t *testing.T) {
+	defer SetSyscall(&NativeSyscall{})
+	d, m, _ := initTestI2cDevice(0xffffffff &^ I2C_FUNC_I2C)
+	gobottest.Assert(t, d.Functionality(), uint64(0xffffffff&^I2C_FUNC_I2C))
+	gobottest.Assert(t, m.address, uintptr(0x42))
+	m.reply = i2cSmbusData{0x03, 0x01, 0x02, 0x03}
+
+	gobottest.Assert(t, d.WriteQuick(I2C_SMBUS_WRITE), nil)
+	gobottest.Assert(t, m.smbus[0].size, uint32(I2C_SMBUS_QUICK))
+
+	b, err := d.ReadByte()
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, b, uint8(0x03))
+	gobottest.Assert(t, d.WriteByte(0x10), nil)
+	gobottest.Assert(t, m.smbus[2].command, uint8(0x10))
+	gobottest.Assert(t, m.smbus[2].size, uint32(I2C_SMBUS_BYTE))
+
+	b, err = d.ReadByteData(0x11)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, b, uint8(0x03))
+	gobottest.Assert(t, m.smbus[3].readWrite, uint8(I2C_SMBUS_READ))
+	gobottest.Assert(t, m.smbus[3].command, uint8(0x11))
+	gobottest.Assert(t, d.WriteByteData(0x12, 0xaa), nil)
+	gobottest.Assert(t, m.sent[len(m.sent)-1][0], uint8(0xaa))
+
+	w, err := d.ReadWordData(0x13)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, w, uint16(0x0103))
+	gobottest.Assert(t, d.WriteWordData(0x14, 0xbbcc), nil)
+	gobottest.Assert(t, m.sent[len(m.sent)-1][:2], []byte{0xcc, 0xbb})
+	n, err := d.WriteWord(0x14, 0xbbcc)
+	gobottest.Assert(t, n, 2)
+	gobottest.Assert(t, err, nil)
+
+	w, err = d.ProcessCall(0x15, 0x0405)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, w, uint16(0x0103))
+	gobottest.Assert(t, m.sent[len(m.sent)-1][:2], []byte{0x05, 0x04})
+
+	buf := make([]byte, 8)
+	n, err = d.ReadBlockData(0x16, buf)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, buf[:n], []byte{0x01, 0x02, 0x03})
+	gobottest.Assert(t, d.WriteBlockData(0x17, []byte{0x0a, 0x0b}), nil)
+	gobottest.Assert(t, m.sent[len(m.sent)-1][:3], []byte{0x02, 0x0a, 0x0b})
+	n, err = d.BlockProcessCall(0x18, []byte{0x0c}, buf)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, buf[:n], []byte{0x01, 0x02, 0x03})
+	gobottest.Assert(t, m.smbus[len(m.smbus)-1].size, uint32(I2C_SMBUS_BLOCK_PROC_CALL))
+	gobottest.Assert(t, d.WriteBlockData(0x17, make([]byte, 33)), ErrI2cBlockSize)
+
+	buf = make([]byte, 2)
+	n, err = d.ReadI2cBlockData(0x19, buf)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, m.sent[len(m.sent)-1][0], uint8(2))
+	gobottest.Assert(t, d.WriteI2cBlockData(0x1a, []byte{0x0d}), nil)
+	gobottest.Assert(t, m.smbus[len(m.smbus)-1].size, uint32(I2C_SMBUS_I2C_BLOCK_DATA))
+
+	// a register read is a single smbus transaction
+	gobottest.Assert(t, d.Tx([]byte{0x1b}, buf), nil)
+	gobottest.Assert(t, m.smbus[len(m.smbus)-1].size, uint32(I2C_SMBUS_WORD_DATA))
+	gobottest.Assert(t, m.smbus[len(m.smbus)-1].command, uint8(0x1b))
+	gobottest.Assert(t, buf, []byte{0x03, 0x01})
+
+	gobottest.Assert(t, d.Transfer(I2cMessage{Buf: []byte{0x01}}), ErrI2cNotSupported)
+
+	// a plain read sends no command byte, it reads the bytes one at a time
+	smbus := len(m.smbus)
+	buf = make([]byte, 3)
+	n, err = d.Read(buf)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, n, 3)
+	gobottest.Assert(t, buf, []byte{0x03, 0x03, 0x03})
+	gobottest.Assert(t, len(m.smbus), smbus+3)
+	for _, s := range m.smbus[smbus:] {
+		gobottest.Assert(t, s.readWrite, byte(I2C_SMBUS_READ))
+		gobottest.Assert(t, s.size, uint32(I2C_SMBUS_BYTE))
+	}
+
+	// a single byte is written as is, not as an empty block to a register
+	n, err = d.Write([]byte{0x1c})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, n, 1)
+	gobottest.Assert(t, m.smbus[len(m.smbus)-1].readWrite, uint8(I2C_SMBUS_WRITE))
+	gobottest.Assert(t, m.smbus[len(m.smbus)-1].size, uint32(I2C_SMBUS_BYTE))
+	gobottest.Assert(t, m.smbus[len(m.smbus)-1].command, uint8(0x1c))
+	n, err = d.Write([]byte{0x1d, 0x0e})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, n, 2)
+	gobottest.Assert(t, m.smbus[len(m.smbus)-1].size, uint32(I2C_SMBUS_I2C_BLOCK_DATA))
+	gobottest.Assert(t, m.smbus[len(m.smbus)-1].command, uint8(0x1d))
+
+	m.errno = syscall.EIO
+	n, err = d.Read(buf)
+	gobottest.Refute(t, err, nil)
+	gobottest.Assert(t, n, 0)
+	_, err = d.ReadByteData(0x11)
+	gobottest.Refute(t, err, nil)
+	gobottest.Refute(t, d.WriteByteData(0x11, 0x01), nil)
+}
+
+func TestI2cDeviceFallbacks(t *testing.T) {
+	defer SetSyscall(&NativeSyscall{})
+	d, m, f := initTestI2cDevice(0)
+
+	gobottest.Assert(t, d.WriteByteData(0x01, 0x02), nil)
+	gobottest.Assert(t, f.Contents, "\x01\x02")
+	gobottest.Assert(t, d.WriteWordData(0x01, 0x0203), nil)
+	gobottest.Assert(t, f.Contents, "\x01\x03\x02")
+	gobottest.Assert(t, d.WriteI2cBlockData(0x04, []byte{0x05, 0x06}), nil)
+	gobottest.Assert(t, f.Contents, "\x04\x05\x06")
+	gobottest.Assert(t, d.WriteByte(0x07), nil)
+	gobottest.Assert(t, f.Contents, "\x07")
+
+	// the mock file reads back what was last written
+	b, err := d.ReadByteData(0x08)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, b, uint8(0x08))
+	_, err = d.ReadWordData(0x09)
+	gobottest.Assert(t, err, io.ErrUnexpectedEOF)
+	gobottest.Assert(t, d.Tx([]byte{0x0a, 0x0b}, make([]byte, 2)), nil)
+	gobottest.Assert(t, len(m.smbus), 0)
+
+	gobottest.Assert(t, d.WriteQuick(0), ErrI2cNotSupported)
+	_, err = d.ProcessCall(0x01, 0x01)
+	gobottest.Assert(t, err, ErrI2cNotSupported)
+	_, err = d.ReadBlockData(0x01, []byte{})
+	gobottest.Assert(t, err, ErrI2cNotSupported)
+	gobottest.Assert(t, d.WriteBlockData(0x01, []byte{}), ErrI2cNotSupported)
+	_, err = d.BlockProcessCall(0x01, []byte{}, []byte{})
+	gobottest.Assert(t, err, ErrI2cNotSupported)
+}
+
+func TestI2cDeviceTransfer(t *testing.T) {
+	defer SetSyscall(&NativeSyscall{})
+	d, m, _ := initTestI2cDevice(I2C_FUNC_I2C | I2C_FUNC_SMBUS_READ_WORD_DATA)
+	m.reply = i2cSmbusData{0x0a, 0x0b, 0x0c}
+
+	buf := make([]byte, 3)
+	gobottest.Assert(t, d.Tx([]byte{0x01}, buf), nil)
+	gobottest.Assert(t, buf, []byte{0x0a, 0x0b, 0x0c})
+	gobottest.Assert(t, m.msgs, []I2cMessage{
+		{Flags: 0, Buf: []byte{0x01}},
+		{Flags: I2C_M_RD, Buf: []byte{0x0a, 0x0b, 0x0c}},
+	})
+	gobottest.Assert(t, m.addrs, []uint16{0x42, 0x42})
+	gobottest.Assert(t, len(m.smbus), 0)
+
+	// block reads without smbus support use a combined transfer
+	m.msgs = nil
+	_, err := d.ReadI2cBlockData(0x02, buf[:1])
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, len(m.msgs), 2)
+
+	// repeated starts to the device selected last
+	gobottest.Assert(t, d.SetAddress(0x43), nil)
+	m.addrs = nil
+	gobottest.Assert(t, d.Transfer(
+		I2cMessage{Buf: []byte{0x03, 0x04}},
+		I2cMessage{Buf: []byte{0x05}},
+	), nil)
+	gobottest.Assert(t, m.addrs, []uint16{0x43, 0x43})
+
+	// word reads still use the smbus command
+	w, err := d.ReadWordData(0x06)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, w, uint16(0x0b0a))
+	gobottest.Assert(t, len(m.smbus), 1)
+
+	m.errno = syscall.EIO
+	gobottest.Refute(t, d.Transfer(I2cMessage{Buf: []byte{0x01}}), nil)
+}
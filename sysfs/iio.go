@@ -0,0 +1,192 @@
+package sysfs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IIOPATH default linux industrial I/O path
+const IIOPATH = "/sys/bus/iio/devices"
+
+var (
+	// ErrIioDeviceNotFound is returned when no iio device matches a name
+	ErrIioDeviceNotFound = errors.New("iio device not found")
+	// ErrIioChannelNotFound is returned when a device has no such channel
+	ErrIioChannelNotFound = errors.New("iio channel not found")
+)
+
+// Linux industrial I/O specific sysfs docs.
+//  https://www.kernel.org/doc/Documentation/ABI/testing/sysfs-bus-iio
+//  https://www.kernel.org/doc/html/latest/driver-api/iio/buffers.html
+
+// IioDevice represents an industrial I/O device such as an ADC, or a sensor
+// like an accelerometer or a light sensor
+type IioDevice struct {
+	// Name is the name of the kernel driver, eg. "TI-am335x-adc"
+	Name string
+	// Dir is the sysfs directory of the device, eg. /sys/bus/iio/devices/iio:device0
+	Dir string
+}
+
+// NewIioDevice returns the IioDevice in the sysfs directory dir
+func NewIioDevice(dir string) *IioDevice {
+	d := &IioDevice{Dir: dir}
+	d.Name, _ = readAttribute(dir + "/name")
+	return d
+}
+
+// IioDevices returns all industrial I/O devices known to the kernel
+func IioDevices() (devices []*IioDevice, err error) {
+	dirs, err := Glob(IIOPATH + "/iio:device*")
+	if err != nil {
+		return
+	}
+	for _, dir := range dirs {
+		devices = append(devices, NewIioDevice(dir))
+	}
+	return
+}
+
+// FindIioDevice returns the first industrial I/O device whose name starts
+// with name, or whose sysfs directory is named name, eg. "iio:device0"
+func FindIioDevice(name string) (*IioDevice, error) {
+	devices, err := IioDevices()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range devices {
+		if filepath.Base(d.Dir) == name || strings.HasPrefix(d.Name, name) {
+			return d, nil
+		}
+	}
+	return nil, ErrIioDeviceNotFound
+}
+
+// Channels returns the names of the input channels of the device, eg.
+// "voltage0" or "accel_x"
+func (d *IioDevice) Channels() (channels []string, err error) {
+	found := make(map[string]bool)
+	for _, suffix := range []string{"_raw", "_input"} {
+		matches, err := Glob(d.Dir + "/in_*" + suffix)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), "in_"), suffix)
+			if !found[name] {
+				found[name] = true
+				channels = append(channels, name)
+			}
+		}
+	}
+	sort.Strings(channels)
+	return
+}
+
+// ReadRaw returns the unscaled value of channel
+func (d *IioDevice) ReadRaw(channel string) (val int, err error) {
+	s, err := readAttribute(d.channelPath(channel, "raw"))
+	if err != nil {
+		return
+	}
+	return strconv.Atoi(s)
+}
+
+// ReadScale returns the scale of channel. The channel specific scale is used
+// if present, then the scale shared by all channels of the same type. A
+// channel without scale has a scale of 1.
+func (d *IioDevice) ReadScale(channel string) (scale float64, err error) {
+	return d.readSharedAttribute(channel, "scale", 1)
+}
+
+// ReadOffset returns the offset of channel, found in the same way as
+// ReadScale. A channel without offset has an offset of 0.
+func (d *IioDevice) ReadOffset(channel string) (offset float64, err error) {
+	return d.readSharedAttribute(channel, "offset", 0)
+}
+
+// ReadValue returns the value of channel in the units defined by the iio
+// ABI, eg. millivolts for voltage channels. Channels which only provide a
+// processed value are returned as is, otherwise the value is computed as
+// (raw + offset) * scale.
+func (d *IioDevice) ReadValue(channel string) (val float64, err error) {
+	if s, err := readAttribute(d.channelPath(channel, "input")); err == nil {
+		return strconv.ParseFloat(s, 64)
+	}
+
+	raw, err := d.ReadRaw(channel)
+	if err != nil {
+		return
+	}
+	offset, err := d.ReadOffset(channel)
+	if err != nil {
+		return
+	}
+	scale, err := d.ReadScale(channel)
+	if err != nil {
+		return
+	}
+	return (float64(raw) + offset) * scale, nil
+}
+
+// channelPath returns the path of the attribute of an input channel
+func (d *IioDevice) channelPath(channel string, attribute string) string {
+	return d.Dir + "/in_" + channel + "_" + attribute
+}
+
+// readSharedAttribute reads a channel attribute which may be shared by all
+// channels of the same type
+func (d *IioDevice) readSharedAttribute(channel string, attribute string, def float64) (float64, error) {
+	paths := []string{d.channelPath(channel, attribute)}
+	if t := channelType(channel); t != channel {
+		paths = append(paths, d.channelPath(t, attribute))
+	}
+	for _, path := range paths {
+		s, err := readAttribute(path)
+		if err == nil {
+			return strconv.ParseFloat(s, 64)
+		}
+	}
+	return def, nil
+}
+
+// channelType returns the type of a channel, eg. "voltage" for "voltage0"
+// and "accel" for "accel_x"
+func channelType(channel string) string {
+	if i := strings.Index(channel, "_"); i > 0 {
+		channel = channel[:i]
+	}
+	return strings.TrimRight(channel, "0123456789")
+}
+
+// readAttribute returns the whitespace trimmed contents of a sysfs attribute
+func readAttribute(path string) (string, error) {
+	f, err := OpenFile(path, os.O_RDONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 4096)
+	n, err := f.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(buf[:n])), nil
+}
+
+// writeAttribute writes the contents of a sysfs attribute
+func writeAttribute(path string, value string) error {
+	f, err := OpenFile(path, os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(value)
+	return err
+}
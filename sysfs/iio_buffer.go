@@ -0,0 +1,291 @@
+package sysfs
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrIioScanType is returned when a scan element type can not be parsed
+var ErrIioScanType = errors.New("invalid iio scan element type")
+
+// IioScanElement describes how a channel is stored in the scans captured by
+// an IioBuffer
+type IioScanElement struct {
+	// Channel is the name of the channel, eg. "voltage0" or "timestamp"
+	Channel string
+	// Index is the position of the channel within a scan
+	Index int
+	// Signed is true for two's complement values
+	Signed bool
+	// BigEndian is true for big endian values
+	BigEndian bool
+	// Bits is the number of valid bits of the value
+	Bits int
+	// StorageBits is the number of bits used to store the value
+	StorageBits int
+	// Shift is the number of bits the value is shifted within its storage
+	Shift int
+	// Repeat is the number of values stored for the channel
+	Repeat int
+}
+
+// IioScan is a single scan read from an IioBuffer, holding the unscaled
+// value of each enabled channel
+type IioScan map[string]int64
+
+// IioBuffer captures scans of the enabled channels of an IioDevice through
+// its character device, eg. /dev/iio:device0
+type IioBuffer struct {
+	device   *IioDevice
+	elements []IioScanElement
+	offsets  []int
+	size     int
+	file     File
+}
+
+// IioTriggers returns the names of the triggers known to the kernel
+func IioTriggers() (triggers []string, err error) {
+	dirs, err := Glob(IIOPATH + "/trigger*")
+	if err != nil {
+		return
+	}
+	for _, dir := range dirs {
+		name, err := readAttribute(dir + "/name")
+		if err != nil {
+			return nil, err
+		}
+		triggers = append(triggers, name)
+	}
+	return
+}
+
+// ScanElements returns the scan elements of every channel of the device
+// which may be captured through a buffer
+func (d *IioDevice) ScanElements() (elements []IioScanElement, err error) {
+	matches, err := Glob(d.Dir + "/scan_elements/in_*_en")
+	if err != nil {
+		return
+	}
+	for _, m := range matches {
+		channel := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), "in_"), "_en")
+		e, err := d.scanElement(channel)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, e)
+	}
+	sort.Sort(byIndex(elements))
+	return
+}
+
+func (d *IioDevice) scanElement(channel string) (e IioScanElement, err error) {
+	prefix := d.Dir + "/scan_elements/in_" + channel
+	t, err := readAttribute(prefix + "_type")
+	if err != nil {
+		return
+	}
+	if e, err = parseScanType(t); err != nil {
+		return
+	}
+	index, err := readAttribute(prefix + "_index")
+	if err != nil {
+		return
+	}
+	e.Channel = channel
+	e.Index, err = strconv.Atoi(index)
+	return
+}
+
+// parseScanType parses a scan element type such as "le:s12/16>>4" or
+// "be:u16/32X2>>0"
+func parseScanType(t string) (e IioScanElement, err error) {
+	if len(t) < 5 || t[2] != ':' {
+		return e, ErrIioScanType
+	}
+	endian, sign, rest := t[0], t[3], t[4:]
+	if (endian != 'b' && endian != 'l') || (sign != 's' && sign != 'u') {
+		return e, ErrIioScanType
+	}
+	e.BigEndian = endian == 'b'
+	e.Signed = sign == 's'
+	e.Repeat = 1
+
+	parts := strings.SplitN(rest, ">>", 2)
+	if len(parts) != 2 {
+		return e, ErrIioScanType
+	}
+	if e.Shift, err = strconv.Atoi(parts[1]); err != nil {
+		return e, ErrIioScanType
+	}
+	sizes := strings.SplitN(parts[0], "/", 2)
+	if len(sizes) != 2 {
+		return e, ErrIioScanType
+	}
+	if e.Bits, err = strconv.Atoi(sizes[0]); err != nil {
+		return e, ErrIioScanType
+	}
+	storage := strings.SplitN(sizes[1], "X", 2)
+	if e.StorageBits, err = strconv.Atoi(storage[0]); err != nil {
+		return e, ErrIioScanType
+	}
+	if len(storage) == 2 {
+		if e.Repeat, err = strconv.Atoi(storage[1]); err != nil {
+			return e, ErrIioScanType
+		}
+	}
+	switch e.StorageBits {
+	case 8, 16, 32, 64:
+	default:
+		return e, ErrIioScanType
+	}
+	return e, nil
+}
+
+// StartBuffer enables capture of channels into a buffer of length scans.
+// When trigger is not empty it is set as the current trigger of the device.
+// Use "timestamp" as a channel to capture the time of each scan. On failure
+// the channels are disabled again and the previous trigger is restored.
+func (d *IioDevice) StartBuffer(channels []string, trigger string, length int) (b *IioBuffer, err error) {
+	var previous string
+	if trigger != "" {
+		if previous, err = readAttribute(d.Dir + "/trigger/current_trigger"); err != nil {
+			return
+		}
+		if err = writeAttribute(d.Dir+"/trigger/current_trigger", trigger); err != nil {
+			return
+		}
+	}
+
+	var enabled []string
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, channel := range enabled {
+			writeAttribute(d.Dir+"/scan_elements/in_"+channel+"_en", "0")
+		}
+		if trigger != "" {
+			writeAttribute(d.Dir+"/trigger/current_trigger", previous)
+		}
+	}()
+
+	b = &IioBuffer{device: d}
+	for _, channel := range channels {
+		var e IioScanElement
+		if e, err = d.scanElement(channel); err != nil {
+			return nil, ErrIioChannelNotFound
+		}
+		if err = writeAttribute(d.Dir+"/scan_elements/in_"+channel+"_en", "1"); err != nil {
+			return nil, err
+		}
+		enabled = append(enabled, channel)
+		b.elements = append(b.elements, e)
+	}
+	sort.Sort(byIndex(b.elements))
+	b.layout()
+
+	if err = writeAttribute(d.Dir+"/buffer/length", strconv.Itoa(length)); err != nil {
+		return nil, err
+	}
+	if err = writeAttribute(d.Dir+"/buffer/enable", "1"); err != nil {
+		return nil, err
+	}
+	if b.file, err = OpenFile("/dev/"+filepath.Base(d.Dir), os.O_RDONLY, 0644); err != nil {
+		writeAttribute(d.Dir+"/buffer/enable", "0")
+		return nil, err
+	}
+	return b, nil
+}
+
+// layout computes the offset of each element within a scan, each element
+// being aligned to its storage size, and the size of a whole scan
+func (b *IioBuffer) layout() {
+	offset, align := 0, 1
+	b.offsets = make([]int, len(b.elements))
+	for i, e := range b.elements {
+		size := e.StorageBits / 8
+		if offset%size != 0 {
+			offset += size - offset%size
+		}
+		b.offsets[i] = offset
+		offset += size * e.Repeat
+		if size > align {
+			align = size
+		}
+	}
+	if offset%align != 0 {
+		offset += align - offset%align
+	}
+	b.size = offset
+}
+
+// ScanSize returns the size in bytes of a single scan
+func (b *IioBuffer) ScanSize() int { return b.size }
+
+// Read blocks until a scan is available and returns it
+func (b *IioBuffer) Read() (scan IioScan, err error) {
+	buf := make([]byte, b.size)
+	if _, err = io.ReadFull(b.file, buf); err != nil {
+		return
+	}
+	return b.decode(buf), nil
+}
+
+func (b *IioBuffer) decode(buf []byte) IioScan {
+	scan := make(IioScan)
+	for i, e := range b.elements {
+		var order binary.ByteOrder = binary.LittleEndian
+		if e.BigEndian {
+			order = binary.BigEndian
+		}
+
+		data := buf[b.offsets[i]:]
+		var v uint64
+		switch e.StorageBits {
+		case 8:
+			v = uint64(data[0])
+		case 16:
+			v = uint64(order.Uint16(data))
+		case 32:
+			v = uint64(order.Uint32(data))
+		case 64:
+			v = order.Uint64(data)
+		}
+
+		v >>= uint(e.Shift)
+		if e.Bits < 64 {
+			v &= 1<<uint(e.Bits) - 1
+		}
+		val := int64(v)
+		if e.Signed && e.Bits < 64 && v&(1<<uint(e.Bits-1)) != 0 {
+			val -= 1 << uint(e.Bits)
+		}
+		scan[e.Channel] = val
+	}
+	return scan
+}
+
+// Close stops the capture and disables the captured channels
+func (b *IioBuffer) Close() (err error) {
+	if err = writeAttribute(b.device.Dir+"/buffer/enable", "0"); err != nil {
+		return
+	}
+	for _, e := range b.elements {
+		if err = writeAttribute(b.device.Dir+"/scan_elements/in_"+e.Channel+"_en", "0"); err != nil {
+			return
+		}
+	}
+	return b.file.Close()
+}
+
+type byIndex []IioScanElement
+
+func (s byIndex) Len() int           { return len(s) }
+func (s byIndex) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byIndex) Less(i, j int) bool { return s[i].Index < s[j].Index }
@@ -0,0 +1,123 @@
+package sysfs
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func initTestIioBufferFilesystem() *MockFilesystem {
+	dir := "/sys/bus/iio/devices/iio:device0"
+	fs := NewMockFilesystem([]string{
+		dir + "/name",
+		dir + "/trigger/current_trigger",
+		dir + "/buffer/length",
+		dir + "/buffer/enable",
+		dir + "/scan_elements/in_voltage0_en",
+		dir + "/scan_elements/in_voltage0_index",
+		dir + "/scan_elements/in_voltage0_type",
+		dir + "/scan_elements/in_voltage1_en",
+		dir + "/scan_elements/in_voltage1_index",
+		dir + "/scan_elements/in_voltage1_type",
+		dir + "/scan_elements/in_timestamp_en",
+		dir + "/scan_elements/in_timestamp_index",
+		dir + "/scan_elements/in_timestamp_type",
+		"/sys/bus/iio/devices/trigger0/name",
+		"/dev/iio:device0",
+	})
+	fs.Files[dir+"/name"].Contents = "TI-am335x-adc"
+	fs.Files[dir+"/scan_elements/in_voltage0_index"].Contents = "0\n"
+	fs.Files[dir+"/scan_elements/in_voltage0_type"].Contents = "le:s12/16>>4\n"
+	fs.Files[dir+"/scan_elements/in_voltage1_index"].Contents = "1\n"
+	fs.Files[dir+"/scan_elements/in_voltage1_type"].Contents = "be:u12/16>>0\n"
+	fs.Files[dir+"/scan_elements/in_timestamp_index"].Contents = "2\n"
+	fs.Files[dir+"/scan_elements/in_timestamp_type"].Contents = "le:s64/64>>0\n"
+	fs.Files["/sys/bus/iio/devices/trigger0/name"].Contents = "sysfstrig0\n"
+	SetFilesystem(fs)
+	return fs
+}
+
+func TestParseScanType(t *testing.T) {
+	e, err := parseScanType("le:s12/16>>4")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, e, IioScanElement{Signed: true, Bits: 12, StorageBits: 16, Shift: 4, Repeat: 1})
+
+	e, err = parseScanType("be:u16/32X3>>0")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, e, IioScanElement{BigEndian: true, Bits: 16, StorageBits: 32, Repeat: 3})
+
+	for _, s := range []string{"", "le:s12", "xe:s12/16>>0", "le:f12/16>>0", "le:s12/12>>0", "le:s12/16>>x", "le:sa/16>>0"} {
+		_, err = parseScanType(s)
+		gobottest.Assert(t, err, ErrIioScanType)
+	}
+}
+
+func TestIioTriggersAndScanElements(t *testing.T) {
+	initTestIioBufferFilesystem()
+
+	triggers, err := IioTriggers()
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, triggers, []string{"sysfstrig0"})
+
+	elements, err := NewIioDevice("/sys/bus/iio/devices/iio:device0").ScanElements()
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, len(elements), 3)
+	gobottest.Assert(t, elements[0].Channel, "voltage0")
+	gobottest.Assert(t, elements[1].Channel, "voltage1")
+	gobottest.Assert(t, elements[2].Channel, "timestamp")
+}
+
+func TestIioBuffer(t *testing.T) {
+	fs := initTestIioBufferFilesystem()
+	dir := "/sys/bus/iio/devices/iio:device0"
+	d := NewIioDevice(dir)
+
+	_, err := d.StartBuffer([]string{"voltage5"}, "", 16)
+	gobottest.Assert(t, err, ErrIioChannelNotFound)
+
+	// the channels and the trigger are restored on failure
+	fs.Files[dir+"/trigger/current_trigger"].Contents = "oldtrig\n"
+	_, err = d.StartBuffer([]string{"voltage0", "voltage5"}, "sysfstrig0", 16)
+	gobottest.Assert(t, err, ErrIioChannelNotFound)
+	gobottest.Assert(t, fs.Files[dir+"/scan_elements/in_voltage0_en"].Contents, "0")
+	gobottest.Assert(t, fs.Files[dir+"/trigger/current_trigger"].Contents, "oldtrig")
+
+	length := fs.Files[dir+"/buffer/length"]
+	delete(fs.Files, dir+"/buffer/length")
+	_, err = d.StartBuffer([]string{"voltage0", "voltage1"}, "sysfstrig0", 16)
+	gobottest.Refute(t, err, nil)
+	gobottest.Assert(t, fs.Files[dir+"/scan_elements/in_voltage0_en"].Contents, "0")
+	gobottest.Assert(t, fs.Files[dir+"/scan_elements/in_voltage1_en"].Contents, "0")
+	gobottest.Assert(t, fs.Files[dir+"/trigger/current_trigger"].Contents, "oldtrig")
+	fs.Files[dir+"/buffer/length"] = length
+
+	b, err := d.StartBuffer([]string{"timestamp", "voltage1", "voltage0"}, "sysfstrig0", 16)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, fs.Files[dir+"/trigger/current_trigger"].Contents, "sysfstrig0")
+	gobottest.Assert(t, fs.Files[dir+"/scan_elements/in_voltage0_en"].Contents, "1")
+	gobottest.Assert(t, fs.Files[dir+"/scan_elements/in_timestamp_en"].Contents, "1")
+	gobottest.Assert(t, fs.Files[dir+"/buffer/length"].Contents, "16")
+	gobottest.Assert(t, fs.Files[dir+"/buffer/enable"].Contents, "1")
+
+	// two 16 bit voltages, padding to align the 64 bit timestamp
+	gobottest.Assert(t, b.ScanSize(), 16)
+
+	fs.Files["/dev/iio:device0"].Contents = string([]byte{
+		0xF0, 0xFF, // voltage0: -1 shifted left by 4
+		0x0A, 0xBC, // voltage1: 0xABC big endian
+		0x00, 0x00,
+		0x00, 0x00,
+		0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	})
+	scan, err := b.Read()
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, scan, IioScan{
+		"voltage0":  -1,
+		"voltage1":  0xABC,
+		"timestamp": 0x0201,
+	})
+
+	gobottest.Assert(t, b.Close(), nil)
+	gobottest.Assert(t, fs.Files[dir+"/buffer/enable"].Contents, "0")
+	gobottest.Assert(t, fs.Files[dir+"/scan_elements/in_voltage1_en"].Contents, "0")
+}
@@ -0,0 +1,104 @@
+package sysfs
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func initTestIioFilesystem() *MockFilesystem {
+	fs := NewMockFilesystem([]string{
+		"/sys/bus/iio/devices/iio:device0/name",
+		"/sys/bus/iio/devices/iio:device0/in_voltage0_raw",
+		"/sys/bus/iio/devices/iio:device0/in_voltage1_raw",
+		"/sys/bus/iio/devices/iio:device0/in_voltage_scale",
+		"/sys/bus/iio/devices/iio:device1/name",
+		"/sys/bus/iio/devices/iio:device1/in_accel_x_raw",
+		"/sys/bus/iio/devices/iio:device1/in_accel_x_scale",
+		"/sys/bus/iio/devices/iio:device1/in_accel_offset",
+		"/sys/bus/iio/devices/iio:device1/in_temp_input",
+	})
+	fs.Files["/sys/bus/iio/devices/iio:device0/name"].Contents = "TI-am335x-adc\n"
+	fs.Files["/sys/bus/iio/devices/iio:device0/in_voltage0_raw"].Contents = "1024\n"
+	fs.Files["/sys/bus/iio/devices/iio:device0/in_voltage1_raw"].Contents = "2048\n"
+	fs.Files["/sys/bus/iio/devices/iio:device0/in_voltage_scale"].Contents = "0.439453125\n"
+	fs.Files["/sys/bus/iio/devices/iio:device1/name"].Contents = "mpu6050\n"
+	fs.Files["/sys/bus/iio/devices/iio:device1/in_accel_x_raw"].Contents = "-100\n"
+	fs.Files["/sys/bus/iio/devices/iio:device1/in_accel_x_scale"].Contents = "0.5\n"
+	fs.Files["/sys/bus/iio/devices/iio:device1/in_accel_offset"].Contents = "20\n"
+	fs.Files["/sys/bus/iio/devices/iio:device1/in_temp_input"].Contents = "36500\n"
+	SetFilesystem(fs)
+	return fs
+}
+
+func TestIioDevices(t *testing.T) {
+	initTestIioFilesystem()
+
+	devices, err := IioDevices()
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, len(devices), 2)
+	gobottest.Assert(t, devices[0].Name, "TI-am335x-adc")
+	gobottest.Assert(t, devices[0].Dir, "/sys/bus/iio/devices/iio:device0")
+	gobottest.Assert(t, devices[1].Name, "mpu6050")
+
+	d, err := FindIioDevice("TI-am335x")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, d.Dir, "/sys/bus/iio/devices/iio:device0")
+
+	d, err = FindIioDevice("iio:device1")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, d.Name, "mpu6050")
+
+	_, err = FindIioDevice("bmp180")
+	gobottest.Assert(t, err, ErrIioDeviceNotFound)
+}
+
+func TestIioDeviceChannels(t *testing.T) {
+	initTestIioFilesystem()
+
+	c, err := NewIioDevice("/sys/bus/iio/devices/iio:device0").Channels()
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, c, []string{"voltage0", "voltage1"})
+
+	c, _ = NewIioDevice("/sys/bus/iio/devices/iio:device1").Channels()
+	gobottest.Assert(t, c, []string{"accel_x", "temp"})
+}
+
+func TestIioDeviceRead(t *testing.T) {
+	initTestIioFilesystem()
+	adc := NewIioDevice("/sys/bus/iio/devices/iio:device0")
+	imu := NewIioDevice("/sys/bus/iio/devices/iio:device1")
+
+	raw, err := adc.ReadRaw("voltage1")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, raw, 2048)
+
+	_, err = adc.ReadRaw("voltage7")
+	gobottest.Refute(t, err, nil)
+
+	// the scale is shared by all voltage channels
+	scale, _ := adc.ReadScale("voltage0")
+	gobottest.Assert(t, scale, 0.439453125)
+	offset, _ := adc.ReadOffset("voltage0")
+	gobottest.Assert(t, offset, 0.0)
+
+	v, err := adc.ReadValue("voltage0")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, v, 450.0)
+
+	v, _ = imu.ReadValue("accel_x")
+	gobottest.Assert(t, v, -40.0)
+
+	v, _ = imu.ReadValue("temp")
+	gobottest.Assert(t, v, 36500.0)
+
+	scale, _ = imu.ReadScale("temp")
+	gobottest.Assert(t, scale, 1.0)
+}
+
+func TestIioChannelType(t *testing.T) {
+	gobottest.Assert(t, channelType("voltage12"), "voltage")
+	gobottest.Assert(t, channelType("accel_x"), "accel")
+	gobottest.Assert(t, channelType("anglvel_z"), "anglvel")
+	gobottest.Assert(t, channelType("temp"), "temp")
+}
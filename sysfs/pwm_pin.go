@@ -0,0 +1,148 @@
+package sysfs
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// PWMPATH default linux pwm path
+	PWMPATH = "/sys/class/pwm"
+	// NORMAL pwm polarity
+	NORMAL = "normal"
+	// INVERSED pwm polarity
+	INVERSED = "inversed"
+)
+
+// Linux pwm specific sysfs docs.
+//  https://www.kernel.org/doc/Documentation/pwm.txt
+
+// PwmPin is the interface for sysfs pwm interactions. Periods and duty
+// cycles are in nanoseconds.
+type PwmPin interface {
+	// Export exports the pin for use by the operating system
+	Export() error
+	// Unexport unexports the pin and releases the pin from the operating system
+	Unexport() error
+	// Enable enables or disables the pwm output of the pin
+	Enable(bool) error
+	// Polarity sets the polarity of the pin, NORMAL or INVERSED
+	Polarity(string) error
+	// Period returns the current period of the pin
+	Period() (int, error)
+	// SetPeriod sets the period of the pin
+	SetPeriod(int) error
+	// DutyCycle returns the current duty cycle of the pin
+	DutyCycle() (int, error)
+	// SetDutyCycle sets the duty cycle of the pin
+	SetDutyCycle(int) error
+}
+
+// pwmExportRetries and pwmExportDelay bound the wait for the pwm directory
+// of a pin, which is created, and given its permissions by udev, shortly
+// after the pin is exported
+var pwmExportRetries = 10
+var pwmExportDelay = 10 * time.Millisecond
+
+type pwmPin struct {
+	pin  string
+	chip string
+}
+
+// NewPwmPin returns a PwmPin given the channel number of the pin on its pwm
+// chip and an optional sysfs pwm chip directory. If no chip is supplied the
+// default chip is PWMPATH/pwmchip0.
+func NewPwmPin(pin int, v ...string) PwmPin {
+	p := &pwmPin{pin: strconv.Itoa(pin), chip: PWMPATH + "/pwmchip0"}
+	if len(v) > 0 {
+		p.chip = v[0]
+	}
+	return p
+}
+
+func (p *pwmPin) path(attribute string) string {
+	return fmt.Sprintf("%v/pwm%v/%v", p.chip, p.pin, attribute)
+}
+
+func (p *pwmPin) Export() (err error) {
+	if err = writeAttribute(p.chip+"/export", p.pin); err != nil {
+		// If EBUSY then the pin has already been exported
+		if e, ok := err.(*os.PathError); !ok || e.Err != syscall.EBUSY {
+			return
+		}
+	}
+
+	for i := 0; ; i++ {
+		if _, err = readAttribute(p.path("period")); err == nil {
+			return
+		}
+		if i == pwmExportRetries {
+			return fmt.Errorf("pwm%v of %v was not exported: %v", p.pin, p.chip, err)
+		}
+		time.Sleep(pwmExportDelay)
+	}
+}
+
+func (p *pwmPin) Unexport() (err error) {
+	if err = writeAttribute(p.chip+"/unexport", p.pin); err != nil {
+		// If EINVAL then the pin is not exported
+		if e, ok := err.(*os.PathError); ok && e.Err == syscall.EINVAL {
+			return nil
+		}
+	}
+	return
+}
+
+func (p *pwmPin) Enable(enable bool) (err error) {
+	val := "0"
+	if enable {
+		val = "1"
+	}
+	return writeAttribute(p.path("enable"), val)
+}
+
+func (p *pwmPin) Polarity(polarity string) (err error) {
+	return writeAttribute(p.path("polarity"), polarity)
+}
+
+func (p *pwmPin) Period() (period int, err error) {
+	return p.readInt("period")
+}
+
+func (p *pwmPin) SetPeriod(period int) (err error) {
+	return writeAttribute(p.path("period"), strconv.Itoa(period))
+}
+
+func (p *pwmPin) DutyCycle() (duty int, err error) {
+	return p.readInt("duty_cycle")
+}
+
+func (p *pwmPin) SetDutyCycle(duty int) (err error) {
+	return writeAttribute(p.path("duty_cycle"), strconv.Itoa(duty))
+}
+
+func (p *pwmPin) readInt(attribute string) (val int, err error) {
+	s, err := readAttribute(p.path(attribute))
+	if err != nil {
+		return
+	}
+	return strconv.Atoi(s)
+}
+
+// SetPwm sets both the period and the duty cycle of p, in the order needed
+// for the kernel to never see a duty cycle longer than the period
+func SetPwm(p PwmPin, period int, duty int) (err error) {
+	if current, err := p.DutyCycle(); err == nil && current > period {
+		if err = p.SetDutyCycle(duty); err != nil {
+			return err
+		}
+		return p.SetPeriod(period)
+	}
+	if err = p.SetPeriod(period); err != nil {
+		return
+	}
+	return p.SetDutyCycle(duty)
+}
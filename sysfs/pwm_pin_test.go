@@ -0,0 +1,87 @@
+package sysfs
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestPwmPin(t *testing.T) {
+	fs := NewMockFilesystem([]string{
+		"/sys/class/pwm/pwmchip0/export",
+		"/sys/class/pwm/pwmchip0/unexport",
+		"/sys/class/pwm/pwmchip0/pwm1/enable",
+		"/sys/class/pwm/pwmchip0/pwm1/period",
+		"/sys/class/pwm/pwmchip0/pwm1/duty_cycle",
+		"/sys/class/pwm/pwmchip0/pwm1/polarity",
+	})
+	SetFilesystem(fs)
+
+	p := NewPwmPin(1)
+	gobottest.Assert(t, p.Export(), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/export"].Contents, "1")
+
+	gobottest.Assert(t, p.Enable(true), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm1/enable"].Contents, "1")
+	gobottest.Assert(t, p.Polarity(INVERSED), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm1/polarity"].Contents, "inversed")
+
+	gobottest.Assert(t, p.SetPeriod(20000000), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm1/period"].Contents, "20000000")
+	fs.Files["/sys/class/pwm/pwmchip0/pwm1/period"].Contents = "20000000\n"
+	period, err := p.Period()
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, period, 20000000)
+
+	gobottest.Assert(t, p.SetDutyCycle(1500000), nil)
+	duty, _ := p.DutyCycle()
+	gobottest.Assert(t, duty, 1500000)
+
+	gobottest.Assert(t, p.Enable(false), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm1/enable"].Contents, "0")
+	gobottest.Assert(t, p.Unexport(), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/unexport"].Contents, "1")
+}
+
+func TestPwmPinExportTimeout(t *testing.T) {
+	fs := NewMockFilesystem([]string{
+		"/sys/class/pwm/pwmchip2/export",
+	})
+	SetFilesystem(fs)
+	defer func(r int) { pwmExportRetries = r }(pwmExportRetries)
+	pwmExportRetries = 2
+
+	p := NewPwmPin(0, "/sys/class/pwm/pwmchip2")
+	gobottest.Refute(t, p.Export(), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip2/export"].Contents, "0")
+
+	gobottest.Refute(t, NewPwmPin(0, "/sys/class/pwm/pwmchip3").Export(), nil)
+}
+
+// orderedPwmPin records the order of period and duty cycle writes
+type orderedPwmPin struct {
+	PwmPin
+	duty   int
+	writes []string
+}
+
+func (p *orderedPwmPin) DutyCycle() (int, error) { return p.duty, nil }
+func (p *orderedPwmPin) SetPeriod(int) error {
+	p.writes = append(p.writes, "period")
+	return nil
+}
+func (p *orderedPwmPin) SetDutyCycle(d int) error {
+	p.duty = d
+	p.writes = append(p.writes, "duty_cycle")
+	return nil
+}
+
+func TestSetPwm(t *testing.T) {
+	p := &orderedPwmPin{duty: 1000}
+	gobottest.Assert(t, SetPwm(p, 20000, 500), nil)
+	gobottest.Assert(t, p.writes, []string{"period", "duty_cycle"})
+
+	p = &orderedPwmPin{duty: 15000}
+	gobottest.Assert(t, SetPwm(p, 10000, 500), nil)
+	gobottest.Assert(t, p.writes, []string{"duty_cycle", "period"})
+}
@@ -0,0 +1,151 @@
+package sysfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// Linux spidev specific docs.
+//  https://www.kernel.org/doc/Documentation/spi/spidev
+//  include/uapi/linux/spi/spidev.h
+const (
+	SPI_IOC_MESSAGE_1        = 0x40206B00
+	SPI_IOC_WR_MODE          = 0x40016B01
+	SPI_IOC_RD_MODE          = 0x80016B01
+	SPI_IOC_WR_BITS_PER_WORD = 0x40016B03
+	SPI_IOC_RD_BITS_PER_WORD = 0x80016B03
+	SPI_IOC_WR_MAX_SPEED_HZ  = 0x40046B04
+	SPI_IOC_RD_MAX_SPEED_HZ  = 0x80046B04
+)
+
+// ErrSpiBufferLength is returned when the receive buffer of a transfer is
+// not the same length as the transmit buffer
+var ErrSpiBufferLength = errors.New("SPI receive buffer must be the same length as the transmit buffer")
+
+// spiIocTransfer mirrors struct spi_ioc_transfer
+type spiIocTransfer struct {
+	txBuf       uint64
+	rxBuf       uint64
+	length      uint32
+	speedHz     uint32
+	delayUsecs  uint16
+	bitsPerWord uint8
+	csChange    uint8
+	txNbits     uint8
+	rxNbits     uint8
+	wordDelay   uint8
+	pad         uint8
+}
+
+// SpiDevice is the interface for sysfs spidev interactions
+type SpiDevice interface {
+	io.Closer
+	// SetMode sets the SPI mode (0-3)
+	SetMode(int) error
+	// SetBitsPerWord sets the word size of transfers
+	SetBitsPerWord(int) error
+	// SetMaxSpeed sets the maximum clock speed in Hz
+	SetMaxSpeed(int64) error
+	// Tx writes w to the device while reading the same number of bytes into
+	// r. r may be nil when the received bytes are not needed.
+	Tx(w []byte, r []byte) error
+}
+
+type spiDevice struct {
+	file  File
+	bits  int
+	speed int64
+}
+
+// NewSpiDevice returns a SpiDevice given a spidev location, such as
+// /dev/spidev0.0, and the mode, bits per word and maximum speed to use. The
+// device is closed again when it can not be set up.
+func NewSpiDevice(location string, mode int, bits int, speed int64) (*spiDevice, error) {
+	file, err := OpenFile(location, os.O_RDWR, os.ModeExclusive)
+	if err != nil {
+		return nil, err
+	}
+	d := &spiDevice{file: file}
+	if err = d.setup(mode, bits, speed); err != nil {
+		d.file.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *spiDevice) setup(mode int, bits int, speed int64) (err error) {
+	if err = d.SetMode(mode); err != nil {
+		return
+	}
+	if err = d.SetBitsPerWord(bits); err != nil {
+		return
+	}
+	return d.SetMaxSpeed(speed)
+}
+
+func (d *spiDevice) ioctl(request uintptr, data unsafe.Pointer) (err error) {
+	_, _, errno := Syscall(
+		syscall.SYS_IOCTL,
+		d.file.Fd(),
+		request,
+		uintptr(data),
+	)
+	if errno != 0 {
+		err = fmt.Errorf("SPI ioctl 0x%X failed with syscall.Errno %v", request, errno)
+	}
+	return
+}
+
+func (d *spiDevice) SetMode(mode int) (err error) {
+	m := uint8(mode)
+	return d.ioctl(SPI_IOC_WR_MODE, unsafe.Pointer(&m))
+}
+
+func (d *spiDevice) SetBitsPerWord(bits int) (err error) {
+	b := uint8(bits)
+	if err = d.ioctl(SPI_IOC_WR_BITS_PER_WORD, unsafe.Pointer(&b)); err == nil {
+		d.bits = bits
+	}
+	return
+}
+
+func (d *spiDevice) SetMaxSpeed(speed int64) (err error) {
+	s := uint32(speed)
+	if err = d.ioctl(SPI_IOC_WR_MAX_SPEED_HZ, unsafe.Pointer(&s)); err == nil {
+		d.speed = speed
+	}
+	return
+}
+
+func (d *spiDevice) Tx(w []byte, r []byte) (err error) {
+	if r != nil && len(r) != len(w) {
+		return ErrSpiBufferLength
+	}
+	if len(w) == 0 {
+		return nil
+	}
+
+	transfer := &spiIocTransfer{
+		txBuf:       uint64(uintptr(unsafe.Pointer(&w[0]))),
+		length:      uint32(len(w)),
+		speedHz:     uint32(d.speed),
+		bitsPerWord: uint8(d.bits),
+	}
+	if r != nil {
+		transfer.rxBuf = uint64(uintptr(unsafe.Pointer(&r[0])))
+	}
+
+	err = d.ioctl(SPI_IOC_MESSAGE_1, unsafe.Pointer(transfer))
+	runtime.KeepAlive(w)
+	runtime.KeepAlive(r)
+	return
+}
+
+func (d *spiDevice) Close() (err error) {
+	return d.file.Close()
+}
@@ -0,0 +1,82 @@
+package sysfs
+
+import (
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// loopbackSyscall emulates a spidev with MOSI wired to MISO
+type loopbackSyscall struct {
+	requests []uintptr
+	errno    syscall.Errno
+}
+
+func (l *loopbackSyscall) Syscall(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+	l.requests = append(l.requests, a2)
+	if a2 == SPI_IOC_MESSAGE_1 {
+		t := *(**spiIocTransfer)(unsafe.Pointer(&a3))
+		if t.rxBuf != 0 {
+			tx := *(*unsafe.Pointer)(unsafe.Pointer(&t.txBuf))
+			rx := *(*unsafe.Pointer)(unsafe.Pointer(&t.rxBuf))
+			copy((*[4096]byte)(rx)[:t.length], (*[4096]byte)(tx)[:t.length])
+		}
+	}
+	return 0, 0, l.errno
+}
+
+func TestNewSpiDevice(t *testing.T) {
+	fs := NewMockFilesystem([]string{})
+	SetFilesystem(fs)
+
+	_, err := NewSpiDevice("/dev/spidev0.0", 0, 8, 1000000)
+	gobottest.Refute(t, err, nil)
+
+	fs = NewMockFilesystem([]string{
+		"/dev/spidev0.0",
+	})
+	SetFilesystem(fs)
+
+	// the device is closed when it can not be set up
+	SetSyscall(&loopbackSyscall{errno: syscall.EINVAL})
+	d, err := NewSpiDevice("/dev/spidev0.0", 0, 8, 1000000)
+	gobottest.Refute(t, err, nil)
+	gobottest.Assert(t, d == nil, true)
+	gobottest.Assert(t, fs.Files["/dev/spidev0.0"].Closed, true)
+
+	sys := &loopbackSyscall{}
+	SetSyscall(sys)
+	d, err = NewSpiDevice("/dev/spidev0.0", 3, 8, 1000000)
+	var _ SpiDevice = d
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, sys.requests, []uintptr{
+		SPI_IOC_WR_MODE,
+		SPI_IOC_WR_BITS_PER_WORD,
+		SPI_IOC_WR_MAX_SPEED_HZ,
+	})
+	gobottest.Assert(t, d.bits, 8)
+	gobottest.Assert(t, d.speed, int64(1000000))
+	gobottest.Assert(t, d.Close(), nil)
+}
+
+func TestSpiDeviceTx(t *testing.T) {
+	fs := NewMockFilesystem([]string{
+		"/dev/spidev0.0",
+	})
+	SetFilesystem(fs)
+	SetSyscall(&loopbackSyscall{})
+	d, _ := NewSpiDevice("/dev/spidev0.0", 0, 8, 1000000)
+
+	r := make([]byte, 3)
+	gobottest.Assert(t, d.Tx([]byte{0x01, 0x02, 0x03}, r), nil)
+	gobottest.Assert(t, r, []byte{0x01, 0x02, 0x03})
+
+	gobottest.Assert(t, d.Tx([]byte{0x01, 0x02}, nil), nil)
+	gobottest.Assert(t, d.Tx([]byte{}, nil), nil)
+	gobottest.Assert(t, d.Tx([]byte{0x01, 0x02}, r), ErrSpiBufferLength)
+
+	SetSyscall(&loopbackSyscall{errno: syscall.EIO})
+	gobottest.Refute(t, d.Tx([]byte{0x01}, nil), nil)
+}
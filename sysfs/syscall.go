@@ -12,8 +12,11 @@ type SystemCaller interface {
 // NativeSyscall represents the native Syscall
 type NativeSyscall struct{}
 
-// MockSyscall represents the mock Syscall
-type MockSyscall struct{}
+// MockSyscall represents the mock Syscall. Impl, when set, is called in
+// place of the syscall.
+type MockSyscall struct {
+	Impl func(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno)
+}
 
 var sys SystemCaller = &NativeSyscall{}
 
@@ -34,5 +37,8 @@ func (sys *NativeSyscall) Syscall(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err
 
 // Syscall implements the SystemCaller interface
 func (sys *MockSyscall) Syscall(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+	if sys.Impl != nil {
+		return sys.Impl(trap, a1, a2, a3)
+	}
 	return 0, 0, 0
 }